@@ -252,6 +252,17 @@ export default function ChatInterface() {
               setStreamingError(
                 parsedData.message || 'An error occurred while streaming response'
               );
+            } else if (parsedData.type === 'title_updated') {
+              // Sessions started without a title are named after the first reply
+              if (parsedData.session_id && parsedData.title) {
+                setSessions(prev =>
+                  prev.map(session =>
+                    session.id === parsedData.session_id
+                      ? { ...session, title: parsedData.title }
+                      : session
+                  )
+                );
+              }
             } else if (parsedData.type === 'user_message') {
               if (parsedData.message) {
                 setMessages(prev =>
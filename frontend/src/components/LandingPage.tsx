@@ -1,5 +1,5 @@
 import { useState, useEffect } from 'react';
-import { useNavigate, Link } from 'react-router-dom';
+import { useNavigate, useSearchParams, Link } from 'react-router-dom';
 import { useAuth } from '../hooks/useApi';
 import { apiClient } from '../services/api';
 import { ErrorDisplay, LoadingSpinner } from './ErrorBoundary';
@@ -7,9 +7,27 @@ import LegalFooter from './LegalFooter';
 import ConnectWithStrava from '../assets/strava/connect-with-strava.svg';
 import StravaAttribution from './StravaAttribution';
 
+// Messages for the auth_error reasons the OAuth callback redirects back with
+function authErrorMessage(reason: string): string {
+  switch (reason) {
+    case 'insufficient_scope':
+      return 'Bodda needs permission to read your private activities. Please reconnect and keep "View data about your private activities" checked.';
+    case 'access_denied':
+      return 'Strava access was not granted. Connect again whenever you are ready.';
+    case 'invalid_state':
+      return 'Your sign-in link expired or was opened in a different browser. Please connect again.';
+    default:
+      return 'Failed to connect to Strava. Please try again.';
+  }
+}
+
 export default function LandingPage() {
+  const [searchParams] = useSearchParams();
+  const authErrorReason = searchParams.get('auth_error');
   const [isConnecting, setIsConnecting] = useState(false);
-  const [connectError, setConnectError] = useState<string | null>(null);
+  const [connectError, setConnectError] = useState<string | null>(
+    authErrorReason ? authErrorMessage(authErrorReason) : null
+  );
   const [consentAccepted, setConsentAccepted] = useState(false);
   const navigate = useNavigate();
   const {
@@ -44,7 +62,7 @@ export default function LandingPage() {
 
     try {
       // Redirect to Strava OAuth using API client
-      apiClient.redirectToStravaAuth();
+      apiClient.redirectToStravaAuth(authErrorReason === 'insufficient_scope');
     } catch (err) {
       setConnectError('Failed to connect to Strava. Please try again.');
       setIsConnecting(false);
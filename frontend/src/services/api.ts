@@ -9,19 +9,72 @@ export interface User {
 export interface Session {
   id: string
   user_id: string
+  athlete_id?: string
   title: string
+  title_customized?: boolean
+  pinned?: boolean
+  archived_at?: string
+  forked_from_session_id?: string
+  active_message_id?: string
   created_at: string
   updated_at: string
 }
 
+// Fields to change on a session; omitted fields are left as they are
+export interface SessionUpdate {
+  title?: string
+  pinned?: boolean
+  archived?: boolean
+}
+
+// A session whose messages match a search, with its best matching message
+export interface SessionSearchResult {
+  session: Session
+  message_id: string
+  message_role: 'user' | 'assistant'
+  message_created_at: string
+  snippet: string // HTML-escaped, matches wrapped in <mark>
+  match_count: number
+}
+
 export interface Message {
   id: string
   session_id: string
+  parent_id?: string
+  // Set when the message has edited or regenerated alternatives, in creation order
+  sibling_ids?: string[]
   role: 'user' | 'assistant'
   content: string
   created_at: string
 }
 
+export type FeedbackRating = 'up' | 'down'
+
+export type FeedbackReason =
+  | 'accurate'
+  | 'actionable'
+  | 'personalized'
+  | 'clear'
+  | 'inaccurate'
+  | 'wrong_data'
+  | 'not_personalized'
+  | 'too_long'
+  | 'too_vague'
+  | 'unsafe_advice'
+  | 'other'
+
+// A user's rating of an assistant reply
+export interface MessageFeedback {
+  id: string
+  message_id: string
+  session_id: string
+  rating: FeedbackRating
+  reasons: FeedbackReason[]
+  comment: string
+  created_at: string
+  updated_at: string
+}
+
 export interface AuthResponse {
   authenticated: boolean
   user: User
@@ -31,6 +84,140 @@ export interface SessionsResponse {
   sessions: Session[]
 }
 
+// A device the user is signed in on
+export interface LoginSession {
+  id: string
+  device_name: string
+  ip_address: string
+  started_at: string
+  last_used_at: string
+  expires_at: string
+  current: boolean
+}
+
+export interface LoginSessionsResponse {
+  sessions: LoginSession[]
+}
+
+// A personal access token for scripts; the secret is only returned on creation
+export interface ApiToken {
+  id: string
+  name: string
+  token_prefix: string
+  scopes: string[]
+  created_at: string
+  expires_at: string
+  last_used_at?: string
+  last_used_ip?: string
+}
+
+export interface ApiTokensResponse {
+  tokens: ApiToken[]
+  available_scopes: string[]
+}
+
+export interface CreateApiTokenResponse {
+  token: string
+  api_token: ApiToken
+}
+
+// A coach–athlete relationship; the coach is unset until the invitation is accepted
+export interface CoachingLink {
+  id: string
+  athlete_id: string
+  coach_id?: string
+  status: 'pending' | 'active' | 'ended'
+  permissions: string[]
+  invite_expires_at?: string
+  created_at: string
+  accepted_at?: string
+  ended_at?: string
+  athlete_name?: string
+  coach_name?: string
+}
+
+export interface CoachesResponse {
+  coaches: CoachingLink[]
+  available_permissions: string[]
+}
+
+export interface CoachingInvitation {
+  code: string
+  link: CoachingLink
+}
+
+export interface CoachComment {
+  id: string
+  coach_id: string
+  athlete_id: string
+  session_id?: string
+  body: string
+  created_at: string
+  coach_name?: string
+}
+
+export interface TeamMember {
+  team_id: string
+  user_id: string
+  role: 'owner' | 'coach' | 'athlete'
+  joined_at: string
+  name: string
+}
+
+// A club or squad; role is the current user's role in it
+export interface Team {
+  id: string
+  name: string
+  owner_id: string
+  created_at: string
+  updated_at: string
+  role?: 'owner' | 'coach' | 'athlete'
+  member_count: number
+}
+
+export interface TeamJoinCode {
+  code: string
+  team: Team
+}
+
+export interface WeeklyLoad {
+  start: string
+  load: number
+  sessions: number
+  hours: number
+  run_km: number
+  longest_run_km: number
+  monotony?: number
+  strain?: number
+}
+
+export interface TeamAthleteStatus {
+  user_id: string
+  name: string
+  weeks?: WeeklyLoad[]
+  sessions_this_week: number
+  usual_sessions: number
+  last_activity?: string
+  days_since_last_activity?: number
+  missing_sessions: boolean
+  missing_reason?: string
+  risk_level?: 'low' | 'moderate' | 'high'
+  acwr?: number
+  flags?: { kind: string; severity: string; message: string }[]
+  error?: string
+}
+
+export interface TeamDashboard {
+  team: Team
+  date: string
+  generated_at: string
+  athletes: TeamAthleteStatus[]
+  total_hours_this_week: number
+  total_run_km_this_week: number
+  at_risk_count: number
+  missing_count: number
+}
+
 export interface MessagesResponse {
   messages: Message[]
 }
@@ -192,14 +379,218 @@ class ApiClient {
     await this.handleResponse(response)
   }
 
+  async getLoginSessions(): Promise<LoginSession[]> {
+    const response = await this.fetchWithRetry('/api/auth/sessions')
+    const data = await this.handleResponse<LoginSessionsResponse>(response)
+    return data?.sessions || []
+  }
+
+  async revokeLoginSession(sessionId: string): Promise<void> {
+    const response = await this.fetchWithRetry(`/api/auth/sessions/${sessionId}`, {
+      method: 'DELETE',
+    })
+    await this.handleResponse(response)
+  }
+
+  // Signs out on every device, including this one
+  async logoutEverywhere(): Promise<void> {
+    const response = await this.fetchWithRetry('/api/auth/logout-all', {
+      method: 'POST',
+    })
+    await this.handleResponse(response)
+  }
+
+  async getApiTokens(): Promise<ApiTokensResponse> {
+    const response = await this.fetchWithRetry('/api/auth/tokens')
+    const data = await this.handleResponse<ApiTokensResponse>(response)
+    return {
+      tokens: data?.tokens || [],
+      available_scopes: data?.available_scopes || [],
+    }
+  }
+
+  async createApiToken(name: string, scopes: string[], expiresInDays?: number): Promise<CreateApiTokenResponse> {
+    const response = await this.fetchWithRetry('/api/auth/tokens', {
+      method: 'POST',
+      body: JSON.stringify({ name, scopes, expires_in_days: expiresInDays }),
+    })
+    return this.handleResponse<CreateApiTokenResponse>(response)
+  }
+
+  async revokeApiToken(tokenId: string): Promise<void> {
+    const response = await this.fetchWithRetry(`/api/auth/tokens/${tokenId}`, {
+      method: 'DELETE',
+    })
+    await this.handleResponse(response)
+  }
+
+  // Coaching methods
+  async getCoaches(): Promise<CoachesResponse> {
+    const response = await this.fetchWithRetry('/api/coaching/coaches')
+    const data = await this.handleResponse<CoachesResponse>(response)
+    return {
+      coaches: data?.coaches || [],
+      available_permissions: data?.available_permissions || [],
+    }
+  }
+
+  async createCoachingInvitation(permissions: string[]): Promise<CoachingInvitation> {
+    const response = await this.fetchWithRetry('/api/coaching/invitations', {
+      method: 'POST',
+      body: JSON.stringify({ permissions }),
+    })
+    return this.handleResponse<CoachingInvitation>(response)
+  }
+
+  async acceptCoachingInvitation(code: string): Promise<CoachingLink> {
+    const response = await this.fetchWithRetry('/api/coaching/invitations/accept', {
+      method: 'POST',
+      body: JSON.stringify({ code }),
+    })
+    const data = await this.handleResponse<{ link: CoachingLink }>(response)
+    return data.link
+  }
+
+  async updateCoachingPermissions(linkId: string, permissions: string[]): Promise<void> {
+    const response = await this.fetchWithRetry(`/api/coaching/links/${linkId}/permissions`, {
+      method: 'PUT',
+      body: JSON.stringify({ permissions }),
+    })
+    await this.handleResponse(response)
+  }
+
+  async endCoachingLink(linkId: string): Promise<void> {
+    const response = await this.fetchWithRetry(`/api/coaching/links/${linkId}`, {
+      method: 'DELETE',
+    })
+    await this.handleResponse(response)
+  }
+
+  async getCoachedAthletes(): Promise<CoachingLink[]> {
+    const response = await this.fetchWithRetry('/api/coaching/athletes')
+    const data = await this.handleResponse<{ athletes: CoachingLink[] }>(response)
+    return data?.athletes || []
+  }
+
+  async createCoachSession(athleteId: string, title?: string): Promise<Session> {
+    const response = await this.fetchWithRetry(`/api/coaching/athletes/${athleteId}/sessions`, {
+      method: 'POST',
+      body: JSON.stringify({ title: title || '' }),
+    })
+    const data = await this.handleResponse<CreateSessionResponse>(response)
+    return data.session
+  }
+
+  async getAthleteSessions(athleteId: string): Promise<Session[]> {
+    const response = await this.fetchWithRetry(`/api/coaching/athletes/${athleteId}/sessions`)
+    const data = await this.handleResponse<SessionsResponse>(response)
+    return data?.sessions || []
+  }
+
+  async getCoachComments(athleteId?: string): Promise<CoachComment[]> {
+    const url = athleteId ? `/api/coaching/athletes/${athleteId}/comments` : '/api/coaching/comments'
+    const response = await this.fetchWithRetry(url)
+    const data = await this.handleResponse<{ comments: CoachComment[] }>(response)
+    return data?.comments || []
+  }
+
+  async addCoachComment(athleteId: string, body: string, sessionId?: string): Promise<CoachComment> {
+    const response = await this.fetchWithRetry(`/api/coaching/athletes/${athleteId}/comments`, {
+      method: 'POST',
+      body: JSON.stringify({ body, session_id: sessionId }),
+    })
+    const data = await this.handleResponse<{ comment: CoachComment }>(response)
+    return data.comment
+  }
+
+  // Team methods
+  async getTeams(): Promise<Team[]> {
+    const response = await this.fetchWithRetry('/api/teams')
+    const data = await this.handleResponse<{ teams: Team[] }>(response)
+    return data?.teams || []
+  }
+
+  async createTeam(name: string): Promise<TeamJoinCode> {
+    const response = await this.fetchWithRetry('/api/teams', {
+      method: 'POST',
+      body: JSON.stringify({ name }),
+    })
+    return this.handleResponse<TeamJoinCode>(response)
+  }
+
+  async joinTeam(code: string): Promise<Team> {
+    const response = await this.fetchWithRetry('/api/teams/join', {
+      method: 'POST',
+      body: JSON.stringify({ code }),
+    })
+    const data = await this.handleResponse<{ team: Team }>(response)
+    return data.team
+  }
+
+  async getTeam(teamId: string): Promise<{ team: Team; members: TeamMember[] }> {
+    const response = await this.fetchWithRetry(`/api/teams/${teamId}`)
+    const data = await this.handleResponse<{ team: Team; members: TeamMember[] }>(response)
+    return { team: data.team, members: data?.members || [] }
+  }
+
+  async renameTeam(teamId: string, name: string): Promise<void> {
+    const response = await this.fetchWithRetry(`/api/teams/${teamId}`, {
+      method: 'PATCH',
+      body: JSON.stringify({ name }),
+    })
+    await this.handleResponse(response)
+  }
+
+  async deleteTeam(teamId: string): Promise<void> {
+    const response = await this.fetchWithRetry(`/api/teams/${teamId}`, {
+      method: 'DELETE',
+    })
+    await this.handleResponse(response)
+  }
+
+  async rotateTeamJoinCode(teamId: string): Promise<TeamJoinCode> {
+    const response = await this.fetchWithRetry(`/api/teams/${teamId}/join-code`, {
+      method: 'POST',
+    })
+    return this.handleResponse<TeamJoinCode>(response)
+  }
+
+  async disableTeamJoinCode(teamId: string): Promise<void> {
+    const response = await this.fetchWithRetry(`/api/teams/${teamId}/join-code`, {
+      method: 'DELETE',
+    })
+    await this.handleResponse(response)
+  }
+
+  async updateTeamMemberRole(teamId: string, userId: string, role: 'coach' | 'athlete'): Promise<void> {
+    const response = await this.fetchWithRetry(`/api/teams/${teamId}/members/${userId}`, {
+      method: 'PUT',
+      body: JSON.stringify({ role }),
+    })
+    await this.handleResponse(response)
+  }
+
+  async removeTeamMember(teamId: string, userId: string): Promise<void> {
+    const response = await this.fetchWithRetry(`/api/teams/${teamId}/members/${userId}`, {
+      method: 'DELETE',
+    })
+    await this.handleResponse(response)
+  }
+
+  async getTeamDashboard(teamId: string): Promise<TeamDashboard> {
+    const response = await this.fetchWithRetry(`/api/teams/${teamId}/dashboard`)
+    const data = await this.handleResponse<{ dashboard: TeamDashboard }>(response)
+    return data.dashboard
+  }
+
   // OAuth redirect method
-  redirectToStravaAuth(): void {
-    window.location.href = '/auth/strava'
+  redirectToStravaAuth(reconsent = false): void {
+    window.location.href = reconsent ? '/auth/strava?reconsent=1' : '/auth/strava'
   }
 
   // Session management methods
-  async getSessions(): Promise<Session[]> {
-    const response = await this.fetchWithRetry('/api/sessions')
+  async getSessions(archived = false): Promise<Session[]> {
+    const response = await this.fetchWithRetry(archived ? '/api/sessions?archived=true' : '/api/sessions')
     const data = await this.handleResponse<SessionsResponse>(response)
     return data?.sessions || []
   }
@@ -218,6 +609,43 @@ class ApiClient {
     return data.session
   }
 
+  async updateSession(sessionId: string, update: SessionUpdate): Promise<Session> {
+    const response = await this.fetchWithRetry(`/api/sessions/${sessionId}`, {
+      method: 'PATCH',
+      body: JSON.stringify(update),
+    })
+    const data = await this.handleResponse<CreateSessionResponse>(response)
+
+    if (!data?.session) {
+      throw new ApiError('Invalid session response from server', response.status)
+    }
+
+    return data.session
+  }
+
+  async searchSessions(query: string, limit?: number): Promise<SessionSearchResult[]> {
+    const params = new URLSearchParams({ q: query })
+    if (limit !== undefined) params.append('limit', limit.toString())
+
+    const response = await this.fetchWithRetry(`/api/sessions/search?${params.toString()}`)
+    const data = await this.handleResponse<{ results: SessionSearchResult[] }>(response)
+    return data?.results || []
+  }
+
+  async forkSession(sessionId: string, messageId: string, title?: string): Promise<Session> {
+    const response = await this.fetchWithRetry(`/api/sessions/${sessionId}/fork`, {
+      method: 'POST',
+      body: JSON.stringify({ message_id: messageId, title: title || '' }),
+    })
+    const data = await this.handleResponse<CreateSessionResponse>(response)
+
+    if (!data?.session) {
+      throw new ApiError('Invalid session response from server', response.status)
+    }
+
+    return data.session
+  }
+
   async deleteSession(sessionId: string): Promise<void> {
     const response = await this.fetchWithRetry(`/api/sessions/${sessionId}`, {
       method: 'DELETE',
@@ -246,6 +674,67 @@ class ApiClient {
     return this.handleResponse<SendMessageResponse>(response)
   }
 
+  // Replaces a user message with an edited copy on a new branch and returns it with the new reply
+  async editMessage(sessionId: string, messageId: string, content: string): Promise<SendMessageResponse> {
+    const response = await this.fetchWithRetry(`/api/sessions/${sessionId}/messages/${messageId}/edit`, {
+      method: 'POST',
+      body: JSON.stringify({ content }),
+    })
+    return this.handleResponse<SendMessageResponse>(response)
+  }
+
+  // Asks for a new reply in place of an assistant message, which stays available as a sibling
+  async regenerateMessage(sessionId: string, messageId: string): Promise<Message> {
+    const response = await this.fetchWithRetry(`/api/sessions/${sessionId}/messages/${messageId}/regenerate`, {
+      method: 'POST',
+    })
+    const data = await this.handleResponse<{ assistant_message: Message }>(response)
+
+    if (!data?.assistant_message) {
+      throw new ApiError('Invalid message response from server', response.status)
+    }
+
+    return data.assistant_message
+  }
+
+  // Switches to the branch through a message and returns the messages now shown
+  async selectBranch(sessionId: string, messageId: string): Promise<Message[]> {
+    const response = await this.fetchWithRetry(`/api/sessions/${sessionId}/active-branch`, {
+      method: 'PUT',
+      body: JSON.stringify({ message_id: messageId }),
+    })
+    const data = await this.handleResponse<MessagesResponse>(response)
+    return data?.messages || []
+  }
+
+  // Rates an assistant reply, replacing any earlier rating by the user
+  async submitFeedback(
+    sessionId: string,
+    messageId: string,
+    rating: FeedbackRating,
+    reasons: FeedbackReason[] = [],
+    comment = ''
+  ): Promise<MessageFeedback> {
+    const response = await this.fetchWithRetry(`/api/sessions/${sessionId}/messages/${messageId}/feedback`, {
+      method: 'PUT',
+      body: JSON.stringify({ rating, reasons, comment }),
+    })
+    const data = await this.handleResponse<{ feedback: MessageFeedback }>(response)
+
+    if (!data?.feedback) {
+      throw new ApiError('Invalid feedback response from server', response.status)
+    }
+
+    return data.feedback
+  }
+
+  async deleteFeedback(sessionId: string, messageId: string): Promise<void> {
+    const response = await this.fetchWithRetry(`/api/sessions/${sessionId}/messages/${messageId}/feedback`, {
+      method: 'DELETE',
+    })
+    await this.handleResponse(response)
+  }
+
   // Streaming methods
   createEventSource(sessionId: string, message: string): EventSource {
     const params = new URLSearchParams({ message })
@@ -13,6 +13,7 @@ require (
 	github.com/openai/openai-go/v2 v2.3.0
 	github.com/stretchr/testify v1.10.0
 	golang.org/x/oauth2 v0.30.0
+	golang.org/x/sync v0.10.0
 )
 
 require (
@@ -48,7 +49,6 @@ require (
 	golang.org/x/arch v0.3.0 // indirect
 	golang.org/x/crypto v0.32.0 // indirect
 	golang.org/x/net v0.34.0 // indirect
-	golang.org/x/sync v0.10.0 // indirect
 	golang.org/x/sys v0.29.0 // indirect
 	golang.org/x/text v0.21.0 // indirect
 	google.golang.org/protobuf v1.30.0 // indirect
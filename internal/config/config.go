@@ -25,6 +25,9 @@ type Config struct {
 	// Development mode
 	IsDevelopment bool
 	
+	// Strava athlete IDs allowed to access admin endpoints
+	AdminStravaIDs []string
+	
 	// Stream Processing
 	StreamProcessing StreamProcessingConfig
 	
@@ -33,6 +36,24 @@ type Config struct {
 	
 	// Tool Execution Settings
 	ToolExecution ToolExecutionConfig
+	
+	// Conversation compaction for long sessions
+	ConversationCompaction ConversationCompactionConfig
+	
+	// Token counting
+	Tokenizer TokenizerConfig
+	
+	// LLM token usage metering and quotas
+	Usage UsageConfig
+	
+	// Strava API rate limit budget
+	StravaRateLimit StravaRateLimitConfig
+	
+	// Encryption of Strava tokens at rest
+	TokenEncryption TokenEncryptionConfig
+	
+	// Login session lifetimes
+	Auth AuthConfig
 }
 
 // StreamProcessingConfig holds configuration for stream data processing
@@ -92,6 +113,8 @@ type ToolExecutionConfig struct {
 	// Caching
 	EnableCaching         bool
 	CacheTTL             int  // seconds
+	CacheMaxEntries      int  // in-memory LRU size
+	CachePersistent      bool // back the in-memory cache with Postgres
 	
 	// Logging
 	EnableDetailedLogging bool
@@ -100,6 +123,54 @@ type ToolExecutionConfig struct {
 	PerformanceThresholds PerformanceThresholds
 }
 
+// ConversationCompactionConfig holds configuration for rolling conversation summarization
+type ConversationCompactionConfig struct {
+	Enabled            bool
+	MaxContextTokens   int     // Token budget for conversation context (summary + history + message)
+	TriggerRatio       float64 // Fraction of MaxContextTokens at which compaction starts
+	KeepRecentMessages int     // Number of most recent messages kept verbatim after compaction
+}
+
+// TokenizerConfig holds configuration for token counting
+type TokenizerConfig struct {
+	Encoding      string // BPE encoding ("o200k_base", "cl100k_base") or "heuristic"
+	VocabDir      string // Optional directory with .tiktoken files used when none are embedded
+	MaxExactChars int    // Texts longer than this are counted on a prefix and extrapolated
+}
+
+// UsageConfig holds configuration for per-user LLM token quotas
+type UsageConfig struct {
+	DailyTokenQuota   int64 // Max input+output tokens per user per UTC day (0 = unlimited)
+	MonthlyTokenQuota int64 // Max input+output tokens per user per UTC calendar month (0 = unlimited)
+}
+
+// StravaRateLimitConfig holds the Strava API budget shared by all users.
+// Limits are replaced by the values Strava reports in response headers.
+type StravaRateLimitConfig struct {
+	ShortTermLimit           int // Overall requests per 15 minutes
+	DailyLimit               int // Overall requests per UTC day
+	ReadShortTermLimit       int // Read requests per 15 minutes
+	ReadDailyLimit           int // Read requests per UTC day
+	BackgroundReservePercent int // Share of each budget kept for interactive requests
+	MaxQueueWait             int // seconds a request may wait for budget before failing
+}
+
+// TokenEncryptionConfig holds the key-encryption keys for Strava tokens stored in the database.
+// Keys are listed as comma-separated keyID:base64key pairs of 32 random bytes; older keys stay
+// listed after a rotation so existing rows can still be decrypted.
+type TokenEncryptionConfig struct {
+	Keys        string // e.g. "2025-06:BASE64KEY,2024-01:BASE64KEY"
+	ActiveKeyID string // Key used for new values; defaults to the first listed key
+}
+
+// AuthConfig holds the lifetimes of login credentials. Short-lived access JWTs are renewed
+// with refresh tokens that rotate on every use.
+type AuthConfig struct {
+	AccessTokenTTL    int // seconds an access JWT is valid
+	RefreshTokenTTL   int // seconds a refresh token stays valid without being used
+	RefreshReuseGrace int // seconds a rotated refresh token is still accepted, for concurrent requests
+}
+
 // PerformanceThresholds holds performance monitoring thresholds
 type PerformanceThresholds struct {
 	MaxExecutionTimeMs int     // milliseconds
@@ -124,6 +195,8 @@ func Load() *Config {
 		
 		IsDevelopment: getEnvBool("DEVELOPMENT_MODE", true),
 		
+		AdminStravaIDs: getEnvStringSlice("ADMIN_STRAVA_IDS", []string{}),
+		
 		StreamProcessing: StreamProcessingConfig{
 			MaxContextTokens:      getEnvInt("STREAM_MAX_CONTEXT_TOKENS", 15000),
 			TokenPerCharRatio:     getEnvFloat("STREAM_TOKEN_PER_CHAR_RATIO", 0.25),
@@ -178,6 +251,8 @@ func Load() *Config {
 			// Caching
 			EnableCaching:        getEnvBool("TOOL_EXECUTION_ENABLE_CACHING", false),
 			CacheTTL:            getEnvInt("TOOL_EXECUTION_CACHE_TTL", 300),
+			CacheMaxEntries:     getEnvInt("TOOL_EXECUTION_CACHE_MAX_ENTRIES", 1000),
+			CachePersistent:     getEnvBool("TOOL_EXECUTION_CACHE_PERSISTENT", false),
 			
 			// Logging
 			EnableDetailedLogging: getEnvBool("TOOL_EXECUTION_ENABLE_DETAILED_LOGGING", true),
@@ -190,12 +265,55 @@ func Load() *Config {
 				MaxQueueDepth:      getEnvInt("TOOL_EXECUTION_MAX_QUEUE_DEPTH", 10),
 			},
 		},
+		
+		ConversationCompaction: ConversationCompactionConfig{
+			Enabled:            getEnvBool("CONVERSATION_COMPACTION_ENABLED", true),
+			MaxContextTokens:   getEnvInt("CONVERSATION_MAX_CONTEXT_TOKENS", getEnvInt("STREAM_MAX_CONTEXT_TOKENS", 15000)),
+			TriggerRatio:       getEnvFloat("CONVERSATION_COMPACTION_TRIGGER_RATIO", 0.8),
+			KeepRecentMessages: getEnvInt("CONVERSATION_KEEP_RECENT_MESSAGES", 6),
+		},
+		
+		Tokenizer: TokenizerConfig{
+			Encoding:      getEnv("TOKENIZER_ENCODING", "o200k_base"),
+			VocabDir:      getEnv("TOKENIZER_VOCAB_DIR", ""),
+			MaxExactChars: getEnvInt("TOKENIZER_MAX_EXACT_CHARS", 200000),
+		},
+		
+		Usage: UsageConfig{
+			DailyTokenQuota:   int64(getEnvInt("USAGE_DAILY_TOKEN_QUOTA", 0)),
+			MonthlyTokenQuota: int64(getEnvInt("USAGE_MONTHLY_TOKEN_QUOTA", 0)),
+		},
+		
+		StravaRateLimit: StravaRateLimitConfig{
+			ShortTermLimit:           getEnvInt("STRAVA_RATE_LIMIT_15MIN", 200),
+			DailyLimit:               getEnvInt("STRAVA_RATE_LIMIT_DAILY", 2000),
+			ReadShortTermLimit:       getEnvInt("STRAVA_READ_RATE_LIMIT_15MIN", 100),
+			ReadDailyLimit:           getEnvInt("STRAVA_READ_RATE_LIMIT_DAILY", 1000),
+			BackgroundReservePercent: getEnvInt("STRAVA_BACKGROUND_RESERVE_PERCENT", 20),
+			MaxQueueWait:             getEnvInt("STRAVA_RATE_LIMIT_MAX_QUEUE_WAIT", 60),
+		},
+		
+		TokenEncryption: TokenEncryptionConfig{
+			Keys:        getEnv("TOKEN_ENCRYPTION_KEYS", ""),
+			ActiveKeyID: getEnv("TOKEN_ENCRYPTION_ACTIVE_KEY_ID", ""),
+		},
+		
+		Auth: AuthConfig{
+			AccessTokenTTL:    getEnvInt("AUTH_ACCESS_TOKEN_TTL", 900),
+			RefreshTokenTTL:   getEnvInt("AUTH_REFRESH_TOKEN_TTL", 30*24*3600),
+			RefreshReuseGrace: getEnvInt("AUTH_REFRESH_REUSE_GRACE", 30),
+		},
 	}
 	
 	// Validate configuration
 	config.validateStreamProcessingConfig()
 	config.validateToolMonitoringConfig()
 	config.validateToolExecutionConfig()
+	config.validateConversationCompactionConfig()
+	config.validateTokenizerConfig()
+	config.validateUsageConfig()
+	config.validateStravaRateLimitConfig()
+	config.validateAuthConfig()
 	
 	return config
 }
@@ -379,6 +497,9 @@ func (c *Config) validateToolExecutionConfig() {
 	if te.CacheTTL <= 0 {
 		te.CacheTTL = 300 // 5 minutes
 	}
+	if te.CacheMaxEntries <= 0 {
+		te.CacheMaxEntries = 1000
+	}
 	
 	// Validate performance thresholds
 	pt := &te.PerformanceThresholds
@@ -400,6 +521,88 @@ func (c *Config) validateToolExecutionConfig() {
 	}
 }
 
+// validateConversationCompactionConfig ensures conversation compaction configuration is valid
+func (c *Config) validateConversationCompactionConfig() {
+	cc := &c.ConversationCompaction
+	
+	if cc.MaxContextTokens <= 0 {
+		cc.MaxContextTokens = 15000
+	}
+	
+	// Trigger before the limit is actually reached
+	if cc.TriggerRatio <= 0 || cc.TriggerRatio > 1 {
+		cc.TriggerRatio = 0.8
+	}
+	
+	// Keep at least one full exchange verbatim
+	if cc.KeepRecentMessages < 2 {
+		cc.KeepRecentMessages = 6
+	}
+}
+
+// validateTokenizerConfig ensures tokenizer configuration is valid
+func (c *Config) validateTokenizerConfig() {
+	tc := &c.Tokenizer
+	
+	switch tc.Encoding {
+	case "o200k_base", "cl100k_base", "heuristic":
+	default:
+		tc.Encoding = "o200k_base"
+	}
+	
+	if tc.MaxExactChars <= 0 {
+		tc.MaxExactChars = 200000
+	}
+}
+
+// validateUsageConfig ensures usage quota configuration is valid
+func (c *Config) validateUsageConfig() {
+	uc := &c.Usage
+	
+	// Negative quotas are treated as unlimited
+	if uc.DailyTokenQuota < 0 {
+		uc.DailyTokenQuota = 0
+	}
+	if uc.MonthlyTokenQuota < 0 {
+		uc.MonthlyTokenQuota = 0
+	}
+}
+
+// validateStravaRateLimitConfig ensures Strava rate limit configuration is valid
+func (c *Config) validateStravaRateLimitConfig() {
+	rc := &c.StravaRateLimit
+	
+	if rc.ShortTermLimit <= 0 {
+		rc.ShortTermLimit = 200
+	}
+	if rc.DailyLimit <= 0 {
+		rc.DailyLimit = 2000
+	}
+	if rc.ReadShortTermLimit <= 0 || rc.ReadShortTermLimit > rc.ShortTermLimit {
+		rc.ReadShortTermLimit = rc.ShortTermLimit
+	}
+	if rc.ReadDailyLimit <= 0 || rc.ReadDailyLimit > rc.DailyLimit {
+		rc.ReadDailyLimit = rc.DailyLimit
+	}
+	if rc.BackgroundReservePercent < 0 || rc.BackgroundReservePercent > 90 {
+		rc.BackgroundReservePercent = 20
+	}
+	if rc.MaxQueueWait < 0 {
+		rc.MaxQueueWait = 0
+	}
+}
+
+// IsAdmin returns true if the Strava athlete is configured as an administrator
+func (c *Config) IsAdmin(stravaID int64) bool {
+	id := strconv.FormatInt(stravaID, 10)
+	for _, adminID := range c.AdminStravaIDs {
+		if adminID == id {
+			return true
+		}
+	}
+	return false
+}
+
 // IsToolExecutionEnabled returns true if tool execution endpoint should be available
 func (c *Config) IsToolExecutionEnabled() bool {
 	return c.IsDevelopment
@@ -416,4 +619,26 @@ func (c *Config) GetToolExecutionTimeout(requestedTimeout int) int {
 	}
 	
 	return requestedTimeout
-}
\ No newline at end of file
+}
+
+// validateAuthConfig ensures login session lifetimes are valid
+func (c *Config) validateAuthConfig() {
+	ac := &c.Auth
+	
+	if ac.AccessTokenTTL <= 0 {
+		ac.AccessTokenTTL = 900
+	}
+	if ac.RefreshTokenTTL <= 0 {
+		ac.RefreshTokenTTL = 30 * 24 * 3600
+	}
+	// A refresh token must outlive the access tokens it renews
+	if ac.RefreshTokenTTL < ac.AccessTokenTTL {
+		ac.RefreshTokenTTL = ac.AccessTokenTTL
+	}
+	if ac.RefreshReuseGrace < 0 {
+		ac.RefreshReuseGrace = 0
+	}
+	if ac.RefreshReuseGrace > 300 {
+		ac.RefreshReuseGrace = 300
+	}
+}
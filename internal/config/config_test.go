@@ -436,4 +436,119 @@ func TestGetEnvHelpers(t *testing.T) {
 	if boolResult {
 		t.Errorf("Expected getEnvBool to return false, got %t", boolResult)
 	}
-}
\ No newline at end of file
+}
+func TestValidateTokenizerConfig(t *testing.T) {
+	config := &Config{
+		Tokenizer: TokenizerConfig{
+			Encoding:      "p50k_base",
+			MaxExactChars: -1,
+		},
+	}
+	
+	config.validateTokenizerConfig()
+	
+	if config.Tokenizer.Encoding != "o200k_base" {
+		t.Errorf("Expected unknown encoding to default to o200k_base, got %s", config.Tokenizer.Encoding)
+	}
+	
+	if config.Tokenizer.MaxExactChars != 200000 {
+		t.Errorf("Expected max exact chars to default to 200000, got %d", config.Tokenizer.MaxExactChars)
+	}
+	
+	config.Tokenizer.Encoding = "heuristic"
+	config.validateTokenizerConfig()
+	
+	if config.Tokenizer.Encoding != "heuristic" {
+		t.Errorf("Expected heuristic encoding to be kept, got %s", config.Tokenizer.Encoding)
+	}
+}
+
+func TestValidateUsageConfig(t *testing.T) {
+	config := &Config{
+		Usage: UsageConfig{
+			DailyTokenQuota:   -5,
+			MonthlyTokenQuota: 1000000,
+		},
+	}
+	
+	config.validateUsageConfig()
+	
+	if config.Usage.DailyTokenQuota != 0 {
+		t.Errorf("Expected negative daily quota to become unlimited (0), got %d", config.Usage.DailyTokenQuota)
+	}
+	
+	if config.Usage.MonthlyTokenQuota != 1000000 {
+		t.Errorf("Expected monthly quota to be kept, got %d", config.Usage.MonthlyTokenQuota)
+	}
+}
+
+func TestValidateStravaRateLimitConfig(t *testing.T) {
+	config := &Config{
+		StravaRateLimit: StravaRateLimitConfig{
+			ShortTermLimit:           0,
+			DailyLimit:               1000,
+			ReadShortTermLimit:       500,
+			ReadDailyLimit:           -1,
+			BackgroundReservePercent: 150,
+			MaxQueueWait:             -10,
+		},
+	}
+	
+	config.validateStravaRateLimitConfig()
+	
+	rc := config.StravaRateLimit
+	if rc.ShortTermLimit != 200 {
+		t.Errorf("Expected default 15-minute limit 200, got %d", rc.ShortTermLimit)
+	}
+	if rc.ReadShortTermLimit != 200 {
+		t.Errorf("Expected read limit capped at overall limit 200, got %d", rc.ReadShortTermLimit)
+	}
+	if rc.ReadDailyLimit != 1000 {
+		t.Errorf("Expected read daily limit to default to overall daily limit, got %d", rc.ReadDailyLimit)
+	}
+	if rc.BackgroundReservePercent != 20 {
+		t.Errorf("Expected default background reserve 20, got %d", rc.BackgroundReservePercent)
+	}
+	if rc.MaxQueueWait != 0 {
+		t.Errorf("Expected negative queue wait to become 0, got %d", rc.MaxQueueWait)
+	}
+}
+
+func TestValidateAuthConfig(t *testing.T) {
+	config := &Config{
+		Auth: AuthConfig{
+			AccessTokenTTL:    0,
+			RefreshTokenTTL:   60,
+			RefreshReuseGrace: -5,
+		},
+	}
+	
+	config.validateAuthConfig()
+	
+	ac := config.Auth
+	if ac.AccessTokenTTL != 900 {
+		t.Errorf("Expected default access token TTL 900, got %d", ac.AccessTokenTTL)
+	}
+	if ac.RefreshTokenTTL != 900 {
+		t.Errorf("Expected refresh token TTL raised to the access token TTL, got %d", ac.RefreshTokenTTL)
+	}
+	if ac.RefreshReuseGrace != 0 {
+		t.Errorf("Expected negative reuse grace to become 0, got %d", ac.RefreshReuseGrace)
+	}
+}
+
+func TestIsAdmin(t *testing.T) {
+	config := &Config{AdminStravaIDs: []string{"12345", "67890"}}
+	
+	if !config.IsAdmin(12345) {
+		t.Error("Expected 12345 to be an admin")
+	}
+	
+	if config.IsAdmin(11111) {
+		t.Error("Expected 11111 not to be an admin")
+	}
+	
+	if (&Config{}).IsAdmin(12345) {
+		t.Error("Expected no admins when none are configured")
+	}
+}
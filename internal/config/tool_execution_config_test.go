@@ -38,6 +38,14 @@ func TestToolExecutionConfigDefaults(t *testing.T) {
 		t.Errorf("Expected CacheTTL to be 300, got %d", config.ToolExecution.CacheTTL)
 	}
 	
+	if config.ToolExecution.CacheMaxEntries != 1000 {
+		t.Errorf("Expected CacheMaxEntries to be 1000, got %d", config.ToolExecution.CacheMaxEntries)
+	}
+	
+	if config.ToolExecution.CachePersistent != false {
+		t.Errorf("Expected CachePersistent to be false, got %v", config.ToolExecution.CachePersistent)
+	}
+	
 	// Test default logging
 	if config.ToolExecution.EnableDetailedLogging != true {
 		t.Errorf("Expected EnableDetailedLogging to be true, got %v", config.ToolExecution.EnableDetailedLogging)
@@ -167,6 +175,10 @@ func TestToolExecutionConfigValidation(t *testing.T) {
 		t.Errorf("Expected CacheTTL to be corrected to 300, got %d", config.ToolExecution.CacheTTL)
 	}
 	
+	if config.ToolExecution.CacheMaxEntries != 1000 {
+		t.Errorf("Expected CacheMaxEntries to be corrected to 1000, got %d", config.ToolExecution.CacheMaxEntries)
+	}
+	
 	// Test performance threshold validation
 	pt := config.ToolExecution.PerformanceThresholds
 	
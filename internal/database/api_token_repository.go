@@ -0,0 +1,148 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"bodda/internal/models"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// APITokenRepository stores users' personal API tokens
+type APITokenRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewAPITokenRepository(db *pgxpool.Pool) *APITokenRepository {
+	return &APITokenRepository{db: db}
+}
+
+// apiTokenColumns selects a token in the order expected by scanAPIToken
+const apiTokenColumns = `id, user_id, name, token_prefix, token_hash, scopes, created_at, expires_at,
+	last_used_at, last_used_ip, revoked_at`
+
+// Create stores a new token and sets its ID
+func (r *APITokenRepository) Create(ctx context.Context, token *models.APIToken) error {
+	query := `
+		INSERT INTO api_tokens (user_id, name, token_prefix, token_hash, scopes, created_at, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id`
+
+	err := r.db.QueryRow(ctx, query,
+		token.UserID,
+		token.Name,
+		token.TokenPrefix,
+		token.TokenHash,
+		token.Scopes,
+		token.CreatedAt,
+		token.ExpiresAt,
+	).Scan(&token.ID)
+	if err != nil {
+		return fmt.Errorf("failed to create API token: %w", err)
+	}
+
+	return nil
+}
+
+// GetByHash returns the token with the given hash, or nil when there is none
+func (r *APITokenRepository) GetByHash(ctx context.Context, tokenHash string) (*models.APIToken, error) {
+	query := `SELECT ` + apiTokenColumns + ` FROM api_tokens WHERE token_hash = $1`
+
+	token := &models.APIToken{}
+	if err := scanAPIToken(r.db.QueryRow(ctx, query, tokenHash), token); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get API token: %w", err)
+	}
+
+	return token, nil
+}
+
+// ListByUser returns the user's tokens that have not been revoked, newest first. Expired tokens are
+// included so the user can see why a script stopped working.
+func (r *APITokenRepository) ListByUser(ctx context.Context, userID string) ([]*models.APIToken, error) {
+	query := `
+		SELECT ` + apiTokenColumns + `
+		FROM api_tokens
+		WHERE user_id = $1 AND revoked_at IS NULL
+		ORDER BY created_at DESC`
+
+	rows, err := r.db.Query(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list API tokens: %w", err)
+	}
+	defer rows.Close()
+
+	var tokens []*models.APIToken
+	for rows.Next() {
+		token := &models.APIToken{}
+		if err := scanAPIToken(rows, token); err != nil {
+			return nil, fmt.Errorf("failed to scan API token: %w", err)
+		}
+		tokens = append(tokens, token)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating API tokens: %w", err)
+	}
+
+	return tokens, nil
+}
+
+// CountActive returns how many of the user's tokens are neither revoked nor expired at the given time
+func (r *APITokenRepository) CountActive(ctx context.Context, userID string, at time.Time) (int, error) {
+	query := `SELECT COUNT(*) FROM api_tokens WHERE user_id = $1 AND revoked_at IS NULL AND expires_at > $2`
+
+	var count int
+	if err := r.db.QueryRow(ctx, query, userID, at).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count API tokens: %w", err)
+	}
+
+	return count, nil
+}
+
+// Revoke revokes one of the user's tokens, returning false when the user has no such active token
+func (r *APITokenRepository) Revoke(ctx context.Context, userID, tokenID string, at time.Time) (bool, error) {
+	result, err := r.db.Exec(ctx, `
+		UPDATE api_tokens SET revoked_at = $3
+		WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL`, tokenID, userID, at)
+	if err != nil {
+		return false, fmt.Errorf("failed to revoke API token: %w", err)
+	}
+
+	return result.RowsAffected() > 0, nil
+}
+
+// TouchLastUsed records that the token was used at the given time from ipAddress. The row is only
+// written when the previous use is older than staleBefore, so busy scripts don't cause a write on
+// every request.
+func (r *APITokenRepository) TouchLastUsed(ctx context.Context, tokenID string, at time.Time, ipAddress string, staleBefore time.Time) error {
+	_, err := r.db.Exec(ctx, `
+		UPDATE api_tokens SET last_used_at = $2, last_used_ip = $3
+		WHERE id = $1 AND (last_used_at IS NULL OR last_used_at < $4)`, tokenID, at, ipAddress, staleBefore)
+	if err != nil {
+		return fmt.Errorf("failed to record API token use: %w", err)
+	}
+
+	return nil
+}
+
+func scanAPIToken(row pgx.Row, token *models.APIToken) error {
+	return row.Scan(
+		&token.ID,
+		&token.UserID,
+		&token.Name,
+		&token.TokenPrefix,
+		&token.TokenHash,
+		&token.Scopes,
+		&token.CreatedAt,
+		&token.ExpiresAt,
+		&token.LastUsedAt,
+		&token.LastUsedIP,
+		&token.RevokedAt,
+	)
+}
@@ -0,0 +1,128 @@
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"bodda/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+type APITokenRepositoryTestSuite struct {
+	suite.Suite
+	repo     *APITokenRepository
+	userRepo *UserRepository
+	db       *TestDB
+	testUser *models.User
+}
+
+func (suite *APITokenRepositoryTestSuite) SetupSuite() {
+	suite.db = NewTestDB(suite.T())
+	suite.repo = NewAPITokenRepository(suite.db.Pool)
+	suite.userRepo = NewUserRepository(suite.db.Pool)
+}
+
+func (suite *APITokenRepositoryTestSuite) TearDownSuite() {
+	suite.db.Close()
+}
+
+func (suite *APITokenRepositoryTestSuite) SetupTest() {
+	suite.db.CleanTables()
+
+	suite.testUser = &models.User{
+		StravaID:     12345,
+		AccessToken:  "access_token_123",
+		RefreshToken: "refresh_token_123",
+		TokenExpiry:  time.Now().Add(time.Hour),
+		FirstName:    "John",
+		LastName:     "Doe",
+	}
+	require.NoError(suite.T(), suite.userRepo.Create(context.Background(), suite.testUser))
+}
+
+func (suite *APITokenRepositoryTestSuite) newToken(name, hash string, now time.Time) *models.APIToken {
+	return &models.APIToken{
+		UserID:      suite.testUser.ID,
+		Name:        name,
+		TokenPrefix: "bdp_" + hash,
+		TokenHash:   hash,
+		Scopes:      []string{models.ScopeSessionsRead, models.ScopeAnalyticsRead},
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(24 * time.Hour),
+	}
+}
+
+func (suite *APITokenRepositoryTestSuite) TestCreateAndGetByHash() {
+	ctx := context.Background()
+	now := time.Now().UTC().Truncate(time.Second)
+
+	token := suite.newToken("notebook", "hash-1", now)
+	require.NoError(suite.T(), suite.repo.Create(ctx, token))
+	assert.NotEmpty(suite.T(), token.ID)
+
+	stored, err := suite.repo.GetByHash(ctx, "hash-1")
+	require.NoError(suite.T(), err)
+	require.NotNil(suite.T(), stored)
+	assert.Equal(suite.T(), "notebook", stored.Name)
+	assert.Equal(suite.T(), []string{models.ScopeSessionsRead, models.ScopeAnalyticsRead}, stored.Scopes)
+	assert.Nil(suite.T(), stored.LastUsedAt)
+
+	missing, err := suite.repo.GetByHash(ctx, "unknown")
+	require.NoError(suite.T(), err)
+	assert.Nil(suite.T(), missing)
+}
+
+func (suite *APITokenRepositoryTestSuite) TestTouchLastUsedIsThrottled() {
+	ctx := context.Background()
+	now := time.Now().UTC().Truncate(time.Second)
+
+	token := suite.newToken("cron", "hash-1", now)
+	require.NoError(suite.T(), suite.repo.Create(ctx, token))
+
+	require.NoError(suite.T(), suite.repo.TouchLastUsed(ctx, token.ID, now, "203.0.113.7", now.Add(-time.Minute)))
+	require.NoError(suite.T(), suite.repo.TouchLastUsed(ctx, token.ID, now.Add(10*time.Second), "198.51.100.2", now.Add(-50*time.Second)))
+
+	stored, err := suite.repo.GetByHash(ctx, "hash-1")
+	require.NoError(suite.T(), err)
+	require.NotNil(suite.T(), stored.LastUsedAt)
+	assert.Equal(suite.T(), now, *stored.LastUsedAt)
+	assert.Equal(suite.T(), "203.0.113.7", stored.LastUsedIP)
+}
+
+func (suite *APITokenRepositoryTestSuite) TestListCountAndRevoke() {
+	ctx := context.Background()
+	now := time.Now().UTC().Truncate(time.Second)
+
+	older := suite.newToken("old script", "hash-1", now.Add(-48*time.Hour))
+	newer := suite.newToken("notebook", "hash-2", now)
+	require.NoError(suite.T(), suite.repo.Create(ctx, older))
+	require.NoError(suite.T(), suite.repo.Create(ctx, newer))
+
+	tokens, err := suite.repo.ListByUser(ctx, suite.testUser.ID)
+	require.NoError(suite.T(), err)
+	require.Len(suite.T(), tokens, 2, "expired tokens are still listed")
+	assert.Equal(suite.T(), "notebook", tokens[0].Name)
+
+	count, err := suite.repo.CountActive(ctx, suite.testUser.ID, now)
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 1, count)
+
+	revoked, err := suite.repo.Revoke(ctx, "00000000-0000-0000-0000-000000000000", newer.ID, now)
+	require.NoError(suite.T(), err)
+	assert.False(suite.T(), revoked, "tokens of other users cannot be revoked")
+
+	revoked, err = suite.repo.Revoke(ctx, suite.testUser.ID, newer.ID, now)
+	require.NoError(suite.T(), err)
+	assert.True(suite.T(), revoked)
+
+	tokens, err = suite.repo.ListByUser(ctx, suite.testUser.ID)
+	require.NoError(suite.T(), err)
+	assert.Len(suite.T(), tokens, 1)
+}
+
+func TestAPITokenRepositoryTestSuite(t *testing.T) {
+	suite.Run(t, new(APITokenRepositoryTestSuite))
+}
@@ -0,0 +1,237 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"bodda/internal/models"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// CoachingRepository stores coach–athlete links and the comments coaches leave
+type CoachingRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewCoachingRepository(db *pgxpool.Pool) *CoachingRepository {
+	return &CoachingRepository{db: db}
+}
+
+// selectCoachingLinks selects links with both sides' names in the order expected by scanCoachingLink
+const selectCoachingLinks = `
+		SELECT l.id, l.athlete_id, l.coach_id, l.status, l.permissions, l.invite_hash, l.invite_expires_at,
+			l.created_at, l.accepted_at, l.ended_at,
+			TRIM(a.first_name || ' ' || a.last_name),
+			COALESCE(TRIM(c.first_name || ' ' || c.last_name), '')
+		FROM coaching_links l
+		JOIN users a ON a.id = l.athlete_id
+		LEFT JOIN users c ON c.id = l.coach_id`
+
+// CreateInvitation stores a pending link created by an athlete
+func (r *CoachingRepository) CreateInvitation(ctx context.Context, link *models.CoachingLink) error {
+	query := `
+		INSERT INTO coaching_links (athlete_id, status, permissions, invite_hash, invite_expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id`
+
+	err := r.db.QueryRow(ctx, query,
+		link.AthleteID,
+		link.Status,
+		link.Permissions,
+		link.InviteHash,
+		link.InviteExpiresAt,
+		link.CreatedAt,
+	).Scan(&link.ID)
+	if err != nil {
+		return fmt.Errorf("failed to create coaching invitation: %w", err)
+	}
+
+	return nil
+}
+
+// GetByID returns the link with the given ID, or nil when there is none
+func (r *CoachingRepository) GetByID(ctx context.Context, id string) (*models.CoachingLink, error) {
+	return r.getOne(ctx, selectCoachingLinks+` WHERE l.id = $1`, id)
+}
+
+// GetByInviteHash returns the link whose invitation code has the given hash, or nil when there is none
+func (r *CoachingRepository) GetByInviteHash(ctx context.Context, inviteHash string) (*models.CoachingLink, error) {
+	return r.getOne(ctx, selectCoachingLinks+` WHERE l.invite_hash = $1`, inviteHash)
+}
+
+// GetActive returns the active link between a coach and an athlete, or nil when there is none
+func (r *CoachingRepository) GetActive(ctx context.Context, coachID, athleteID string) (*models.CoachingLink, error) {
+	return r.getOne(ctx, selectCoachingLinks+`
+		WHERE l.coach_id = $1 AND l.athlete_id = $2 AND l.status = 'active'`, coachID, athleteID)
+}
+
+func (r *CoachingRepository) getOne(ctx context.Context, query string, args ...interface{}) (*models.CoachingLink, error) {
+	link := &models.CoachingLink{}
+	if err := scanCoachingLink(r.db.QueryRow(ctx, query, args...), link); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get coaching link: %w", err)
+	}
+
+	return link, nil
+}
+
+// Accept activates a pending invitation for the coach. It returns false when the invitation was
+// already accepted, cancelled or has expired.
+func (r *CoachingRepository) Accept(ctx context.Context, linkID, coachID string, at time.Time) (bool, error) {
+	result, err := r.db.Exec(ctx, `
+		UPDATE coaching_links
+		SET coach_id = $2, status = 'active', accepted_at = $3, invite_hash = NULL
+		WHERE id = $1 AND status = 'pending' AND invite_expires_at > $3`, linkID, coachID, at)
+	if err != nil {
+		return false, fmt.Errorf("failed to accept coaching invitation: %w", err)
+	}
+
+	return result.RowsAffected() > 0, nil
+}
+
+// ListForCoach returns the coach's active links, most recently accepted first
+func (r *CoachingRepository) ListForCoach(ctx context.Context, coachID string) ([]*models.CoachingLink, error) {
+	return r.list(ctx, selectCoachingLinks+`
+		WHERE l.coach_id = $1 AND l.status = 'active'
+		ORDER BY l.accepted_at DESC`, coachID)
+}
+
+// ListForAthlete returns the athlete's active links and pending invitations, newest first
+func (r *CoachingRepository) ListForAthlete(ctx context.Context, athleteID string) ([]*models.CoachingLink, error) {
+	return r.list(ctx, selectCoachingLinks+`
+		WHERE l.athlete_id = $1 AND l.status IN ('pending', 'active')
+		ORDER BY l.created_at DESC`, athleteID)
+}
+
+func (r *CoachingRepository) list(ctx context.Context, query string, args ...interface{}) ([]*models.CoachingLink, error) {
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list coaching links: %w", err)
+	}
+	defer rows.Close()
+
+	var links []*models.CoachingLink
+	for rows.Next() {
+		link := &models.CoachingLink{}
+		if err := scanCoachingLink(rows, link); err != nil {
+			return nil, fmt.Errorf("failed to scan coaching link: %w", err)
+		}
+		links = append(links, link)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating coaching links: %w", err)
+	}
+
+	return links, nil
+}
+
+// UpdatePermissions replaces the permissions of one of the athlete's pending or active links,
+// returning false when the athlete has no such link
+func (r *CoachingRepository) UpdatePermissions(ctx context.Context, athleteID, linkID string, permissions []string) (bool, error) {
+	result, err := r.db.Exec(ctx, `
+		UPDATE coaching_links SET permissions = $3
+		WHERE id = $1 AND athlete_id = $2 AND status IN ('pending', 'active')`, linkID, athleteID, permissions)
+	if err != nil {
+		return false, fmt.Errorf("failed to update coaching permissions: %w", err)
+	}
+
+	return result.RowsAffected() > 0, nil
+}
+
+// End ends a link the user is either side of, returning false when there is no such open link
+func (r *CoachingRepository) End(ctx context.Context, userID, linkID string, at time.Time) (bool, error) {
+	result, err := r.db.Exec(ctx, `
+		UPDATE coaching_links SET status = 'ended', ended_at = $3, invite_hash = NULL
+		WHERE id = $1 AND (athlete_id = $2 OR coach_id = $2) AND status IN ('pending', 'active')`, linkID, userID, at)
+	if err != nil {
+		return false, fmt.Errorf("failed to end coaching link: %w", err)
+	}
+
+	return result.RowsAffected() > 0, nil
+}
+
+// CreateComment stores a coach's comment
+func (r *CoachingRepository) CreateComment(ctx context.Context, comment *models.CoachComment) error {
+	query := `
+		INSERT INTO coach_comments (coach_id, athlete_id, session_id, body, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id`
+
+	err := r.db.QueryRow(ctx, query,
+		comment.CoachID,
+		comment.AthleteID,
+		comment.SessionID,
+		comment.Body,
+		comment.CreatedAt,
+	).Scan(&comment.ID)
+	if err != nil {
+		return fmt.Errorf("failed to create coach comment: %w", err)
+	}
+
+	return nil
+}
+
+// ListComments returns the newest comments left for the athlete, limited to one coach when coachID
+// is not empty
+func (r *CoachingRepository) ListComments(ctx context.Context, athleteID, coachID string, limit int) ([]*models.CoachComment, error) {
+	query := `
+		SELECT cc.id, cc.coach_id, cc.athlete_id, cc.session_id, cc.body, cc.created_at,
+			TRIM(u.first_name || ' ' || u.last_name)
+		FROM coach_comments cc
+		JOIN users u ON u.id = cc.coach_id
+		WHERE cc.athlete_id = $1 AND ($2 = '' OR cc.coach_id::text = $2)
+		ORDER BY cc.created_at DESC
+		LIMIT $3`
+
+	rows, err := r.db.Query(ctx, query, athleteID, coachID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list coach comments: %w", err)
+	}
+	defer rows.Close()
+
+	var comments []*models.CoachComment
+	for rows.Next() {
+		comment := &models.CoachComment{}
+		if err := rows.Scan(
+			&comment.ID,
+			&comment.CoachID,
+			&comment.AthleteID,
+			&comment.SessionID,
+			&comment.Body,
+			&comment.CreatedAt,
+			&comment.CoachName,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan coach comment: %w", err)
+		}
+		comments = append(comments, comment)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating coach comments: %w", err)
+	}
+
+	return comments, nil
+}
+
+func scanCoachingLink(row pgx.Row, link *models.CoachingLink) error {
+	return row.Scan(
+		&link.ID,
+		&link.AthleteID,
+		&link.CoachID,
+		&link.Status,
+		&link.Permissions,
+		&link.InviteHash,
+		&link.InviteExpiresAt,
+		&link.CreatedAt,
+		&link.AcceptedAt,
+		&link.EndedAt,
+		&link.AthleteName,
+		&link.CoachName,
+	)
+}
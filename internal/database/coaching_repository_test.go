@@ -0,0 +1,146 @@
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"bodda/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+type CoachingRepositoryTestSuite struct {
+	suite.Suite
+	repo     *CoachingRepository
+	userRepo *UserRepository
+	db       *TestDB
+	athlete  *models.User
+	coach    *models.User
+}
+
+func (suite *CoachingRepositoryTestSuite) SetupSuite() {
+	suite.db = NewTestDB(suite.T())
+	suite.repo = NewCoachingRepository(suite.db.Pool)
+	suite.userRepo = NewUserRepository(suite.db.Pool)
+}
+
+func (suite *CoachingRepositoryTestSuite) TearDownSuite() {
+	suite.db.Close()
+}
+
+func (suite *CoachingRepositoryTestSuite) SetupTest() {
+	suite.db.CleanTables()
+
+	suite.athlete = &models.User{StravaID: 1001, AccessToken: "a", RefreshToken: "a", TokenExpiry: time.Now().Add(time.Hour), FirstName: "Ada", LastName: "Athlete"}
+	suite.coach = &models.User{StravaID: 2002, AccessToken: "c", RefreshToken: "c", TokenExpiry: time.Now().Add(time.Hour), FirstName: "Cora", LastName: "Coach"}
+	require.NoError(suite.T(), suite.userRepo.Create(context.Background(), suite.athlete))
+	require.NoError(suite.T(), suite.userRepo.Create(context.Background(), suite.coach))
+}
+
+func (suite *CoachingRepositoryTestSuite) invite(hash string, now time.Time) *models.CoachingLink {
+	expires := now.Add(7 * 24 * time.Hour)
+	link := &models.CoachingLink{
+		AthleteID:       suite.athlete.ID,
+		Status:          models.CoachingStatusPending,
+		Permissions:     []string{models.CoachPermissionViewLogbook},
+		InviteHash:      &hash,
+		InviteExpiresAt: &expires,
+		CreatedAt:       now,
+	}
+	require.NoError(suite.T(), suite.repo.CreateInvitation(context.Background(), link))
+	return link
+}
+
+func (suite *CoachingRepositoryTestSuite) TestInvitationLifecycle() {
+	ctx := context.Background()
+	now := time.Now().UTC().Truncate(time.Second)
+
+	link := suite.invite("invite-hash", now)
+	assert.NotEmpty(suite.T(), link.ID)
+
+	pending, err := suite.repo.GetByInviteHash(ctx, "invite-hash")
+	require.NoError(suite.T(), err)
+	require.NotNil(suite.T(), pending)
+	assert.Equal(suite.T(), "Ada Athlete", pending.AthleteName)
+	assert.Nil(suite.T(), pending.CoachID)
+
+	accepted, err := suite.repo.Accept(ctx, link.ID, suite.coach.ID, now)
+	require.NoError(suite.T(), err)
+	assert.True(suite.T(), accepted)
+
+	accepted, err = suite.repo.Accept(ctx, link.ID, suite.coach.ID, now)
+	require.NoError(suite.T(), err)
+	assert.False(suite.T(), accepted, "an invitation is accepted once")
+
+	missing, err := suite.repo.GetByInviteHash(ctx, "invite-hash")
+	require.NoError(suite.T(), err)
+	assert.Nil(suite.T(), missing, "the code is cleared on acceptance")
+
+	active, err := suite.repo.GetActive(ctx, suite.coach.ID, suite.athlete.ID)
+	require.NoError(suite.T(), err)
+	require.NotNil(suite.T(), active)
+	assert.Equal(suite.T(), "Cora Coach", active.CoachName)
+
+	athletes, err := suite.repo.ListForCoach(ctx, suite.coach.ID)
+	require.NoError(suite.T(), err)
+	assert.Len(suite.T(), athletes, 1)
+
+	updated, err := suite.repo.UpdatePermissions(ctx, suite.coach.ID, link.ID, []string{models.CoachPermissionComment})
+	require.NoError(suite.T(), err)
+	assert.False(suite.T(), updated, "only the athlete grants permissions")
+
+	updated, err = suite.repo.UpdatePermissions(ctx, suite.athlete.ID, link.ID, []string{models.CoachPermissionComment})
+	require.NoError(suite.T(), err)
+	assert.True(suite.T(), updated)
+
+	ended, err := suite.repo.End(ctx, suite.coach.ID, link.ID, now)
+	require.NoError(suite.T(), err)
+	assert.True(suite.T(), ended)
+
+	active, err = suite.repo.GetActive(ctx, suite.coach.ID, suite.athlete.ID)
+	require.NoError(suite.T(), err)
+	assert.Nil(suite.T(), active)
+}
+
+func (suite *CoachingRepositoryTestSuite) TestExpiredInvitationCannotBeAccepted() {
+	ctx := context.Background()
+	now := time.Now().UTC().Truncate(time.Second)
+
+	link := suite.invite("old-invite", now.Add(-8*24*time.Hour))
+
+	accepted, err := suite.repo.Accept(ctx, link.ID, suite.coach.ID, now)
+	require.NoError(suite.T(), err)
+	assert.False(suite.T(), accepted)
+
+	links, err := suite.repo.ListForAthlete(ctx, suite.athlete.ID)
+	require.NoError(suite.T(), err)
+	assert.Len(suite.T(), links, 1)
+}
+
+func (suite *CoachingRepositoryTestSuite) TestComments() {
+	ctx := context.Background()
+	now := time.Now().UTC().Truncate(time.Second)
+
+	require.NoError(suite.T(), suite.repo.CreateComment(ctx, &models.CoachComment{
+		CoachID: suite.coach.ID, AthleteID: suite.athlete.ID, Body: "Great tempo run", CreatedAt: now.Add(-time.Hour),
+	}))
+	require.NoError(suite.T(), suite.repo.CreateComment(ctx, &models.CoachComment{
+		CoachID: suite.coach.ID, AthleteID: suite.athlete.ID, Body: "Easy day tomorrow", CreatedAt: now,
+	}))
+
+	comments, err := suite.repo.ListComments(ctx, suite.athlete.ID, "", 10)
+	require.NoError(suite.T(), err)
+	require.Len(suite.T(), comments, 2)
+	assert.Equal(suite.T(), "Easy day tomorrow", comments[0].Body)
+	assert.Equal(suite.T(), "Cora Coach", comments[0].CoachName)
+
+	comments, err = suite.repo.ListComments(ctx, suite.athlete.ID, suite.athlete.ID, 10)
+	require.NoError(suite.T(), err)
+	assert.Empty(suite.T(), comments)
+}
+
+func TestCoachingRepositoryTestSuite(t *testing.T) {
+	suite.Run(t, new(CoachingRepositoryTestSuite))
+}
@@ -0,0 +1,200 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"bodda/internal/models"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// FeedbackRepository stores ratings of assistant replies together with how the replies were produced
+type FeedbackRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewFeedbackRepository(db *pgxpool.Pool) *FeedbackRepository {
+	return &FeedbackRepository{db: db}
+}
+
+// feedbackColumns selects feedback in the order expected by scanFeedback
+const feedbackColumns = `id, message_id, session_id, user_id, rating, reasons, comment, generation, created_at, updated_at`
+
+// SetGeneration records the model and tool calls that produced an assistant reply
+func (r *FeedbackRepository) SetGeneration(ctx context.Context, messageID string, generation *models.MessageGeneration) error {
+	encoded, err := json.Marshal(generation)
+	if err != nil {
+		return fmt.Errorf("failed to encode message generation: %w", err)
+	}
+
+	result, err := r.db.Exec(ctx, `UPDATE messages SET generation = $2 WHERE id = $1 AND role = 'assistant'`, messageID, encoded)
+	if err != nil {
+		return fmt.Errorf("failed to store message generation: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return fmt.Errorf("message not found")
+	}
+
+	return nil
+}
+
+// Upsert stores the user's rating of an assistant reply in the session, replacing an earlier rating
+// and copying the reply's generation. It returns false when no such reply exists.
+func (r *FeedbackRepository) Upsert(ctx context.Context, feedback *models.MessageFeedback) (bool, error) {
+	query := `
+		INSERT INTO message_feedback (message_id, session_id, user_id, rating, reasons, comment, generation, created_at, updated_at)
+		SELECT m.id, m.session_id, $3, $4, $5, $6, m.generation, NOW(), NOW()
+		FROM messages m
+		WHERE m.id = $1 AND m.session_id = $2 AND m.role = 'assistant'
+		ON CONFLICT (message_id, user_id) DO UPDATE SET
+			rating = EXCLUDED.rating,
+			reasons = EXCLUDED.reasons,
+			comment = EXCLUDED.comment,
+			generation = EXCLUDED.generation,
+			updated_at = NOW()
+		RETURNING ` + feedbackColumns
+
+	row := r.db.QueryRow(ctx, query,
+		feedback.MessageID,
+		feedback.SessionID,
+		feedback.UserID,
+		feedback.Rating,
+		feedback.Reasons,
+		feedback.Comment,
+	)
+	if err := scanFeedback(row, feedback); err != nil {
+		if err == pgx.ErrNoRows {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to store message feedback: %w", err)
+	}
+
+	return true, nil
+}
+
+// Delete removes the user's rating of a reply, returning false when there was none
+func (r *FeedbackRepository) Delete(ctx context.Context, messageID, userID string) (bool, error) {
+	result, err := r.db.Exec(ctx, `DELETE FROM message_feedback WHERE message_id = $1 AND user_id = $2`, messageID, userID)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete message feedback: %w", err)
+	}
+
+	return result.RowsAffected() > 0, nil
+}
+
+// ListRated returns feedback last updated in [from, to), oldest first. An empty rating returns
+// both ratings.
+func (r *FeedbackRepository) ListRated(ctx context.Context, from, to time.Time, rating string, limit int) ([]*models.MessageFeedback, error) {
+	query := `
+		SELECT ` + feedbackColumns + `
+		FROM message_feedback
+		WHERE updated_at >= $1 AND updated_at < $2 AND ($3 = '' OR rating = $3)
+		ORDER BY updated_at, id
+		LIMIT $4`
+
+	rows, err := r.db.Query(ctx, query, from, to, rating, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list message feedback: %w", err)
+	}
+	defer rows.Close()
+
+	var feedback []*models.MessageFeedback
+	for rows.Next() {
+		entry := &models.MessageFeedback{}
+		if err := scanFeedback(rows, entry); err != nil {
+			return nil, fmt.Errorf("failed to scan message feedback: %w", err)
+		}
+		feedback = append(feedback, entry)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating message feedback: %w", err)
+	}
+
+	return feedback, nil
+}
+
+// GetConversations returns, for each message, the branch of the conversation that led to it, from the
+// first message to the message itself. Messages that do not exist are left out of the map.
+func (r *FeedbackRepository) GetConversations(ctx context.Context, messageIDs []string) (map[string][]*models.Message, error) {
+	conversations := make(map[string][]*models.Message, len(messageIDs))
+	if len(messageIDs) == 0 {
+		return conversations, nil
+	}
+
+	query := `
+		WITH RECURSIVE path AS (
+			SELECT id AS root_id, id, parent_id, 0 AS depth FROM messages WHERE id = ANY($1::uuid[])
+			UNION ALL
+			SELECT path.root_id, m.id, m.parent_id, path.depth + 1
+			FROM messages m JOIN path ON m.id = path.parent_id
+		)
+		SELECT path.root_id, m.id, m.session_id, m.parent_id, m.role, m.content, m.response_id, m.created_at
+		FROM path
+		JOIN messages m ON m.id = path.id
+		ORDER BY path.root_id, path.depth DESC`
+
+	rows, err := r.db.Query(ctx, query, messageIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get conversations: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var rootID string
+		message := &models.Message{}
+		err := rows.Scan(
+			&rootID,
+			&message.ID,
+			&message.SessionID,
+			&message.ParentID,
+			&message.Role,
+			&message.Content,
+			&message.ResponseID,
+			&message.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan message: %w", err)
+		}
+		conversations[rootID] = append(conversations[rootID], message)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating messages: %w", err)
+	}
+
+	return conversations, nil
+}
+
+func scanFeedback(row pgx.Row, feedback *models.MessageFeedback) error {
+	var generation []byte
+	err := row.Scan(
+		&feedback.ID,
+		&feedback.MessageID,
+		&feedback.SessionID,
+		&feedback.UserID,
+		&feedback.Rating,
+		&feedback.Reasons,
+		&feedback.Comment,
+		&generation,
+		&feedback.CreatedAt,
+		&feedback.UpdatedAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	feedback.Generation = nil
+	if generation != nil {
+		feedback.Generation = &models.MessageGeneration{}
+		if err := json.Unmarshal(generation, feedback.Generation); err != nil {
+			return fmt.Errorf("failed to decode message generation: %w", err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,172 @@
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"bodda/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+type FeedbackRepositoryTestSuite struct {
+	suite.Suite
+	repo        *FeedbackRepository
+	messageRepo *MessageRepository
+	db          *TestDB
+	testUser    *models.User
+	testSession *models.Session
+}
+
+func (suite *FeedbackRepositoryTestSuite) SetupSuite() {
+	suite.db = NewTestDB(suite.T())
+	suite.repo = NewFeedbackRepository(suite.db.Pool)
+	suite.messageRepo = NewMessageRepository(suite.db.Pool)
+}
+
+func (suite *FeedbackRepositoryTestSuite) TearDownSuite() {
+	suite.db.Close()
+}
+
+func (suite *FeedbackRepositoryTestSuite) SetupTest() {
+	suite.db.CleanTables()
+
+	suite.testUser = &models.User{
+		StravaID:     12345,
+		AccessToken:  "access_token_123",
+		RefreshToken: "refresh_token_123",
+		TokenExpiry:  time.Now().Add(time.Hour),
+		FirstName:    "John",
+		LastName:     "Doe",
+	}
+	require.NoError(suite.T(), NewUserRepository(suite.db.Pool).Create(context.Background(), suite.testUser))
+
+	suite.testSession = &models.Session{UserID: suite.testUser.ID, Title: "Test Session"}
+	require.NoError(suite.T(), NewSessionRepository(suite.db.Pool).Create(context.Background(), suite.testSession))
+}
+
+func (suite *FeedbackRepositoryTestSuite) createMessage(role, content string) *models.Message {
+	message := &models.Message{SessionID: suite.testSession.ID, Role: role, Content: content}
+	require.NoError(suite.T(), suite.messageRepo.Create(context.Background(), message))
+	return message
+}
+
+func (suite *FeedbackRepositoryTestSuite) TestUpsertCopiesGeneration() {
+	ctx := context.Background()
+	suite.createMessage("user", "How was my week?")
+	reply := suite.createMessage("assistant", "You ran 42 km.")
+
+	generation := &models.MessageGeneration{Model: "gpt-5", ToolInvocations: []models.ToolInvocation{
+		{Name: "get-recent-activities", Arguments: `{"per_page":5}`, Round: 1},
+	}}
+	require.NoError(suite.T(), suite.repo.SetGeneration(ctx, reply.ID, generation))
+
+	feedback := &models.MessageFeedback{
+		MessageID: reply.ID,
+		SessionID: suite.testSession.ID,
+		UserID:    suite.testUser.ID,
+		Rating:    models.FeedbackDown,
+		Reasons:   []string{"too_long"},
+	}
+	stored, err := suite.repo.Upsert(ctx, feedback)
+	require.NoError(suite.T(), err)
+	assert.True(suite.T(), stored)
+	assert.NotEmpty(suite.T(), feedback.ID)
+	assert.Equal(suite.T(), generation, feedback.Generation)
+
+	// Rating again replaces the earlier rating
+	again := &models.MessageFeedback{
+		MessageID: reply.ID,
+		SessionID: suite.testSession.ID,
+		UserID:    suite.testUser.ID,
+		Rating:    models.FeedbackUp,
+		Reasons:   []string{},
+		Comment:   "Changed my mind",
+	}
+	stored, err = suite.repo.Upsert(ctx, again)
+	require.NoError(suite.T(), err)
+	assert.True(suite.T(), stored)
+	assert.Equal(suite.T(), feedback.ID, again.ID)
+
+	rated, err := suite.repo.ListRated(ctx, time.Now().Add(-time.Hour), time.Now().Add(time.Hour), "", 10)
+	require.NoError(suite.T(), err)
+	require.Len(suite.T(), rated, 1)
+	assert.Equal(suite.T(), models.FeedbackUp, rated[0].Rating)
+	assert.Equal(suite.T(), "Changed my mind", rated[0].Comment)
+
+	rated, err = suite.repo.ListRated(ctx, time.Now().Add(-time.Hour), time.Now().Add(time.Hour), models.FeedbackDown, 10)
+	require.NoError(suite.T(), err)
+	assert.Empty(suite.T(), rated)
+}
+
+func (suite *FeedbackRepositoryTestSuite) TestUpsertOnlyRatesAssistantRepliesInSession() {
+	ctx := context.Background()
+	question := suite.createMessage("user", "How was my week?")
+	reply := suite.createMessage("assistant", "You ran 42 km.")
+
+	stored, err := suite.repo.Upsert(ctx, &models.MessageFeedback{
+		MessageID: question.ID, SessionID: suite.testSession.ID, UserID: suite.testUser.ID, Rating: models.FeedbackUp, Reasons: []string{},
+	})
+	require.NoError(suite.T(), err)
+	assert.False(suite.T(), stored, "user messages cannot be rated")
+
+	other := &models.Session{UserID: suite.testUser.ID, Title: "Other"}
+	require.NoError(suite.T(), NewSessionRepository(suite.db.Pool).Create(ctx, other))
+	stored, err = suite.repo.Upsert(ctx, &models.MessageFeedback{
+		MessageID: reply.ID, SessionID: other.ID, UserID: suite.testUser.ID, Rating: models.FeedbackUp, Reasons: []string{},
+	})
+	require.NoError(suite.T(), err)
+	assert.False(suite.T(), stored, "replies are rated in their own session")
+
+	feedback := &models.MessageFeedback{
+		MessageID: reply.ID, SessionID: suite.testSession.ID, UserID: suite.testUser.ID, Rating: models.FeedbackUp, Reasons: []string{},
+	}
+	stored, err = suite.repo.Upsert(ctx, feedback)
+	require.NoError(suite.T(), err)
+	assert.True(suite.T(), stored)
+	assert.Nil(suite.T(), feedback.Generation, "replies without recorded generations have none")
+
+	deleted, err := suite.repo.Delete(ctx, reply.ID, suite.testUser.ID)
+	require.NoError(suite.T(), err)
+	assert.True(suite.T(), deleted)
+	deleted, err = suite.repo.Delete(ctx, reply.ID, suite.testUser.ID)
+	require.NoError(suite.T(), err)
+	assert.False(suite.T(), deleted)
+}
+
+func (suite *FeedbackRepositoryTestSuite) TestGetConversations() {
+	ctx := context.Background()
+	suite.createMessage("user", "How was my week?")
+	firstReply := suite.createMessage("assistant", "You ran 42 km.")
+	suite.createMessage("user", "And my long run?")
+	reply := suite.createMessage("assistant", "18 km on Sunday.")
+
+	conversations, err := suite.repo.GetConversations(ctx, []string{reply.ID, firstReply.ID})
+	require.NoError(suite.T(), err)
+
+	contents := func(messages []*models.Message) []string {
+		var contents []string
+		for _, message := range messages {
+			contents = append(contents, message.Content)
+		}
+		return contents
+	}
+	assert.Equal(suite.T(), []string{"How was my week?", "You ran 42 km.", "And my long run?", "18 km on Sunday."}, contents(conversations[reply.ID]))
+	assert.Equal(suite.T(), []string{"How was my week?", "You ran 42 km."}, contents(conversations[firstReply.ID]))
+
+	conversations, err = suite.repo.GetConversations(ctx, nil)
+	require.NoError(suite.T(), err)
+	assert.Empty(suite.T(), conversations)
+}
+
+func (suite *FeedbackRepositoryTestSuite) TestSetGenerationRequiresAssistantReply() {
+	question := suite.createMessage("user", "How was my week?")
+	err := suite.repo.SetGeneration(context.Background(), question.ID, &models.MessageGeneration{Model: "gpt-5"})
+	assert.Error(suite.T(), err)
+}
+
+func TestFeedbackRepositoryTestSuite(t *testing.T) {
+	suite.Run(t, new(FeedbackRepositoryTestSuite))
+}
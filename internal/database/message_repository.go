@@ -17,14 +17,59 @@ func NewMessageRepository(db *pgxpool.Pool) *MessageRepository {
 	return &MessageRepository{db: db}
 }
 
+// messageColumns lists the message columns in the order expected by scanMessage
+const messageColumns = `id, session_id, parent_id, role, content, response_id, created_at`
+
+// Create appends the message to the session's active branch, unless ParentID is already set, and
+// makes it the active message
 func (r *MessageRepository) Create(ctx context.Context, message *models.Message) error {
+	return r.insertActive(ctx, message, message.ParentID == nil)
+}
+
+// CreateBranch stores the message under ParentID exactly, so a nil parent starts a new first
+// message, and makes it the active message. Used to add a sibling of an existing message.
+func (r *MessageRepository) CreateBranch(ctx context.Context, message *models.Message) error {
+	return r.insertActive(ctx, message, false)
+}
+
+func (r *MessageRepository) insertActive(ctx context.Context, message *models.Message, appendToActive bool) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin message creation: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	// Locking the session serializes concurrent appends to the same branch
+	var activeID *string
+	err = tx.QueryRow(ctx, `SELECT active_message_id FROM sessions WHERE id = $1 FOR UPDATE`, message.SessionID).Scan(&activeID)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return fmt.Errorf("failed to create message: session not found")
+		}
+		return fmt.Errorf("failed to create message: %w", err)
+	}
+
+	if appendToActive {
+		if activeID == nil {
+			// Sessions whose active message was deleted continue from their newest message
+			err = tx.QueryRow(ctx, `
+				SELECT id FROM messages WHERE session_id = $1
+				ORDER BY created_at DESC, id DESC LIMIT 1`, message.SessionID).Scan(&activeID)
+			if err != nil && err != pgx.ErrNoRows {
+				return fmt.Errorf("failed to find active message: %w", err)
+			}
+		}
+		message.ParentID = activeID
+	}
+
 	query := `
-		INSERT INTO messages (session_id, role, content, response_id)
-		VALUES ($1, $2, $3, $4)
+		INSERT INTO messages (session_id, parent_id, role, content, response_id)
+		VALUES ($1, $2, $3, $4, $5)
 		RETURNING id, created_at`
 
-	err := r.db.QueryRow(ctx, query,
+	err = tx.QueryRow(ctx, query,
 		message.SessionID,
+		message.ParentID,
 		message.Role,
 		message.Content,
 		message.ResponseID,
@@ -34,23 +79,24 @@ func (r *MessageRepository) Create(ctx context.Context, message *models.Message)
 		return fmt.Errorf("failed to create message: %w", err)
 	}
 
+	if _, err := tx.Exec(ctx, `UPDATE sessions SET active_message_id = $2 WHERE id = $1`, message.SessionID, message.ID); err != nil {
+		return fmt.Errorf("failed to update active message: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit message creation: %w", err)
+	}
+
 	return nil
 }
 
 func (r *MessageRepository) GetByID(ctx context.Context, id string) (*models.Message, error) {
 	message := &models.Message{}
 	query := `
-		SELECT id, session_id, role, content, response_id, created_at
+		SELECT ` + messageColumns + `
 		FROM messages WHERE id = $1`
 
-	err := r.db.QueryRow(ctx, query, id).Scan(
-		&message.ID,
-		&message.SessionID,
-		&message.Role,
-		&message.Content,
-		&message.ResponseID,
-		&message.CreatedAt,
-	)
+	err := scanMessage(r.db.QueryRow(ctx, query, id), message)
 
 	if err != nil {
 		if err == pgx.ErrNoRows {
@@ -64,7 +110,7 @@ func (r *MessageRepository) GetByID(ctx context.Context, id string) (*models.Mes
 
 func (r *MessageRepository) GetBySessionID(ctx context.Context, sessionID string) ([]*models.Message, error) {
 	query := `
-		SELECT id, session_id, role, content, response_id, created_at
+		SELECT ` + messageColumns + `
 		FROM messages 
 		WHERE session_id = $1 
 		ORDER BY created_at ASC`
@@ -78,15 +124,7 @@ func (r *MessageRepository) GetBySessionID(ctx context.Context, sessionID string
 	var messages []*models.Message
 	for rows.Next() {
 		message := &models.Message{}
-		err := rows.Scan(
-			&message.ID,
-			&message.SessionID,
-			&message.Role,
-			&message.Content,
-			&message.ResponseID,
-			&message.CreatedAt,
-		)
-		if err != nil {
+		if err := scanMessage(rows, message); err != nil {
 			return nil, fmt.Errorf("failed to scan message: %w", err)
 		}
 		messages = append(messages, message)
@@ -101,7 +139,7 @@ func (r *MessageRepository) GetBySessionID(ctx context.Context, sessionID string
 
 func (r *MessageRepository) GetBySessionIDWithLimit(ctx context.Context, sessionID string, limit int) ([]*models.Message, error) {
 	query := `
-		SELECT id, session_id, role, content, response_id, created_at
+		SELECT ` + messageColumns + `
 		FROM messages 
 		WHERE session_id = $1 
 		ORDER BY created_at DESC
@@ -116,15 +154,7 @@ func (r *MessageRepository) GetBySessionIDWithLimit(ctx context.Context, session
 	var messages []*models.Message
 	for rows.Next() {
 		message := &models.Message{}
-		err := rows.Scan(
-			&message.ID,
-			&message.SessionID,
-			&message.Role,
-			&message.Content,
-			&message.ResponseID,
-			&message.CreatedAt,
-		)
-		if err != nil {
+		if err := scanMessage(rows, message); err != nil {
 			return nil, fmt.Errorf("failed to scan message: %w", err)
 		}
 		messages = append(messages, message)
@@ -144,7 +174,7 @@ func (r *MessageRepository) GetBySessionIDWithLimit(ctx context.Context, session
 
 func (r *MessageRepository) GetBySessionIDWithPagination(ctx context.Context, sessionID string, limit, offset int) ([]*models.Message, error) {
 	query := `
-		SELECT id, session_id, role, content, response_id, created_at
+		SELECT ` + messageColumns + `
 		FROM messages 
 		WHERE session_id = $1 
 		ORDER BY created_at ASC
@@ -159,15 +189,7 @@ func (r *MessageRepository) GetBySessionIDWithPagination(ctx context.Context, se
 	var messages []*models.Message
 	for rows.Next() {
 		message := &models.Message{}
-		err := rows.Scan(
-			&message.ID,
-			&message.SessionID,
-			&message.Role,
-			&message.Content,
-			&message.ResponseID,
-			&message.CreatedAt,
-		)
-		if err != nil {
+		if err := scanMessage(rows, message); err != nil {
 			return nil, fmt.Errorf("failed to scan message: %w", err)
 		}
 		messages = append(messages, message)
@@ -205,4 +227,118 @@ func (r *MessageRepository) Delete(ctx context.Context, id string) error {
 	}
 
 	return nil
-}
\ No newline at end of file
+}
+
+// GetActivePath returns the messages of the session's active branch from the first message to the
+// active one. Messages where the conversation branches carry the IDs of their siblings.
+func (r *MessageRepository) GetActivePath(ctx context.Context, sessionID string) ([]*models.Message, error) {
+	query := `
+		WITH RECURSIVE leaf AS (
+			SELECT COALESCE(s.active_message_id, (
+				SELECT m.id FROM messages m WHERE m.session_id = s.id ORDER BY m.created_at DESC, m.id DESC LIMIT 1
+			)) AS id
+			FROM sessions s WHERE s.id = $1
+		),
+		path AS (
+			SELECT m.id, m.parent_id, 0 AS depth
+			FROM messages m JOIN leaf ON m.id = leaf.id
+			WHERE m.session_id = $1
+			UNION ALL
+			SELECT m.id, m.parent_id, path.depth + 1
+			FROM messages m JOIN path ON m.id = path.parent_id
+		)
+		SELECT m.id, m.session_id, m.parent_id, m.role, m.content, m.response_id, m.created_at,
+			ARRAY(
+				SELECT sibling.id::text FROM messages sibling
+				WHERE sibling.session_id = m.session_id AND sibling.parent_id IS NOT DISTINCT FROM m.parent_id
+				ORDER BY sibling.created_at, sibling.id
+			)
+		FROM path
+		JOIN messages m ON m.id = path.id
+		ORDER BY path.depth DESC`
+
+	rows, err := r.db.Query(ctx, query, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get active messages: %w", err)
+	}
+	defer rows.Close()
+
+	var messages []*models.Message
+	for rows.Next() {
+		message := &models.Message{}
+		var siblingIDs []string
+		if err := rows.Scan(
+			&message.ID,
+			&message.SessionID,
+			&message.ParentID,
+			&message.Role,
+			&message.Content,
+			&message.ResponseID,
+			&message.CreatedAt,
+			&siblingIDs,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan message: %w", err)
+		}
+		if len(siblingIDs) > 1 {
+			message.SiblingIDs = siblingIDs
+		}
+		messages = append(messages, message)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating messages: %w", err)
+	}
+
+	return messages, nil
+}
+
+// SetActiveMessage continues the session from the given message, returning false when the
+// message is not part of the session
+func (r *MessageRepository) SetActiveMessage(ctx context.Context, sessionID, messageID string) (bool, error) {
+	result, err := r.db.Exec(ctx, `
+		UPDATE sessions SET active_message_id = $2
+		WHERE id = $1 AND EXISTS (SELECT 1 FROM messages WHERE id = $2 AND session_id = $1)`, sessionID, messageID)
+	if err != nil {
+		return false, fmt.Errorf("failed to set active message: %w", err)
+	}
+
+	return result.RowsAffected() > 0, nil
+}
+
+// SelectBranch makes the branch through the given message active, continuing down to its newest
+// leaf. It returns false when the message is not part of the session.
+func (r *MessageRepository) SelectBranch(ctx context.Context, sessionID, messageID string) (bool, error) {
+	query := `
+		WITH RECURSIVE descent AS (
+			SELECT id, 0 AS depth FROM messages WHERE id = $2 AND session_id = $1
+			UNION ALL
+			SELECT child.id, descent.depth + 1
+			FROM descent
+			CROSS JOIN LATERAL (
+				SELECT c.id FROM messages c WHERE c.parent_id = descent.id
+				ORDER BY c.created_at DESC, c.id DESC LIMIT 1
+			) child
+		)
+		UPDATE sessions
+		SET active_message_id = (SELECT id FROM descent ORDER BY depth DESC LIMIT 1)
+		WHERE id = $1 AND EXISTS (SELECT 1 FROM descent)`
+
+	result, err := r.db.Exec(ctx, query, sessionID, messageID)
+	if err != nil {
+		return false, fmt.Errorf("failed to select branch: %w", err)
+	}
+
+	return result.RowsAffected() > 0, nil
+}
+
+func scanMessage(row pgx.Row, message *models.Message) error {
+	return row.Scan(
+		&message.ID,
+		&message.SessionID,
+		&message.ParentID,
+		&message.Role,
+		&message.Content,
+		&message.ResponseID,
+		&message.CreatedAt,
+	)
+}
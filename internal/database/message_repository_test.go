@@ -265,6 +265,113 @@ func (suite *MessageRepositoryTestSuite) TestMessageRoleValidation() {
 	}
 }
 
+func (suite *MessageRepositoryTestSuite) createMessage(role, content string) *models.Message {
+	message := &models.Message{
+		SessionID: suite.testSession.ID,
+		Role:      role,
+		Content:   content,
+	}
+	err := suite.repo.Create(context.Background(), message)
+	suite.Require().NoError(err)
+	return message
+}
+
+func (suite *MessageRepositoryTestSuite) activeContents() []string {
+	path, err := suite.repo.GetActivePath(context.Background(), suite.testSession.ID)
+	suite.Require().NoError(err)
+	var contents []string
+	for _, message := range path {
+		contents = append(contents, message.Content)
+	}
+	return contents
+}
+
+func (suite *MessageRepositoryTestSuite) TestCreateAppendsToActiveBranch() {
+	question := suite.createMessage("user", "How far should I run?")
+	answer := suite.createMessage("assistant", "Ten kilometres.")
+
+	assert.Nil(suite.T(), question.ParentID)
+	suite.Require().NotNil(answer.ParentID)
+	assert.Equal(suite.T(), question.ID, *answer.ParentID)
+
+	session, err := suite.sessionRepo.GetByID(context.Background(), suite.testSession.ID)
+	suite.Require().NoError(err)
+	suite.Require().NotNil(session.ActiveMessageID)
+	assert.Equal(suite.T(), answer.ID, *session.ActiveMessageID)
+}
+
+func (suite *MessageRepositoryTestSuite) TestBranching() {
+	ctx := context.Background()
+	question := suite.createMessage("user", "How far should I run?")
+	suite.createMessage("assistant", "Ten kilometres.")
+
+	edited := &models.Message{
+		SessionID: suite.testSession.ID,
+		ParentID:  question.ParentID,
+		Role:      "user",
+		Content:   "How far should I run tomorrow?",
+	}
+	suite.Require().NoError(suite.repo.CreateBranch(ctx, edited))
+	suite.createMessage("assistant", "Rest tomorrow.")
+
+	assert.Equal(suite.T(), []string{"How far should I run tomorrow?", "Rest tomorrow."}, suite.activeContents())
+
+	path, err := suite.repo.GetActivePath(ctx, suite.testSession.ID)
+	suite.Require().NoError(err)
+	assert.Equal(suite.T(), []string{question.ID, edited.ID}, path[0].SiblingIDs)
+	assert.Empty(suite.T(), path[1].SiblingIDs)
+
+	// Selecting the original question continues to its newest reply
+	selected, err := suite.repo.SelectBranch(ctx, suite.testSession.ID, question.ID)
+	suite.Require().NoError(err)
+	assert.True(suite.T(), selected)
+	assert.Equal(suite.T(), []string{"How far should I run?", "Ten kilometres."}, suite.activeContents())
+
+	// Rewinding to the question makes the next reply a sibling of the first one
+	updated, err := suite.repo.SetActiveMessage(ctx, suite.testSession.ID, question.ID)
+	suite.Require().NoError(err)
+	assert.True(suite.T(), updated)
+	suite.createMessage("assistant", "Five kilometres.")
+	path, err = suite.repo.GetActivePath(ctx, suite.testSession.ID)
+	suite.Require().NoError(err)
+	suite.Require().Len(path, 2)
+	assert.Equal(suite.T(), "Five kilometres.", path[1].Content)
+	assert.Len(suite.T(), path[1].SiblingIDs, 2)
+
+	count, err := suite.repo.CountBySessionID(ctx, suite.testSession.ID)
+	suite.Require().NoError(err)
+	assert.Equal(suite.T(), 5, count, "branches keep every message")
+}
+
+func (suite *MessageRepositoryTestSuite) TestSelectBranchRejectsOtherSessions() {
+	ctx := context.Background()
+	message := suite.createMessage("user", "Hello")
+
+	other := &models.Session{UserID: suite.testUser.ID, Title: "Other"}
+	suite.Require().NoError(suite.sessionRepo.Create(ctx, other))
+
+	selected, err := suite.repo.SelectBranch(ctx, other.ID, message.ID)
+	suite.Require().NoError(err)
+	assert.False(suite.T(), selected)
+
+	updated, err := suite.repo.SetActiveMessage(ctx, other.ID, message.ID)
+	suite.Require().NoError(err)
+	assert.False(suite.T(), updated)
+}
+
+func (suite *MessageRepositoryTestSuite) TestActivePathFallsBackToLatestMessage() {
+	ctx := context.Background()
+	suite.createMessage("user", "First")
+	reply := suite.createMessage("assistant", "Second")
+
+	// Deleting the active message clears the session's pointer
+	suite.Require().NoError(suite.repo.Delete(ctx, reply.ID))
+	assert.Equal(suite.T(), []string{"First"}, suite.activeContents())
+
+	suite.createMessage("assistant", "Replacement")
+	assert.Equal(suite.T(), []string{"First", "Replacement"}, suite.activeContents())
+}
+
 func TestMessageRepositoryTestSuite(t *testing.T) {
 	suite.Run(t, new(MessageRepositoryTestSuite))
 }
\ No newline at end of file
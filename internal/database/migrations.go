@@ -15,6 +15,38 @@ func RunMigrations(db *pgxpool.Pool) error {
 		createAthleteLogbooksTable,
 		addResponseIdToMessages,
 		addLastResponseIdToSessions,
+		addSummaryToSessions,
+		createTokenUsageTable,
+		createTokenUsageDateIndex,
+		createToolResultCacheTable,
+		createToolResultCacheExpiryIndex,
+		createDailyWellnessTable,
+		createRefreshTokensTable,
+		createRefreshTokensFamilyIndex,
+		createRefreshTokensUserIndex,
+		createAPITokensTable,
+		createAPITokensUserIndex,
+		createCoachingLinksTable,
+		createCoachingLinksActivePairIndex,
+		createCoachingLinksAthleteIndex,
+		addAthleteIdToSessions,
+		createCoachCommentsTable,
+		createCoachCommentsAthleteIndex,
+		createTeamsTable,
+		createTeamMembersTable,
+		createTeamMembersUserIndex,
+		addOrganizationToSessions,
+		addSearchVectorToMessages,
+		createMessagesSearchIndex,
+		addTitleCustomizedToSessions,
+		addParentToMessages,
+		addActiveMessageToSessions,
+		backfillMessageParents,
+		backfillActiveMessages,
+		createMessagesParentIndex,
+		addGenerationToMessages,
+		createMessageFeedbackTable,
+		createMessageFeedbackUpdatedIndex,
 	}
 
 	for i, migration := range migrations {
@@ -71,4 +103,238 @@ ADD COLUMN IF NOT EXISTS response_id TEXT;`
 
 const addLastResponseIdToSessions = `
 ALTER TABLE sessions 
-ADD COLUMN IF NOT EXISTS last_response_id TEXT;`
\ No newline at end of file
+ADD COLUMN IF NOT EXISTS last_response_id TEXT;`
+
+const addSummaryToSessions = `
+ALTER TABLE sessions 
+ADD COLUMN IF NOT EXISTS summary TEXT,
+ADD COLUMN IF NOT EXISTS summarized_message_count INTEGER NOT NULL DEFAULT 0;`
+
+// Sessions are not referenced so usage still counts towards quotas after a session is deleted
+const createTokenUsageTable = `
+CREATE TABLE IF NOT EXISTS token_usage (
+    user_id UUID REFERENCES users(id) ON DELETE CASCADE,
+    session_id TEXT NOT NULL DEFAULT '',
+    usage_date DATE NOT NULL,
+    input_tokens BIGINT NOT NULL DEFAULT 0,
+    output_tokens BIGINT NOT NULL DEFAULT 0,
+    cached_input_tokens BIGINT NOT NULL DEFAULT 0,
+    reasoning_tokens BIGINT NOT NULL DEFAULT 0,
+    request_count INTEGER NOT NULL DEFAULT 0,
+    updated_at TIMESTAMP DEFAULT NOW(),
+    PRIMARY KEY (user_id, session_id, usage_date)
+);`
+
+const createTokenUsageDateIndex = `
+CREATE INDEX IF NOT EXISTS idx_token_usage_usage_date ON token_usage(usage_date);`
+
+const createToolResultCacheTable = `
+CREATE TABLE IF NOT EXISTS tool_result_cache (
+    cache_key TEXT PRIMARY KEY,
+    user_id UUID REFERENCES users(id) ON DELETE CASCADE,
+    tool_name VARCHAR(100) NOT NULL,
+    content TEXT NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP DEFAULT NOW()
+);`
+
+const createToolResultCacheExpiryIndex = `
+CREATE INDEX IF NOT EXISTS idx_tool_result_cache_expires_at ON tool_result_cache(expires_at);`
+
+// Values are nullable because athletes rarely record every metric every day
+const createDailyWellnessTable = `
+CREATE TABLE IF NOT EXISTS daily_wellness (
+    user_id UUID REFERENCES users(id) ON DELETE CASCADE,
+    metric_date DATE NOT NULL,
+    resting_hr INTEGER CHECK (resting_hr BETWEEN 20 AND 150),
+    hrv_rmssd DOUBLE PRECISION CHECK (hrv_rmssd > 0 AND hrv_rmssd <= 300),
+    sleep_hours DOUBLE PRECISION CHECK (sleep_hours >= 0 AND sleep_hours <= 24),
+    fatigue SMALLINT CHECK (fatigue BETWEEN 1 AND 5),
+    soreness SMALLINT CHECK (soreness BETWEEN 1 AND 5),
+    mood SMALLINT CHECK (mood BETWEEN 1 AND 5),
+    notes TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP DEFAULT NOW(),
+    updated_at TIMESTAMP DEFAULT NOW(),
+    PRIMARY KEY (user_id, metric_date)
+);`
+
+// Refresh tokens are stored as SHA-256 hashes. Rotated tokens are kept until they expire so a
+// replayed token can be recognized and its whole family revoked.
+const createRefreshTokensTable = `
+CREATE TABLE IF NOT EXISTS refresh_tokens (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    family_id UUID NOT NULL,
+    token_hash TEXT UNIQUE NOT NULL,
+    device_name TEXT NOT NULL DEFAULT '',
+    ip_address TEXT NOT NULL DEFAULT '',
+    user_agent TEXT NOT NULL DEFAULT '',
+    session_started_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    last_used_at TIMESTAMP NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    rotated_at TIMESTAMP,
+    revoked_at TIMESTAMP,
+    revoked_reason TEXT NOT NULL DEFAULT ''
+);`
+
+const createRefreshTokensFamilyIndex = `
+CREATE INDEX IF NOT EXISTS idx_refresh_tokens_family_id ON refresh_tokens(family_id);`
+
+const createRefreshTokensUserIndex = `
+CREATE INDEX IF NOT EXISTS idx_refresh_tokens_user_id ON refresh_tokens(user_id, expires_at);`
+
+const createAPITokensTable = `
+CREATE TABLE IF NOT EXISTS api_tokens (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    token_prefix TEXT NOT NULL,
+    token_hash TEXT UNIQUE NOT NULL,
+    scopes TEXT[] NOT NULL DEFAULT '{}',
+    created_at TIMESTAMP NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    last_used_at TIMESTAMP,
+    last_used_ip TEXT NOT NULL DEFAULT '',
+    revoked_at TIMESTAMP
+);`
+
+const createAPITokensUserIndex = `
+CREATE INDEX IF NOT EXISTS idx_api_tokens_user_id ON api_tokens(user_id, created_at DESC);`
+
+const createCoachingLinksTable = `
+CREATE TABLE IF NOT EXISTS coaching_links (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    athlete_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    coach_id UUID REFERENCES users(id) ON DELETE CASCADE,
+    status TEXT NOT NULL,
+    permissions TEXT[] NOT NULL DEFAULT '{}',
+    invite_hash TEXT UNIQUE,
+    invite_expires_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL,
+    accepted_at TIMESTAMP,
+    ended_at TIMESTAMP
+);`
+
+const createCoachingLinksActivePairIndex = `
+CREATE UNIQUE INDEX IF NOT EXISTS idx_coaching_links_active_pair ON coaching_links(coach_id, athlete_id) WHERE status = 'active';`
+
+const createCoachingLinksAthleteIndex = `
+CREATE INDEX IF NOT EXISTS idx_coaching_links_athlete_id ON coaching_links(athlete_id);`
+
+const addAthleteIdToSessions = `
+ALTER TABLE sessions 
+ADD COLUMN IF NOT EXISTS athlete_id UUID REFERENCES users(id) ON DELETE CASCADE;`
+
+const createCoachCommentsTable = `
+CREATE TABLE IF NOT EXISTS coach_comments (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    coach_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    athlete_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    session_id UUID REFERENCES sessions(id) ON DELETE CASCADE,
+    body TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL
+);`
+
+const createCoachCommentsAthleteIndex = `
+CREATE INDEX IF NOT EXISTS idx_coach_comments_athlete_id ON coach_comments(athlete_id, created_at DESC);`
+
+const createTeamsTable = `
+CREATE TABLE IF NOT EXISTS teams (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name TEXT NOT NULL,
+    owner_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    invite_hash TEXT UNIQUE,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL
+);`
+
+const createTeamMembersTable = `
+CREATE TABLE IF NOT EXISTS team_members (
+    team_id UUID NOT NULL REFERENCES teams(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    role TEXT NOT NULL CHECK (role IN ('owner', 'coach', 'athlete')),
+    joined_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (team_id, user_id)
+);`
+
+const createTeamMembersUserIndex = `
+CREATE INDEX IF NOT EXISTS idx_team_members_user_id ON team_members(user_id);`
+
+const addOrganizationToSessions = `
+ALTER TABLE sessions 
+ADD COLUMN IF NOT EXISTS pinned BOOLEAN NOT NULL DEFAULT FALSE,
+ADD COLUMN IF NOT EXISTS archived_at TIMESTAMP,
+ADD COLUMN IF NOT EXISTS forked_from_session_id UUID REFERENCES sessions(id) ON DELETE SET NULL;`
+
+// The generated column keeps the full-text index in step with message content without triggers
+const addSearchVectorToMessages = `
+ALTER TABLE messages 
+ADD COLUMN IF NOT EXISTS search_vector tsvector GENERATED ALWAYS AS (to_tsvector('english', content)) STORED;`
+
+const createMessagesSearchIndex = `
+CREATE INDEX IF NOT EXISTS idx_messages_search_vector ON messages USING GIN (search_vector);`
+
+// Sessions titled by their user keep that title; the others get a generated one after the first reply
+const addTitleCustomizedToSessions = `
+ALTER TABLE sessions 
+ADD COLUMN IF NOT EXISTS title_customized BOOLEAN NOT NULL DEFAULT FALSE;`
+
+// Messages form a tree per session: editing or regenerating a message adds a sibling branch
+const addParentToMessages = `
+ALTER TABLE messages 
+ADD COLUMN IF NOT EXISTS parent_id UUID REFERENCES messages(id) ON DELETE CASCADE;`
+
+// The active message is the leaf of the branch the conversation continues from
+const addActiveMessageToSessions = `
+ALTER TABLE sessions 
+ADD COLUMN IF NOT EXISTS active_message_id UUID REFERENCES messages(id) ON DELETE SET NULL;`
+
+// Links the messages of sessions from before branching into a single chain. Sessions where any
+// message already has a parent are skipped, so branches created since are never rewritten.
+const backfillMessageParents = `
+UPDATE messages m
+SET parent_id = ordered.previous_id
+FROM (
+    SELECT id, LAG(id) OVER (PARTITION BY session_id ORDER BY created_at, id) AS previous_id
+    FROM messages
+) ordered
+WHERE m.id = ordered.id
+  AND ordered.previous_id IS NOT NULL
+  AND NOT EXISTS (
+    SELECT 1 FROM messages linked WHERE linked.session_id = m.session_id AND linked.parent_id IS NOT NULL
+  );`
+
+const backfillActiveMessages = `
+UPDATE sessions s
+SET active_message_id = (
+    SELECT m.id FROM messages m WHERE m.session_id = s.id ORDER BY m.created_at DESC, m.id DESC LIMIT 1
+)
+WHERE s.active_message_id IS NULL
+  AND EXISTS (SELECT 1 FROM messages m WHERE m.session_id = s.id);`
+
+const createMessagesParentIndex = `
+CREATE INDEX IF NOT EXISTS idx_messages_parent_id ON messages(parent_id);`
+
+// The model and tool calls that produced an assistant reply, kept for feedback evaluation
+const addGenerationToMessages = `
+ALTER TABLE messages 
+ADD COLUMN IF NOT EXISTS generation JSONB;`
+
+const createMessageFeedbackTable = `
+CREATE TABLE IF NOT EXISTS message_feedback (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    message_id UUID NOT NULL REFERENCES messages(id) ON DELETE CASCADE,
+    session_id UUID NOT NULL REFERENCES sessions(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    rating TEXT NOT NULL CHECK (rating IN ('up', 'down')),
+    reasons TEXT[] NOT NULL DEFAULT '{}',
+    comment TEXT NOT NULL DEFAULT '',
+    generation JSONB,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    UNIQUE (message_id, user_id)
+);`
+
+const createMessageFeedbackUpdatedIndex = `
+CREATE INDEX IF NOT EXISTS idx_message_feedback_updated_at ON message_feedback(updated_at);`
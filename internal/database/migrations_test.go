@@ -60,6 +60,96 @@ func TestMigrationQueries(t *testing.T) {
 		assert.Contains(t, addLastResponseIdToSessions, "ALTER TABLE sessions")
 		assert.Contains(t, addLastResponseIdToSessions, "ADD COLUMN IF NOT EXISTS last_response_id TEXT")
 	})
+
+	t.Run("Add summary to sessions migration", func(t *testing.T) {
+		assert.Contains(t, addSummaryToSessions, "ALTER TABLE sessions")
+		assert.Contains(t, addSummaryToSessions, "ADD COLUMN IF NOT EXISTS summary TEXT")
+		assert.Contains(t, addSummaryToSessions, "ADD COLUMN IF NOT EXISTS summarized_message_count INTEGER NOT NULL DEFAULT 0")
+	})
+
+	t.Run("Token usage table migration", func(t *testing.T) {
+		assert.Contains(t, createTokenUsageTable, "CREATE TABLE IF NOT EXISTS token_usage")
+		assert.Contains(t, createTokenUsageTable, "user_id UUID REFERENCES users(id) ON DELETE CASCADE")
+		assert.Contains(t, createTokenUsageTable, "PRIMARY KEY (user_id, session_id, usage_date)")
+		assert.NotContains(t, createTokenUsageTable, "REFERENCES sessions(id)")
+		assert.Contains(t, createTokenUsageDateIndex, "CREATE INDEX IF NOT EXISTS")
+	})
+
+	t.Run("Tool result cache table migration", func(t *testing.T) {
+		assert.Contains(t, createToolResultCacheTable, "CREATE TABLE IF NOT EXISTS tool_result_cache")
+		assert.Contains(t, createToolResultCacheTable, "cache_key TEXT PRIMARY KEY")
+		assert.Contains(t, createToolResultCacheTable, "user_id UUID REFERENCES users(id) ON DELETE CASCADE")
+		assert.Contains(t, createToolResultCacheTable, "expires_at TIMESTAMP NOT NULL")
+		assert.Contains(t, createToolResultCacheExpiryIndex, "CREATE INDEX IF NOT EXISTS")
+	})
+
+	t.Run("Daily wellness table migration", func(t *testing.T) {
+		assert.Contains(t, createDailyWellnessTable, "CREATE TABLE IF NOT EXISTS daily_wellness")
+		assert.Contains(t, createDailyWellnessTable, "user_id UUID REFERENCES users(id) ON DELETE CASCADE")
+		assert.Contains(t, createDailyWellnessTable, "PRIMARY KEY (user_id, metric_date)")
+		assert.Contains(t, createDailyWellnessTable, "CHECK (fatigue BETWEEN 1 AND 5)")
+	})
+
+	t.Run("Refresh tokens table migration", func(t *testing.T) {
+		assert.Contains(t, createRefreshTokensTable, "CREATE TABLE IF NOT EXISTS refresh_tokens")
+		assert.Contains(t, createRefreshTokensTable, "user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE")
+		assert.Contains(t, createRefreshTokensTable, "token_hash TEXT UNIQUE NOT NULL")
+		assert.Contains(t, createRefreshTokensFamilyIndex, "ON refresh_tokens(family_id)")
+	})
+
+	t.Run("API tokens table migration", func(t *testing.T) {
+		assert.Contains(t, createAPITokensTable, "CREATE TABLE IF NOT EXISTS api_tokens")
+		assert.Contains(t, createAPITokensTable, "user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE")
+		assert.Contains(t, createAPITokensTable, "token_hash TEXT UNIQUE NOT NULL")
+		assert.Contains(t, createAPITokensTable, "scopes TEXT[] NOT NULL")
+		assert.Contains(t, createAPITokensUserIndex, "ON api_tokens(user_id, created_at DESC)")
+	})
+
+	t.Run("Coaching migrations", func(t *testing.T) {
+		assert.Contains(t, createCoachingLinksTable, "CREATE TABLE IF NOT EXISTS coaching_links")
+		assert.Contains(t, createCoachingLinksTable, "coach_id UUID REFERENCES users(id) ON DELETE CASCADE")
+		assert.Contains(t, createCoachingLinksTable, "invite_hash TEXT UNIQUE")
+		assert.Contains(t, createCoachingLinksActivePairIndex, "WHERE status = 'active'")
+		assert.Contains(t, addAthleteIdToSessions, "ADD COLUMN IF NOT EXISTS athlete_id UUID")
+		assert.Contains(t, createCoachCommentsTable, "CREATE TABLE IF NOT EXISTS coach_comments")
+		assert.Contains(t, createCoachCommentsTable, "session_id UUID REFERENCES sessions(id) ON DELETE CASCADE")
+	})
+
+	t.Run("Team migrations", func(t *testing.T) {
+		assert.Contains(t, createTeamsTable, "CREATE TABLE IF NOT EXISTS teams")
+		assert.Contains(t, createTeamsTable, "invite_hash TEXT UNIQUE")
+		assert.Contains(t, createTeamMembersTable, "team_id UUID NOT NULL REFERENCES teams(id) ON DELETE CASCADE")
+		assert.Contains(t, createTeamMembersTable, "PRIMARY KEY (team_id, user_id)")
+		assert.Contains(t, createTeamMembersUserIndex, "ON team_members(user_id)")
+	})
+
+	t.Run("Session organization and search migrations", func(t *testing.T) {
+		assert.Contains(t, addOrganizationToSessions, "ADD COLUMN IF NOT EXISTS pinned BOOLEAN NOT NULL DEFAULT FALSE")
+		assert.Contains(t, addOrganizationToSessions, "ADD COLUMN IF NOT EXISTS archived_at TIMESTAMP")
+		assert.Contains(t, addOrganizationToSessions, "REFERENCES sessions(id) ON DELETE SET NULL")
+		assert.Contains(t, addSearchVectorToMessages, "GENERATED ALWAYS AS (to_tsvector('english', content)) STORED")
+		assert.Contains(t, createMessagesSearchIndex, "USING GIN (search_vector)")
+	})
+
+	t.Run("Session title customization migration", func(t *testing.T) {
+		assert.Contains(t, addTitleCustomizedToSessions, "ADD COLUMN IF NOT EXISTS title_customized BOOLEAN NOT NULL DEFAULT FALSE")
+	})
+
+	t.Run("Message branching migrations", func(t *testing.T) {
+		assert.Contains(t, addParentToMessages, "parent_id UUID REFERENCES messages(id) ON DELETE CASCADE")
+		assert.Contains(t, addActiveMessageToSessions, "active_message_id UUID REFERENCES messages(id) ON DELETE SET NULL")
+		assert.Contains(t, backfillMessageParents, "linked.parent_id IS NOT NULL", "sessions already branching are not rewritten")
+		assert.Contains(t, backfillActiveMessages, "WHERE s.active_message_id IS NULL")
+		assert.Contains(t, createMessagesParentIndex, "ON messages(parent_id)")
+	})
+
+	t.Run("Message feedback migrations", func(t *testing.T) {
+		assert.Contains(t, addGenerationToMessages, "ADD COLUMN IF NOT EXISTS generation JSONB")
+		assert.Contains(t, createMessageFeedbackTable, "CREATE TABLE IF NOT EXISTS message_feedback")
+		assert.Contains(t, createMessageFeedbackTable, "CHECK (rating IN ('up', 'down'))")
+		assert.Contains(t, createMessageFeedbackTable, "UNIQUE (message_id, user_id)")
+		assert.Contains(t, createMessageFeedbackUpdatedIndex, "ON message_feedback(updated_at)")
+	})
 }
 
 func TestMigrationOrder(t *testing.T) {
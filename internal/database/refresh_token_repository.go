@@ -0,0 +1,206 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"bodda/internal/models"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// RefreshTokenRepository stores the refresh tokens behind login sessions
+type RefreshTokenRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewRefreshTokenRepository(db *pgxpool.Pool) *RefreshTokenRepository {
+	return &RefreshTokenRepository{db: db}
+}
+
+// refreshTokenColumns selects a token in the order expected by scanRefreshToken
+const refreshTokenColumns = `id, user_id, family_id, token_hash, device_name, ip_address, user_agent,
+	session_started_at, created_at, last_used_at, expires_at, rotated_at, revoked_at, revoked_reason`
+
+const insertRefreshTokenQuery = `
+		INSERT INTO refresh_tokens (user_id, family_id, token_hash, device_name, ip_address, user_agent,
+			session_started_at, created_at, last_used_at, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $8, $9)
+		RETURNING id`
+
+// Create stores the first token of a new login session
+func (r *RefreshTokenRepository) Create(ctx context.Context, token *models.RefreshToken) error {
+	if err := r.db.QueryRow(ctx, insertRefreshTokenQuery, refreshTokenArgs(token)...).Scan(&token.ID); err != nil {
+		return fmt.Errorf("failed to create refresh token: %w", err)
+	}
+
+	return nil
+}
+
+// GetByHash returns the token with the given hash, or nil when there is none
+func (r *RefreshTokenRepository) GetByHash(ctx context.Context, tokenHash string) (*models.RefreshToken, error) {
+	query := `SELECT ` + refreshTokenColumns + ` FROM refresh_tokens WHERE token_hash = $1`
+
+	token := &models.RefreshToken{}
+	if err := scanRefreshToken(r.db.QueryRow(ctx, query, tokenHash), token); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get refresh token: %w", err)
+	}
+
+	return token, nil
+}
+
+// Rotate marks the token with currentID as used and stores next as its successor. It returns false
+// without storing next when the current token was already rotated or revoked, which happens when
+// two requests refresh with the same token at once.
+func (r *RefreshTokenRepository) Rotate(ctx context.Context, currentID string, next *models.RefreshToken) (bool, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to begin refresh token rotation: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	result, err := tx.Exec(ctx, `
+		UPDATE refresh_tokens SET rotated_at = $2, last_used_at = $2
+		WHERE id = $1 AND rotated_at IS NULL AND revoked_at IS NULL`, currentID, next.CreatedAt)
+	if err != nil {
+		return false, fmt.Errorf("failed to rotate refresh token: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return false, nil
+	}
+
+	if err := tx.QueryRow(ctx, insertRefreshTokenQuery, refreshTokenArgs(next)...).Scan(&next.ID); err != nil {
+		return false, fmt.Errorf("failed to store rotated refresh token: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return false, fmt.Errorf("failed to commit refresh token rotation: %w", err)
+	}
+
+	return true, nil
+}
+
+// IsFamilyActive reports whether the login session still has a usable token
+func (r *RefreshTokenRepository) IsFamilyActive(ctx context.Context, familyID string, at time.Time) (bool, error) {
+	query := `
+		SELECT EXISTS (
+			SELECT 1 FROM refresh_tokens
+			WHERE family_id = $1 AND rotated_at IS NULL AND revoked_at IS NULL AND expires_at > $2
+		)`
+
+	var active bool
+	if err := r.db.QueryRow(ctx, query, familyID, at).Scan(&active); err != nil {
+		return false, fmt.Errorf("failed to check login session: %w", err)
+	}
+
+	return active, nil
+}
+
+// ListActive returns the current token of each of the user's active login sessions, most recently
+// used first
+func (r *RefreshTokenRepository) ListActive(ctx context.Context, userID string, at time.Time) ([]*models.RefreshToken, error) {
+	query := `
+		SELECT ` + refreshTokenColumns + `
+		FROM refresh_tokens
+		WHERE user_id = $1 AND rotated_at IS NULL AND revoked_at IS NULL AND expires_at > $2
+		ORDER BY last_used_at DESC`
+
+	rows, err := r.db.Query(ctx, query, userID, at)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list login sessions: %w", err)
+	}
+	defer rows.Close()
+
+	var tokens []*models.RefreshToken
+	for rows.Next() {
+		token := &models.RefreshToken{}
+		if err := scanRefreshToken(rows, token); err != nil {
+			return nil, fmt.Errorf("failed to scan refresh token: %w", err)
+		}
+		tokens = append(tokens, token)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating refresh tokens: %w", err)
+	}
+
+	return tokens, nil
+}
+
+// RevokeFamily revokes every token of a login session, returning false when it was already revoked
+func (r *RefreshTokenRepository) RevokeFamily(ctx context.Context, userID, familyID, reason string, at time.Time) (bool, error) {
+	result, err := r.db.Exec(ctx, `
+		UPDATE refresh_tokens SET revoked_at = $3, revoked_reason = $4
+		WHERE user_id = $1 AND family_id = $2 AND revoked_at IS NULL`, userID, familyID, at, reason)
+	if err != nil {
+		return false, fmt.Errorf("failed to revoke login session: %w", err)
+	}
+
+	return result.RowsAffected() > 0, nil
+}
+
+// RevokeAllForUser revokes every login session of the user and returns how many were active
+func (r *RefreshTokenRepository) RevokeAllForUser(ctx context.Context, userID, reason string, at time.Time) (int, error) {
+	query := `
+		WITH revoked AS (
+			UPDATE refresh_tokens SET revoked_at = $2, revoked_reason = $3
+			WHERE user_id = $1 AND revoked_at IS NULL
+			RETURNING family_id, rotated_at, expires_at
+		)
+		SELECT COUNT(DISTINCT family_id) FROM revoked WHERE rotated_at IS NULL AND expires_at > $2`
+
+	var sessions int
+	if err := r.db.QueryRow(ctx, query, userID, at, reason).Scan(&sessions); err != nil {
+		return 0, fmt.Errorf("failed to revoke login sessions: %w", err)
+	}
+
+	return sessions, nil
+}
+
+// DeleteExpired removes tokens that expired before at and returns how many were deleted
+func (r *RefreshTokenRepository) DeleteExpired(ctx context.Context, at time.Time) (int64, error) {
+	result, err := r.db.Exec(ctx, `DELETE FROM refresh_tokens WHERE expires_at <= $1`, at)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete expired refresh tokens: %w", err)
+	}
+
+	return result.RowsAffected(), nil
+}
+
+func refreshTokenArgs(token *models.RefreshToken) []interface{} {
+	return []interface{}{
+		token.UserID,
+		token.FamilyID,
+		token.TokenHash,
+		token.DeviceName,
+		token.IPAddress,
+		token.UserAgent,
+		token.SessionStartedAt,
+		token.CreatedAt,
+		token.ExpiresAt,
+	}
+}
+
+func scanRefreshToken(row pgx.Row, token *models.RefreshToken) error {
+	return row.Scan(
+		&token.ID,
+		&token.UserID,
+		&token.FamilyID,
+		&token.TokenHash,
+		&token.DeviceName,
+		&token.IPAddress,
+		&token.UserAgent,
+		&token.SessionStartedAt,
+		&token.CreatedAt,
+		&token.LastUsedAt,
+		&token.ExpiresAt,
+		&token.RotatedAt,
+		&token.RevokedAt,
+		&token.RevokedReason,
+	)
+}
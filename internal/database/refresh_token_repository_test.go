@@ -0,0 +1,120 @@
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"bodda/internal/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+type RefreshTokenRepositoryTestSuite struct {
+	suite.Suite
+	repo     *RefreshTokenRepository
+	userRepo *UserRepository
+	db       *TestDB
+	testUser *models.User
+}
+
+func (suite *RefreshTokenRepositoryTestSuite) SetupSuite() {
+	suite.db = NewTestDB(suite.T())
+	suite.repo = NewRefreshTokenRepository(suite.db.Pool)
+	suite.userRepo = NewUserRepository(suite.db.Pool)
+}
+
+func (suite *RefreshTokenRepositoryTestSuite) TearDownSuite() {
+	suite.db.Close()
+}
+
+func (suite *RefreshTokenRepositoryTestSuite) SetupTest() {
+	suite.db.CleanTables()
+
+	suite.testUser = &models.User{
+		StravaID:     12345,
+		AccessToken:  "access_token_123",
+		RefreshToken: "refresh_token_123",
+		TokenExpiry:  time.Now().Add(time.Hour),
+		FirstName:    "John",
+		LastName:     "Doe",
+	}
+	require.NoError(suite.T(), suite.userRepo.Create(context.Background(), suite.testUser))
+}
+
+func (suite *RefreshTokenRepositoryTestSuite) newToken(familyID, hash string, now time.Time) *models.RefreshToken {
+	return &models.RefreshToken{
+		UserID:           suite.testUser.ID,
+		FamilyID:         familyID,
+		TokenHash:        hash,
+		DeviceName:       "Firefox on Linux",
+		IPAddress:        "203.0.113.7",
+		SessionStartedAt: now,
+		CreatedAt:        now,
+		LastUsedAt:       now,
+		ExpiresAt:        now.Add(24 * time.Hour),
+	}
+}
+
+func (suite *RefreshTokenRepositoryTestSuite) TestRotateOnce() {
+	ctx := context.Background()
+	now := time.Now().UTC().Truncate(time.Second)
+	familyID := uuid.New().String()
+
+	first := suite.newToken(familyID, "hash-1", now)
+	require.NoError(suite.T(), suite.repo.Create(ctx, first))
+	assert.NotEmpty(suite.T(), first.ID)
+
+	rotated, err := suite.repo.Rotate(ctx, first.ID, suite.newToken(familyID, "hash-2", now.Add(time.Minute)))
+	require.NoError(suite.T(), err)
+	assert.True(suite.T(), rotated)
+
+	rotated, err = suite.repo.Rotate(ctx, first.ID, suite.newToken(familyID, "hash-3", now.Add(time.Minute)))
+	require.NoError(suite.T(), err)
+	assert.False(suite.T(), rotated, "a token is exchanged only once")
+
+	stored, err := suite.repo.GetByHash(ctx, "hash-1")
+	require.NoError(suite.T(), err)
+	require.NotNil(suite.T(), stored.RotatedAt)
+
+	missing, err := suite.repo.GetByHash(ctx, "hash-3")
+	require.NoError(suite.T(), err)
+	assert.Nil(suite.T(), missing)
+
+	active, err := suite.repo.ListActive(ctx, suite.testUser.ID, now)
+	require.NoError(suite.T(), err)
+	require.Len(suite.T(), active, 1)
+	assert.Equal(suite.T(), "hash-2", active[0].TokenHash)
+	assert.Equal(suite.T(), now, active[0].SessionStartedAt)
+}
+
+func (suite *RefreshTokenRepositoryTestSuite) TestRevocation() {
+	ctx := context.Background()
+	now := time.Now().UTC().Truncate(time.Second)
+	laptop, phone := uuid.New().String(), uuid.New().String()
+
+	require.NoError(suite.T(), suite.repo.Create(ctx, suite.newToken(laptop, "laptop", now)))
+	require.NoError(suite.T(), suite.repo.Create(ctx, suite.newToken(phone, "phone", now)))
+
+	revoked, err := suite.repo.RevokeFamily(ctx, suite.testUser.ID, laptop, "logout", now)
+	require.NoError(suite.T(), err)
+	assert.True(suite.T(), revoked)
+
+	active, err := suite.repo.IsFamilyActive(ctx, laptop, now)
+	require.NoError(suite.T(), err)
+	assert.False(suite.T(), active)
+
+	count, err := suite.repo.RevokeAllForUser(ctx, suite.testUser.ID, "sign_out_everywhere", now)
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 1, count, "the laptop was already signed out")
+
+	deleted, err := suite.repo.DeleteExpired(ctx, now.Add(48*time.Hour))
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), int64(2), deleted)
+}
+
+func TestRefreshTokenRepositoryTestSuite(t *testing.T) {
+	suite.Run(t, new(RefreshTokenRepositoryTestSuite))
+}
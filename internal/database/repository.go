@@ -18,18 +18,35 @@ type LogbookRepositoryInterface interface {
 
 // Repository provides access to all database repositories
 type Repository struct {
-	User     *UserRepository
-	Session  *SessionRepository
-	Message  *MessageRepository
-	Logbook  *LogbookRepository
+	User      *UserRepository
+	Session   *SessionRepository
+	Message   *MessageRepository
+	Logbook   *LogbookRepository
+	Usage     *UsageRepository
+	ToolCache *ToolCacheRepository
+	Wellness  *WellnessRepository
+	Refresh   *RefreshTokenRepository
+	APIToken  *APITokenRepository
+	Coaching  *CoachingRepository
+	Team      *TeamRepository
+	Feedback  *FeedbackRepository
 }
 
-// NewRepository creates a new repository instance with all sub-repositories
-func NewRepository(db *pgxpool.Pool) *Repository {
+// NewRepository creates a new repository instance with all sub-repositories. userOpts configure
+// the user repository, e.g. WithTokenCipher to encrypt Strava tokens at rest.
+func NewRepository(db *pgxpool.Pool, userOpts ...UserRepositoryOption) *Repository {
 	return &Repository{
-		User:     NewUserRepository(db),
-		Session:  NewSessionRepository(db),
-		Message:  NewMessageRepository(db),
-		Logbook:  NewLogbookRepository(db),
+		User:      NewUserRepository(db, userOpts...),
+		Session:   NewSessionRepository(db),
+		Message:   NewMessageRepository(db),
+		Logbook:   NewLogbookRepository(db),
+		Usage:     NewUsageRepository(db),
+		ToolCache: NewToolCacheRepository(db),
+		Wellness:  NewWellnessRepository(db),
+		Refresh:   NewRefreshTokenRepository(db),
+		APIToken:  NewAPITokenRepository(db),
+		Coaching:  NewCoachingRepository(db),
+		Team:      NewTeamRepository(db),
+		Feedback:  NewFeedbackRepository(db),
 	}
 }
\ No newline at end of file
@@ -17,16 +17,25 @@ func NewSessionRepository(db *pgxpool.Pool) *SessionRepository {
 	return &SessionRepository{db: db}
 }
 
+// sessionColumns lists the session columns in the order expected by scanSession
+const sessionColumns = `id, user_id, title, title_customized, last_response_id, summary, summarized_message_count, athlete_id,
+			pinned, archived_at, forked_from_session_id, active_message_id, created_at, updated_at`
+
+const prefixedSessionColumns = `s.id, s.user_id, s.title, s.title_customized, s.last_response_id, s.summary, s.summarized_message_count, s.athlete_id,
+			s.pinned, s.archived_at, s.forked_from_session_id, s.active_message_id, s.created_at, s.updated_at`
+
 func (r *SessionRepository) Create(ctx context.Context, session *models.Session) error {
 	query := `
-		INSERT INTO sessions (user_id, title, last_response_id)
-		VALUES ($1, $2, $3)
+		INSERT INTO sessions (user_id, title, title_customized, last_response_id, athlete_id)
+		VALUES ($1, $2, $3, $4, $5)
 		RETURNING id, created_at, updated_at`
 
 	err := r.db.QueryRow(ctx, query,
 		session.UserID,
 		session.Title,
+		session.TitleCustomized,
 		session.LastResponseID,
+		session.AthleteID,
 	).Scan(&session.ID, &session.CreatedAt, &session.UpdatedAt)
 
 	if err != nil {
@@ -39,17 +48,10 @@ func (r *SessionRepository) Create(ctx context.Context, session *models.Session)
 func (r *SessionRepository) GetByID(ctx context.Context, id string) (*models.Session, error) {
 	session := &models.Session{}
 	query := `
-		SELECT id, user_id, title, last_response_id, created_at, updated_at
+		SELECT ` + sessionColumns + `
 		FROM sessions WHERE id = $1`
 
-	err := r.db.QueryRow(ctx, query, id).Scan(
-		&session.ID,
-		&session.UserID,
-		&session.Title,
-		&session.LastResponseID,
-		&session.CreatedAt,
-		&session.UpdatedAt,
-	)
+	err := scanSession(r.db.QueryRow(ctx, query, id), session)
 
 	if err != nil {
 		if err == pgx.ErrNoRows {
@@ -61,14 +63,30 @@ func (r *SessionRepository) GetByID(ctx context.Context, id string) (*models.Ses
 	return session, nil
 }
 
+// GetByUserID returns all of the user's sessions, pinned first and then most recently updated
 func (r *SessionRepository) GetByUserID(ctx context.Context, userID string) ([]*models.Session, error) {
 	query := `
-		SELECT id, user_id, title, last_response_id, created_at, updated_at
+		SELECT ` + sessionColumns + `
 		FROM sessions 
 		WHERE user_id = $1 
-		ORDER BY updated_at DESC`
+		ORDER BY pinned DESC, updated_at DESC`
+
+	return r.list(ctx, query, userID)
+}
+
+// ListByUser returns the user's active sessions, or only the archived ones when archived is true
+func (r *SessionRepository) ListByUser(ctx context.Context, userID string, archived bool) ([]*models.Session, error) {
+	query := `
+		SELECT ` + sessionColumns + `
+		FROM sessions 
+		WHERE user_id = $1 AND (archived_at IS NOT NULL) = $2
+		ORDER BY pinned DESC, updated_at DESC`
+
+	return r.list(ctx, query, userID, archived)
+}
 
-	rows, err := r.db.Query(ctx, query, userID)
+func (r *SessionRepository) list(ctx context.Context, query string, args ...interface{}) ([]*models.Session, error) {
+	rows, err := r.db.Query(ctx, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get sessions: %w", err)
 	}
@@ -77,15 +95,7 @@ func (r *SessionRepository) GetByUserID(ctx context.Context, userID string) ([]*
 	var sessions []*models.Session
 	for rows.Next() {
 		session := &models.Session{}
-		err := rows.Scan(
-			&session.ID,
-			&session.UserID,
-			&session.Title,
-			&session.LastResponseID,
-			&session.CreatedAt,
-			&session.UpdatedAt,
-		)
-		if err != nil {
+		if err := scanSession(rows, session); err != nil {
 			return nil, fmt.Errorf("failed to scan session: %w", err)
 		}
 		sessions = append(sessions, session)
@@ -98,16 +108,20 @@ func (r *SessionRepository) GetByUserID(ctx context.Context, userID string) ([]*
 	return sessions, nil
 }
 
+// Update stores the session's title, pin and archive state
 func (r *SessionRepository) Update(ctx context.Context, session *models.Session) error {
 	query := `
 		UPDATE sessions 
-		SET title = $2, updated_at = NOW()
+		SET title = $2, title_customized = $3, pinned = $4, archived_at = $5, updated_at = NOW()
 		WHERE id = $1
 		RETURNING updated_at`
 
 	err := r.db.QueryRow(ctx, query,
 		session.ID,
 		session.Title,
+		session.TitleCustomized,
+		session.Pinned,
+		session.ArchivedAt,
 	).Scan(&session.UpdatedAt)
 
 	if err != nil {
@@ -117,6 +131,19 @@ func (r *SessionRepository) Update(ctx context.Context, session *models.Session)
 	return nil
 }
 
+// UpdateGeneratedTitle replaces the default title with a generated one. It returns false when the
+// session no longer has the default title, e.g. because the user renamed it in the meantime.
+func (r *SessionRepository) UpdateGeneratedTitle(ctx context.Context, sessionID, title, defaultTitle string) (bool, error) {
+	result, err := r.db.Exec(ctx, `
+		UPDATE sessions SET title = $2, updated_at = NOW()
+		WHERE id = $1 AND NOT title_customized AND title = $3`, sessionID, title, defaultTitle)
+	if err != nil {
+		return false, fmt.Errorf("failed to update session title: %w", err)
+	}
+
+	return result.RowsAffected() > 0, nil
+}
+
 func (r *SessionRepository) UpdateLastResponseID(ctx context.Context, sessionID string, responseID string) error {
 	query := `
 		UPDATE sessions 
@@ -135,6 +162,26 @@ func (r *SessionRepository) UpdateLastResponseID(ctx context.Context, sessionID
 	return nil
 }
 
+// UpdateSummary stores the rolling conversation summary and how many leading messages it covers.
+// The last_response_id is cleared so the next request rebuilds context from the summary.
+func (r *SessionRepository) UpdateSummary(ctx context.Context, sessionID string, summary string, summarizedMessageCount int) error {
+	query := `
+		UPDATE sessions 
+		SET summary = $2, summarized_message_count = $3, last_response_id = NULL, updated_at = NOW()
+		WHERE id = $1`
+
+	result, err := r.db.Exec(ctx, query, sessionID, summary, summarizedMessageCount)
+	if err != nil {
+		return fmt.Errorf("failed to update session summary: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return fmt.Errorf("session not found")
+	}
+
+	return nil
+}
+
 func (r *SessionRepository) Delete(ctx context.Context, id string) error {
 	query := `DELETE FROM sessions WHERE id = $1`
 	
@@ -148,4 +195,173 @@ func (r *SessionRepository) Delete(ctx context.Context, id string) error {
 	}
 
 	return nil
-}
\ No newline at end of file
+}
+
+// Search finds the user's sessions with messages matching a web-search style query, best match
+// first. Each result carries the best matching message of its session with the matched terms
+// between startSel and stopSel.
+func (r *SessionRepository) Search(ctx context.Context, userID, query, startSel, stopSel string, limit int) ([]*models.SessionSearchResult, error) {
+	sqlQuery := `
+		WITH q AS (SELECT websearch_to_tsquery('english', $2) AS query),
+		matches AS (
+			SELECT m.session_id, m.id, m.role, m.content, m.created_at,
+				ts_rank(m.search_vector, q.query) AS rank,
+				COUNT(*) OVER (PARTITION BY m.session_id) AS match_count,
+				ROW_NUMBER() OVER (PARTITION BY m.session_id ORDER BY ts_rank(m.search_vector, q.query) DESC, m.created_at DESC) AS position
+			FROM messages m
+			JOIN sessions s ON s.id = m.session_id
+			CROSS JOIN q
+			WHERE s.user_id = $1 AND m.search_vector @@ q.query
+		)
+		SELECT ` + prefixedSessionColumns + `,
+			matches.id, matches.role, matches.created_at, matches.match_count,
+			ts_headline('english', matches.content, q.query, $4)
+		FROM matches
+		JOIN sessions s ON s.id = matches.session_id
+		CROSS JOIN q
+		WHERE matches.position = 1
+		ORDER BY matches.rank DESC, s.updated_at DESC
+		LIMIT $3`
+
+	options := fmt.Sprintf(`StartSel="%s", StopSel="%s", MaxWords=35, MinWords=15, MaxFragments=2, FragmentDelimiter=" ... "`, startSel, stopSel)
+	rows, err := r.db.Query(ctx, sqlQuery, userID, query, limit, options)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search sessions: %w", err)
+	}
+	defer rows.Close()
+
+	var results []*models.SessionSearchResult
+	for rows.Next() {
+		session := &models.Session{}
+		result := &models.SessionSearchResult{Session: session}
+		if err := rows.Scan(
+			&session.ID,
+			&session.UserID,
+			&session.Title,
+			&session.TitleCustomized,
+			&session.LastResponseID,
+			&session.Summary,
+			&session.SummarizedMessageCount,
+			&session.AthleteID,
+			&session.Pinned,
+			&session.ArchivedAt,
+			&session.ForkedFromSessionID,
+			&session.ActiveMessageID,
+			&session.CreatedAt,
+			&session.UpdatedAt,
+			&result.MessageID,
+			&result.MessageRole,
+			&result.MessageCreatedAt,
+			&result.MatchCount,
+			&result.Snippet,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan session search result: %w", err)
+		}
+		results = append(results, result)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating session search results: %w", err)
+	}
+
+	return results, nil
+}
+
+// Fork stores fork as a new session holding a copy of the source session's messages up to and
+// including upToMessageID, following that message's branch. It returns false without creating the
+// session when the message is not part of the source session.
+func (r *SessionRepository) Fork(ctx context.Context, fork *models.Session, sourceID, upToMessageID string) (bool, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to begin session fork: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	rows, err := tx.Query(ctx, `
+		WITH RECURSIVE path AS (
+			SELECT id, parent_id, 0 AS depth FROM messages WHERE id = $1 AND session_id = $2
+			UNION ALL
+			SELECT m.id, m.parent_id, path.depth + 1
+			FROM messages m JOIN path ON m.id = path.parent_id
+		)
+		SELECT m.role, m.content, m.created_at
+		FROM path JOIN messages m ON m.id = path.id
+		ORDER BY path.depth DESC`, upToMessageID, sourceID)
+	if err != nil {
+		return false, fmt.Errorf("failed to find fork messages: %w", err)
+	}
+	var path []*models.Message
+	for rows.Next() {
+		message := &models.Message{}
+		if err := rows.Scan(&message.Role, &message.Content, &message.CreatedAt); err != nil {
+			rows.Close()
+			return false, fmt.Errorf("failed to scan fork message: %w", err)
+		}
+		path = append(path, message)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return false, fmt.Errorf("error iterating fork messages: %w", err)
+	}
+	if len(path) == 0 {
+		return false, nil
+	}
+
+	err = tx.QueryRow(ctx, `
+		INSERT INTO sessions (user_id, title, title_customized, athlete_id, forked_from_session_id)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at, updated_at`,
+		fork.UserID,
+		fork.Title,
+		fork.TitleCustomized,
+		fork.AthleteID,
+		fork.ForkedFromSessionID,
+	).Scan(&fork.ID, &fork.CreatedAt, &fork.UpdatedAt)
+	if err != nil {
+		return false, fmt.Errorf("failed to create forked session: %w", err)
+	}
+
+	// Response IDs are not copied: the fork starts a new response chain from its own history
+	var parentID *string
+	for _, message := range path {
+		var id string
+		err := tx.QueryRow(ctx, `
+			INSERT INTO messages (session_id, parent_id, role, content, created_at)
+			VALUES ($1, $2, $3, $4, $5)
+			RETURNING id`, fork.ID, parentID, message.Role, message.Content, message.CreatedAt).Scan(&id)
+		if err != nil {
+			return false, fmt.Errorf("failed to copy messages into forked session: %w", err)
+		}
+		parentID = &id
+	}
+
+	if _, err := tx.Exec(ctx, `UPDATE sessions SET active_message_id = $2 WHERE id = $1`, fork.ID, parentID); err != nil {
+		return false, fmt.Errorf("failed to set forked session active message: %w", err)
+	}
+	fork.ActiveMessageID = parentID
+
+	if err := tx.Commit(ctx); err != nil {
+		return false, fmt.Errorf("failed to commit session fork: %w", err)
+	}
+
+	return true, nil
+}
+
+func scanSession(row pgx.Row, session *models.Session) error {
+	return row.Scan(
+		&session.ID,
+		&session.UserID,
+		&session.Title,
+		&session.TitleCustomized,
+		&session.LastResponseID,
+		&session.Summary,
+		&session.SummarizedMessageCount,
+		&session.AthleteID,
+		&session.Pinned,
+		&session.ArchivedAt,
+		&session.ForkedFromSessionID,
+		&session.ActiveMessageID,
+		&session.CreatedAt,
+		&session.UpdatedAt,
+	)
+}
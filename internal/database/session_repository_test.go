@@ -7,6 +7,7 @@ import (
 
 	"bodda/internal/models"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
 )
 
@@ -177,6 +178,30 @@ func (suite *SessionRepositoryTestSuite) TestUpdateSession() {
 	assert.Equal(suite.T(), "Updated Title", retrievedSession.Title)
 }
 
+func (suite *SessionRepositoryTestSuite) TestUpdateGeneratedTitle() {
+	ctx := context.Background()
+	session := &models.Session{UserID: suite.testUser.ID, Title: "New Conversation"}
+	require.NoError(suite.T(), suite.repo.Create(ctx, session))
+
+	updated, err := suite.repo.UpdateGeneratedTitle(ctx, session.ID, "Marathon taper plan", "New Conversation")
+	require.NoError(suite.T(), err)
+	assert.True(suite.T(), updated)
+
+	updated, err = suite.repo.UpdateGeneratedTitle(ctx, session.ID, "Another title", "New Conversation")
+	require.NoError(suite.T(), err)
+	assert.False(suite.T(), updated, "generated titles are not replaced")
+
+	named := &models.Session{UserID: suite.testUser.ID, Title: "New Conversation", TitleCustomized: true}
+	require.NoError(suite.T(), suite.repo.Create(ctx, named))
+	updated, err = suite.repo.UpdateGeneratedTitle(ctx, named.ID, "Marathon taper plan", "New Conversation")
+	require.NoError(suite.T(), err)
+	assert.False(suite.T(), updated, "titles the user chose are kept")
+
+	stored, err := suite.repo.GetByID(ctx, named.ID)
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "New Conversation", stored.Title)
+}
+
 func (suite *SessionRepositoryTestSuite) TestDeleteSession() {
 	// Create a session first
 	session := &models.Session{
@@ -292,6 +317,136 @@ func (suite *SessionRepositoryTestSuite) TestGetNonExistentSession() {
 	assert.Contains(suite.T(), err.Error(), "session not found")
 }
 
+func (suite *SessionRepositoryTestSuite) createSessionWithMessages(title string, contents ...string) (*models.Session, []*models.Message) {
+	ctx := context.Background()
+	session := &models.Session{UserID: suite.testUser.ID, Title: title}
+	assert.NoError(suite.T(), suite.repo.Create(ctx, session))
+
+	messageRepo := NewMessageRepository(suite.db.Pool)
+	var messages []*models.Message
+	for i, content := range contents {
+		role := "user"
+		if i%2 == 1 {
+			role = "assistant"
+		}
+		message := &models.Message{SessionID: session.ID, Role: role, Content: content}
+		assert.NoError(suite.T(), messageRepo.Create(ctx, message))
+		messages = append(messages, message)
+	}
+
+	return session, messages
+}
+
+func (suite *SessionRepositoryTestSuite) TestPinAndArchive() {
+	ctx := context.Background()
+	pinned, _ := suite.createSessionWithMessages("Pinned")
+	archived, _ := suite.createSessionWithMessages("Archived")
+	suite.createSessionWithMessages("Latest")
+
+	pinned.Pinned = true
+	assert.NoError(suite.T(), suite.repo.Update(ctx, pinned))
+	archivedAt := time.Now()
+	archived.ArchivedAt = &archivedAt
+	assert.NoError(suite.T(), suite.repo.Update(ctx, archived))
+
+	active, err := suite.repo.ListByUser(ctx, suite.testUser.ID, false)
+	assert.NoError(suite.T(), err)
+	if assert.Len(suite.T(), active, 2) {
+		assert.Equal(suite.T(), "Pinned", active[0].Title, "pinned sessions come first")
+		assert.True(suite.T(), active[0].Pinned)
+		assert.Equal(suite.T(), "Latest", active[1].Title)
+	}
+
+	archivedSessions, err := suite.repo.ListByUser(ctx, suite.testUser.ID, true)
+	assert.NoError(suite.T(), err)
+	if assert.Len(suite.T(), archivedSessions, 1) {
+		assert.Equal(suite.T(), archived.ID, archivedSessions[0].ID)
+		assert.NotNil(suite.T(), archivedSessions[0].ArchivedAt)
+	}
+
+	all, err := suite.repo.GetByUserID(ctx, suite.testUser.ID)
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), all, 3)
+}
+
+func (suite *SessionRepositoryTestSuite) TestSearch() {
+	ctx := context.Background()
+	tempo, _ := suite.createSessionWithMessages("Tempo",
+		"How should I pace my tempo runs?",
+		"Run tempo efforts at roughly your half marathon pace.",
+	)
+	suite.createSessionWithMessages("Recovery", "What should I eat after a long ride?")
+
+	results, err := suite.repo.Search(ctx, suite.testUser.ID, "tempo", "[", "]", 10)
+	assert.NoError(suite.T(), err)
+	if assert.Len(suite.T(), results, 1) {
+		assert.Equal(suite.T(), tempo.ID, results[0].Session.ID)
+		assert.Equal(suite.T(), 2, results[0].MatchCount)
+		assert.Contains(suite.T(), results[0].Snippet, "[tempo]")
+	}
+
+	results, err = suite.repo.Search(ctx, suite.testUser.ID, "swimming", "[", "]", 10)
+	assert.NoError(suite.T(), err)
+	assert.Empty(suite.T(), results)
+}
+
+func (suite *SessionRepositoryTestSuite) TestFork() {
+	ctx := context.Background()
+	source, messages := suite.createSessionWithMessages("Source", "first", "second", "third")
+
+	fork := &models.Session{UserID: suite.testUser.ID, Title: "Fork of Source", ForkedFromSessionID: &source.ID}
+	forked, err := suite.repo.Fork(ctx, fork, source.ID, messages[1].ID)
+	assert.NoError(suite.T(), err)
+	assert.True(suite.T(), forked)
+	assert.NotEmpty(suite.T(), fork.ID)
+
+	copied, err := NewMessageRepository(suite.db.Pool).GetBySessionID(ctx, fork.ID)
+	assert.NoError(suite.T(), err)
+	if assert.Len(suite.T(), copied, 2) {
+		assert.Equal(suite.T(), "first", copied[0].Content)
+		assert.Equal(suite.T(), "second", copied[1].Content)
+	}
+
+	stored, err := suite.repo.GetByID(ctx, fork.ID)
+	assert.NoError(suite.T(), err)
+	if assert.NotNil(suite.T(), stored.ForkedFromSessionID) {
+		assert.Equal(suite.T(), source.ID, *stored.ForkedFromSessionID)
+	}
+
+	other, otherMessages := suite.createSessionWithMessages("Other", "elsewhere")
+	forked, err = suite.repo.Fork(ctx, &models.Session{UserID: suite.testUser.ID, Title: "Fork"}, source.ID, otherMessages[0].ID)
+	assert.NoError(suite.T(), err)
+	assert.False(suite.T(), forked, "the message must belong to the source session")
+	assert.NotEmpty(suite.T(), other.ID)
+}
+
+func (suite *SessionRepositoryTestSuite) TestForkFollowsBranch() {
+	ctx := context.Background()
+	messageRepo := NewMessageRepository(suite.db.Pool)
+	source, messages := suite.createSessionWithMessages("Source", "first", "second")
+
+	// A later branch replaces the first message; forking the original keeps only its own branch
+	edited := &models.Message{SessionID: source.ID, Role: "user", Content: "first, edited"}
+	suite.Require().NoError(messageRepo.CreateBranch(ctx, edited))
+
+	fork := &models.Session{UserID: suite.testUser.ID, Title: "Fork of Source"}
+	forked, err := suite.repo.Fork(ctx, fork, source.ID, messages[1].ID)
+	suite.Require().NoError(err)
+	suite.Require().True(forked)
+
+	path, err := messageRepo.GetActivePath(ctx, fork.ID)
+	suite.Require().NoError(err)
+	if assert.Len(suite.T(), path, 2) {
+		assert.Equal(suite.T(), "first", path[0].Content)
+		assert.Equal(suite.T(), "second", path[1].Content)
+		assert.Empty(suite.T(), path[0].SiblingIDs)
+		suite.Require().NotNil(path[1].ParentID)
+		assert.Equal(suite.T(), path[0].ID, *path[1].ParentID)
+	}
+	suite.Require().NotNil(fork.ActiveMessageID)
+	assert.Equal(suite.T(), path[len(path)-1].ID, *fork.ActiveMessageID)
+}
+
 func TestSessionRepositoryTestSuite(t *testing.T) {
 	suite.Run(t, new(SessionRepositoryTestSuite))
 }
\ No newline at end of file
@@ -0,0 +1,300 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"bodda/internal/models"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// TeamRepository stores teams and their memberships
+type TeamRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewTeamRepository(db *pgxpool.Pool) *TeamRepository {
+	return &TeamRepository{db: db}
+}
+
+// Create stores a team and makes its owner the first member
+func (r *TeamRepository) Create(ctx context.Context, team *models.Team) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin team creation: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	err = tx.QueryRow(ctx, `
+		INSERT INTO teams (name, owner_id, invite_hash, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id`,
+		team.Name,
+		team.OwnerID,
+		team.InviteHash,
+		team.CreatedAt,
+		team.UpdatedAt,
+	).Scan(&team.ID)
+	if err != nil {
+		return fmt.Errorf("failed to create team: %w", err)
+	}
+
+	_, err = tx.Exec(ctx, `
+		INSERT INTO team_members (team_id, user_id, role, joined_at)
+		VALUES ($1, $2, 'owner', $3)`, team.ID, team.OwnerID, team.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to add team owner: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit team creation: %w", err)
+	}
+
+	return nil
+}
+
+// GetByID returns the team with the given ID, or nil when there is none
+func (r *TeamRepository) GetByID(ctx context.Context, id string) (*models.Team, error) {
+	return r.getOne(ctx, `WHERE id = $1`, id)
+}
+
+// GetByInviteHash returns the team whose join code has the given hash, or nil when there is none
+func (r *TeamRepository) GetByInviteHash(ctx context.Context, inviteHash string) (*models.Team, error) {
+	return r.getOne(ctx, `WHERE invite_hash = $1`, inviteHash)
+}
+
+func (r *TeamRepository) getOne(ctx context.Context, where string, args ...interface{}) (*models.Team, error) {
+	query := `
+		SELECT id, name, owner_id, invite_hash, created_at, updated_at
+		FROM teams ` + where
+
+	team := &models.Team{}
+	err := r.db.QueryRow(ctx, query, args...).Scan(
+		&team.ID,
+		&team.Name,
+		&team.OwnerID,
+		&team.InviteHash,
+		&team.CreatedAt,
+		&team.UpdatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get team: %w", err)
+	}
+
+	return team, nil
+}
+
+// ListForUser returns the teams the user belongs to with their role and the member count, by name
+func (r *TeamRepository) ListForUser(ctx context.Context, userID string) ([]*models.Team, error) {
+	query := `
+		SELECT t.id, t.name, t.owner_id, t.invite_hash, t.created_at, t.updated_at, m.role,
+			(SELECT COUNT(*) FROM team_members c WHERE c.team_id = t.id)
+		FROM teams t
+		JOIN team_members m ON m.team_id = t.id AND m.user_id = $1
+		ORDER BY t.name, t.created_at`
+
+	rows, err := r.db.Query(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list teams: %w", err)
+	}
+	defer rows.Close()
+
+	var teams []*models.Team
+	for rows.Next() {
+		team := &models.Team{}
+		if err := rows.Scan(
+			&team.ID,
+			&team.Name,
+			&team.OwnerID,
+			&team.InviteHash,
+			&team.CreatedAt,
+			&team.UpdatedAt,
+			&team.Role,
+			&team.MemberCount,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan team: %w", err)
+		}
+		teams = append(teams, team)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating teams: %w", err)
+	}
+
+	return teams, nil
+}
+
+// Rename changes the team's name
+func (r *TeamRepository) Rename(ctx context.Context, teamID, name string, at time.Time) error {
+	_, err := r.db.Exec(ctx, `UPDATE teams SET name = $2, updated_at = $3 WHERE id = $1`, teamID, name, at)
+	if err != nil {
+		return fmt.Errorf("failed to rename team: %w", err)
+	}
+
+	return nil
+}
+
+// SetInviteHash replaces the team's join code; nil disables joining
+func (r *TeamRepository) SetInviteHash(ctx context.Context, teamID string, inviteHash *string, at time.Time) error {
+	_, err := r.db.Exec(ctx, `UPDATE teams SET invite_hash = $2, updated_at = $3 WHERE id = $1`, teamID, inviteHash, at)
+	if err != nil {
+		return fmt.Errorf("failed to update team join code: %w", err)
+	}
+
+	return nil
+}
+
+// Delete removes the team and all of its memberships
+func (r *TeamRepository) Delete(ctx context.Context, teamID string) error {
+	_, err := r.db.Exec(ctx, `DELETE FROM teams WHERE id = $1`, teamID)
+	if err != nil {
+		return fmt.Errorf("failed to delete team: %w", err)
+	}
+
+	return nil
+}
+
+// GetMember returns the user's membership of the team, or nil when they are not a member
+func (r *TeamRepository) GetMember(ctx context.Context, teamID, userID string) (*models.TeamMember, error) {
+	member := &models.TeamMember{}
+	err := r.db.QueryRow(ctx, `
+		SELECT team_id, user_id, role, joined_at
+		FROM team_members
+		WHERE team_id = $1 AND user_id = $2`, teamID, userID).Scan(
+		&member.TeamID,
+		&member.UserID,
+		&member.Role,
+		&member.JoinedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get team member: %w", err)
+	}
+
+	return member, nil
+}
+
+// ListMembers returns the team's members with their names, staff first
+func (r *TeamRepository) ListMembers(ctx context.Context, teamID string) ([]*models.TeamMember, error) {
+	query := `
+		SELECT m.team_id, m.user_id, m.role, m.joined_at, TRIM(u.first_name || ' ' || u.last_name)
+		FROM team_members m
+		JOIN users u ON u.id = m.user_id
+		WHERE m.team_id = $1
+		ORDER BY CASE m.role WHEN 'owner' THEN 0 WHEN 'coach' THEN 1 ELSE 2 END, u.first_name, u.last_name`
+
+	rows, err := r.db.Query(ctx, query, teamID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list team members: %w", err)
+	}
+	defer rows.Close()
+
+	var members []*models.TeamMember
+	for rows.Next() {
+		member := &models.TeamMember{}
+		if err := rows.Scan(
+			&member.TeamID,
+			&member.UserID,
+			&member.Role,
+			&member.JoinedAt,
+			&member.Name,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan team member: %w", err)
+		}
+		members = append(members, member)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating team members: %w", err)
+	}
+
+	return members, nil
+}
+
+// CountMembers returns the number of members of the team, including its owner
+func (r *TeamRepository) CountMembers(ctx context.Context, teamID string) (int, error) {
+	var count int
+	if err := r.db.QueryRow(ctx, `SELECT COUNT(*) FROM team_members WHERE team_id = $1`, teamID).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count team members: %w", err)
+	}
+
+	return count, nil
+}
+
+// AddMember stores a membership unless the team already has maxMembers members. It returns false
+// when the user already belongs to the team or the team is full, along with the member count after
+// the call. The team row is locked so concurrent joins cannot overfill the team.
+func (r *TeamRepository) AddMember(ctx context.Context, member *models.TeamMember, maxMembers int) (bool, int, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return false, 0, fmt.Errorf("failed to begin adding team member: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var locked string
+	err = tx.QueryRow(ctx, `SELECT id FROM teams WHERE id = $1 FOR UPDATE`, member.TeamID).Scan(&locked)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return false, 0, fmt.Errorf("failed to add team member: team not found")
+		}
+		return false, 0, fmt.Errorf("failed to add team member: %w", err)
+	}
+
+	var count int
+	if err := tx.QueryRow(ctx, `SELECT COUNT(*) FROM team_members WHERE team_id = $1`, member.TeamID).Scan(&count); err != nil {
+		return false, 0, fmt.Errorf("failed to count team members: %w", err)
+	}
+	if count >= maxMembers {
+		return false, count, nil
+	}
+
+	result, err := tx.Exec(ctx, `
+		INSERT INTO team_members (team_id, user_id, role, joined_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (team_id, user_id) DO NOTHING`, member.TeamID, member.UserID, member.Role, member.JoinedAt)
+	if err != nil {
+		return false, 0, fmt.Errorf("failed to add team member: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return false, count, nil
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return false, 0, fmt.Errorf("failed to commit team member: %w", err)
+	}
+
+	return true, count + 1, nil
+}
+
+// UpdateMemberRole changes a member's role. The owner's membership cannot be changed, so false is
+// returned for the owner as well as for users who are not members.
+func (r *TeamRepository) UpdateMemberRole(ctx context.Context, teamID, userID, role string) (bool, error) {
+	result, err := r.db.Exec(ctx, `
+		UPDATE team_members SET role = $3
+		WHERE team_id = $1 AND user_id = $2 AND role <> 'owner'`, teamID, userID, role)
+	if err != nil {
+		return false, fmt.Errorf("failed to update team member role: %w", err)
+	}
+
+	return result.RowsAffected() > 0, nil
+}
+
+// RemoveMember removes a member other than the owner, returning false when there is no such member
+func (r *TeamRepository) RemoveMember(ctx context.Context, teamID, userID string) (bool, error) {
+	result, err := r.db.Exec(ctx, `
+		DELETE FROM team_members
+		WHERE team_id = $1 AND user_id = $2 AND role <> 'owner'`, teamID, userID)
+	if err != nil {
+		return false, fmt.Errorf("failed to remove team member: %w", err)
+	}
+
+	return result.RowsAffected() > 0, nil
+}
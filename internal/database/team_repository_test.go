@@ -0,0 +1,131 @@
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"bodda/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+type TeamRepositoryTestSuite struct {
+	suite.Suite
+	repo     *TeamRepository
+	userRepo *UserRepository
+	db       *TestDB
+	owner    *models.User
+	athlete  *models.User
+}
+
+func (suite *TeamRepositoryTestSuite) SetupSuite() {
+	suite.db = NewTestDB(suite.T())
+	suite.repo = NewTeamRepository(suite.db.Pool)
+	suite.userRepo = NewUserRepository(suite.db.Pool)
+}
+
+func (suite *TeamRepositoryTestSuite) TearDownSuite() {
+	suite.db.Close()
+}
+
+func (suite *TeamRepositoryTestSuite) SetupTest() {
+	suite.db.CleanTables()
+
+	suite.owner = &models.User{StravaID: 3001, AccessToken: "o", RefreshToken: "o", TokenExpiry: time.Now().Add(time.Hour), FirstName: "Olga", LastName: "Owner"}
+	suite.athlete = &models.User{StravaID: 3002, AccessToken: "a", RefreshToken: "a", TokenExpiry: time.Now().Add(time.Hour), FirstName: "Ada", LastName: "Athlete"}
+	require.NoError(suite.T(), suite.userRepo.Create(context.Background(), suite.owner))
+	require.NoError(suite.T(), suite.userRepo.Create(context.Background(), suite.athlete))
+}
+
+func (suite *TeamRepositoryTestSuite) createTeam() *models.Team {
+	now := time.Now().UTC().Truncate(time.Second)
+	hash := "join-hash"
+	team := &models.Team{Name: "Harriers", OwnerID: suite.owner.ID, InviteHash: &hash, CreatedAt: now, UpdatedAt: now}
+	require.NoError(suite.T(), suite.repo.Create(context.Background(), team))
+	return team
+}
+
+func (suite *TeamRepositoryTestSuite) TestCreateAddsOwner() {
+	ctx := context.Background()
+	team := suite.createTeam()
+	assert.NotEmpty(suite.T(), team.ID)
+
+	owner, err := suite.repo.GetMember(ctx, team.ID, suite.owner.ID)
+	require.NoError(suite.T(), err)
+	require.NotNil(suite.T(), owner)
+	assert.Equal(suite.T(), models.TeamRoleOwner, owner.Role)
+
+	found, err := suite.repo.GetByInviteHash(ctx, "join-hash")
+	require.NoError(suite.T(), err)
+	require.NotNil(suite.T(), found)
+	assert.Equal(suite.T(), team.ID, found.ID)
+
+	require.NoError(suite.T(), suite.repo.SetInviteHash(ctx, team.ID, nil, time.Now()))
+	found, err = suite.repo.GetByInviteHash(ctx, "join-hash")
+	require.NoError(suite.T(), err)
+	assert.Nil(suite.T(), found)
+}
+
+func (suite *TeamRepositoryTestSuite) TestMembership() {
+	ctx := context.Background()
+	team := suite.createTeam()
+	member := &models.TeamMember{TeamID: team.ID, UserID: suite.athlete.ID, Role: models.TeamRoleAthlete, JoinedAt: time.Now()}
+
+	added, count, err := suite.repo.AddMember(ctx, member, 30)
+	require.NoError(suite.T(), err)
+	assert.True(suite.T(), added)
+	assert.Equal(suite.T(), 2, count)
+
+	added, count, err = suite.repo.AddMember(ctx, member, 30)
+	require.NoError(suite.T(), err)
+	assert.False(suite.T(), added, "users join a team once")
+	assert.Equal(suite.T(), 2, count)
+
+	members, err := suite.repo.ListMembers(ctx, team.ID)
+	require.NoError(suite.T(), err)
+	require.Len(suite.T(), members, 2)
+	assert.Equal(suite.T(), "Olga Owner", members[0].Name)
+	assert.Equal(suite.T(), "Ada Athlete", members[1].Name)
+
+	teams, err := suite.repo.ListForUser(ctx, suite.athlete.ID)
+	require.NoError(suite.T(), err)
+	require.Len(suite.T(), teams, 1)
+	assert.Equal(suite.T(), models.TeamRoleAthlete, teams[0].Role)
+	assert.Equal(suite.T(), 2, teams[0].MemberCount)
+
+	updated, err := suite.repo.UpdateMemberRole(ctx, team.ID, suite.owner.ID, models.TeamRoleAthlete)
+	require.NoError(suite.T(), err)
+	assert.False(suite.T(), updated, "the owner's role cannot change")
+
+	updated, err = suite.repo.UpdateMemberRole(ctx, team.ID, suite.athlete.ID, models.TeamRoleCoach)
+	require.NoError(suite.T(), err)
+	assert.True(suite.T(), updated)
+
+	removed, err := suite.repo.RemoveMember(ctx, team.ID, suite.owner.ID)
+	require.NoError(suite.T(), err)
+	assert.False(suite.T(), removed)
+
+	removed, err = suite.repo.RemoveMember(ctx, team.ID, suite.athlete.ID)
+	require.NoError(suite.T(), err)
+	assert.True(suite.T(), removed)
+
+	added, count, err = suite.repo.AddMember(ctx, member, 1)
+	require.NoError(suite.T(), err)
+	assert.False(suite.T(), added, "full teams take no new members")
+	assert.Equal(suite.T(), 1, count)
+
+	count, err = suite.repo.CountMembers(ctx, team.ID)
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 1, count)
+
+	require.NoError(suite.T(), suite.repo.Delete(ctx, team.ID))
+	deleted, err := suite.repo.GetByID(ctx, team.ID)
+	require.NoError(suite.T(), err)
+	assert.Nil(suite.T(), deleted)
+}
+
+func TestTeamRepositoryTestSuite(t *testing.T) {
+	suite.Run(t, new(TeamRepositoryTestSuite))
+}
@@ -47,6 +47,16 @@ func (db *TestDB) Close() {
 
 func (db *TestDB) CleanTables() {
 	tables := []string{
+		"team_members",
+		"teams",
+		"coach_comments",
+		"coaching_links",
+		"api_tokens",
+		"refresh_tokens",
+		"token_usage",
+		"daily_wellness",
+		"tool_result_cache",
+		"message_feedback",
 		"messages",
 		"sessions", 
 		"athlete_logbooks",
@@ -0,0 +1,237 @@
+package database
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// encryptedTokenPrefix marks a token column value written by TokenCipher. Values without it or
+// legacyEncryptedTokenPrefix are plaintext rows that have not been re-encrypted yet.
+const encryptedTokenPrefix = "enc:v2:"
+
+// legacyEncryptedTokenPrefix marks values sealed before tokens were bound to their user. They
+// authenticate the column name only and are upgraded by re-encryption.
+const legacyEncryptedTokenPrefix = "enc:v1:"
+
+// tokenKeySize is the length of key-encryption and data keys (AES-256)
+const tokenKeySize = 32
+
+// ErrTokenDecryption is returned when a stored token cannot be decrypted with the configured keys
+var ErrTokenDecryption = errors.New("failed to decrypt token")
+
+// TokenCipher encrypts OAuth tokens with envelope encryption: every value gets a random data key
+// that seals the token with AES-GCM, and the data key is itself sealed with a key-encryption key
+// (KEK) from configuration. The KEK's ID is stored with the value so keys can be rotated while
+// rows written under older keys remain readable.
+//
+// Stored values look like enc:v2:<key id>:<wrapped data key>:<sealed token>, with both binary
+// parts base64url encoded and prefixed by their GCM nonce.
+type TokenCipher struct {
+	keys        map[string]cipher.AEAD
+	activeKeyID string
+}
+
+// NewTokenCipher creates a cipher from comma-separated keyID:base64key pairs, each key being 32
+// random bytes. New values are encrypted with activeKeyID, or the first listed key when it is
+// empty. It returns nil without error when no keys are configured, which leaves tokens in
+// plaintext.
+func NewTokenCipher(keys string, activeKeyID string) (*TokenCipher, error) {
+	c := &TokenCipher{keys: make(map[string]cipher.AEAD)}
+
+	for _, entry := range strings.Split(keys, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		keyID, encoded, ok := strings.Cut(entry, ":")
+		keyID = strings.TrimSpace(keyID)
+		if !ok || keyID == "" {
+			return nil, fmt.Errorf("invalid token encryption key entry: expected keyID:base64key")
+		}
+		if _, exists := c.keys[keyID]; exists {
+			return nil, fmt.Errorf("duplicate token encryption key ID %q", keyID)
+		}
+
+		key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode token encryption key %q: %w", keyID, err)
+		}
+		if len(key) != tokenKeySize {
+			return nil, fmt.Errorf("token encryption key %q must be %d bytes, got %d", keyID, tokenKeySize, len(key))
+		}
+
+		aead, err := newGCM(key)
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialize token encryption key %q: %w", keyID, err)
+		}
+		c.keys[keyID] = aead
+		if c.activeKeyID == "" {
+			c.activeKeyID = keyID
+		}
+	}
+
+	if len(c.keys) == 0 {
+		if activeKeyID != "" {
+			return nil, fmt.Errorf("active token encryption key %q is not configured", activeKeyID)
+		}
+		return nil, nil
+	}
+
+	if activeKeyID != "" {
+		if _, ok := c.keys[activeKeyID]; !ok {
+			return nil, fmt.Errorf("active token encryption key %q is not configured", activeKeyID)
+		}
+		c.activeKeyID = activeKeyID
+	}
+
+	return c, nil
+}
+
+// ActiveKeyID returns the ID of the key used for new values
+func (c *TokenCipher) ActiveKeyID() string {
+	if c == nil {
+		return ""
+	}
+	return c.activeKeyID
+}
+
+// Encrypt seals a token for storage in the named column of a user's row. The column name and user
+// ID are authenticated so a value cannot be moved to another column or copied to another user.
+// Empty tokens and a nil cipher are passed through.
+func (c *TokenCipher) Encrypt(plaintext, field, userID string) (string, error) {
+	if c == nil || plaintext == "" {
+		return plaintext, nil
+	}
+
+	dataKey := make([]byte, tokenKeySize)
+	if _, err := rand.Read(dataKey); err != nil {
+		return "", fmt.Errorf("failed to generate data key: %w", err)
+	}
+	dataAEAD, err := newGCM(dataKey)
+	if err != nil {
+		return "", fmt.Errorf("failed to initialize data key: %w", err)
+	}
+
+	sealedToken, err := seal(dataAEAD, []byte(plaintext), tokenAAD(field, userID))
+	if err != nil {
+		return "", err
+	}
+	wrappedKey, err := seal(c.keys[c.activeKeyID], dataKey, dataKeyAAD(c.activeKeyID))
+	if err != nil {
+		return "", err
+	}
+
+	return encryptedTokenPrefix + c.activeKeyID + ":" +
+		base64.RawURLEncoding.EncodeToString(wrappedKey) + ":" +
+		base64.RawURLEncoding.EncodeToString(sealedToken), nil
+}
+
+// Decrypt opens a value read from the named column of a user's row. Legacy plaintext values are
+// returned as is.
+func (c *TokenCipher) Decrypt(stored, field, userID string) (string, error) {
+	var sealedValue string
+	var additionalData []byte
+	switch {
+	case strings.HasPrefix(stored, encryptedTokenPrefix):
+		sealedValue = strings.TrimPrefix(stored, encryptedTokenPrefix)
+		additionalData = tokenAAD(field, userID)
+	case strings.HasPrefix(stored, legacyEncryptedTokenPrefix):
+		sealedValue = strings.TrimPrefix(stored, legacyEncryptedTokenPrefix)
+		additionalData = []byte(field)
+	default:
+		return stored, nil
+	}
+	if c == nil {
+		return "", fmt.Errorf("%w: token is encrypted but no token encryption keys are configured", ErrTokenDecryption)
+	}
+
+	parts := strings.Split(sealedValue, ":")
+	if len(parts) != 3 {
+		return "", fmt.Errorf("%w: malformed value", ErrTokenDecryption)
+	}
+	keyID := parts[0]
+
+	kek, ok := c.keys[keyID]
+	if !ok {
+		return "", fmt.Errorf("%w: key %q is not configured", ErrTokenDecryption, keyID)
+	}
+
+	wrappedKey, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return "", fmt.Errorf("%w: malformed data key", ErrTokenDecryption)
+	}
+	sealedToken, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return "", fmt.Errorf("%w: malformed token", ErrTokenDecryption)
+	}
+
+	dataKey, err := open(kek, wrappedKey, dataKeyAAD(keyID))
+	if err != nil {
+		return "", fmt.Errorf("%w: data key does not authenticate with key %q", ErrTokenDecryption, keyID)
+	}
+	dataAEAD, err := newGCM(dataKey)
+	if err != nil {
+		return "", fmt.Errorf("%w: invalid data key", ErrTokenDecryption)
+	}
+
+	plaintext, err := open(dataAEAD, sealedToken, additionalData)
+	if err != nil {
+		return "", fmt.Errorf("%w: token does not authenticate for %s", ErrTokenDecryption, field)
+	}
+
+	return string(plaintext), nil
+}
+
+// NeedsReencryption reports whether a stored value is plaintext, sealed in the legacy format or
+// sealed under a key other than the active one
+func (c *TokenCipher) NeedsReencryption(stored string) bool {
+	if c == nil || stored == "" {
+		return false
+	}
+	if !strings.HasPrefix(stored, encryptedTokenPrefix) {
+		return true
+	}
+	keyID, _, _ := strings.Cut(strings.TrimPrefix(stored, encryptedTokenPrefix), ":")
+	return keyID != c.activeKeyID
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// seal encrypts plaintext with a random nonce and returns nonce||ciphertext
+func seal(aead cipher.AEAD, plaintext, additionalData []byte) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	return aead.Seal(nonce, nonce, plaintext, additionalData), nil
+}
+
+func open(aead cipher.AEAD, sealed, additionalData []byte) ([]byte, error) {
+	if len(sealed) < aead.NonceSize() {
+		return nil, errors.New("ciphertext too short")
+	}
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	return aead.Open(nil, nonce, ciphertext, additionalData)
+}
+
+// tokenAAD binds a sealed token to its column and the user it belongs to
+func tokenAAD(field, userID string) []byte {
+	return []byte(field + ":" + userID)
+}
+
+// dataKeyAAD binds a wrapped data key to the ID of the key that wrapped it
+func dataKeyAAD(keyID string) []byte {
+	return []byte("token-data-key:" + keyID)
+}
@@ -0,0 +1,160 @@
+package database
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testTokenUserID is the user that sealed test tokens belong to
+const testTokenUserID = "5f1c0d2e-8a3b-4c6d-9e7f-0a1b2c3d4e5f"
+
+func testTokenKey(fill byte) string {
+	return base64.StdEncoding.EncodeToString([]byte(strings.Repeat(string(fill), tokenKeySize)))
+}
+
+func TestNewTokenCipher(t *testing.T) {
+	cipher, err := NewTokenCipher("", "")
+	require.NoError(t, err)
+	assert.Nil(t, cipher, "no keys leaves encryption disabled")
+
+	cipher, err = NewTokenCipher(" k1:"+testTokenKey('a')+", k2:"+testTokenKey('b'), "")
+	require.NoError(t, err)
+	assert.Equal(t, "k1", cipher.ActiveKeyID(), "the first listed key is active by default")
+
+	cipher, err = NewTokenCipher("k1:"+testTokenKey('a')+",k2:"+testTokenKey('b'), "k2")
+	require.NoError(t, err)
+	assert.Equal(t, "k2", cipher.ActiveKeyID())
+
+	tests := []struct {
+		name   string
+		keys   string
+		active string
+		want   string
+	}{
+		{"missing key ID", testTokenKey('a'), "", "expected keyID:base64key"},
+		{"invalid base64", "k1:not base64!", "", "failed to decode"},
+		{"wrong length", "k1:" + base64.StdEncoding.EncodeToString([]byte("short")), "", "must be 32 bytes"},
+		{"duplicate ID", "k1:" + testTokenKey('a') + ",k1:" + testTokenKey('b'), "", "duplicate"},
+		{"unknown active key", "k1:" + testTokenKey('a'), "k2", "not configured"},
+		{"active key without keys", "", "k1", "not configured"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewTokenCipher(tt.keys, tt.active)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.want)
+		})
+	}
+}
+
+func TestTokenCipher_RoundTrip(t *testing.T) {
+	cipher, err := NewTokenCipher("k1:"+testTokenKey('a'), "")
+	require.NoError(t, err)
+
+	sealed, err := cipher.Encrypt("strava-access-token", "access_token", testTokenUserID)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(sealed, "enc:v2:k1:"))
+	assert.NotContains(t, sealed, "strava-access-token")
+
+	again, err := cipher.Encrypt("strava-access-token", "access_token", testTokenUserID)
+	require.NoError(t, err)
+	assert.NotEqual(t, sealed, again, "every value uses a fresh data key and nonce")
+
+	opened, err := cipher.Decrypt(sealed, "access_token", testTokenUserID)
+	require.NoError(t, err)
+	assert.Equal(t, "strava-access-token", opened)
+
+	_, err = cipher.Decrypt(sealed, "refresh_token", testTokenUserID)
+	assert.True(t, errors.Is(err, ErrTokenDecryption), "values are bound to their column")
+
+	_, err = cipher.Decrypt(sealed, "access_token", "another-user")
+	assert.True(t, errors.Is(err, ErrTokenDecryption), "values are bound to their user")
+
+	tampered := sealed[:len(sealed)-2] + "AA"
+	_, err = cipher.Decrypt(tampered, "access_token", testTokenUserID)
+	assert.True(t, errors.Is(err, ErrTokenDecryption))
+
+	empty, err := cipher.Encrypt("", "access_token", testTokenUserID)
+	require.NoError(t, err)
+	assert.Empty(t, empty)
+
+	legacy, err := cipher.Decrypt("plaintext-token", "access_token", testTokenUserID)
+	require.NoError(t, err)
+	assert.Equal(t, "plaintext-token", legacy, "legacy plaintext rows stay readable")
+}
+
+func TestTokenCipher_KeyRotation(t *testing.T) {
+	oldCipher, err := NewTokenCipher("k1:"+testTokenKey('a'), "")
+	require.NoError(t, err)
+	sealed, err := oldCipher.Encrypt("refresh", "refresh_token", testTokenUserID)
+	require.NoError(t, err)
+
+	rotated, err := NewTokenCipher("k2:"+testTokenKey('b')+",k1:"+testTokenKey('a'), "")
+	require.NoError(t, err)
+
+	opened, err := rotated.Decrypt(sealed, "refresh_token", testTokenUserID)
+	require.NoError(t, err)
+	assert.Equal(t, "refresh", opened)
+
+	assert.True(t, rotated.NeedsReencryption(sealed))
+	assert.True(t, rotated.NeedsReencryption("plaintext-token"))
+	assert.False(t, rotated.NeedsReencryption(""))
+	resealed, err := rotated.Encrypt(opened, "refresh_token", testTokenUserID)
+	require.NoError(t, err)
+	assert.False(t, rotated.NeedsReencryption(resealed))
+
+	retired, err := NewTokenCipher("k2:"+testTokenKey('b'), "")
+	require.NoError(t, err)
+	_, err = retired.Decrypt(sealed, "refresh_token", testTokenUserID)
+	assert.True(t, errors.Is(err, ErrTokenDecryption))
+	assert.Contains(t, err.Error(), `key "k1" is not configured`)
+
+	wrongKey, err := NewTokenCipher("k1:"+testTokenKey('c'), "")
+	require.NoError(t, err)
+	_, err = wrongKey.Decrypt(sealed, "refresh_token", testTokenUserID)
+	assert.True(t, errors.Is(err, ErrTokenDecryption))
+
+	var disabled *TokenCipher
+	_, err = disabled.Decrypt(sealed, "refresh_token", testTokenUserID)
+	assert.True(t, errors.Is(err, ErrTokenDecryption))
+	assert.False(t, disabled.NeedsReencryption("plaintext-token"))
+}
+
+func TestTokenCipher_LegacyValues(t *testing.T) {
+	cipher, err := NewTokenCipher("k1:"+testTokenKey('a'), "")
+	require.NoError(t, err)
+
+	legacy := sealLegacyToken(t, cipher, "strava-refresh-token", "refresh_token")
+	opened, err := cipher.Decrypt(legacy, "refresh_token", testTokenUserID)
+	require.NoError(t, err)
+	assert.Equal(t, "strava-refresh-token", opened, "values sealed before user binding stay readable")
+	assert.True(t, cipher.NeedsReencryption(legacy), "re-encryption binds legacy values to their user")
+
+	_, err = cipher.Decrypt(legacy, "access_token", testTokenUserID)
+	assert.True(t, errors.Is(err, ErrTokenDecryption), "legacy values are still bound to their column")
+}
+
+// sealLegacyToken seals a token in the enc:v1 format, which authenticates only the column name
+func sealLegacyToken(t *testing.T, c *TokenCipher, plaintext, field string) string {
+	t.Helper()
+	dataKey := make([]byte, tokenKeySize)
+	_, err := rand.Read(dataKey)
+	require.NoError(t, err)
+	dataAEAD, err := newGCM(dataKey)
+	require.NoError(t, err)
+
+	sealedToken, err := seal(dataAEAD, []byte(plaintext), []byte(field))
+	require.NoError(t, err)
+	wrappedKey, err := seal(c.keys[c.activeKeyID], dataKey, dataKeyAAD(c.activeKeyID))
+	require.NoError(t, err)
+
+	return legacyEncryptedTokenPrefix + c.activeKeyID + ":" +
+		base64.RawURLEncoding.EncodeToString(wrappedKey) + ":" +
+		base64.RawURLEncoding.EncodeToString(sealedToken)
+}
@@ -0,0 +1,83 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"bodda/internal/models"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ToolCacheRepository persists tool results so cached entries survive restarts
+// and are shared between server instances
+type ToolCacheRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewToolCacheRepository(db *pgxpool.Pool) *ToolCacheRepository {
+	return &ToolCacheRepository{db: db}
+}
+
+// Get returns the unexpired entry for key, or nil when there is none
+func (r *ToolCacheRepository) Get(ctx context.Context, key string) (*models.ToolCacheEntry, error) {
+	query := `
+		SELECT cache_key, user_id, tool_name, content, expires_at, created_at
+		FROM tool_result_cache
+		WHERE cache_key = $1 AND expires_at > $2`
+
+	entry := &models.ToolCacheEntry{}
+	err := r.db.QueryRow(ctx, query, key, time.Now().UTC()).Scan(
+		&entry.Key,
+		&entry.UserID,
+		&entry.ToolName,
+		&entry.Content,
+		&entry.ExpiresAt,
+		&entry.CreatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get cached tool result: %w", err)
+	}
+
+	return entry, nil
+}
+
+// Set stores or replaces the entry for its key
+func (r *ToolCacheRepository) Set(ctx context.Context, entry *models.ToolCacheEntry) error {
+	query := `
+		INSERT INTO tool_result_cache (cache_key, user_id, tool_name, content, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, NOW())
+		ON CONFLICT (cache_key) DO UPDATE SET
+			content = EXCLUDED.content,
+			expires_at = EXCLUDED.expires_at,
+			created_at = NOW()
+		RETURNING created_at`
+
+	err := r.db.QueryRow(ctx, query,
+		entry.Key,
+		entry.UserID,
+		entry.ToolName,
+		entry.Content,
+		entry.ExpiresAt.UTC(),
+	).Scan(&entry.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to store cached tool result: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteExpired removes expired entries and returns how many were deleted
+func (r *ToolCacheRepository) DeleteExpired(ctx context.Context) (int64, error) {
+	tag, err := r.db.Exec(ctx, `DELETE FROM tool_result_cache WHERE expires_at <= $1`, time.Now().UTC())
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete expired tool results: %w", err)
+	}
+
+	return tag.RowsAffected(), nil
+}
@@ -0,0 +1,94 @@
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"bodda/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+type ToolCacheRepositoryTestSuite struct {
+	suite.Suite
+	repo     *ToolCacheRepository
+	userRepo *UserRepository
+	db       *TestDB
+	testUser *models.User
+}
+
+func (suite *ToolCacheRepositoryTestSuite) SetupSuite() {
+	suite.db = NewTestDB(suite.T())
+	suite.repo = NewToolCacheRepository(suite.db.Pool)
+	suite.userRepo = NewUserRepository(suite.db.Pool)
+}
+
+func (suite *ToolCacheRepositoryTestSuite) TearDownSuite() {
+	suite.db.Close()
+}
+
+func (suite *ToolCacheRepositoryTestSuite) SetupTest() {
+	suite.db.CleanTables()
+
+	suite.testUser = &models.User{
+		StravaID:     12345,
+		AccessToken:  "access_token_123",
+		RefreshToken: "refresh_token_123",
+		TokenExpiry:  time.Now().Add(time.Hour),
+		FirstName:    "John",
+		LastName:     "Doe",
+	}
+	require.NoError(suite.T(), suite.userRepo.Create(context.Background(), suite.testUser))
+}
+
+func (suite *ToolCacheRepositoryTestSuite) newEntry(key string, ttl time.Duration) *models.ToolCacheEntry {
+	return &models.ToolCacheEntry{
+		Key:       key,
+		UserID:    suite.testUser.ID,
+		ToolName:  "get-activity-details",
+		Content:   "cached content for " + key,
+		ExpiresAt: time.Now().Add(ttl),
+	}
+}
+
+func (suite *ToolCacheRepositoryTestSuite) TestSetAndGet() {
+	ctx := context.Background()
+
+	entry := suite.newEntry("key-1", time.Hour)
+	require.NoError(suite.T(), suite.repo.Set(ctx, entry))
+	assert.False(suite.T(), entry.CreatedAt.IsZero())
+
+	cached, err := suite.repo.Get(ctx, "key-1")
+	require.NoError(suite.T(), err)
+	require.NotNil(suite.T(), cached)
+	assert.Equal(suite.T(), "cached content for key-1", cached.Content)
+	assert.Equal(suite.T(), suite.testUser.ID, cached.UserID)
+
+	// Overwrite replaces the content
+	entry.Content = "updated"
+	require.NoError(suite.T(), suite.repo.Set(ctx, entry))
+	cached, err = suite.repo.Get(ctx, "key-1")
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "updated", cached.Content)
+}
+
+func (suite *ToolCacheRepositoryTestSuite) TestExpiredEntriesAreIgnored() {
+	ctx := context.Background()
+
+	require.NoError(suite.T(), suite.repo.Set(ctx, suite.newEntry("expired", -time.Minute)))
+	require.NoError(suite.T(), suite.repo.Set(ctx, suite.newEntry("fresh", time.Hour)))
+
+	cached, err := suite.repo.Get(ctx, "expired")
+	assert.NoError(suite.T(), err)
+	assert.Nil(suite.T(), cached)
+
+	deleted, err := suite.repo.DeleteExpired(ctx)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), int64(1), deleted)
+}
+
+func TestToolCacheRepositoryTestSuite(t *testing.T) {
+	suite.Run(t, new(ToolCacheRepositoryTestSuite))
+}
@@ -0,0 +1,255 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"bodda/internal/models"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// UsageRepository stores per-user, per-session, per-day LLM token usage aggregates
+type UsageRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewUsageRepository(db *pgxpool.Pool) *UsageRepository {
+	return &UsageRepository{db: db}
+}
+
+// usageTotalsColumns selects aggregated totals in the order expected by scanUsageTotals
+const usageTotalsColumns = `
+		COALESCE(SUM(input_tokens), 0)::BIGINT,
+		COALESCE(SUM(output_tokens), 0)::BIGINT,
+		COALESCE(SUM(cached_input_tokens), 0)::BIGINT,
+		COALESCE(SUM(reasoning_tokens), 0)::BIGINT,
+		COALESCE(SUM(input_tokens + output_tokens), 0)::BIGINT,
+		COALESCE(SUM(request_count), 0)::BIGINT`
+
+// RecordUsage adds the token usage of one model response to the day's aggregate
+func (r *UsageRepository) RecordUsage(ctx context.Context, userID, sessionID string, usageDate time.Time, inputTokens, outputTokens, cachedInputTokens, reasoningTokens int64) error {
+	query := `
+		INSERT INTO token_usage (user_id, session_id, usage_date, input_tokens, output_tokens, cached_input_tokens, reasoning_tokens, request_count, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, 1, NOW())
+		ON CONFLICT (user_id, session_id, usage_date) DO UPDATE SET
+			input_tokens = token_usage.input_tokens + EXCLUDED.input_tokens,
+			output_tokens = token_usage.output_tokens + EXCLUDED.output_tokens,
+			cached_input_tokens = token_usage.cached_input_tokens + EXCLUDED.cached_input_tokens,
+			reasoning_tokens = token_usage.reasoning_tokens + EXCLUDED.reasoning_tokens,
+			request_count = token_usage.request_count + 1,
+			updated_at = NOW()`
+
+	_, err := r.db.Exec(ctx, query,
+		userID,
+		sessionID,
+		dateParam(usageDate),
+		inputTokens,
+		outputTokens,
+		cachedInputTokens,
+		reasoningTokens,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to record token usage: %w", err)
+	}
+
+	return nil
+}
+
+// GetUserTotals returns a user's usage for days in [from, to)
+func (r *UsageRepository) GetUserTotals(ctx context.Context, userID string, from, to time.Time) (*models.TokenUsageTotals, error) {
+	query := `
+		SELECT` + usageTotalsColumns + `
+		FROM token_usage
+		WHERE user_id = $1 AND usage_date >= $2 AND usage_date < $3`
+
+	totals := &models.TokenUsageTotals{}
+	if err := scanUsageTotals(r.db.QueryRow(ctx, query, userID, dateParam(from), dateParam(to)), totals); err != nil {
+		return nil, fmt.Errorf("failed to get user token usage: %w", err)
+	}
+
+	return totals, nil
+}
+
+// GetUserDailyUsage returns a user's usage per day for days in [from, to)
+func (r *UsageRepository) GetUserDailyUsage(ctx context.Context, userID string, from, to time.Time) ([]*models.DailyTokenUsage, error) {
+	query := `
+		SELECT usage_date,` + usageTotalsColumns + `
+		FROM token_usage
+		WHERE user_id = $1 AND usage_date >= $2 AND usage_date < $3
+		GROUP BY usage_date
+		ORDER BY usage_date DESC`
+
+	rows, err := r.db.Query(ctx, query, userID, dateParam(from), dateParam(to))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get daily token usage: %w", err)
+	}
+	defer rows.Close()
+
+	return scanDailyUsage(rows)
+}
+
+// GetUserSessionUsage returns a user's heaviest sessions for days in [from, to)
+func (r *UsageRepository) GetUserSessionUsage(ctx context.Context, userID string, from, to time.Time, limit int) ([]*models.SessionTokenUsage, error) {
+	query := `
+		SELECT tu.session_id, MAX(s.title),` + usageTotalsColumns + `
+		FROM token_usage tu
+		LEFT JOIN sessions s ON s.id::text = tu.session_id
+		WHERE tu.user_id = $1 AND tu.usage_date >= $2 AND tu.usage_date < $3
+		GROUP BY tu.session_id
+		ORDER BY SUM(tu.input_tokens + tu.output_tokens) DESC
+		LIMIT $4`
+
+	rows, err := r.db.Query(ctx, query, userID, dateParam(from), dateParam(to), limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get session token usage: %w", err)
+	}
+	defer rows.Close()
+
+	var sessions []*models.SessionTokenUsage
+	for rows.Next() {
+		usage := &models.SessionTokenUsage{}
+		err := rows.Scan(
+			&usage.SessionID,
+			&usage.SessionTitle,
+			&usage.InputTokens,
+			&usage.OutputTokens,
+			&usage.CachedInputTokens,
+			&usage.ReasoningTokens,
+			&usage.TotalTokens,
+			&usage.RequestCount,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan session token usage: %w", err)
+		}
+		sessions = append(sessions, usage)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating session token usage: %w", err)
+	}
+
+	return sessions, nil
+}
+
+// GetTotals returns usage across all users for days in [from, to)
+func (r *UsageRepository) GetTotals(ctx context.Context, from, to time.Time) (*models.TokenUsageTotals, error) {
+	query := `
+		SELECT` + usageTotalsColumns + `
+		FROM token_usage
+		WHERE usage_date >= $1 AND usage_date < $2`
+
+	totals := &models.TokenUsageTotals{}
+	if err := scanUsageTotals(r.db.QueryRow(ctx, query, dateParam(from), dateParam(to)), totals); err != nil {
+		return nil, fmt.Errorf("failed to get token usage totals: %w", err)
+	}
+
+	return totals, nil
+}
+
+// GetDailyUsage returns usage across all users per day for days in [from, to)
+func (r *UsageRepository) GetDailyUsage(ctx context.Context, from, to time.Time) ([]*models.DailyTokenUsage, error) {
+	query := `
+		SELECT usage_date,` + usageTotalsColumns + `
+		FROM token_usage
+		WHERE usage_date >= $1 AND usage_date < $2
+		GROUP BY usage_date
+		ORDER BY usage_date DESC`
+
+	rows, err := r.db.Query(ctx, query, dateParam(from), dateParam(to))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get daily token usage: %w", err)
+	}
+	defer rows.Close()
+
+	return scanDailyUsage(rows)
+}
+
+// GetTopUsers returns the heaviest users for days in [from, to)
+func (r *UsageRepository) GetTopUsers(ctx context.Context, from, to time.Time, limit int) ([]*models.UserTokenUsage, error) {
+	query := `
+		SELECT u.id, u.strava_id, COALESCE(u.first_name, ''), COALESCE(u.last_name, ''),` + usageTotalsColumns + `
+		FROM token_usage tu
+		JOIN users u ON u.id = tu.user_id
+		WHERE tu.usage_date >= $1 AND tu.usage_date < $2
+		GROUP BY u.id, u.strava_id, u.first_name, u.last_name
+		ORDER BY SUM(tu.input_tokens + tu.output_tokens) DESC
+		LIMIT $3`
+
+	rows, err := r.db.Query(ctx, query, dateParam(from), dateParam(to), limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get top token users: %w", err)
+	}
+	defer rows.Close()
+
+	var users []*models.UserTokenUsage
+	for rows.Next() {
+		usage := &models.UserTokenUsage{}
+		err := rows.Scan(
+			&usage.UserID,
+			&usage.StravaID,
+			&usage.FirstName,
+			&usage.LastName,
+			&usage.InputTokens,
+			&usage.OutputTokens,
+			&usage.CachedInputTokens,
+			&usage.ReasoningTokens,
+			&usage.TotalTokens,
+			&usage.RequestCount,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan user token usage: %w", err)
+		}
+		users = append(users, usage)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating user token usage: %w", err)
+	}
+
+	return users, nil
+}
+
+// dateParam truncates a time to its UTC calendar date for DATE columns
+func dateParam(t time.Time) time.Time {
+	utc := t.UTC()
+	return time.Date(utc.Year(), utc.Month(), utc.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+func scanUsageTotals(row pgx.Row, totals *models.TokenUsageTotals) error {
+	return row.Scan(
+		&totals.InputTokens,
+		&totals.OutputTokens,
+		&totals.CachedInputTokens,
+		&totals.ReasoningTokens,
+		&totals.TotalTokens,
+		&totals.RequestCount,
+	)
+}
+
+func scanDailyUsage(rows pgx.Rows) ([]*models.DailyTokenUsage, error) {
+	var days []*models.DailyTokenUsage
+	for rows.Next() {
+		usage := &models.DailyTokenUsage{}
+		err := rows.Scan(
+			&usage.Date,
+			&usage.InputTokens,
+			&usage.OutputTokens,
+			&usage.CachedInputTokens,
+			&usage.ReasoningTokens,
+			&usage.TotalTokens,
+			&usage.RequestCount,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan daily token usage: %w", err)
+		}
+		days = append(days, usage)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating daily token usage: %w", err)
+	}
+
+	return days, nil
+}
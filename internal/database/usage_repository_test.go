@@ -0,0 +1,138 @@
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"bodda/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+type UsageRepositoryTestSuite struct {
+	suite.Suite
+	repo        *UsageRepository
+	userRepo    *UserRepository
+	sessionRepo *SessionRepository
+	db          *TestDB
+	testUser    *models.User
+	testSession *models.Session
+}
+
+func (suite *UsageRepositoryTestSuite) SetupSuite() {
+	suite.db = NewTestDB(suite.T())
+	suite.repo = NewUsageRepository(suite.db.Pool)
+	suite.userRepo = NewUserRepository(suite.db.Pool)
+	suite.sessionRepo = NewSessionRepository(suite.db.Pool)
+}
+
+func (suite *UsageRepositoryTestSuite) TearDownSuite() {
+	suite.db.Close()
+}
+
+func (suite *UsageRepositoryTestSuite) SetupTest() {
+	suite.db.CleanTables()
+
+	suite.testUser = &models.User{
+		StravaID:     12345,
+		AccessToken:  "access_token_123",
+		RefreshToken: "refresh_token_123",
+		TokenExpiry:  time.Now().Add(time.Hour),
+		FirstName:    "John",
+		LastName:     "Doe",
+	}
+	require.NoError(suite.T(), suite.userRepo.Create(context.Background(), suite.testUser))
+
+	suite.testSession = &models.Session{
+		UserID: suite.testUser.ID,
+		Title:  "Usage Session",
+	}
+	require.NoError(suite.T(), suite.sessionRepo.Create(context.Background(), suite.testSession))
+}
+
+func (suite *UsageRepositoryTestSuite) TestRecordUsageAggregatesPerDay() {
+	ctx := context.Background()
+	today := time.Now().UTC()
+
+	assert.NoError(suite.T(), suite.repo.RecordUsage(ctx, suite.testUser.ID, suite.testSession.ID, today, 100, 50, 20, 10))
+	assert.NoError(suite.T(), suite.repo.RecordUsage(ctx, suite.testUser.ID, suite.testSession.ID, today, 200, 25, 0, 5))
+	assert.NoError(suite.T(), suite.repo.RecordUsage(ctx, suite.testUser.ID, suite.testSession.ID, today.AddDate(0, 0, -1), 1000, 500, 0, 0))
+
+	totals, err := suite.repo.GetUserTotals(ctx, suite.testUser.ID, today, today.AddDate(0, 0, 1))
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), int64(300), totals.InputTokens)
+	assert.Equal(suite.T(), int64(75), totals.OutputTokens)
+	assert.Equal(suite.T(), int64(20), totals.CachedInputTokens)
+	assert.Equal(suite.T(), int64(15), totals.ReasoningTokens)
+	assert.Equal(suite.T(), int64(375), totals.TotalTokens)
+	assert.Equal(suite.T(), int64(2), totals.RequestCount)
+
+	daily, err := suite.repo.GetUserDailyUsage(ctx, suite.testUser.ID, today.AddDate(0, 0, -7), today.AddDate(0, 0, 1))
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), daily, 2)
+	assert.Equal(suite.T(), int64(375), daily[0].TotalTokens)
+	assert.Equal(suite.T(), int64(1500), daily[1].TotalTokens)
+}
+
+func (suite *UsageRepositoryTestSuite) TestUsageSurvivesSessionDeletion() {
+	ctx := context.Background()
+	today := time.Now().UTC()
+
+	assert.NoError(suite.T(), suite.repo.RecordUsage(ctx, suite.testUser.ID, suite.testSession.ID, today, 100, 50, 0, 0))
+
+	sessions, err := suite.repo.GetUserSessionUsage(ctx, suite.testUser.ID, today, today.AddDate(0, 0, 1), 10)
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), sessions, 1)
+	assert.NotNil(suite.T(), sessions[0].SessionTitle)
+	assert.Equal(suite.T(), "Usage Session", *sessions[0].SessionTitle)
+
+	assert.NoError(suite.T(), suite.sessionRepo.Delete(ctx, suite.testSession.ID))
+
+	totals, err := suite.repo.GetUserTotals(ctx, suite.testUser.ID, today, today.AddDate(0, 0, 1))
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), int64(150), totals.TotalTokens)
+
+	sessions, err = suite.repo.GetUserSessionUsage(ctx, suite.testUser.ID, today, today.AddDate(0, 0, 1), 10)
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), sessions, 1)
+	assert.Nil(suite.T(), sessions[0].SessionTitle)
+}
+
+func (suite *UsageRepositoryTestSuite) TestAdminAggregates() {
+	ctx := context.Background()
+	today := time.Now().UTC()
+
+	otherUser := &models.User{
+		StravaID:     67890,
+		AccessToken:  "access_token_456",
+		RefreshToken: "refresh_token_456",
+		TokenExpiry:  time.Now().Add(time.Hour),
+		FirstName:    "Jane",
+		LastName:     "Smith",
+	}
+	require.NoError(suite.T(), suite.userRepo.Create(ctx, otherUser))
+
+	assert.NoError(suite.T(), suite.repo.RecordUsage(ctx, suite.testUser.ID, suite.testSession.ID, today, 100, 50, 0, 0))
+	assert.NoError(suite.T(), suite.repo.RecordUsage(ctx, otherUser.ID, "", today, 1000, 500, 0, 0))
+
+	totals, err := suite.repo.GetTotals(ctx, today, today.AddDate(0, 0, 1))
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), int64(1650), totals.TotalTokens)
+
+	users, err := suite.repo.GetTopUsers(ctx, today, today.AddDate(0, 0, 1), 10)
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), users, 2)
+	assert.Equal(suite.T(), otherUser.ID, users[0].UserID)
+	assert.Equal(suite.T(), "Jane", users[0].FirstName)
+
+	daily, err := suite.repo.GetDailyUsage(ctx, today.AddDate(0, 0, -1), today.AddDate(0, 0, 1))
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), daily, 1)
+	assert.Equal(suite.T(), int64(2), daily[0].RequestCount)
+}
+
+func TestUsageRepositoryTestSuite(t *testing.T) {
+	suite.Run(t, new(UsageRepositoryTestSuite))
+}
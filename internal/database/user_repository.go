@@ -5,28 +5,56 @@ import (
 	"fmt"
 
 	"bodda/internal/models"
+	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 type UserRepository struct {
-	db *pgxpool.Pool
+	db     *pgxpool.Pool
+	cipher *TokenCipher
 }
 
-func NewUserRepository(db *pgxpool.Pool) *UserRepository {
-	return &UserRepository{db: db}
+// UserRepositoryOption configures optional UserRepository behaviour
+type UserRepositoryOption func(*UserRepository)
+
+// WithTokenCipher encrypts Strava access and refresh tokens at rest. Without it tokens are stored
+// in plaintext.
+func WithTokenCipher(cipher *TokenCipher) UserRepositoryOption {
+	return func(r *UserRepository) {
+		r.cipher = cipher
+	}
+}
+
+func NewUserRepository(db *pgxpool.Pool, opts ...UserRepositoryOption) *UserRepository {
+	r := &UserRepository{db: db}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
 }
 
 func (r *UserRepository) Create(ctx context.Context, user *models.User) error {
 	query := `
-		INSERT INTO users (strava_id, access_token, refresh_token, token_expiry, first_name, last_name)
-		VALUES ($1, $2, $3, $4, $5, $6)
+		INSERT INTO users (id, strava_id, access_token, refresh_token, token_expiry, first_name, last_name)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
 		RETURNING id, created_at, updated_at`
 
-	err := r.db.QueryRow(ctx, query,
+	// The ID is chosen up front because encrypted tokens are bound to it
+	if user.ID == "" {
+		user.ID = uuid.NewString()
+	}
+
+	accessToken, refreshToken, err := r.sealTokens(user)
+	if err != nil {
+		return fmt.Errorf("failed to create user: %w", err)
+	}
+
+	err = r.db.QueryRow(ctx, query,
+		user.ID,
 		user.StravaID,
-		user.AccessToken,
-		user.RefreshToken,
+		accessToken,
+		refreshToken,
 		user.TokenExpiry,
 		user.FirstName,
 		user.LastName,
@@ -65,6 +93,10 @@ func (r *UserRepository) GetByID(ctx context.Context, id string) (*models.User,
 		return nil, fmt.Errorf("failed to get user: %w", err)
 	}
 
+	if err := r.openTokens(user); err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
 	return user, nil
 }
 
@@ -94,6 +126,10 @@ func (r *UserRepository) GetByStravaID(ctx context.Context, stravaID int64) (*mo
 		return nil, fmt.Errorf("failed to get user: %w", err)
 	}
 
+	if err := r.openTokens(user); err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
 	return user, nil
 }
 
@@ -105,10 +141,15 @@ func (r *UserRepository) Update(ctx context.Context, user *models.User) error {
 		WHERE id = $1
 		RETURNING updated_at`
 
-	err := r.db.QueryRow(ctx, query,
+	accessToken, refreshToken, err := r.sealTokens(user)
+	if err != nil {
+		return fmt.Errorf("failed to update user: %w", err)
+	}
+
+	err = r.db.QueryRow(ctx, query,
 		user.ID,
-		user.AccessToken,
-		user.RefreshToken,
+		accessToken,
+		refreshToken,
 		user.TokenExpiry,
 		user.FirstName,
 		user.LastName,
@@ -134,4 +175,120 @@ func (r *UserRepository) Delete(ctx context.Context, id string) error {
 	}
 
 	return nil
-}
\ No newline at end of file
+}
+
+// TokenReencryptionResult summarizes a ReencryptTokens run
+type TokenReencryptionResult struct {
+	Scanned   int      // users examined
+	Updated   int      // users whose tokens were rewritten under the active key
+	Skipped   int      // users whose tokens changed while the run was in progress
+	FailedIDs []string // users whose tokens could not be decrypted with the configured keys
+}
+
+// ReencryptTokens rewrites every user's tokens that are still plaintext or sealed under an older
+// key with the active key. Rows are processed in batches of batchSize ordered by ID, and a row is
+// only rewritten if its tokens are unchanged since they were read, so it is safe to run while the
+// server is handling logins and token refreshes.
+func (r *UserRepository) ReencryptTokens(ctx context.Context, batchSize int) (*TokenReencryptionResult, error) {
+	if r.cipher == nil {
+		return nil, fmt.Errorf("token encryption is not configured")
+	}
+	if batchSize <= 0 {
+		batchSize = 100
+	}
+
+	result := &TokenReencryptionResult{}
+	lastID := "00000000-0000-0000-0000-000000000000"
+
+	for {
+		rows, err := r.db.Query(ctx, `
+			SELECT id, access_token, refresh_token
+			FROM users WHERE id > $1
+			ORDER BY id
+			LIMIT $2`, lastID, batchSize)
+		if err != nil {
+			return result, fmt.Errorf("failed to list users for re-encryption: %w", err)
+		}
+
+		type storedTokens struct {
+			id, accessToken, refreshToken string
+		}
+		var batch []storedTokens
+		for rows.Next() {
+			var row storedTokens
+			if err := rows.Scan(&row.id, &row.accessToken, &row.refreshToken); err != nil {
+				rows.Close()
+				return result, fmt.Errorf("failed to scan user tokens: %w", err)
+			}
+			batch = append(batch, row)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return result, fmt.Errorf("failed to list users for re-encryption: %w", err)
+		}
+
+		for _, row := range batch {
+			result.Scanned++
+			lastID = row.id
+
+			if !r.cipher.NeedsReencryption(row.accessToken) && !r.cipher.NeedsReencryption(row.refreshToken) {
+				continue
+			}
+
+			user := &models.User{ID: row.id, AccessToken: row.accessToken, RefreshToken: row.refreshToken}
+			if err := r.openTokens(user); err != nil {
+				result.FailedIDs = append(result.FailedIDs, row.id)
+				continue
+			}
+			accessToken, refreshToken, err := r.sealTokens(user)
+			if err != nil {
+				return result, fmt.Errorf("failed to re-encrypt tokens for user %s: %w", row.id, err)
+			}
+
+			tag, err := r.db.Exec(ctx, `
+				UPDATE users SET access_token = $2, refresh_token = $3
+				WHERE id = $1 AND access_token = $4 AND refresh_token = $5`,
+				row.id, accessToken, refreshToken, row.accessToken, row.refreshToken)
+			if err != nil {
+				return result, fmt.Errorf("failed to store re-encrypted tokens for user %s: %w", row.id, err)
+			}
+			if tag.RowsAffected() == 0 {
+				result.Skipped++
+				continue
+			}
+			result.Updated++
+		}
+
+		if len(batch) < batchSize {
+			return result, nil
+		}
+	}
+}
+
+// sealTokens returns the user's tokens as they are stored in the database
+func (r *UserRepository) sealTokens(user *models.User) (string, string, error) {
+	accessToken, err := r.cipher.Encrypt(user.AccessToken, "access_token", user.ID)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to encrypt access token: %w", err)
+	}
+	refreshToken, err := r.cipher.Encrypt(user.RefreshToken, "refresh_token", user.ID)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to encrypt refresh token: %w", err)
+	}
+	return accessToken, refreshToken, nil
+}
+
+// openTokens replaces the stored tokens on user with their plaintext
+func (r *UserRepository) openTokens(user *models.User) error {
+	accessToken, err := r.cipher.Decrypt(user.AccessToken, "access_token", user.ID)
+	if err != nil {
+		return fmt.Errorf("access token: %w", err)
+	}
+	refreshToken, err := r.cipher.Decrypt(user.RefreshToken, "refresh_token", user.ID)
+	if err != nil {
+		return fmt.Errorf("refresh token: %w", err)
+	}
+	user.AccessToken = accessToken
+	user.RefreshToken = refreshToken
+	return nil
+}
@@ -2,6 +2,7 @@ package database
 
 import (
 	"context"
+	"strings"
 	"testing"
 	"time"
 
@@ -147,6 +148,78 @@ func (suite *UserRepositoryTestSuite) TestGetNonExistentUser() {
 	assert.Contains(suite.T(), err.Error(), "user not found")
 }
 
+func (suite *UserRepositoryTestSuite) TestTokensEncryptedAtRest() {
+	cipher, err := NewTokenCipher("k1:"+testTokenKey('a'), "")
+	suite.Require().NoError(err)
+	repo := NewUserRepository(suite.db.Pool, WithTokenCipher(cipher))
+
+	user := &models.User{
+		StravaID:     12345,
+		AccessToken:  "access_token_123",
+		RefreshToken: "refresh_token_123",
+		TokenExpiry:  time.Now().Add(time.Hour),
+		FirstName:    "John",
+		LastName:     "Doe",
+	}
+	suite.Require().NoError(repo.Create(context.Background(), user))
+	assert.Equal(suite.T(), "access_token_123", user.AccessToken, "the caller's user keeps plaintext tokens")
+
+	var storedAccess, storedRefresh string
+	err = suite.db.Pool.QueryRow(context.Background(),
+		"SELECT access_token, refresh_token FROM users WHERE id = $1", user.ID).Scan(&storedAccess, &storedRefresh)
+	suite.Require().NoError(err)
+	assert.True(suite.T(), strings.HasPrefix(storedAccess, "enc:v2:k1:"))
+	assert.NotContains(suite.T(), storedRefresh, "refresh_token_123")
+
+	retrieved, err := repo.GetByStravaID(context.Background(), 12345)
+	suite.Require().NoError(err)
+	assert.Equal(suite.T(), "access_token_123", retrieved.AccessToken)
+	assert.Equal(suite.T(), "refresh_token_123", retrieved.RefreshToken)
+
+	_, err = suite.repo.GetByID(context.Background(), user.ID)
+	assert.ErrorIs(suite.T(), err, ErrTokenDecryption, "a repository without keys cannot read encrypted rows")
+}
+
+func (suite *UserRepositoryTestSuite) TestReencryptTokens() {
+	legacy := &models.User{StravaID: 1, AccessToken: "plain_access", RefreshToken: "plain_refresh", TokenExpiry: time.Now()}
+	suite.Require().NoError(suite.repo.Create(context.Background(), legacy))
+
+	oldCipher, err := NewTokenCipher("k1:"+testTokenKey('a'), "")
+	suite.Require().NoError(err)
+	oldKeyUser := &models.User{StravaID: 2, AccessToken: "old_access", RefreshToken: "old_refresh", TokenExpiry: time.Now()}
+	suite.Require().NoError(NewUserRepository(suite.db.Pool, WithTokenCipher(oldCipher)).Create(context.Background(), oldKeyUser))
+
+	_, err = suite.repo.ReencryptTokens(context.Background(), 10)
+	assert.Error(suite.T(), err, "re-encryption requires keys")
+
+	rotated, err := NewTokenCipher("k2:"+testTokenKey('b')+",k1:"+testTokenKey('a'), "")
+	suite.Require().NoError(err)
+	repo := NewUserRepository(suite.db.Pool, WithTokenCipher(rotated))
+
+	result, err := repo.ReencryptTokens(context.Background(), 1)
+	suite.Require().NoError(err)
+	assert.Equal(suite.T(), 2, result.Scanned)
+	assert.Equal(suite.T(), 2, result.Updated)
+	assert.Empty(suite.T(), result.FailedIDs)
+
+	for _, user := range []*models.User{legacy, oldKeyUser} {
+		var storedAccess string
+		err = suite.db.Pool.QueryRow(context.Background(),
+			"SELECT access_token FROM users WHERE id = $1", user.ID).Scan(&storedAccess)
+		suite.Require().NoError(err)
+		assert.True(suite.T(), strings.HasPrefix(storedAccess, "enc:v2:k2:"))
+
+		retrieved, err := repo.GetByID(context.Background(), user.ID)
+		suite.Require().NoError(err)
+		assert.Equal(suite.T(), user.AccessToken, retrieved.AccessToken)
+		assert.Equal(suite.T(), user.RefreshToken, retrieved.RefreshToken)
+	}
+
+	result, err = repo.ReencryptTokens(context.Background(), 10)
+	suite.Require().NoError(err)
+	assert.Equal(suite.T(), 0, result.Updated, "a second run has nothing to do")
+}
+
 func TestUserRepositoryTestSuite(t *testing.T) {
 	suite.Run(t, new(UserRepositoryTestSuite))
 }
\ No newline at end of file
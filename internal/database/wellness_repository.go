@@ -0,0 +1,161 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"bodda/internal/models"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// WellnessRepository stores daily recovery metrics entered by athletes
+type WellnessRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewWellnessRepository(db *pgxpool.Pool) *WellnessRepository {
+	return &WellnessRepository{db: db}
+}
+
+// wellnessColumns selects a day's metrics in the order expected by scanWellness
+const wellnessColumns = `user_id, metric_date, resting_hr, hrv_rmssd, sleep_hours, fatigue, soreness, mood, notes, created_at, updated_at`
+
+// upsertWellnessQuery merges an entry into the day's metrics. Metrics missing from the entry keep
+// their stored value so a morning HRV reading and a later sleep log can be recorded separately.
+const upsertWellnessQuery = `
+		INSERT INTO daily_wellness (user_id, metric_date, resting_hr, hrv_rmssd, sleep_hours, fatigue, soreness, mood, notes, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, NOW(), NOW())
+		ON CONFLICT (user_id, metric_date) DO UPDATE SET
+			resting_hr = COALESCE(EXCLUDED.resting_hr, daily_wellness.resting_hr),
+			hrv_rmssd = COALESCE(EXCLUDED.hrv_rmssd, daily_wellness.hrv_rmssd),
+			sleep_hours = COALESCE(EXCLUDED.sleep_hours, daily_wellness.sleep_hours),
+			fatigue = COALESCE(EXCLUDED.fatigue, daily_wellness.fatigue),
+			soreness = COALESCE(EXCLUDED.soreness, daily_wellness.soreness),
+			mood = COALESCE(EXCLUDED.mood, daily_wellness.mood),
+			notes = CASE WHEN EXCLUDED.notes = '' THEN daily_wellness.notes ELSE EXCLUDED.notes END,
+			updated_at = NOW()
+		RETURNING ` + wellnessColumns
+
+// Upsert records an entry, merging it with any metrics already stored for the day
+func (r *WellnessRepository) Upsert(ctx context.Context, entry *models.DailyWellness) error {
+	row := r.db.QueryRow(ctx, upsertWellnessQuery, wellnessArgs(entry)...)
+	if err := scanWellness(row, entry); err != nil {
+		return fmt.Errorf("failed to upsert wellness entry: %w", err)
+	}
+
+	return nil
+}
+
+// UpsertBatch records several entries in one transaction, as used by CSV imports
+func (r *WellnessRepository) UpsertBatch(ctx context.Context, entries []*models.DailyWellness) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin wellness import: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	for _, entry := range entries {
+		if err := scanWellness(tx.QueryRow(ctx, upsertWellnessQuery, wellnessArgs(entry)...), entry); err != nil {
+			return fmt.Errorf("failed to import wellness entry for %s: %w", entry.Date.Format("2006-01-02"), err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit wellness import: %w", err)
+	}
+
+	return nil
+}
+
+// Get returns the user's metrics for a day, or nil when nothing was recorded
+func (r *WellnessRepository) Get(ctx context.Context, userID string, date time.Time) (*models.DailyWellness, error) {
+	query := `
+		SELECT ` + wellnessColumns + `
+		FROM daily_wellness
+		WHERE user_id = $1 AND metric_date = $2`
+
+	entry := &models.DailyWellness{}
+	if err := scanWellness(r.db.QueryRow(ctx, query, userID, dateParam(date)), entry); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get wellness entry: %w", err)
+	}
+
+	return entry, nil
+}
+
+// GetRange returns the user's metrics for days in [from, to), oldest first
+func (r *WellnessRepository) GetRange(ctx context.Context, userID string, from, to time.Time) ([]*models.DailyWellness, error) {
+	query := `
+		SELECT ` + wellnessColumns + `
+		FROM daily_wellness
+		WHERE user_id = $1 AND metric_date >= $2 AND metric_date < $3
+		ORDER BY metric_date ASC`
+
+	rows, err := r.db.Query(ctx, query, userID, dateParam(from), dateParam(to))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get wellness entries: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []*models.DailyWellness
+	for rows.Next() {
+		entry := &models.DailyWellness{}
+		if err := scanWellness(rows, entry); err != nil {
+			return nil, fmt.Errorf("failed to scan wellness entry: %w", err)
+		}
+		entries = append(entries, entry)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating wellness entries: %w", err)
+	}
+
+	return entries, nil
+}
+
+// Delete removes the user's metrics for a day, returning false when nothing was stored
+func (r *WellnessRepository) Delete(ctx context.Context, userID string, date time.Time) (bool, error) {
+	query := `DELETE FROM daily_wellness WHERE user_id = $1 AND metric_date = $2`
+
+	result, err := r.db.Exec(ctx, query, userID, dateParam(date))
+	if err != nil {
+		return false, fmt.Errorf("failed to delete wellness entry: %w", err)
+	}
+
+	return result.RowsAffected() > 0, nil
+}
+
+func wellnessArgs(entry *models.DailyWellness) []interface{} {
+	return []interface{}{
+		entry.UserID,
+		dateParam(entry.Date),
+		entry.RestingHR,
+		entry.HRVRMSSD,
+		entry.SleepHours,
+		entry.Fatigue,
+		entry.Soreness,
+		entry.Mood,
+		entry.Notes,
+	}
+}
+
+func scanWellness(row pgx.Row, entry *models.DailyWellness) error {
+	return row.Scan(
+		&entry.UserID,
+		&entry.Date,
+		&entry.RestingHR,
+		&entry.HRVRMSSD,
+		&entry.SleepHours,
+		&entry.Fatigue,
+		&entry.Soreness,
+		&entry.Mood,
+		&entry.Notes,
+		&entry.CreatedAt,
+		&entry.UpdatedAt,
+	)
+}
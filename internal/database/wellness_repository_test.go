@@ -0,0 +1,106 @@
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"bodda/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+type WellnessRepositoryTestSuite struct {
+	suite.Suite
+	repo     *WellnessRepository
+	userRepo *UserRepository
+	db       *TestDB
+	testUser *models.User
+}
+
+func (suite *WellnessRepositoryTestSuite) SetupSuite() {
+	suite.db = NewTestDB(suite.T())
+	suite.repo = NewWellnessRepository(suite.db.Pool)
+	suite.userRepo = NewUserRepository(suite.db.Pool)
+}
+
+func (suite *WellnessRepositoryTestSuite) TearDownSuite() {
+	suite.db.Close()
+}
+
+func (suite *WellnessRepositoryTestSuite) SetupTest() {
+	suite.db.CleanTables()
+
+	suite.testUser = &models.User{
+		StravaID:     12345,
+		AccessToken:  "access_token_123",
+		RefreshToken: "refresh_token_123",
+		TokenExpiry:  time.Now().Add(time.Hour),
+		FirstName:    "John",
+		LastName:     "Doe",
+	}
+	require.NoError(suite.T(), suite.userRepo.Create(context.Background(), suite.testUser))
+}
+
+func intPtr(v int) *int { return &v }
+
+func floatPtr(v float64) *float64 { return &v }
+
+func (suite *WellnessRepositoryTestSuite) TestUpsertMergesPartialEntries() {
+	ctx := context.Background()
+	day := time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC)
+
+	morning := &models.DailyWellness{UserID: suite.testUser.ID, Date: day, RestingHR: intPtr(48), HRVRMSSD: floatPtr(72.5)}
+	require.NoError(suite.T(), suite.repo.Upsert(ctx, morning))
+
+	evening := &models.DailyWellness{UserID: suite.testUser.ID, Date: day, SleepHours: floatPtr(7.5), Fatigue: intPtr(2), Notes: "legs ok"}
+	require.NoError(suite.T(), suite.repo.Upsert(ctx, evening))
+
+	stored, err := suite.repo.Get(ctx, suite.testUser.ID, day)
+	require.NoError(suite.T(), err)
+	require.NotNil(suite.T(), stored)
+	assert.Equal(suite.T(), 48, *stored.RestingHR)
+	assert.Equal(suite.T(), 72.5, *stored.HRVRMSSD)
+	assert.Equal(suite.T(), 7.5, *stored.SleepHours)
+	assert.Equal(suite.T(), 2, *stored.Fatigue)
+	assert.Nil(suite.T(), stored.Mood)
+	assert.Equal(suite.T(), "legs ok", stored.Notes)
+}
+
+func (suite *WellnessRepositoryTestSuite) TestRejectsOutOfRangeValues() {
+	entry := &models.DailyWellness{UserID: suite.testUser.ID, Date: time.Now(), Mood: intPtr(9)}
+	assert.Error(suite.T(), suite.repo.Upsert(context.Background(), entry))
+}
+
+func (suite *WellnessRepositoryTestSuite) TestBatchRangeAndDelete() {
+	ctx := context.Background()
+	start := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	var entries []*models.DailyWellness
+	for i := 0; i < 5; i++ {
+		entries = append(entries, &models.DailyWellness{UserID: suite.testUser.ID, Date: start.AddDate(0, 0, i), RestingHR: intPtr(50 + i)})
+	}
+	require.NoError(suite.T(), suite.repo.UpsertBatch(ctx, entries))
+
+	stored, err := suite.repo.GetRange(ctx, suite.testUser.ID, start.AddDate(0, 0, 1), start.AddDate(0, 0, 4))
+	require.NoError(suite.T(), err)
+	require.Len(suite.T(), stored, 3)
+	assert.Equal(suite.T(), 51, *stored[0].RestingHR, "oldest first")
+
+	deleted, err := suite.repo.Delete(ctx, suite.testUser.ID, start)
+	require.NoError(suite.T(), err)
+	assert.True(suite.T(), deleted)
+
+	deleted, err = suite.repo.Delete(ctx, suite.testUser.ID, start)
+	require.NoError(suite.T(), err)
+	assert.False(suite.T(), deleted)
+
+	missing, err := suite.repo.Get(ctx, suite.testUser.ID, start)
+	require.NoError(suite.T(), err)
+	assert.Nil(suite.T(), missing)
+}
+
+func TestWellnessRepositoryTestSuite(t *testing.T) {
+	suite.Run(t, new(WellnessRepositoryTestSuite))
+}
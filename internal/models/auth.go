@@ -0,0 +1,62 @@
+package models
+
+import (
+	"time"
+)
+
+// RefreshToken is one link in the chain of rotating refresh tokens issued for a login. Every
+// refresh replaces the token with a new one in the same family, so FamilyID identifies the login
+// session shown to the user. Only a hash of the token is stored.
+type RefreshToken struct {
+	ID               string     `json:"id" db:"id"`
+	UserID           string     `json:"user_id" db:"user_id"`
+	FamilyID         string     `json:"family_id" db:"family_id"`
+	TokenHash        string     `json:"-" db:"token_hash"`
+	DeviceName       string     `json:"device_name" db:"device_name"`
+	IPAddress        string     `json:"ip_address" db:"ip_address"`
+	UserAgent        string     `json:"user_agent" db:"user_agent"`
+	SessionStartedAt time.Time  `json:"session_started_at" db:"session_started_at"` // When the family's first token was issued
+	CreatedAt        time.Time  `json:"created_at" db:"created_at"`
+	LastUsedAt       time.Time  `json:"last_used_at" db:"last_used_at"`
+	ExpiresAt        time.Time  `json:"expires_at" db:"expires_at"`
+	RotatedAt        *time.Time `json:"rotated_at,omitempty" db:"rotated_at"` // Set once the token was exchanged for its successor
+	RevokedAt        *time.Time `json:"revoked_at,omitempty" db:"revoked_at"`
+	RevokedReason    string     `json:"revoked_reason,omitempty" db:"revoked_reason"`
+}
+
+// Scopes a personal API token can be granted
+const (
+	ScopeSessionsRead  = "sessions:read"  // List chat sessions and read their messages
+	ScopeMessagesWrite = "messages:write" // Create and delete sessions, send messages and stream replies
+	ScopeAnalyticsRead = "analytics:read" // Usage, activity search, wellness history and readiness, and the athlete logbook
+	ScopeLogbookManage = "logbook:manage" // Update the athlete logbook and wellness entries
+)
+
+// APITokenScopes lists every scope in the order they are shown to users
+var APITokenScopes = []string{ScopeSessionsRead, ScopeMessagesWrite, ScopeAnalyticsRead, ScopeLogbookManage}
+
+// APIToken is a personal access token a user creates for scripts. Only a hash of the token is
+// stored; TokenPrefix keeps its first characters so the user can tell tokens apart.
+type APIToken struct {
+	ID          string     `json:"id" db:"id"`
+	UserID      string     `json:"-" db:"user_id"`
+	Name        string     `json:"name" db:"name"`
+	TokenPrefix string     `json:"token_prefix" db:"token_prefix"`
+	TokenHash   string     `json:"-" db:"token_hash"`
+	Scopes      []string   `json:"scopes" db:"scopes"`
+	CreatedAt   time.Time  `json:"created_at" db:"created_at"`
+	ExpiresAt   time.Time  `json:"expires_at" db:"expires_at"`
+	LastUsedAt  *time.Time `json:"last_used_at,omitempty" db:"last_used_at"`
+	LastUsedIP  string     `json:"last_used_ip,omitempty" db:"last_used_ip"`
+	RevokedAt   *time.Time `json:"revoked_at,omitempty" db:"revoked_at"`
+}
+
+// HasScope reports whether the token was granted scope
+func (t *APIToken) HasScope(scope string) bool {
+	for _, granted := range t.Scopes {
+		if granted == scope {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,64 @@
+package models
+
+import (
+	"time"
+)
+
+// Coaching link statuses
+const (
+	CoachingStatusPending = "pending" // Invitation created by the athlete, not yet accepted
+	CoachingStatusActive  = "active"
+	CoachingStatusEnded   = "ended" // Ended by either side, or an invitation that was cancelled
+)
+
+// Permissions an athlete can grant a coach
+const (
+	CoachPermissionViewLogbook  = "view_logbook"  // Read the athlete logbook
+	CoachPermissionViewTraining = "view_training" // Training summaries and AI sessions over the athlete's Strava data
+	CoachPermissionViewSessions = "view_sessions" // Read the athlete's own AI coaching conversations
+	CoachPermissionViewWellness = "view_wellness" // Daily wellness check-ins and readiness
+	CoachPermissionComment      = "comment"       // Leave comments for the athlete
+)
+
+// CoachPermissions lists every coach permission in the order they are shown to users
+var CoachPermissions = []string{CoachPermissionViewLogbook, CoachPermissionViewTraining, CoachPermissionViewSessions, CoachPermissionViewWellness, CoachPermissionComment}
+
+// CoachingLink connects a human coach to an athlete. Athletes create the link as an invitation and
+// choose the permissions; it becomes active once a coach accepts the invitation code.
+type CoachingLink struct {
+	ID              string     `json:"id" db:"id"`
+	AthleteID       string     `json:"athlete_id" db:"athlete_id"`
+	CoachID         *string    `json:"coach_id,omitempty" db:"coach_id"` // Nil until the invitation is accepted
+	Status          string     `json:"status" db:"status"`
+	Permissions     []string   `json:"permissions" db:"permissions"`
+	InviteHash      *string    `json:"-" db:"invite_hash"`
+	InviteExpiresAt *time.Time `json:"invite_expires_at,omitempty" db:"invite_expires_at"`
+	CreatedAt       time.Time  `json:"created_at" db:"created_at"`
+	AcceptedAt      *time.Time `json:"accepted_at,omitempty" db:"accepted_at"`
+	EndedAt         *time.Time `json:"ended_at,omitempty" db:"ended_at"`
+
+	// Display names of both sides, filled in when links are listed
+	AthleteName string `json:"athlete_name,omitempty" db:"-"`
+	CoachName   string `json:"coach_name,omitempty" db:"-"`
+}
+
+// Allows reports whether the athlete granted the coach permission
+func (l *CoachingLink) Allows(permission string) bool {
+	for _, granted := range l.Permissions {
+		if granted == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// CoachComment is a note a coach leaves for an athlete, optionally about one of their AI sessions
+type CoachComment struct {
+	ID        string    `json:"id" db:"id"`
+	CoachID   string    `json:"coach_id" db:"coach_id"`
+	AthleteID string    `json:"athlete_id" db:"athlete_id"`
+	SessionID *string   `json:"session_id,omitempty" db:"session_id"`
+	Body      string    `json:"body" db:"body"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	CoachName string    `json:"coach_name,omitempty" db:"-"`
+}
@@ -0,0 +1,52 @@
+package models
+
+import (
+	"time"
+)
+
+// Feedback ratings
+const (
+	FeedbackUp   = "up"
+	FeedbackDown = "down"
+)
+
+// ToolInvocation is a tool the assistant called while producing a reply
+type ToolInvocation struct {
+	Name      string `json:"name"`
+	Arguments string `json:"arguments,omitempty"` // JSON arguments as sent by the model
+	Round     int    `json:"round"`               // Analysis round, starting at 1
+}
+
+// Generation stop reasons
+const (
+	GenerationStopComplete  = "complete"   // The model answered without requesting more tools
+	GenerationStopMaxRounds = "max_rounds" // The round limit was reached while the model still requested tools
+	GenerationStopToolError = "tool_error" // A tool round failed and the reply was built from earlier results
+)
+
+// MessageGeneration records how an assistant reply was produced
+type MessageGeneration struct {
+	Model             string           `json:"model"`
+	PromptVersion     string           `json:"prompt_version,omitempty"` // Version of the base system prompt
+	SummaryInContext  bool             `json:"summary_in_context"`       // A compaction summary replaced older turns
+	CoachContext      bool             `json:"coach_context"`            // A coach was chatting about an athlete
+	InjuryRiskWarning bool             `json:"injury_risk_warning"`      // An injury risk warning was added to the prompt
+	Rounds            int              `json:"rounds"`                   // Tool-call rounds executed
+	StopReason        string           `json:"stop_reason,omitempty"`    // One of the GenerationStop values
+	ToolInvocations   []ToolInvocation `json:"tool_invocations"`
+}
+
+// MessageFeedback is a user's rating of an assistant reply. Generation is copied from the reply
+// when the feedback is stored; it is nil for replies from before generations were recorded.
+type MessageFeedback struct {
+	ID         string             `json:"id" db:"id"`
+	MessageID  string             `json:"message_id" db:"message_id"`
+	SessionID  string             `json:"session_id" db:"session_id"`
+	UserID     string             `json:"user_id" db:"user_id"`
+	Rating     string             `json:"rating" db:"rating"` // FeedbackUp or FeedbackDown
+	Reasons    []string           `json:"reasons" db:"reasons"`
+	Comment    string             `json:"comment,omitempty" db:"comment"`
+	Generation *MessageGeneration `json:"generation,omitempty" db:"generation"`
+	CreatedAt  time.Time          `json:"created_at" db:"created_at"`
+	UpdatedAt  time.Time          `json:"updated_at" db:"updated_at"`
+}
@@ -0,0 +1,42 @@
+package models
+
+import (
+	"time"
+)
+
+// Team member roles
+const (
+	TeamRoleOwner   = "owner"   // Created the team; manages members and the join code
+	TeamRoleCoach   = "coach"   // Sees the team dashboards and can ask the AI about the team
+	TeamRoleAthlete = "athlete" // Shares their training data with the team's owner and coaches
+)
+
+// Team is a club or squad whose members share training data with its staff
+type Team struct {
+	ID         string    `json:"id" db:"id"`
+	Name       string    `json:"name" db:"name"`
+	OwnerID    string    `json:"owner_id" db:"owner_id"`
+	InviteHash *string   `json:"-" db:"invite_hash"` // Nil when joining is disabled
+	CreatedAt  time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at" db:"updated_at"`
+
+	// Filled in when listing a user's teams
+	Role        string `json:"role,omitempty" db:"-"`
+	MemberCount int    `json:"member_count" db:"-"`
+}
+
+// TeamMember is a user's membership of a team
+type TeamMember struct {
+	TeamID   string    `json:"team_id" db:"team_id"`
+	UserID   string    `json:"user_id" db:"user_id"`
+	Role     string    `json:"role" db:"role"`
+	JoinedAt time.Time `json:"joined_at" db:"joined_at"`
+
+	// Filled in from the user when listing members
+	Name string `json:"name" db:"-"`
+}
+
+// IsTeamStaff reports whether the role may see team dashboards and member training data
+func IsTeamStaff(role string) bool {
+	return role == TeamRoleOwner || role == TeamRoleCoach
+}
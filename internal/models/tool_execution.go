@@ -95,4 +95,13 @@ type ErrorResponse struct {
 	} `json:"error"`
 	RequestID string    `json:"request_id"`
 	Timestamp time.Time `json:"timestamp"`
-}
\ No newline at end of file
+}
+// ToolCacheEntry is a persisted tool result served for repeated tool calls
+type ToolCacheEntry struct {
+	Key       string    `json:"cache_key" db:"cache_key"`
+	UserID    string    `json:"user_id" db:"user_id"`
+	ToolName  string    `json:"tool_name" db:"tool_name"`
+	Content   string    `json:"content" db:"content"`
+	ExpiresAt time.Time `json:"expires_at" db:"expires_at"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
@@ -0,0 +1,37 @@
+package models
+
+import (
+	"time"
+)
+
+// TokenUsageTotals aggregates LLM token consumption over a period
+type TokenUsageTotals struct {
+	InputTokens       int64 `json:"input_tokens" db:"input_tokens"`
+	OutputTokens      int64 `json:"output_tokens" db:"output_tokens"`
+	CachedInputTokens int64 `json:"cached_input_tokens" db:"cached_input_tokens"` // Subset of InputTokens served from the prompt cache
+	ReasoningTokens   int64 `json:"reasoning_tokens" db:"reasoning_tokens"`       // Subset of OutputTokens spent on reasoning
+	TotalTokens       int64 `json:"total_tokens" db:"total_tokens"`               // Input plus output tokens, counted against quotas
+	RequestCount      int64 `json:"request_count" db:"request_count"`
+}
+
+// DailyTokenUsage is token consumption for a single UTC day
+type DailyTokenUsage struct {
+	Date time.Time `json:"date" db:"usage_date"`
+	TokenUsageTotals
+}
+
+// SessionTokenUsage is token consumption for a single chat session
+type SessionTokenUsage struct {
+	SessionID    string  `json:"session_id" db:"session_id"`
+	SessionTitle *string `json:"session_title,omitempty" db:"title"` // Nil when the session was deleted
+	TokenUsageTotals
+}
+
+// UserTokenUsage is token consumption for a single user
+type UserTokenUsage struct {
+	UserID    string `json:"user_id" db:"user_id"`
+	StravaID  int64  `json:"strava_id" db:"strava_id"`
+	FirstName string `json:"first_name" db:"first_name"`
+	LastName  string `json:"last_name" db:"last_name"`
+	TokenUsageTotals
+}
@@ -17,21 +17,44 @@ type User struct {
 }
 
 type Session struct {
-	ID             string    `json:"id" db:"id"`
-	UserID         string    `json:"user_id" db:"user_id"`
-	Title          string    `json:"title" db:"title"`
-	LastResponseID *string   `json:"last_response_id,omitempty" db:"last_response_id"`
-	CreatedAt      time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt      time.Time `json:"updated_at" db:"updated_at"`
+	ID                     string     `json:"id" db:"id"`
+	UserID                 string     `json:"user_id" db:"user_id"`
+	Title                  string     `json:"title" db:"title"`
+	TitleCustomized        bool       `json:"title_customized" db:"title_customized"` // Set when the user chose the title, which stops automatic titling
+	LastResponseID         *string    `json:"last_response_id,omitempty" db:"last_response_id"`
+	Summary                *string    `json:"summary,omitempty" db:"summary"`                               // Rolling summary of compacted older turns
+	SummarizedMessageCount int        `json:"summarized_message_count" db:"summarized_message_count"`       // Leading messages covered by Summary
+	AthleteID              *string    `json:"athlete_id,omitempty" db:"athlete_id"`                         // Set when a coach is chatting about one of their athletes
+	Pinned                 bool       `json:"pinned" db:"pinned"`                                           // Pinned sessions are listed first
+	ArchivedAt             *time.Time `json:"archived_at,omitempty" db:"archived_at"`                       // Archived sessions are hidden from the default list
+	ForkedFromSessionID    *string    `json:"forked_from_session_id,omitempty" db:"forked_from_session_id"` // Session whose history was copied into this one
+	ActiveMessageID        *string    `json:"active_message_id,omitempty" db:"active_message_id"`           // Leaf of the branch the conversation continues from
+	CreatedAt              time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt              time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// SessionSearchResult is a session matching a full-text search, with its best matching message
+type SessionSearchResult struct {
+	Session          *Session  `json:"session"`
+	MessageID        string    `json:"message_id"`
+	MessageRole      string    `json:"message_role"`
+	MessageCreatedAt time.Time `json:"message_created_at"`
+	Snippet          string    `json:"snippet"`     // HTML-escaped excerpt with matches wrapped in <mark>
+	MatchCount       int       `json:"match_count"` // Messages in the session that match
 }
 
 type Message struct {
 	ID         string    `json:"id" db:"id"`
 	SessionID  string    `json:"session_id" db:"session_id"`
-	Role       string    `json:"role" db:"role"` // "user" or "assistant"
+	ParentID   *string   `json:"parent_id,omitempty" db:"parent_id"` // Previous message on this branch; nil for the first message
+	Role       string    `json:"role" db:"role"`                     // "user" or "assistant"
 	Content    string    `json:"content" db:"content"`
 	ResponseID *string   `json:"response_id,omitempty" db:"response_id"` // OpenAI Response ID for multi-turn conversations
 	CreatedAt  time.Time `json:"created_at" db:"created_at"`
+
+	// Filled in on the active path: IDs of the messages sharing this message's parent, oldest first,
+	// when an edit or regeneration has branched the conversation here
+	SiblingIDs []string `json:"sibling_ids,omitempty" db:"-"`
 }
 
 type AthleteLogbook struct {
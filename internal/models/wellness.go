@@ -0,0 +1,22 @@
+package models
+
+import (
+	"time"
+)
+
+// DailyWellness holds an athlete's recovery metrics for one calendar day.
+// Nil fields were not recorded. Subjective ratings use a 1-5 scale: fatigue and
+// soreness from 1 (none) to 5 (severe), mood from 1 (very low) to 5 (excellent).
+type DailyWellness struct {
+	UserID     string    `json:"user_id" db:"user_id"`
+	Date       time.Time `json:"date" db:"metric_date"`
+	RestingHR  *int      `json:"resting_hr,omitempty" db:"resting_hr"`   // Morning resting heart rate in bpm
+	HRVRMSSD   *float64  `json:"hrv_rmssd,omitempty" db:"hrv_rmssd"`     // Heart rate variability rMSSD in ms
+	SleepHours *float64  `json:"sleep_hours,omitempty" db:"sleep_hours"` // Total sleep the night before
+	Fatigue    *int      `json:"fatigue,omitempty" db:"fatigue"`
+	Soreness   *int      `json:"soreness,omitempty" db:"soreness"`
+	Mood       *int      `json:"mood,omitempty" db:"mood"`
+	Notes      string    `json:"notes,omitempty" db:"notes"`
+	CreatedAt  time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at" db:"updated_at"`
+}
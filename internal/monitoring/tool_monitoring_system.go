@@ -126,6 +126,24 @@ func (tms *ToolMonitoringSystem) RecordQueueDepth(depth int64) {
 	tms.performanceTracker.RecordQueueDepth(depth)
 }
 
+// RecordCacheHit records a tool result served from the cache
+func (tms *ToolMonitoringSystem) RecordCacheHit(toolName string) {
+	if !tms.enabled {
+		return
+	}
+
+	tms.performanceTracker.RecordCacheLookup(toolName, true)
+}
+
+// RecordCacheMiss records a cacheable tool call that had to be executed
+func (tms *ToolMonitoringSystem) RecordCacheMiss(toolName string) {
+	if !tms.enabled {
+		return
+	}
+
+	tms.performanceTracker.RecordCacheLookup(toolName, false)
+}
+
 // GetPerformanceMetrics returns current performance metrics
 func (tms *ToolMonitoringSystem) GetPerformanceMetrics() *ToolPerformanceMetrics {
 	if !tms.enabled {
@@ -247,4 +265,12 @@ func MonitorToolDiscovery(ctx context.Context, operation string, toolName string
 
 func RecordQueueDepth(depth int64) {
 	GetGlobalToolMonitoring().RecordQueueDepth(depth)
+}
+
+func RecordCacheHit(toolName string) {
+	GetGlobalToolMonitoring().RecordCacheHit(toolName)
+}
+
+func RecordCacheMiss(toolName string) {
+	GetGlobalToolMonitoring().RecordCacheMiss(toolName)
 }
\ No newline at end of file
@@ -256,6 +256,26 @@ func TestToolMonitoringSystem(t *testing.T) {
 			t.Errorf("Expected queue depth to be 3, got %d", metrics.QueueDepth)
 		}
 	})
+
+	t.Run("RecordCacheLookups", func(t *testing.T) {
+		monitoringSystem.RecordCacheHit(toolName)
+		monitoringSystem.RecordCacheHit(toolName)
+		monitoringSystem.RecordCacheMiss(toolName)
+
+		metrics := monitoringSystem.GetPerformanceMetrics()
+		if metrics.CacheHits[toolName] != 2 {
+			t.Errorf("Expected 2 cache hits, got %d", metrics.CacheHits[toolName])
+		}
+		if metrics.CacheMisses[toolName] != 1 {
+			t.Errorf("Expected 1 cache miss, got %d", metrics.CacheMisses[toolName])
+		}
+
+		toolMetrics := monitoringSystem.GetToolMetrics(toolName)
+		hitRate, ok := toolMetrics["cache_hit_rate_percent"].(float64)
+		if !ok || hitRate < 66 || hitRate > 67 {
+			t.Errorf("Expected cache hit rate of ~66.7%%, got %v", toolMetrics["cache_hit_rate_percent"])
+		}
+	})
 }
 
 func TestDisabledMonitoring(t *testing.T) {
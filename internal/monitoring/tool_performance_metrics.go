@@ -22,6 +22,8 @@ type ToolPerformanceMetrics struct {
 	ActiveExecutions      map[string]int64          `json:"active_executions"`
 	UserExecutionCount    map[string]int64          `json:"user_execution_count"`
 	LastExecutionTime     map[string]time.Time      `json:"last_execution_time"`
+	CacheHits             map[string]int64          `json:"cache_hits"`
+	CacheMisses           map[string]int64          `json:"cache_misses"`
 	PerformanceAlerts     []PerformanceAlert        `json:"performance_alerts"`
 }
 
@@ -70,6 +72,8 @@ func NewToolPerformanceTracker(logger *Logger, thresholds PerformanceThresholds)
 			ActiveExecutions:     make(map[string]int64),
 			UserExecutionCount:   make(map[string]int64),
 			LastExecutionTime:    make(map[string]time.Time),
+			CacheHits:            make(map[string]int64),
+			CacheMisses:          make(map[string]int64),
 			PerformanceAlerts:    make([]PerformanceAlert, 0),
 		},
 		thresholds: thresholds,
@@ -152,6 +156,18 @@ func (tpt *ToolPerformanceTracker) RecordToolExecutionEnd(ctx context.Context, t
 	tpt.checkPerformanceThresholds(toolName, userID, durationMs, success, isTimeout)
 }
 
+// RecordCacheLookup records a tool result cache hit or miss
+func (tpt *ToolPerformanceTracker) RecordCacheLookup(toolName string, hit bool) {
+	tpt.metrics.mu.Lock()
+	defer tpt.metrics.mu.Unlock()
+
+	if hit {
+		tpt.metrics.CacheHits[toolName]++
+	} else {
+		tpt.metrics.CacheMisses[toolName]++
+	}
+}
+
 // RecordQueueDepth records the current queue depth
 func (tpt *ToolPerformanceTracker) RecordQueueDepth(depth int64) {
 	tpt.metrics.mu.Lock()
@@ -323,6 +339,8 @@ func (tpt *ToolPerformanceTracker) GetMetrics() *ToolPerformanceMetrics {
 		ActiveExecutions:     make(map[string]int64),
 		UserExecutionCount:   make(map[string]int64),
 		LastExecutionTime:    make(map[string]time.Time),
+		CacheHits:            make(map[string]int64),
+		CacheMisses:          make(map[string]int64),
 		ConcurrentExecutions: tpt.metrics.ConcurrentExecutions,
 		QueueDepth:           tpt.metrics.QueueDepth,
 		PerformanceAlerts:    make([]PerformanceAlert, len(tpt.metrics.PerformanceAlerts)),
@@ -362,6 +380,12 @@ func (tpt *ToolPerformanceTracker) GetMetrics() *ToolPerformanceMetrics {
 	for k, v := range tpt.metrics.LastExecutionTime {
 		copy.LastExecutionTime[k] = v
 	}
+	for k, v := range tpt.metrics.CacheHits {
+		copy.CacheHits[k] = v
+	}
+	for k, v := range tpt.metrics.CacheMisses {
+		copy.CacheMisses[k] = v
+	}
 
 	// Copy alerts
 	for i, alert := range tpt.metrics.PerformanceAlerts {
@@ -387,6 +411,13 @@ func (tpt *ToolPerformanceTracker) GetToolMetrics(toolName string) map[string]in
 		"min_execution_time":     tpt.metrics.MinExecutionTime[toolName],
 		"active_executions":      tpt.metrics.ActiveExecutions[toolName],
 		"last_execution_time":    tpt.metrics.LastExecutionTime[toolName],
+		"cache_hits":             tpt.metrics.CacheHits[toolName],
+		"cache_misses":           tpt.metrics.CacheMisses[toolName],
+	}
+
+	// Calculate cache hit rate
+	if lookups := tpt.metrics.CacheHits[toolName] + tpt.metrics.CacheMisses[toolName]; lookups > 0 {
+		metrics["cache_hit_rate_percent"] = float64(tpt.metrics.CacheHits[toolName]) / float64(lookups) * 100
 	}
 
 	// Calculate success rate
@@ -0,0 +1,116 @@
+package server
+
+import (
+	"bodda/internal/models"
+	"bodda/internal/services"
+	"errors"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// searchActivities filters the authenticated user's Strava activities and returns compact summaries
+func (s *Server) searchActivities(c *gin.Context) {
+	user, exists := c.Get("user")
+	if !exists {
+		c.JSON(401, gin.H{
+			"error": "Authentication required",
+			"code":  "AUTH_REQUIRED",
+		})
+		return
+	}
+
+	req, ok := parseActivitySearchQuery(c)
+	if !ok {
+		return
+	}
+
+	userModel := user.(*models.User)
+	result, err := services.SearchActivities(c.Request.Context(), s.stravaService, userModel, req)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidActivitySearch):
+			c.JSON(400, gin.H{
+				"error": err.Error(),
+				"code":  "INVALID_PARAMETER",
+			})
+		case errors.Is(err, services.ErrRateLimitExceeded):
+			c.JSON(429, gin.H{
+				"error": "Strava rate limit exceeded",
+				"code":  "STRAVA_RATE_LIMITED",
+			})
+		case errors.Is(err, services.ErrTokenExpired), errors.Is(err, services.ErrInvalidToken):
+			c.JSON(401, gin.H{
+				"error": "Strava connection expired",
+				"code":  "STRAVA_REAUTH_REQUIRED",
+			})
+		default:
+			log.Printf("Error searching activities for user %s: %v", userModel.ID, err)
+			c.JSON(502, gin.H{
+				"error": "Failed to search activities",
+				"code":  "ACTIVITY_SEARCH_ERROR",
+			})
+		}
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"activities": result.Summaries(),
+		"count":      len(result.Matches),
+		"scanned":    result.Scanned,
+		"complete":   result.Complete,
+	})
+}
+
+// parseActivitySearchQuery builds a search request from query parameters.
+// sport_type may be repeated or comma-separated. Writes a 400 response and returns false on malformed numbers.
+func parseActivitySearchQuery(c *gin.Context) (services.ActivitySearchRequest, bool) {
+	req := services.ActivitySearchRequest{
+		After:    c.Query("after"),
+		Before:   c.Query("before"),
+		Keywords: c.Query("q"),
+		Trainer:  c.Query("trainer"),
+		Commute:  c.Query("commute"),
+		Race:     c.Query("race"),
+		GearID:   c.Query("gear_id"),
+	}
+
+	for _, value := range c.QueryArray("sport_type") {
+		req.SportTypes = append(req.SportTypes, strings.Split(value, ",")...)
+	}
+
+	floats := []struct {
+		name   string
+		target *float64
+	}{
+		{"min_distance_km", &req.MinDistanceKm},
+		{"max_distance_km", &req.MaxDistanceKm},
+		{"min_duration_minutes", &req.MinDurationMinutes},
+		{"max_duration_minutes", &req.MaxDurationMinutes},
+	}
+	for _, param := range floats {
+		raw := c.Query(param.name)
+		if raw == "" {
+			continue
+		}
+		value, err := strconv.ParseFloat(raw, 64)
+		if err != nil || value < 0 {
+			c.JSON(400, gin.H{
+				"error": "Invalid " + param.name + " parameter",
+				"code":  "INVALID_PARAMETER",
+			})
+			return req, false
+		}
+		*param.target = value
+	}
+
+	limit, ok := parseUsageQueryInt(c, "limit")
+	if !ok {
+		return req, false
+	}
+	req.Limit = limit
+
+	return req, true
+}
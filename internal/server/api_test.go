@@ -47,6 +47,14 @@ func (m *MockChatService) GetSessions(userID string) ([]*models.Session, error)
 	return args.Get(0).([]*models.Session), args.Error(1)
 }
 
+func (m *MockChatService) GetArchivedSessions(userID string) ([]*models.Session, error) {
+	args := m.Called(userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.Session), args.Error(1)
+}
+
 func (m *MockChatService) GetSession(sessionID string) (*models.Session, error) {
 	args := m.Called(sessionID)
 	if args.Get(0) == nil {
@@ -60,6 +68,35 @@ func (m *MockChatService) UpdateSessionTitle(sessionID, title string) error {
 	return args.Error(0)
 }
 
+func (m *MockChatService) SetGeneratedTitle(sessionID, title string) (bool, error) {
+	args := m.Called(sessionID, title)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockChatService) UpdateSession(sessionID string, update services.SessionUpdate) (*models.Session, error) {
+	args := m.Called(sessionID, update)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Session), args.Error(1)
+}
+
+func (m *MockChatService) SearchSessions(userID, query string, limit int) ([]*models.SessionSearchResult, error) {
+	args := m.Called(userID, query, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.SessionSearchResult), args.Error(1)
+}
+
+func (m *MockChatService) ForkSession(sessionID, messageID, title string) (*models.Session, error) {
+	args := m.Called(sessionID, messageID, title)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Session), args.Error(1)
+}
+
 func (m *MockChatService) DeleteSession(sessionID string) error {
 	args := m.Called(sessionID)
 	return args.Error(0)
@@ -73,6 +110,14 @@ func (m *MockChatService) SendMessage(sessionID, role, content string) (*models.
 	return args.Get(0).(*models.Message), args.Error(1)
 }
 
+func (m *MockChatService) SendMessageWithResponseID(sessionID, role, content string, responseID *string) (*models.Message, error) {
+	args := m.Called(sessionID, role, content, responseID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Message), args.Error(1)
+}
+
 func (m *MockChatService) GetMessages(sessionID string) ([]*models.Message, error) {
 	args := m.Called(sessionID)
 	if args.Get(0) == nil {
@@ -94,6 +139,30 @@ func (m *MockChatService) GetMessageCount(sessionID string) (int, error) {
 	return args.Int(0), args.Error(1)
 }
 
+func (m *MockChatService) EditMessage(sessionID, messageID, content string) (*models.Message, error) {
+	args := m.Called(sessionID, messageID, content)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Message), args.Error(1)
+}
+
+func (m *MockChatService) RegenerateFrom(sessionID, messageID string) (*models.Message, error) {
+	args := m.Called(sessionID, messageID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Message), args.Error(1)
+}
+
+func (m *MockChatService) SelectBranch(sessionID, messageID string) ([]*models.Message, error) {
+	args := m.Called(sessionID, messageID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.Message), args.Error(1)
+}
+
 func (m *MockChatService) StreamResponse(sessionID string, response chan string) error {
 	args := m.Called(sessionID, response)
 	return args.Error(0)
@@ -277,7 +346,7 @@ func TestServer_getSessions_UserNotInContext(t *testing.T) {
 	server.getSessions(c)
 
 	assert.Equal(t, http.StatusUnauthorized, w.Code)
-	assert.Contains(t, w.Body.String(), "user not found in context")
+	assert.Contains(t, w.Body.String(), "AUTH_REQUIRED")
 }
 
 func TestServer_createSession_Success(t *testing.T) {
@@ -460,7 +529,7 @@ func TestServer_sendMessage_Success(t *testing.T) {
 	mockChatService.On("GetSession", "test-session-id").Return(session, nil)
 	mockChatService.On("SendMessage", "test-session-id", "user", "Hello AI").Return(userMessage, nil)
 	mockChatService.On("GetMessages", "test-session-id").Return(messages, nil)
-	mockChatService.On("SendMessage", "test-session-id", "assistant", "Hello! How can I help you?").Return(assistantMessage, nil)
+	mockChatService.On("SendMessageWithResponseID", "test-session-id", "assistant", "Hello! How can I help you?", mock.Anything).Return(assistantMessage, nil)
 
 	mockLogbookService.On("GetLogbook", mock.Anything, "test-user-id").Return(nil, assert.AnError) // No logbook found
 
@@ -500,7 +569,7 @@ func TestServer_sendMessage_MissingContent(t *testing.T) {
 	server.sendMessage(c)
 
 	assert.Equal(t, http.StatusBadRequest, w.Code)
-	assert.Contains(t, w.Body.String(), "message content is required")
+	assert.Contains(t, w.Body.String(), "INVALID_REQUEST")
 }
 
 func TestServer_streamResponse_MissingMessage(t *testing.T) {
@@ -0,0 +1,192 @@
+package server
+
+import (
+	"errors"
+	"log"
+
+	"bodda/internal/models"
+	"bodda/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// apiTokenContextKey holds the personal API token a request was authenticated with. It is absent
+// for browser sessions, which are not limited by scopes.
+const apiTokenContextKey = "api_token"
+
+// authenticateAPIToken authenticates a request made with a personal API token and continues the
+// handler chain
+func (s *Server) authenticateAPIToken(c *gin.Context, token string) {
+	if s.apiTokenService == nil {
+		c.JSON(401, gin.H{"error": "invalid or expired token"})
+		c.Abort()
+		return
+	}
+
+	user, record, err := s.apiTokenService.Authenticate(c.Request.Context(), token, c.ClientIP())
+	if err != nil {
+		if !errors.Is(err, services.ErrInvalidAPIToken) {
+			log.Printf("Failed to authenticate API token: %v", err)
+		}
+		c.JSON(401, gin.H{"error": "invalid, expired or revoked API token"})
+		c.Abort()
+		return
+	}
+
+	c.Set("user", user)
+	c.Set(apiTokenContextKey, record)
+	c.Next()
+}
+
+// requestAPIToken returns the API token the request was authenticated with, if any
+func requestAPIToken(c *gin.Context) (*models.APIToken, bool) {
+	value, exists := c.Get(apiTokenContextKey)
+	if !exists {
+		return nil, false
+	}
+	token, ok := value.(*models.APIToken)
+	return token, ok
+}
+
+// requireScope rejects requests authenticated with an API token that was not granted scope
+func (s *Server) requireScope(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if token, ok := requestAPIToken(c); ok && !token.HasScope(scope) {
+			c.JSON(403, gin.H{
+				"error":          "API token is missing the " + scope + " scope",
+				"code":           "INSUFFICIENT_SCOPE",
+				"required_scope": scope,
+			})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// rejectAPITokens restricts a route to browser sessions. It guards credential management and
+// admin routes so a leaked API token cannot mint new tokens or escalate.
+func (s *Server) rejectAPITokens() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, ok := requestAPIToken(c); ok {
+			c.JSON(403, gin.H{
+				"error": "This endpoint is not available with an API token",
+				"code":  "SESSION_REQUIRED",
+			})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// listAPITokens returns the user's personal API tokens without their secrets
+func (s *Server) listAPITokens(c *gin.Context) {
+	user, exists := c.Get("user")
+	if !exists {
+		c.JSON(401, gin.H{
+			"error": "Authentication required",
+			"code":  "AUTH_REQUIRED",
+		})
+		return
+	}
+
+	userModel := user.(*models.User)
+	tokens, err := s.apiTokenService.ListTokens(c.Request.Context(), userModel.ID)
+	if err != nil {
+		log.Printf("Error listing API tokens for user %s: %v", userModel.ID, err)
+		c.JSON(500, gin.H{
+			"error": "Failed to retrieve API tokens",
+			"code":  "API_TOKENS_FETCH_FAILED",
+		})
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"tokens":           tokens,
+		"available_scopes": models.APITokenScopes,
+	})
+}
+
+// createAPIToken issues a personal API token. The secret is only returned in this response.
+func (s *Server) createAPIToken(c *gin.Context) {
+	user, exists := c.Get("user")
+	if !exists {
+		c.JSON(401, gin.H{
+			"error": "Authentication required",
+			"code":  "AUTH_REQUIRED",
+		})
+		return
+	}
+
+	var req services.CreateAPITokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{
+			"error": "Invalid request format",
+			"code":  "INVALID_REQUEST",
+		})
+		return
+	}
+
+	userModel := user.(*models.User)
+	created, err := s.apiTokenService.CreateToken(c.Request.Context(), userModel.ID, req)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidAPITokenRequest):
+			c.JSON(400, gin.H{
+				"error": err.Error(),
+				"code":  "INVALID_API_TOKEN_REQUEST",
+			})
+		case errors.Is(err, services.ErrAPITokenLimitReached):
+			c.JSON(409, gin.H{
+				"error": err.Error(),
+				"code":  "API_TOKEN_LIMIT_REACHED",
+			})
+		default:
+			log.Printf("Error creating API token for user %s: %v", userModel.ID, err)
+			c.JSON(500, gin.H{
+				"error": "Failed to create API token",
+				"code":  "API_TOKEN_CREATE_FAILED",
+			})
+		}
+		return
+	}
+
+	c.JSON(201, created)
+}
+
+// revokeAPIToken revokes one of the user's personal API tokens
+func (s *Server) revokeAPIToken(c *gin.Context) {
+	user, exists := c.Get("user")
+	if !exists {
+		c.JSON(401, gin.H{
+			"error": "Authentication required",
+			"code":  "AUTH_REQUIRED",
+		})
+		return
+	}
+
+	userModel := user.(*models.User)
+	tokenID := c.Param("id")
+	if err := s.apiTokenService.RevokeToken(c.Request.Context(), userModel.ID, tokenID); err != nil {
+		if errors.Is(err, services.ErrAPITokenNotFound) {
+			c.JSON(404, gin.H{
+				"error": "API token not found",
+				"code":  "API_TOKEN_NOT_FOUND",
+			})
+			return
+		}
+		log.Printf("Error revoking API token %s for user %s: %v", tokenID, userModel.ID, err)
+		c.JSON(500, gin.H{
+			"error": "Failed to revoke API token",
+			"code":  "API_TOKEN_REVOKE_FAILED",
+		})
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"message": "API token revoked",
+	})
+}
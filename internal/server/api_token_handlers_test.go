@@ -0,0 +1,226 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"bodda/internal/models"
+	"bodda/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockAPITokenService is a mock implementation of APITokenService
+type MockAPITokenService struct {
+	mock.Mock
+}
+
+func (m *MockAPITokenService) CreateToken(ctx context.Context, userID string, req services.CreateAPITokenRequest) (*services.CreatedAPIToken, error) {
+	args := m.Called(userID, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*services.CreatedAPIToken), args.Error(1)
+}
+
+func (m *MockAPITokenService) ListTokens(ctx context.Context, userID string) ([]*models.APIToken, error) {
+	args := m.Called(userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.APIToken), args.Error(1)
+}
+
+func (m *MockAPITokenService) RevokeToken(ctx context.Context, userID, tokenID string) error {
+	args := m.Called(userID, tokenID)
+	return args.Error(0)
+}
+
+func (m *MockAPITokenService) Authenticate(ctx context.Context, token, ipAddress string) (*models.User, *models.APIToken, error) {
+	args := m.Called(token)
+	if args.Get(0) == nil {
+		return nil, nil, args.Error(2)
+	}
+	return args.Get(0).(*models.User), args.Get(1).(*models.APIToken), args.Error(2)
+}
+
+// newAPITokenTestRouter mounts a scoped route, a browser-only route and the token routes behind authMiddleware
+func newAPITokenTestRouter(authService *MockAuthService, apiTokens *MockAPITokenService) *gin.Engine {
+	server := newOAuthTestServer(authService)
+	server.apiTokenService = apiTokens
+
+	ok := func(c *gin.Context) { c.JSON(200, gin.H{"ok": true}) }
+	router := gin.New()
+	api := router.Group("/api", server.authMiddleware())
+	api.GET("/sessions", server.requireScope(models.ScopeSessionsRead), ok)
+	api.POST("/sessions", server.requireScope(models.ScopeMessagesWrite), ok)
+	api.GET("/auth/tokens", server.rejectAPITokens(), server.listAPITokens)
+	api.POST("/auth/tokens", server.rejectAPITokens(), server.createAPIToken)
+	api.DELETE("/auth/tokens/:id", server.rejectAPITokens(), server.revokeAPIToken)
+	return router
+}
+
+func TestServer_authMiddleware_APIToken(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	user := &models.User{ID: "test-user-id", StravaID: 12345}
+	readOnly := &models.APIToken{ID: "token-id", UserID: user.ID, Scopes: []string{models.ScopeSessionsRead}}
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		token      string
+		wantStatus int
+		wantCode   string
+	}{
+		{"granted scope", "GET", "/api/sessions", "bdp_valid", 200, ""},
+		{"missing scope", "POST", "/api/sessions", "bdp_valid", 403, "INSUFFICIENT_SCOPE"},
+		{"token management needs a browser session", "GET", "/api/auth/tokens", "bdp_valid", 403, "SESSION_REQUIRED"},
+		{"revoked token", "GET", "/api/sessions", "bdp_revoked", 401, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			apiTokens := &MockAPITokenService{}
+			apiTokens.On("Authenticate", "bdp_valid").Return(user, readOnly, nil)
+			apiTokens.On("Authenticate", "bdp_revoked").Return(nil, nil, services.ErrInvalidAPIToken)
+			router := newAPITokenTestRouter(&MockAuthService{}, apiTokens)
+
+			req, _ := http.NewRequest(tt.method, tt.path, nil)
+			req.Header.Set("Authorization", "Bearer "+tt.token)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			if tt.wantCode != "" {
+				var response map[string]interface{}
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+				assert.Equal(t, tt.wantCode, response["code"])
+			}
+		})
+	}
+
+	t.Run("session JWTs are not limited by scopes", func(t *testing.T) {
+		authService := &MockAuthService{}
+		authService.On("ValidateToken", "session-jwt").Return(user, nil)
+		apiTokens := &MockAPITokenService{}
+		router := newAPITokenTestRouter(authService, apiTokens)
+
+		req, _ := http.NewRequest("POST", "/api/sessions", nil)
+		req.Header.Set("Authorization", "Bearer session-jwt")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, 200, w.Code)
+		apiTokens.AssertNotCalled(t, "Authenticate", mock.Anything)
+	})
+}
+
+func TestServer_apiTokenHandlers(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	user := &models.User{ID: "test-user-id", StravaID: 12345}
+	authService := &MockAuthService{}
+	authService.On("ValidateToken", "session-jwt").Return(user, nil)
+
+	send := func(router *gin.Engine, method, path, body string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, path, bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.AddCookie(&http.Cookie{Name: "auth_token", Value: "session-jwt"})
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("create returns the secret once", func(t *testing.T) {
+		apiTokens := &MockAPITokenService{}
+		request := services.CreateAPITokenRequest{Name: "notebook", Scopes: []string{models.ScopeAnalyticsRead}, ExpiresInDays: 30}
+		apiTokens.On("CreateToken", user.ID, request).Return(&services.CreatedAPIToken{
+			Token:  "bdp_secret",
+			Record: &models.APIToken{ID: "token-id", Name: "notebook", TokenPrefix: "bdp_secr", Scopes: request.Scopes, ExpiresAt: time.Now().Add(30 * 24 * time.Hour)},
+		}, nil)
+
+		w := send(newAPITokenTestRouter(authService, apiTokens), "POST", "/api/auth/tokens",
+			`{"name":"notebook","scopes":["analytics:read"],"expires_in_days":30}`)
+
+		assert.Equal(t, 201, w.Code)
+		var response map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, "bdp_secret", response["token"])
+		record := response["api_token"].(map[string]interface{})
+		assert.Equal(t, "bdp_secr", record["token_prefix"])
+		assert.NotContains(t, record, "token_hash")
+	})
+
+	t.Run("create rejects invalid requests", func(t *testing.T) {
+		apiTokens := &MockAPITokenService{}
+		apiTokens.On("CreateToken", user.ID, mock.Anything).Return(nil, services.ErrInvalidAPITokenRequest)
+
+		w := send(newAPITokenTestRouter(authService, apiTokens), "POST", "/api/auth/tokens", `{"name":"x","scopes":["admin"]}`)
+		assert.Equal(t, 400, w.Code)
+		assert.Contains(t, w.Body.String(), "INVALID_API_TOKEN_REQUEST")
+	})
+
+	t.Run("list", func(t *testing.T) {
+		apiTokens := &MockAPITokenService{}
+		apiTokens.On("ListTokens", user.ID).Return([]*models.APIToken{{ID: "token-id", Name: "notebook"}}, nil)
+
+		w := send(newAPITokenTestRouter(authService, apiTokens), "GET", "/api/auth/tokens", "")
+		assert.Equal(t, 200, w.Code)
+		var response map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Len(t, response["tokens"], 1)
+		assert.Len(t, response["available_scopes"], len(models.APITokenScopes))
+	})
+
+	t.Run("revoke unknown token", func(t *testing.T) {
+		apiTokens := &MockAPITokenService{}
+		apiTokens.On("RevokeToken", user.ID, "missing").Return(services.ErrAPITokenNotFound)
+
+		w := send(newAPITokenTestRouter(authService, apiTokens), "DELETE", "/api/auth/tokens/missing", "")
+		assert.Equal(t, 404, w.Code)
+		assert.Contains(t, w.Body.String(), "API_TOKEN_NOT_FOUND")
+	})
+}
+
+func TestServer_logbookRouteScopes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	user := &models.User{ID: "test-user-id", StravaID: 12345}
+	readToken := &models.APIToken{ID: "token-id", UserID: user.ID, Scopes: []string{models.ScopeAnalyticsRead}}
+
+	server := newOAuthTestServer(&MockAuthService{})
+	apiTokens := &MockAPITokenService{}
+	apiTokens.On("Authenticate", "bdp_read").Return(user, readToken, nil)
+	server.apiTokenService = apiTokens
+	logbookService := &MockLogbookService{}
+	logbookService.On("GetLogbook", mock.Anything, user.ID).Return(&models.AthleteLogbook{UserID: user.ID, Content: "Base phase"}, nil)
+	server.logbookService = logbookService
+	server.setupRoutes()
+
+	send := func(method string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, "/api/logbook", bytes.NewBufferString(`{"content":"Build phase"}`))
+		req.Header.Set("Authorization", "Bearer bdp_read")
+		w := httptest.NewRecorder()
+		server.router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := send("GET")
+	assert.Equal(t, 200, w.Code, "reading the logbook needs only analytics:read")
+	assert.Contains(t, w.Body.String(), "Base phase")
+
+	w = send("PUT")
+	assert.Equal(t, 403, w.Code)
+	assert.Contains(t, w.Body.String(), models.ScopeLogbookManage)
+	logbookService.AssertNotCalled(t, "UpdateLogbook", mock.Anything, mock.Anything, mock.Anything)
+}
@@ -0,0 +1,224 @@
+package server
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"bodda/internal/models"
+	"bodda/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// authCookieName holds the short-lived access JWT
+	authCookieName = "auth_token"
+	// refreshCookieName holds the refresh token that renews the access JWT
+	refreshCookieName = "refresh_token"
+)
+
+// clientInfo describes the device making the request, for the signed-in devices list
+func clientInfo(c *gin.Context) services.ClientInfo {
+	return services.ClientInfo{
+		UserAgent: c.Request.UserAgent(),
+		IPAddress: c.ClientIP(),
+	}
+}
+
+// setSessionCookies stores issued tokens in HTTP-only cookies that expire with the tokens. The
+// refresh cookie is left alone when no new refresh token was issued.
+func (s *Server) setSessionCookies(c *gin.Context, tokens *services.SessionTokens) {
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(authCookieName, tokens.AccessToken, secondsUntil(tokens.AccessExpiresAt), "/", "", s.secureCookies(), true)
+	if tokens.RefreshToken != "" {
+		c.SetCookie(refreshCookieName, tokens.RefreshToken, secondsUntil(tokens.RefreshExpiresAt), "/", "", s.secureCookies(), true)
+	}
+}
+
+func (s *Server) clearSessionCookies(c *gin.Context) {
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(authCookieName, "", -1, "/", "", s.secureCookies(), true)
+	c.SetCookie(refreshCookieName, "", -1, "/", "", s.secureCookies(), true)
+}
+
+func secondsUntil(t time.Time) int {
+	seconds := int(time.Until(t).Seconds())
+	if seconds < 1 {
+		return 1
+	}
+	return seconds
+}
+
+// refreshSessionFromCookie renews the login session of a browser whose access token expired,
+// so requests keep working without a separate round trip to /auth/refresh
+func (s *Server) refreshSessionFromCookie(c *gin.Context) (*models.User, bool) {
+	refreshToken, err := c.Cookie(refreshCookieName)
+	if err != nil || refreshToken == "" {
+		return nil, false
+	}
+
+	tokens, err := s.authService.RefreshSession(c.Request.Context(), refreshToken, clientInfo(c))
+	if err != nil {
+		if !errors.Is(err, services.ErrInvalidRefreshToken) && !errors.Is(err, services.ErrRefreshTokenReused) {
+			log.Printf("Failed to refresh login session: %v", err)
+		}
+		s.clearSessionCookies(c)
+		return nil, false
+	}
+
+	s.setSessionCookies(c, tokens)
+	return tokens.User, true
+}
+
+// handleRefreshSession exchanges a refresh token for new credentials. Browsers send the refresh
+// cookie; other clients post {"refresh_token": "..."} and receive the tokens in the response.
+func (s *Server) handleRefreshSession(c *gin.Context) {
+	refreshToken, err := c.Cookie(refreshCookieName)
+	fromCookie := err == nil && refreshToken != ""
+	if !fromCookie {
+		var req struct {
+			RefreshToken string `json:"refresh_token"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil || req.RefreshToken == "" {
+			c.JSON(401, gin.H{
+				"error": "Refresh token required",
+				"code":  "REFRESH_TOKEN_REQUIRED",
+			})
+			return
+		}
+		refreshToken = req.RefreshToken
+	}
+
+	tokens, err := s.authService.RefreshSession(c.Request.Context(), refreshToken, clientInfo(c))
+	if err != nil {
+		if fromCookie {
+			s.clearSessionCookies(c)
+		}
+		switch {
+		case errors.Is(err, services.ErrRefreshTokenReused):
+			c.JSON(401, gin.H{
+				"error": "Refresh token was already used; the session has been signed out",
+				"code":  "REFRESH_TOKEN_REUSED",
+			})
+		case errors.Is(err, services.ErrInvalidRefreshToken):
+			c.JSON(401, gin.H{
+				"error": "Invalid or expired refresh token",
+				"code":  "INVALID_REFRESH_TOKEN",
+			})
+		default:
+			log.Printf("Failed to refresh login session: %v", err)
+			c.JSON(500, gin.H{
+				"error": "Failed to refresh session",
+				"code":  "REFRESH_FAILED",
+			})
+		}
+		return
+	}
+
+	if fromCookie {
+		s.setSessionCookies(c, tokens)
+		c.JSON(200, gin.H{
+			"expires_at": tokens.AccessExpiresAt,
+		})
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"access_token":  tokens.AccessToken,
+		"refresh_token": tokens.RefreshToken,
+		"expires_at":    tokens.AccessExpiresAt,
+	})
+}
+
+// listLoginSessions returns the devices the user is signed in on
+func (s *Server) listLoginSessions(c *gin.Context) {
+	user, exists := c.Get("user")
+	if !exists {
+		c.JSON(401, gin.H{
+			"error": "Authentication required",
+			"code":  "AUTH_REQUIRED",
+		})
+		return
+	}
+
+	userModel := user.(*models.User)
+	currentRefreshToken, _ := c.Cookie(refreshCookieName)
+	sessions, err := s.authService.ListSessions(c.Request.Context(), userModel.ID, currentRefreshToken)
+	if err != nil {
+		log.Printf("Error listing login sessions for user %s: %v", userModel.ID, err)
+		c.JSON(500, gin.H{
+			"error": "Failed to retrieve login sessions",
+			"code":  "LOGIN_SESSIONS_FETCH_FAILED",
+		})
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"sessions": sessions,
+	})
+}
+
+// revokeLoginSession signs one device out
+func (s *Server) revokeLoginSession(c *gin.Context) {
+	user, exists := c.Get("user")
+	if !exists {
+		c.JSON(401, gin.H{
+			"error": "Authentication required",
+			"code":  "AUTH_REQUIRED",
+		})
+		return
+	}
+
+	userModel := user.(*models.User)
+	sessionID := c.Param("id")
+	if err := s.authService.RevokeSession(c.Request.Context(), userModel.ID, sessionID); err != nil {
+		if errors.Is(err, services.ErrLoginSessionNotFound) {
+			c.JSON(404, gin.H{
+				"error": "Login session not found",
+				"code":  "LOGIN_SESSION_NOT_FOUND",
+			})
+			return
+		}
+		log.Printf("Error revoking login session %s for user %s: %v", sessionID, userModel.ID, err)
+		c.JSON(500, gin.H{
+			"error": "Failed to revoke login session",
+			"code":  "LOGIN_SESSION_REVOKE_FAILED",
+		})
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"message": "Login session revoked",
+	})
+}
+
+// logoutEverywhere signs the user out on every device, including this one
+func (s *Server) logoutEverywhere(c *gin.Context) {
+	user, exists := c.Get("user")
+	if !exists {
+		c.JSON(401, gin.H{
+			"error": "Authentication required",
+			"code":  "AUTH_REQUIRED",
+		})
+		return
+	}
+
+	userModel := user.(*models.User)
+	revoked, err := s.authService.RevokeAllSessions(c.Request.Context(), userModel.ID)
+	if err != nil {
+		log.Printf("Error signing out user %s everywhere: %v", userModel.ID, err)
+		c.JSON(500, gin.H{
+			"error": "Failed to sign out of all devices",
+			"code":  "LOGOUT_ALL_FAILED",
+		})
+		return
+	}
+
+	s.clearSessionCookies(c)
+	c.JSON(200, gin.H{
+		"message":          "Signed out of all devices",
+		"revoked_sessions": revoked,
+	})
+}
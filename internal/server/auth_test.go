@@ -1,16 +1,21 @@
 package server
 
 import (
+	"context"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
 	"bodda/internal/config"
 	"bodda/internal/models"
+	"bodda/internal/services"
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
 // Mock AuthService
@@ -39,30 +44,122 @@ func (m *MockAuthService) RefreshStravaToken(user *models.User) error {
 	return args.Error(0)
 }
 
-func (m *MockAuthService) GenerateJWT(userID string) (string, error) {
+func (m *MockAuthService) StartSession(ctx context.Context, user *models.User, client services.ClientInfo) (*services.SessionTokens, error) {
+	args := m.Called(user, client)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*services.SessionTokens), args.Error(1)
+}
+
+func (m *MockAuthService) RefreshSession(ctx context.Context, refreshToken string, client services.ClientInfo) (*services.SessionTokens, error) {
+	args := m.Called(refreshToken, client)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*services.SessionTokens), args.Error(1)
+}
+
+func (m *MockAuthService) EndSession(ctx context.Context, refreshToken string) error {
+	args := m.Called(refreshToken)
+	return args.Error(0)
+}
+
+func (m *MockAuthService) ListSessions(ctx context.Context, userID, currentRefreshToken string) ([]*services.LoginSession, error) {
+	args := m.Called(userID, currentRefreshToken)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*services.LoginSession), args.Error(1)
+}
+
+func (m *MockAuthService) RevokeSession(ctx context.Context, userID, sessionID string) error {
+	args := m.Called(userID, sessionID)
+	return args.Error(0)
+}
+
+func (m *MockAuthService) RevokeAllSessions(ctx context.Context, userID string) (int, error) {
 	args := m.Called(userID)
-	return args.String(0), args.Error(1)
+	return args.Int(0), args.Error(1)
 }
 
-func (m *MockAuthService) GetStravaOAuthURL(state string) string {
-	args := m.Called(state)
+func (m *MockAuthService) GetStravaOAuthURL(state string, forceApproval bool) string {
+	args := m.Called(state, forceApproval)
 	return args.String(0)
 }
 
+func newOAuthTestServer(authService services.AuthService) *Server {
+	cfg := &config.Config{
+		JWTSecret:   "test-secret",
+		FrontendURL: "http://localhost:3000",
+	}
+	return &Server{
+		config:      cfg,
+		authService: authService,
+		oauthState:  services.NewOAuthStateManager(cfg),
+		router:      gin.New(),
+	}
+}
+
+// startOAuthLogin runs handleStravaOAuth and returns the issued state and state cookie
+func startOAuthLogin(t *testing.T, server *Server, authService *MockAuthService, query string, reconsent bool) (string, *http.Cookie) {
+	var state string
+	authService.On("GetStravaOAuthURL", mock.AnythingOfType("string"), reconsent).
+		Run(func(args mock.Arguments) { state = args.String(0) }).
+		Return("https://www.strava.com/oauth/authorize").
+		Once()
+
+	req, _ := http.NewRequest("GET", "/auth/strava"+query, nil)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+
+	server.handleStravaOAuth(c)
+	require.Equal(t, http.StatusFound, w.Code)
+
+	for _, cookie := range w.Result().Cookies() {
+		if cookie.Name == services.OAuthStateCookieName {
+			return state, cookie
+		}
+	}
+	t.Fatal("state cookie was not set")
+	return "", nil
+}
+
+// findCookie returns the cookie named name set by the response, or nil
+func findCookie(w *httptest.ResponseRecorder, name string) *http.Cookie {
+	for _, cookie := range w.Result().Cookies() {
+		if cookie.Name == name {
+			return cookie
+		}
+	}
+	return nil
+}
+
+// runOAuthCallback runs handleStravaCallback with the given query and optional state cookie
+func runOAuthCallback(server *Server, query string, cookie *http.Cookie) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("GET", "/auth/callback?"+query, nil)
+	if cookie != nil {
+		req.AddCookie(cookie)
+	}
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+
+	server.handleStravaCallback(c)
+	return w
+}
+
 func TestServer_handleStravaOAuth(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
 	mockAuthService := &MockAuthService{}
-	server := &Server{
-		config: &config.Config{
-			FrontendURL: "http://localhost:3000",
-		},
-		authService: mockAuthService,
-		router:      gin.New(),
-	}
+	server := newOAuthTestServer(mockAuthService)
 
-	expectedURL := "https://www.strava.com/oauth/authorize?client_id=test&state=random-state-string"
-	mockAuthService.On("GetStravaOAuthURL", "random-state-string").Return(expectedURL)
+	var issuedState string
+	mockAuthService.On("GetStravaOAuthURL", mock.AnythingOfType("string"), false).
+		Run(func(args mock.Arguments) { issuedState = args.String(0) }).
+		Return("https://www.strava.com/oauth/authorize?client_id=test")
 
 	req, _ := http.NewRequest("GET", "/auth/strava", nil)
 	w := httptest.NewRecorder()
@@ -73,20 +170,35 @@ func TestServer_handleStravaOAuth(t *testing.T) {
 	server.handleStravaOAuth(c)
 
 	assert.Equal(t, http.StatusFound, w.Code)
+	assert.Len(t, issuedState, 43, "state is 32 random bytes")
+
+	var stateCookie *http.Cookie
+	for _, cookie := range w.Result().Cookies() {
+		if cookie.Name == services.OAuthStateCookieName {
+			stateCookie = cookie
+		}
+	}
+	require.NotNil(t, stateCookie)
+	assert.True(t, stateCookie.HttpOnly)
+	assert.Equal(t, http.SameSiteLaxMode, stateCookie.SameSite)
+	assert.Equal(t, "/auth", stateCookie.Path)
+	assert.NotContains(t, stateCookie.Value, issuedState, "the cookie carries a signed payload")
 	mockAuthService.AssertExpectations(t)
+
+	// Each login gets a fresh state, and re-consent forces the Strava approval screen
+	secondAuth := &MockAuthService{}
+	server.authService = secondAuth
+	secondState, _ := startOAuthLogin(t, server, secondAuth, "?reconsent=1", true)
+	assert.NotEqual(t, issuedState, secondState)
+	secondAuth.AssertExpectations(t)
 }
 
 func TestServer_handleStravaCallback_Success(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
 	mockAuthService := &MockAuthService{}
-	server := &Server{
-		config: &config.Config{
-			FrontendURL: "http://localhost:3000",
-		},
-		authService: mockAuthService,
-		router:      gin.New(),
-	}
+	server := newOAuthTestServer(mockAuthService)
+	state, stateCookie := startOAuthLogin(t, server, mockAuthService, "?redirect=%2Fchat%2Fsession-1", false)
 
 	user := &models.User{
 		ID:        "test-user-id",
@@ -96,17 +208,18 @@ func TestServer_handleStravaCallback_Success(t *testing.T) {
 	}
 
 	mockAuthService.On("HandleStravaOAuth", "test-code").Return(user, nil)
-	mockAuthService.On("GenerateJWT", "test-user-id").Return("test-jwt-token", nil)
+	mockAuthService.On("StartSession", user, mock.AnythingOfType("services.ClientInfo")).Return(&services.SessionTokens{
+		User:             user,
+		AccessToken:      "test-jwt-token",
+		AccessExpiresAt:  time.Now().Add(15 * time.Minute),
+		RefreshToken:     "test-refresh-token",
+		RefreshExpiresAt: time.Now().Add(30 * 24 * time.Hour),
+	}, nil)
 
-	req, _ := http.NewRequest("GET", "/auth/callback?code=test-code", nil)
-	w := httptest.NewRecorder()
-	c, _ := gin.CreateTestContext(w)
-	c.Request = req
-
-	server.handleStravaCallback(c)
+	w := runOAuthCallback(server, "code=test-code&scope=read,activity:read_all,profile:read_all&state="+state, stateCookie)
 
 	assert.Equal(t, http.StatusFound, w.Code)
-	assert.Contains(t, w.Header().Get("Location"), "http://localhost:3000/chat")
+	assert.Equal(t, "http://localhost:3000/chat/session-1", w.Header().Get("Location"))
 	
 	// Check that auth cookie was set
 	cookies := w.Result().Cookies()
@@ -120,6 +233,13 @@ func TestServer_handleStravaCallback_Success(t *testing.T) {
 	assert.NotNil(t, authCookie)
 	assert.Equal(t, "test-jwt-token", authCookie.Value)
 	assert.True(t, authCookie.HttpOnly)
+	assert.InDelta(t, 900, authCookie.MaxAge, 5, "the access cookie expires with its token")
+
+	refreshCookie := findCookie(w, "refresh_token")
+	require.NotNil(t, refreshCookie)
+	assert.Equal(t, "test-refresh-token", refreshCookie.Value)
+	assert.True(t, refreshCookie.HttpOnly)
+	assert.Equal(t, http.SameSiteLaxMode, refreshCookie.SameSite)
 
 	mockAuthService.AssertExpectations(t)
 }
@@ -128,22 +248,62 @@ func TestServer_handleStravaCallback_MissingCode(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
 	mockAuthService := &MockAuthService{}
-	server := &Server{
-		authService: mockAuthService,
-		router:      gin.New(),
-	}
+	server := newOAuthTestServer(mockAuthService)
+	state, stateCookie := startOAuthLogin(t, server, mockAuthService, "", false)
 
-	req, _ := http.NewRequest("GET", "/auth/callback", nil)
-	w := httptest.NewRecorder()
-	c, _ := gin.CreateTestContext(w)
-	c.Request = req
-
-	server.handleStravaCallback(c)
+	w := runOAuthCallback(server, "state="+state, stateCookie)
 
 	assert.Equal(t, http.StatusBadRequest, w.Code)
 	assert.Contains(t, w.Body.String(), "authorization code not provided")
 }
 
+func TestServer_handleStravaCallback_RejectsLoginCSRF(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockAuthService := &MockAuthService{}
+	server := newOAuthTestServer(mockAuthService)
+	state, stateCookie := startOAuthLogin(t, server, mockAuthService, "", false)
+
+	tests := []struct {
+		name   string
+		query  string
+		cookie *http.Cookie
+	}{
+		{"no cookie", "code=attacker-code&scope=read,activity:read_all&state=" + state, nil},
+		{"no state", "code=attacker-code&scope=read,activity:read_all", stateCookie},
+		{"state from another login", "code=attacker-code&scope=read,activity:read_all&state=other-state", stateCookie},
+		{"forged cookie", "code=attacker-code&scope=read,activity:read_all&state=" + state, &http.Cookie{Name: services.OAuthStateCookieName, Value: stateCookie.Value + "x"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := runOAuthCallback(server, tt.query, tt.cookie)
+			assert.Equal(t, http.StatusFound, w.Code)
+			assert.Equal(t, "http://localhost:3000/?auth_error=invalid_state", w.Header().Get("Location"))
+		})
+	}
+
+	// The code is never exchanged, so no session is created
+	mockAuthService.AssertNotCalled(t, "HandleStravaOAuth", mock.Anything)
+}
+
+func TestServer_handleStravaCallback_ScopesAndDenial(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockAuthService := &MockAuthService{}
+	server := newOAuthTestServer(mockAuthService)
+
+	state, stateCookie := startOAuthLogin(t, server, mockAuthService, "", false)
+	w := runOAuthCallback(server, "code=test-code&scope=read,profile:read_all&state="+state, stateCookie)
+	assert.Equal(t, "http://localhost:3000/?auth_error=insufficient_scope", w.Header().Get("Location"))
+
+	state, stateCookie = startOAuthLogin(t, server, mockAuthService, "", false)
+	w = runOAuthCallback(server, "error=access_denied&state="+state, stateCookie)
+	assert.Equal(t, "http://localhost:3000/?auth_error=access_denied", w.Header().Get("Location"))
+
+	mockAuthService.AssertNotCalled(t, "HandleStravaOAuth", mock.Anything)
+}
+
 func TestServer_handleLogout(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
@@ -346,4 +506,182 @@ func TestServer_handleAuthCheck_UserNotInContext(t *testing.T) {
 
 	assert.Equal(t, http.StatusUnauthorized, w.Code)
 	assert.Contains(t, w.Body.String(), "user not found in context")
-}
\ No newline at end of file
+}
+func TestServer_handleLogout_EndsLoginSession(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockAuthService := &MockAuthService{}
+	server := newOAuthTestServer(mockAuthService)
+	mockAuthService.On("EndSession", "refresh-token").Return(nil)
+
+	req, _ := http.NewRequest("POST", "/auth/logout", nil)
+	req.AddCookie(&http.Cookie{Name: "refresh_token", Value: "refresh-token"})
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+
+	server.handleLogout(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	refreshCookie := findCookie(w, "refresh_token")
+	require.NotNil(t, refreshCookie)
+	assert.Equal(t, -1, refreshCookie.MaxAge)
+	mockAuthService.AssertExpectations(t)
+}
+
+func TestServer_authMiddleware_RefreshesExpiredSession(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	user := &models.User{ID: "test-user-id", StravaID: 12345}
+	renewed := &services.SessionTokens{
+		User:             user,
+		AccessToken:      "new-access-token",
+		AccessExpiresAt:  time.Now().Add(15 * time.Minute),
+		RefreshToken:     "new-refresh-token",
+		RefreshExpiresAt: time.Now().Add(30 * 24 * time.Hour),
+	}
+
+	tests := []struct {
+		name        string
+		accessToken string
+	}{
+		{"access cookie expired", ""},
+		{"access token rejected", "expired-token"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockAuthService := &MockAuthService{}
+			server := newOAuthTestServer(mockAuthService)
+			if tt.accessToken != "" {
+				mockAuthService.On("ValidateToken", tt.accessToken).Return(nil, assert.AnError)
+			}
+			mockAuthService.On("RefreshSession", "old-refresh-token", mock.AnythingOfType("services.ClientInfo")).Return(renewed, nil)
+
+			req, _ := http.NewRequest("GET", "/api/sessions", nil)
+			if tt.accessToken != "" {
+				req.AddCookie(&http.Cookie{Name: "auth_token", Value: tt.accessToken})
+			}
+			req.AddCookie(&http.Cookie{Name: "refresh_token", Value: "old-refresh-token"})
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = req
+
+			server.authMiddleware()(c)
+
+			assert.False(t, c.IsAborted())
+			contextUser, exists := c.Get("user")
+			assert.True(t, exists)
+			assert.Equal(t, user, contextUser)
+			assert.Equal(t, "new-access-token", findCookie(w, "auth_token").Value)
+			assert.Equal(t, "new-refresh-token", findCookie(w, "refresh_token").Value)
+			mockAuthService.AssertExpectations(t)
+		})
+	}
+
+	t.Run("revoked session", func(t *testing.T) {
+		mockAuthService := &MockAuthService{}
+		server := newOAuthTestServer(mockAuthService)
+		mockAuthService.On("RefreshSession", "stolen-token", mock.AnythingOfType("services.ClientInfo")).Return(nil, services.ErrRefreshTokenReused)
+
+		req, _ := http.NewRequest("GET", "/api/sessions", nil)
+		req.AddCookie(&http.Cookie{Name: "refresh_token", Value: "stolen-token"})
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = req
+
+		server.authMiddleware()(c)
+
+		assert.True(t, c.IsAborted())
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.Equal(t, -1, findCookie(w, "refresh_token").MaxAge)
+	})
+}
+
+func TestServer_handleRefreshSession(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockAuthService := &MockAuthService{}
+	server := newOAuthTestServer(mockAuthService)
+	server.router.POST("/auth/refresh", server.handleRefreshSession)
+
+	mockAuthService.On("RefreshSession", "api-refresh-token", mock.AnythingOfType("services.ClientInfo")).Return(&services.SessionTokens{
+		AccessToken:     "new-access-token",
+		AccessExpiresAt: time.Now().Add(15 * time.Minute),
+		RefreshToken:    "new-refresh-token",
+	}, nil)
+	mockAuthService.On("RefreshSession", "replayed-token", mock.AnythingOfType("services.ClientInfo")).Return(nil, services.ErrRefreshTokenReused)
+
+	req, _ := http.NewRequest("POST", "/auth/refresh", strings.NewReader(`{"refresh_token":"api-refresh-token"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	server.router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"access_token":"new-access-token"`)
+	assert.Contains(t, w.Body.String(), `"refresh_token":"new-refresh-token"`)
+	assert.Nil(t, findCookie(w, "auth_token"), "API clients get tokens in the body")
+
+	req, _ = http.NewRequest("POST", "/auth/refresh", nil)
+	req.AddCookie(&http.Cookie{Name: "refresh_token", Value: "replayed-token"})
+	w = httptest.NewRecorder()
+	server.router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Contains(t, w.Body.String(), "REFRESH_TOKEN_REUSED")
+	assert.Equal(t, -1, findCookie(w, "auth_token").MaxAge)
+
+	req, _ = http.NewRequest("POST", "/auth/refresh", nil)
+	w = httptest.NewRecorder()
+	server.router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Contains(t, w.Body.String(), "REFRESH_TOKEN_REQUIRED")
+}
+
+func TestServer_loginSessionHandlers(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockAuthService := &MockAuthService{}
+	server := newOAuthTestServer(mockAuthService)
+	user := &models.User{ID: "test-user-id"}
+	server.router.Use(func(c *gin.Context) { c.Set("user", user) })
+	server.router.GET("/api/auth/sessions", server.listLoginSessions)
+	server.router.DELETE("/api/auth/sessions/:id", server.revokeLoginSession)
+	server.router.POST("/api/auth/logout-all", server.logoutEverywhere)
+
+	mockAuthService.On("ListSessions", "test-user-id", "current-refresh").Return([]*services.LoginSession{
+		{ID: "session-1", DeviceName: "Firefox on Linux", Current: true},
+	}, nil)
+	mockAuthService.On("RevokeSession", "test-user-id", "session-2").Return(nil)
+	mockAuthService.On("RevokeSession", "test-user-id", "missing").Return(services.ErrLoginSessionNotFound)
+	mockAuthService.On("RevokeAllSessions", "test-user-id").Return(3, nil)
+
+	req, _ := http.NewRequest("GET", "/api/auth/sessions", nil)
+	req.AddCookie(&http.Cookie{Name: "refresh_token", Value: "current-refresh"})
+	w := httptest.NewRecorder()
+	server.router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"device_name":"Firefox on Linux"`)
+	assert.Contains(t, w.Body.String(), `"current":true`)
+
+	req, _ = http.NewRequest("DELETE", "/api/auth/sessions/session-2", nil)
+	w = httptest.NewRecorder()
+	server.router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	req, _ = http.NewRequest("DELETE", "/api/auth/sessions/missing", nil)
+	w = httptest.NewRecorder()
+	server.router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Contains(t, w.Body.String(), "LOGIN_SESSION_NOT_FOUND")
+
+	req, _ = http.NewRequest("POST", "/api/auth/logout-all", nil)
+	w = httptest.NewRecorder()
+	server.router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"revoked_sessions":3`)
+	assert.Equal(t, -1, findCookie(w, "auth_token").MaxAge)
+	assert.Equal(t, -1, findCookie(w, "refresh_token").MaxAge)
+
+	mockAuthService.AssertExpectations(t)
+}
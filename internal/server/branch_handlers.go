@@ -0,0 +1,260 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	"bodda/internal/models"
+	"bodda/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// editMessage replaces a user message with an edited copy on a new branch and answers it
+func (s *Server) editMessage(c *gin.Context) {
+	user, exists := c.Get("user")
+	if !exists {
+		c.JSON(401, gin.H{
+			"error": "Authentication required",
+			"code":  "AUTH_REQUIRED",
+		})
+		return
+	}
+
+	var req struct {
+		Content string `json:"content" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{
+			"error": "content is required",
+			"code":  "INVALID_REQUEST",
+		})
+		return
+	}
+
+	userModel := user.(*models.User)
+	session := s.ownedSession(c, userModel)
+	if session == nil {
+		return
+	}
+
+	subject, coach, link, ok := s.prepareReply(c, session, userModel)
+	if !ok {
+		return
+	}
+
+	userMessage, err := s.chatService.EditMessage(session.ID, c.Param("messageId"), req.Content)
+	if err != nil {
+		writeSessionError(c, err, "edit message")
+		return
+	}
+
+	assistantMessage := s.replyToActiveBranch(c, session.ID, userModel, subject, coach, link, userMessage.Content)
+	if assistantMessage == nil {
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"user_message":      userMessage,
+		"assistant_message": assistantMessage,
+	})
+}
+
+// regenerateMessage answers the user message behind an assistant reply again, keeping the
+// previous reply as a sibling branch
+func (s *Server) regenerateMessage(c *gin.Context) {
+	user, exists := c.Get("user")
+	if !exists {
+		c.JSON(401, gin.H{
+			"error": "Authentication required",
+			"code":  "AUTH_REQUIRED",
+		})
+		return
+	}
+
+	userModel := user.(*models.User)
+	session := s.ownedSession(c, userModel)
+	if session == nil {
+		return
+	}
+
+	subject, coach, link, ok := s.prepareReply(c, session, userModel)
+	if !ok {
+		return
+	}
+
+	prompt, err := s.chatService.RegenerateFrom(session.ID, c.Param("messageId"))
+	if err != nil {
+		writeSessionError(c, err, "regenerate message")
+		return
+	}
+
+	assistantMessage := s.replyToActiveBranch(c, session.ID, userModel, subject, coach, link, prompt.Content)
+	if assistantMessage == nil {
+		return
+	}
+
+	c.JSON(200, gin.H{"assistant_message": assistantMessage})
+}
+
+// selectBranch switches the session to the branch through a message and returns its messages
+func (s *Server) selectBranch(c *gin.Context) {
+	user, exists := c.Get("user")
+	if !exists {
+		c.JSON(401, gin.H{
+			"error": "Authentication required",
+			"code":  "AUTH_REQUIRED",
+		})
+		return
+	}
+
+	var req struct {
+		MessageID string `json:"message_id" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{
+			"error": "message_id is required",
+			"code":  "INVALID_REQUEST",
+		})
+		return
+	}
+
+	userModel := user.(*models.User)
+	session := s.ownedSession(c, userModel)
+	if session == nil {
+		return
+	}
+
+	messages, err := s.chatService.SelectBranch(session.ID, req.MessageID)
+	if err != nil {
+		writeSessionError(c, err, "select branch")
+		return
+	}
+
+	c.JSON(200, gin.H{"messages": messages})
+}
+
+// prepareReply runs the checks sendMessage makes before asking the assistant for a reply: token
+// quotas and, in a coach's session, the coach's access. It writes the error response on failure.
+func (s *Server) prepareReply(c *gin.Context, session *models.Session, user *models.User) (subject *models.User, coach *models.User, link *models.CoachingLink, ok bool) {
+	if !s.checkTokenQuota(c, user.ID) {
+		return nil, nil, nil, false
+	}
+
+	subject, coach, link, err := s.sessionSubject(c.Request.Context(), session, user)
+	if err != nil {
+		writeCoachingError(c, err, "authorize coach")
+		return nil, nil, nil, false
+	}
+
+	return subject, coach, link, true
+}
+
+// replyToActiveBranch asks the assistant to answer content, the user message that ends the
+// session's active branch, and saves the reply. It writes the error response and returns nil on
+// failure.
+func (s *Server) replyToActiveBranch(c *gin.Context, sessionID string, user, subject, coach *models.User, link *models.CoachingLink, content string) *models.Message {
+	messages, err := s.chatService.GetMessages(sessionID)
+	if err != nil || len(messages) == 0 {
+		log.Printf("Error getting conversation history: %v", err)
+		c.JSON(500, gin.H{"error": "failed to get conversation history"})
+		return nil
+	}
+
+	ctx := context.Background()
+
+	var logbook *models.AthleteLogbook
+	if canReadLogbook(link) {
+		logbook, err = s.logbookService.GetLogbook(ctx, subject.ID)
+		if err != nil {
+			// Logbook might not exist yet, that's okay
+			log.Printf("No logbook found for user %s: %v", subject.ID, err)
+		}
+	}
+
+	// Read the session again: branching may have reset its summary
+	session, err := s.chatService.GetSession(sessionID)
+	if err != nil {
+		log.Printf("Error getting session for last_response_id: %v", err)
+		c.JSON(500, gin.H{
+			"error": "Failed to get session",
+			"code":  "SESSION_ERROR",
+		})
+		return nil
+	}
+
+	var lastResponseID string
+	if session.LastResponseID != nil {
+		lastResponseID = *session.LastResponseID
+	}
+	var conversationSummary string
+	if session.Summary != nil {
+		conversationSummary = *session.Summary
+	}
+
+	msgCtx := &services.MessageContext{
+		UserID:                 user.ID,
+		SessionID:              sessionID,
+		Message:                content,
+		ConversationHistory:    messages[:len(messages)-1], // Exclude the message being answered
+		AthleteLogbook:         logbook,
+		User:                   subject,
+		LastResponseID:         lastResponseID,
+		ConversationSummary:    conversationSummary,
+		SummarizedMessageCount: session.SummarizedMessageCount,
+		Coach:                  coach,
+		CoachLink:              link,
+	}
+
+	aiResponse, err := s.aiService.ProcessMessageSync(ctx, msgCtx)
+	if err != nil {
+		log.Printf("Error processing AI message: %v", err)
+		writeAIError(c, err)
+		return nil
+	}
+
+	var responseIDPtr *string
+	if msgCtx.LastResponseID != "" {
+		responseIDPtr = &msgCtx.LastResponseID
+	}
+
+	assistantMessage, err := s.chatService.SendMessageWithResponseID(sessionID, "assistant", aiResponse, responseIDPtr)
+	if err != nil {
+		log.Printf("Error saving AI response: %v", err)
+		c.JSON(500, gin.H{
+			"error": "Failed to save AI response",
+			"code":  "RESPONSE_SAVE_ERROR",
+		})
+		return nil
+	}
+	s.recordGeneration(assistantMessage.ID, msgCtx)
+
+	return assistantMessage
+}
+
+// writeAIError maps errors from the AI service to responses
+func writeAIError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrOpenAIUnavailable):
+		c.JSON(503, gin.H{
+			"error": "AI service temporarily unavailable",
+			"code":  "AI_UNAVAILABLE",
+		})
+	case errors.Is(err, services.ErrOpenAIRateLimit):
+		c.JSON(429, gin.H{
+			"error": "AI service rate limit exceeded",
+			"code":  "AI_RATE_LIMIT",
+		})
+	case errors.Is(err, services.ErrContextTooLong):
+		c.JSON(400, gin.H{
+			"error": "Conversation is too long",
+			"code":  "CONTEXT_TOO_LONG",
+		})
+	default:
+		c.JSON(500, gin.H{
+			"error": "Failed to process message",
+			"code":  "AI_PROCESSING_ERROR",
+		})
+	}
+}
@@ -0,0 +1,149 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"bodda/internal/models"
+	"bodda/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer_editMessage(t *testing.T) {
+	server, mockChatService, mockAIService, mockLogbookService := createTestServer()
+
+	session := &models.Session{ID: "session-1", UserID: "test-user-id", Title: "Plans"}
+	edited := &models.Message{ID: "m3", SessionID: "session-1", Role: "user", Content: "Plan a 5k", SiblingIDs: []string{"m1", "m3"}}
+	reply := &models.Message{ID: "m4", SessionID: "session-1", Role: "assistant", Content: "Here is a 5k plan"}
+
+	mockChatService.On("GetSession", "session-1").Return(session, nil)
+	mockChatService.On("EditMessage", "session-1", "m1", "Plan a 5k").Return(edited, nil)
+	mockChatService.On("GetMessages", "session-1").Return([]*models.Message{edited}, nil)
+	mockChatService.On("SendMessageWithResponseID", "session-1", "assistant", "Here is a 5k plan", mock.Anything).Return(reply, nil)
+	mockLogbookService.On("GetLogbook", mock.Anything, "test-user-id").Return(nil, errors.New("not found"))
+	mockAIService.On("ProcessMessageSync", mock.Anything, mock.MatchedBy(func(msgCtx *services.MessageContext) bool {
+		return msgCtx.Message == "Plan a 5k" && len(msgCtx.ConversationHistory) == 0
+	})).Return("Here is a 5k plan", nil)
+
+	c, w := createAuthenticatedContext(server, "POST", "/api/sessions/session-1/messages/m1/edit", []byte(`{"content":"Plan a 5k"}`))
+	c.Params = gin.Params{{Key: "id", Value: "session-1"}, {Key: "messageId", Value: "m1"}}
+	server.editMessage(c)
+
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var response struct {
+		UserMessage      models.Message `json:"user_message"`
+		AssistantMessage models.Message `json:"assistant_message"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, "m3", response.UserMessage.ID)
+	assert.Equal(t, []string{"m1", "m3"}, response.UserMessage.SiblingIDs)
+	assert.Equal(t, "m4", response.AssistantMessage.ID)
+	mockChatService.AssertExpectations(t)
+}
+
+func TestServer_editMessage_Rejected(t *testing.T) {
+	server, mockChatService, mockAIService, _ := createTestServer()
+
+	mockChatService.On("GetSession", "session-1").Return(&models.Session{ID: "session-1", UserID: "test-user-id"}, nil)
+	mockChatService.On("EditMessage", "session-1", "m2", "Plan a 5k").
+		Return(nil, fmt.Errorf("%w: only user messages can be edited", services.ErrInvalidBranchMessage))
+
+	c, w := createAuthenticatedContext(server, "POST", "/api/sessions/session-1/messages/m2/edit", []byte(`{"content":"Plan a 5k"}`))
+	c.Params = gin.Params{{Key: "id", Value: "session-1"}, {Key: "messageId", Value: "m2"}}
+	server.editMessage(c)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "INVALID_BRANCH_MESSAGE")
+
+	c, w = createAuthenticatedContext(server, "POST", "/api/sessions/session-1/messages/m2/edit", []byte(`{}`))
+	c.Params = gin.Params{{Key: "id", Value: "session-1"}, {Key: "messageId", Value: "m2"}}
+	server.editMessage(c)
+	assert.Equal(t, http.StatusBadRequest, w.Code, "content is required")
+
+	mockAIService.AssertNotCalled(t, "ProcessMessageSync", mock.Anything, mock.Anything)
+}
+
+func TestServer_regenerateMessage(t *testing.T) {
+	server, mockChatService, mockAIService, mockLogbookService := createTestServer()
+
+	session := &models.Session{ID: "session-1", UserID: "test-user-id", Title: "Plans"}
+	question := &models.Message{ID: "m1", SessionID: "session-1", Role: "user", Content: "Plan a 10k"}
+	reply := &models.Message{ID: "m3", SessionID: "session-1", Role: "assistant", Content: "Another 10k plan", SiblingIDs: []string{"m2", "m3"}}
+
+	mockChatService.On("GetSession", "session-1").Return(session, nil)
+	mockChatService.On("RegenerateFrom", "session-1", "m2").Return(question, nil)
+	mockChatService.On("GetMessages", "session-1").Return([]*models.Message{question}, nil)
+	mockChatService.On("SendMessageWithResponseID", "session-1", "assistant", "Another 10k plan", mock.Anything).Return(reply, nil)
+	mockLogbookService.On("GetLogbook", mock.Anything, "test-user-id").Return(nil, errors.New("not found"))
+	mockAIService.On("ProcessMessageSync", mock.Anything, mock.MatchedBy(func(msgCtx *services.MessageContext) bool {
+		return msgCtx.Message == "Plan a 10k"
+	})).Return("Another 10k plan", nil)
+
+	c, w := createAuthenticatedContext(server, "POST", "/api/sessions/session-1/messages/m2/regenerate", nil)
+	c.Params = gin.Params{{Key: "id", Value: "session-1"}, {Key: "messageId", Value: "m2"}}
+	server.regenerateMessage(c)
+
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var response struct {
+		AssistantMessage models.Message `json:"assistant_message"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, []string{"m2", "m3"}, response.AssistantMessage.SiblingIDs)
+}
+
+func TestServer_regenerateMessage_AIUnavailable(t *testing.T) {
+	server, mockChatService, mockAIService, mockLogbookService := createTestServer()
+
+	question := &models.Message{ID: "m1", SessionID: "session-1", Role: "user", Content: "Plan a 10k"}
+	mockChatService.On("GetSession", "session-1").Return(&models.Session{ID: "session-1", UserID: "test-user-id"}, nil)
+	mockChatService.On("RegenerateFrom", "session-1", "m2").Return(question, nil)
+	mockChatService.On("GetMessages", "session-1").Return([]*models.Message{question}, nil)
+	mockLogbookService.On("GetLogbook", mock.Anything, "test-user-id").Return(nil, errors.New("not found"))
+	mockAIService.On("ProcessMessageSync", mock.Anything, mock.Anything).Return("", services.ErrOpenAIUnavailable)
+
+	c, w := createAuthenticatedContext(server, "POST", "/api/sessions/session-1/messages/m2/regenerate", nil)
+	c.Params = gin.Params{{Key: "id", Value: "session-1"}, {Key: "messageId", Value: "m2"}}
+	server.regenerateMessage(c)
+
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Contains(t, w.Body.String(), "AI_UNAVAILABLE")
+	mockChatService.AssertNotCalled(t, "SendMessageWithResponseID", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestServer_selectBranch(t *testing.T) {
+	server, mockChatService, _, _ := createTestServer()
+
+	mockChatService.On("GetSession", "session-1").Return(&models.Session{ID: "session-1", UserID: "test-user-id"}, nil)
+	mockChatService.On("SelectBranch", "session-1", "m1").Return([]*models.Message{
+		{ID: "m1", Role: "user", Content: "Plan a 10k", SiblingIDs: []string{"m1", "m3"}},
+		{ID: "m2", Role: "assistant", Content: "Here is a 10k plan"},
+	}, nil)
+	mockChatService.On("SelectBranch", "session-1", "missing").Return(nil, services.ErrMessageNotFound)
+
+	c, w := createAuthenticatedContext(server, "PUT", "/api/sessions/session-1/active-branch", []byte(`{"message_id":"m1"}`))
+	c.Params = gin.Params{{Key: "id", Value: "session-1"}}
+	server.selectBranch(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var response struct {
+		Messages []models.Message `json:"messages"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Len(t, response.Messages, 2)
+
+	c, w = createAuthenticatedContext(server, "PUT", "/api/sessions/session-1/active-branch", []byte(`{"message_id":"missing"}`))
+	c.Params = gin.Params{{Key: "id", Value: "session-1"}}
+	server.selectBranch(c)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	c, w = createAuthenticatedContext(server, "PUT", "/api/sessions/session-1/active-branch", []byte(`{}`))
+	c.Params = gin.Params{{Key: "id", Value: "session-1"}}
+	server.selectBranch(c)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
@@ -0,0 +1,494 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"bodda/internal/models"
+	"bodda/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// writeCoachingError maps coaching service errors to responses. action describes the failed
+// operation for the log and the 500 message.
+func writeCoachingError(c *gin.Context, err error, action string) {
+	switch {
+	case errors.Is(err, services.ErrCoachingAccessDenied):
+		c.JSON(403, gin.H{
+			"error": "You do not have access to this athlete's data",
+			"code":  "COACHING_ACCESS_DENIED",
+		})
+	case errors.Is(err, services.ErrInvalidCoachingInvitation):
+		c.JSON(400, gin.H{
+			"error": "Invitation code is invalid or has expired",
+			"code":  "INVALID_INVITATION",
+		})
+	case errors.Is(err, services.ErrAlreadyCoaching):
+		c.JSON(409, gin.H{
+			"error": "You already coach this athlete",
+			"code":  "ALREADY_COACHING",
+		})
+	case errors.Is(err, services.ErrCoachingLinkNotFound):
+		c.JSON(404, gin.H{
+			"error": "Coaching relationship not found",
+			"code":  "COACHING_LINK_NOT_FOUND",
+		})
+	case errors.Is(err, services.ErrSessionNotFound):
+		c.JSON(404, gin.H{
+			"error": "Session not found",
+			"code":  "SESSION_NOT_FOUND",
+		})
+	case errors.Is(err, services.ErrInvalidCoachingRequest):
+		c.JSON(400, gin.H{
+			"error": err.Error(),
+			"code":  "INVALID_COACHING_REQUEST",
+		})
+	default:
+		log.Printf("Failed to %s: %v", action, err)
+		c.JSON(500, gin.H{
+			"error": "Failed to " + action,
+			"code":  "COACHING_ERROR",
+		})
+	}
+}
+
+// sessionSubject resolves whose data the AI tools read in a session. In a coach's session about an
+// athlete this is the athlete, after checking the coach still has access; otherwise it is the user.
+// link is the coaching link that grants the access, nil in the user's own sessions.
+func (s *Server) sessionSubject(ctx context.Context, session *models.Session, user *models.User) (subject *models.User, coach *models.User, link *models.CoachingLink, err error) {
+	if session.AthleteID == nil {
+		return user, nil, nil, nil
+	}
+	if s.coachingService == nil {
+		return nil, nil, nil, services.ErrCoachingAccessDenied
+	}
+
+	athlete, link, err := s.coachingService.Authorize(ctx, user.ID, *session.AthleteID, models.CoachPermissionViewTraining)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return athlete, user, link, nil
+}
+
+// canReadLogbook reports whether the session subject's logbook may be put in the AI context
+func canReadLogbook(link *models.CoachingLink) bool {
+	return link == nil || link.Allows(models.CoachPermissionViewLogbook)
+}
+
+// listCoaches returns the authenticated athlete's coaches and pending invitations
+func (s *Server) listCoaches(c *gin.Context) {
+	user, exists := c.Get("user")
+	if !exists {
+		c.JSON(401, gin.H{
+			"error": "Authentication required",
+			"code":  "AUTH_REQUIRED",
+		})
+		return
+	}
+
+	userModel := user.(*models.User)
+	links, err := s.coachingService.ListCoaches(c.Request.Context(), userModel.ID)
+	if err != nil {
+		writeCoachingError(c, err, "retrieve coaches")
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"coaches":               links,
+		"available_permissions": models.CoachPermissions,
+	})
+}
+
+// createCoachingInvitation creates an invitation code the athlete shares with a coach
+func (s *Server) createCoachingInvitation(c *gin.Context) {
+	user, exists := c.Get("user")
+	if !exists {
+		c.JSON(401, gin.H{
+			"error": "Authentication required",
+			"code":  "AUTH_REQUIRED",
+		})
+		return
+	}
+
+	var req struct {
+		Permissions []string `json:"permissions"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{
+			"error": "Invalid request format",
+			"code":  "INVALID_REQUEST",
+		})
+		return
+	}
+
+	userModel := user.(*models.User)
+	invitation, err := s.coachingService.CreateInvitation(c.Request.Context(), userModel.ID, req.Permissions)
+	if err != nil {
+		writeCoachingError(c, err, "create coaching invitation")
+		return
+	}
+
+	c.JSON(201, invitation)
+}
+
+// acceptCoachingInvitation makes the authenticated user the coach of the athlete who shared the code
+func (s *Server) acceptCoachingInvitation(c *gin.Context) {
+	user, exists := c.Get("user")
+	if !exists {
+		c.JSON(401, gin.H{
+			"error": "Authentication required",
+			"code":  "AUTH_REQUIRED",
+		})
+		return
+	}
+
+	var req struct {
+		Code string `json:"code"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{
+			"error": "Invalid request format",
+			"code":  "INVALID_REQUEST",
+		})
+		return
+	}
+
+	userModel := user.(*models.User)
+	link, err := s.coachingService.AcceptInvitation(c.Request.Context(), userModel.ID, req.Code)
+	if err != nil {
+		writeCoachingError(c, err, "accept coaching invitation")
+		return
+	}
+
+	c.JSON(200, gin.H{"link": link})
+}
+
+// updateCoachingPermissions changes what a coach may access; only the athlete can do this
+func (s *Server) updateCoachingPermissions(c *gin.Context) {
+	user, exists := c.Get("user")
+	if !exists {
+		c.JSON(401, gin.H{
+			"error": "Authentication required",
+			"code":  "AUTH_REQUIRED",
+		})
+		return
+	}
+
+	var req struct {
+		Permissions []string `json:"permissions"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{
+			"error": "Invalid request format",
+			"code":  "INVALID_REQUEST",
+		})
+		return
+	}
+
+	userModel := user.(*models.User)
+	if err := s.coachingService.UpdatePermissions(c.Request.Context(), userModel.ID, c.Param("id"), req.Permissions); err != nil {
+		writeCoachingError(c, err, "update coaching permissions")
+		return
+	}
+
+	c.JSON(200, gin.H{"message": "Coaching permissions updated"})
+}
+
+// endCoachingLink ends a coaching relationship or cancels an invitation, from either side
+func (s *Server) endCoachingLink(c *gin.Context) {
+	user, exists := c.Get("user")
+	if !exists {
+		c.JSON(401, gin.H{
+			"error": "Authentication required",
+			"code":  "AUTH_REQUIRED",
+		})
+		return
+	}
+
+	userModel := user.(*models.User)
+	if err := s.coachingService.EndLink(c.Request.Context(), userModel.ID, c.Param("id")); err != nil {
+		writeCoachingError(c, err, "end coaching relationship")
+		return
+	}
+
+	c.JSON(200, gin.H{"message": "Coaching relationship ended"})
+}
+
+// listCoachedAthletes returns the athletes the authenticated user coaches
+func (s *Server) listCoachedAthletes(c *gin.Context) {
+	user, exists := c.Get("user")
+	if !exists {
+		c.JSON(401, gin.H{
+			"error": "Authentication required",
+			"code":  "AUTH_REQUIRED",
+		})
+		return
+	}
+
+	userModel := user.(*models.User)
+	links, err := s.coachingService.ListAthletes(c.Request.Context(), userModel.ID)
+	if err != nil {
+		writeCoachingError(c, err, "retrieve athletes")
+		return
+	}
+
+	c.JSON(200, gin.H{"athletes": links})
+}
+
+// getAthleteLogbook returns a coached athlete's logbook
+func (s *Server) getAthleteLogbook(c *gin.Context) {
+	user, exists := c.Get("user")
+	if !exists {
+		c.JSON(401, gin.H{
+			"error": "Authentication required",
+			"code":  "AUTH_REQUIRED",
+		})
+		return
+	}
+
+	userModel := user.(*models.User)
+	athlete, _, err := s.coachingService.Authorize(c.Request.Context(), userModel.ID, c.Param("athleteId"), models.CoachPermissionViewLogbook)
+	if err != nil {
+		writeCoachingError(c, err, "authorize coach")
+		return
+	}
+
+	logbook, err := s.logbookService.GetLogbook(c.Request.Context(), athlete.ID)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			c.JSON(404, gin.H{
+				"error": "Logbook not found",
+				"code":  "LOGBOOK_NOT_FOUND",
+			})
+			return
+		}
+
+		log.Printf("Error getting logbook of athlete %s for coach %s: %v", athlete.ID, userModel.ID, err)
+		c.JSON(500, gin.H{
+			"error": "Failed to retrieve logbook",
+			"code":  "LOGBOOK_RETRIEVAL_ERROR",
+		})
+		return
+	}
+
+	c.JSON(200, gin.H{"logbook": logbook})
+}
+
+// getAthleteTrainingSummary returns weekly or monthly training totals for a coached athlete
+func (s *Server) getAthleteTrainingSummary(c *gin.Context) {
+	user, exists := c.Get("user")
+	if !exists {
+		c.JSON(401, gin.H{
+			"error": "Authentication required",
+			"code":  "AUTH_REQUIRED",
+		})
+		return
+	}
+
+	req := services.TrainingSummaryRequest{Period: c.DefaultQuery("period", services.SummaryPeriodWeek)}
+	if req.Period != services.SummaryPeriodWeek && req.Period != services.SummaryPeriodMonth {
+		c.JSON(400, gin.H{
+			"error": "Invalid period parameter, expected week or month",
+			"code":  "INVALID_PARAMETER",
+		})
+		return
+	}
+	if raw := c.Query("periods"); raw != "" {
+		periods, err := strconv.Atoi(raw)
+		if err != nil || periods < 1 {
+			c.JSON(400, gin.H{
+				"error": "Invalid periods parameter",
+				"code":  "INVALID_PARAMETER",
+			})
+			return
+		}
+		req.Periods = periods
+	}
+	for _, value := range c.QueryArray("sport_type") {
+		for _, sport := range strings.Split(value, ",") {
+			if sport = strings.TrimSpace(sport); sport != "" {
+				req.SportTypes = append(req.SportTypes, sport)
+			}
+		}
+	}
+
+	userModel := user.(*models.User)
+	athlete, _, err := s.coachingService.Authorize(c.Request.Context(), userModel.ID, c.Param("athleteId"), models.CoachPermissionViewTraining)
+	if err != nil {
+		writeCoachingError(c, err, "authorize coach")
+		return
+	}
+
+	summary, err := services.GetTrainingSummary(c.Request.Context(), s.stravaService, athlete, req, time.Now())
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrRateLimitExceeded):
+			c.JSON(429, gin.H{
+				"error": "Strava rate limit exceeded",
+				"code":  "STRAVA_RATE_LIMITED",
+			})
+		case errors.Is(err, services.ErrTokenExpired), errors.Is(err, services.ErrInvalidToken):
+			c.JSON(409, gin.H{
+				"error": "The athlete's Strava connection has expired",
+				"code":  "ATHLETE_STRAVA_REAUTH_REQUIRED",
+			})
+		default:
+			log.Printf("Error getting training summary of athlete %s for coach %s: %v", athlete.ID, userModel.ID, err)
+			c.JSON(502, gin.H{
+				"error": "Failed to build training summary",
+				"code":  "TRAINING_SUMMARY_ERROR",
+			})
+		}
+		return
+	}
+
+	c.JSON(200, gin.H{"summary": summary})
+}
+
+// listAthleteSessions returns a coached athlete's own AI coaching sessions
+func (s *Server) listAthleteSessions(c *gin.Context) {
+	user, exists := c.Get("user")
+	if !exists {
+		c.JSON(401, gin.H{
+			"error": "Authentication required",
+			"code":  "AUTH_REQUIRED",
+		})
+		return
+	}
+
+	userModel := user.(*models.User)
+	sessions, err := s.coachingService.ListAthleteSessions(c.Request.Context(), userModel.ID, c.Param("athleteId"))
+	if err != nil {
+		writeCoachingError(c, err, "retrieve athlete sessions")
+		return
+	}
+
+	c.JSON(200, gin.H{"sessions": sessions})
+}
+
+// getAthleteSessionMessages returns the messages of one of a coached athlete's sessions
+func (s *Server) getAthleteSessionMessages(c *gin.Context) {
+	user, exists := c.Get("user")
+	if !exists {
+		c.JSON(401, gin.H{
+			"error": "Authentication required",
+			"code":  "AUTH_REQUIRED",
+		})
+		return
+	}
+
+	userModel := user.(*models.User)
+	session, err := s.coachingService.GetAthleteSession(c.Request.Context(), userModel.ID, c.Param("athleteId"), c.Param("sessionId"))
+	if err != nil {
+		writeCoachingError(c, err, "retrieve athlete session")
+		return
+	}
+
+	messages, err := s.chatService.GetMessages(session.ID)
+	if err != nil {
+		log.Printf("Error getting messages of athlete session %s for coach %s: %v", session.ID, userModel.ID, err)
+		c.JSON(500, gin.H{
+			"error": "Failed to retrieve messages",
+			"code":  "MESSAGE_RETRIEVAL_ERROR",
+		})
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"session":  session,
+		"messages": messages,
+	})
+}
+
+// createCoachSession starts an AI session in which the coach asks about a coached athlete. The
+// session belongs to the coach and is used through the regular session endpoints.
+func (s *Server) createCoachSession(c *gin.Context) {
+	user, exists := c.Get("user")
+	if !exists {
+		c.JSON(401, gin.H{
+			"error": "Authentication required",
+			"code":  "AUTH_REQUIRED",
+		})
+		return
+	}
+
+	var req struct {
+		Title string `json:"title"`
+	}
+	// Title is optional, so an empty body is fine
+	_ = c.ShouldBindJSON(&req)
+
+	userModel := user.(*models.User)
+	session, err := s.coachingService.CreateCoachSession(c.Request.Context(), userModel.ID, c.Param("athleteId"), req.Title)
+	if err != nil {
+		writeCoachingError(c, err, "create coaching session")
+		return
+	}
+
+	c.JSON(201, gin.H{"session": session})
+}
+
+// listCoachComments returns comments for an athlete: all of them for the athlete themself, or the
+// caller's own comments for a coach
+func (s *Server) listCoachComments(c *gin.Context) {
+	user, exists := c.Get("user")
+	if !exists {
+		c.JSON(401, gin.H{
+			"error": "Authentication required",
+			"code":  "AUTH_REQUIRED",
+		})
+		return
+	}
+
+	userModel := user.(*models.User)
+	athleteID := c.Param("athleteId")
+	if athleteID == "" {
+		athleteID = userModel.ID
+	}
+
+	comments, err := s.coachingService.ListComments(c.Request.Context(), userModel.ID, athleteID)
+	if err != nil {
+		writeCoachingError(c, err, "retrieve comments")
+		return
+	}
+
+	c.JSON(200, gin.H{"comments": comments})
+}
+
+// addCoachComment leaves a comment for a coached athlete, optionally about one of their sessions
+func (s *Server) addCoachComment(c *gin.Context) {
+	user, exists := c.Get("user")
+	if !exists {
+		c.JSON(401, gin.H{
+			"error": "Authentication required",
+			"code":  "AUTH_REQUIRED",
+		})
+		return
+	}
+
+	var req struct {
+		Body      string  `json:"body"`
+		SessionID *string `json:"session_id"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{
+			"error": "Invalid request format",
+			"code":  "INVALID_REQUEST",
+		})
+		return
+	}
+
+	userModel := user.(*models.User)
+	comment, err := s.coachingService.AddComment(c.Request.Context(), userModel.ID, c.Param("athleteId"), req.SessionID, req.Body)
+	if err != nil {
+		writeCoachingError(c, err, "add comment")
+		return
+	}
+
+	c.JSON(201, gin.H{"comment": comment})
+}
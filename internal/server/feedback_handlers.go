@@ -0,0 +1,156 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"bodda/internal/models"
+	"bodda/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// writeFeedbackError maps feedback service errors to responses. action describes the failed
+// operation for the log and the 500 message.
+func writeFeedbackError(c *gin.Context, err error, action string) {
+	switch {
+	case errors.Is(err, services.ErrInvalidFeedback):
+		c.JSON(400, gin.H{
+			"error": err.Error(),
+			"code":  "INVALID_FEEDBACK",
+		})
+	case errors.Is(err, services.ErrMessageNotFound):
+		c.JSON(404, gin.H{
+			"error": "Assistant message not found in this session",
+			"code":  "MESSAGE_NOT_FOUND",
+		})
+	case errors.Is(err, services.ErrFeedbackNotFound):
+		c.JSON(404, gin.H{
+			"error": "Feedback not found",
+			"code":  "FEEDBACK_NOT_FOUND",
+		})
+	default:
+		log.Printf("Failed to %s: %v", action, err)
+		c.JSON(500, gin.H{
+			"error": "Failed to " + action,
+			"code":  "FEEDBACK_ERROR",
+		})
+	}
+}
+
+// submitFeedback rates an assistant reply in one of the authenticated user's sessions
+func (s *Server) submitFeedback(c *gin.Context) {
+	user, exists := c.Get("user")
+	if !exists {
+		c.JSON(401, gin.H{
+			"error": "Authentication required",
+			"code":  "AUTH_REQUIRED",
+		})
+		return
+	}
+
+	var req services.FeedbackInput
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{
+			"error": "Invalid request format",
+			"code":  "INVALID_REQUEST",
+		})
+		return
+	}
+
+	userModel := user.(*models.User)
+	session := s.ownedSession(c, userModel)
+	if session == nil {
+		return
+	}
+
+	feedback, err := s.feedbackService.SubmitFeedback(c.Request.Context(), userModel.ID, session.ID, c.Param("messageId"), req)
+	if err != nil {
+		writeFeedbackError(c, err, "save feedback")
+		return
+	}
+
+	c.JSON(200, gin.H{"feedback": feedback})
+}
+
+// deleteFeedback removes the authenticated user's rating of a reply
+func (s *Server) deleteFeedback(c *gin.Context) {
+	user, exists := c.Get("user")
+	if !exists {
+		c.JSON(401, gin.H{
+			"error": "Authentication required",
+			"code":  "AUTH_REQUIRED",
+		})
+		return
+	}
+
+	userModel := user.(*models.User)
+	session := s.ownedSession(c, userModel)
+	if session == nil {
+		return
+	}
+
+	if err := s.feedbackService.DeleteFeedback(c.Request.Context(), userModel.ID, c.Param("messageId")); err != nil {
+		writeFeedbackError(c, err, "delete feedback")
+		return
+	}
+
+	c.JSON(200, gin.H{"message": "Feedback deleted"})
+}
+
+// getFeedbackReport returns feedback rates by tool usage pattern for administrators
+func (s *Server) getFeedbackReport(c *gin.Context) {
+	days, ok := parseUsageQueryInt(c, "days")
+	if !ok {
+		return
+	}
+
+	report, err := s.feedbackService.GetReport(c.Request.Context(), days)
+	if err != nil {
+		writeFeedbackError(c, err, "build feedback report")
+		return
+	}
+
+	c.JSON(200, gin.H{"report": report})
+}
+
+// exportFeedback downloads rated replies with their conversations as JSON lines for offline
+// evaluation. Administrators only.
+func (s *Server) exportFeedback(c *gin.Context) {
+	days, ok := parseUsageQueryInt(c, "days")
+	if !ok {
+		return
+	}
+	export, err := s.feedbackService.PrepareExport(c.Request.Context(), days, c.Query("rating"))
+	if err != nil {
+		writeFeedbackError(c, err, "export feedback")
+		return
+	}
+
+	c.Header("Content-Type", "application/x-ndjson")
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="feedback-%s.jsonl"`, time.Now().UTC().Format("2006-01-02")))
+	if export.Truncated {
+		c.Header("X-Feedback-Truncated", "true")
+	}
+	c.Status(200)
+
+	// Lines are streamed, so a failure part way through can only be logged
+	written, err := s.feedbackService.ExportRated(c.Request.Context(), c.Writer, export)
+	if err != nil {
+		log.Printf("Failed to export feedback after %d examples: %v", written, err)
+	}
+}
+
+// recordGeneration stores the model and tool calls behind a saved reply. Failures are logged only.
+func (s *Server) recordGeneration(messageID string, msgCtx *services.MessageContext) {
+	if s.feedbackService == nil {
+		return
+	}
+
+	if err := s.feedbackService.RecordGeneration(context.Background(), messageID, &msgCtx.Generation); err != nil {
+		log.Printf("Failed to record generation for message %s: %v", messageID, err)
+	}
+}
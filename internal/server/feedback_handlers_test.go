@@ -0,0 +1,222 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"bodda/internal/models"
+	"bodda/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockFeedbackService is a mock implementation of FeedbackService
+type MockFeedbackService struct {
+	mock.Mock
+}
+
+func (m *MockFeedbackService) RecordGeneration(ctx context.Context, messageID string, generation *models.MessageGeneration) error {
+	args := m.Called(ctx, messageID, generation)
+	return args.Error(0)
+}
+
+func (m *MockFeedbackService) SubmitFeedback(ctx context.Context, userID, sessionID, messageID string, input services.FeedbackInput) (*models.MessageFeedback, error) {
+	args := m.Called(ctx, userID, sessionID, messageID, input)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.MessageFeedback), args.Error(1)
+}
+
+func (m *MockFeedbackService) DeleteFeedback(ctx context.Context, userID, messageID string) error {
+	args := m.Called(ctx, userID, messageID)
+	return args.Error(0)
+}
+
+func (m *MockFeedbackService) PrepareExport(ctx context.Context, days int, rating string) (*services.FeedbackExport, error) {
+	args := m.Called(ctx, days, rating)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*services.FeedbackExport), args.Error(1)
+}
+
+func (m *MockFeedbackService) ExportRated(ctx context.Context, w io.Writer, export *services.FeedbackExport) (int, error) {
+	args := m.Called(ctx, w, export)
+	if lines, ok := args.Get(0).(string); ok {
+		io.WriteString(w, lines)
+	}
+	return args.Int(1), args.Error(2)
+}
+
+func (m *MockFeedbackService) GetReport(ctx context.Context, days int) (*services.FeedbackReport, error) {
+	args := m.Called(ctx, days)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*services.FeedbackReport), args.Error(1)
+}
+
+func TestWriteFeedbackError(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		err    error
+		status int
+		code   string
+	}{
+		{fmt.Errorf("%w: unknown reason \"boring\"", services.ErrInvalidFeedback), 400, "INVALID_FEEDBACK"},
+		{services.ErrMessageNotFound, 404, "MESSAGE_NOT_FOUND"},
+		{services.ErrFeedbackNotFound, 404, "FEEDBACK_NOT_FOUND"},
+		{errors.New("connection reset"), 500, "FEEDBACK_ERROR"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.code, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+
+			writeFeedbackError(c, tt.err, "save feedback")
+
+			assert.Equal(t, tt.status, w.Code)
+			var body map[string]string
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+			assert.Equal(t, tt.code, body["code"])
+		})
+	}
+}
+
+func TestServer_submitFeedback(t *testing.T) {
+	server, mockChatService, _, _ := createTestServer()
+	mockFeedbackService := &MockFeedbackService{}
+	server.feedbackService = mockFeedbackService
+
+	input := services.FeedbackInput{Rating: "down", Reasons: []string{"too_long"}}
+	mockChatService.On("GetSession", "session-1").Return(&models.Session{ID: "session-1", UserID: "test-user-id"}, nil)
+	mockChatService.On("GetSession", "session-2").Return(&models.Session{ID: "session-2", UserID: "other-user"}, nil)
+	mockFeedbackService.On("SubmitFeedback", mock.Anything, "test-user-id", "session-1", "m2", input).
+		Return(&models.MessageFeedback{ID: "f1", MessageID: "m2", Rating: "down", Reasons: []string{"too_long"}}, nil)
+
+	c, w := createAuthenticatedContext(server, "PUT", "/api/sessions/session-1/messages/m2/feedback", []byte(`{"rating":"down","reasons":["too_long"]}`))
+	c.Params = gin.Params{{Key: "id", Value: "session-1"}, {Key: "messageId", Value: "m2"}}
+	server.submitFeedback(c)
+
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var response struct {
+		Feedback models.MessageFeedback `json:"feedback"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, "f1", response.Feedback.ID)
+
+	c, w = createAuthenticatedContext(server, "PUT", "/api/sessions/session-2/messages/m2/feedback", []byte(`{"rating":"up"}`))
+	c.Params = gin.Params{{Key: "id", Value: "session-2"}, {Key: "messageId", Value: "m2"}}
+	server.submitFeedback(c)
+	assert.Equal(t, http.StatusForbidden, w.Code, "other users' replies cannot be rated")
+
+	mockFeedbackService.AssertNumberOfCalls(t, "SubmitFeedback", 1)
+}
+
+func TestServer_deleteFeedback(t *testing.T) {
+	server, mockChatService, _, _ := createTestServer()
+	mockFeedbackService := &MockFeedbackService{}
+	server.feedbackService = mockFeedbackService
+
+	mockChatService.On("GetSession", "session-1").Return(&models.Session{ID: "session-1", UserID: "test-user-id"}, nil)
+	mockFeedbackService.On("DeleteFeedback", mock.Anything, "test-user-id", "m2").Return(nil).Once()
+	mockFeedbackService.On("DeleteFeedback", mock.Anything, "test-user-id", "m2").Return(services.ErrFeedbackNotFound)
+
+	c, w := createAuthenticatedContext(server, "DELETE", "/api/sessions/session-1/messages/m2/feedback", nil)
+	c.Params = gin.Params{{Key: "id", Value: "session-1"}, {Key: "messageId", Value: "m2"}}
+	server.deleteFeedback(c)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	c, w = createAuthenticatedContext(server, "DELETE", "/api/sessions/session-1/messages/m2/feedback", nil)
+	c.Params = gin.Params{{Key: "id", Value: "session-1"}, {Key: "messageId", Value: "m2"}}
+	server.deleteFeedback(c)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Contains(t, w.Body.String(), "FEEDBACK_NOT_FOUND")
+}
+
+func TestServer_exportFeedback(t *testing.T) {
+	server, _, _, _ := createTestServer()
+	mockFeedbackService := &MockFeedbackService{}
+	server.feedbackService = mockFeedbackService
+
+	export := &services.FeedbackExport{}
+	mockFeedbackService.On("PrepareExport", mock.Anything, 7, "down").Return(export, nil)
+	mockFeedbackService.On("ExportRated", mock.Anything, mock.Anything, export).Return("{\"rating\":\"down\"}\n", 1, nil)
+
+	c, w := createAuthenticatedContext(server, "GET", "/api/admin/feedback/export?days=7&rating=down", nil)
+	server.exportFeedback(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/x-ndjson", w.Header().Get("Content-Type"))
+	assert.Contains(t, w.Header().Get("Content-Disposition"), ".jsonl")
+	assert.Empty(t, w.Header().Get("X-Feedback-Truncated"))
+	assert.Equal(t, "{\"rating\":\"down\"}\n", w.Body.String())
+
+	truncated := &services.FeedbackExport{Truncated: true}
+	mockFeedbackService.On("PrepareExport", mock.Anything, 0, "").Return(truncated, nil)
+	mockFeedbackService.On("ExportRated", mock.Anything, mock.Anything, truncated).Return("", 0, nil)
+
+	c, w = createAuthenticatedContext(server, "GET", "/api/admin/feedback/export", nil)
+	server.exportFeedback(c)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "true", w.Header().Get("X-Feedback-Truncated"))
+
+	mockFeedbackService.On("PrepareExport", mock.Anything, 0, "sideways").Return(nil,
+		fmt.Errorf("%w: rating must be \"up\" or \"down\"", services.ErrInvalidFeedback))
+
+	c, w = createAuthenticatedContext(server, "GET", "/api/admin/feedback/export?rating=sideways", nil)
+	server.exportFeedback(c)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "INVALID_FEEDBACK")
+	mockFeedbackService.AssertNumberOfCalls(t, "ExportRated", 2)
+}
+
+func TestServer_getFeedbackReport(t *testing.T) {
+	server, _, _, _ := createTestServer()
+	mockFeedbackService := &MockFeedbackService{}
+	server.feedbackService = mockFeedbackService
+
+	mockFeedbackService.On("GetReport", mock.Anything, 14).Return(&services.FeedbackReport{
+		Totals: services.FeedbackRate{Rated: 4, Up: 3, Down: 1, HelpfulRate: 0.75},
+		ByToolPattern: []*services.ToolPatternFeedback{
+			{Pattern: "none", Tools: []string{}, FeedbackRate: services.FeedbackRate{Rated: 4, Up: 3, Down: 1, HelpfulRate: 0.75}},
+		},
+	}, nil)
+
+	c, w := createAuthenticatedContext(server, "GET", "/api/admin/feedback/report?days=14", nil)
+	server.getFeedbackReport(c)
+
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var response struct {
+		Report services.FeedbackReport `json:"report"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, 0.75, response.Report.Totals.HelpfulRate)
+	require.Len(t, response.Report.ByToolPattern, 1)
+	assert.Equal(t, 4, response.Report.ByToolPattern[0].Rated, "pattern rates are flattened into the pattern")
+	assert.Contains(t, w.Body.String(), `"helpful_rate":0.75`)
+}
+
+func TestServer_recordGeneration(t *testing.T) {
+	server, _, _, _ := createTestServer()
+	server.recordGeneration("m2", &services.MessageContext{}) // No feedback service configured
+
+	mockFeedbackService := &MockFeedbackService{}
+	server.feedbackService = mockFeedbackService
+	mockFeedbackService.On("RecordGeneration", mock.Anything, "m2", &models.MessageGeneration{Model: "gpt-5"}).Return(errors.New("message not found"))
+
+	server.recordGeneration("m2", &services.MessageContext{Generation: models.MessageGeneration{Model: "gpt-5"}})
+	mockFeedbackService.AssertExpectations(t)
+}
@@ -0,0 +1,90 @@
+package server
+
+import (
+	"log"
+	"strings"
+
+	"bodda/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxLogbookContentBytes bounds a logbook replaced through the API
+const maxLogbookContentBytes = 64 << 10
+
+// getLogbook returns the authenticated user's athlete logbook
+func (s *Server) getLogbook(c *gin.Context) {
+	user, exists := c.Get("user")
+	if !exists {
+		c.JSON(401, gin.H{
+			"error": "Authentication required",
+			"code":  "AUTH_REQUIRED",
+		})
+		return
+	}
+
+	userModel := user.(*models.User)
+	logbook, err := s.logbookService.GetLogbook(c.Request.Context(), userModel.ID)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			c.JSON(404, gin.H{
+				"error": "Logbook not found",
+				"code":  "LOGBOOK_NOT_FOUND",
+			})
+			return
+		}
+
+		log.Printf("Error getting logbook for user %s: %v", userModel.ID, err)
+		c.JSON(500, gin.H{
+			"error": "Failed to retrieve logbook",
+			"code":  "LOGBOOK_RETRIEVAL_ERROR",
+		})
+		return
+	}
+
+	c.JSON(200, gin.H{"logbook": logbook})
+}
+
+// putLogbook replaces the authenticated user's athlete logbook
+func (s *Server) putLogbook(c *gin.Context) {
+	user, exists := c.Get("user")
+	if !exists {
+		c.JSON(401, gin.H{
+			"error": "Authentication required",
+			"code":  "AUTH_REQUIRED",
+		})
+		return
+	}
+
+	var req struct {
+		Content string `json:"content"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{
+			"error": "Invalid request format",
+			"code":  "INVALID_REQUEST",
+		})
+		return
+	}
+
+	if strings.TrimSpace(req.Content) == "" || len(req.Content) > maxLogbookContentBytes {
+		c.JSON(400, gin.H{
+			"error": "Logbook content must be between 1 byte and 64 KB",
+			"code":  "INVALID_LOGBOOK_CONTENT",
+		})
+		return
+	}
+
+	userModel := user.(*models.User)
+	logbook, err := s.logbookService.UpsertLogbook(c.Request.Context(), userModel.ID, req.Content)
+	if err != nil {
+		log.Printf("Error saving logbook for user %s: %v", userModel.ID, err)
+		c.JSON(500, gin.H{
+			"error": "Failed to save logbook",
+			"code":  "LOGBOOK_SAVE_ERROR",
+		})
+		return
+	}
+
+	c.JSON(200, gin.H{"logbook": logbook})
+}
@@ -9,6 +9,8 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"net/http"
+	"net/url"
 	"strconv"
 	"strings"
 	"time"
@@ -18,46 +20,80 @@ import (
 )
 
 type Server struct {
-	config         *config.Config
-	db             *pgxpool.Pool
-	router         *gin.Engine
-	authService    services.AuthService
-	chatService    services.ChatService
-	aiService      services.AIService
-	stravaService  services.StravaService
-	logbookService services.LogbookService
-	repo           *database.Repository
-	toolController *ToolController
+	config          *config.Config
+	db              *pgxpool.Pool
+	router          *gin.Engine
+	authService     services.AuthService
+	oauthState      services.OAuthStateManager
+	chatService     services.ChatService
+	sessionTitles   services.SessionTitleService
+	aiService       services.AIService
+	stravaService   services.StravaService
+	logbookService  services.LogbookService
+	usageService    services.UsageService
+	wellnessService services.WellnessService
+	apiTokenService services.APITokenService
+	coachingService services.CoachingService
+	teamService     services.TeamService
+	feedbackService services.FeedbackService
+	repo            *database.Repository
+	toolController  *ToolController
 }
 
-func New(cfg *config.Config, db *pgxpool.Pool) *Server {
+func New(cfg *config.Config, db *pgxpool.Pool, tokenCipher *database.TokenCipher) *Server {
 	// Initialize repositories
-	repo := database.NewRepository(db)
+	repo := database.NewRepository(db, database.WithTokenCipher(tokenCipher))
 
 	// Initialize services
-	authService := services.NewAuthService(cfg, repo.User)
+	authService := services.NewAuthService(cfg, repo.User, repo.Refresh)
 	stravaService := services.NewStravaService(cfg, repo.User)
 	logbookService := services.NewLogbookService(repo.Logbook)
 	chatService := services.NewChatService(repo)
+	usageService := services.NewUsageService(cfg, repo.Usage)
+	wellnessService := services.NewWellnessService(repo.Wellness)
+	apiTokenService := services.NewAPITokenService(repo.APIToken, repo.User)
+	coachingService := services.NewCoachingService(repo.Coaching, repo.Session, repo.User)
+	teamService := services.NewTeamService(repo.Team, repo.User, stravaService)
 
 	// Initialize tool services
 	toolRegistry := services.NewToolRegistry()
-	aiService := services.NewAIService(cfg, stravaService, logbookService, repo.Session, toolRegistry)
+	aiService := services.NewAIService(cfg, stravaService, logbookService, repo.Session, toolRegistry,
+		services.WithUsageRecorder(usageService),
+		services.WithWellnessService(wellnessService),
+		services.WithTeamService(teamService))
 	toolExecutionService := services.NewToolExecutionAdapter(aiService)
-	toolExecutor := services.NewToolExecutor(toolExecutionService, toolRegistry)
+	var toolExecutorOpts []services.ToolExecutorOption
+	if cfg.ToolExecution.EnableCaching {
+		var cacheStore services.ToolResultStore
+		if cfg.ToolExecution.CachePersistent {
+			cacheStore = repo.ToolCache
+		}
+		toolCache := services.NewToolResultCache(cfg.ToolExecution.CacheMaxEntries, cacheStore)
+		toolExecutorOpts = append(toolExecutorOpts,
+			services.WithToolResultCache(toolCache, time.Duration(cfg.ToolExecution.CacheTTL)*time.Second))
+	}
+	toolExecutor := services.NewToolExecutor(toolExecutionService, toolRegistry, toolExecutorOpts...)
 	toolController := NewToolController(toolRegistry, toolExecutor, cfg)
 
 	s := &Server{
-		config:         cfg,
-		db:             db,
-		router:         gin.Default(),
-		authService:    authService,
-		chatService:    chatService,
-		aiService:      aiService,
-		stravaService:  stravaService,
-		logbookService: logbookService,
-		repo:           repo,
-		toolController: toolController,
+		config:          cfg,
+		db:              db,
+		router:          gin.Default(),
+		authService:     authService,
+		oauthState:      services.NewOAuthStateManager(cfg),
+		chatService:     chatService,
+		sessionTitles:   services.NewSessionTitleService(cfg, chatService, usageService),
+		aiService:       aiService,
+		stravaService:   stravaService,
+		logbookService:  logbookService,
+		usageService:    usageService,
+		wellnessService: wellnessService,
+		apiTokenService: apiTokenService,
+		coachingService: coachingService,
+		teamService:     teamService,
+		feedbackService: services.NewFeedbackService(repo.Feedback),
+		repo:            repo,
+		toolController:  toolController,
 	}
 
 	s.setupRoutes()
@@ -72,19 +108,7 @@ func (s *Server) setupRoutes() {
 	s.router.Use(s.errorRecoveryMiddleware())
 
 	// CORS middleware
-	s.router.Use(func(c *gin.Context) {
-		c.Header("Access-Control-Allow-Origin", s.config.FrontendURL)
-		c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		c.Header("Access-Control-Allow-Headers", "Content-Type, Authorization")
-		c.Header("Access-Control-Allow-Credentials", "true")
-
-		if c.Request.Method == "OPTIONS" {
-			c.AbortWithStatus(204)
-			return
-		}
-
-		c.Next()
-	})
+	s.router.Use(s.corsMiddleware())
 
 	// Health check
 	s.router.GET("/health", func(c *gin.Context) {
@@ -96,6 +120,7 @@ func (s *Server) setupRoutes() {
 	{
 		auth.GET("/strava", s.handleStravaOAuth)
 		auth.GET("/callback", s.handleStravaCallback)
+		auth.POST("/refresh", s.handleRefreshSession)
 		auth.POST("/logout", s.handleLogout)
 	}
 
@@ -104,18 +129,100 @@ func (s *Server) setupRoutes() {
 	apiAuth.Use(s.authMiddleware())
 	{
 		apiAuth.GET("/check", s.handleAuthCheck)
+
+		// Credentials can only be managed from a signed-in browser, never with an API token
+		apiAuth.GET("/sessions", s.rejectAPITokens(), s.listLoginSessions)
+		apiAuth.DELETE("/sessions/:id", s.rejectAPITokens(), s.revokeLoginSession)
+		apiAuth.POST("/logout-all", s.rejectAPITokens(), s.logoutEverywhere)
+		apiAuth.GET("/tokens", s.rejectAPITokens(), s.listAPITokens)
+		apiAuth.POST("/tokens", s.rejectAPITokens(), s.createAPIToken)
+		apiAuth.DELETE("/tokens/:id", s.rejectAPITokens(), s.revokeAPIToken)
 	}
 
 	// API routes
 	api := s.router.Group("/api")
 	api.Use(s.authMiddleware())
 	{
-		api.GET("/sessions", s.getSessions)
-		api.POST("/sessions", s.createSession)
-		api.DELETE("/sessions/:id", s.deleteSession)
-		api.GET("/sessions/:id/messages", s.getMessages)
-		api.POST("/sessions/:id/messages", s.sendMessage)
-		api.GET("/sessions/:id/stream", s.streamResponse)
+		// Requests made with a personal API token need the matching scope
+		readSessions := s.requireScope(models.ScopeSessionsRead)
+		writeMessages := s.requireScope(models.ScopeMessagesWrite)
+		readAnalytics := s.requireScope(models.ScopeAnalyticsRead)
+		manageLogbook := s.requireScope(models.ScopeLogbookManage)
+
+		api.GET("/sessions", readSessions, s.getSessions)
+		api.GET("/sessions/search", readSessions, s.searchSessions)
+		api.POST("/sessions", writeMessages, s.createSession)
+		api.PATCH("/sessions/:id", writeMessages, s.updateSession)
+		api.DELETE("/sessions/:id", writeMessages, s.deleteSession)
+		api.POST("/sessions/:id/fork", writeMessages, s.forkSession)
+		api.GET("/sessions/:id/messages", readSessions, s.getMessages)
+		api.POST("/sessions/:id/messages", writeMessages, s.sendMessage)
+		api.GET("/sessions/:id/stream", writeMessages, s.streamResponse)
+		api.POST("/sessions/:id/messages/:messageId/edit", writeMessages, s.editMessage)
+		api.POST("/sessions/:id/messages/:messageId/regenerate", writeMessages, s.regenerateMessage)
+		api.PUT("/sessions/:id/active-branch", writeMessages, s.selectBranch)
+		api.PUT("/sessions/:id/messages/:messageId/feedback", writeMessages, s.submitFeedback)
+		api.DELETE("/sessions/:id/messages/:messageId/feedback", writeMessages, s.deleteFeedback)
+		api.GET("/usage", readAnalytics, s.getUsage)
+		api.GET("/activities/search", readAnalytics, s.searchActivities)
+		api.GET("/wellness", readAnalytics, s.getWellness)
+		api.GET("/wellness/readiness", readAnalytics, s.getReadiness)
+		api.POST("/wellness/import", manageLogbook, s.importWellness)
+		api.PUT("/wellness/:date", manageLogbook, s.putWellness)
+		api.DELETE("/wellness/:date", manageLogbook, s.deleteWellness)
+		api.GET("/logbook", readAnalytics, s.getLogbook)
+		api.PUT("/logbook", manageLogbook, s.putLogbook)
+	}
+
+	// Coaching routes: athletes invite coaches and grant permissions, coaches read athlete data
+	coaching := s.router.Group("/api/coaching")
+	coaching.Use(s.authMiddleware())
+	coaching.Use(s.rejectAPITokens())
+	{
+		coaching.GET("/coaches", s.listCoaches)
+		coaching.GET("/comments", s.listCoachComments)
+		coaching.POST("/invitations", s.createCoachingInvitation)
+		coaching.POST("/invitations/accept", s.acceptCoachingInvitation)
+		coaching.PUT("/links/:id/permissions", s.updateCoachingPermissions)
+		coaching.DELETE("/links/:id", s.endCoachingLink)
+		coaching.GET("/athletes", s.listCoachedAthletes)
+		coaching.GET("/athletes/:athleteId/logbook", s.getAthleteLogbook)
+		coaching.GET("/athletes/:athleteId/training-summary", s.getAthleteTrainingSummary)
+		coaching.GET("/athletes/:athleteId/sessions", s.listAthleteSessions)
+		coaching.POST("/athletes/:athleteId/sessions", s.createCoachSession)
+		coaching.GET("/athletes/:athleteId/sessions/:sessionId/messages", s.getAthleteSessionMessages)
+		coaching.GET("/athletes/:athleteId/comments", s.listCoachComments)
+		coaching.POST("/athletes/:athleteId/comments", s.addCoachComment)
+	}
+
+	// Team routes: members join with a code, owners manage the team, staff see the dashboard
+	teams := s.router.Group("/api/teams")
+	teams.Use(s.authMiddleware())
+	teams.Use(s.rejectAPITokens())
+	{
+		teams.GET("", s.listTeams)
+		teams.POST("", s.createTeam)
+		teams.POST("/join", s.joinTeam)
+		teams.GET("/:id", s.getTeam)
+		teams.PATCH("/:id", s.renameTeam)
+		teams.DELETE("/:id", s.deleteTeam)
+		teams.POST("/:id/join-code", s.rotateTeamJoinCode)
+		teams.DELETE("/:id/join-code", s.disableTeamJoinCode)
+		teams.PUT("/:id/members/:userId", s.updateTeamMemberRole)
+		teams.DELETE("/:id/members/:userId", s.removeTeamMember)
+		teams.GET("/:id/dashboard", s.getTeamDashboard)
+	}
+
+	// Admin routes (require authentication and admin access)
+	admin := s.router.Group("/api/admin")
+	admin.Use(s.authMiddleware())
+	admin.Use(s.rejectAPITokens())
+	admin.Use(s.adminMiddleware())
+	{
+		admin.GET("/usage", s.getUsageSummary)
+		admin.GET("/strava/rate-limits", s.getStravaRateLimits)
+		admin.GET("/feedback/report", s.getFeedbackReport)
+		admin.GET("/feedback/export", s.exportFeedback)
 	}
 
 	// Tool execution routes (development only)
@@ -124,6 +231,7 @@ func (s *Server) setupRoutes() {
 	tools.Use(InputValidationMiddleware())
 	tools.Use(WorkspaceBoundaryMiddleware())
 	tools.Use(s.authMiddleware())
+	tools.Use(s.rejectAPITokens())
 	{
 		tools.GET("", s.toolController.ListTools)
 		tools.GET("/:toolName/schema", s.toolController.GetToolSchema)
@@ -137,18 +245,53 @@ func (s *Server) Run(addr string) error {
 
 // Authentication handlers
 func (s *Server) handleStravaOAuth(c *gin.Context) {
-	state := "random-state-string" // In production, use a secure random state
-	url := s.authService.GetStravaOAuthURL(state)
+	// The state is bound to this browser through a signed cookie, which the callback must present
+	state, cookie, err := s.oauthState.Issue(c.Query("redirect"))
+	if err != nil {
+		log.Printf("Failed to issue OAuth state: %v", err)
+		c.JSON(500, gin.H{"error": "failed to start Strava login"})
+		return
+	}
+
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(services.OAuthStateCookieName, cookie, int(services.OAuthStateTTL.Seconds()), "/auth", "", s.secureCookies(), true)
+
+	url := s.authService.GetStravaOAuthURL(state, c.Query("reconsent") != "")
 	c.Redirect(302, url)
 }
 
 func (s *Server) handleStravaCallback(c *gin.Context) {
+	// The state cookie is single use
+	stateCookie, _ := c.Cookie(services.OAuthStateCookieName)
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(services.OAuthStateCookieName, "", -1, "/auth", "", s.secureCookies(), true)
+
+	if stravaErr := c.Query("error"); stravaErr != "" {
+		log.Printf("Strava authorization was not granted: %s", stravaErr)
+		s.redirectLoginError(c, "access_denied")
+		return
+	}
+
+	redirect, err := s.oauthState.Verify(stateCookie, c.Query("state"))
+	if err != nil {
+		log.Printf("Rejected Strava callback: %v", err)
+		s.redirectLoginError(c, "invalid_state")
+		return
+	}
+
 	code := c.Query("code")
 	if code == "" {
 		c.JSON(400, gin.H{"error": "authorization code not provided"})
 		return
 	}
 
+	// Athletes can untick scopes on the consent screen, so check what was actually granted before creating a session
+	if missing := services.MissingStravaScopes(c.Query("scope")); len(missing) > 0 {
+		log.Printf("Strava login granted insufficient scopes, missing: %s", strings.Join(missing, ","))
+		s.redirectLoginError(c, "insufficient_scope")
+		return
+	}
+
 	// Handle OAuth callback
 	user, err := s.authService.HandleStravaOAuth(code)
 	if err != nil {
@@ -156,23 +299,39 @@ func (s *Server) handleStravaCallback(c *gin.Context) {
 		return
 	}
 
-	// Generate JWT token
-	token, err := s.authService.GenerateJWT(user.ID)
+	// Start a login session with a short-lived access token and a rotating refresh token
+	tokens, err := s.authService.StartSession(c.Request.Context(), user, clientInfo(c))
 	if err != nil {
+		log.Printf("Failed to start login session for user %s: %v", user.ID, err)
 		c.JSON(500, gin.H{"error": "failed to generate session token"})
 		return
 	}
-
-	// Set JWT as HTTP-only cookie
-	c.SetCookie("auth_token", token, 86400, "/", "", false, true) // 24 hours
+	s.setSessionCookies(c, tokens)
 
 	// Redirect to frontend
-	c.Redirect(302, s.config.FrontendURL+"/chat")
+	c.Redirect(302, strings.TrimSuffix(s.config.FrontendURL, "/")+redirect)
+}
+
+// redirectLoginError sends the browser back to the landing page, which explains the error and offers to retry
+func (s *Server) redirectLoginError(c *gin.Context, reason string) {
+	c.Redirect(302, strings.TrimSuffix(s.config.FrontendURL, "/")+"/?auth_error="+url.QueryEscape(reason))
+}
+
+// secureCookies reports whether cookies should be restricted to HTTPS, which is the case when the frontend is served over TLS
+func (s *Server) secureCookies() bool {
+	return s.config != nil && strings.HasPrefix(s.config.FrontendURL, "https://")
 }
 
 func (s *Server) handleLogout(c *gin.Context) {
-	// Clear the auth cookie
-	c.SetCookie("auth_token", "", -1, "/", "", false, true)
+	// Revoke the login session so its refresh token cannot be used again
+	if refreshToken, err := c.Cookie(refreshCookieName); err == nil && refreshToken != "" {
+		if err := s.authService.EndSession(c.Request.Context(), refreshToken); err != nil {
+			log.Printf("Failed to end login session: %v", err)
+		}
+	}
+
+	// Clear the auth cookies
+	s.clearSessionCookies(c)
 	c.JSON(200, gin.H{"message": "logged out successfully"})
 }
 
@@ -197,6 +356,23 @@ func (s *Server) handleAuthCheck(c *gin.Context) {
 	})
 }
 
+// CORS middleware for the frontend origin
+func (s *Server) corsMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header("Access-Control-Allow-Origin", s.config.FrontendURL)
+		c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
+		c.Header("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		c.Header("Access-Control-Allow-Credentials", "true")
+
+		if c.Request.Method == "OPTIONS" {
+			c.AbortWithStatus(204)
+			return
+		}
+
+		c.Next()
+	}
+}
+
 // Request logging middleware
 func (s *Server) requestLoggingMiddleware() gin.HandlerFunc {
 	return gin.LoggerWithFormatter(func(param gin.LogFormatterParams) string {
@@ -235,11 +411,17 @@ func (s *Server) errorRecoveryMiddleware() gin.HandlerFunc {
 func (s *Server) authMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// Try to get token from cookie first
-		token, err := c.Cookie("auth_token")
+		token, err := c.Cookie(authCookieName)
 		if err != nil {
 			// Fallback to Authorization header
 			authHeader := c.GetHeader("Authorization")
 			if authHeader == "" {
+				// The access cookie expires with its token; renew it from the refresh cookie
+				if user, ok := s.refreshSessionFromCookie(c); ok {
+					c.Set("user", user)
+					c.Next()
+					return
+				}
 				c.JSON(401, gin.H{"error": "authentication required"})
 				c.Abort()
 				return
@@ -253,11 +435,24 @@ func (s *Server) authMiddleware() gin.HandlerFunc {
 				c.Abort()
 				return
 			}
+
+			// Personal API tokens are looked up in the database rather than verified as JWTs
+			if services.IsAPIToken(token) {
+				s.authenticateAPIToken(c, token)
+				return
+			}
 		}
 
 		// Validate token
 		user, err := s.authService.ValidateToken(token)
 		if err != nil {
+			if c.GetHeader("Authorization") == "" {
+				if user, ok := s.refreshSessionFromCookie(c); ok {
+					c.Set("user", user)
+					c.Next()
+					return
+				}
+			}
 			c.JSON(401, gin.H{"error": "invalid or expired token"})
 			c.Abort()
 			return
@@ -271,7 +466,7 @@ func (s *Server) authMiddleware() gin.HandlerFunc {
 
 // Session management handlers
 
-// getSessions retrieves all sessions for the authenticated user
+// getSessions retrieves the authenticated user's active sessions, or the archived ones with ?archived=true
 func (s *Server) getSessions(c *gin.Context) {
 	user, exists := c.Get("user")
 	if !exists {
@@ -283,7 +478,13 @@ func (s *Server) getSessions(c *gin.Context) {
 	}
 
 	userModel := user.(*models.User)
-	sessions, err := s.chatService.GetSessions(userModel.ID)
+	var sessions []*models.Session
+	var err error
+	if c.Query("archived") == "true" {
+		sessions, err = s.chatService.GetArchivedSessions(userModel.ID)
+	} else {
+		sessions, err = s.chatService.GetSessions(userModel.ID)
+	}
 	if err != nil {
 		log.Printf("Error getting sessions for user %s: %v", userModel.ID, err)
 
@@ -550,6 +751,18 @@ func (s *Server) sendMessage(c *gin.Context) {
 		return
 	}
 
+	// Enforce token quotas before doing any work
+	if !s.checkTokenQuota(c, userModel.ID) {
+		return
+	}
+
+	// In a coach's session the tools read the athlete's data, as long as the coach still has access
+	subject, coach, link, err := s.sessionSubject(c.Request.Context(), session, userModel)
+	if err != nil {
+		writeCoachingError(c, err, "authorize coach")
+		return
+	}
+
 	// Save user message
 	userMessage, err := s.chatService.SendMessage(sessionID, "user", req.Content)
 	if err != nil {
@@ -599,10 +812,13 @@ func (s *Server) sendMessage(c *gin.Context) {
 	ctx := context.Background()
 
 	// Get athlete logbook for AI context
-	logbook, err := s.logbookService.GetLogbook(ctx, userModel.ID)
-	if err != nil {
-		// Logbook might not exist yet, that's okay
-		log.Printf("No logbook found for user %s: %v", userModel.ID, err)
+	var logbook *models.AthleteLogbook
+	if canReadLogbook(link) {
+		logbook, err = s.logbookService.GetLogbook(ctx, subject.ID)
+		if err != nil {
+			// Logbook might not exist yet, that's okay
+			log.Printf("No logbook found for user %s: %v", subject.ID, err)
+		}
 	}
 
 	// Get session to retrieve last_response_id for multi-turn conversation context
@@ -622,50 +838,31 @@ func (s *Server) sendMessage(c *gin.Context) {
 		lastResponseID = *session.LastResponseID
 	}
 
+	// Extract rolling conversation summary for compacted sessions
+	var conversationSummary string
+	if session.Summary != nil {
+		conversationSummary = *session.Summary
+	}
+
 	msgCtx := &services.MessageContext{
-		UserID:              userModel.ID,
-		SessionID:           sessionID,
-		Message:             req.Content,
-		ConversationHistory: messages[:len(messages)-1], // Exclude the just-added user message
-		AthleteLogbook:      logbook,
-		User:                userModel,
-		LastResponseID:      lastResponseID,
+		UserID:                 userModel.ID,
+		SessionID:              sessionID,
+		Message:                req.Content,
+		ConversationHistory:    messages[:len(messages)-1], // Exclude the just-added user message
+		AthleteLogbook:         logbook,
+		User:                   subject,
+		LastResponseID:         lastResponseID,
+		ConversationSummary:    conversationSummary,
+		SummarizedMessageCount: session.SummarizedMessageCount,
+		Coach:                  coach,
+		CoachLink:              link,
 	}
 
 	// Get AI response synchronously for this endpoint
 	aiResponse, err := s.aiService.ProcessMessageSync(ctx, msgCtx)
 	if err != nil {
 		log.Printf("Error processing AI message: %v", err)
-
-		// Handle specific AI error types
-		if errors.Is(err, services.ErrOpenAIUnavailable) {
-			c.JSON(503, gin.H{
-				"error": "AI service temporarily unavailable",
-				"code":  "AI_UNAVAILABLE",
-			})
-			return
-		}
-
-		if errors.Is(err, services.ErrOpenAIRateLimit) {
-			c.JSON(429, gin.H{
-				"error": "AI service rate limit exceeded",
-				"code":  "AI_RATE_LIMIT",
-			})
-			return
-		}
-
-		if errors.Is(err, services.ErrContextTooLong) {
-			c.JSON(400, gin.H{
-				"error": "Conversation is too long",
-				"code":  "CONTEXT_TOO_LONG",
-			})
-			return
-		}
-
-		c.JSON(500, gin.H{
-			"error": "Failed to process message",
-			"code":  "AI_PROCESSING_ERROR",
-		})
+		writeAIError(c, err)
 		return
 	}
 
@@ -684,6 +881,10 @@ func (s *Server) sendMessage(c *gin.Context) {
 		})
 		return
 	}
+	s.recordGeneration(assistantMessage.ID, msgCtx)
+
+	// Name sessions started without a title in the background; clients see it when they reload the list
+	go s.generateSessionTitle(userModel.ID, sessionID)
 
 	c.JSON(200, gin.H{
 		"user_message":      userMessage,
@@ -727,6 +928,18 @@ func (s *Server) streamResponse(c *gin.Context) {
 		return
 	}
 
+	// Enforce token quotas before switching to SSE
+	if !s.checkTokenQuota(c, userModel.ID) {
+		return
+	}
+
+	// In a coach's session the tools read the athlete's data, as long as the coach still has access
+	subject, coach, link, err := s.sessionSubject(c.Request.Context(), session, userModel)
+	if err != nil {
+		writeCoachingError(c, err, "authorize coach")
+		return
+	}
+
 	// Set SSE headers
 	c.Header("Content-Type", "text/event-stream")
 	c.Header("Cache-Control", "no-cache")
@@ -765,10 +978,13 @@ func (s *Server) streamResponse(c *gin.Context) {
 	ctx := context.Background()
 
 	// Get athlete logbook for AI context
-	logbook, err := s.logbookService.GetLogbook(ctx, userModel.ID)
-	if err != nil {
-		// Logbook might not exist yet, that's okay
-		log.Printf("No logbook found for user %s: %v", userModel.ID, err)
+	var logbook *models.AthleteLogbook
+	if canReadLogbook(link) {
+		logbook, err = s.logbookService.GetLogbook(ctx, subject.ID)
+		if err != nil {
+			// Logbook might not exist yet, that's okay
+			log.Printf("No logbook found for user %s: %v", subject.ID, err)
+		}
 	}
 
 	// Get session to retrieve last_response_id for multi-turn conversation context
@@ -789,14 +1005,24 @@ func (s *Server) streamResponse(c *gin.Context) {
 		lastResponseID = *session.LastResponseID
 	}
 
+	// Extract rolling conversation summary for compacted sessions
+	var conversationSummary string
+	if session.Summary != nil {
+		conversationSummary = *session.Summary
+	}
+
 	msgCtx := &services.MessageContext{
-		UserID:              userModel.ID,
-		SessionID:           sessionID,
-		Message:             message,
-		ConversationHistory: messages[:len(messages)-1], // Exclude the just-added user message
-		AthleteLogbook:      logbook,
-		User:                userModel,
-		LastResponseID:      lastResponseID,
+		UserID:                 userModel.ID,
+		SessionID:              sessionID,
+		Message:                message,
+		ConversationHistory:    messages[:len(messages)-1], // Exclude the just-added user message
+		AthleteLogbook:         logbook,
+		User:                   subject,
+		LastResponseID:         lastResponseID,
+		ConversationSummary:    conversationSummary,
+		SummarizedMessageCount: session.SummarizedMessageCount,
+		Coach:                  coach,
+		CoachLink:              link,
 	}
 
 	responseChan, err := s.aiService.ProcessMessage(ctx, msgCtx)
@@ -838,6 +1064,19 @@ func (s *Server) streamResponse(c *gin.Context) {
 		c.SSEvent("message", errorEvent)
 		return
 	}
+	s.recordGeneration(assistantMessage.ID, msgCtx)
+
+	// Name sessions started without a title now that the first reply is in. This comes before the
+	// completion event because clients close the stream when they receive it.
+	if title := s.generateSessionTitle(userModel.ID, sessionID); title != "" {
+		titleEvent := map[string]interface{}{
+			"type":       "title_updated",
+			"session_id": sessionID,
+			"title":      title,
+		}
+		c.SSEvent("message", titleEvent)
+		c.Writer.Flush()
+	}
 
 	// Send completion event
 	completeEvent := map[string]interface{}{
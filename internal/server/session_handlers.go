@@ -0,0 +1,226 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"bodda/internal/models"
+	"bodda/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// sessionTitleTimeout bounds title generation, which holds a finished stream open until it is done
+const sessionTitleTimeout = 10 * time.Second
+
+// writeSessionError maps chat service errors from session management to responses. action
+// describes the failed operation for the log and the 500 message.
+func writeSessionError(c *gin.Context, err error, action string) {
+	switch {
+	case errors.Is(err, services.ErrSessionNotFound):
+		c.JSON(404, gin.H{
+			"error": "Session not found",
+			"code":  "SESSION_NOT_FOUND",
+		})
+	case errors.Is(err, services.ErrMessageNotFound):
+		c.JSON(404, gin.H{
+			"error": "Message not found in this session",
+			"code":  "MESSAGE_NOT_FOUND",
+		})
+	case errors.Is(err, services.ErrInvalidSessionTitle):
+		c.JSON(400, gin.H{
+			"error": "Invalid session title",
+			"code":  "INVALID_TITLE",
+		})
+	case errors.Is(err, services.ErrInvalidBranchMessage):
+		c.JSON(400, gin.H{
+			"error": err.Error(),
+			"code":  "INVALID_BRANCH_MESSAGE",
+		})
+	case errors.Is(err, services.ErrMessageTooLong):
+		c.JSON(400, gin.H{
+			"error": "Message is too long",
+			"code":  "MESSAGE_TOO_LONG",
+		})
+	case errors.Is(err, services.ErrInvalidMessageContent):
+		c.JSON(400, gin.H{
+			"error": "Invalid message content",
+			"code":  "INVALID_CONTENT",
+		})
+	case errors.Is(err, services.ErrInvalidSearchQuery):
+		c.JSON(400, gin.H{
+			"error": err.Error(),
+			"code":  "INVALID_SEARCH_QUERY",
+		})
+	default:
+		log.Printf("Failed to %s: %v", action, err)
+		c.JSON(500, gin.H{
+			"error": "Failed to " + action,
+			"code":  "SESSION_ERROR",
+		})
+	}
+}
+
+// ownedSession returns the session named in the URL when it belongs to the user. Otherwise it
+// writes the error response and returns nil.
+func (s *Server) ownedSession(c *gin.Context, user *models.User) *models.Session {
+	session, err := s.chatService.GetSession(c.Param("id"))
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			writeSessionError(c, services.ErrSessionNotFound, "verify session")
+			return nil
+		}
+		writeSessionError(c, err, "verify session")
+		return nil
+	}
+
+	if session.UserID != user.ID {
+		c.JSON(403, gin.H{
+			"error": "Access denied",
+			"code":  "ACCESS_DENIED",
+		})
+		return nil
+	}
+
+	return session
+}
+
+// updateSession renames, pins or archives one of the authenticated user's sessions
+func (s *Server) updateSession(c *gin.Context) {
+	user, exists := c.Get("user")
+	if !exists {
+		c.JSON(401, gin.H{
+			"error": "Authentication required",
+			"code":  "AUTH_REQUIRED",
+		})
+		return
+	}
+
+	var req services.SessionUpdate
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{
+			"error": "Invalid request format",
+			"code":  "INVALID_REQUEST",
+		})
+		return
+	}
+	if req.Title == nil && req.Pinned == nil && req.Archived == nil {
+		c.JSON(400, gin.H{
+			"error": "Nothing to update: set title, pinned or archived",
+			"code":  "INVALID_REQUEST",
+		})
+		return
+	}
+
+	userModel := user.(*models.User)
+	session := s.ownedSession(c, userModel)
+	if session == nil {
+		return
+	}
+
+	updated, err := s.chatService.UpdateSession(session.ID, req)
+	if err != nil {
+		writeSessionError(c, err, "update session")
+		return
+	}
+
+	c.JSON(200, gin.H{"session": updated})
+}
+
+// searchSessions finds the authenticated user's sessions whose messages match the q parameter
+func (s *Server) searchSessions(c *gin.Context) {
+	user, exists := c.Get("user")
+	if !exists {
+		c.JSON(401, gin.H{
+			"error": "Authentication required",
+			"code":  "AUTH_REQUIRED",
+		})
+		return
+	}
+
+	limit := 0
+	if limitStr := c.Query("limit"); limitStr != "" {
+		parsed, err := strconv.Atoi(limitStr)
+		if err != nil || parsed < 1 {
+			c.JSON(400, gin.H{
+				"error": "limit must be a positive number",
+				"code":  "INVALID_REQUEST",
+			})
+			return
+		}
+		limit = parsed
+	}
+
+	userModel := user.(*models.User)
+	results, err := s.chatService.SearchSessions(userModel.ID, c.Query("q"), limit)
+	if err != nil {
+		writeSessionError(c, err, "search sessions")
+		return
+	}
+	if results == nil {
+		results = []*models.SessionSearchResult{}
+	}
+
+	c.JSON(200, gin.H{"results": results})
+}
+
+// forkSession copies one of the authenticated user's sessions up to a message into a new session
+func (s *Server) forkSession(c *gin.Context) {
+	user, exists := c.Get("user")
+	if !exists {
+		c.JSON(401, gin.H{
+			"error": "Authentication required",
+			"code":  "AUTH_REQUIRED",
+		})
+		return
+	}
+
+	var req struct {
+		MessageID string `json:"message_id" binding:"required"`
+		Title     string `json:"title"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{
+			"error": "message_id is required",
+			"code":  "INVALID_REQUEST",
+		})
+		return
+	}
+
+	userModel := user.(*models.User)
+	session := s.ownedSession(c, userModel)
+	if session == nil {
+		return
+	}
+
+	fork, err := s.chatService.ForkSession(session.ID, req.MessageID, req.Title)
+	if err != nil {
+		writeSessionError(c, err, "fork session")
+		return
+	}
+
+	c.JSON(201, gin.H{"session": fork})
+}
+
+// generateSessionTitle names a session started without a title once it has a reply. It returns the
+// new title, or "" when the session keeps its title. Failures are logged only; the default title stays.
+func (s *Server) generateSessionTitle(userID, sessionID string) string {
+	if s.sessionTitles == nil {
+		return ""
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), sessionTitleTimeout)
+	defer cancel()
+
+	title, err := s.sessionTitles.GenerateTitle(ctx, userID, sessionID)
+	if err != nil {
+		log.Printf("Failed to generate title for session %s: %v", sessionID, err)
+		return ""
+	}
+
+	return title
+}
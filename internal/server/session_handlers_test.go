@@ -0,0 +1,253 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"bodda/internal/models"
+	"bodda/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteSessionError(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		err    error
+		status int
+		code   string
+	}{
+		{services.ErrSessionNotFound, 404, "SESSION_NOT_FOUND"},
+		{services.ErrMessageNotFound, 404, "MESSAGE_NOT_FOUND"},
+		{services.ErrInvalidSessionTitle, 400, "INVALID_TITLE"},
+		{fmt.Errorf("%w: query is required", services.ErrInvalidSearchQuery), 400, "INVALID_SEARCH_QUERY"},
+		{fmt.Errorf("%w: only user messages can be edited", services.ErrInvalidBranchMessage), 400, "INVALID_BRANCH_MESSAGE"},
+		{services.ErrMessageTooLong, 400, "MESSAGE_TOO_LONG"},
+		{errors.New("connection reset"), 500, "SESSION_ERROR"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.code, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+
+			writeSessionError(c, tt.err, "fork session")
+
+			assert.Equal(t, tt.status, w.Code)
+			var body map[string]string
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+			assert.Equal(t, tt.code, body["code"])
+			if tt.status == 500 {
+				assert.Equal(t, "Failed to fork session", body["error"], "internal errors are not exposed")
+			}
+		})
+	}
+}
+
+func TestServer_getSessions_Archived(t *testing.T) {
+	server, mockChatService, _, _ := createTestServer()
+
+	archivedAt := time.Now()
+	mockChatService.On("GetArchivedSessions", "test-user-id").Return([]*models.Session{
+		{ID: "session-1", UserID: "test-user-id", Title: "Old plan", ArchivedAt: &archivedAt},
+	}, nil)
+
+	c, w := createAuthenticatedContext(server, "GET", "/api/sessions?archived=true", nil)
+	server.getSessions(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "Old plan")
+	mockChatService.AssertNotCalled(t, "GetSessions", mock.Anything)
+}
+
+func TestServer_updateSession(t *testing.T) {
+	server, mockChatService, _, _ := createTestServer()
+
+	session := &models.Session{ID: "session-1", UserID: "test-user-id", Title: "Marathon plan"}
+	mockChatService.On("GetSession", "session-1").Return(session, nil)
+	mockChatService.On("UpdateSession", "session-1", mock.MatchedBy(func(update services.SessionUpdate) bool {
+		return update.Title == nil && update.Pinned != nil && *update.Pinned && update.Archived == nil
+	})).Return(&models.Session{ID: "session-1", UserID: "test-user-id", Title: "Marathon plan", Pinned: true}, nil)
+
+	c, w := createAuthenticatedContext(server, "PATCH", "/api/sessions/session-1", []byte(`{"pinned":true}`))
+	c.Params = gin.Params{{Key: "id", Value: "session-1"}}
+	server.updateSession(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var response struct {
+		Session models.Session `json:"session"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.True(t, response.Session.Pinned)
+	mockChatService.AssertExpectations(t)
+}
+
+func TestServer_updateSession_Rejected(t *testing.T) {
+	server, mockChatService, _, _ := createTestServer()
+	mockChatService.On("GetSession", "other-session").Return(&models.Session{ID: "other-session", UserID: "someone-else"}, nil)
+
+	c, w := createAuthenticatedContext(server, "PATCH", "/api/sessions/other-session", []byte(`{"archived":true}`))
+	c.Params = gin.Params{{Key: "id", Value: "other-session"}}
+	server.updateSession(c)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	c, w = createAuthenticatedContext(server, "PATCH", "/api/sessions/other-session", []byte(`{}`))
+	c.Params = gin.Params{{Key: "id", Value: "other-session"}}
+	server.updateSession(c)
+	assert.Equal(t, http.StatusBadRequest, w.Code, "an empty update is rejected")
+
+	mockChatService.AssertNotCalled(t, "UpdateSession", mock.Anything, mock.Anything)
+}
+
+func TestServer_searchSessions(t *testing.T) {
+	server, mockChatService, _, _ := createTestServer()
+
+	mockChatService.On("SearchSessions", "test-user-id", "tempo run", 5).Return([]*models.SessionSearchResult{
+		{
+			Session:    &models.Session{ID: "session-1", UserID: "test-user-id", Title: "Workouts"},
+			MessageID:  "message-1",
+			Snippet:    "a <mark>tempo</mark> <mark>run</mark> on Tuesday",
+			MatchCount: 2,
+		},
+	}, nil)
+	mockChatService.On("SearchSessions", "test-user-id", "", 0).Return(nil, fmt.Errorf("%w: query is required", services.ErrInvalidSearchQuery))
+
+	c, w := createAuthenticatedContext(server, "GET", "/api/sessions/search?q=tempo+run&limit=5", nil)
+	server.searchSessions(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var response struct {
+		Results []models.SessionSearchResult `json:"results"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	require.Len(t, response.Results, 1)
+	assert.Equal(t, "session-1", response.Results[0].Session.ID)
+	assert.Equal(t, 2, response.Results[0].MatchCount)
+
+	c, w = createAuthenticatedContext(server, "GET", "/api/sessions/search", nil)
+	server.searchSessions(c)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "INVALID_SEARCH_QUERY")
+
+	c, w = createAuthenticatedContext(server, "GET", "/api/sessions/search?q=tempo&limit=abc", nil)
+	server.searchSessions(c)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestServer_forkSession(t *testing.T) {
+	server, mockChatService, _, _ := createTestServer()
+
+	source := "session-1"
+	mockChatService.On("GetSession", "session-1").Return(&models.Session{ID: "session-1", UserID: "test-user-id", Title: "Marathon plan"}, nil)
+	mockChatService.On("ForkSession", "session-1", "message-2", "").Return(&models.Session{
+		ID: "session-2", UserID: "test-user-id", Title: "Fork of Marathon plan", ForkedFromSessionID: &source,
+	}, nil)
+	mockChatService.On("ForkSession", "session-1", "missing", "").Return(nil, services.ErrMessageNotFound)
+
+	c, w := createAuthenticatedContext(server, "POST", "/api/sessions/session-1/fork", []byte(`{"message_id":"message-2"}`))
+	c.Params = gin.Params{{Key: "id", Value: "session-1"}}
+	server.forkSession(c)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	var response struct {
+		Session models.Session `json:"session"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, "session-2", response.Session.ID)
+	require.NotNil(t, response.Session.ForkedFromSessionID)
+	assert.Equal(t, "session-1", *response.Session.ForkedFromSessionID)
+
+	c, w = createAuthenticatedContext(server, "POST", "/api/sessions/session-1/fork", []byte(`{"message_id":"missing"}`))
+	c.Params = gin.Params{{Key: "id", Value: "session-1"}}
+	server.forkSession(c)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Contains(t, w.Body.String(), "MESSAGE_NOT_FOUND")
+
+	c, w = createAuthenticatedContext(server, "POST", "/api/sessions/session-1/fork", []byte(`{}`))
+	c.Params = gin.Params{{Key: "id", Value: "session-1"}}
+	server.forkSession(c)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+type stubSessionTitles struct {
+	title string
+	err   error
+}
+
+func (s *stubSessionTitles) GenerateTitle(ctx context.Context, userID, sessionID string) (string, error) {
+	return s.title, s.err
+}
+
+func TestServer_streamResponse_TitleUpdated(t *testing.T) {
+	tests := []struct {
+		name      string
+		titles    *stubSessionTitles
+		wantTitle bool
+	}{
+		{"generated", &stubSessionTitles{title: "Marathon taper plan"}, true},
+		{"kept", &stubSessionTitles{}, false},
+		{"failed", &stubSessionTitles{err: errors.New("rate limited")}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, mockChatService, mockAIService, mockLogbookService := createTestServer()
+			server.sessionTitles = tt.titles
+
+			session := &models.Session{ID: "session-1", UserID: "test-user-id", Title: "New Conversation"}
+			mockChatService.On("GetSession", "session-1").Return(session, nil)
+			mockChatService.On("SendMessage", "session-1", "user", "taper?").Return(&models.Message{ID: "m1", Role: "user", Content: "taper?"}, nil)
+			mockChatService.On("GetMessages", "session-1").Return([]*models.Message{{ID: "m1", Role: "user", Content: "taper?"}}, nil)
+			mockChatService.On("SendMessageWithResponseID", "session-1", "assistant", "Cut volume.", mock.Anything).Return(&models.Message{ID: "m2", Role: "assistant", Content: "Cut volume."}, nil)
+			mockLogbookService.On("GetLogbook", mock.Anything, "test-user-id").Return(nil, errors.New("not found"))
+
+			chunks := make(chan string, 1)
+			chunks <- "Cut volume."
+			close(chunks)
+			mockAIService.On("ProcessMessage", mock.Anything, mock.Anything).Return((<-chan string)(chunks), nil)
+
+			c, w := createAuthenticatedContext(server, "GET", "/api/sessions/session-1/stream?message=taper%3F", nil)
+			c.Params = gin.Params{{Key: "id", Value: "session-1"}}
+			server.streamResponse(c)
+
+			body := w.Body.String()
+			complete := strings.Index(body, `"type":"complete"`)
+			require.NotEqual(t, -1, complete, body)
+			titleUpdated := strings.Index(body, `"type":"title_updated"`)
+			if !tt.wantTitle {
+				assert.Equal(t, -1, titleUpdated)
+				return
+			}
+			require.NotEqual(t, -1, titleUpdated, body)
+			assert.Less(t, titleUpdated, complete, "the title arrives before clients close the stream")
+			assert.Contains(t, body, `"title":"Marathon taper plan"`)
+		})
+	}
+}
+
+func TestServer_corsMiddleware_AllowsPatch(t *testing.T) {
+	server, _, _, _ := createTestServer()
+	server.router.Use(server.corsMiddleware())
+	server.router.PATCH("/api/sessions/:id", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	req := httptest.NewRequest("OPTIONS", "/api/sessions/session-1", nil)
+	req.Header.Set("Origin", "http://localhost:3000")
+	req.Header.Set("Access-Control-Request-Method", "PATCH")
+	w := httptest.NewRecorder()
+	server.router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Equal(t, "http://localhost:3000", w.Header().Get("Access-Control-Allow-Origin"))
+	assert.Contains(t, strings.Split(w.Header().Get("Access-Control-Allow-Methods"), ", "), "PATCH")
+}
@@ -0,0 +1,21 @@
+package server
+
+import (
+	"bodda/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// getStravaRateLimits returns the remaining application-wide Strava API budget for administrators
+func (s *Server) getStravaRateLimits(c *gin.Context) {
+	provider, ok := s.stravaService.(services.StravaRateLimitProvider)
+	if !ok || provider.RateLimits() == nil {
+		c.JSON(404, gin.H{
+			"error": "Strava rate limit tracking is not enabled",
+			"code":  "RATE_LIMITS_UNAVAILABLE",
+		})
+		return
+	}
+
+	c.JSON(200, gin.H{"rate_limits": provider.RateLimits().Status()})
+}
@@ -0,0 +1,332 @@
+package server
+
+import (
+	"errors"
+	"log"
+
+	"bodda/internal/models"
+	"bodda/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// writeTeamError maps team service errors to responses. action describes the failed operation for
+// the log and the 500 message.
+func writeTeamError(c *gin.Context, err error, action string) {
+	switch {
+	case errors.Is(err, services.ErrTeamNotFound):
+		c.JSON(404, gin.H{
+			"error": "Team not found",
+			"code":  "TEAM_NOT_FOUND",
+		})
+	case errors.Is(err, services.ErrTeamAccessDenied):
+		c.JSON(403, gin.H{
+			"error": err.Error(),
+			"code":  "TEAM_ACCESS_DENIED",
+		})
+	case errors.Is(err, services.ErrInvalidTeamCode):
+		c.JSON(400, gin.H{
+			"error": "Join code is invalid or has been replaced",
+			"code":  "INVALID_JOIN_CODE",
+		})
+	case errors.Is(err, services.ErrAlreadyTeamMember):
+		c.JSON(409, gin.H{
+			"error": "You are already a member of this team",
+			"code":  "ALREADY_TEAM_MEMBER",
+		})
+	case errors.Is(err, services.ErrTeamFull):
+		c.JSON(409, gin.H{
+			"error": err.Error(),
+			"code":  "TEAM_FULL",
+		})
+	case errors.Is(err, services.ErrTeamMemberNotFound):
+		c.JSON(404, gin.H{
+			"error": "Team member not found",
+			"code":  "TEAM_MEMBER_NOT_FOUND",
+		})
+	case errors.Is(err, services.ErrInvalidTeamRequest):
+		c.JSON(400, gin.H{
+			"error": err.Error(),
+			"code":  "INVALID_TEAM_REQUEST",
+		})
+	default:
+		log.Printf("Failed to %s: %v", action, err)
+		c.JSON(500, gin.H{
+			"error": "Failed to " + action,
+			"code":  "TEAM_ERROR",
+		})
+	}
+}
+
+// listTeams returns the teams the authenticated user belongs to
+func (s *Server) listTeams(c *gin.Context) {
+	user, exists := c.Get("user")
+	if !exists {
+		c.JSON(401, gin.H{
+			"error": "Authentication required",
+			"code":  "AUTH_REQUIRED",
+		})
+		return
+	}
+
+	userModel := user.(*models.User)
+	teams, err := s.teamService.ListTeams(c.Request.Context(), userModel.ID)
+	if err != nil {
+		writeTeamError(c, err, "retrieve teams")
+		return
+	}
+
+	c.JSON(200, gin.H{"teams": teams})
+}
+
+// createTeam creates a team owned by the authenticated user and returns its join code
+func (s *Server) createTeam(c *gin.Context) {
+	user, exists := c.Get("user")
+	if !exists {
+		c.JSON(401, gin.H{
+			"error": "Authentication required",
+			"code":  "AUTH_REQUIRED",
+		})
+		return
+	}
+
+	var req struct {
+		Name string `json:"name"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{
+			"error": "Invalid request format",
+			"code":  "INVALID_REQUEST",
+		})
+		return
+	}
+
+	userModel := user.(*models.User)
+	created, err := s.teamService.CreateTeam(c.Request.Context(), userModel.ID, req.Name)
+	if err != nil {
+		writeTeamError(c, err, "create team")
+		return
+	}
+
+	c.JSON(201, created)
+}
+
+// joinTeam adds the authenticated user to the team with the given join code as an athlete
+func (s *Server) joinTeam(c *gin.Context) {
+	user, exists := c.Get("user")
+	if !exists {
+		c.JSON(401, gin.H{
+			"error": "Authentication required",
+			"code":  "AUTH_REQUIRED",
+		})
+		return
+	}
+
+	var req struct {
+		Code string `json:"code"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{
+			"error": "Invalid request format",
+			"code":  "INVALID_REQUEST",
+		})
+		return
+	}
+
+	userModel := user.(*models.User)
+	team, err := s.teamService.JoinTeam(c.Request.Context(), userModel.ID, req.Code)
+	if err != nil {
+		writeTeamError(c, err, "join team")
+		return
+	}
+
+	c.JSON(200, gin.H{"team": team})
+}
+
+// getTeam returns a team and its members to any member
+func (s *Server) getTeam(c *gin.Context) {
+	user, exists := c.Get("user")
+	if !exists {
+		c.JSON(401, gin.H{
+			"error": "Authentication required",
+			"code":  "AUTH_REQUIRED",
+		})
+		return
+	}
+
+	userModel := user.(*models.User)
+	team, members, err := s.teamService.GetTeam(c.Request.Context(), userModel.ID, c.Param("id"))
+	if err != nil {
+		writeTeamError(c, err, "retrieve team")
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"team":    team,
+		"members": members,
+	})
+}
+
+// renameTeam changes the name of a team the authenticated user owns
+func (s *Server) renameTeam(c *gin.Context) {
+	user, exists := c.Get("user")
+	if !exists {
+		c.JSON(401, gin.H{
+			"error": "Authentication required",
+			"code":  "AUTH_REQUIRED",
+		})
+		return
+	}
+
+	var req struct {
+		Name string `json:"name"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{
+			"error": "Invalid request format",
+			"code":  "INVALID_REQUEST",
+		})
+		return
+	}
+
+	userModel := user.(*models.User)
+	if err := s.teamService.RenameTeam(c.Request.Context(), userModel.ID, c.Param("id"), req.Name); err != nil {
+		writeTeamError(c, err, "rename team")
+		return
+	}
+
+	c.JSON(200, gin.H{"message": "Team renamed"})
+}
+
+// deleteTeam deletes a team the authenticated user owns
+func (s *Server) deleteTeam(c *gin.Context) {
+	user, exists := c.Get("user")
+	if !exists {
+		c.JSON(401, gin.H{
+			"error": "Authentication required",
+			"code":  "AUTH_REQUIRED",
+		})
+		return
+	}
+
+	userModel := user.(*models.User)
+	if err := s.teamService.DeleteTeam(c.Request.Context(), userModel.ID, c.Param("id")); err != nil {
+		writeTeamError(c, err, "delete team")
+		return
+	}
+
+	c.JSON(200, gin.H{"message": "Team deleted"})
+}
+
+// rotateTeamJoinCode replaces the team's join code, invalidating the previous one
+func (s *Server) rotateTeamJoinCode(c *gin.Context) {
+	user, exists := c.Get("user")
+	if !exists {
+		c.JSON(401, gin.H{
+			"error": "Authentication required",
+			"code":  "AUTH_REQUIRED",
+		})
+		return
+	}
+
+	userModel := user.(*models.User)
+	joinCode, err := s.teamService.RotateJoinCode(c.Request.Context(), userModel.ID, c.Param("id"))
+	if err != nil {
+		writeTeamError(c, err, "create join code")
+		return
+	}
+
+	c.JSON(201, joinCode)
+}
+
+// disableTeamJoinCode stops new members from joining the team
+func (s *Server) disableTeamJoinCode(c *gin.Context) {
+	user, exists := c.Get("user")
+	if !exists {
+		c.JSON(401, gin.H{
+			"error": "Authentication required",
+			"code":  "AUTH_REQUIRED",
+		})
+		return
+	}
+
+	userModel := user.(*models.User)
+	if err := s.teamService.DisableJoinCode(c.Request.Context(), userModel.ID, c.Param("id")); err != nil {
+		writeTeamError(c, err, "disable join code")
+		return
+	}
+
+	c.JSON(200, gin.H{"message": "Join code disabled"})
+}
+
+// updateTeamMemberRole makes a member a coach or an athlete
+func (s *Server) updateTeamMemberRole(c *gin.Context) {
+	user, exists := c.Get("user")
+	if !exists {
+		c.JSON(401, gin.H{
+			"error": "Authentication required",
+			"code":  "AUTH_REQUIRED",
+		})
+		return
+	}
+
+	var req struct {
+		Role string `json:"role"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{
+			"error": "Invalid request format",
+			"code":  "INVALID_REQUEST",
+		})
+		return
+	}
+
+	userModel := user.(*models.User)
+	if err := s.teamService.UpdateMemberRole(c.Request.Context(), userModel.ID, c.Param("id"), c.Param("userId"), req.Role); err != nil {
+		writeTeamError(c, err, "update team member")
+		return
+	}
+
+	c.JSON(200, gin.H{"message": "Team member updated"})
+}
+
+// removeTeamMember removes a member; members remove themselves to leave the team
+func (s *Server) removeTeamMember(c *gin.Context) {
+	user, exists := c.Get("user")
+	if !exists {
+		c.JSON(401, gin.H{
+			"error": "Authentication required",
+			"code":  "AUTH_REQUIRED",
+		})
+		return
+	}
+
+	userModel := user.(*models.User)
+	if err := s.teamService.RemoveMember(c.Request.Context(), userModel.ID, c.Param("id"), c.Param("userId")); err != nil {
+		writeTeamError(c, err, "remove team member")
+		return
+	}
+
+	c.JSON(200, gin.H{"message": "Team member removed"})
+}
+
+// getTeamDashboard returns weekly volume, missed sessions and load warnings for the team's athletes
+func (s *Server) getTeamDashboard(c *gin.Context) {
+	user, exists := c.Get("user")
+	if !exists {
+		c.JSON(401, gin.H{
+			"error": "Authentication required",
+			"code":  "AUTH_REQUIRED",
+		})
+		return
+	}
+
+	userModel := user.(*models.User)
+	dashboard, err := s.teamService.Dashboard(c.Request.Context(), userModel.ID, c.Param("id"))
+	if err != nil {
+		writeTeamError(c, err, "build team dashboard")
+		return
+	}
+
+	c.JSON(200, gin.H{"dashboard": dashboard})
+}
@@ -0,0 +1,51 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http/httptest"
+	"testing"
+
+	"bodda/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteTeamError(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		err    error
+		status int
+		code   string
+	}{
+		{services.ErrTeamNotFound, 404, "TEAM_NOT_FOUND"},
+		{fmt.Errorf("%w: only the team owner can do this", services.ErrTeamAccessDenied), 403, "TEAM_ACCESS_DENIED"},
+		{services.ErrInvalidTeamCode, 400, "INVALID_JOIN_CODE"},
+		{services.ErrAlreadyTeamMember, 409, "ALREADY_TEAM_MEMBER"},
+		{fmt.Errorf("%w: teams are limited to 50 members", services.ErrTeamFull), 409, "TEAM_FULL"},
+		{services.ErrTeamMemberNotFound, 404, "TEAM_MEMBER_NOT_FOUND"},
+		{fmt.Errorf("%w: team name is required", services.ErrInvalidTeamRequest), 400, "INVALID_TEAM_REQUEST"},
+		{errors.New("connection reset"), 500, "TEAM_ERROR"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.code, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+
+			writeTeamError(c, tt.err, "build team dashboard")
+
+			assert.Equal(t, tt.status, w.Code)
+			var body map[string]string
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+			assert.Equal(t, tt.code, body["code"])
+			if tt.status == 500 {
+				assert.Equal(t, "Failed to build team dashboard", body["error"], "internal errors are not exposed")
+			}
+		})
+	}
+}
@@ -107,6 +107,15 @@ func (suite *ToolIntegrationValidationTestSuite) TestAllEndpointsWithExistingToo
 			"get-activity-details",
 			"get-activity-streams",
 			"update-athlete-logbook",
+			"render-activity-chart",
+			"compare-activities",
+			"search-activities",
+			"get-training-summary",
+			"get-aerobic-trend",
+			"analyze-activity-conditions",
+			"get-readiness",
+			"get-injury-risk",
+			"get-team-overview",
 		}
 		
 		suite.Equal(len(expectedTools), response.Count)
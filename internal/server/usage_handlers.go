@@ -0,0 +1,139 @@
+package server
+
+import (
+	"bodda/internal/models"
+	"bodda/internal/services"
+	"errors"
+	"log"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// adminMiddleware restricts access to users listed in ADMIN_STRAVA_IDS.
+// Must be used after authMiddleware.
+func (s *Server) adminMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, exists := c.Get("user")
+		if !exists {
+			c.JSON(401, gin.H{
+				"error": "Authentication required",
+				"code":  "AUTH_REQUIRED",
+			})
+			c.Abort()
+			return
+		}
+
+		userModel := user.(*models.User)
+		if !s.config.IsAdmin(userModel.StravaID) {
+			c.JSON(403, gin.H{
+				"error": "Admin access required",
+				"code":  "ADMIN_REQUIRED",
+			})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// checkTokenQuota writes a 429 response and returns false when the user has exhausted a token quota.
+// Quota lookup failures are logged and the request is allowed through.
+func (s *Server) checkTokenQuota(c *gin.Context, userID string) bool {
+	if s.usageService == nil {
+		return true
+	}
+
+	err := s.usageService.CheckQuota(c.Request.Context(), userID)
+	if err == nil {
+		return true
+	}
+
+	var quotaErr *services.QuotaExceededError
+	if errors.As(err, &quotaErr) {
+		c.JSON(429, gin.H{
+			"error": "Token quota exceeded",
+			"code":  "TOKEN_QUOTA_EXCEEDED",
+			"quota": quotaErr,
+		})
+		return false
+	}
+
+	log.Printf("Error checking token quota for user %s: %v", userID, err)
+	return true
+}
+
+// getUsage returns the authenticated user's token usage and quota status
+func (s *Server) getUsage(c *gin.Context) {
+	user, exists := c.Get("user")
+	if !exists {
+		c.JSON(401, gin.H{
+			"error": "Authentication required",
+			"code":  "AUTH_REQUIRED",
+		})
+		return
+	}
+
+	days, ok := parseUsageQueryInt(c, "days")
+	if !ok {
+		return
+	}
+
+	userModel := user.(*models.User)
+	report, err := s.usageService.GetUserUsage(c.Request.Context(), userModel.ID, days)
+	if err != nil {
+		log.Printf("Error getting token usage for user %s: %v", userModel.ID, err)
+		c.JSON(500, gin.H{
+			"error": "Failed to retrieve usage",
+			"code":  "USAGE_RETRIEVAL_ERROR",
+		})
+		return
+	}
+
+	c.JSON(200, gin.H{"usage": report})
+}
+
+// getUsageSummary returns token usage across all users for administrators
+func (s *Server) getUsageSummary(c *gin.Context) {
+	days, ok := parseUsageQueryInt(c, "days")
+	if !ok {
+		return
+	}
+
+	limit, ok := parseUsageQueryInt(c, "limit")
+	if !ok {
+		return
+	}
+
+	report, err := s.usageService.GetUsageSummary(c.Request.Context(), days, limit)
+	if err != nil {
+		log.Printf("Error getting token usage summary: %v", err)
+		c.JSON(500, gin.H{
+			"error": "Failed to retrieve usage summary",
+			"code":  "USAGE_RETRIEVAL_ERROR",
+		})
+		return
+	}
+
+	c.JSON(200, gin.H{"usage": report})
+}
+
+// parseUsageQueryInt reads an optional non-negative integer query parameter, writing a 400 on bad input
+func parseUsageQueryInt(c *gin.Context, name string) (int, bool) {
+	raw := c.Query(name)
+	if raw == "" {
+		return 0, true
+	}
+
+	value, err := strconv.Atoi(raw)
+	if err != nil || value < 0 {
+		c.JSON(400, gin.H{
+			"error": "Invalid " + name + " parameter",
+			"code":  "INVALID_PARAMETER",
+		})
+		return 0, false
+	}
+
+	return value, true
+}
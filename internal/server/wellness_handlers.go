@@ -0,0 +1,262 @@
+package server
+
+import (
+	"bodda/internal/models"
+	"bodda/internal/services"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxWellnessImportBytes bounds the size of an uploaded wellness CSV
+const maxWellnessImportBytes = 1 << 20
+
+// wellnessEntryRequest is the body of PUT /api/wellness/:date. Omitted metrics keep their stored value.
+type wellnessEntryRequest struct {
+	RestingHR  *int     `json:"resting_hr"`
+	HRVRMSSD   *float64 `json:"hrv_rmssd"`
+	SleepHours *float64 `json:"sleep_hours"`
+	Fatigue    *int     `json:"fatigue"`
+	Soreness   *int     `json:"soreness"`
+	Mood       *int     `json:"mood"`
+	Notes      string   `json:"notes"`
+}
+
+// getWellness returns the authenticated user's recent wellness entries
+func (s *Server) getWellness(c *gin.Context) {
+	user, exists := c.Get("user")
+	if !exists {
+		c.JSON(401, gin.H{
+			"error": "Authentication required",
+			"code":  "AUTH_REQUIRED",
+		})
+		return
+	}
+
+	days, ok := parseUsageQueryInt(c, "days")
+	if !ok {
+		return
+	}
+
+	userModel := user.(*models.User)
+	entries, err := s.wellnessService.GetEntries(c.Request.Context(), userModel.ID, days)
+	if err != nil {
+		log.Printf("Error getting wellness entries for user %s: %v", userModel.ID, err)
+		c.JSON(500, gin.H{
+			"error": "Failed to retrieve wellness entries",
+			"code":  "WELLNESS_RETRIEVAL_ERROR",
+		})
+		return
+	}
+
+	if entries == nil {
+		entries = []*models.DailyWellness{}
+	}
+	c.JSON(200, gin.H{"entries": entries})
+}
+
+// putWellness records or updates the authenticated user's metrics for a day
+func (s *Server) putWellness(c *gin.Context) {
+	user, exists := c.Get("user")
+	if !exists {
+		c.JSON(401, gin.H{
+			"error": "Authentication required",
+			"code":  "AUTH_REQUIRED",
+		})
+		return
+	}
+
+	date, ok := parseWellnessDateParam(c)
+	if !ok {
+		return
+	}
+
+	var req wellnessEntryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{
+			"error": "Invalid request format",
+			"code":  "INVALID_REQUEST",
+		})
+		return
+	}
+
+	userModel := user.(*models.User)
+	entry := &models.DailyWellness{
+		UserID:     userModel.ID,
+		Date:       date,
+		RestingHR:  req.RestingHR,
+		HRVRMSSD:   req.HRVRMSSD,
+		SleepHours: req.SleepHours,
+		Fatigue:    req.Fatigue,
+		Soreness:   req.Soreness,
+		Mood:       req.Mood,
+		Notes:      req.Notes,
+	}
+
+	if err := s.wellnessService.RecordEntry(c.Request.Context(), entry); err != nil {
+		if errors.Is(err, services.ErrInvalidWellnessEntry) {
+			c.JSON(400, gin.H{
+				"error": err.Error(),
+				"code":  "INVALID_WELLNESS_ENTRY",
+			})
+			return
+		}
+
+		log.Printf("Error recording wellness entry for user %s: %v", userModel.ID, err)
+		c.JSON(500, gin.H{
+			"error": "Failed to record wellness entry",
+			"code":  "WELLNESS_SAVE_ERROR",
+		})
+		return
+	}
+
+	c.JSON(200, gin.H{"entry": entry})
+}
+
+// deleteWellness removes the authenticated user's metrics for a day
+func (s *Server) deleteWellness(c *gin.Context) {
+	user, exists := c.Get("user")
+	if !exists {
+		c.JSON(401, gin.H{
+			"error": "Authentication required",
+			"code":  "AUTH_REQUIRED",
+		})
+		return
+	}
+
+	date, ok := parseWellnessDateParam(c)
+	if !ok {
+		return
+	}
+
+	userModel := user.(*models.User)
+	deleted, err := s.wellnessService.DeleteEntry(c.Request.Context(), userModel.ID, date)
+	if err != nil {
+		log.Printf("Error deleting wellness entry for user %s: %v", userModel.ID, err)
+		c.JSON(500, gin.H{
+			"error": "Failed to delete wellness entry",
+			"code":  "WELLNESS_DELETE_ERROR",
+		})
+		return
+	}
+	if !deleted {
+		c.JSON(404, gin.H{
+			"error": "No wellness entry for this date",
+			"code":  "WELLNESS_NOT_FOUND",
+		})
+		return
+	}
+
+	c.JSON(200, gin.H{"message": "Wellness entry deleted successfully"})
+}
+
+// importWellness imports a CSV of daily metrics, sent either as a multipart "file" field or as a text/csv body
+func (s *Server) importWellness(c *gin.Context) {
+	user, exists := c.Get("user")
+	if !exists {
+		c.JSON(401, gin.H{
+			"error": "Authentication required",
+			"code":  "AUTH_REQUIRED",
+		})
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxWellnessImportBytes)
+
+	var body io.Reader = c.Request.Body
+	if strings.HasPrefix(c.ContentType(), "multipart/") {
+		file, _, err := c.Request.FormFile("file")
+		if err != nil {
+			c.JSON(400, gin.H{
+				"error": "A CSV file is required in the 'file' field",
+				"code":  "INVALID_REQUEST",
+			})
+			return
+		}
+		defer file.Close()
+		body = file
+	}
+
+	userModel := user.(*models.User)
+	result, err := s.wellnessService.ImportCSV(c.Request.Context(), userModel.ID, body)
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		switch {
+		case errors.As(err, &maxBytesErr):
+			c.JSON(413, gin.H{
+				"error": "CSV file is too large",
+				"code":  "PAYLOAD_TOO_LARGE",
+			})
+		case errors.Is(err, services.ErrInvalidWellnessEntry):
+			c.JSON(400, gin.H{
+				"error": err.Error(),
+				"code":  "INVALID_WELLNESS_IMPORT",
+			})
+		default:
+			log.Printf("Error importing wellness CSV for user %s: %v", userModel.ID, err)
+			c.JSON(500, gin.H{
+				"error": "Failed to import wellness entries",
+				"code":  "WELLNESS_IMPORT_ERROR",
+			})
+		}
+		return
+	}
+
+	c.JSON(200, gin.H{"import": result})
+}
+
+// getReadiness returns the authenticated user's readiness for a day, defaulting to today
+func (s *Server) getReadiness(c *gin.Context) {
+	user, exists := c.Get("user")
+	if !exists {
+		c.JSON(401, gin.H{
+			"error": "Authentication required",
+			"code":  "AUTH_REQUIRED",
+		})
+		return
+	}
+
+	var date time.Time
+	if raw := c.Query("date"); raw != "" {
+		parsed, err := services.ParseWellnessDate(raw)
+		if err != nil {
+			c.JSON(400, gin.H{
+				"error": "Invalid date parameter, expected YYYY-MM-DD",
+				"code":  "INVALID_PARAMETER",
+			})
+			return
+		}
+		date = parsed
+	}
+
+	userModel := user.(*models.User)
+	report, err := s.wellnessService.GetReadiness(c.Request.Context(), userModel.ID, date)
+	if err != nil {
+		log.Printf("Error computing readiness for user %s: %v", userModel.ID, err)
+		c.JSON(500, gin.H{
+			"error": "Failed to compute readiness",
+			"code":  "READINESS_ERROR",
+		})
+		return
+	}
+
+	c.JSON(200, gin.H{"readiness": report})
+}
+
+// parseWellnessDateParam reads the :date path parameter, writing a 400 on bad input
+func parseWellnessDateParam(c *gin.Context) (time.Time, bool) {
+	date, err := services.ParseWellnessDate(c.Param("date"))
+	if err != nil {
+		c.JSON(400, gin.H{
+			"error": "Invalid date, expected YYYY-MM-DD",
+			"code":  "INVALID_PARAMETER",
+		})
+		return time.Time{}, false
+	}
+	return date, true
+}
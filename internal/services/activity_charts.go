@@ -0,0 +1,586 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+)
+
+const (
+	vegaLiteSchema = "https://vega.github.io/schema/vega-lite/v5.json"
+
+	defaultChartPoints = 300
+	minChartPoints     = 50
+	maxChartPoints     = 1000
+
+	// Below this speed the athlete is effectively stopped and pace is meaningless
+	minPaceSpeed = 0.5 // m/s
+)
+
+// Chart types supported by the render-activity-chart tool
+const (
+	ChartTypeMetrics   = "metrics"
+	ChartTypeZones     = "zones"
+	ChartTypeLaps      = "laps"
+	ChartTypeElevation = "elevation"
+)
+
+// Metrics that can be plotted on a metrics chart
+const (
+	ChartMetricPace      = "pace"
+	ChartMetricHeartRate = "heartrate"
+	ChartMetricPower     = "power"
+	ChartMetricCadence   = "cadence"
+	ChartMetricAltitude  = "altitude"
+)
+
+// ErrChartDataUnavailable is returned when an activity lacks the data needed for a chart
+var ErrChartDataUnavailable = errors.New("chart data unavailable")
+
+// chartStreamTypes are the streams fetched for stream-based charts
+var chartStreamTypes = []string{"time", "distance", "velocity_smooth", "heartrate", "watts", "cadence", "altitude"}
+
+// ActivityChartRequest describes a chart to render for an activity
+type ActivityChartRequest struct {
+	ActivityID int64    `json:"activity_id"`
+	ChartType  string   `json:"chart_type"`
+	Metrics    []string `json:"metrics"`
+	ZoneType   string   `json:"zone_type"`
+	MaxPoints  int      `json:"max_points"`
+}
+
+// normalize applies defaults and clamps the point budget
+func (r *ActivityChartRequest) normalize() error {
+	if r.ActivityID <= 0 {
+		return fmt.Errorf("activity_id is required")
+	}
+
+	if r.ChartType == "" {
+		r.ChartType = ChartTypeMetrics
+	}
+	switch r.ChartType {
+	case ChartTypeMetrics, ChartTypeZones, ChartTypeLaps, ChartTypeElevation:
+	default:
+		return fmt.Errorf("unsupported chart_type '%s'", r.ChartType)
+	}
+
+	switch {
+	case r.MaxPoints <= 0:
+		r.MaxPoints = defaultChartPoints
+	case r.MaxPoints < minChartPoints:
+		r.MaxPoints = minChartPoints
+	case r.MaxPoints > maxChartPoints:
+		r.MaxPoints = maxChartPoints
+	}
+
+	return nil
+}
+
+// isRunActivity reports whether pace rather than speed is the natural measure for a sport
+func isRunActivity(sportType string) bool {
+	return strings.Contains(sportType, "Run") || sportType == "Walk" || sportType == "Hike"
+}
+
+// paceMinPerKm converts a speed in m/s to decimal minutes per kilometre
+func paceMinPerKm(speed float64) (float64, bool) {
+	if speed < minPaceSpeed {
+		return 0, false
+	}
+	return roundTo(1000/speed/60, 2), true
+}
+
+func roundTo(value float64, decimals int) float64 {
+	scale := math.Pow(10, float64(decimals))
+	return math.Round(value*scale) / scale
+}
+
+// chartBucket is a contiguous index range that is collapsed into one chart point
+type chartBucket struct {
+	start, end int // end is exclusive
+}
+
+// downsampleBuckets splits n samples into at most maxPoints contiguous buckets
+func downsampleBuckets(n, maxPoints int) []chartBucket {
+	if n <= 0 {
+		return nil
+	}
+	if maxPoints <= 0 || n <= maxPoints {
+		buckets := make([]chartBucket, n)
+		for i := range buckets {
+			buckets[i] = chartBucket{start: i, end: i + 1}
+		}
+		return buckets
+	}
+
+	buckets := make([]chartBucket, maxPoints)
+	for i := range buckets {
+		buckets[i] = chartBucket{start: i * n / maxPoints, end: (i + 1) * n / maxPoints}
+	}
+	return buckets
+}
+
+func meanFloat(data []float64, b chartBucket) (float64, bool) {
+	if len(data) < b.end || b.end <= b.start {
+		return 0, false
+	}
+	sum := 0.0
+	for _, v := range data[b.start:b.end] {
+		sum += v
+	}
+	return sum / float64(b.end-b.start), true
+}
+
+func meanInt(data []int, b chartBucket) (float64, bool) {
+	if len(data) < b.end || b.end <= b.start {
+		return 0, false
+	}
+	sum := 0
+	for _, v := range data[b.start:b.end] {
+		sum += v
+	}
+	return float64(sum) / float64(b.end-b.start), true
+}
+
+// availableChartMetrics lists the metrics an activity's streams can plot
+func availableChartMetrics(streams *StravaStreams) map[string]bool {
+	return map[string]bool{
+		ChartMetricPace:      len(streams.VelocitySmooth) > 0,
+		ChartMetricHeartRate: len(streams.Heartrate) > 0,
+		ChartMetricPower:     len(streams.Watts) > 0,
+		ChartMetricCadence:   len(streams.Cadence) > 0,
+		ChartMetricAltitude:  len(streams.Altitude) > 0,
+	}
+}
+
+type chartMetricInfo struct {
+	field   string
+	title   string
+	color   string
+	reverse bool
+}
+
+var chartMetricInfos = map[string]chartMetricInfo{
+	ChartMetricPace:      {field: "pace_min_per_km", title: "Pace (min/km)", color: "#1f77b4", reverse: true},
+	ChartMetricHeartRate: {field: "heartrate_bpm", title: "Heart rate (bpm)", color: "#d62728"},
+	ChartMetricPower:     {field: "power_w", title: "Power (W)", color: "#ff7f0e"},
+	ChartMetricCadence:   {field: "cadence_rpm", title: "Cadence", color: "#9467bd"},
+	ChartMetricAltitude:  {field: "altitude_m", title: "Altitude (m)", color: "#2ca02c"},
+}
+
+// chartXAxis returns the x field for stream charts, preferring distance over elapsed time
+func chartXAxis(streams *StravaStreams) (field, title string, ok bool) {
+	if len(streams.Distance) > 0 {
+		return "distance_km", "Distance (km)", true
+	}
+	if len(streams.Time) > 0 {
+		return "elapsed_min", "Elapsed time (min)", true
+	}
+	return "", "", false
+}
+
+func chartXValue(streams *StravaStreams, b chartBucket) (float64, bool) {
+	last := b.end - 1
+	if len(streams.Distance) > 0 {
+		if last >= len(streams.Distance) {
+			return 0, false
+		}
+		return roundTo(streams.Distance[last]/1000, 3), true
+	}
+	if last >= len(streams.Time) {
+		return 0, false
+	}
+	return roundTo(float64(streams.Time[last])/60, 2), true
+}
+
+// BuildMetricsChartSpec plots the requested metrics over distance (or time), one panel per metric.
+// Metrics default to pace, heart rate and power when available.
+func BuildMetricsChartSpec(streams *StravaStreams, metrics []string, maxPoints int) (map[string]interface{}, error) {
+	if streams == nil {
+		return nil, fmt.Errorf("%w: activity has no streams", ErrChartDataUnavailable)
+	}
+
+	xField, xTitle, ok := chartXAxis(streams)
+	if !ok {
+		return nil, fmt.Errorf("%w: activity has no distance or time stream", ErrChartDataUnavailable)
+	}
+
+	available := availableChartMetrics(streams)
+	if len(metrics) == 0 {
+		metrics = []string{ChartMetricPace, ChartMetricHeartRate, ChartMetricPower}
+	}
+
+	var selected []string
+	for _, metric := range metrics {
+		if _, known := chartMetricInfos[metric]; !known {
+			return nil, fmt.Errorf("unsupported metric '%s'", metric)
+		}
+		if available[metric] {
+			selected = append(selected, metric)
+		}
+	}
+	if len(selected) == 0 {
+		return nil, fmt.Errorf("%w: activity has none of the requested metrics (%s)", ErrChartDataUnavailable, strings.Join(metrics, ", "))
+	}
+
+	samples := len(streams.Distance)
+	if samples == 0 {
+		samples = len(streams.Time)
+	}
+
+	values := make([]map[string]interface{}, 0, maxPoints)
+	for _, b := range downsampleBuckets(samples, maxPoints) {
+		x, ok := chartXValue(streams, b)
+		if !ok {
+			continue
+		}
+		row := map[string]interface{}{xField: x}
+
+		for _, metric := range selected {
+			field := chartMetricInfos[metric].field
+			switch metric {
+			case ChartMetricPace:
+				if speed, ok := meanFloat(streams.VelocitySmooth, b); ok {
+					if pace, ok := paceMinPerKm(speed); ok {
+						row[field] = pace
+					}
+				}
+			case ChartMetricHeartRate:
+				if v, ok := meanInt(streams.Heartrate, b); ok {
+					row[field] = math.Round(v)
+				}
+			case ChartMetricPower:
+				if v, ok := meanInt(streams.Watts, b); ok {
+					row[field] = math.Round(v)
+				}
+			case ChartMetricCadence:
+				if v, ok := meanInt(streams.Cadence, b); ok {
+					row[field] = math.Round(v)
+				}
+			case ChartMetricAltitude:
+				if v, ok := meanFloat(streams.Altitude, b); ok {
+					row[field] = roundTo(v, 1)
+				}
+			}
+		}
+		values = append(values, row)
+	}
+
+	panels := make([]interface{}, 0, len(selected))
+	for _, metric := range selected {
+		info := chartMetricInfos[metric]
+		y := map[string]interface{}{
+			"field": info.field,
+			"type":  "quantitative",
+			"title": info.title,
+			"scale": map[string]interface{}{"zero": false, "reverse": info.reverse},
+		}
+		panels = append(panels, map[string]interface{}{
+			"width":  "container",
+			"height": 120,
+			"mark":   map[string]interface{}{"type": "line", "color": info.color, "strokeWidth": 1.5},
+			"encoding": map[string]interface{}{
+				"x": map[string]interface{}{"field": xField, "type": "quantitative", "title": xTitle},
+				"y": y,
+				"tooltip": []interface{}{
+					map[string]interface{}{"field": xField, "type": "quantitative", "title": xTitle},
+					map[string]interface{}{"field": info.field, "type": "quantitative", "title": info.title},
+				},
+			},
+		})
+	}
+
+	return map[string]interface{}{
+		"$schema": vegaLiteSchema,
+		"data":    map[string]interface{}{"values": values},
+		"vconcat": panels,
+	}, nil
+}
+
+// BuildZoneChartSpec draws a histogram of time in each zone.
+// zoneType selects heartrate, power or pace; empty picks the first available distribution.
+func BuildZoneChartSpec(zones *StravaActivityZones, zoneType string) (map[string]interface{}, error) {
+	if zones == nil {
+		return nil, fmt.Errorf("%w: activity has no zone data", ErrChartDataUnavailable)
+	}
+
+	distributions := []struct {
+		name  string
+		unit  string
+		title string
+		dist  *StravaZoneDistribution
+	}{
+		{"heartrate", "bpm", "Heart rate zones", zones.HeartRate},
+		{"power", "W", "Power zones", zones.Power},
+		{"pace", "", "Pace zones", zones.Pace},
+	}
+
+	for _, d := range distributions {
+		if zoneType != "" && zoneType != d.name {
+			continue
+		}
+		if d.dist == nil || len(d.dist.Zones) == 0 {
+			if zoneType != "" {
+				return nil, fmt.Errorf("%w: activity has no %s zone data", ErrChartDataUnavailable, zoneType)
+			}
+			continue
+		}
+
+		values := make([]map[string]interface{}, 0, len(d.dist.Zones))
+		for i, zone := range d.dist.Zones {
+			values = append(values, map[string]interface{}{
+				"zone":    fmt.Sprintf("Z%d", i+1),
+				"range":   zoneRangeLabel(zone, d.unit),
+				"minutes": roundTo(zone.Time/60, 1),
+			})
+		}
+
+		return map[string]interface{}{
+			"$schema": vegaLiteSchema,
+			"title":   d.title,
+			"width":   "container",
+			"height":  200,
+			"data":    map[string]interface{}{"values": values},
+			"mark":    "bar",
+			"encoding": map[string]interface{}{
+				"x": map[string]interface{}{"field": "zone", "type": "ordinal", "title": "Zone", "sort": nil},
+				"y": map[string]interface{}{"field": "minutes", "type": "quantitative", "title": "Time (min)"},
+				"color": map[string]interface{}{
+					"field":  "zone",
+					"type":   "ordinal",
+					"legend": nil,
+					"scale":  map[string]interface{}{"scheme": "yelloworangered"},
+				},
+				"tooltip": []interface{}{
+					map[string]interface{}{"field": "zone", "type": "ordinal", "title": "Zone"},
+					map[string]interface{}{"field": "range", "type": "nominal", "title": "Range"},
+					map[string]interface{}{"field": "minutes", "type": "quantitative", "title": "Minutes"},
+				},
+			},
+		}, nil
+	}
+
+	if zoneType != "" {
+		return nil, fmt.Errorf("unsupported zone_type '%s'", zoneType)
+	}
+	return nil, fmt.Errorf("%w: activity has no zone data", ErrChartDataUnavailable)
+}
+
+func zoneRangeLabel(zone StravaZoneData, unit string) string {
+	suffix := ""
+	if unit != "" {
+		suffix = " " + unit
+	}
+	if zone.Max < 0 {
+		return fmt.Sprintf("%.0f+%s", zone.Min, suffix)
+	}
+	return fmt.Sprintf("%.0f-%.0f%s", zone.Min, zone.Max, suffix)
+}
+
+// BuildLapChartSpec draws one bar per lap: pace for runs, otherwise power when recorded, else speed
+func BuildLapChartSpec(laps []LapSummary, isRun bool) (map[string]interface{}, error) {
+	if len(laps) == 0 {
+		return nil, fmt.Errorf("%w: activity has no laps", ErrChartDataUnavailable)
+	}
+
+	hasPower := false
+	for _, lap := range laps {
+		if lap.AvgPower > 0 {
+			hasPower = true
+			break
+		}
+	}
+
+	values := make([]map[string]interface{}, 0, len(laps))
+	for _, lap := range laps {
+		row := map[string]interface{}{
+			"lap":          lap.LapNumber,
+			"distance_km":  roundTo(lap.Distance/1000, 2),
+			"duration_min": roundTo(float64(lap.Duration)/60, 1),
+			"speed_kmh":    roundTo(lap.AvgSpeed*3.6, 1),
+		}
+		if pace, ok := paceMinPerKm(lap.AvgSpeed); ok {
+			row["pace_min_per_km"] = pace
+		}
+		if lap.AvgHeartRate > 0 {
+			row["heartrate_bpm"] = math.Round(lap.AvgHeartRate)
+		}
+		if lap.AvgPower > 0 {
+			row["power_w"] = math.Round(lap.AvgPower)
+		}
+		values = append(values, row)
+	}
+
+	y := map[string]interface{}{"field": "speed_kmh", "type": "quantitative", "title": "Avg speed (km/h)"}
+	switch {
+	case isRun:
+		y = map[string]interface{}{"field": "pace_min_per_km", "type": "quantitative", "title": "Avg pace (min/km)", "scale": map[string]interface{}{"reverse": true, "zero": false}}
+	case hasPower:
+		y = map[string]interface{}{"field": "power_w", "type": "quantitative", "title": "Avg power (W)"}
+	}
+
+	tooltip := []interface{}{
+		map[string]interface{}{"field": "lap", "type": "ordinal", "title": "Lap"},
+		map[string]interface{}{"field": "distance_km", "type": "quantitative", "title": "Distance (km)"},
+		map[string]interface{}{"field": "duration_min", "type": "quantitative", "title": "Duration (min)"},
+		y,
+		map[string]interface{}{"field": "heartrate_bpm", "type": "quantitative", "title": "Avg HR (bpm)"},
+	}
+
+	return map[string]interface{}{
+		"$schema": vegaLiteSchema,
+		"title":   "Laps",
+		"width":   "container",
+		"height":  200,
+		"data":    map[string]interface{}{"values": values},
+		"mark":    "bar",
+		"encoding": map[string]interface{}{
+			"x":       map[string]interface{}{"field": "lap", "type": "ordinal", "title": "Lap"},
+			"y":       y,
+			"tooltip": tooltip,
+		},
+	}, nil
+}
+
+// BuildElevationChartSpec draws the elevation profile with climb segments highlighted
+func BuildElevationChartSpec(streams *StravaStreams, climbs []ClimbSegment, maxPoints int) (map[string]interface{}, error) {
+	if streams == nil || len(streams.Altitude) == 0 || len(streams.Distance) != len(streams.Altitude) {
+		return nil, fmt.Errorf("%w: activity has no altitude and distance streams", ErrChartDataUnavailable)
+	}
+
+	profile := make([]map[string]interface{}, 0, maxPoints)
+	for _, b := range downsampleBuckets(len(streams.Altitude), maxPoints) {
+		altitude, _ := meanFloat(streams.Altitude, b)
+		profile = append(profile, map[string]interface{}{
+			"distance_km": roundTo(streams.Distance[b.end-1]/1000, 3),
+			"altitude_m":  roundTo(altitude, 1),
+		})
+	}
+
+	climbValues := make([]map[string]interface{}, 0, len(climbs))
+	for _, climb := range climbs {
+		if climb.StartIndex < 0 || climb.EndIndex >= len(streams.Distance) || climb.EndIndex <= climb.StartIndex {
+			continue
+		}
+		climbValues = append(climbValues, map[string]interface{}{
+			"start_km":  roundTo(streams.Distance[climb.StartIndex]/1000, 3),
+			"end_km":    roundTo(streams.Distance[climb.EndIndex]/1000, 3),
+			"gain_m":    roundTo(climb.ElevationGain, 0),
+			"avg_grade": roundTo(climb.AvgGrade, 1),
+		})
+	}
+
+	layers := []interface{}{
+		map[string]interface{}{
+			"data": map[string]interface{}{"values": profile},
+			"mark": map[string]interface{}{"type": "area", "color": "#8c9aa8", "opacity": 0.6, "line": true},
+			"encoding": map[string]interface{}{
+				"x": map[string]interface{}{"field": "distance_km", "type": "quantitative", "title": "Distance (km)"},
+				"y": map[string]interface{}{"field": "altitude_m", "type": "quantitative", "title": "Altitude (m)", "scale": map[string]interface{}{"zero": false}},
+				"tooltip": []interface{}{
+					map[string]interface{}{"field": "distance_km", "type": "quantitative", "title": "Distance (km)"},
+					map[string]interface{}{"field": "altitude_m", "type": "quantitative", "title": "Altitude (m)"},
+				},
+			},
+		},
+	}
+	if len(climbValues) > 0 {
+		layers = append(layers, map[string]interface{}{
+			"data": map[string]interface{}{"values": climbValues},
+			"mark": map[string]interface{}{"type": "rect", "color": "#d62728", "opacity": 0.2},
+			"encoding": map[string]interface{}{
+				"x":  map[string]interface{}{"field": "start_km", "type": "quantitative"},
+				"x2": map[string]interface{}{"field": "end_km"},
+				"tooltip": []interface{}{
+					map[string]interface{}{"field": "gain_m", "type": "quantitative", "title": "Climb gain (m)"},
+					map[string]interface{}{"field": "avg_grade", "type": "quantitative", "title": "Avg grade (%)"},
+				},
+			},
+		})
+	}
+
+	return map[string]interface{}{
+		"$schema": vegaLiteSchema,
+		"title":   "Elevation profile",
+		"width":   "container",
+		"height":  200,
+		"layer":   layers,
+	}, nil
+}
+
+// formatChartSpec wraps a spec in a vega-lite code block the chat UI renders directly
+func formatChartSpec(spec map[string]interface{}) (string, error) {
+	encoded, err := json.Marshal(spec)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode chart spec: %w", err)
+	}
+
+	var builder strings.Builder
+	builder.WriteString("Chart generated from the activity's Strava data. ")
+	builder.WriteString("Include the following block in your reply exactly as-is to display it:\n\n")
+	builder.WriteString("```vega-lite\n")
+	builder.Write(encoded)
+	builder.WriteString("\n```\n")
+	return builder.String(), nil
+}
+
+// executeRenderActivityChart fetches the data a chart needs and renders it as a Vega-Lite spec
+func (s *aiService) executeRenderActivityChart(ctx context.Context, msgCtx *MessageContext, req ActivityChartRequest) (string, error) {
+	if msgCtx == nil || msgCtx.User == nil {
+		return "", fmt.Errorf("user context is required")
+	}
+	if err := req.normalize(); err != nil {
+		return "", err
+	}
+
+	var spec map[string]interface{}
+	var err error
+
+	switch req.ChartType {
+	case ChartTypeZones:
+		zones, zoneErr := s.stravaService.GetActivityZones(ctx, msgCtx.User, req.ActivityID)
+		if zoneErr != nil {
+			return "", s.handleStravaError(zoneErr, "activity zones")
+		}
+		spec, err = BuildZoneChartSpec(zones, req.ZoneType)
+
+	case ChartTypeLaps:
+		detail, detailErr := s.stravaService.GetActivityDetail(ctx, msgCtx.User, req.ActivityID)
+		if detailErr != nil {
+			return "", s.handleStravaError(detailErr, "activity details")
+		}
+		streams, streamErr := s.stravaService.GetActivityStreams(ctx, msgCtx.User, req.ActivityID, chartStreamTypes, "high")
+		if streamErr != nil {
+			return "", s.handleStravaError(streamErr, "activity streams")
+		}
+		analysis := AnalyzeLapByLap(streams, detail.Laps)
+		spec, err = BuildLapChartSpec(analysis.LapSummaries, isRunActivity(detail.SportType) || isRunActivity(detail.Type))
+
+	case ChartTypeElevation:
+		streams, streamErr := s.stravaService.GetActivityStreams(ctx, msgCtx.User, req.ActivityID, chartStreamTypes, "high")
+		if streamErr != nil {
+			return "", s.handleStravaError(streamErr, "activity streams")
+		}
+		elevation := CalculateElevationAnalysis(streams.Altitude, streams.Distance, streams.Time)
+		spec, err = BuildElevationChartSpec(streams, elevation.ClimbSegments, req.MaxPoints)
+
+	default:
+		streams, streamErr := s.stravaService.GetActivityStreams(ctx, msgCtx.User, req.ActivityID, chartStreamTypes, "high")
+		if streamErr != nil {
+			return "", s.handleStravaError(streamErr, "activity streams")
+		}
+		spec, err = BuildMetricsChartSpec(streams, req.Metrics, req.MaxPoints)
+	}
+
+	if err != nil {
+		return "", err
+	}
+	return formatChartSpec(spec)
+}
+
+// ExecuteRenderActivityChart executes the render-activity-chart tool
+func (s *aiService) ExecuteRenderActivityChart(ctx context.Context, msgCtx *MessageContext, req ActivityChartRequest) (string, error) {
+	return s.executeRenderActivityChart(ctx, msgCtx, req)
+}
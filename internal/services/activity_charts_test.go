@@ -0,0 +1,213 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"bodda/internal/config"
+	"bodda/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func syntheticRunStreams(points int) *StravaStreams {
+	streams := &StravaStreams{}
+	for i := 0; i < points; i++ {
+		streams.Time = append(streams.Time, i)
+		streams.Distance = append(streams.Distance, float64(i)*3.0)
+		streams.VelocitySmooth = append(streams.VelocitySmooth, 3.0)
+		streams.Heartrate = append(streams.Heartrate, 140+i%10)
+		// A single climb in the middle third
+		altitude := 100.0
+		if i > points/3 && i <= 2*points/3 {
+			altitude += float64(i-points/3) * 0.3
+		} else if i > 2*points/3 {
+			altitude += float64(points/3) * 0.3
+		}
+		streams.Altitude = append(streams.Altitude, altitude)
+	}
+	return streams
+}
+
+// chartValues extracts data.values from a spec after a JSON round trip
+func chartValues(t *testing.T, spec map[string]interface{}) []interface{} {
+	t.Helper()
+	encoded, err := json.Marshal(spec)
+	require.NoError(t, err)
+
+	var decoded map[string]interface{}
+	require.NoError(t, json.Unmarshal(encoded, &decoded))
+	assert.Equal(t, vegaLiteSchema, decoded["$schema"])
+
+	data, ok := decoded["data"].(map[string]interface{})
+	require.True(t, ok)
+	values, ok := data["values"].([]interface{})
+	require.True(t, ok)
+	return values
+}
+
+func TestDownsampleBuckets(t *testing.T) {
+	assert.Len(t, downsampleBuckets(10, 300), 10)
+	assert.Nil(t, downsampleBuckets(0, 300))
+
+	buckets := downsampleBuckets(1000, 300)
+	require.Len(t, buckets, 300)
+	assert.Equal(t, 0, buckets[0].start)
+	assert.Equal(t, 1000, buckets[len(buckets)-1].end)
+	for i := 1; i < len(buckets); i++ {
+		assert.Equal(t, buckets[i-1].end, buckets[i].start, "buckets are contiguous")
+	}
+}
+
+func TestActivityChartRequest_Normalize(t *testing.T) {
+	req := ActivityChartRequest{ActivityID: 1}
+	require.NoError(t, req.normalize())
+	assert.Equal(t, ChartTypeMetrics, req.ChartType)
+	assert.Equal(t, defaultChartPoints, req.MaxPoints)
+
+	req = ActivityChartRequest{ActivityID: 1, MaxPoints: 10}
+	require.NoError(t, req.normalize())
+	assert.Equal(t, minChartPoints, req.MaxPoints)
+
+	req = ActivityChartRequest{ActivityID: 1, MaxPoints: 5000}
+	require.NoError(t, req.normalize())
+	assert.Equal(t, maxChartPoints, req.MaxPoints)
+
+	assert.Error(t, (&ActivityChartRequest{}).normalize())
+	assert.Error(t, (&ActivityChartRequest{ActivityID: 1, ChartType: "pie"}).normalize())
+}
+
+func TestBuildMetricsChartSpec(t *testing.T) {
+	streams := syntheticRunStreams(3000)
+
+	spec, err := BuildMetricsChartSpec(streams, nil, 200)
+	require.NoError(t, err)
+
+	values := chartValues(t, spec)
+	assert.Len(t, values, 200, "streams are down-sampled to the point budget")
+
+	first := values[0].(map[string]interface{})
+	assert.InDelta(t, 5.56, first["pace_min_per_km"], 0.01, "3 m/s is 5:33 per km")
+	assert.Contains(t, first, "heartrate_bpm")
+	assert.NotContains(t, first, "power_w", "missing streams are skipped")
+
+	last := values[len(values)-1].(map[string]interface{})
+	assert.InDelta(t, 8.997, last["distance_km"], 0.001)
+
+	panels := spec["vconcat"].([]interface{})
+	require.Len(t, panels, 2)
+	paceY := panels[0].(map[string]interface{})["encoding"].(map[string]interface{})["y"].(map[string]interface{})
+	assert.Equal(t, true, paceY["scale"].(map[string]interface{})["reverse"], "faster pace is plotted higher")
+
+	_, err = BuildMetricsChartSpec(streams, []string{"power"}, 200)
+	assert.True(t, errors.Is(err, ErrChartDataUnavailable))
+
+	_, err = BuildMetricsChartSpec(streams, []string{"vo2"}, 200)
+	assert.Error(t, err)
+}
+
+func TestBuildMetricsChartSpec_TimeAxisWithoutDistance(t *testing.T) {
+	streams := &StravaStreams{Time: []int{0, 60, 120}, Watts: []int{200, 210, 220}}
+
+	spec, err := BuildMetricsChartSpec(streams, []string{"power"}, 100)
+	require.NoError(t, err)
+
+	values := chartValues(t, spec)
+	require.Len(t, values, 3)
+	assert.Equal(t, 2.0, values[2].(map[string]interface{})["elapsed_min"])
+}
+
+func TestBuildZoneChartSpec(t *testing.T) {
+	zones := &StravaActivityZones{
+		HeartRate: &StravaZoneDistribution{Zones: []StravaZoneData{
+			{Min: 0, Max: 130, Time: 600},
+			{Min: 130, Max: 150, Time: 1800},
+			{Min: 150, Max: -1, Time: 300},
+		}},
+	}
+
+	spec, err := BuildZoneChartSpec(zones, "")
+	require.NoError(t, err)
+
+	values := chartValues(t, spec)
+	require.Len(t, values, 3)
+	assert.Equal(t, map[string]interface{}{"zone": "Z2", "range": "130-150 bpm", "minutes": 30.0}, values[1])
+	assert.Equal(t, "150+ bpm", values[2].(map[string]interface{})["range"])
+
+	_, err = BuildZoneChartSpec(zones, "power")
+	assert.True(t, errors.Is(err, ErrChartDataUnavailable))
+}
+
+func TestBuildLapChartSpec(t *testing.T) {
+	laps := []LapSummary{
+		{LapNumber: 1, Distance: 1000, Duration: 300, AvgSpeed: 3.33, AvgHeartRate: 150},
+		{LapNumber: 2, Distance: 1000, Duration: 280, AvgSpeed: 3.57, AvgHeartRate: 158},
+	}
+
+	spec, err := BuildLapChartSpec(laps, true)
+	require.NoError(t, err)
+	values := chartValues(t, spec)
+	require.Len(t, values, 2)
+	assert.InDelta(t, 5.0, values[0].(map[string]interface{})["pace_min_per_km"], 0.01)
+
+	y := spec["encoding"].(map[string]interface{})["y"].(map[string]interface{})
+	assert.Equal(t, "pace_min_per_km", y["field"])
+
+	laps[0].AvgPower, laps[1].AvgPower = 250, 260
+	spec, err = BuildLapChartSpec(laps, false)
+	require.NoError(t, err)
+	y = spec["encoding"].(map[string]interface{})["y"].(map[string]interface{})
+	assert.Equal(t, "power_w", y["field"])
+
+	_, err = BuildLapChartSpec(nil, true)
+	assert.True(t, errors.Is(err, ErrChartDataUnavailable))
+}
+
+func TestBuildElevationChartSpec(t *testing.T) {
+	streams := syntheticRunStreams(600)
+	elevation := CalculateElevationAnalysis(streams.Altitude, streams.Distance, streams.Time)
+
+	spec, err := BuildElevationChartSpec(streams, elevation.ClimbSegments, 100)
+	require.NoError(t, err)
+
+	layers := spec["layer"].([]interface{})
+	require.NotEmpty(t, layers)
+	profile := layers[0].(map[string]interface{})["data"].(map[string]interface{})["values"].([]map[string]interface{})
+	assert.Len(t, profile, 100)
+
+	if len(elevation.ClimbSegments) > 0 {
+		require.Len(t, layers, 2, "climbs are overlaid on the profile")
+	}
+
+	_, err = BuildElevationChartSpec(&StravaStreams{Time: []int{1}}, nil, 100)
+	assert.True(t, errors.Is(err, ErrChartDataUnavailable))
+}
+
+func TestRenderActivityChartTool(t *testing.T) {
+	aiService := NewAIService(&config.Config{OpenAIAPIKey: "test-key"}, &mockStravaServiceForToolExecutor{}, &mockLogbookServiceForToolExecutor{}, &MockSessionRepositoryForToolExecutor{}, NewToolRegistry())
+	executor := NewToolExecutor(aiService, NewToolRegistry())
+
+	msgCtx := &MessageContext{
+		UserID: "test-user",
+		User:   &models.User{ID: "test-user", AccessToken: "test-token"},
+	}
+
+	result, err := executor.ExecuteTool(context.Background(), "render-activity-chart", map[string]interface{}{
+		"activity_id": float64(123),
+		"chart_type":  "zones",
+	}, msgCtx)
+	require.NoError(t, err)
+	require.True(t, result.Success, result.Error)
+
+	content := result.Data.(string)
+	assert.Contains(t, content, "```vega-lite\n")
+	start := strings.Index(content, "{")
+	end := strings.LastIndex(content, "}")
+	var spec map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(content[start:end+1]), &spec), "the block is valid JSON")
+	assert.Equal(t, "Heart rate zones", spec["title"])
+}
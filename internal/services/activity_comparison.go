@@ -0,0 +1,461 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+)
+
+const (
+	minComparedActivities = 2
+	maxComparedActivities = 5
+
+	// Aligned ranges are split into this many equal segments for side-by-side splits
+	comparisonSegments = 10
+)
+
+// Alignment modes for compare-activities
+const (
+	AlignByDistance = "distance"
+	AlignByTime     = "time"
+)
+
+// comparisonStreamTypes are the streams fetched for each compared activity
+var comparisonStreamTypes = []string{"time", "distance", "velocity_smooth", "heartrate", "watts", "cadence"}
+
+// ActivityComparisonRequest describes which activities to compare and how to align them
+type ActivityComparisonRequest struct {
+	ActivityIDs []int64 `json:"activity_ids"`
+	AlignBy     string  `json:"align_by"`
+}
+
+// normalize applies defaults and validates the activity list
+func (r *ActivityComparisonRequest) normalize() error {
+	if len(r.ActivityIDs) < minComparedActivities || len(r.ActivityIDs) > maxComparedActivities {
+		return fmt.Errorf("compare-activities needs between %d and %d activity IDs, got %d", minComparedActivities, maxComparedActivities, len(r.ActivityIDs))
+	}
+
+	seen := make(map[int64]bool, len(r.ActivityIDs))
+	for _, id := range r.ActivityIDs {
+		if id <= 0 {
+			return fmt.Errorf("invalid activity ID %d", id)
+		}
+		if seen[id] {
+			return fmt.Errorf("activity %d is listed more than once", id)
+		}
+		seen[id] = true
+	}
+
+	if r.AlignBy == "" {
+		r.AlignBy = AlignByDistance
+	}
+	if r.AlignBy != AlignByDistance && r.AlignBy != AlignByTime {
+		return fmt.Errorf("unsupported align_by '%s'", r.AlignBy)
+	}
+	return nil
+}
+
+// ComparedActivityData is the raw data for one activity in a comparison
+type ComparedActivityData struct {
+	Detail  *StravaActivityDetail
+	Streams *StravaStreams
+}
+
+// ComparisonSegment holds averages for one aligned slice of an activity
+type ComparisonSegment struct {
+	Start        float64 `json:"start"`
+	End          float64 `json:"end"`
+	AvgSpeed     float64 `json:"avg_speed,omitempty"`
+	AvgHeartRate float64 `json:"avg_heart_rate,omitempty"`
+	AvgPower     float64 `json:"avg_power,omitempty"`
+}
+
+// ActivityComparisonEntry summarizes one activity over the aligned range
+type ActivityComparisonEntry struct {
+	ActivityID int64               `json:"activity_id"`
+	Name       string              `json:"name"`
+	StartDate  string              `json:"start_date"`
+	SportType  string              `json:"sport_type"`
+	Speed      *MetricStats        `json:"speed,omitempty"`
+	HeartRate  *MetricStats        `json:"heart_rate,omitempty"`
+	Power      *MetricStats        `json:"power,omitempty"`
+	Cadence    *MetricStats        `json:"cadence,omitempty"`
+	Decoupling *float64            `json:"decoupling_percent,omitempty"`
+	LapCount   int                 `json:"lap_count"`
+	Laps       *LapComparisons     `json:"laps,omitempty"`
+	Segments   []ComparisonSegment `json:"segments"`
+}
+
+// ActivityComparison is the result of aligning several activities over a common range
+type ActivityComparison struct {
+	AlignBy     string                    `json:"align_by"`
+	CommonRange float64                   `json:"common_range"` // metres or seconds
+	MixedSports bool                      `json:"mixed_sports"`
+	Activities  []ActivityComparisonEntry `json:"activities"`
+}
+
+// comparisonAxis returns the stream activities are aligned on
+func comparisonAxis(streams *StravaStreams, alignBy string) []float64 {
+	if streams == nil {
+		return nil
+	}
+	if alignBy == AlignByTime {
+		axis := make([]float64, len(streams.Time))
+		for i, t := range streams.Time {
+			axis[i] = float64(t)
+		}
+		return axis
+	}
+	return streams.Distance
+}
+
+// alignedEnd returns the number of leading samples that fall within limit
+func alignedEnd(axis []float64, limit float64) int {
+	for i, v := range axis {
+		if v > limit {
+			return i
+		}
+	}
+	return len(axis)
+}
+
+// CompareActivities aligns activities on distance or time over the range they all cover
+// and summarizes each one. The first activity is the baseline for deltas.
+func CompareActivities(activities []ComparedActivityData, alignBy string) (*ActivityComparison, error) {
+	if len(activities) < minComparedActivities {
+		return nil, fmt.Errorf("at least %d activities are required for a comparison", minComparedActivities)
+	}
+
+	commonRange := math.MaxFloat64
+	for _, activity := range activities {
+		axis := comparisonAxis(activity.Streams, alignBy)
+		if len(axis) < 2 {
+			return nil, fmt.Errorf("activity %d has no %s stream to align on", activityIDOf(activity), alignBy)
+		}
+		commonRange = math.Min(commonRange, axis[len(axis)-1])
+	}
+	if commonRange <= 0 {
+		return nil, fmt.Errorf("activities have no overlapping %s to compare", alignBy)
+	}
+
+	comparison := &ActivityComparison{
+		AlignBy:     alignBy,
+		CommonRange: commonRange,
+	}
+
+	for i, activity := range activities {
+		entry := summarizeAlignedActivity(activity, alignBy, commonRange)
+		if i > 0 && entry.SportType != comparison.Activities[0].SportType {
+			comparison.MixedSports = true
+		}
+		comparison.Activities = append(comparison.Activities, entry)
+	}
+
+	return comparison, nil
+}
+
+func activityIDOf(activity ComparedActivityData) int64 {
+	if activity.Detail == nil {
+		return 0
+	}
+	return activity.Detail.ID
+}
+
+func summarizeAlignedActivity(activity ComparedActivityData, alignBy string, commonRange float64) ActivityComparisonEntry {
+	streams := activity.Streams
+	axis := comparisonAxis(streams, alignBy)
+	end := alignedEnd(axis, commonRange)
+
+	entry := ActivityComparisonEntry{}
+	if detail := activity.Detail; detail != nil {
+		entry.ActivityID = detail.ID
+		entry.Name = detail.Name
+		entry.StartDate = detail.StartDateLocal
+		entry.SportType = detail.SportType
+		if entry.SportType == "" {
+			entry.SportType = detail.Type
+		}
+	}
+
+	var moving []float64
+	if len(streams.VelocitySmooth) >= end {
+		for _, v := range streams.VelocitySmooth[:end] {
+			if v >= minPaceSpeed {
+				moving = append(moving, v)
+			}
+		}
+	}
+	if len(moving) > 0 {
+		entry.Speed = CalculateFloatStats(moving)
+	}
+	if len(streams.Heartrate) >= end && end > 0 {
+		entry.HeartRate = CalculateIntStats(streams.Heartrate[:end])
+	}
+	if len(streams.Watts) >= end && end > 0 {
+		entry.Power = CalculateIntStats(streams.Watts[:end])
+	}
+	if len(streams.Cadence) >= end && end > 0 {
+		entry.Cadence = CalculateIntStats(streams.Cadence[:end])
+	}
+	if decoupling := CalculateAerobicDecoupling(streams, end); decoupling != nil {
+		entry.Decoupling = &decoupling.DecouplingPercent
+	}
+
+	if activity.Detail != nil && len(activity.Detail.Laps) > 1 {
+		analysis := AnalyzeLapByLap(streams, activity.Detail.Laps)
+		entry.LapCount = analysis.TotalLaps
+		entry.Laps = &analysis.LapComparisons
+	}
+
+	entry.Segments = alignedSegments(streams, axis[:end], commonRange)
+	return entry
+}
+
+// alignedSegments averages each metric over equal slices of the aligned range
+func alignedSegments(streams *StravaStreams, axis []float64, commonRange float64) []ComparisonSegment {
+	segments := make([]ComparisonSegment, comparisonSegments)
+	width := commonRange / comparisonSegments
+
+	idx := 0
+	for s := range segments {
+		segment := ComparisonSegment{Start: width * float64(s), End: width * float64(s+1)}
+
+		start := idx
+		for idx < len(axis) && (axis[idx] <= segment.End || s == len(segments)-1) {
+			idx++
+		}
+		bucket := chartBucket{start: start, end: idx}
+
+		if speed, ok := meanFloat(streams.VelocitySmooth, bucket); ok {
+			segment.AvgSpeed = roundTo(speed, 2)
+		}
+		if hr, ok := meanInt(streams.Heartrate, bucket); ok {
+			segment.AvgHeartRate = math.Round(hr)
+		}
+		if power, ok := meanInt(streams.Watts, bucket); ok {
+			segment.AvgPower = math.Round(power)
+		}
+		segments[s] = segment
+	}
+	return segments
+}
+
+// formatActivityComparison renders a comparison as markdown for the model
+func formatActivityComparison(comparison *ActivityComparison) string {
+	var b strings.Builder
+	baseline := comparison.Activities[0]
+	isRun := isRunActivity(baseline.SportType)
+
+	b.WriteString("# Activity Comparison\n\n")
+	if comparison.AlignBy == AlignByTime {
+		b.WriteString(fmt.Sprintf("Aligned on elapsed time over the first %s that all activities cover. ", formatComparisonDuration(comparison.CommonRange)))
+	} else {
+		b.WriteString(fmt.Sprintf("Aligned on distance over the first %.2f km that all activities cover. ", comparison.CommonRange/1000))
+	}
+	b.WriteString(fmt.Sprintf("Deltas are relative to the baseline, %s.\n", describeComparedActivity(baseline)))
+	if comparison.MixedSports {
+		b.WriteString("\n⚠️ The activities are different sport types, so pace and power deltas may not be meaningful.\n")
+	}
+
+	b.WriteString("\n## Summary\n\n")
+	b.WriteString("| Activity | Date | " + speedHeader(isRun) + " | Avg HR | Avg Power | Avg Cadence | Decoupling | Laps |\n")
+	b.WriteString("|---|---|---|---|---|---|---|---|\n")
+	for _, entry := range comparison.Activities {
+		b.WriteString(fmt.Sprintf("| [%s](https://www.strava.com/activities/%d) | %s | %s | %s | %s | %s | %s | %s |\n",
+			entry.Name, entry.ActivityID, entry.StartDate,
+			formatComparisonSpeed(entry.Speed, isRun),
+			formatComparisonStat(entry.HeartRate, "bpm"),
+			formatComparisonStat(entry.Power, "W"),
+			formatComparisonStat(entry.Cadence, ""),
+			formatComparisonDecoupling(entry.Decoupling),
+			formatComparisonLaps(entry)))
+	}
+
+	if len(comparison.Activities) > 1 {
+		b.WriteString("\n## Deltas vs Baseline\n\n")
+		for _, entry := range comparison.Activities[1:] {
+			b.WriteString(fmt.Sprintf("**%s**\n", describeComparedActivity(entry)))
+			for _, line := range comparisonDeltas(baseline, entry, isRun) {
+				b.WriteString("- " + line + "\n")
+			}
+			b.WriteString("\n")
+		}
+	}
+
+	b.WriteString("## Aligned Splits\n\n")
+	b.WriteString("| Segment |")
+	for i := range comparison.Activities {
+		b.WriteString(fmt.Sprintf(" #%d %s | #%d HR |", i+1, speedHeader(isRun), i+1))
+	}
+	b.WriteString("\n|---|")
+	for range comparison.Activities {
+		b.WriteString("---|---|")
+	}
+	b.WriteString("\n")
+	for s := 0; s < comparisonSegments; s++ {
+		segment := baseline.Segments[s]
+		if comparison.AlignBy == AlignByTime {
+			b.WriteString(fmt.Sprintf("| %s-%s |", formatComparisonDuration(segment.Start), formatComparisonDuration(segment.End)))
+		} else {
+			b.WriteString(fmt.Sprintf("| %.1f-%.1f km |", segment.Start/1000, segment.End/1000))
+		}
+		for _, entry := range comparison.Activities {
+			seg := entry.Segments[s]
+			b.WriteString(fmt.Sprintf(" %s | %s |", formatSpeedValue(seg.AvgSpeed, isRun), formatOptional(seg.AvgHeartRate, "%.0f")))
+		}
+		b.WriteString("\n")
+	}
+
+	return b.String()
+}
+
+func describeComparedActivity(entry ActivityComparisonEntry) string {
+	if entry.StartDate != "" {
+		return fmt.Sprintf("%s (%s)", entry.Name, entry.StartDate)
+	}
+	return entry.Name
+}
+
+// comparisonDeltas lists the differences between an activity and the baseline
+func comparisonDeltas(baseline, entry ActivityComparisonEntry, isRun bool) []string {
+	var deltas []string
+
+	if baseline.Speed != nil && entry.Speed != nil {
+		if isRun {
+			basePace, _ := paceMinPerKm(baseline.Speed.Mean)
+			pace, _ := paceMinPerKm(entry.Speed.Mean)
+			diff := (pace - basePace) * 60
+			direction := "faster"
+			if diff > 0 {
+				direction = "slower"
+			}
+			deltas = append(deltas, fmt.Sprintf("Pace: %.0f s/km %s", math.Abs(diff), direction))
+		} else {
+			deltas = append(deltas, fmt.Sprintf("Speed: %+.1f km/h", (entry.Speed.Mean-baseline.Speed.Mean)*3.6))
+		}
+	}
+	if baseline.HeartRate != nil && entry.HeartRate != nil {
+		deltas = append(deltas, fmt.Sprintf("Avg HR: %+.0f bpm", entry.HeartRate.Mean-baseline.HeartRate.Mean))
+	}
+	if baseline.Power != nil && entry.Power != nil {
+		deltas = append(deltas, fmt.Sprintf("Avg power: %+.0f W", entry.Power.Mean-baseline.Power.Mean))
+	}
+	if baseline.Cadence != nil && entry.Cadence != nil {
+		deltas = append(deltas, fmt.Sprintf("Avg cadence: %+.1f", entry.Cadence.Mean-baseline.Cadence.Mean))
+	}
+	if baseline.Decoupling != nil && entry.Decoupling != nil {
+		deltas = append(deltas, fmt.Sprintf("Decoupling: %+.1f percentage points", *entry.Decoupling-*baseline.Decoupling))
+	}
+	if baseline.Speed != nil && entry.Speed != nil && baseline.HeartRate != nil && entry.HeartRate != nil &&
+		baseline.HeartRate.Mean > 0 && entry.HeartRate.Mean > 0 {
+		baseEff := baseline.Speed.Mean / baseline.HeartRate.Mean
+		eff := entry.Speed.Mean / entry.HeartRate.Mean
+		deltas = append(deltas, fmt.Sprintf("Speed per heartbeat: %+.1f%%", (eff-baseEff)/baseEff*100))
+	}
+	if baseline.Laps != nil && entry.Laps != nil {
+		deltas = append(deltas, fmt.Sprintf("Lap consistency score: %+.1f", entry.Laps.ConsistencyScore-baseline.Laps.ConsistencyScore))
+	}
+
+	if len(deltas) == 0 {
+		deltas = append(deltas, "No shared metrics to compare")
+	}
+	return deltas
+}
+
+func speedHeader(isRun bool) string {
+	if isRun {
+		return "Avg Pace"
+	}
+	return "Avg Speed"
+}
+
+func formatSpeedValue(speed float64, isRun bool) string {
+	if speed <= 0 {
+		return "-"
+	}
+	if isRun {
+		if speed < minPaceSpeed {
+			return "-"
+		}
+		secondsPerKm := int(math.Round(1000 / speed))
+		return fmt.Sprintf("%d:%02d/km", secondsPerKm/60, secondsPerKm%60)
+	}
+	return fmt.Sprintf("%.1f km/h", speed*3.6)
+}
+
+func formatComparisonSpeed(stats *MetricStats, isRun bool) string {
+	if stats == nil {
+		return "-"
+	}
+	return formatSpeedValue(stats.Mean, isRun)
+}
+
+func formatComparisonStat(stats *MetricStats, unit string) string {
+	if stats == nil || stats.Mean == 0 {
+		return "-"
+	}
+	return strings.TrimSpace(fmt.Sprintf("%.0f %s", stats.Mean, unit))
+}
+
+func formatComparisonDecoupling(decoupling *float64) string {
+	if decoupling == nil {
+		return "-"
+	}
+	return fmt.Sprintf("%.1f%%", *decoupling)
+}
+
+func formatComparisonLaps(entry ActivityComparisonEntry) string {
+	if entry.Laps == nil {
+		return "-"
+	}
+	return fmt.Sprintf("%d (fastest #%d, consistency %.0f)", entry.LapCount, entry.Laps.FastestLap, entry.Laps.ConsistencyScore)
+}
+
+func formatOptional(value float64, format string) string {
+	if value == 0 {
+		return "-"
+	}
+	return fmt.Sprintf(format, value)
+}
+
+func formatComparisonDuration(seconds float64) string {
+	total := int(seconds)
+	if total >= 3600 {
+		return fmt.Sprintf("%d:%02d:%02d", total/3600, (total%3600)/60, total%60)
+	}
+	return fmt.Sprintf("%d:%02d", total/60, total%60)
+}
+
+// executeCompareActivities fetches each activity and compares them over their common range
+func (s *aiService) executeCompareActivities(ctx context.Context, msgCtx *MessageContext, req ActivityComparisonRequest) (string, error) {
+	if msgCtx == nil || msgCtx.User == nil {
+		return "", fmt.Errorf("user context is required")
+	}
+	if err := req.normalize(); err != nil {
+		return "", err
+	}
+
+	activities := make([]ComparedActivityData, 0, len(req.ActivityIDs))
+	for _, id := range req.ActivityIDs {
+		if err := ctx.Err(); err != nil {
+			return "", err
+		}
+
+		detail, err := s.stravaService.GetActivityDetail(ctx, msgCtx.User, id)
+		if err != nil {
+			return "", s.handleStravaError(err, fmt.Sprintf("activity %d details", id))
+		}
+		streams, err := s.stravaService.GetActivityStreams(ctx, msgCtx.User, id, comparisonStreamTypes, "high")
+		if err != nil {
+			return "", s.handleStravaError(err, fmt.Sprintf("activity %d streams", id))
+		}
+		activities = append(activities, ComparedActivityData{Detail: detail, Streams: streams})
+	}
+
+	comparison, err := CompareActivities(activities, req.AlignBy)
+	if err != nil {
+		return "", err
+	}
+	return formatActivityComparison(comparison), nil
+}
@@ -0,0 +1,131 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"bodda/internal/config"
+	"bodda/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// steadyRun builds an activity at a constant speed with heart rate drifting up by hrDrift over the run
+func steadyRun(id int64, points int, speed float64, hr int, hrDrift int) ComparedActivityData {
+	streams := &StravaStreams{}
+	for i := 0; i < points; i++ {
+		streams.Time = append(streams.Time, i)
+		streams.Distance = append(streams.Distance, float64(i)*speed)
+		streams.VelocitySmooth = append(streams.VelocitySmooth, speed)
+		streams.Heartrate = append(streams.Heartrate, hr+hrDrift*i/points)
+		streams.Cadence = append(streams.Cadence, 85)
+	}
+	return ComparedActivityData{
+		Detail: &StravaActivityDetail{StravaActivity: StravaActivity{
+			ID:             id,
+			Name:           "Tempo",
+			SportType:      "Run",
+			StartDateLocal: "2025-03-15T07:00:00Z",
+		}},
+		Streams: streams,
+	}
+}
+
+func TestActivityComparisonRequest_Normalize(t *testing.T) {
+	req := ActivityComparisonRequest{ActivityIDs: []int64{1, 2}}
+	require.NoError(t, req.normalize())
+	assert.Equal(t, AlignByDistance, req.AlignBy)
+
+	assert.Error(t, (&ActivityComparisonRequest{ActivityIDs: []int64{1}}).normalize())
+	assert.Error(t, (&ActivityComparisonRequest{ActivityIDs: []int64{1, 2, 3, 4, 5, 6}}).normalize())
+	assert.Error(t, (&ActivityComparisonRequest{ActivityIDs: []int64{1, 1}}).normalize())
+	assert.Error(t, (&ActivityComparisonRequest{ActivityIDs: []int64{1, 2}, AlignBy: "heartrate"}).normalize())
+}
+
+func TestCompareActivities_AlignsOnCommonDistance(t *testing.T) {
+	baseline := steadyRun(1, 2000, 3.0, 160, 10) // 6 km
+	faster := steadyRun(2, 1800, 3.5, 158, 2)    // 6.3 km
+
+	comparison, err := CompareActivities([]ComparedActivityData{baseline, faster}, AlignByDistance)
+	require.NoError(t, err)
+
+	assert.InDelta(t, 5997, comparison.CommonRange, 1, "aligned over the shorter activity")
+	require.Len(t, comparison.Activities, 2)
+	assert.False(t, comparison.MixedSports)
+
+	first, second := comparison.Activities[0], comparison.Activities[1]
+	require.NotNil(t, first.Speed)
+	assert.InDelta(t, 3.0, first.Speed.Mean, 0.001)
+	assert.InDelta(t, 3.5, second.Speed.Mean, 0.001)
+
+	require.NotNil(t, first.Decoupling)
+	require.NotNil(t, second.Decoupling)
+	assert.Greater(t, *first.Decoupling, *second.Decoupling, "bigger HR drift means more decoupling")
+
+	require.Len(t, first.Segments, comparisonSegments)
+	assert.InDelta(t, 599.7, first.Segments[0].End, 0.1)
+	assert.Equal(t, 3.5, second.Segments[9].AvgSpeed)
+
+	deltas := comparisonDeltas(first, second, true)
+	assert.Contains(t, deltas[0], "s/km faster")
+	assert.Contains(t, deltas[1], "Avg HR")
+}
+
+func TestCompareActivities_Errors(t *testing.T) {
+	run := steadyRun(1, 100, 3.0, 150, 0)
+	noDistance := steadyRun(2, 100, 3.0, 150, 0)
+	noDistance.Streams.Distance = nil
+
+	_, err := CompareActivities([]ComparedActivityData{run}, AlignByDistance)
+	assert.Error(t, err)
+
+	_, err = CompareActivities([]ComparedActivityData{run, noDistance}, AlignByDistance)
+	assert.Error(t, err)
+
+	comparison, err := CompareActivities([]ComparedActivityData{run, noDistance}, AlignByTime)
+	require.NoError(t, err)
+	assert.Equal(t, 99.0, comparison.CommonRange)
+}
+
+func TestFormatActivityComparison(t *testing.T) {
+	ride := steadyRun(3, 1000, 8.0, 140, 0)
+	ride.Detail.SportType = "Ride"
+
+	comparison, err := CompareActivities([]ComparedActivityData{steadyRun(1, 1000, 3.0, 150, 5), steadyRun(2, 1000, 3.2, 150, 5), ride}, AlignByTime)
+	require.NoError(t, err)
+
+	output := formatActivityComparison(comparison)
+	assert.Contains(t, output, "Aligned on elapsed time over the first 16:39")
+	assert.Contains(t, output, "different sport types")
+	assert.Contains(t, output, "https://www.strava.com/activities/2")
+	assert.Contains(t, output, "5:33/km")
+	assert.Contains(t, output, "## Aligned Splits")
+}
+
+func TestCompareActivitiesTool(t *testing.T) {
+	aiService := NewAIService(&config.Config{OpenAIAPIKey: "test-key"}, &mockStravaServiceForToolExecutor{}, &mockLogbookServiceForToolExecutor{}, &MockSessionRepositoryForToolExecutor{}, NewToolRegistry())
+	executor := NewToolExecutor(aiService, NewToolRegistry())
+
+	msgCtx := &MessageContext{
+		UserID: "test-user",
+		User:   &models.User{ID: "test-user", AccessToken: "test-token"},
+	}
+
+	result, err := executor.ExecuteTool(context.Background(), "compare-activities", map[string]interface{}{
+		"activity_ids": []interface{}{float64(1), float64(2)},
+		"align_by":     "time",
+	}, msgCtx)
+	require.NoError(t, err)
+	require.True(t, result.Success, result.Error)
+	assert.Contains(t, result.Data, "# Activity Comparison")
+
+	// The mock streams have no distance, so distance alignment is refused
+	result, err = executor.ExecuteTool(context.Background(), "compare-activities", map[string]interface{}{
+		"activity_ids": []interface{}{float64(1), float64(2)},
+		"align_by":     "distance",
+	}, msgCtx)
+	require.NoError(t, err)
+	assert.False(t, result.Success)
+	assert.Contains(t, result.Error, "no distance stream")
+}
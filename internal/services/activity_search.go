@@ -0,0 +1,383 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"bodda/internal/models"
+)
+
+const (
+	defaultSearchLimit = 20
+	maxSearchLimit     = 100
+
+	// Activities are requested from Strava in pages of this size
+	searchPageSize = 200
+	// Upper bound on pages scanned per search so a broad filter cannot exhaust the rate limit
+	maxSearchPages = 10
+)
+
+// Flag filter values for trainer, commute and race
+const (
+	SearchFlagAny     = "any"
+	SearchFlagOnly    = "only"
+	SearchFlagExclude = "exclude"
+)
+
+// Strava workout_type values marking a race
+const (
+	workoutTypeRunRace  = 1
+	workoutTypeRideRace = 11
+)
+
+// ErrInvalidActivitySearch is returned when search filters are malformed or contradictory
+var ErrInvalidActivitySearch = errors.New("invalid activity search")
+
+// ActivitySearchRequest describes the filters for search-activities.
+// Zero values mean "no filter"; dates are YYYY-MM-DD (or RFC3339) and both ends are inclusive.
+type ActivitySearchRequest struct {
+	After              string   `json:"after"`
+	Before             string   `json:"before"`
+	SportTypes         []string `json:"sport_types"`
+	MinDistanceKm      float64  `json:"min_distance_km"`
+	MaxDistanceKm      float64  `json:"max_distance_km"`
+	MinDurationMinutes float64  `json:"min_duration_minutes"`
+	MaxDurationMinutes float64  `json:"max_duration_minutes"`
+	Keywords           string   `json:"keywords"`
+	Trainer            string   `json:"trainer"`
+	Commute            string   `json:"commute"`
+	Race               string   `json:"race"`
+	GearID             string   `json:"gear_id"`
+	Limit              int      `json:"limit"`
+
+	after    *time.Time
+	before   *time.Time
+	keywords []string
+}
+
+// normalize applies defaults, parses dates and validates ranges
+func (r *ActivitySearchRequest) normalize() error {
+	var err error
+	if r.after, err = parseSearchDate(r.After, false); err != nil {
+		return fmt.Errorf("%w: after: %v", ErrInvalidActivitySearch, err)
+	}
+	if r.before, err = parseSearchDate(r.Before, true); err != nil {
+		return fmt.Errorf("%w: before: %v", ErrInvalidActivitySearch, err)
+	}
+	if r.after != nil && r.before != nil && !r.after.Before(*r.before) {
+		return fmt.Errorf("%w: after must be earlier than before", ErrInvalidActivitySearch)
+	}
+
+	if r.MinDistanceKm < 0 || r.MaxDistanceKm < 0 || r.MinDurationMinutes < 0 || r.MaxDurationMinutes < 0 {
+		return fmt.Errorf("%w: distance and duration bounds must not be negative", ErrInvalidActivitySearch)
+	}
+	if r.MaxDistanceKm > 0 && r.MinDistanceKm > r.MaxDistanceKm {
+		return fmt.Errorf("%w: min_distance_km exceeds max_distance_km", ErrInvalidActivitySearch)
+	}
+	if r.MaxDurationMinutes > 0 && r.MinDurationMinutes > r.MaxDurationMinutes {
+		return fmt.Errorf("%w: min_duration_minutes exceeds max_duration_minutes", ErrInvalidActivitySearch)
+	}
+
+	for _, flag := range []*string{&r.Trainer, &r.Commute, &r.Race} {
+		*flag = strings.ToLower(strings.TrimSpace(*flag))
+		if *flag == "" {
+			*flag = SearchFlagAny
+		}
+		if *flag != SearchFlagAny && *flag != SearchFlagOnly && *flag != SearchFlagExclude {
+			return fmt.Errorf("%w: flag filters must be any, only or exclude, got '%s'", ErrInvalidActivitySearch, *flag)
+		}
+	}
+
+	sportTypes := make([]string, 0, len(r.SportTypes))
+	for _, sportType := range r.SportTypes {
+		if sportType = strings.TrimSpace(sportType); sportType != "" {
+			sportTypes = append(sportTypes, sportType)
+		}
+	}
+	r.SportTypes = sportTypes
+	r.GearID = strings.TrimSpace(r.GearID)
+	r.keywords = strings.Fields(strings.ToLower(r.Keywords))
+
+	if r.Limit <= 0 {
+		r.Limit = defaultSearchLimit
+	}
+	if r.Limit > maxSearchLimit {
+		r.Limit = maxSearchLimit
+	}
+	return nil
+}
+
+// parseSearchDate parses a date filter. A date-only upper bound covers the whole day.
+func parseSearchDate(value string, endOfDay bool) (*time.Time, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil, nil
+	}
+	if parsed, err := time.Parse(time.RFC3339, value); err == nil {
+		return &parsed, nil
+	}
+	parsed, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return nil, fmt.Errorf("expected YYYY-MM-DD, got '%s'", value)
+	}
+	if endOfDay {
+		parsed = parsed.Add(24 * time.Hour)
+	}
+	return &parsed, nil
+}
+
+// Matches reports whether an activity passes every filter except the date range,
+// which Strava applies server-side. The request must have been normalized.
+func (r *ActivitySearchRequest) Matches(activity *StravaActivity) bool {
+	if activity == nil {
+		return false
+	}
+
+	if len(r.SportTypes) > 0 {
+		matched := false
+		for _, sportType := range r.SportTypes {
+			if strings.EqualFold(sportType, activity.SportType) || strings.EqualFold(sportType, activity.Type) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+
+	distanceKm := activity.Distance / 1000
+	if r.MinDistanceKm > 0 && distanceKm < r.MinDistanceKm {
+		return false
+	}
+	if r.MaxDistanceKm > 0 && distanceKm > r.MaxDistanceKm {
+		return false
+	}
+
+	durationMinutes := float64(activity.MovingTime) / 60
+	if r.MinDurationMinutes > 0 && durationMinutes < r.MinDurationMinutes {
+		return false
+	}
+	if r.MaxDurationMinutes > 0 && durationMinutes > r.MaxDurationMinutes {
+		return false
+	}
+
+	name := strings.ToLower(activity.Name)
+	for _, keyword := range r.keywords {
+		if !strings.Contains(name, keyword) {
+			return false
+		}
+	}
+
+	if !matchesSearchFlag(r.Trainer, activity.Trainer) ||
+		!matchesSearchFlag(r.Commute, activity.Commute) ||
+		!matchesSearchFlag(r.Race, isRaceActivity(activity)) {
+		return false
+	}
+
+	if r.GearID != "" && activity.GearID != r.GearID {
+		return false
+	}
+	return true
+}
+
+func matchesSearchFlag(filter string, value bool) bool {
+	switch filter {
+	case SearchFlagOnly:
+		return value
+	case SearchFlagExclude:
+		return !value
+	default:
+		return true
+	}
+}
+
+// isRaceActivity reports whether Strava marks the activity as a race
+func isRaceActivity(activity *StravaActivity) bool {
+	return activity.WorkoutType == workoutTypeRunRace || activity.WorkoutType == workoutTypeRideRace
+}
+
+// Describe renders the active filters as a short human-readable list
+func (r *ActivitySearchRequest) Describe() string {
+	var parts []string
+	if r.After != "" {
+		parts = append(parts, "from "+r.After)
+	}
+	if r.Before != "" {
+		parts = append(parts, "until "+r.Before)
+	}
+	if len(r.SportTypes) > 0 {
+		parts = append(parts, "sport: "+strings.Join(r.SportTypes, "/"))
+	}
+	if r.MinDistanceKm > 0 || r.MaxDistanceKm > 0 {
+		parts = append(parts, "distance: "+describeSearchRange(r.MinDistanceKm, r.MaxDistanceKm, "km"))
+	}
+	if r.MinDurationMinutes > 0 || r.MaxDurationMinutes > 0 {
+		parts = append(parts, "moving time: "+describeSearchRange(r.MinDurationMinutes, r.MaxDurationMinutes, "min"))
+	}
+	if r.Keywords != "" {
+		parts = append(parts, fmt.Sprintf("name contains \"%s\"", r.Keywords))
+	}
+	for _, flag := range []struct{ name, value string }{{"trainer", r.Trainer}, {"commute", r.Commute}, {"race", r.Race}} {
+		switch flag.value {
+		case SearchFlagOnly:
+			parts = append(parts, flag.name+" only")
+		case SearchFlagExclude:
+			parts = append(parts, "no "+flag.name)
+		}
+	}
+	if r.GearID != "" {
+		parts = append(parts, "gear "+r.GearID)
+	}
+	if len(parts) == 0 {
+		return "no filters"
+	}
+	return strings.Join(parts, ", ")
+}
+
+func describeSearchRange(min, max float64, unit string) string {
+	switch {
+	case min > 0 && max > 0:
+		return fmt.Sprintf("%g-%g %s", min, max, unit)
+	case min > 0:
+		return fmt.Sprintf("≥ %g %s", min, unit)
+	default:
+		return fmt.Sprintf("≤ %g %s", max, unit)
+	}
+}
+
+// ActivitySearchResult holds the matches of a search and how much history was scanned
+type ActivitySearchResult struct {
+	Request  ActivitySearchRequest `json:"-"`
+	Matches  []*StravaActivity     `json:"-"`
+	Scanned  int                   `json:"scanned"`
+	Pages    int                   `json:"pages"`
+	Complete bool                  `json:"complete"`
+}
+
+// ActivitySummary is the compact representation of an activity returned by the search API
+type ActivitySummary struct {
+	ID             int64   `json:"id"`
+	Name           string  `json:"name"`
+	SportType      string  `json:"sport_type"`
+	StartDateLocal string  `json:"start_date_local"`
+	DistanceKm     float64 `json:"distance_km"`
+	MovingTime     int     `json:"moving_time"`
+	ElevationGain  float64 `json:"elevation_gain"`
+	Trainer        bool    `json:"trainer"`
+	Commute        bool    `json:"commute"`
+	Race           bool    `json:"race"`
+	GearID         string  `json:"gear_id,omitempty"`
+}
+
+// Summaries converts the matches to compact summaries
+func (r *ActivitySearchResult) Summaries() []ActivitySummary {
+	summaries := make([]ActivitySummary, 0, len(r.Matches))
+	for _, activity := range r.Matches {
+		sportType := activity.SportType
+		if sportType == "" {
+			sportType = activity.Type
+		}
+		summaries = append(summaries, ActivitySummary{
+			ID:             activity.ID,
+			Name:           activity.Name,
+			SportType:      sportType,
+			StartDateLocal: activity.StartDateLocal,
+			DistanceKm:     roundTo(activity.Distance/1000, 2),
+			MovingTime:     activity.MovingTime,
+			ElevationGain:  activity.TotalElevationGain,
+			Trainer:        activity.Trainer,
+			Commute:        activity.Commute,
+			Race:           isRaceActivity(activity),
+			GearID:         activity.GearID,
+		})
+	}
+	return summaries
+}
+
+// SearchActivities pages through the athlete's Strava activities within the requested date range
+// and returns up to req.Limit matches, newest first. Scanning stops after maxSearchPages pages;
+// Complete is false when matches may exist beyond what was scanned.
+func SearchActivities(ctx context.Context, stravaService StravaService, user *models.User, req ActivitySearchRequest) (*ActivitySearchResult, error) {
+	if user == nil {
+		return nil, fmt.Errorf("user context is required")
+	}
+	if err := req.normalize(); err != nil {
+		return nil, err
+	}
+
+	result := &ActivitySearchResult{Request: req}
+	pages, scanned, complete, err := scanActivities(ctx, stravaService, user, req.after, req.before, func(activities []*StravaActivity) bool {
+		for _, activity := range activities {
+			if req.Matches(activity) {
+				result.Matches = append(result.Matches, activity)
+			}
+		}
+		return len(result.Matches) < req.Limit
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to search activities: %w", err)
+	}
+	result.Pages, result.Scanned, result.Complete = pages, scanned, complete
+
+	// Strava returns pages oldest first when only "after" is set, so order explicitly
+	sort.SliceStable(result.Matches, func(i, j int) bool {
+		return result.Matches[i].StartDate > result.Matches[j].StartDate
+	})
+	if len(result.Matches) > req.Limit {
+		result.Matches = result.Matches[:req.Limit]
+		result.Complete = false
+	}
+	return result, nil
+}
+
+// scanActivities pages through activities between after and before, passing each page to visit
+// until visit returns false, a short page marks the end of the range, or maxSearchPages is reached.
+// complete reports whether the whole range was read.
+func scanActivities(ctx context.Context, stravaService StravaService, user *models.User, after, before *time.Time, visit func([]*StravaActivity) bool) (pages, scanned int, complete bool, err error) {
+	for page := 1; page <= maxSearchPages; page++ {
+		if err := ctx.Err(); err != nil {
+			return pages, scanned, false, err
+		}
+
+		activities, err := stravaService.GetActivities(ctx, user, ActivityParams{
+			Before:  before,
+			After:   after,
+			Page:    page,
+			PerPage: searchPageSize,
+		})
+		if err != nil {
+			return pages, scanned, false, err
+		}
+		pages = page
+		scanned += len(activities)
+
+		more := visit(activities)
+		if len(activities) < searchPageSize {
+			return pages, scanned, true, nil
+		}
+		if !more {
+			break
+		}
+	}
+	return pages, scanned, false, nil
+}
+
+func (s *aiService) executeSearchActivities(ctx context.Context, msgCtx *MessageContext, req ActivitySearchRequest) (string, error) {
+	if msgCtx == nil || msgCtx.User == nil {
+		return "", fmt.Errorf("user context is required")
+	}
+
+	result, err := SearchActivities(ctx, s.stravaService, msgCtx.User, req)
+	if err != nil {
+		if errors.Is(err, ErrInvalidActivitySearch) || errors.Is(err, context.Canceled) {
+			return "", err
+		}
+		return "", s.handleStravaError(err, "activities")
+	}
+	return s.formatter.FormatActivitySearch(result), nil
+}
@@ -0,0 +1,180 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"bodda/internal/config"
+	"bodda/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// pagedStravaService serves a fixed activity history in Strava-style pages
+type pagedStravaService struct {
+	mockStravaServiceForToolExecutor
+	activities []*StravaActivity
+	requests   []ActivityParams
+}
+
+func (m *pagedStravaService) GetActivities(ctx context.Context, user *models.User, params ActivityParams) ([]*StravaActivity, error) {
+	m.requests = append(m.requests, params)
+	start := (params.Page - 1) * params.PerPage
+	if start >= len(m.activities) {
+		return nil, nil
+	}
+	end := start + params.PerPage
+	if end > len(m.activities) {
+		end = len(m.activities)
+	}
+	return m.activities[start:end], nil
+}
+
+// activityHistory builds n activities, newest first, with a trainer ride every fourth day
+func activityHistory(n int) []*StravaActivity {
+	start := time.Date(2025, 6, 1, 7, 0, 0, 0, time.UTC)
+	activities := make([]*StravaActivity, 0, n)
+	for i := 0; i < n; i++ {
+		activity := &StravaActivity{
+			ID:             int64(i + 1),
+			Name:           fmt.Sprintf("Easy Run %d", i),
+			Type:           "Run",
+			SportType:      "Run",
+			Distance:       8000,
+			MovingTime:     2700,
+			StartDate:      start.Add(-time.Duration(i) * 24 * time.Hour).Format(time.RFC3339),
+			StartDateLocal: start.Add(-time.Duration(i) * 24 * time.Hour).Format(time.RFC3339),
+			GearID:         "g1",
+		}
+		if i%4 == 1 {
+			activity.Name = fmt.Sprintf("Zwift Ride %d", i)
+			activity.Type, activity.SportType = "Ride", "VirtualRide"
+			activity.Distance, activity.MovingTime = 30000, 3600
+			activity.Trainer = true
+			activity.GearID = "b1"
+		}
+		activities = append(activities, activity)
+	}
+	return activities
+}
+
+func TestActivitySearchRequest_Normalize(t *testing.T) {
+	req := ActivitySearchRequest{After: "2025-03-01", Before: "2025-03-31"}
+	require.NoError(t, req.normalize())
+	assert.Equal(t, defaultSearchLimit, req.Limit)
+	assert.Equal(t, SearchFlagAny, req.Trainer)
+	assert.Equal(t, time.Date(2025, 4, 1, 0, 0, 0, 0, time.UTC), *req.before, "a date-only upper bound includes the whole day")
+
+	req = ActivitySearchRequest{Limit: 1000, Race: " ONLY "}
+	require.NoError(t, req.normalize())
+	assert.Equal(t, maxSearchLimit, req.Limit)
+	assert.Equal(t, SearchFlagOnly, req.Race)
+
+	for _, invalid := range []ActivitySearchRequest{
+		{After: "March 1st"},
+		{After: "2025-03-10", Before: "2025-03-01"},
+		{MinDistanceKm: 10, MaxDistanceKm: 5},
+		{MinDurationMinutes: -1},
+		{Commute: "sometimes"},
+	} {
+		err := invalid.normalize()
+		assert.True(t, errors.Is(err, ErrInvalidActivitySearch), "%+v", invalid)
+	}
+}
+
+func TestActivitySearchRequest_Matches(t *testing.T) {
+	run := &StravaActivity{Name: "Sunday Long Run", Type: "Run", SportType: "TrailRun", Distance: 21000, MovingTime: 7200, GearID: "g7"}
+	race := &StravaActivity{Name: "City 10K", Type: "Run", SportType: "Run", Distance: 10000, MovingTime: 2400, WorkoutType: workoutTypeRunRace}
+
+	tests := []struct {
+		name     string
+		req      ActivitySearchRequest
+		activity *StravaActivity
+		want     bool
+	}{
+		{"no filters", ActivitySearchRequest{}, run, true},
+		{"sport type matches sport_type or type", ActivitySearchRequest{SportTypes: []string{"run"}}, run, true},
+		{"sport type excluded", ActivitySearchRequest{SportTypes: []string{"Ride"}}, run, false},
+		{"distance range", ActivitySearchRequest{MinDistanceKm: 20, MaxDistanceKm: 25}, run, true},
+		{"too short", ActivitySearchRequest{MinDistanceKm: 25}, run, false},
+		{"duration range", ActivitySearchRequest{MaxDurationMinutes: 60}, run, false},
+		{"all keywords", ActivitySearchRequest{Keywords: "long sunday"}, run, true},
+		{"missing keyword", ActivitySearchRequest{Keywords: "long tempo"}, run, false},
+		{"races only", ActivitySearchRequest{Race: SearchFlagOnly}, race, true},
+		{"races excluded", ActivitySearchRequest{Race: SearchFlagExclude}, race, false},
+		{"trainer only", ActivitySearchRequest{Trainer: SearchFlagOnly}, run, false},
+		{"gear", ActivitySearchRequest{GearID: "g7"}, run, true},
+		{"other gear", ActivitySearchRequest{GearID: "g8"}, run, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.NoError(t, tt.req.normalize())
+			assert.Equal(t, tt.want, tt.req.Matches(tt.activity))
+		})
+	}
+}
+
+func TestSearchActivities_PaginatesUntilLimit(t *testing.T) {
+	strava := &pagedStravaService{activities: activityHistory(450)}
+	user := &models.User{ID: "user-1"}
+
+	result, err := SearchActivities(context.Background(), strava, user, ActivitySearchRequest{
+		SportTypes: []string{"VirtualRide"},
+		Trainer:    SearchFlagOnly,
+		Limit:      maxSearchLimit,
+	})
+	require.NoError(t, err)
+
+	assert.Len(t, result.Matches, maxSearchLimit)
+	assert.Equal(t, 2, result.Pages, "stops as soon as enough matches are found")
+	assert.Equal(t, 400, result.Scanned)
+	assert.False(t, result.Complete)
+	assert.Equal(t, int64(2), result.Matches[0].ID, "newest first")
+
+	strava = &pagedStravaService{activities: activityHistory(150)}
+	result, err = SearchActivities(context.Background(), strava, user, ActivitySearchRequest{After: "2025-01-01", Keywords: "zwift", Limit: 50})
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Pages)
+	assert.Len(t, result.Matches, 38)
+	assert.True(t, result.Complete, "a short page means the whole range was scanned")
+	require.NotNil(t, strava.requests[0].After, "the date range is passed to Strava")
+	assert.Equal(t, searchPageSize, strava.requests[0].PerPage)
+}
+
+func TestSearchActivitiesTool(t *testing.T) {
+	strava := &pagedStravaService{activities: activityHistory(10)}
+	strava.activities[2].WorkoutType = workoutTypeRunRace
+
+	aiService := NewAIService(&config.Config{OpenAIAPIKey: "test-key"}, strava, &mockLogbookServiceForToolExecutor{}, &MockSessionRepositoryForToolExecutor{}, NewToolRegistry())
+	executor := NewToolExecutor(aiService, NewToolRegistry())
+
+	msgCtx := &MessageContext{
+		UserID: "test-user",
+		User:   &models.User{ID: "test-user", AccessToken: "test-token"},
+	}
+
+	result, err := executor.ExecuteTool(context.Background(), "search-activities", map[string]interface{}{
+		"sport_types": []interface{}{"Run"},
+		"race":        "only",
+	}, msgCtx)
+	require.NoError(t, err)
+	require.True(t, result.Success, result.Error)
+
+	content := result.Data.(string)
+	assert.Contains(t, content, "🔎 **Activity Search** (1 matches, 10 activities scanned)")
+	assert.Contains(t, content, "Filters: sport: Run, race only")
+	assert.Contains(t, content, "**Easy Run 2** (ID: 3) — 8.00km in 45:00 on 5/30/2025 [race]")
+
+	result, err = executor.ExecuteTool(context.Background(), "search-activities", map[string]interface{}{
+		"min_distance_km": float64(50),
+		"max_distance_km": float64(10),
+	}, msgCtx)
+	require.NoError(t, err)
+	assert.False(t, result.Success)
+	assert.Contains(t, result.Error, "min_distance_km exceeds max_distance_km")
+}
@@ -0,0 +1,466 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Output bases for decoupling and efficiency factor
+const (
+	AerobicBasisPace  = "Pa:HR"
+	AerobicBasisPower = "Pw:HR"
+	// Efficiency factor from normalized graded pace, used for runs with grade data
+	AerobicBasisGradedPace = "NGP:HR"
+)
+
+const (
+	// Below this speed a runner is treated as stopped and the sample is ignored for pace-based metrics
+	minMovingSpeed = 0.5
+	// Decoupling needs enough samples in each half to be meaningful
+	minDecouplingSamples = 20
+	// Decoupling under this percentage indicates a well-developed aerobic base
+	decouplingAerobicThreshold = 5.0
+)
+
+// AerobicDecoupling compares output per heartbeat between the first and second half of an effort.
+// Positive values mean heart rate drifted up relative to pace or power.
+type AerobicDecoupling struct {
+	Basis             string  `json:"basis"`
+	FirstHalfRatio    float64 `json:"first_half_ratio"`
+	SecondHalfRatio   float64 `json:"second_half_ratio"`
+	DecouplingPercent float64 `json:"decoupling_percent"`
+}
+
+// EfficiencyFactor is output per heartbeat: normalized power / HR for rides with power,
+// normalized graded pace in m/min / HR for runs with grade data, or moving speed in m/min / HR otherwise
+type EfficiencyFactor struct {
+	Basis        string  `json:"basis"`
+	Output       float64 `json:"output"`
+	AvgHeartRate float64 `json:"avg_heart_rate"`
+	Value        float64 `json:"value"`
+}
+
+// AerobicAnalysis groups the aerobic base indicators derived from one activity's streams
+type AerobicAnalysis struct {
+	Decoupling       *AerobicDecoupling `json:"decoupling,omitempty"`
+	EfficiencyFactor *EfficiencyFactor  `json:"efficiency_factor,omitempty"`
+}
+
+// CalculateAerobicAnalysis derives decoupling and efficiency factor from streams, or returns nil without heart rate
+func CalculateAerobicAnalysis(streams *StravaStreams) *AerobicAnalysis {
+	if streams == nil || len(streams.Heartrate) == 0 {
+		return nil
+	}
+
+	analysis := &AerobicAnalysis{
+		Decoupling:       CalculateAerobicDecoupling(streams, len(streams.Heartrate)),
+		EfficiencyFactor: CalculateEfficiencyFactor(streams),
+	}
+	if analysis.Decoupling == nil && analysis.EfficiencyFactor == nil {
+		return nil
+	}
+	return analysis
+}
+
+// aerobicOutput returns the output series used for decoupling (power when recorded, otherwise speed)
+func aerobicOutput(streams *StravaStreams, end int) ([]float64, string) {
+	switch {
+	case len(streams.Watts) >= end:
+		output := make([]float64, end)
+		for i, w := range streams.Watts[:end] {
+			output[i] = float64(w)
+		}
+		return output, AerobicBasisPower
+	case len(streams.VelocitySmooth) >= end:
+		// Grade-adjusted speed keeps hills from masquerading as drift
+		if gap := CalculateGAPStream(streams); len(gap) >= end {
+			return gap[:end], AerobicBasisPace
+		}
+		return streams.VelocitySmooth[:end], AerobicBasisPace
+	default:
+		return nil, ""
+	}
+}
+
+// CalculateAerobicDecoupling splits the first end samples into halves by elapsed time and compares
+// output per heartbeat between them. Samples without heart rate, and stopped samples for pace, are skipped.
+func CalculateAerobicDecoupling(streams *StravaStreams, end int) *AerobicDecoupling {
+	if streams == nil || end > len(streams.Heartrate) || end < 2*minDecouplingSamples {
+		return nil
+	}
+	output, basis := aerobicOutput(streams, end)
+	if output == nil {
+		return nil
+	}
+
+	half := end / 2
+	if len(streams.Time) >= end {
+		midpoint := (streams.Time[0] + streams.Time[end-1]) / 2
+		half = sort.SearchInts(streams.Time[:end], midpoint)
+	}
+
+	firstRatio, ok := outputPerBeat(output, streams.Heartrate, 0, half, basis)
+	if !ok {
+		return nil
+	}
+	secondRatio, ok := outputPerBeat(output, streams.Heartrate, half, end, basis)
+	if !ok {
+		return nil
+	}
+
+	return &AerobicDecoupling{
+		Basis:             basis,
+		FirstHalfRatio:    roundTo(firstRatio, 4),
+		SecondHalfRatio:   roundTo(secondRatio, 4),
+		DecouplingPercent: roundTo((firstRatio-secondRatio)/firstRatio*100, 1),
+	}
+}
+
+// outputPerBeat averages output and heart rate over samples [start, end) and returns their ratio
+func outputPerBeat(output []float64, heartRate []int, start, end int, basis string) (float64, bool) {
+	var outputSum, hrSum float64
+	samples := 0
+	for i := start; i < end; i++ {
+		if heartRate[i] <= 0 {
+			continue
+		}
+		if basis == AerobicBasisPace && output[i] < minMovingSpeed {
+			continue
+		}
+		outputSum += output[i]
+		hrSum += float64(heartRate[i])
+		samples++
+	}
+	if samples < minDecouplingSamples || outputSum <= 0 {
+		return 0, false
+	}
+	return outputSum / hrSum, true
+}
+
+// CalculateEfficiencyFactor computes NP/HR when power is recorded, NGP (m/min)/HR when grade is available,
+// otherwise moving speed (m/min) per heartbeat
+func CalculateEfficiencyFactor(streams *StravaStreams) *EfficiencyFactor {
+	if streams == nil {
+		return nil
+	}
+
+	var hrSum float64
+	hrSamples := 0
+	for _, hr := range streams.Heartrate {
+		if hr > 0 {
+			hrSum += float64(hr)
+			hrSamples++
+		}
+	}
+	if hrSamples == 0 {
+		return nil
+	}
+	avgHR := hrSum / float64(hrSamples)
+
+	ef := &EfficiencyFactor{AvgHeartRate: roundTo(avgHR, 1)}
+	if np := CalculateNormalizedPower(streams.Watts, streams.Time); np > 0 {
+		ef.Basis = AerobicBasisPower
+		ef.Output = roundTo(np, 1)
+	} else if ngp := CalculateNormalizedGradedPace(CalculateGAPStream(streams)); ngp > 0 {
+		ef.Basis = AerobicBasisGradedPace
+		ef.Output = roundTo(ngp*60, 1)
+	} else {
+		var speedSum float64
+		moving := 0
+		for _, speed := range streams.VelocitySmooth {
+			if speed >= minMovingSpeed {
+				speedSum += speed
+				moving++
+			}
+		}
+		if moving == 0 {
+			return nil
+		}
+		ef.Basis = AerobicBasisPace
+		ef.Output = roundTo(speedSum/float64(moving)*60, 1)
+	}
+	ef.Value = roundTo(ef.Output/avgHR, 3)
+	return ef
+}
+
+// Limits for get-aerobic-trend
+const (
+	defaultAerobicTrendWeeks       = 8
+	maxAerobicTrendWeeks           = 26
+	defaultAerobicTrendActivities  = 10
+	maxAerobicTrendActivities      = 15
+	defaultAerobicTrendMinDuration = 40
+)
+
+// aerobicTrendStreamTypes are the streams fetched for each activity in a trend
+var aerobicTrendStreamTypes = []string{"time", "velocity_smooth", "heartrate", "watts", "grade_smooth"}
+
+// AerobicTrendRequest selects the easy sessions whose efficiency and decoupling are tracked
+type AerobicTrendRequest struct {
+	SportTypes         []string `json:"sport_types"`
+	Weeks              int      `json:"weeks"`
+	MaxAvgHeartRate    int      `json:"max_avg_heart_rate"`
+	MinDurationMinutes int      `json:"min_duration_minutes"`
+	Limit              int      `json:"limit"`
+}
+
+// normalize applies defaults and bounds
+func (r *AerobicTrendRequest) normalize() error {
+	if len(r.SportTypes) == 0 {
+		r.SportTypes = []string{"Run"}
+	}
+	if r.Weeks <= 0 {
+		r.Weeks = defaultAerobicTrendWeeks
+	}
+	if r.Weeks > maxAerobicTrendWeeks {
+		r.Weeks = maxAerobicTrendWeeks
+	}
+	if r.MaxAvgHeartRate < 0 {
+		return fmt.Errorf("max_avg_heart_rate must not be negative")
+	}
+	if r.MinDurationMinutes <= 0 {
+		r.MinDurationMinutes = defaultAerobicTrendMinDuration
+	}
+	if r.Limit <= 0 {
+		r.Limit = defaultAerobicTrendActivities
+	}
+	if r.Limit > maxAerobicTrendActivities {
+		r.Limit = maxAerobicTrendActivities
+	}
+	return nil
+}
+
+// AerobicTrendPoint holds the aerobic indicators of one session
+type AerobicTrendPoint struct {
+	ActivityID int64            `json:"activity_id"`
+	Name       string           `json:"name"`
+	Date       time.Time        `json:"date"`
+	SportType  string           `json:"sport_type"`
+	Analysis   *AerobicAnalysis `json:"analysis"`
+}
+
+// AerobicTrend summarizes how efficiency and decoupling develop across sessions, oldest first
+type AerobicTrend struct {
+	Points []AerobicTrendPoint `json:"points"`
+	// EFChangePer4Weeks is the fitted change in efficiency factor over 28 days, as a percentage of the first fitted value
+	EFChangePer4Weeks *float64 `json:"ef_change_per_4_weeks,omitempty"`
+	// MeanDecoupling averages decoupling over sessions where it could be computed
+	MeanDecoupling *float64 `json:"mean_decoupling,omitempty"`
+	// DecouplingChange is the fitted change in decoupling over the covered period, in percentage points
+	DecouplingChange *float64 `json:"decoupling_change,omitempty"`
+	HeartRateCap     int      `json:"heart_rate_cap,omitempty"`
+}
+
+// BuildAerobicTrend orders the points by date and fits linear trends to efficiency factor and decoupling
+func BuildAerobicTrend(points []AerobicTrendPoint) *AerobicTrend {
+	trend := &AerobicTrend{Points: append([]AerobicTrendPoint(nil), points...)}
+	sort.SliceStable(trend.Points, func(i, j int) bool {
+		return trend.Points[i].Date.Before(trend.Points[j].Date)
+	})
+	if len(trend.Points) == 0 {
+		return trend
+	}
+
+	origin := trend.Points[0].Date
+	var efDays, efValues, decDays, decValues []float64
+	for _, point := range trend.Points {
+		if point.Analysis == nil {
+			continue
+		}
+		days := point.Date.Sub(origin).Hours() / 24
+		if point.Analysis.EfficiencyFactor != nil {
+			efDays = append(efDays, days)
+			efValues = append(efValues, point.Analysis.EfficiencyFactor.Value)
+		}
+		if point.Analysis.Decoupling != nil {
+			decDays = append(decDays, days)
+			decValues = append(decValues, point.Analysis.Decoupling.DecouplingPercent)
+		}
+	}
+
+	if slope, intercept, ok := linearFit(efDays, efValues); ok && intercept > 0 {
+		change := roundTo(slope*28/intercept*100, 1)
+		trend.EFChangePer4Weeks = &change
+	}
+	if len(decValues) > 0 {
+		sum := 0.0
+		for _, v := range decValues {
+			sum += v
+		}
+		mean := roundTo(sum/float64(len(decValues)), 1)
+		trend.MeanDecoupling = &mean
+	}
+	if slope, _, ok := linearFit(decDays, decValues); ok {
+		change := roundTo(slope*(decDays[len(decDays)-1]-decDays[0]), 1)
+		trend.DecouplingChange = &change
+	}
+	return trend
+}
+
+// linearFit returns the least-squares slope and intercept, requiring at least three points spread over time
+func linearFit(x, y []float64) (slope, intercept float64, ok bool) {
+	n := float64(len(x))
+	if len(x) < 3 || len(x) != len(y) {
+		return 0, 0, false
+	}
+	var sumX, sumY, sumXY, sumXX float64
+	for i := range x {
+		sumX += x[i]
+		sumY += y[i]
+		sumXY += x[i] * y[i]
+		sumXX += x[i] * x[i]
+	}
+	denominator := n*sumXX - sumX*sumX
+	if math.Abs(denominator) < 1e-9 {
+		return 0, 0, false
+	}
+	slope = (n*sumXY - sumX*sumY) / denominator
+	intercept = (sumY - slope*sumX) / n
+	return slope, intercept, true
+}
+
+// easyHeartRateCap returns the upper bound of the athlete's second heart rate zone, or 0 when unknown
+func easyHeartRateCap(zones *StravaAthleteZones) int {
+	if zones == nil || zones.HeartRate == nil || len(zones.HeartRate.Zones) < 2 {
+		return 0
+	}
+	return zones.HeartRate.Zones[1].Max
+}
+
+// formatAerobicTrend renders the trend as a markdown table with an interpretation
+func formatAerobicTrend(trend *AerobicTrend, req AerobicTrendRequest) string {
+	var b strings.Builder
+	b.WriteString("# Aerobic Efficiency Trend\n\n")
+	b.WriteString(fmt.Sprintf("Easy %s sessions over the last %d weeks, at least %d minutes", strings.Join(req.SportTypes, "/"), req.Weeks, req.MinDurationMinutes))
+	if trend.HeartRateCap > 0 {
+		b.WriteString(fmt.Sprintf(", average heart rate ≤ %d bpm", trend.HeartRateCap))
+	}
+	b.WriteString(".\n\n")
+
+	if len(trend.Points) == 0 {
+		b.WriteString("No matching sessions with heart rate data were found. Try a longer period, a higher heart rate cap or a shorter minimum duration.\n")
+		return b.String()
+	}
+
+	b.WriteString("| Date | Activity | EF | Basis | Output | Avg HR | Decoupling |\n|---|---|---|---|---|---|---|\n")
+	for _, point := range trend.Points {
+		ef, basis, output, hr, decoupling := "–", "–", "–", "–", "–"
+		if point.Analysis != nil && point.Analysis.EfficiencyFactor != nil {
+			factor := point.Analysis.EfficiencyFactor
+			ef = strconv.FormatFloat(factor.Value, 'f', 3, 64)
+			basis = factor.Basis
+			output = formatEfficiencyOutput(factor)
+			hr = fmt.Sprintf("%.0f", factor.AvgHeartRate)
+		}
+		if point.Analysis != nil && point.Analysis.Decoupling != nil {
+			decoupling = fmt.Sprintf("%.1f%%", point.Analysis.Decoupling.DecouplingPercent)
+		}
+		b.WriteString(fmt.Sprintf("| %s | [%s](https://www.strava.com/activities/%d) | %s | %s | %s | %s | %s |\n",
+			point.Date.Format("2006-01-02"), point.Name, point.ActivityID, ef, basis, output, hr, decoupling))
+	}
+
+	b.WriteString("\n## Trend\n\n")
+	if trend.EFChangePer4Weeks != nil {
+		direction := "improving"
+		if *trend.EFChangePer4Weeks < 0 {
+			direction = "declining"
+		}
+		b.WriteString(fmt.Sprintf("- Efficiency factor: %+.1f%% per 4 weeks (%s)\n", *trend.EFChangePer4Weeks, direction))
+	} else {
+		b.WriteString("- Efficiency factor: not enough sessions for a trend\n")
+	}
+	if trend.MeanDecoupling != nil {
+		verdict := "above the 5% aerobic threshold; the aerobic base has room to develop"
+		if *trend.MeanDecoupling < decouplingAerobicThreshold {
+			verdict = "below the 5% threshold that indicates a solid aerobic base"
+		}
+		b.WriteString(fmt.Sprintf("- Mean decoupling: %.1f%% (%s)\n", *trend.MeanDecoupling, verdict))
+	}
+	if trend.DecouplingChange != nil {
+		b.WriteString(fmt.Sprintf("- Decoupling change over the period: %+.1f percentage points\n", *trend.DecouplingChange))
+	}
+	b.WriteString("\nEF compares output per heartbeat: higher is better. Compare sessions of similar terrain and conditions, since hills and heat lower EF.\n")
+	return b.String()
+}
+
+// formatEfficiencyOutput shows the output behind an efficiency factor in familiar units
+func formatEfficiencyOutput(ef *EfficiencyFactor) string {
+	switch ef.Basis {
+	case AerobicBasisPower:
+		return fmt.Sprintf("%.0f W NP", ef.Output)
+	case AerobicBasisGradedPace:
+		return formatSpeedValue(ef.Output/60, true) + " NGP"
+	default:
+		return formatSpeedValue(ef.Output/60, true)
+	}
+}
+
+func (s *aiService) executeGetAerobicTrend(ctx context.Context, msgCtx *MessageContext, req AerobicTrendRequest) (string, error) {
+	if msgCtx == nil || msgCtx.User == nil {
+		return "", fmt.Errorf("user context is required")
+	}
+	if err := req.normalize(); err != nil {
+		return "", err
+	}
+
+	heartRateCap := req.MaxAvgHeartRate
+	if heartRateCap == 0 {
+		if zones, err := s.stravaService.GetAthleteZones(ctx, msgCtx.User); err == nil {
+			heartRateCap = easyHeartRateCap(zones)
+		}
+	}
+
+	search, err := SearchActivities(ctx, s.stravaService, msgCtx.User, ActivitySearchRequest{
+		After:              time.Now().AddDate(0, 0, -7*req.Weeks).Format("2006-01-02"),
+		SportTypes:         req.SportTypes,
+		MinDurationMinutes: float64(req.MinDurationMinutes),
+		Race:               SearchFlagExclude,
+		Limit:              maxSearchLimit,
+	})
+	if err != nil {
+		return "", s.handleStravaError(err, "activities")
+	}
+
+	var points []AerobicTrendPoint
+	for _, activity := range search.Matches {
+		if len(points) >= req.Limit {
+			break
+		}
+		if activity.AverageHeartrate <= 0 && !activity.HasHeartrate {
+			continue
+		}
+		if heartRateCap > 0 && activity.AverageHeartrate > float64(heartRateCap) {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return "", err
+		}
+
+		streams, err := s.stravaService.GetActivityStreams(ctx, msgCtx.User, activity.ID, aerobicTrendStreamTypes, "medium")
+		if err != nil {
+			return "", s.handleStravaError(err, fmt.Sprintf("activity %d streams", activity.ID))
+		}
+		analysis := CalculateAerobicAnalysis(streams)
+		if analysis == nil {
+			continue
+		}
+
+		date, _ := activityStartLocal(activity)
+		points = append(points, AerobicTrendPoint{
+			ActivityID: activity.ID,
+			Name:       activity.Name,
+			Date:       date,
+			SportType:  activity.SportType,
+			Analysis:   analysis,
+		})
+	}
+
+	trend := BuildAerobicTrend(points)
+	trend.HeartRateCap = heartRateCap
+	return formatAerobicTrend(trend, req), nil
+}
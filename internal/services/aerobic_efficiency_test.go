@@ -0,0 +1,159 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"bodda/internal/config"
+	"bodda/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// driftingStreams builds a steady effort where heart rate rises linearly by drift bpm over the session
+func driftingStreams(points int, speed float64, watts int, hr int, drift int) *StravaStreams {
+	streams := &StravaStreams{}
+	for i := 0; i < points; i++ {
+		streams.Time = append(streams.Time, i)
+		streams.VelocitySmooth = append(streams.VelocitySmooth, speed)
+		streams.Heartrate = append(streams.Heartrate, hr+drift*i/points)
+		if watts > 0 {
+			streams.Watts = append(streams.Watts, watts)
+		}
+	}
+	return streams
+}
+
+func TestCalculateAerobicDecoupling(t *testing.T) {
+	steady := CalculateAerobicDecoupling(driftingStreams(3600, 3.0, 0, 140, 0), 3600)
+	require.NotNil(t, steady)
+	assert.Equal(t, AerobicBasisPace, steady.Basis)
+	assert.Equal(t, 0.0, steady.DecouplingPercent)
+
+	drifting := CalculateAerobicDecoupling(driftingStreams(3600, 3.0, 0, 140, 14), 3600)
+	require.NotNil(t, drifting)
+	assert.InDelta(t, 4.8, drifting.DecouplingPercent, 0.2, "HR 143.5 vs 150.5 at equal pace")
+
+	power := CalculateAerobicDecoupling(driftingStreams(3600, 8.0, 200, 130, 10), 3600)
+	require.NotNil(t, power)
+	assert.Equal(t, AerobicBasisPower, power.Basis)
+	assert.Greater(t, power.DecouplingPercent, 0.0)
+
+	assert.Nil(t, CalculateAerobicDecoupling(driftingStreams(10, 3.0, 0, 140, 0), 10), "too short")
+	assert.Nil(t, CalculateAerobicDecoupling(&StravaStreams{Heartrate: make([]int, 100)}, 100), "no output stream")
+}
+
+func TestCalculateAerobicDecoupling_SkipsStopsAndDropouts(t *testing.T) {
+	streams := driftingStreams(1200, 3.0, 0, 150, 0)
+	for i := 700; i < 800; i++ {
+		streams.VelocitySmooth[i] = 0 // waiting at a crossing
+		streams.Heartrate[i] = 110
+	}
+	for i := 900; i < 950; i++ {
+		streams.Heartrate[i] = 0 // strap dropout
+	}
+
+	decoupling := CalculateAerobicDecoupling(streams, len(streams.Heartrate))
+	require.NotNil(t, decoupling)
+	assert.Equal(t, 0.0, decoupling.DecouplingPercent)
+}
+
+func TestCalculateEfficiencyFactor(t *testing.T) {
+	run := CalculateEfficiencyFactor(driftingStreams(600, 3.0, 0, 150, 0))
+	require.NotNil(t, run)
+	assert.Equal(t, AerobicBasisPace, run.Basis)
+	assert.Equal(t, 180.0, run.Output, "3 m/s is 180 m/min")
+	assert.Equal(t, 1.2, run.Value)
+
+	ride := CalculateEfficiencyFactor(driftingStreams(600, 8.0, 200, 125, 0))
+	require.NotNil(t, ride)
+	assert.Equal(t, AerobicBasisPower, ride.Basis)
+	assert.Equal(t, 1.6, ride.Value)
+
+	assert.Nil(t, CalculateEfficiencyFactor(&StravaStreams{VelocitySmooth: []float64{3}}))
+}
+
+func TestDerivedFeaturesIncludeAerobicAnalysis(t *testing.T) {
+	features, err := NewDerivedFeaturesProcessor().ExtractFeatures(driftingStreams(1800, 3.0, 0, 140, 10), nil)
+	require.NoError(t, err)
+	require.NotNil(t, features.Aerobic)
+	require.NotNil(t, features.Aerobic.Decoupling)
+
+	output := NewOutputFormatter().FormatDerivedFeatures(features)
+	assert.Contains(t, output, "## 🫀 **Aerobic Efficiency**")
+	assert.Contains(t, output, "**Pa:HR Decoupling:**")
+	assert.Contains(t, output, "5:33/km at 144 bpm average")
+}
+
+func TestBuildAerobicTrend(t *testing.T) {
+	start := time.Date(2025, 1, 6, 7, 0, 0, 0, time.UTC)
+	point := func(day int, ef, decoupling float64) AerobicTrendPoint {
+		return AerobicTrendPoint{
+			ActivityID: int64(day),
+			Date:       start.AddDate(0, 0, day),
+			Analysis: &AerobicAnalysis{
+				EfficiencyFactor: &EfficiencyFactor{Basis: AerobicBasisPace, Value: ef},
+				Decoupling:       &AerobicDecoupling{Basis: AerobicBasisPace, DecouplingPercent: decoupling},
+			},
+		}
+	}
+
+	// Newest first, as activities come from Strava
+	trend := BuildAerobicTrend([]AerobicTrendPoint{point(56, 1.30, 3), point(28, 1.25, 5), point(0, 1.20, 7)})
+	require.Len(t, trend.Points, 3)
+	assert.Equal(t, int64(0), trend.Points[0].ActivityID, "ordered oldest first")
+
+	require.NotNil(t, trend.EFChangePer4Weeks)
+	assert.InDelta(t, 4.2, *trend.EFChangePer4Weeks, 0.05)
+	assert.Equal(t, 5.0, *trend.MeanDecoupling)
+	assert.Equal(t, -4.0, *trend.DecouplingChange)
+
+	short := BuildAerobicTrend([]AerobicTrendPoint{point(0, 1.2, 4)})
+	assert.Nil(t, short.EFChangePer4Weeks, "a trend needs at least three sessions")
+
+	output := formatAerobicTrend(trend, AerobicTrendRequest{SportTypes: []string{"Run"}, Weeks: 8, MinDurationMinutes: 40})
+	assert.Contains(t, output, "+4.2% per 4 weeks (improving)")
+	assert.Contains(t, output, "Mean decoupling: 5.0% (above the 5% aerobic threshold")
+}
+
+func TestGetAerobicTrendTool(t *testing.T) {
+	now := time.Now()
+	strava := &aerobicTrendStravaService{}
+	strava.activities = []*StravaActivity{
+		{ID: 1, Name: "Easy", SportType: "Run", MovingTime: 3600, AverageHeartrate: 140, HasHeartrate: true, StartDate: now.AddDate(0, 0, -1).Format(time.RFC3339), StartDateLocal: now.AddDate(0, 0, -1).Format(time.RFC3339)},
+		{ID: 2, Name: "Tempo", SportType: "Run", MovingTime: 3600, AverageHeartrate: 170, HasHeartrate: true, StartDate: now.AddDate(0, 0, -3).Format(time.RFC3339), StartDateLocal: now.AddDate(0, 0, -3).Format(time.RFC3339)},
+		{ID: 3, Name: "Race", SportType: "Run", MovingTime: 3600, AverageHeartrate: 140, HasHeartrate: true, WorkoutType: workoutTypeRunRace, StartDate: now.AddDate(0, 0, -5).Format(time.RFC3339), StartDateLocal: now.AddDate(0, 0, -5).Format(time.RFC3339)},
+	}
+
+	aiService := NewAIService(&config.Config{OpenAIAPIKey: "test-key"}, strava, &mockLogbookServiceForToolExecutor{}, &MockSessionRepositoryForToolExecutor{}, NewToolRegistry())
+	executor := NewToolExecutor(aiService, NewToolRegistry())
+
+	msgCtx := &MessageContext{
+		UserID: "test-user",
+		User:   &models.User{ID: "test-user", AccessToken: "test-token"},
+	}
+
+	result, err := executor.ExecuteTool(context.Background(), "get-aerobic-trend", map[string]interface{}{
+		"sport_types":        []interface{}{"Run"},
+		"max_avg_heart_rate": float64(150),
+	}, msgCtx)
+	require.NoError(t, err)
+	require.True(t, result.Success, result.Error)
+	assert.Contains(t, result.Data, "[Easy](https://www.strava.com/activities/1)")
+	assert.NotContains(t, result.Data, "[Tempo]", "sessions above the heart rate cap are excluded")
+	assert.NotContains(t, result.Data, "[Race]", "races are excluded")
+	assert.Equal(t, []int64{1}, strava.streamRequests)
+}
+
+// aerobicTrendStravaService returns steady run streams for every activity
+type aerobicTrendStravaService struct {
+	pagedStravaService
+	streamRequests []int64
+}
+
+func (m *aerobicTrendStravaService) GetActivityStreams(ctx context.Context, user *models.User, activityID int64, streamTypes []string, resolution string) (*StravaStreams, error) {
+	m.streamRequests = append(m.streamRequests, activityID)
+	return driftingStreams(3600, 3.0, 0, 140, 5), nil
+}
@@ -34,6 +34,24 @@ type MessageContext struct {
 	AthleteLogbook      *models.AthleteLogbook
 	User                *models.User
 	LastResponseID      string // OpenAI Response ID for multi-turn conversations
+
+	// Rolling summary of older turns that were compacted out of ConversationHistory
+	ConversationSummary    string
+	SummarizedMessageCount int // Leading session messages covered by ConversationSummary
+	historyOffset          int // Leading messages already dropped from ConversationHistory
+
+	// Proactive warning added to the system prompt when training load crosses injury risk thresholds
+	InjuryRiskWarning string
+
+	// Coach is set when a coach is chatting about one of their athletes. User is then the athlete
+	// whose data the tools read, while UserID stays the coach who owns and pays for the session.
+	Coach *models.User
+	// CoachLink holds the permissions the athlete granted Coach; set together with Coach
+	CoachLink *models.CoachingLink
+
+	// Filled in during processing with the model and tool calls that produced the reply, so
+	// feedback on the reply can be evaluated against them
+	Generation models.MessageGeneration
 }
 
 // ToolResult represents the result of a tool execution
@@ -46,12 +64,13 @@ type ToolResult struct {
 
 // IterativeProcessor manages multiple rounds of data analysis and tool execution
 type IterativeProcessor struct {
-	MaxRounds        int                                     // Maximum tool call rounds (default: 5)
-	CurrentRound     int                                     // Current analysis round
-	ProgressCallback func(string)                            // Stream progress updates
-	ToolResults      [][]ToolResult                          // Results from each round
-	Context          *MessageContext                         // Persistent context
-	Messages         []responses.ResponseInputItemUnionParam // Accumulated conversation context
+	MaxRounds         int                                     // Maximum tool call rounds (default: 5)
+	CurrentRound      int                                     // Current analysis round
+	ProgressCallback  func(string)                            // Stream progress updates
+	ToolResults       [][]ToolResult                          // Results from each round
+	Context           *MessageContext                         // Persistent context
+	Messages          []responses.ResponseInputItemUnionParam // Accumulated conversation context
+	CompactionRetried bool                                    // Whether a forced compaction retry was already attempted
 }
 
 // NewIterativeProcessor creates a new iterative processor with default settings
@@ -90,6 +109,9 @@ var (
 	ErrContextTooLong      = errors.New("Conversation context is too long")
 )
 
+// coachingModel answers chat messages; it is recorded with each reply for feedback evaluation
+const coachingModel = responses.ChatModelGPT5
+
 // AIService handles OpenAI integration and function calling
 type AIService interface {
 	ProcessMessage(ctx context.Context, msgCtx *MessageContext) (<-chan string, error)
@@ -116,14 +138,46 @@ type aiService struct {
 	unifiedProcessor     *UnifiedStreamProcessor
 	contextManager       ContextManager
 	toolRegistry         ToolRegistry
+	compactor            ConversationCompactor
+	tokenCounter         TokenCounter
+	usageRecorder        UsageRecorder
+	wellnessService      WellnessService
+	teamService          TeamService
+	injuryRisk           InjuryRiskService
+	toolLimiter          *userConcurrencyLimiter
+}
+
+// AIServiceOption configures optional AI service dependencies
+type AIServiceOption func(*aiService)
+
+// WithUsageRecorder meters the token usage of every model call made for a user
+func WithUsageRecorder(recorder UsageRecorder) AIServiceOption {
+	return func(s *aiService) {
+		s.usageRecorder = recorder
+	}
+}
+
+// WithWellnessService gives the get-readiness tool access to the athlete's daily wellness metrics
+func WithWellnessService(wellness WellnessService) AIServiceOption {
+	return func(s *aiService) {
+		s.wellnessService = wellness
+	}
+}
+
+// WithTeamService gives the get-team-overview tool access to the dashboards of teams the user coaches
+func WithTeamService(teams TeamService) AIServiceOption {
+	return func(s *aiService) {
+		s.teamService = teams
+	}
 }
 
 // NewAIService creates a new AI service instance
-func NewAIService(cfg *config.Config, stravaService StravaService, logbookService LogbookService, sessionRepository SessionRepository, toolRegistry ToolRegistry) AIService {
+func NewAIService(cfg *config.Config, stravaService StravaService, logbookService LogbookService, sessionRepository SessionRepository, toolRegistry ToolRegistry, opts ...AIServiceOption) AIService {
 	// Initialize OpenAI client
 	client := openai.NewClient(option.WithAPIKey(cfg.OpenAIAPIKey))
 
 	// Create stream processing components
+	tokenCounter := NewTokenCounter(cfg)
 	streamProcessor := NewStreamProcessor(cfg)
 	summaryProcessor := NewSummaryProcessor(&client)
 	processingDispatcher := NewProcessingModeDispatcher(streamProcessor, summaryProcessor)
@@ -139,6 +193,7 @@ func NewAIService(cfg *config.Config, stravaService StravaService, logbookServic
 			MaxPageSize:       cfg.StreamProcessing.MaxPageSize,
 			RedactionEnabled:  cfg.StreamProcessing.RedactionEnabled,
 			StravaResolutions: []string{"low", "medium", "high"},
+			TokenCounter:      tokenCounter,
 		},
 		stravaService,
 		derivedProcessor,
@@ -151,7 +206,7 @@ func NewAIService(cfg *config.Config, stravaService StravaService, logbookServic
 
 	slog.Info("AI Service initialized with Responses API", "implementation", "responses_api")
 
-	return &aiService{
+	service := &aiService{
 		client:               client,
 		stravaService:        stravaService,
 		logbookService:       logbookService,
@@ -164,16 +219,38 @@ func NewAIService(cfg *config.Config, stravaService StravaService, logbookServic
 		unifiedProcessor:     unifiedProcessor,
 		contextManager:       contextManager,
 		toolRegistry:         toolRegistry,
+		tokenCounter:         tokenCounter,
+		injuryRisk:           NewInjuryRiskService(stravaService),
+		toolLimiter:          newUserConcurrencyLimiter(int(cfg.ToolMonitoring.MaxConcurrentPerUser)),
 	}
+
+	// Create conversation compactor; summaries are persisted when the session repository supports it
+	summaryStore, _ := sessionRepository.(SessionSummaryStore)
+	summarizer, _ := summaryProcessor.(ConversationSummarizer)
+	service.compactor = NewConversationCompactor(cfg.ConversationCompaction, summarizer, summaryStore, service.estimateCurrentContextTokens)
+
+	for _, opt := range opts {
+		opt(service)
+	}
+
+	return service
 }
 
 // ProcessMessage processes a user message and returns a streaming response channel
 func (s *aiService) ProcessMessage(ctx context.Context, msgCtx *MessageContext) (<-chan string, error) {
+	// Attribute token usage of all model calls in this request to the user
+	ctx = s.withUsage(ctx, msgCtx)
+
+	// Compact long conversations before validating context length
+	s.compactConversation(ctx, msgCtx, false)
+
 	// Validate input
 	if err := s.validateMessageContext(msgCtx); err != nil {
 		return nil, err
 	}
 
+	s.attachInjuryRiskWarning(ctx, msgCtx)
+
 	responseChan := make(chan string, 100)
 
 	// Create iterative processor with progress callback
@@ -216,11 +293,19 @@ func (s *aiService) ProcessMessage(ctx context.Context, msgCtx *MessageContext)
 
 // ProcessMessageSync processes a message synchronously and returns the complete response
 func (s *aiService) ProcessMessageSync(ctx context.Context, msgCtx *MessageContext) (string, error) {
+	// Attribute token usage of all model calls in this request to the user
+	ctx = s.withUsage(ctx, msgCtx)
+
+	// Compact long conversations before validating context length
+	s.compactConversation(ctx, msgCtx, false)
+
 	// Validate input
 	if err := s.validateMessageContext(msgCtx); err != nil {
 		return "", err
 	}
 
+	s.attachInjuryRiskWarning(ctx, msgCtx)
+
 	// Use the same iterative processor logic as streaming, but collect all output
 	var responseBuilder strings.Builder
 	responseChan := make(chan string, 100)
@@ -334,13 +419,15 @@ func (s *aiService) processMessageWithResponsesAPI(ctx context.Context, processo
 		processor.Messages = s.buildConversationContextForResponsesAPI(processor.Context)
 	}
 	tools := s.getAvailableTools()
+	processor.Context.Generation.Model = string(coachingModel)
+	processor.Context.Generation.PromptVersion = systemPromptVersion
 
 	for {
 		// Build input items for this iteration
 		var inputItems []responses.ResponseInputItemUnionParam
 		
 		// For first iteration or when no response ID is available, include conversation context
-		if processor.Context.LastResponseID == "" {
+		if processor.Context.LastResponseID == "" || len(processor.Messages) > 0 {
 			inputItems = processor.Messages
 		} else {
 			// For subsequent iterations with response ID, only include new user message and tool results
@@ -365,8 +452,9 @@ func (s *aiService) processMessageWithResponsesAPI(ctx context.Context, processo
 
 		// Create responses API request with system prompt as Instructions
 		systemPrompt := s.buildEnhancedSystemPrompt(processor.Context)
+		recordPromptContext(processor.Context)
 		params := responses.ResponseNewParams{
-			Model: responses.ChatModelGPT5,
+			Model: coachingModel,
 			Input: responses.ResponseNewParamsInputUnion{
 				OfInputItemList: inputItems,
 			},
@@ -397,9 +485,20 @@ func (s *aiService) processMessageWithResponsesAPI(ctx context.Context, processo
 
 		// Process streaming response with event-based processing
 		var responseID string
-		err := s.processResponsesAPIStreamWithID(stream, responseChan, &responseContent, &hasContent, &toolCalls, &responseID)
+		err := s.processResponsesAPIStreamWithID(ctx, stream, responseChan, &responseContent, &hasContent, &toolCalls, &responseID)
 		if err != nil {
-			return s.handleResponsesAPIError(err)
+			aiErr := s.handleResponsesAPIError(err)
+
+			// The conversation outgrew the model context: compact once and retry from scratch
+			if errors.Is(aiErr, ErrContextTooLong) && !processor.CompactionRetried && processor.CurrentRound == 0 && !hasContent {
+				processor.CompactionRetried = true
+				if s.compactConversation(ctx, processor.Context, true) {
+					processor.Messages = s.buildConversationContextForResponsesAPI(processor.Context)
+					continue
+				}
+			}
+
+			return aiErr
 		}
 
 		// Store response ID for multi-turn conversations
@@ -435,6 +534,7 @@ func (s *aiService) processMessageWithResponsesAPI(ctx context.Context, processo
 				if finalResponse != "" {
 					responseChan <- finalResponse
 				}
+				finishGeneration(processor, models.GenerationStopMaxRounds)
 				break
 			}
 
@@ -442,9 +542,14 @@ func (s *aiService) processMessageWithResponsesAPI(ctx context.Context, processo
 			progressMsg := s.getCoachingProgressMessage(processor, toolCalls)
 			responseChan <- fmt.Sprintf("\n\n*%s*\n\n", progressMsg)
 
-			// Execute tools with enhanced error handling
-			toolResults, err := s.executeToolsWithRecovery(ctx, processor.Context, toolCalls)
+			// Execute tools with enhanced error handling; queueing notices are streamed to the user
+			toolCtx := withToolProgress(ctx, func(message string) {
+				responseChan <- fmt.Sprintf("\n\n*%s*\n\n", message)
+			})
+			recordToolInvocations(processor.Context, processor.CurrentRound+1, toolCalls)
+			toolResults, err := s.executeToolsWithRecovery(toolCtx, processor.Context, toolCalls)
 			if err != nil {
+				finishGeneration(processor, models.GenerationStopToolError)
 				return s.handleToolExecutionError(err, processor, responseChan)
 			}
 
@@ -464,17 +569,34 @@ func (s *aiService) processMessageWithResponsesAPI(ctx context.Context, processo
 			"total_tool_calls", processor.GetTotalToolCalls(),
 			"final_message_count", len(processor.Messages),
 			"processing_mode", "complete")
+		finishGeneration(processor, models.GenerationStopComplete)
 		break
 	}
 
 	return nil
 }
 
+// recordPromptContext notes which optional sections the system prompt carries for the reply's generation details
+func recordPromptContext(msgCtx *MessageContext) {
+	msgCtx.Generation.SummaryInContext = msgCtx.ConversationSummary != ""
+	msgCtx.Generation.CoachContext = msgCtx.Coach != nil
+	msgCtx.Generation.InjuryRiskWarning = msgCtx.InjuryRiskWarning != ""
+}
+
+// finishGeneration records how many tool rounds ran and why analysis stopped
+func finishGeneration(processor *IterativeProcessor, stopReason string) {
+	processor.Context.Generation.Rounds = processor.CurrentRound
+	processor.Context.Generation.StopReason = stopReason
+}
+
 // buildConversationContextForResponsesAPI creates conversation context directly in Responses API format
 // Following OpenAI Responses API multi-turn pattern: only include new user message when previous response ID is available
 func (s *aiService) buildConversationContextForResponsesAPI(msgCtx *MessageContext) []responses.ResponseInputItemUnionParam {
 	var inputItems []responses.ResponseInputItemUnionParam
 
+	// The history is the session's active branch, which the stored response chain may not follow
+	replay := followActivePath(msgCtx)
+
 	// With Responses API, we only need to include the current user message
 	// Previous conversation context is handled via LastResponseID parameter
 	// System prompt is passed separately as Instructions parameter
@@ -484,9 +606,10 @@ func (s *aiService) buildConversationContextForResponsesAPI(msgCtx *MessageConte
 		slog.Info("No previous response ID available, including recent conversation history for context",
 			"conversation_length", len(msgCtx.ConversationHistory))
 
-		// Include only the last few messages to maintain context for first interaction
+		// Include only the last few messages to maintain context for first interaction.
+		// Compacted conversations keep every remaining turn since older ones live in the summary.
 		recentMessages := msgCtx.ConversationHistory
-		if len(recentMessages) > 4 { // Limit to last 4 messages for efficiency
+		if len(recentMessages) > 4 && msgCtx.ConversationSummary == "" { // Limit to last 4 messages for efficiency
 			recentMessages = recentMessages[len(recentMessages)-4:]
 		}
 
@@ -504,7 +627,20 @@ func (s *aiService) buildConversationContextForResponsesAPI(msgCtx *MessageConte
 	} else if msgCtx.LastResponseID != "" {
 		slog.Info("Using previous response ID for conversation context, skipping message history",
 			"previous_response_id", msgCtx.LastResponseID,
-			"conversation_length", len(msgCtx.ConversationHistory))
+			"conversation_length", len(msgCtx.ConversationHistory),
+			"replayed_messages", len(replay))
+
+		for _, msg := range replay {
+			role := responses.EasyInputMessageRoleUser
+			if msg.Role == "assistant" {
+				role = responses.EasyInputMessageRoleAssistant
+			}
+
+			inputItems = append(inputItems, responses.ResponseInputItemParamOfMessage(
+				msg.Content,
+				role,
+			))
+		}
 	}
 
 	// Add current message
@@ -516,8 +652,50 @@ func (s *aiService) buildConversationContextForResponsesAPI(msgCtx *MessageConte
 	return inputItems
 }
 
+// recordToolInvocations adds the tool calls of an analysis round to the reply's generation details
+func recordToolInvocations(msgCtx *MessageContext, round int, toolCalls []responses.ResponseFunctionToolCall) {
+	for _, toolCall := range toolCalls {
+		msgCtx.Generation.ToolInvocations = append(msgCtx.Generation.ToolInvocations, models.ToolInvocation{
+			Name:      toolCall.Name,
+			Arguments: toolCall.Arguments,
+			Round:     round,
+		})
+	}
+}
+
+// followActivePath keeps the response chain on the conversation's active branch. After a message is
+// edited or a reply regenerated, the session's last response belongs to another branch, so the chain
+// continues from the newest response in the history instead, or starts over when there is none.
+// It returns the history messages that come after that response, which the chain does not hold.
+func followActivePath(msgCtx *MessageContext) []*models.Message {
+	if msgCtx.LastResponseID == "" {
+		return nil
+	}
+
+	for i := len(msgCtx.ConversationHistory) - 1; i >= 0; i-- {
+		responseID := msgCtx.ConversationHistory[i].ResponseID
+		if responseID == nil || *responseID == "" {
+			continue
+		}
+		if *responseID != msgCtx.LastResponseID {
+			slog.Info("Previous response belongs to another branch, continuing from the active branch",
+				"session_id", msgCtx.SessionID,
+				"stale_response_id", msgCtx.LastResponseID,
+				"previous_response_id", *responseID)
+			msgCtx.LastResponseID = *responseID
+		}
+		return msgCtx.ConversationHistory[i+1:]
+	}
+
+	slog.Info("No response on the active branch, starting a new response chain",
+		"session_id", msgCtx.SessionID,
+		"stale_response_id", msgCtx.LastResponseID)
+	msgCtx.LastResponseID = ""
+	return nil
+}
+
 // processResponsesAPIStreamWithID processes the streaming response from Responses API using event-based processing and captures response ID
-func (s *aiService) processResponsesAPIStreamWithID(stream *ssestream.Stream[responses.ResponseStreamEventUnion], responseChan chan<- string, responseContent *strings.Builder, hasContent *bool, toolCalls *[]responses.ResponseFunctionToolCall, responseID *string) error {
+func (s *aiService) processResponsesAPIStreamWithID(ctx context.Context, stream *ssestream.Stream[responses.ResponseStreamEventUnion], responseChan chan<- string, responseContent *strings.Builder, hasContent *bool, toolCalls *[]responses.ResponseFunctionToolCall, responseID *string) error {
 	defer stream.Close()
 
 	// Initialize tool call state manager for proper accumulation across multiple events
@@ -575,6 +753,9 @@ func (s *aiService) processResponsesAPIStreamWithID(stream *ssestream.Stream[res
 				"completed_call_ids", s.getCompletedCallIDs(toolCallState),
 				"pending_call_ids", s.getPendingCallIDs(toolCallState))
 
+			// Meter token usage reported for this response
+			recordResponseUsage(ctx, completedEvent.Response)
+
 			// Capture the response ID for multi-turn conversations
 			if completedEvent.Response.ID != "" && responseID != nil {
 				*responseID = completedEvent.Response.ID
@@ -642,7 +823,7 @@ func (s *aiService) processResponsesAPIStreamWithID(stream *ssestream.Stream[res
 // processResponsesAPIStream processes the streaming response from Responses API using event-based processing (backward compatibility)
 func (s *aiService) processResponsesAPIStream(stream *ssestream.Stream[responses.ResponseStreamEventUnion], responseChan chan<- string, responseContent *strings.Builder, hasContent *bool, toolCalls *[]responses.ResponseFunctionToolCall) error {
 	var responseID string
-	return s.processResponsesAPIStreamWithID(stream, responseChan, responseContent, hasContent, toolCalls, &responseID)
+	return s.processResponsesAPIStreamWithID(context.Background(), stream, responseChan, responseContent, hasContent, toolCalls, &responseID)
 }
 
 // extractFunctionNameFromArguments attempts to extract function name from tool call arguments
@@ -1071,11 +1252,20 @@ func (s *aiService) validateToolCall(toolCall *responses.ResponseFunctionToolCal
 
 	// Validate function name is one of our known tools
 	knownTools := map[string]bool{
-		"get-athlete-profile":    true,
-		"get-recent-activities":  true,
-		"get-activity-details":   true,
-		"get-activity-streams":   true,
-		"update-athlete-logbook": true,
+		"get-athlete-profile":         true,
+		"get-recent-activities":       true,
+		"get-activity-details":        true,
+		"get-activity-streams":        true,
+		"update-athlete-logbook":      true,
+		"render-activity-chart":       true,
+		"compare-activities":          true,
+		"search-activities":           true,
+		"get-training-summary":        true,
+		"get-aerobic-trend":           true,
+		"analyze-activity-conditions": true,
+		"get-readiness":               true,
+		"get-injury-risk":             true,
+		"get-team-overview":           true,
 	}
 
 	if !knownTools[toolCall.Name] {
@@ -1323,8 +1513,6 @@ func (s *aiService) getContextualProgressMessageForResponsesAPI(processor *Itera
 
 // executeToolsFromResponsesAPI executes the tool calls from Responses API and returns the results
 func (s *aiService) executeToolsFromResponsesAPI(ctx context.Context, msgCtx *MessageContext, toolCalls []responses.ResponseFunctionToolCall) ([]ToolResult, error) {
-	var results []ToolResult
-
 	// Enhanced logging with all call_id values for traceability
 	allCallIDs := make([]string, len(toolCalls))
 	for i, toolCall := range toolCalls {
@@ -1336,170 +1524,309 @@ func (s *aiService) executeToolsFromResponsesAPI(ctx context.Context, msgCtx *Me
 		"all_call_ids", allCallIDs,
 		"implementation", "responses_api")
 
-	for i, toolCall := range toolCalls {
-		slog.Info("Executing individual tool call with call_id context",
-			"index", i,
-			"call_id", toolCall.CallID,
-			"tool_call_id", toolCall.CallID, // Include both for consistency
-			"function_name", toolCall.Name,
-			"arguments", toolCall.Arguments,
-			"item_id", toolCall.ID)
+	results := s.executeToolCallsConcurrently(ctx, msgCtx, toolCalls)
 
-		result := ToolResult{
-			ToolCallID: toolCall.CallID,
+	// Enhanced completion summary with all call_id values processed
+	completedCallIDs := make([]string, len(results))
+	successfulExecutions := 0
+	for i, result := range results {
+		completedCallIDs[i] = result.ToolCallID
+		if result.Error == "" {
+			successfulExecutions++
 		}
+	}
+
+	slog.Info("All tool executions completed with comprehensive call_id summary",
+		"total_results", len(results),
+		"successful_executions", successfulExecutions,
+		"failed_executions", len(results)-successfulExecutions,
+		"completed_call_ids", completedCallIDs,
+		"implementation", "responses_api")
 
-		slog.Info("Creating ToolResult with extracted call_id",
-			"tool_call_id", toolCall.CallID,
-			"function_name", toolCall.Name,
-			"item_id", toolCall.ID)
+	return results, nil
+}
 
-		switch toolCall.Name {
-		case "get-athlete-profile":
-			content, err := s.executeGetAthleteProfile(ctx, msgCtx)
+// executeResponsesAPIToolCall executes a single tool call from the Responses API.
+// It is safe to call concurrently for calls within the same round.
+func (s *aiService) executeResponsesAPIToolCall(ctx context.Context, msgCtx *MessageContext, index int, toolCall responses.ResponseFunctionToolCall) ToolResult {
+	slog.Info("Executing individual tool call with call_id context",
+		"index", index,
+		"call_id", toolCall.CallID,
+		"tool_call_id", toolCall.CallID, // Include both for consistency
+		"function_name", toolCall.Name,
+		"arguments", toolCall.Arguments,
+		"item_id", toolCall.ID)
+
+	result := ToolResult{
+		ToolCallID: toolCall.CallID,
+	}
+
+	slog.Info("Creating ToolResult with extracted call_id",
+		"tool_call_id", toolCall.CallID,
+		"function_name", toolCall.Name,
+		"item_id", toolCall.ID)
+
+	switch toolCall.Name {
+	case "get-athlete-profile":
+		content, err := s.executeGetAthleteProfile(ctx, msgCtx)
+		if err != nil {
+			result.Error = err.Error()
+			result.Content = fmt.Sprintf("Error getting athlete profile: %v", err)
+		} else {
+			result.Content = content
+		}
+
+	case "get-recent-activities":
+		var args struct {
+			PerPage int `json:"per_page"`
+		}
+		if err := json.Unmarshal([]byte(toolCall.Arguments), &args); err != nil {
+			result.Error = err.Error()
+			result.Content = fmt.Sprintf("Error parsing arguments: %v", err)
+		} else {
+			if args.PerPage == 0 {
+				args.PerPage = 30
+			}
+			content, err := s.executeGetRecentActivities(ctx, msgCtx, args.PerPage)
 			if err != nil {
 				result.Error = err.Error()
-				result.Content = fmt.Sprintf("Error getting athlete profile: %v", err)
+				result.Content = fmt.Sprintf("Error getting recent activities: %v", err)
 			} else {
 				result.Content = content
 			}
+		}
 
-		case "get-recent-activities":
-			var args struct {
-				PerPage int `json:"per_page"`
-			}
-			if err := json.Unmarshal([]byte(toolCall.Arguments), &args); err != nil {
+	case "get-activity-details":
+		var args struct {
+			ActivityID int64 `json:"activity_id"`
+		}
+		if err := json.Unmarshal([]byte(toolCall.Arguments), &args); err != nil {
+			result.Error = err.Error()
+			result.Content = fmt.Sprintf("Error parsing arguments: %v", err)
+		} else {
+			content, err := s.executeGetActivityDetails(ctx, msgCtx, args.ActivityID)
+			if err != nil {
 				result.Error = err.Error()
-				result.Content = fmt.Sprintf("Error parsing arguments: %v", err)
+				result.Content = fmt.Sprintf("Error getting activity details: %v", err)
 			} else {
-				if args.PerPage == 0 {
-					args.PerPage = 30
-				}
-				content, err := s.executeGetRecentActivities(ctx, msgCtx, args.PerPage)
-				if err != nil {
-					result.Error = err.Error()
-					result.Content = fmt.Sprintf("Error getting recent activities: %v", err)
-				} else {
-					result.Content = content
-				}
+				result.Content = content
 			}
+		}
 
-		case "get-activity-details":
-			var args struct {
-				ActivityID int64 `json:"activity_id"`
+	case "get-activity-streams":
+		var args struct {
+			ActivityID     int64    `json:"activity_id"`
+			StreamTypes    []string `json:"stream_types"`
+			Resolution     string   `json:"resolution"`
+			ProcessingMode string   `json:"processing_mode"`
+			PageNumber     int      `json:"page_number"`
+			PageSize       int      `json:"page_size"`
+			SummaryPrompt  string   `json:"summary_prompt"`
+		}
+		if err := json.Unmarshal([]byte(toolCall.Arguments), &args); err != nil {
+			result.Error = err.Error()
+			result.Content = fmt.Sprintf("Error parsing arguments: %v", err)
+		} else {
+			// Set defaults
+			if len(args.StreamTypes) == 0 {
+				args.StreamTypes = []string{"time", "distance", "heartrate", "watts"}
 			}
-			if err := json.Unmarshal([]byte(toolCall.Arguments), &args); err != nil {
-				result.Error = err.Error()
-				result.Content = fmt.Sprintf("Error parsing arguments: %v", err)
+			if args.Resolution == "" {
+				args.Resolution = "medium"
+			}
+			if args.ProcessingMode == "" {
+				args.ProcessingMode = "ai-summary"
+			}
+			if args.PageNumber == 0 {
+				args.PageNumber = 1
+			}
+			if args.PageSize == 0 {
+				args.PageSize = 1000
+			}
+
+			// Validate ai-summary mode requires summary_prompt
+			if args.ProcessingMode == "ai-summary" && args.SummaryPrompt == "" {
+				result.Error = "summary_prompt is required when processing_mode is 'ai-summary'"
+				result.Content = "Error: summary_prompt parameter is required when using ai-summary processing mode"
 			} else {
-				content, err := s.executeGetActivityDetails(ctx, msgCtx, args.ActivityID)
+				processedResult, err := s.executeGetActivityStreamsWithProcessing(ctx, msgCtx, args.ActivityID, args.StreamTypes, args.Resolution, args.ProcessingMode, args.PageNumber, args.PageSize, args.SummaryPrompt)
 				if err != nil {
 					result.Error = err.Error()
-					result.Content = fmt.Sprintf("Error getting activity details: %v", err)
+					result.Content = fmt.Sprintf("Error getting activity streams: %v", err)
 				} else {
-					result.Content = content
+					result.Data = processedResult.Data
+					result.Content = processedResult.Content
 				}
 			}
+		}
 
-		case "get-activity-streams":
-			var args struct {
-				ActivityID     int64    `json:"activity_id"`
-				StreamTypes    []string `json:"stream_types"`
-				Resolution     string   `json:"resolution"`
-				ProcessingMode string   `json:"processing_mode"`
-				PageNumber     int      `json:"page_number"`
-				PageSize       int      `json:"page_size"`
-				SummaryPrompt  string   `json:"summary_prompt"`
+	case "update-athlete-logbook":
+		var args struct {
+			Content string `json:"content"`
+		}
+		if err := json.Unmarshal([]byte(toolCall.Arguments), &args); err != nil {
+			result.Error = err.Error()
+			result.Content = fmt.Sprintf("Error parsing arguments: %v", err)
+		} else {
+			data, err := s.executeUpdateAthleteLogbook(ctx, msgCtx, args.Content)
+			if err != nil {
+				result.Error = err.Error()
+				result.Content = fmt.Sprintf("Error updating athlete logbook: %v", err)
+			} else {
+				result.Data = data
+				result.Content = "Athlete logbook updated successfully"
 			}
-			if err := json.Unmarshal([]byte(toolCall.Arguments), &args); err != nil {
+		}
+
+	case "render-activity-chart":
+		var args ActivityChartRequest
+		if err := json.Unmarshal([]byte(toolCall.Arguments), &args); err != nil {
+			result.Error = err.Error()
+			result.Content = fmt.Sprintf("Error parsing arguments: %v", err)
+		} else {
+			content, err := s.executeRenderActivityChart(ctx, msgCtx, args)
+			if err != nil {
 				result.Error = err.Error()
-				result.Content = fmt.Sprintf("Error parsing arguments: %v", err)
+				result.Content = fmt.Sprintf("Error rendering activity chart: %v", err)
 			} else {
-				// Set defaults
-				if len(args.StreamTypes) == 0 {
-					args.StreamTypes = []string{"time", "distance", "heartrate", "watts"}
-				}
-				if args.Resolution == "" {
-					args.Resolution = "medium"
-				}
-				if args.ProcessingMode == "" {
-					args.ProcessingMode = "ai-summary"
-				}
-				if args.PageNumber == 0 {
-					args.PageNumber = 1
-				}
-				if args.PageSize == 0 {
-					args.PageSize = 1000
-				}
+				result.Content = content
+			}
+		}
 
-				// Validate ai-summary mode requires summary_prompt
-				if args.ProcessingMode == "ai-summary" && args.SummaryPrompt == "" {
-					result.Error = "summary_prompt is required when processing_mode is 'ai-summary'"
-					result.Content = "Error: summary_prompt parameter is required when using ai-summary processing mode"
-				} else {
-					processedResult, err := s.executeGetActivityStreamsWithProcessing(ctx, msgCtx, args.ActivityID, args.StreamTypes, args.Resolution, args.ProcessingMode, args.PageNumber, args.PageSize, args.SummaryPrompt)
-					if err != nil {
-						result.Error = err.Error()
-						result.Content = fmt.Sprintf("Error getting activity streams: %v", err)
-					} else {
-						result.Data = processedResult.Data
-						result.Content = processedResult.Content
-					}
-				}
+	case "compare-activities":
+		var args ActivityComparisonRequest
+		if err := json.Unmarshal([]byte(toolCall.Arguments), &args); err != nil {
+			result.Error = err.Error()
+			result.Content = fmt.Sprintf("Error parsing arguments: %v", err)
+		} else {
+			content, err := s.executeCompareActivities(ctx, msgCtx, args)
+			if err != nil {
+				result.Error = err.Error()
+				result.Content = fmt.Sprintf("Error comparing activities: %v", err)
+			} else {
+				result.Content = content
 			}
+		}
 
-		case "update-athlete-logbook":
-			var args struct {
-				Content string `json:"content"`
+	case "search-activities":
+		var args ActivitySearchRequest
+		if err := json.Unmarshal([]byte(toolCall.Arguments), &args); err != nil {
+			result.Error = err.Error()
+			result.Content = fmt.Sprintf("Error parsing arguments: %v", err)
+		} else {
+			content, err := s.executeSearchActivities(ctx, msgCtx, args)
+			if err != nil {
+				result.Error = err.Error()
+				result.Content = fmt.Sprintf("Error searching activities: %v", err)
+			} else {
+				result.Content = content
 			}
-			if err := json.Unmarshal([]byte(toolCall.Arguments), &args); err != nil {
+		}
+
+	case "get-training-summary":
+		var args TrainingSummaryRequest
+		if err := json.Unmarshal([]byte(toolCall.Arguments), &args); err != nil {
+			result.Error = err.Error()
+			result.Content = fmt.Sprintf("Error parsing arguments: %v", err)
+		} else {
+			content, err := s.executeGetTrainingSummary(ctx, msgCtx, args)
+			if err != nil {
 				result.Error = err.Error()
-				result.Content = fmt.Sprintf("Error parsing arguments: %v", err)
+				result.Content = fmt.Sprintf("Error building training summary: %v", err)
 			} else {
-				data, err := s.executeUpdateAthleteLogbook(ctx, msgCtx, args.Content)
-				if err != nil {
-					result.Error = err.Error()
-					result.Content = fmt.Sprintf("Error updating athlete logbook: %v", err)
-				} else {
-					result.Data = data
-					result.Content = "Athlete logbook updated successfully"
-				}
+				result.Content = content
 			}
+		}
 
-		default:
-			result.Error = "unknown tool"
-			result.Content = fmt.Sprintf("Unknown tool: %s", toolCall.Name)
+	case "get-aerobic-trend":
+		var args AerobicTrendRequest
+		if err := json.Unmarshal([]byte(toolCall.Arguments), &args); err != nil {
+			result.Error = err.Error()
+			result.Content = fmt.Sprintf("Error parsing arguments: %v", err)
+		} else {
+			content, err := s.executeGetAerobicTrend(ctx, msgCtx, args)
+			if err != nil {
+				result.Error = err.Error()
+				result.Content = fmt.Sprintf("Error analyzing aerobic trend: %v", err)
+			} else {
+				result.Content = content
+			}
 		}
 
-		slog.Info("Completed tool execution with call_id traceability",
-			"call_id", result.ToolCallID,
-			"tool_call_id", result.ToolCallID, // Include both for consistency
-			"function_name", toolCall.Name,
-			"has_error", result.Error != "",
-			"content_length", len(result.Content),
-			"execution_index", i)
+	case "analyze-activity-conditions":
+		var args ActivityConditionsRequest
+		if err := json.Unmarshal([]byte(toolCall.Arguments), &args); err != nil {
+			result.Error = err.Error()
+			result.Content = fmt.Sprintf("Error parsing arguments: %v", err)
+		} else {
+			content, err := s.executeAnalyzeActivityConditions(ctx, msgCtx, args)
+			if err != nil {
+				result.Error = err.Error()
+				result.Content = fmt.Sprintf("Error analyzing activity conditions: %v", err)
+			} else {
+				result.Content = content
+			}
+		}
 
-		results = append(results, result)
-	}
+	case "get-readiness":
+		var args ReadinessRequest
+		if err := json.Unmarshal([]byte(toolCall.Arguments), &args); err != nil {
+			result.Error = err.Error()
+			result.Content = fmt.Sprintf("Error parsing arguments: %v", err)
+		} else {
+			content, err := s.executeGetReadiness(ctx, msgCtx, args)
+			if err != nil {
+				result.Error = err.Error()
+				result.Content = fmt.Sprintf("Error assessing readiness: %v", err)
+			} else {
+				result.Content = content
+			}
+		}
 
-	// Enhanced completion summary with all call_id values processed
-	completedCallIDs := make([]string, len(results))
-	successfulExecutions := 0
-	for i, result := range results {
-		completedCallIDs[i] = result.ToolCallID
-		if result.Error == "" {
-			successfulExecutions++
+	case "get-injury-risk":
+		var args InjuryRiskRequest
+		if err := json.Unmarshal([]byte(toolCall.Arguments), &args); err != nil {
+			result.Error = err.Error()
+			result.Content = fmt.Sprintf("Error parsing arguments: %v", err)
+		} else {
+			content, err := s.executeGetInjuryRisk(ctx, msgCtx, args)
+			if err != nil {
+				result.Error = err.Error()
+				result.Content = fmt.Sprintf("Error assessing injury risk: %v", err)
+			} else {
+				result.Content = content
+			}
+		}
+
+	case "get-team-overview":
+		var args TeamOverviewRequest
+		if err := json.Unmarshal([]byte(toolCall.Arguments), &args); err != nil {
+			result.Error = err.Error()
+			result.Content = fmt.Sprintf("Error parsing arguments: %v", err)
+		} else {
+			content, err := s.executeGetTeamOverview(ctx, msgCtx, args)
+			if err != nil {
+				result.Error = err.Error()
+				result.Content = fmt.Sprintf("Error getting team overview: %v", err)
+			} else {
+				result.Content = content
+			}
 		}
+
+	default:
+		result.Error = "unknown tool"
+		result.Content = fmt.Sprintf("Unknown tool: %s", toolCall.Name)
 	}
 
-	slog.Info("All tool executions completed with comprehensive call_id summary",
-		"total_results", len(results),
-		"successful_executions", successfulExecutions,
-		"failed_executions", len(results)-successfulExecutions,
-		"completed_call_ids", completedCallIDs,
-		"implementation", "responses_api")
+	slog.Info("Completed tool execution with call_id traceability",
+		"call_id", result.ToolCallID,
+		"tool_call_id", result.ToolCallID, // Include both for consistency
+		"function_name", toolCall.Name,
+		"has_error", result.Error != "",
+		"content_length", len(result.Content),
+		"execution_index", index)
 
-	return results, nil
+	return result
 }
 
 // accumulateAnalysisContext builds enhanced context with accumulated insights
@@ -1566,8 +1893,12 @@ func (s *aiService) fixToolResultIDs(toolCalls []responses.ResponseFunctionToolC
 	// Create a mapping from function name + arguments to tool call ID
 	// This allows us to match tool results to the correct tool call IDs
 	toolCallMap := make(map[string]string) // key: function_name, value: tool_call_id
+	knownCallIDs := make(map[string]bool)
 
 	for _, toolCall := range toolCalls {
+		if toolCall.CallID != "" {
+			knownCallIDs[toolCall.CallID] = true
+		}
 		if toolCall.Name != "" && toolCall.CallID != "" {
 			// Use function name as the key for matching
 			// In most cases, there's only one call per function per round
@@ -1582,6 +1913,14 @@ func (s *aiService) fixToolResultIDs(toolCalls []responses.ResponseFunctionToolC
 	// Fix the tool result IDs
 	var fixedResults []ToolResult
 	for i, result := range toolResults {
+		// Results that already carry a call ID from this round are correct as-is.
+		// This keeps several calls to the same function (e.g. activity details
+		// fetched in parallel) correlated with their own call IDs.
+		if knownCallIDs[result.ToolCallID] {
+			fixedResults = append(fixedResults, result)
+			continue
+		}
+
 		// Try to determine which tool call this result corresponds to
 		// We need to infer the function name from the tool execution
 		functionName := s.inferFunctionNameFromToolResult(result, i, toolCalls)
@@ -1720,6 +2059,9 @@ func (s *aiService) accumulateAnalysisContextSafely(processor *IterativeProcesso
 	return processor
 }
 
+// systemPromptVersion identifies systemPrompt in recorded generations; bump it whenever the prompt changes
+const systemPromptVersion = "2026-10-18"
+
 var systemPrompt = `You are Bodda, an elite running and/or cycling coach mentoring an athlete with access to their Strava profile and all of their activities. Your responses should look and feel like it is coming from an elite professional coach.
 
 When asked about any particular workout, provide a thorough, data-driven assessment, combining both quantitative insights and textual interpretation. Begin your report with a written summary that highlights key findings and context. Add clear coaching feedback and personalized training recommendations. These should be practical, actionable, and grounded solely in the data provided—no assumptions or fabrications. Do not hide or sugarcoat weakness.
@@ -1743,6 +2085,7 @@ COACHING APPROACH:
 RESPONSE FORMAT:
 - Your response will be rendered as markdown, so use headings, bold, italics, tables etc when appropriate.
 - Mermaid and vega lite is also supported for graphics rendering. Provide simple graphics or diagrams when appropriate.
+- For charts of activity data (pace, heart rate, power, zones, laps, elevation) always use the render-activity-chart tool and include the vega-lite block it returns unchanged. Never hand-write chart data from numbers in tool text.
 
 CRITICAL INSTRUCTION
 - Whenever you provide analysis or information of a specific activity from strava, include a link back to the original activity in strava in markdown format.
@@ -1753,6 +2096,15 @@ Available tools:
 - get-activity-details: Get detailed information about a specific activity
 - get-activity-streams: Get time-series data from an activity (heart rate, power, etc.)
 - update-athlete-logbook: Update the athlete's logbook with new information
+- render-activity-chart: Render an accurate Vega-Lite chart of an activity (metrics over distance, zones, laps or elevation)
+- compare-activities: Compare 2-5 activities aligned on distance or time, with pace, HR, power, cadence, decoupling and lap deltas
+- search-activities: Find activities by date range, sport, distance, duration, name keywords, trainer/commute/race flags or gear
+- get-training-summary: Weekly or monthly training volume by sport with ramp rate, intensity distribution and longest sessions. Use it instead of reading long activity lists to answer volume questions
+- get-aerobic-trend: Efficiency factor and aerobic decoupling (Pa:HR / Pw:HR) across recent easy sessions, to assess aerobic base development
+- analyze-activity-conditions: Heat and altitude penalty for an activity with neutral-conditions pace/power, optionally explaining the difference to a reference activity. Use it before attributing a slow or hard-feeling session to fitness
+- get-readiness: Readiness score from the athlete's logged resting HR, HRV, sleep and subjective ratings against their own baseline, with recent Strava load. Check it before prescribing hard sessions or when the athlete reports fatigue
+- get-injury-risk: Acute:chronic workload ratio, training monotony and strain, and sudden volume jumps over the last 4 weeks, cross-referenced with injuries in the logbook. Use it before increasing training load or when the athlete reports pain
+- get-team-overview: Weekly volume, missed sessions and load warnings for every athlete of a team the user owns or coaches. Use it for team-level questions such as who is overreaching or who has gone quiet
 
 **Your Final Goal**
 Provide professional grade coaching to your athlete to help them improve their performance, achieve their goals. Make them feel good and inspire them to continue when they actually are making progress.`
@@ -1764,10 +2116,28 @@ func (s *aiService) buildEnhancedSystemPrompt(msgCtx *MessageContext) string {
 	// Add athlete logbook context if available
 	if msgCtx.AthleteLogbook != nil && msgCtx.AthleteLogbook.Content != "" {
 		basePrompt += fmt.Sprintf("\n\nCurrent Athlete Logbook:\n%s", msgCtx.AthleteLogbook.Content)
-	} else {
+	} else if msgCtx.Coach == nil {
 		basePrompt += "\n\nNo athlete logbook exists yet. You should create one."
 	}
 
+	// A human coach is reviewing one of their athletes rather than the athlete chatting themselves
+	if msgCtx.Coach != nil {
+		basePrompt += fmt.Sprintf("\n\nCoach Context:\nYou are assisting %s, the human coach of %s. The tools return %s's data. "+
+			"Address the coach as a fellow professional and refer to the athlete in the third person. "+
+			"The athlete logbook is read-only in this conversation; do not call update-athlete-logbook.",
+			displayName(msgCtx.Coach), displayName(msgCtx.User), displayName(msgCtx.User))
+	}
+
+	// Add the rolling summary of earlier turns in this session if the conversation was compacted
+	if msgCtx.ConversationSummary != "" {
+		basePrompt += fmt.Sprintf("\n\nSummary of earlier conversation in this session (older messages were condensed to save context):\n%s", msgCtx.ConversationSummary)
+	}
+
+	// Warn proactively when recent training load crosses injury risk thresholds
+	if msgCtx.InjuryRiskWarning != "" {
+		basePrompt += fmt.Sprintf("\n\nInjury Risk Warning:\n%s", msgCtx.InjuryRiskWarning)
+	}
+
 	return basePrompt
 }
 
@@ -1969,7 +2339,7 @@ func (s *aiService) executeGetAthleteProfile(ctx context.Context, msgCtx *Messag
 		return "", fmt.Errorf("user context is required")
 	}
 
-	profile, err := s.stravaService.GetAthleteProfile(msgCtx.User)
+	profile, err := s.stravaService.GetAthleteProfile(ctx, msgCtx.User)
 	if err != nil {
 		return "", s.handleStravaError(err, "athlete profile")
 	}
@@ -1986,7 +2356,7 @@ func (s *aiService) executeGetRecentActivities(ctx context.Context, msgCtx *Mess
 		PerPage: perPage,
 	}
 
-	activities, err := s.stravaService.GetActivities(msgCtx.User, params)
+	activities, err := s.stravaService.GetActivities(ctx, msgCtx.User, params)
 	if err != nil {
 		return "", s.handleStravaError(err, "recent activities")
 	}
@@ -2000,7 +2370,7 @@ func (s *aiService) executeGetActivityDetails(ctx context.Context, msgCtx *Messa
 	}
 
 	// Use the integrated method to get activity details with zones
-	detailsWithZones, err := s.stravaService.GetActivityDetailWithZones(msgCtx.User, activityID)
+	detailsWithZones, err := s.stravaService.GetActivityDetailWithZones(ctx, msgCtx.User, activityID)
 	if err != nil {
 		return "", s.handleStravaError(err, "activity details")
 	}
@@ -2014,7 +2384,7 @@ func (s *aiService) executeGetActivityStreams(ctx context.Context, msgCtx *Messa
 		return nil, fmt.Errorf("user context is required")
 	}
 
-	streams, err := s.stravaService.GetActivityStreams(msgCtx.User, activityID, streamTypes, resolution)
+	streams, err := s.stravaService.GetActivityStreams(ctx, msgCtx.User, activityID, streamTypes, resolution)
 	if err != nil {
 		return nil, s.handleStravaError(err, "activity streams")
 	}
@@ -2030,7 +2400,7 @@ func (s *aiService) executeGetActivityStreamsWithProcessing(ctx context.Context,
 	// Handle auto mode - check if data needs processing first
 	if processingMode == "auto" {
 		// Get a sample of the data to determine if processing is needed
-		streams, err := s.stravaService.GetActivityStreams(msgCtx.User, activityID, streamTypes, "low")
+		streams, err := s.stravaService.GetActivityStreams(ctx, msgCtx.User, activityID, streamTypes, "low")
 		if err != nil {
 			return nil, s.handleStravaError(err, "activity streams")
 		}
@@ -2038,7 +2408,7 @@ func (s *aiService) executeGetActivityStreamsWithProcessing(ctx context.Context,
 		// Use stream processor to determine if processing is needed
 		if !s.streamProcessor.ShouldProcess(streams) {
 			// Data is small enough, get full resolution and return raw formatted data
-			fullStreams, err := s.stravaService.GetActivityStreams(msgCtx.User, activityID, streamTypes, resolution)
+			fullStreams, err := s.stravaService.GetActivityStreams(ctx, msgCtx.User, activityID, streamTypes, resolution)
 			if err != nil {
 				return nil, s.handleStravaError(err, "activity streams")
 			}
@@ -2075,7 +2445,7 @@ func (s *aiService) executeGetActivityStreamsWithProcessing(ctx context.Context,
 	currentContextTokens := s.estimateCurrentContextTokens(msgCtx)
 
 	// Process the paginated stream request
-	streamPage, err := s.unifiedProcessor.ProcessPaginatedStreamRequest(msgCtx.User, req, currentContextTokens)
+	streamPage, err := s.unifiedProcessor.ProcessPaginatedStreamRequest(ctx, msgCtx.User, req, currentContextTokens)
 	if err != nil {
 		log.Printf("Unified stream processing failed for activity %d with mode %s: %v", activityID, processingMode, err)
 		return nil, fmt.Errorf("failed to process stream data: %w", err)
@@ -2100,6 +2470,11 @@ func (s *aiService) executeUpdateAthleteLogbook(ctx context.Context, msgCtx *Mes
 		return nil, fmt.Errorf("user ID is required")
 	}
 
+	// UserID is the coach in coach sessions; the athlete's logbook is theirs alone to change
+	if msgCtx.Coach != nil {
+		return nil, fmt.Errorf("the athlete logbook is read-only for coaches")
+	}
+
 	if content == "" {
 		return nil, fmt.Errorf("logbook content cannot be empty")
 	}
@@ -2123,25 +2498,65 @@ func (s *aiService) executeUpdateAthleteLogbook(ctx context.Context, msgCtx *Mes
 
 // estimateCurrentContextTokens estimates the current context usage for pagination decisions
 func (s *aiService) estimateCurrentContextTokens(msgCtx *MessageContext) int {
-	// Simple estimation based on conversation history
-	totalChars := 0
+	counter := s.getTokenCounter()
+	totalTokens := 0
 
-	// Count characters in conversation history
+	// Count tokens in conversation history
 	for _, msg := range msgCtx.ConversationHistory {
-		totalChars += len(msg.Content)
+		totalTokens += counter.CountTokens(msg.Content)
 	}
 
 	// Add current message
-	totalChars += len(msgCtx.Message)
+	totalTokens += counter.CountTokens(msgCtx.Message)
+
+	// Add rolling conversation summary
+	totalTokens += counter.CountTokens(msgCtx.ConversationSummary)
+
+	// Add base system prompt
+	totalTokens += counter.CountTokens(systemPrompt)
+
+	log.Printf("Estimated current context tokens: %d (%s)", totalTokens, counter.Name())
+	return totalTokens
+}
+
+// getTokenCounter returns the service token counter, falling back to the configured character ratio
+func (s *aiService) getTokenCounter() TokenCounter {
+	if s.tokenCounter != nil {
+		return s.tokenCounter
+	}
+
+	ratio := 0.0
+	if s.config != nil {
+		ratio = s.config.StreamProcessing.TokenPerCharRatio
+	}
+	return NewHeuristicTokenCounter(ratio)
+}
+
+// withUsage attaches usage attribution for the message's user and session when metering is enabled
+func (s *aiService) withUsage(ctx context.Context, msgCtx *MessageContext) context.Context {
+	if msgCtx == nil {
+		return ctx
+	}
+	return withUsageScope(ctx, s.usageRecorder, msgCtx.UserID, msgCtx.SessionID)
+}
 
-	// Add system prompt (rough estimate)
-	totalChars += 2000
+// compactConversation summarizes older turns when the conversation approaches the context budget.
+// Failures are logged and the request continues with the uncompacted history.
+func (s *aiService) compactConversation(ctx context.Context, msgCtx *MessageContext, force bool) bool {
+	if s.compactor == nil || msgCtx == nil {
+		return false
+	}
 
-	// Convert to tokens using the configured ratio
-	estimatedTokens := int(float64(totalChars) * s.config.StreamProcessing.TokenPerCharRatio)
+	compacted, err := s.compactor.CompactIfNeeded(ctx, msgCtx, force)
+	if err != nil {
+		slog.ErrorContext(ctx, "Conversation compaction failed, continuing with full history",
+			"session_id", msgCtx.SessionID,
+			"forced", force,
+			"error", err)
+		return false
+	}
 
-	log.Printf("Estimated current context tokens: %d (based on %d characters)", estimatedTokens, totalChars)
-	return estimatedTokens
+	return compacted
 }
 
 // validateMessageContext validates the message context before processing
@@ -2239,6 +2654,14 @@ func (s *aiService) handleStravaError(err error, operation string) error {
 
 	switch {
 	case errors.Is(err, ErrRateLimitExceeded):
+		var limitErr *StravaRateLimitError
+		if errors.As(err, &limitErr) && limitErr.RetryAfter > 0 {
+			minutes := int(limitErr.RetryAfter.Round(time.Minute).Minutes())
+			if minutes < 1 {
+				minutes = 1
+			}
+			return fmt.Errorf("strava API rate limit exceeded. Please try again in about %d minute(s)", minutes)
+		}
 		return fmt.Errorf("strava API rate limit exceeded. Please try again in a few minutes")
 	case errors.Is(err, ErrTokenExpired):
 		return fmt.Errorf("your Strava connection has expired. Please reconnect your Strava account")
@@ -2408,6 +2831,11 @@ func (s *aiService) ExecuteGetActivityStreams(ctx context.Context, msgCtx *Messa
 	}
 }
 
+// ExecuteToolCall executes any registered tool call, including tools without a dedicated method
+func (s *aiService) ExecuteToolCall(ctx context.Context, msgCtx *MessageContext, toolCall responses.ResponseFunctionToolCall) ToolResult {
+	return s.executeResponsesAPIToolCall(ctx, msgCtx, 0, toolCall)
+}
+
 // ExecuteUpdateAthleteLogbook executes the update-athlete-logbook tool
 func (s *aiService) ExecuteUpdateAthleteLogbook(ctx context.Context, msgCtx *MessageContext, content string) (string, error) {
 	logbook, err := s.executeUpdateAthleteLogbook(ctx, msgCtx, content)
@@ -0,0 +1,61 @@
+package services
+
+import (
+	"testing"
+
+	"bodda/internal/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func branchHistory() []*models.Message {
+	return []*models.Message{
+		{ID: "m1", Role: "user", Content: "Plan a 10k"},
+		{ID: "m2", Role: "assistant", Content: "Here is a 10k plan", ResponseID: stringPtr("resp_1")},
+		{ID: "m3", Role: "user", Content: "Make it harder"},
+		{ID: "m4", Role: "assistant", Content: "Added intervals", ResponseID: stringPtr("resp_2")},
+	}
+}
+
+func TestFollowActivePath(t *testing.T) {
+	tests := []struct {
+		name           string
+		history        []*models.Message
+		lastResponseID string
+		wantResponseID string
+		wantReplayed   int
+	}{
+		{"on the active branch", branchHistory(), "resp_2", "resp_2", 0},
+		{"from another branch", branchHistory(), "resp_other", "resp_2", 0},
+		{"from another branch with later messages", branchHistory()[:3], "resp_other", "resp_1", 1},
+		{"no response on the branch", branchHistory()[:1], "resp_other", "", 0},
+		{"empty branch", nil, "resp_other", "", 0},
+		{"no response chain", branchHistory(), "", "", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msgCtx := &MessageContext{ConversationHistory: tt.history, LastResponseID: tt.lastResponseID}
+
+			replay := followActivePath(msgCtx)
+
+			assert.Equal(t, tt.wantResponseID, msgCtx.LastResponseID)
+			assert.Len(t, replay, tt.wantReplayed)
+		})
+	}
+}
+
+func TestBuildConversationContext_ReplaysActiveBranch(t *testing.T) {
+	service := &aiService{}
+	msgCtx := &MessageContext{
+		Message:             "Make it shorter",
+		ConversationHistory: branchHistory()[:3],
+		LastResponseID:      "resp_other",
+	}
+
+	items := service.buildConversationContextForResponsesAPI(msgCtx)
+
+	// The user message after resp_1 is replayed before the new message
+	assert.Len(t, items, 2)
+	assert.Equal(t, "resp_1", msgCtx.LastResponseID)
+}
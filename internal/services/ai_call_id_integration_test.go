@@ -555,7 +555,7 @@ func stringPtr(s string) *string {
 
 type mockStravaServiceForIntegration struct{}
 
-func (m *mockStravaServiceForIntegration) GetAthleteProfile(user *models.User) (*StravaAthleteWithZones, error) {
+func (m *mockStravaServiceForIntegration) GetAthleteProfile(ctx context.Context, user *models.User) (*StravaAthleteWithZones, error) {
 	return &StravaAthleteWithZones{
 		StravaAthlete: &StravaAthlete{
 			ID:        12345,
@@ -569,11 +569,11 @@ func (m *mockStravaServiceForIntegration) GetAthleteProfile(user *models.User) (
 	}, nil
 }
 
-func (m *mockStravaServiceForIntegration) GetAthleteZones(user *models.User) (*StravaAthleteZones, error) {
+func (m *mockStravaServiceForIntegration) GetAthleteZones(ctx context.Context, user *models.User) (*StravaAthleteZones, error) {
 	return &StravaAthleteZones{}, nil
 }
 
-func (m *mockStravaServiceForIntegration) GetActivities(user *models.User, params ActivityParams) ([]*StravaActivity, error) {
+func (m *mockStravaServiceForIntegration) GetActivities(ctx context.Context, user *models.User, params ActivityParams) ([]*StravaActivity, error) {
 	return []*StravaActivity{
 		{
 			ID:   123456,
@@ -583,7 +583,7 @@ func (m *mockStravaServiceForIntegration) GetActivities(user *models.User, param
 	}, nil
 }
 
-func (m *mockStravaServiceForIntegration) GetActivityDetail(user *models.User, activityID int64) (*StravaActivityDetail, error) {
+func (m *mockStravaServiceForIntegration) GetActivityDetail(ctx context.Context, user *models.User, activityID int64) (*StravaActivityDetail, error) {
 	return &StravaActivityDetail{
 		StravaActivity: StravaActivity{
 			ID:   activityID,
@@ -593,7 +593,7 @@ func (m *mockStravaServiceForIntegration) GetActivityDetail(user *models.User, a
 	}, nil
 }
 
-func (m *mockStravaServiceForIntegration) GetActivityDetailWithZones(user *models.User, activityID int64) (*StravaActivityDetailWithZones, error) {
+func (m *mockStravaServiceForIntegration) GetActivityDetailWithZones(ctx context.Context, user *models.User, activityID int64) (*StravaActivityDetailWithZones, error) {
 	return &StravaActivityDetailWithZones{
 		StravaActivityDetail: &StravaActivityDetail{
 			StravaActivity: StravaActivity{
@@ -605,7 +605,7 @@ func (m *mockStravaServiceForIntegration) GetActivityDetailWithZones(user *model
 	}, nil
 }
 
-func (m *mockStravaServiceForIntegration) GetActivityStreams(user *models.User, activityID int64, streamTypes []string, resolution string) (*StravaStreams, error) {
+func (m *mockStravaServiceForIntegration) GetActivityStreams(ctx context.Context, user *models.User, activityID int64, streamTypes []string, resolution string) (*StravaStreams, error) {
 	return &StravaStreams{
 		Time:      []int{0, 1, 2, 3, 4},
 		Distance:  []float64{0, 100, 200, 300, 400},
@@ -613,7 +613,7 @@ func (m *mockStravaServiceForIntegration) GetActivityStreams(user *models.User,
 	}, nil
 }
 
-func (m *mockStravaServiceForIntegration) GetActivityZones(user *models.User, activityID int64) (*StravaActivityZones, error) {
+func (m *mockStravaServiceForIntegration) GetActivityZones(ctx context.Context, user *models.User, activityID int64) (*StravaActivityZones, error) {
 	return &StravaActivityZones{}, nil
 }
 
@@ -658,31 +658,31 @@ func (m *mockLogbookServiceForIntegration) UpsertLogbook(ctx context.Context, us
 // Failing mock service for error recovery testing
 type failingMockStravaService struct{}
 
-func (m *failingMockStravaService) GetAthleteProfile(user *models.User) (*StravaAthleteWithZones, error) {
+func (m *failingMockStravaService) GetAthleteProfile(ctx context.Context, user *models.User) (*StravaAthleteWithZones, error) {
 	return nil, fmt.Errorf("simulated Strava API error: athlete profile not found")
 }
 
-func (m *failingMockStravaService) GetAthleteZones(user *models.User) (*StravaAthleteZones, error) {
+func (m *failingMockStravaService) GetAthleteZones(ctx context.Context, user *models.User) (*StravaAthleteZones, error) {
 	return nil, fmt.Errorf("simulated Strava API error: zones not available")
 }
 
-func (m *failingMockStravaService) GetActivities(user *models.User, params ActivityParams) ([]*StravaActivity, error) {
+func (m *failingMockStravaService) GetActivities(ctx context.Context, user *models.User, params ActivityParams) ([]*StravaActivity, error) {
 	return nil, fmt.Errorf("simulated Strava API error: activities not accessible")
 }
 
-func (m *failingMockStravaService) GetActivityDetail(user *models.User, activityID int64) (*StravaActivityDetail, error) {
+func (m *failingMockStravaService) GetActivityDetail(ctx context.Context, user *models.User, activityID int64) (*StravaActivityDetail, error) {
 	return nil, fmt.Errorf("simulated Strava API error: activity %d not found", activityID)
 }
 
-func (m *failingMockStravaService) GetActivityDetailWithZones(user *models.User, activityID int64) (*StravaActivityDetailWithZones, error) {
+func (m *failingMockStravaService) GetActivityDetailWithZones(ctx context.Context, user *models.User, activityID int64) (*StravaActivityDetailWithZones, error) {
 	return nil, fmt.Errorf("simulated Strava API error: activity %d zones not available", activityID)
 }
 
-func (m *failingMockStravaService) GetActivityStreams(user *models.User, activityID int64, streamTypes []string, resolution string) (*StravaStreams, error) {
+func (m *failingMockStravaService) GetActivityStreams(ctx context.Context, user *models.User, activityID int64, streamTypes []string, resolution string) (*StravaStreams, error) {
 	return nil, fmt.Errorf("simulated Strava API error: streams for activity %d not available", activityID)
 }
 
-func (m *failingMockStravaService) GetActivityZones(user *models.User, activityID int64) (*StravaActivityZones, error) {
+func (m *failingMockStravaService) GetActivityZones(ctx context.Context, user *models.User, activityID int64) (*StravaActivityZones, error) {
 	return nil, fmt.Errorf("simulated Strava API error: zones for activity %d not available", activityID)
 }
 
@@ -369,7 +369,7 @@ func TestConvertAllRegistryTools(t *testing.T) {
 
 	// Get all tools from registry
 	tools := registry.GetAvailableTools()
-	require.Len(t, tools, 5, "Expected 5 tools in registry")
+	require.Len(t, tools, 14, "Expected 14 tools in registry")
 
 	// Convert each tool and verify
 	for _, tool := range tools {
@@ -397,7 +397,7 @@ func TestConversionConsistencyWithCurrentImplementation(t *testing.T) {
 	}
 
 	// Verify we have the expected number of tools
-	assert.Len(t, convertedTools, 5, "Should have 5 tools")
+	assert.Len(t, convertedTools, 14, "Should have 14 tools")
 
 	// Verify that the conversion produces valid results for all tools
 	for i, convertedTool := range convertedTools {
@@ -417,6 +417,15 @@ func TestConversionConsistencyWithCurrentImplementation(t *testing.T) {
 		"get-activity-details",
 		"get-activity-streams",
 		"update-athlete-logbook",
+		"render-activity-chart",
+		"compare-activities",
+		"search-activities",
+		"get-training-summary",
+		"get-aerobic-trend",
+		"analyze-activity-conditions",
+		"get-readiness",
+		"get-injury-risk",
+		"get-team-overview",
 	}
 
 	for _, toolName := range expectedToolNames {
@@ -0,0 +1,224 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"bodda/internal/models"
+
+	"github.com/google/uuid"
+)
+
+var (
+	// ErrInvalidAPIToken is returned for unknown, expired or revoked API tokens
+	ErrInvalidAPIToken = errors.New("invalid API token")
+	// ErrAPITokenNotFound is returned when revoking a token the user does not have
+	ErrAPITokenNotFound = errors.New("API token not found")
+	// ErrInvalidAPITokenRequest is returned when a token cannot be created as requested
+	ErrInvalidAPITokenRequest = errors.New("invalid API token request")
+	// ErrAPITokenLimitReached is returned when the user already has the maximum number of active tokens
+	ErrAPITokenLimitReached = errors.New("API token limit reached")
+)
+
+const (
+	// APITokenPrefix starts every personal API token, so they are easy to tell apart from session
+	// JWTs and to find with secret scanners
+	APITokenPrefix = "bdp_"
+
+	apiTokenDisplayLength     = len(APITokenPrefix) + 8
+	apiTokenNameMaxLength     = 100
+	defaultAPITokenLifetime   = 90 * 24 * time.Hour
+	maxAPITokenLifetime       = 365 * 24 * time.Hour
+	maxActiveAPITokensPerUser = 20
+	// apiTokenLastUsedInterval bounds how often last-used tracking writes to the database
+	apiTokenLastUsedInterval = time.Minute
+)
+
+// APITokenStore persists personal API tokens. Only token hashes are stored.
+type APITokenStore interface {
+	Create(ctx context.Context, token *models.APIToken) error
+	GetByHash(ctx context.Context, tokenHash string) (*models.APIToken, error)
+	ListByUser(ctx context.Context, userID string) ([]*models.APIToken, error)
+	CountActive(ctx context.Context, userID string, at time.Time) (int, error)
+	Revoke(ctx context.Context, userID, tokenID string, at time.Time) (bool, error)
+	TouchLastUsed(ctx context.Context, tokenID string, at time.Time, ipAddress string, staleBefore time.Time) error
+}
+
+// CreateAPITokenRequest describes a token a user asks for
+type CreateAPITokenRequest struct {
+	Name          string   `json:"name"`
+	Scopes        []string `json:"scopes"`
+	ExpiresInDays int      `json:"expires_in_days"` // Defaults to 90, at most 365
+}
+
+// CreatedAPIToken is a new token together with its secret, which is only ever shown once
+type CreatedAPIToken struct {
+	Token  string           `json:"token"`
+	Record *models.APIToken `json:"api_token"`
+}
+
+// APITokenService manages personal API tokens and authenticates requests made with them
+type APITokenService interface {
+	CreateToken(ctx context.Context, userID string, req CreateAPITokenRequest) (*CreatedAPIToken, error)
+	ListTokens(ctx context.Context, userID string) ([]*models.APIToken, error)
+	RevokeToken(ctx context.Context, userID, tokenID string) error
+	Authenticate(ctx context.Context, token, ipAddress string) (*models.User, *models.APIToken, error)
+}
+
+type apiTokenService struct {
+	store    APITokenStore
+	userRepo UserRepository
+	now      func() time.Time
+}
+
+func NewAPITokenService(store APITokenStore, userRepo UserRepository) APITokenService {
+	return &apiTokenService{
+		store:    store,
+		userRepo: userRepo,
+		now:      time.Now,
+	}
+}
+
+// IsAPIToken reports whether a bearer credential is a personal API token rather than a session JWT
+func IsAPIToken(token string) bool {
+	return strings.HasPrefix(token, APITokenPrefix)
+}
+
+func (s *apiTokenService) CreateToken(ctx context.Context, userID string, req CreateAPITokenRequest) (*CreatedAPIToken, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return nil, fmt.Errorf("%w: name is required", ErrInvalidAPITokenRequest)
+	}
+	if len(name) > apiTokenNameMaxLength {
+		return nil, fmt.Errorf("%w: name must be at most %d characters", ErrInvalidAPITokenRequest, apiTokenNameMaxLength)
+	}
+
+	scopes, err := normalizeAPITokenScopes(req.Scopes)
+	if err != nil {
+		return nil, err
+	}
+
+	lifetime := defaultAPITokenLifetime
+	if req.ExpiresInDays != 0 {
+		lifetime = time.Duration(req.ExpiresInDays) * 24 * time.Hour
+		if req.ExpiresInDays < 0 || lifetime > maxAPITokenLifetime {
+			return nil, fmt.Errorf("%w: expires_in_days must be between 1 and %d", ErrInvalidAPITokenRequest, int(maxAPITokenLifetime.Hours()/24))
+		}
+	}
+
+	now := s.now().UTC()
+	active, err := s.store.CountActive(ctx, userID, now)
+	if err != nil {
+		return nil, err
+	}
+	if active >= maxActiveAPITokensPerUser {
+		return nil, fmt.Errorf("%w: revoke an unused token first (limit %d)", ErrAPITokenLimitReached, maxActiveAPITokensPerUser)
+	}
+
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return nil, fmt.Errorf("failed to generate API token: %w", err)
+	}
+	secret := APITokenPrefix + base64.RawURLEncoding.EncodeToString(raw)
+
+	record := &models.APIToken{
+		UserID:      userID,
+		Name:        name,
+		TokenPrefix: secret[:apiTokenDisplayLength],
+		TokenHash:   hashOpaqueToken(secret),
+		Scopes:      scopes,
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(lifetime),
+	}
+	if err := s.store.Create(ctx, record); err != nil {
+		return nil, err
+	}
+
+	return &CreatedAPIToken{Token: secret, Record: record}, nil
+}
+
+func (s *apiTokenService) ListTokens(ctx context.Context, userID string) ([]*models.APIToken, error) {
+	tokens, err := s.store.ListByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if tokens == nil {
+		tokens = []*models.APIToken{}
+	}
+	return tokens, nil
+}
+
+func (s *apiTokenService) RevokeToken(ctx context.Context, userID, tokenID string) error {
+	if _, err := uuid.Parse(tokenID); err != nil {
+		return ErrAPITokenNotFound
+	}
+
+	revoked, err := s.store.Revoke(ctx, userID, tokenID, s.now().UTC())
+	if err != nil {
+		return err
+	}
+	if !revoked {
+		return ErrAPITokenNotFound
+	}
+	return nil
+}
+
+// Authenticate resolves a bearer API token to its owner. Revoked and expired tokens are rejected.
+func (s *apiTokenService) Authenticate(ctx context.Context, token, ipAddress string) (*models.User, *models.APIToken, error) {
+	if !IsAPIToken(token) {
+		return nil, nil, ErrInvalidAPIToken
+	}
+
+	record, err := s.store.GetByHash(ctx, hashOpaqueToken(token))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to look up API token: %w", err)
+	}
+
+	now := s.now().UTC()
+	if record == nil || record.RevokedAt != nil || !record.ExpiresAt.After(now) {
+		return nil, nil, ErrInvalidAPIToken
+	}
+
+	user, err := s.userRepo.GetByID(ctx, record.UserID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("user not found: %w", err)
+	}
+
+	// Last-used tracking is informational; a failed write must not fail the request
+	if err := s.store.TouchLastUsed(ctx, record.ID, now, ipAddress, now.Add(-apiTokenLastUsedInterval)); err != nil {
+		log.Printf("Failed to record use of API token %s: %v", record.ID, err)
+	}
+
+	return user, record, nil
+}
+
+// normalizeAPITokenScopes validates requested scopes and returns them deduplicated in display order
+func normalizeAPITokenScopes(requested []string) ([]string, error) {
+	if len(requested) == 0 {
+		return nil, fmt.Errorf("%w: at least one scope is required", ErrInvalidAPITokenRequest)
+	}
+
+	wanted := make(map[string]bool, len(requested))
+	for _, scope := range requested {
+		wanted[scope] = true
+	}
+
+	scopes := make([]string, 0, len(wanted))
+	for _, scope := range models.APITokenScopes {
+		if wanted[scope] {
+			scopes = append(scopes, scope)
+			delete(wanted, scope)
+		}
+	}
+	for scope := range wanted {
+		return nil, fmt.Errorf("%w: unknown scope %q", ErrInvalidAPITokenRequest, scope)
+	}
+
+	return scopes, nil
+}
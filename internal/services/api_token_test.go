@@ -0,0 +1,185 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"bodda/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryAPITokenStore keeps API tokens in memory with the same semantics as the repository
+type memoryAPITokenStore struct {
+	tokens  []*models.APIToken
+	touches int
+}
+
+func (m *memoryAPITokenStore) Create(ctx context.Context, token *models.APIToken) error {
+	token.ID = fmt.Sprintf("00000000-0000-0000-0000-%012d", len(m.tokens)+1)
+	m.tokens = append(m.tokens, token)
+	return nil
+}
+
+func (m *memoryAPITokenStore) GetByHash(ctx context.Context, tokenHash string) (*models.APIToken, error) {
+	for _, token := range m.tokens {
+		if token.TokenHash == tokenHash {
+			copied := *token
+			return &copied, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *memoryAPITokenStore) ListByUser(ctx context.Context, userID string) ([]*models.APIToken, error) {
+	var tokens []*models.APIToken
+	for i := len(m.tokens) - 1; i >= 0; i-- {
+		if m.tokens[i].UserID == userID && m.tokens[i].RevokedAt == nil {
+			tokens = append(tokens, m.tokens[i])
+		}
+	}
+	return tokens, nil
+}
+
+func (m *memoryAPITokenStore) CountActive(ctx context.Context, userID string, at time.Time) (int, error) {
+	count := 0
+	for _, token := range m.tokens {
+		if token.UserID == userID && token.RevokedAt == nil && token.ExpiresAt.After(at) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (m *memoryAPITokenStore) Revoke(ctx context.Context, userID, tokenID string, at time.Time) (bool, error) {
+	for _, token := range m.tokens {
+		if token.ID == tokenID && token.UserID == userID && token.RevokedAt == nil {
+			token.RevokedAt = &at
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (m *memoryAPITokenStore) TouchLastUsed(ctx context.Context, tokenID string, at time.Time, ipAddress string, staleBefore time.Time) error {
+	for _, token := range m.tokens {
+		if token.ID == tokenID && (token.LastUsedAt == nil || token.LastUsedAt.Before(staleBefore)) {
+			token.LastUsedAt = &at
+			token.LastUsedIP = ipAddress
+			m.touches++
+		}
+	}
+	return nil
+}
+
+func newAPITokenTestService() (*apiTokenService, *memoryAPITokenStore, *time.Time) {
+	store := &memoryAPITokenStore{}
+	userRepo := &MockUserRepository{}
+	userRepo.On("GetByID", mock.Anything, "test-user-id").Return(&models.User{ID: "test-user-id", StravaID: 12345}, nil)
+
+	service := NewAPITokenService(store, userRepo).(*apiTokenService)
+	clock := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	service.now = func() time.Time { return clock }
+	return service, store, &clock
+}
+
+func TestAPITokenService_CreateAndAuthenticate(t *testing.T) {
+	service, store, clock := newAPITokenTestService()
+	ctx := context.Background()
+
+	created, err := service.CreateToken(ctx, "test-user-id", CreateAPITokenRequest{
+		Name:   "  training notebook ",
+		Scopes: []string{models.ScopeAnalyticsRead, models.ScopeSessionsRead, models.ScopeAnalyticsRead},
+	})
+	require.NoError(t, err)
+	assert.True(t, IsAPIToken(created.Token))
+	assert.True(t, strings.HasPrefix(created.Token, created.Record.TokenPrefix))
+	assert.Equal(t, "training notebook", created.Record.Name)
+	assert.Equal(t, []string{models.ScopeSessionsRead, models.ScopeAnalyticsRead}, created.Record.Scopes)
+	assert.Equal(t, clock.Add(90*24*time.Hour), created.Record.ExpiresAt)
+	assert.NotContains(t, store.tokens[0].TokenHash, created.Token, "only the hash is stored")
+
+	user, token, err := service.Authenticate(ctx, created.Token, "203.0.113.7")
+	require.NoError(t, err)
+	assert.Equal(t, "test-user-id", user.ID)
+	assert.True(t, token.HasScope(models.ScopeSessionsRead))
+	assert.False(t, token.HasScope(models.ScopeMessagesWrite))
+	require.NotNil(t, store.tokens[0].LastUsedAt)
+	assert.Equal(t, "203.0.113.7", store.tokens[0].LastUsedIP)
+
+	// Last-used tracking is written at most once a minute
+	*clock = clock.Add(10 * time.Second)
+	_, _, err = service.Authenticate(ctx, created.Token, "203.0.113.7")
+	require.NoError(t, err)
+	assert.Equal(t, 1, store.touches)
+
+	_, _, err = service.Authenticate(ctx, created.Token+"x", "")
+	assert.True(t, errors.Is(err, ErrInvalidAPIToken))
+	_, _, err = service.Authenticate(ctx, "eyJhbGciOiJIUzI1NiJ9.session.jwt", "")
+	assert.True(t, errors.Is(err, ErrInvalidAPIToken))
+
+	*clock = clock.Add(91 * 24 * time.Hour)
+	_, _, err = service.Authenticate(ctx, created.Token, "")
+	assert.True(t, errors.Is(err, ErrInvalidAPIToken), "expired tokens are rejected")
+}
+
+func TestAPITokenService_CreateTokenValidation(t *testing.T) {
+	service, _, _ := newAPITokenTestService()
+	ctx := context.Background()
+
+	tests := []struct {
+		name string
+		req  CreateAPITokenRequest
+	}{
+		{"missing name", CreateAPITokenRequest{Scopes: []string{models.ScopeSessionsRead}}},
+		{"long name", CreateAPITokenRequest{Name: strings.Repeat("a", 101), Scopes: []string{models.ScopeSessionsRead}}},
+		{"no scopes", CreateAPITokenRequest{Name: "script"}},
+		{"unknown scope", CreateAPITokenRequest{Name: "script", Scopes: []string{"admin"}}},
+		{"negative expiry", CreateAPITokenRequest{Name: "script", Scopes: []string{models.ScopeSessionsRead}, ExpiresInDays: -1}},
+		{"expiry too long", CreateAPITokenRequest{Name: "script", Scopes: []string{models.ScopeSessionsRead}, ExpiresInDays: 366}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := service.CreateToken(ctx, "test-user-id", tt.req)
+			assert.True(t, errors.Is(err, ErrInvalidAPITokenRequest), err)
+		})
+	}
+
+	for i := 0; i < maxActiveAPITokensPerUser; i++ {
+		_, err := service.CreateToken(ctx, "test-user-id", CreateAPITokenRequest{Name: "script", Scopes: []string{models.ScopeSessionsRead}, ExpiresInDays: 7})
+		require.NoError(t, err)
+	}
+	_, err := service.CreateToken(ctx, "test-user-id", CreateAPITokenRequest{Name: "one too many", Scopes: []string{models.ScopeSessionsRead}})
+	assert.True(t, errors.Is(err, ErrAPITokenLimitReached))
+}
+
+func TestAPITokenService_ListAndRevoke(t *testing.T) {
+	service, _, _ := newAPITokenTestService()
+	ctx := context.Background()
+
+	tokens, err := service.ListTokens(ctx, "test-user-id")
+	require.NoError(t, err)
+	assert.NotNil(t, tokens)
+	assert.Empty(t, tokens)
+
+	created, err := service.CreateToken(ctx, "test-user-id", CreateAPITokenRequest{Name: "cron", Scopes: []string{models.ScopeLogbookManage}})
+	require.NoError(t, err)
+
+	assert.True(t, errors.Is(service.RevokeToken(ctx, "other-user", created.Record.ID), ErrAPITokenNotFound))
+	assert.True(t, errors.Is(service.RevokeToken(ctx, "test-user-id", "not-a-uuid"), ErrAPITokenNotFound))
+	require.NoError(t, service.RevokeToken(ctx, "test-user-id", created.Record.ID))
+
+	_, _, err = service.Authenticate(ctx, created.Token, "")
+	assert.True(t, errors.Is(err, ErrInvalidAPIToken), "revoked tokens stop working immediately")
+
+	tokens, err = service.ListTokens(ctx, "test-user-id")
+	require.NoError(t, err)
+	assert.Empty(t, tokens)
+}
@@ -26,14 +26,27 @@ type AuthService interface {
 	HandleStravaOAuth(code string) (*models.User, error)
 	ValidateToken(token string) (*models.User, error)
 	RefreshStravaToken(user *models.User) error
-	GenerateJWT(userID string) (string, error)
-	GetStravaOAuthURL(state string) string
+	GetStravaOAuthURL(state string, forceApproval bool) string
+
+	// Login sessions: short-lived access JWTs renewed with rotating refresh tokens
+	StartSession(ctx context.Context, user *models.User, client ClientInfo) (*SessionTokens, error)
+	RefreshSession(ctx context.Context, refreshToken string, client ClientInfo) (*SessionTokens, error)
+	EndSession(ctx context.Context, refreshToken string) error
+	ListSessions(ctx context.Context, userID, currentRefreshToken string) ([]*LoginSession, error)
+	RevokeSession(ctx context.Context, userID, sessionID string) error
+	RevokeAllSessions(ctx context.Context, userID string) (int, error)
 }
 
 type authService struct {
-	config      *config.Config
-	userRepo    UserRepository
-	oauthConfig *oauth2.Config
+	config        *config.Config
+	userRepo      UserRepository
+	refreshTokens RefreshTokenStore
+	oauthConfig   *oauth2.Config
+
+	accessTokenTTL    time.Duration
+	refreshTokenTTL   time.Duration
+	refreshReuseGrace time.Duration
+	now               func() time.Time
 }
 
 type StravaTokenResponse struct {
@@ -47,7 +60,7 @@ type StravaTokenResponse struct {
 	} `json:"athlete"`
 }
 
-func NewAuthService(cfg *config.Config, userRepo UserRepository) AuthService {
+func NewAuthService(cfg *config.Config, userRepo UserRepository, refreshTokens RefreshTokenStore) AuthService {
 	oauthConfig := &oauth2.Config{
 		ClientID:     cfg.StravaClientID,
 		ClientSecret: cfg.StravaClientSecret,
@@ -59,14 +72,35 @@ func NewAuthService(cfg *config.Config, userRepo UserRepository) AuthService {
 		},
 	}
 
-	return &authService{
-		config:      cfg,
-		userRepo:    userRepo,
-		oauthConfig: oauthConfig,
+	service := &authService{
+		config:            cfg,
+		userRepo:          userRepo,
+		refreshTokens:     refreshTokens,
+		oauthConfig:       oauthConfig,
+		accessTokenTTL:    defaultAccessTokenTTL,
+		refreshTokenTTL:   defaultRefreshTokenTTL,
+		refreshReuseGrace: defaultRefreshReuseGrace,
+		now:               time.Now,
 	}
+	if cfg.Auth.AccessTokenTTL > 0 {
+		service.accessTokenTTL = time.Duration(cfg.Auth.AccessTokenTTL) * time.Second
+	}
+	if cfg.Auth.RefreshTokenTTL > 0 {
+		service.refreshTokenTTL = time.Duration(cfg.Auth.RefreshTokenTTL) * time.Second
+	}
+	if cfg.Auth.RefreshReuseGrace > 0 {
+		service.refreshReuseGrace = time.Duration(cfg.Auth.RefreshReuseGrace) * time.Second
+	}
+
+	return service
 }
 
-func (s *authService) GetStravaOAuthURL(state string) string {
+// GetStravaOAuthURL builds the authorize URL. forceApproval shows the consent screen again even if the
+// athlete already authorized the app, so scopes they unticked before can be granted.
+func (s *authService) GetStravaOAuthURL(state string, forceApproval bool) string {
+	if forceApproval {
+		return s.oauthConfig.AuthCodeURL(state, oauth2.AccessTypeOffline, oauth2.SetAuthURLParam("approval_prompt", "force"))
+	}
 	return s.oauthConfig.AuthCodeURL(state, oauth2.AccessTypeOffline)
 }
 
@@ -134,24 +168,15 @@ func (s *authService) HandleStravaOAuth(code string) (*models.User, error) {
 	return user, nil
 }
 
-func (s *authService) GenerateJWT(userID string) (string, error) {
-	claims := jwt.MapClaims{
-		"user_id": userID,
-		"exp":     time.Now().Add(24 * time.Hour).Unix(),
-		"iat":     time.Now().Unix(),
-	}
-
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(s.config.JWTSecret))
-}
-
+// ValidateToken checks an access JWT and returns its user. Tokens of revoked login sessions are
+// rejected even before they expire.
 func (s *authService) ValidateToken(tokenString string) (*models.User, error) {
 	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
 		}
 		return []byte(s.config.JWTSecret), nil
-	})
+	}, jwt.WithTimeFunc(s.now))
 
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse token: %w", err)
@@ -166,12 +191,30 @@ func (s *authService) ValidateToken(tokenString string) (*models.User, error) {
 		return nil, fmt.Errorf("invalid token claims")
 	}
 
+	if tokenType, _ := claims["typ"].(string); tokenType != accessTokenType {
+		return nil, fmt.Errorf("invalid token type")
+	}
+
 	userID, ok := claims["user_id"].(string)
 	if !ok {
 		return nil, fmt.Errorf("invalid user_id in token")
 	}
 
-	user, err := s.userRepo.GetByID(context.Background(), userID)
+	sessionID, ok := claims["sid"].(string)
+	if !ok || sessionID == "" {
+		return nil, fmt.Errorf("invalid sid in token")
+	}
+
+	ctx := context.Background()
+	active, err := s.refreshTokens.IsFamilyActive(ctx, sessionID, s.now().UTC())
+	if err != nil {
+		return nil, fmt.Errorf("failed to check login session: %w", err)
+	}
+	if !active {
+		return nil, fmt.Errorf("login session has been revoked")
+	}
+
+	user, err := s.userRepo.GetByID(ctx, userID)
 	if err != nil {
 		return nil, fmt.Errorf("user not found: %w", err)
 	}
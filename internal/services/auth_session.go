@@ -0,0 +1,352 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"bodda/internal/models"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+var (
+	// ErrInvalidRefreshToken is returned for unknown, expired or revoked refresh tokens
+	ErrInvalidRefreshToken = errors.New("invalid refresh token")
+	// ErrRefreshTokenReused is returned when a refresh token that was already exchanged is presented
+	// again. The login session is revoked because the token was most likely stolen.
+	ErrRefreshTokenReused = errors.New("refresh token reuse detected")
+	// ErrLoginSessionNotFound is returned when revoking a login session the user does not have
+	ErrLoginSessionNotFound = errors.New("login session not found")
+)
+
+const (
+	defaultAccessTokenTTL    = 15 * time.Minute
+	defaultRefreshTokenTTL   = 30 * 24 * time.Hour
+	defaultRefreshReuseGrace = 30 * time.Second
+
+	// accessTokenType distinguishes access JWTs from other tokens signed with the same secret
+	accessTokenType = "access"
+
+	revokedReasonLogout      = "logout"
+	revokedReasonUser        = "revoked_by_user"
+	revokedReasonSignOutAll  = "sign_out_everywhere"
+	revokedReasonTokenReused = "reuse_detected"
+)
+
+// RefreshTokenStore persists refresh tokens. Only token hashes are stored.
+type RefreshTokenStore interface {
+	Create(ctx context.Context, token *models.RefreshToken) error
+	GetByHash(ctx context.Context, tokenHash string) (*models.RefreshToken, error)
+	Rotate(ctx context.Context, currentID string, next *models.RefreshToken) (bool, error)
+	IsFamilyActive(ctx context.Context, familyID string, at time.Time) (bool, error)
+	ListActive(ctx context.Context, userID string, at time.Time) ([]*models.RefreshToken, error)
+	RevokeFamily(ctx context.Context, userID, familyID, reason string, at time.Time) (bool, error)
+	RevokeAllForUser(ctx context.Context, userID, reason string, at time.Time) (int, error)
+	DeleteExpired(ctx context.Context, at time.Time) (int64, error)
+}
+
+// ClientInfo describes the device a login session is used from
+type ClientInfo struct {
+	UserAgent string
+	IPAddress string
+}
+
+// SessionTokens are the credentials issued when a login session starts or is refreshed
+type SessionTokens struct {
+	User             *models.User
+	SessionID        string
+	AccessToken      string
+	AccessExpiresAt  time.Time
+	RefreshToken     string // Empty when a concurrent request already rotated the presented token
+	RefreshExpiresAt time.Time
+}
+
+// LoginSession is an active login as shown in the user's list of signed-in devices
+type LoginSession struct {
+	ID         string    `json:"id"`
+	DeviceName string    `json:"device_name"`
+	IPAddress  string    `json:"ip_address"`
+	StartedAt  time.Time `json:"started_at"`
+	LastUsedAt time.Time `json:"last_used_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	Current    bool      `json:"current"`
+}
+
+// StartSession starts a login session for a user who just signed in
+func (s *authService) StartSession(ctx context.Context, user *models.User, client ClientInfo) (*SessionTokens, error) {
+	now := s.now().UTC()
+
+	// Expired tokens are no longer needed for reuse detection
+	if _, err := s.refreshTokens.DeleteExpired(ctx, now); err != nil {
+		log.Printf("Failed to delete expired refresh tokens: %v", err)
+	}
+
+	refreshToken, record, err := s.newRefreshToken(user.ID, uuid.New().String(), now, now, client)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.refreshTokens.Create(ctx, record); err != nil {
+		return nil, fmt.Errorf("failed to start login session: %w", err)
+	}
+
+	return s.issueTokens(user, record, refreshToken, now)
+}
+
+// RefreshSession exchanges a refresh token for a new access token and a new refresh token. A token
+// can be exchanged once; presenting it again after the reuse grace period revokes the session.
+func (s *authService) RefreshSession(ctx context.Context, refreshToken string, client ClientInfo) (*SessionTokens, error) {
+	now := s.now().UTC()
+
+	current, err := s.refreshTokens.GetByHash(ctx, hashOpaqueToken(refreshToken))
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up refresh token: %w", err)
+	}
+	if current == nil || current.RevokedAt != nil || !now.Before(current.ExpiresAt) {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	user, err := s.userRepo.GetByID(ctx, current.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("user not found: %w", err)
+	}
+
+	if current.RotatedAt == nil {
+		next, record, err := s.newRefreshToken(current.UserID, current.FamilyID, current.SessionStartedAt, now, client)
+		if err != nil {
+			return nil, err
+		}
+		rotated, err := s.refreshTokens.Rotate(ctx, current.ID, record)
+		if err != nil {
+			return nil, fmt.Errorf("failed to refresh login session: %w", err)
+		}
+		if rotated {
+			return s.issueTokens(user, record, next, now)
+		}
+
+		// Another request rotated the token between our read and the update
+		rotatedAt := now
+		current.RotatedAt = &rotatedAt
+	}
+
+	return s.refreshRotated(ctx, user, current, now)
+}
+
+// refreshRotated handles a refresh token that was already exchanged. Browsers send several requests
+// at once, so a token rotated moments ago is accepted for an access token only; anything older is
+// treated as a replayed, stolen token and the whole login session is revoked.
+func (s *authService) refreshRotated(ctx context.Context, user *models.User, current *models.RefreshToken, now time.Time) (*SessionTokens, error) {
+	if now.Sub(*current.RotatedAt) <= s.refreshReuseGrace {
+		active, err := s.refreshTokens.IsFamilyActive(ctx, current.FamilyID, now)
+		if err != nil {
+			return nil, fmt.Errorf("failed to refresh login session: %w", err)
+		}
+		if !active {
+			return nil, ErrInvalidRefreshToken
+		}
+		return s.issueTokens(user, current, "", now)
+	}
+
+	if _, err := s.refreshTokens.RevokeFamily(ctx, current.UserID, current.FamilyID, revokedReasonTokenReused, now); err != nil {
+		return nil, fmt.Errorf("failed to revoke login session after token reuse: %w", err)
+	}
+	log.Printf("Refresh token reuse detected for user %s, revoked login session %s", current.UserID, current.FamilyID)
+
+	return nil, ErrRefreshTokenReused
+}
+
+// EndSession revokes the login session a refresh token belongs to. Unknown tokens are ignored so
+// signing out always succeeds.
+func (s *authService) EndSession(ctx context.Context, refreshToken string) error {
+	if refreshToken == "" {
+		return nil
+	}
+
+	current, err := s.refreshTokens.GetByHash(ctx, hashOpaqueToken(refreshToken))
+	if err != nil {
+		return fmt.Errorf("failed to look up refresh token: %w", err)
+	}
+	if current == nil {
+		return nil
+	}
+
+	if _, err := s.refreshTokens.RevokeFamily(ctx, current.UserID, current.FamilyID, revokedReasonLogout, s.now().UTC()); err != nil {
+		return fmt.Errorf("failed to end login session: %w", err)
+	}
+
+	return nil
+}
+
+// ListSessions returns the user's active login sessions, marking the one currentRefreshToken
+// belongs to
+func (s *authService) ListSessions(ctx context.Context, userID, currentRefreshToken string) ([]*LoginSession, error) {
+	tokens, err := s.refreshTokens.ListActive(ctx, userID, s.now().UTC())
+	if err != nil {
+		return nil, err
+	}
+
+	currentHash := ""
+	if currentRefreshToken != "" {
+		currentHash = hashOpaqueToken(currentRefreshToken)
+	}
+
+	sessions := make([]*LoginSession, 0, len(tokens))
+	for _, token := range tokens {
+		sessions = append(sessions, &LoginSession{
+			ID:         token.FamilyID,
+			DeviceName: token.DeviceName,
+			IPAddress:  token.IPAddress,
+			StartedAt:  token.SessionStartedAt,
+			LastUsedAt: token.LastUsedAt,
+			ExpiresAt:  token.ExpiresAt,
+			Current:    token.TokenHash == currentHash,
+		})
+	}
+
+	return sessions, nil
+}
+
+// RevokeSession signs one of the user's devices out
+func (s *authService) RevokeSession(ctx context.Context, userID, sessionID string) error {
+	if _, err := uuid.Parse(sessionID); err != nil {
+		return ErrLoginSessionNotFound
+	}
+
+	revoked, err := s.refreshTokens.RevokeFamily(ctx, userID, sessionID, revokedReasonUser, s.now().UTC())
+	if err != nil {
+		return err
+	}
+	if !revoked {
+		return ErrLoginSessionNotFound
+	}
+
+	return nil
+}
+
+// RevokeAllSessions signs the user out on every device and returns how many sessions were ended
+func (s *authService) RevokeAllSessions(ctx context.Context, userID string) (int, error) {
+	return s.refreshTokens.RevokeAllForUser(ctx, userID, revokedReasonSignOutAll, s.now().UTC())
+}
+
+// newRefreshToken generates a refresh token and the record that stores its hash
+func (s *authService) newRefreshToken(userID, familyID string, startedAt, now time.Time, client ClientInfo) (string, *models.RefreshToken, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", nil, fmt.Errorf("failed to generate refresh token: %w", err)
+	}
+	token := base64.RawURLEncoding.EncodeToString(raw)
+
+	return token, &models.RefreshToken{
+		UserID:           userID,
+		FamilyID:         familyID,
+		TokenHash:        hashOpaqueToken(token),
+		DeviceName:       DeviceNameFromUserAgent(client.UserAgent),
+		IPAddress:        client.IPAddress,
+		UserAgent:        limitLength(client.UserAgent, 512),
+		SessionStartedAt: startedAt,
+		CreatedAt:        now,
+		LastUsedAt:       now,
+		ExpiresAt:        now.Add(s.refreshTokenTTL),
+	}, nil
+}
+
+// issueTokens signs an access token for the login session of record
+func (s *authService) issueTokens(user *models.User, record *models.RefreshToken, refreshToken string, now time.Time) (*SessionTokens, error) {
+	expiresAt := now.Add(s.accessTokenTTL)
+	claims := jwt.MapClaims{
+		"user_id": record.UserID,
+		"sid":     record.FamilyID,
+		"typ":     accessTokenType,
+		"exp":     expiresAt.Unix(),
+		"iat":     now.Unix(),
+	}
+
+	accessToken, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(s.config.JWTSecret))
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign access token: %w", err)
+	}
+
+	return &SessionTokens{
+		User:             user,
+		SessionID:        record.FamilyID,
+		AccessToken:      accessToken,
+		AccessExpiresAt:  expiresAt,
+		RefreshToken:     refreshToken,
+		RefreshExpiresAt: record.ExpiresAt,
+	}, nil
+}
+
+// hashOpaqueToken hashes a refresh or API token for storage. The tokens are random, so a plain
+// SHA-256 is enough to make a leaked table useless.
+func hashOpaqueToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// DeviceNameFromUserAgent gives a readable name such as "Chrome on macOS" for a User-Agent header
+func DeviceNameFromUserAgent(userAgent string) string {
+	if userAgent == "" {
+		return "Unknown device"
+	}
+
+	browser := ""
+	for _, candidate := range []struct{ token, name string }{
+		{"Edg/", "Edge"},
+		{"OPR/", "Opera"},
+		{"Firefox/", "Firefox"},
+		{"CriOS/", "Chrome"},
+		{"Chrome/", "Chrome"},
+		{"Safari/", "Safari"},
+	} {
+		if strings.Contains(userAgent, candidate.token) {
+			browser = candidate.name
+			break
+		}
+	}
+
+	platform := ""
+	for _, candidate := range []struct{ token, name string }{
+		{"iPhone", "iPhone"},
+		{"iPad", "iPad"},
+		{"Android", "Android"},
+		{"Windows", "Windows"},
+		{"Mac OS X", "macOS"},
+		{"CrOS", "ChromeOS"},
+		{"Linux", "Linux"},
+	} {
+		if strings.Contains(userAgent, candidate.token) {
+			platform = candidate.name
+			break
+		}
+	}
+
+	switch {
+	case browser != "" && platform != "":
+		return browser + " on " + platform
+	case browser != "":
+		return browser
+	case platform != "":
+		return platform
+	}
+
+	// Non-browser clients such as curl/8.4.0
+	product, _, _ := strings.Cut(userAgent, " ")
+	product, _, _ = strings.Cut(product, "/")
+	return limitLength(product, 64)
+}
+
+// limitLength cuts s to at most n bytes without splitting a UTF-8 sequence
+func limitLength(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return strings.ToValidUTF8(s[:n], "")
+}
@@ -0,0 +1,267 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"testing"
+	"time"
+
+	"bodda/internal/config"
+	"bodda/internal/models"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryRefreshTokenStore keeps refresh tokens in memory with the same semantics as the repository
+type memoryRefreshTokenStore struct {
+	tokens []*models.RefreshToken
+}
+
+func newMemoryRefreshTokenStore() *memoryRefreshTokenStore {
+	return &memoryRefreshTokenStore{}
+}
+
+func (m *memoryRefreshTokenStore) Create(ctx context.Context, token *models.RefreshToken) error {
+	token.ID = fmt.Sprintf("token-%d", len(m.tokens)+1)
+	m.tokens = append(m.tokens, token)
+	return nil
+}
+
+func (m *memoryRefreshTokenStore) GetByHash(ctx context.Context, tokenHash string) (*models.RefreshToken, error) {
+	for _, token := range m.tokens {
+		if token.TokenHash == tokenHash {
+			copied := *token
+			return &copied, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *memoryRefreshTokenStore) Rotate(ctx context.Context, currentID string, next *models.RefreshToken) (bool, error) {
+	for _, token := range m.tokens {
+		if token.ID == currentID {
+			if token.RotatedAt != nil || token.RevokedAt != nil {
+				return false, nil
+			}
+			rotatedAt := next.CreatedAt
+			token.RotatedAt = &rotatedAt
+			token.LastUsedAt = rotatedAt
+			return true, m.Create(ctx, next)
+		}
+	}
+	return false, nil
+}
+
+func (m *memoryRefreshTokenStore) IsFamilyActive(ctx context.Context, familyID string, at time.Time) (bool, error) {
+	for _, token := range m.tokens {
+		if token.FamilyID == familyID && token.RotatedAt == nil && token.RevokedAt == nil && token.ExpiresAt.After(at) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (m *memoryRefreshTokenStore) ListActive(ctx context.Context, userID string, at time.Time) ([]*models.RefreshToken, error) {
+	var active []*models.RefreshToken
+	for _, token := range m.tokens {
+		if token.UserID == userID && token.RotatedAt == nil && token.RevokedAt == nil && token.ExpiresAt.After(at) {
+			active = append(active, token)
+		}
+	}
+	sort.Slice(active, func(i, j int) bool { return active[i].LastUsedAt.After(active[j].LastUsedAt) })
+	return active, nil
+}
+
+func (m *memoryRefreshTokenStore) RevokeFamily(ctx context.Context, userID, familyID, reason string, at time.Time) (bool, error) {
+	revoked := false
+	for _, token := range m.tokens {
+		if token.UserID == userID && token.FamilyID == familyID && token.RevokedAt == nil {
+			token.RevokedAt = &at
+			token.RevokedReason = reason
+			revoked = true
+		}
+	}
+	return revoked, nil
+}
+
+func (m *memoryRefreshTokenStore) RevokeAllForUser(ctx context.Context, userID, reason string, at time.Time) (int, error) {
+	families := make(map[string]bool)
+	for _, token := range m.tokens {
+		if token.UserID == userID && token.RevokedAt == nil {
+			if token.RotatedAt == nil && token.ExpiresAt.After(at) {
+				families[token.FamilyID] = true
+			}
+			token.RevokedAt = &at
+			token.RevokedReason = reason
+		}
+	}
+	return len(families), nil
+}
+
+func (m *memoryRefreshTokenStore) DeleteExpired(ctx context.Context, at time.Time) (int64, error) {
+	var kept []*models.RefreshToken
+	for _, token := range m.tokens {
+		if token.ExpiresAt.After(at) {
+			kept = append(kept, token)
+		}
+	}
+	deleted := int64(len(m.tokens) - len(kept))
+	m.tokens = kept
+	return deleted, nil
+}
+
+// newSessionTestService returns an auth service whose clock is controlled by the returned pointer
+func newSessionTestService(t *testing.T) (*authService, *memoryRefreshTokenStore, *time.Time) {
+	store := newMemoryRefreshTokenStore()
+	userRepo := &MockUserRepository{}
+	userRepo.On("GetByID", mock.Anything, "test-user-id").Return(&models.User{ID: "test-user-id", StravaID: 12345}, nil)
+
+	service := NewAuthService(&config.Config{JWTSecret: "test-secret"}, userRepo, store).(*authService)
+	clock := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	service.now = func() time.Time { return clock }
+	return service, store, &clock
+}
+
+func TestAuthService_RefreshSession(t *testing.T) {
+	service, store, clock := newSessionTestService(t)
+	ctx := context.Background()
+	laptop := ClientInfo{UserAgent: "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/605.1.15 Version/17.0 Safari/605.1.15", IPAddress: "203.0.113.7"}
+
+	started, err := service.StartSession(ctx, &models.User{ID: "test-user-id"}, laptop)
+	require.NoError(t, err)
+
+	*clock = clock.Add(20 * time.Minute)
+	_, err = service.ValidateToken(started.AccessToken)
+	assert.Error(t, err, "the access token has expired")
+
+	refreshed, err := service.RefreshSession(ctx, started.RefreshToken, laptop)
+	require.NoError(t, err)
+	assert.Equal(t, started.SessionID, refreshed.SessionID)
+	assert.NotEqual(t, started.RefreshToken, refreshed.RefreshToken, "refresh tokens rotate on every use")
+	assert.Equal(t, int64(12345), refreshed.User.StravaID)
+
+	user, err := service.ValidateToken(refreshed.AccessToken)
+	require.NoError(t, err)
+	assert.Equal(t, "test-user-id", user.ID)
+
+	// A concurrent request presenting the old token moments later gets an access token only
+	*clock = clock.Add(5 * time.Second)
+	concurrent, err := service.RefreshSession(ctx, started.RefreshToken, laptop)
+	require.NoError(t, err)
+	assert.Empty(t, concurrent.RefreshToken)
+	assert.Equal(t, started.SessionID, concurrent.SessionID)
+
+	// Replaying it later means it leaked: the whole family is revoked
+	*clock = clock.Add(time.Minute)
+	_, err = service.RefreshSession(ctx, started.RefreshToken, laptop)
+	assert.True(t, errors.Is(err, ErrRefreshTokenReused))
+
+	_, err = service.RefreshSession(ctx, refreshed.RefreshToken, laptop)
+	assert.True(t, errors.Is(err, ErrInvalidRefreshToken), "the legitimate holder is signed out too")
+	_, err = service.ValidateToken(refreshed.AccessToken)
+	assert.Error(t, err)
+
+	for _, token := range store.tokens {
+		require.NotNil(t, token.RevokedAt)
+		assert.Equal(t, "reuse_detected", token.RevokedReason)
+	}
+
+	_, err = service.RefreshSession(ctx, "unknown-token", laptop)
+	assert.True(t, errors.Is(err, ErrInvalidRefreshToken))
+}
+
+func TestAuthService_RefreshSessionExpiry(t *testing.T) {
+	service, _, clock := newSessionTestService(t)
+	ctx := context.Background()
+
+	started, err := service.StartSession(ctx, &models.User{ID: "test-user-id"}, ClientInfo{})
+	require.NoError(t, err)
+
+	*clock = clock.Add(29 * 24 * time.Hour)
+	refreshed, err := service.RefreshSession(ctx, started.RefreshToken, ClientInfo{})
+	require.NoError(t, err)
+	assert.Equal(t, clock.Add(30*24*time.Hour), refreshed.RefreshExpiresAt, "using a session extends it")
+
+	*clock = clock.Add(31 * 24 * time.Hour)
+	_, err = service.RefreshSession(ctx, refreshed.RefreshToken, ClientInfo{})
+	assert.True(t, errors.Is(err, ErrInvalidRefreshToken))
+}
+
+func TestAuthService_ManageSessions(t *testing.T) {
+	service, _, clock := newSessionTestService(t)
+	ctx := context.Background()
+	user := &models.User{ID: "test-user-id"}
+
+	phone, err := service.StartSession(ctx, user, ClientInfo{UserAgent: "Mozilla/5.0 (iPhone; CPU iPhone OS 17_0 like Mac OS X) AppleWebKit/605.1.15 CriOS/120.0 Mobile Safari/604.1", IPAddress: "198.51.100.2"})
+	require.NoError(t, err)
+	*clock = clock.Add(time.Hour)
+	laptop, err := service.StartSession(ctx, user, ClientInfo{UserAgent: "Mozilla/5.0 (Windows NT 10.0; Win64; x64) Chrome/120.0 Safari/537.36 Edg/120.0", IPAddress: "203.0.113.7"})
+	require.NoError(t, err)
+
+	sessions, err := service.ListSessions(ctx, user.ID, laptop.RefreshToken)
+	require.NoError(t, err)
+	require.Len(t, sessions, 2)
+	assert.Equal(t, laptop.SessionID, sessions[0].ID, "most recently used first")
+	assert.Equal(t, "Edge on Windows", sessions[0].DeviceName)
+	assert.Equal(t, "203.0.113.7", sessions[0].IPAddress)
+	assert.True(t, sessions[0].Current)
+	assert.Equal(t, "Chrome on iPhone", sessions[1].DeviceName)
+	assert.False(t, sessions[1].Current)
+
+	assert.True(t, errors.Is(service.RevokeSession(ctx, "other-user", phone.SessionID), ErrLoginSessionNotFound))
+	assert.True(t, errors.Is(service.RevokeSession(ctx, user.ID, "not-a-uuid"), ErrLoginSessionNotFound))
+	require.NoError(t, service.RevokeSession(ctx, user.ID, phone.SessionID))
+	_, err = service.RefreshSession(ctx, phone.RefreshToken, ClientInfo{})
+	assert.True(t, errors.Is(err, ErrInvalidRefreshToken))
+
+	sessions, err = service.ListSessions(ctx, user.ID, "")
+	require.NoError(t, err)
+	assert.Len(t, sessions, 1)
+
+	_, err = service.StartSession(ctx, user, ClientInfo{})
+	require.NoError(t, err)
+	revoked, err := service.RevokeAllSessions(ctx, user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 2, revoked)
+
+	sessions, err = service.ListSessions(ctx, user.ID, "")
+	require.NoError(t, err)
+	assert.Empty(t, sessions)
+}
+
+func TestAuthService_ValidateToken_RejectsLegacyTokens(t *testing.T) {
+	service, _, clock := newSessionTestService(t)
+
+	// 24-hour tokens issued before login sessions carry no session ID
+	legacy, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"user_id": "test-user-id",
+		"exp":     clock.Add(24 * time.Hour).Unix(),
+		"iat":     clock.Unix(),
+	}).SignedString([]byte("test-secret"))
+	require.NoError(t, err)
+
+	_, err = service.ValidateToken(legacy)
+	assert.Error(t, err)
+}
+
+func TestDeviceNameFromUserAgent(t *testing.T) {
+	tests := []struct {
+		userAgent string
+		want      string
+	}{
+		{"Mozilla/5.0 (X11; Linux x86_64; rv:121.0) Gecko/20100101 Firefox/121.0", "Firefox on Linux"},
+		{"Mozilla/5.0 (Linux; Android 14; Pixel 8) AppleWebKit/537.36 Chrome/120.0 Mobile Safari/537.36", "Chrome on Android"},
+		{"Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/605.1.15 Version/17.0 Safari/605.1.15", "Safari on macOS"},
+		{"curl/8.4.0", "curl"},
+		{"", "Unknown device"},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, DeviceNameFromUserAgent(tt.userAgent), tt.userAgent)
+	}
+}
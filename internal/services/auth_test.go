@@ -58,21 +58,23 @@ func (m *MockUserRepository) Delete(ctx context.Context, id string) error {
 	return args.Error(0)
 }
 
-func TestAuthService_GenerateJWT(t *testing.T) {
+func TestAuthService_StartSession(t *testing.T) {
 	cfg := &config.Config{
 		JWTSecret: "test-secret",
 	}
 	mockRepo := &MockUserRepository{}
-	authService := NewAuthService(cfg, mockRepo)
+	authService := NewAuthService(cfg, mockRepo, newMemoryRefreshTokenStore())
 
-	userID := "test-user-id"
-	token, err := authService.GenerateJWT(userID)
+	user := &models.User{ID: "test-user-id"}
+	tokens, err := authService.StartSession(context.Background(), user, ClientInfo{})
 
 	assert.NoError(t, err)
-	assert.NotEmpty(t, token)
+	assert.NotEmpty(t, tokens.AccessToken)
+	assert.NotEmpty(t, tokens.RefreshToken)
+	assert.Equal(t, user, tokens.User)
 
 	// Verify token can be parsed
-	parsedToken, err := jwt.Parse(token, func(token *jwt.Token) (interface{}, error) {
+	parsedToken, err := jwt.Parse(tokens.AccessToken, func(token *jwt.Token) (interface{}, error) {
 		return []byte(cfg.JWTSecret), nil
 	})
 
@@ -81,7 +83,10 @@ func TestAuthService_GenerateJWT(t *testing.T) {
 
 	claims, ok := parsedToken.Claims.(jwt.MapClaims)
 	assert.True(t, ok)
-	assert.Equal(t, userID, claims["user_id"])
+	assert.Equal(t, user.ID, claims["user_id"])
+	assert.Equal(t, tokens.SessionID, claims["sid"])
+	assert.Equal(t, "access", claims["typ"])
+	assert.InDelta(t, time.Now().Add(15*time.Minute).Unix(), claims["exp"], 5, "access tokens are short-lived")
 }
 
 func TestAuthService_ValidateToken(t *testing.T) {
@@ -89,7 +94,7 @@ func TestAuthService_ValidateToken(t *testing.T) {
 		JWTSecret: "test-secret",
 	}
 	mockRepo := &MockUserRepository{}
-	authService := NewAuthService(cfg, mockRepo)
+	authService := NewAuthService(cfg, mockRepo, newMemoryRefreshTokenStore())
 
 	user := &models.User{
 		ID:        "test-user-id",
@@ -102,15 +107,21 @@ func TestAuthService_ValidateToken(t *testing.T) {
 	mockRepo.On("GetByID", mock.Anything, "test-user-id").Return(user, nil)
 
 	// Generate a valid token
-	token, err := authService.GenerateJWT(user.ID)
+	tokens, err := authService.StartSession(context.Background(), user, ClientInfo{})
 	assert.NoError(t, err)
 
 	// Validate the token
-	validatedUser, err := authService.ValidateToken(token)
+	validatedUser, err := authService.ValidateToken(tokens.AccessToken)
 	assert.NoError(t, err)
 	assert.Equal(t, user.ID, validatedUser.ID)
 	assert.Equal(t, user.StravaID, validatedUser.StravaID)
 
+	// Tokens of a signed out session are rejected before they expire
+	assert.NoError(t, authService.EndSession(context.Background(), tokens.RefreshToken))
+	_, err = authService.ValidateToken(tokens.AccessToken)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "revoked")
+
 	mockRepo.AssertExpectations(t)
 }
 
@@ -119,7 +130,7 @@ func TestAuthService_ValidateToken_Invalid(t *testing.T) {
 		JWTSecret: "test-secret",
 	}
 	mockRepo := &MockUserRepository{}
-	authService := NewAuthService(cfg, mockRepo)
+	authService := NewAuthService(cfg, mockRepo, newMemoryRefreshTokenStore())
 
 	// Test with invalid token
 	_, err := authService.ValidateToken("invalid-token")
@@ -133,15 +144,19 @@ func TestAuthService_GetStravaOAuthURL(t *testing.T) {
 		StravaRedirectURL: "http://localhost:8080/auth/callback",
 	}
 	mockRepo := &MockUserRepository{}
-	authService := NewAuthService(cfg, mockRepo)
+	authService := NewAuthService(cfg, mockRepo, newMemoryRefreshTokenStore())
 
 	state := "test-state"
-	url := authService.GetStravaOAuthURL(state)
+	url := authService.GetStravaOAuthURL(state, false)
 
 	assert.Contains(t, url, "https://www.strava.com/oauth/authorize")
 	assert.Contains(t, url, "client_id=test-client-id")
 	assert.Contains(t, url, "state=test-state")
 	assert.Contains(t, url, "redirect_uri=http%3A%2F%2Flocalhost%3A8080%2Fauth%2Fcallback")
+	assert.NotContains(t, url, "approval_prompt")
+
+	url = authService.GetStravaOAuthURL(state, true)
+	assert.Contains(t, url, "approval_prompt=force")
 }
 
 func TestAuthService_HandleStravaOAuth_NewUser(t *testing.T) {
@@ -198,7 +213,7 @@ func TestAuthService_RefreshStravaToken(t *testing.T) {
 		StravaClientSecret: "test-client-secret",
 	}
 	mockRepo := &MockUserRepository{}
-	authService := NewAuthService(cfg, mockRepo)
+	authService := NewAuthService(cfg, mockRepo, newMemoryRefreshTokenStore())
 
 	user := &models.User{
 		ID:           "test-user-id",
@@ -7,9 +7,12 @@ import (
 	"html"
 	"regexp"
 	"strings"
+	"time"
 
 	"bodda/internal/database"
 	"bodda/internal/models"
+
+	"github.com/google/uuid"
 )
 
 // Custom error types for chat service
@@ -19,35 +22,72 @@ var (
 	ErrMessageTooLong        = errors.New("message content is too long")
 	ErrSessionNotFound       = errors.New("session not found")
 	ErrUnauthorizedAccess    = errors.New("unauthorized access to session")
+	ErrInvalidSearchQuery    = errors.New("invalid search query")
+	ErrMessageNotFound       = errors.New("message not found")
+	ErrInvalidBranchMessage  = errors.New("message cannot start a new branch")
+)
+
+// defaultSessionTitle is given to sessions created without a title until one is generated
+const defaultSessionTitle = "New Conversation"
+
+// Session search limits
+const (
+	maxSessionSearchQueryLength = 200
+	defaultSessionSearchLimit   = 20
+	maxSessionSearchLimit       = 50
+)
+
+// Highlight delimiters passed to the database. They come from the Unicode private use area, which
+// chat messages do not use, so they survive HTML escaping and are then replaced with <mark> tags.
+const (
+	searchMatchStart = "\ue000"
+	searchMatchStop  = "\ue001"
 )
 
+// SessionUpdate holds the session fields to change; nil fields are left as they are
+type SessionUpdate struct {
+	Title    *string `json:"title"`
+	Pinned   *bool   `json:"pinned"`
+	Archived *bool   `json:"archived"`
+}
+
 type ChatService interface {
 	CreateSession(userID string) (*models.Session, error)
 	CreateSessionWithTitle(userID, title string) (*models.Session, error)
 	GetSessions(userID string) ([]*models.Session, error)
+	GetArchivedSessions(userID string) ([]*models.Session, error)
 	GetSession(sessionID string) (*models.Session, error)
 	UpdateSessionTitle(sessionID, title string) error
+	SetGeneratedTitle(sessionID, title string) (bool, error)
+	UpdateSession(sessionID string, update SessionUpdate) (*models.Session, error)
+	SearchSessions(userID, query string, limit int) ([]*models.SessionSearchResult, error)
+	ForkSession(sessionID, messageID, title string) (*models.Session, error)
 	DeleteSession(sessionID string) error
 	SendMessage(sessionID, role, content string) (*models.Message, error)
 	SendMessageWithResponseID(sessionID, role, content string, responseID *string) (*models.Message, error)
 	GetMessages(sessionID string) ([]*models.Message, error)
 	GetMessagesWithPagination(sessionID string, limit, offset int) ([]*models.Message, error)
 	GetMessageCount(sessionID string) (int, error)
+	EditMessage(sessionID, messageID, content string) (*models.Message, error)
+	RegenerateFrom(sessionID, messageID string) (*models.Message, error)
+	SelectBranch(sessionID, messageID string) ([]*models.Message, error)
 	StreamResponse(sessionID string, response chan string) error
 }
 
 type chatService struct {
 	repo *database.Repository
+	now  func() time.Time
 }
 
 func NewChatService(repo *database.Repository) ChatService {
 	return &chatService{
 		repo: repo,
+		now:  time.Now,
 	}
 }
 
 func (s *chatService) CreateSession(userID string) (*models.Session, error) {
-	return s.CreateSessionWithTitle(userID, "New Conversation")
+	return s.CreateSessionWithTitle(userID, defaultSessionTitle)
 }
 
 func (s *chatService) CreateSessionWithTitle(userID, title string) (*models.Session, error) {
@@ -64,8 +104,9 @@ func (s *chatService) CreateSessionWithTitle(userID, title string) (*models.Sess
 	}
 
 	session := &models.Session{
-		UserID: userID,
-		Title:  sanitizedTitle,
+		UserID:          userID,
+		Title:           sanitizedTitle,
+		TitleCustomized: sanitizedTitle != defaultSessionTitle,
 	}
 
 	err = s.repo.Session.Create(ctx, session)
@@ -76,10 +117,11 @@ func (s *chatService) CreateSessionWithTitle(userID, title string) (*models.Sess
 	return session, nil
 }
 
+// GetSessions returns the user's sessions that are not archived, pinned sessions first
 func (s *chatService) GetSessions(userID string) ([]*models.Session, error) {
 	ctx := context.Background()
 
-	sessions, err := s.repo.Session.GetByUserID(ctx, userID)
+	sessions, err := s.repo.Session.ListByUser(ctx, userID, false)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get sessions: %w", err)
 	}
@@ -87,6 +129,18 @@ func (s *chatService) GetSessions(userID string) ([]*models.Session, error) {
 	return sessions, nil
 }
 
+// GetArchivedSessions returns the user's archived sessions
+func (s *chatService) GetArchivedSessions(userID string) ([]*models.Session, error) {
+	ctx := context.Background()
+
+	sessions, err := s.repo.Session.ListByUser(ctx, userID, true)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get archived sessions: %w", err)
+	}
+
+	return sessions, nil
+}
+
 func (s *chatService) GetSession(sessionID string) (*models.Session, error) {
 	ctx := context.Background()
 
@@ -130,6 +184,156 @@ func (s *chatService) UpdateSessionTitle(sessionID, title string) error {
 	return nil
 }
 
+// SetGeneratedTitle replaces the default title of a session with a generated one. It returns false
+// and leaves the session alone when the user has named it or it was already titled.
+func (s *chatService) SetGeneratedTitle(sessionID, title string) (bool, error) {
+	if err := s.validateSessionID(sessionID); err != nil {
+		return false, err
+	}
+
+	sanitizedTitle, err := s.validateAndSanitizeTitle(title)
+	if err != nil {
+		return false, err
+	}
+
+	return s.repo.Session.UpdateGeneratedTitle(context.Background(), sessionID, sanitizedTitle, defaultSessionTitle)
+}
+
+// UpdateSession renames, pins or archives a session and returns the updated session
+func (s *chatService) UpdateSession(sessionID string, update SessionUpdate) (*models.Session, error) {
+	ctx := context.Background()
+
+	if err := s.validateSessionID(sessionID); err != nil {
+		return nil, err
+	}
+
+	session, err := s.repo.Session.GetByID(ctx, sessionID)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return nil, ErrSessionNotFound
+		}
+		return nil, fmt.Errorf("failed to get session: %w", err)
+	}
+
+	if update.Title != nil {
+		title, err := s.validateAndSanitizeTitle(*update.Title)
+		if err != nil {
+			return nil, err
+		}
+		session.Title = title
+		session.TitleCustomized = true
+	}
+	if update.Pinned != nil {
+		session.Pinned = *update.Pinned
+	}
+	if update.Archived != nil {
+		switch {
+		case !*update.Archived:
+			session.ArchivedAt = nil
+		case session.ArchivedAt == nil:
+			archivedAt := s.now()
+			session.ArchivedAt = &archivedAt
+		}
+	}
+
+	if err := s.repo.Session.Update(ctx, session); err != nil {
+		return nil, fmt.Errorf("failed to update session: %w", err)
+	}
+
+	return session, nil
+}
+
+// SearchSessions finds the user's sessions whose messages match the query, archived sessions
+// included. Each result's snippet is HTML-escaped with the matched terms wrapped in <mark> tags.
+func (s *chatService) SearchSessions(userID, query string, limit int) ([]*models.SessionSearchResult, error) {
+	ctx := context.Background()
+
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return nil, fmt.Errorf("%w: query is required", ErrInvalidSearchQuery)
+	}
+	if len(query) > maxSessionSearchQueryLength {
+		return nil, fmt.Errorf("%w: query must be at most %d characters", ErrInvalidSearchQuery, maxSessionSearchQueryLength)
+	}
+	if limit <= 0 {
+		limit = defaultSessionSearchLimit
+	}
+	if limit > maxSessionSearchLimit {
+		limit = maxSessionSearchLimit
+	}
+
+	results, err := s.repo.Session.Search(ctx, userID, query, searchMatchStart, searchMatchStop, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search sessions: %w", err)
+	}
+
+	for _, result := range results {
+		result.Snippet = highlightSnippet(result.Snippet)
+	}
+
+	return results, nil
+}
+
+// highlightSnippet escapes a search excerpt for HTML and turns the match markers into <mark> tags
+func highlightSnippet(snippet string) string {
+	snippet = html.EscapeString(snippet)
+	snippet = strings.ReplaceAll(snippet, searchMatchStart, "<mark>")
+	return strings.ReplaceAll(snippet, searchMatchStop, "</mark>")
+}
+
+// ForkSession starts a new session holding a copy of the conversation up to and including the
+// given message. The title defaults to "Fork of" the original title.
+func (s *chatService) ForkSession(sessionID, messageID, title string) (*models.Session, error) {
+	ctx := context.Background()
+
+	if err := s.validateSessionID(sessionID); err != nil {
+		return nil, err
+	}
+	if _, err := uuid.Parse(messageID); err != nil {
+		return nil, ErrMessageNotFound
+	}
+
+	source, err := s.repo.Session.GetByID(ctx, sessionID)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return nil, ErrSessionNotFound
+		}
+		return nil, fmt.Errorf("failed to get session: %w", err)
+	}
+
+	customTitle := strings.TrimSpace(title) != ""
+	if customTitle {
+		title, err = s.validateAndSanitizeTitle(title)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		// The source title is already sanitized
+		title = "Fork of " + source.Title
+		if len(title) > 200 {
+			title = strings.TrimSpace(title[:200])
+		}
+	}
+
+	fork := &models.Session{
+		UserID:              source.UserID,
+		Title:               title,
+		TitleCustomized:     customTitle,
+		AthleteID:           source.AthleteID,
+		ForkedFromSessionID: &source.ID,
+	}
+
+	forked, err := s.repo.Session.Fork(ctx, fork, source.ID, messageID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fork session: %w", err)
+	}
+	if !forked {
+		return nil, ErrMessageNotFound
+	}
+
+	return fork, nil
+}
+
 func (s *chatService) DeleteSession(sessionID string) error {
 	ctx := context.Background()
 
@@ -222,10 +426,11 @@ func (s *chatService) SendMessageWithResponseID(sessionID, role, content string,
 	return message, nil
 }
 
+// GetMessages returns the messages on the session's active branch
 func (s *chatService) GetMessages(sessionID string) ([]*models.Message, error) {
 	ctx := context.Background()
 
-	messages, err := s.repo.Message.GetBySessionID(ctx, sessionID)
+	messages, err := s.repo.Message.GetActivePath(ctx, sessionID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get messages: %w", err)
 	}
@@ -236,23 +441,191 @@ func (s *chatService) GetMessages(sessionID string) ([]*models.Message, error) {
 func (s *chatService) GetMessagesWithPagination(sessionID string, limit, offset int) ([]*models.Message, error) {
 	ctx := context.Background()
 
-	messages, err := s.repo.Message.GetBySessionIDWithPagination(ctx, sessionID, limit, offset)
+	messages, err := s.repo.Message.GetActivePath(ctx, sessionID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get messages with pagination: %w", err)
 	}
 
+	if offset >= len(messages) {
+		return []*models.Message{}, nil
+	}
+	messages = messages[offset:]
+	if limit < len(messages) {
+		messages = messages[:limit]
+	}
+
 	return messages, nil
 }
 
 func (s *chatService) GetMessageCount(sessionID string) (int, error) {
 	ctx := context.Background()
 
-	count, err := s.repo.Message.CountBySessionID(ctx, sessionID)
+	messages, err := s.repo.Message.GetActivePath(ctx, sessionID)
 	if err != nil {
 		return 0, fmt.Errorf("failed to get message count: %w", err)
 	}
 
-	return count, nil
+	return len(messages), nil
+}
+
+// EditMessage stores new content for a user message as a sibling of it, leaving the original and
+// its replies on their own branch, and makes the edited message the end of the active branch
+func (s *chatService) EditMessage(sessionID, messageID, content string) (*models.Message, error) {
+	ctx := context.Background()
+
+	sanitizedContent, err := s.validateAndSanitizeContent(content)
+	if err != nil {
+		return nil, err
+	}
+
+	session, original, err := s.branchMessage(ctx, sessionID, messageID)
+	if err != nil {
+		return nil, err
+	}
+	if original.Role != "user" {
+		return nil, fmt.Errorf("%w: only user messages can be edited", ErrInvalidBranchMessage)
+	}
+
+	before, err := s.repo.Message.GetActivePath(ctx, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get messages: %w", err)
+	}
+
+	edited := &models.Message{
+		SessionID: sessionID,
+		ParentID:  original.ParentID,
+		Role:      "user",
+		Content:   sanitizedContent,
+	}
+	if err := s.repo.Message.CreateBranch(ctx, edited); err != nil {
+		return nil, fmt.Errorf("failed to create edited message: %w", err)
+	}
+
+	if _, err := s.resetStaleSummary(ctx, session, before); err != nil {
+		return nil, err
+	}
+
+	return edited, nil
+}
+
+// RegenerateFrom rewinds the active branch to the user message an assistant reply answered and
+// returns that message. The next assistant message sent to the session becomes a sibling of the
+// replaced reply.
+func (s *chatService) RegenerateFrom(sessionID, messageID string) (*models.Message, error) {
+	ctx := context.Background()
+
+	session, reply, err := s.branchMessage(ctx, sessionID, messageID)
+	if err != nil {
+		return nil, err
+	}
+	if reply.Role != "assistant" || reply.ParentID == nil {
+		return nil, fmt.Errorf("%w: only assistant replies can be regenerated", ErrInvalidBranchMessage)
+	}
+
+	prompt, err := s.repo.Message.GetByID(ctx, *reply.ParentID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get replied message: %w", err)
+	}
+	if prompt.Role != "user" {
+		return nil, fmt.Errorf("%w: the reply does not answer a user message", ErrInvalidBranchMessage)
+	}
+
+	before, err := s.repo.Message.GetActivePath(ctx, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get messages: %w", err)
+	}
+
+	updated, err := s.repo.Message.SetActiveMessage(ctx, sessionID, prompt.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to rewind session: %w", err)
+	}
+	if !updated {
+		return nil, ErrMessageNotFound
+	}
+
+	if _, err := s.resetStaleSummary(ctx, session, before); err != nil {
+		return nil, err
+	}
+
+	return prompt, nil
+}
+
+// SelectBranch makes the branch through the given message active, continuing to its newest reply,
+// and returns the messages of the new active branch
+func (s *chatService) SelectBranch(sessionID, messageID string) ([]*models.Message, error) {
+	ctx := context.Background()
+
+	session, _, err := s.branchMessage(ctx, sessionID, messageID)
+	if err != nil {
+		return nil, err
+	}
+
+	before, err := s.repo.Message.GetActivePath(ctx, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get messages: %w", err)
+	}
+
+	selected, err := s.repo.Message.SelectBranch(ctx, sessionID, messageID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to select branch: %w", err)
+	}
+	if !selected {
+		return nil, ErrMessageNotFound
+	}
+
+	return s.resetStaleSummary(ctx, session, before)
+}
+
+// branchMessage loads a session and one of its messages for the branching operations
+func (s *chatService) branchMessage(ctx context.Context, sessionID, messageID string) (*models.Session, *models.Message, error) {
+	if err := s.validateSessionID(sessionID); err != nil {
+		return nil, nil, err
+	}
+	if _, err := uuid.Parse(messageID); err != nil {
+		return nil, nil, ErrMessageNotFound
+	}
+
+	session, err := s.repo.Session.GetByID(ctx, sessionID)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return nil, nil, ErrSessionNotFound
+		}
+		return nil, nil, fmt.Errorf("failed to get session: %w", err)
+	}
+
+	message, err := s.repo.Message.GetByID(ctx, messageID)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return nil, nil, ErrMessageNotFound
+		}
+		return nil, nil, fmt.Errorf("failed to get message: %w", err)
+	}
+	if message.SessionID != sessionID {
+		return nil, nil, ErrMessageNotFound
+	}
+
+	return session, message, nil
+}
+
+// resetStaleSummary drops the session's conversation summary when the active branch no longer
+// starts with the messages it covers, and returns the messages of the active branch
+func (s *chatService) resetStaleSummary(ctx context.Context, session *models.Session, before []*models.Message) ([]*models.Message, error) {
+	after, err := s.repo.Message.GetActivePath(ctx, session.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get messages: %w", err)
+	}
+
+	shared := 0
+	for shared < len(before) && shared < len(after) && before[shared].ID == after[shared].ID {
+		shared++
+	}
+	if shared < session.SummarizedMessageCount {
+		if err := s.repo.Session.UpdateSummary(ctx, session.ID, "", 0); err != nil {
+			return nil, fmt.Errorf("failed to reset session summary: %w", err)
+		}
+	}
+
+	return after, nil
 }
 
 func (s *chatService) StreamResponse(sessionID string, response chan string) error {
@@ -318,7 +691,7 @@ func (s *chatService) validateAndSanitizeTitle(title string) (string, error) {
 
 	// Check length
 	if len(title) == 0 {
-		return "New Conversation", nil // Default title
+		return defaultSessionTitle, nil
 	}
 
 	if len(title) > 200 {
@@ -348,6 +348,164 @@ func (suite *ChatServiceTestSuite) TestCompleteWorkflow() {
 	assert.Equal(suite.T(), 2, count)
 }
 
+func (suite *ChatServiceTestSuite) TestUpdateSession() {
+	session, err := suite.service.CreateSession(suite.testUser.ID)
+	assert.NoError(suite.T(), err)
+
+	title := "  Race week  "
+	pinned := true
+	updated, err := suite.service.UpdateSession(session.ID, SessionUpdate{Title: &title, Pinned: &pinned})
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "Race week", updated.Title)
+	assert.True(suite.T(), updated.Pinned)
+
+	archived := true
+	_, err = suite.service.UpdateSession(session.ID, SessionUpdate{Archived: &archived})
+	assert.NoError(suite.T(), err)
+
+	active, err := suite.service.GetSessions(suite.testUser.ID)
+	assert.NoError(suite.T(), err)
+	assert.Empty(suite.T(), active, "archived sessions are hidden from the session list")
+
+	archivedSessions, err := suite.service.GetArchivedSessions(suite.testUser.ID)
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), archivedSessions, 1)
+
+	_, err = suite.service.UpdateSession("00000000-0000-0000-0000-000000000000", SessionUpdate{Pinned: &pinned})
+	assert.ErrorIs(suite.T(), err, ErrSessionNotFound)
+}
+
+func (suite *ChatServiceTestSuite) TestSearchSessions() {
+	session, err := suite.service.CreateSession(suite.testUser.ID)
+	assert.NoError(suite.T(), err)
+	_, err = suite.service.SendMessage(session.ID, "user", "Is <b>interval</b> training useful?")
+	assert.NoError(suite.T(), err)
+
+	results, err := suite.service.SearchSessions(suite.testUser.ID, "interval", 0)
+	assert.NoError(suite.T(), err)
+	if assert.Len(suite.T(), results, 1) {
+		assert.Contains(suite.T(), results[0].Snippet, "<mark>interval</mark>")
+		assert.Contains(suite.T(), results[0].Snippet, "&lt;b&gt;", "message content is escaped")
+	}
+
+	_, err = suite.service.SearchSessions(suite.testUser.ID, "   ", 0)
+	assert.ErrorIs(suite.T(), err, ErrInvalidSearchQuery)
+}
+
+func (suite *ChatServiceTestSuite) TestForkSession() {
+	session, err := suite.service.CreateSessionWithTitle(suite.testUser.ID, "Base plan")
+	assert.NoError(suite.T(), err)
+	first, err := suite.service.SendMessage(session.ID, "user", "Build me a plan")
+	assert.NoError(suite.T(), err)
+	_, err = suite.service.SendMessage(session.ID, "assistant", "Here is a plan")
+	assert.NoError(suite.T(), err)
+
+	fork, err := suite.service.ForkSession(session.ID, first.ID, "")
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "Fork of Base plan", fork.Title)
+
+	messages, err := suite.service.GetMessages(fork.ID)
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), messages, 1)
+
+	_, err = suite.service.ForkSession(session.ID, "not-a-message", "")
+	assert.ErrorIs(suite.T(), err, ErrMessageNotFound)
+}
+
+func (suite *ChatServiceTestSuite) TestEditMessage() {
+	session, err := suite.service.CreateSession(suite.testUser.ID)
+	assert.NoError(suite.T(), err)
+	question, err := suite.service.SendMessage(session.ID, "user", "Plan a 10k")
+	assert.NoError(suite.T(), err)
+	answer, err := suite.service.SendMessage(session.ID, "assistant", "Here is a 10k plan")
+	assert.NoError(suite.T(), err)
+
+	edited, err := suite.service.EditMessage(session.ID, question.ID, "  Plan a half marathon ")
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "Plan a half marathon", edited.Content)
+	assert.Nil(suite.T(), edited.ParentID)
+
+	messages, err := suite.service.GetMessages(session.ID)
+	assert.NoError(suite.T(), err)
+	if assert.Len(suite.T(), messages, 1) {
+		assert.Equal(suite.T(), edited.ID, messages[0].ID)
+		assert.Equal(suite.T(), []string{question.ID, edited.ID}, messages[0].SiblingIDs)
+	}
+
+	_, err = suite.service.EditMessage(session.ID, answer.ID, "Different answer")
+	assert.ErrorIs(suite.T(), err, ErrInvalidBranchMessage)
+
+	other, err := suite.service.CreateSession(suite.testUser.ID)
+	assert.NoError(suite.T(), err)
+	_, err = suite.service.EditMessage(other.ID, question.ID, "Elsewhere")
+	assert.ErrorIs(suite.T(), err, ErrMessageNotFound)
+}
+
+func (suite *ChatServiceTestSuite) TestRegenerateFrom() {
+	session, err := suite.service.CreateSession(suite.testUser.ID)
+	assert.NoError(suite.T(), err)
+	question, err := suite.service.SendMessage(session.ID, "user", "Plan a 10k")
+	assert.NoError(suite.T(), err)
+	answer, err := suite.service.SendMessage(session.ID, "assistant", "Here is a 10k plan")
+	assert.NoError(suite.T(), err)
+
+	prompt, err := suite.service.RegenerateFrom(session.ID, answer.ID)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), question.ID, prompt.ID)
+
+	count, err := suite.service.GetMessageCount(session.ID)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 1, count, "the replaced reply leaves the active branch")
+
+	retry, err := suite.service.SendMessage(session.ID, "assistant", "Here is another 10k plan")
+	assert.NoError(suite.T(), err)
+	messages, err := suite.service.GetMessages(session.ID)
+	assert.NoError(suite.T(), err)
+	if assert.Len(suite.T(), messages, 2) {
+		assert.Equal(suite.T(), retry.ID, messages[1].ID)
+		assert.Equal(suite.T(), []string{answer.ID, retry.ID}, messages[1].SiblingIDs)
+	}
+
+	_, err = suite.service.RegenerateFrom(session.ID, question.ID)
+	assert.ErrorIs(suite.T(), err, ErrInvalidBranchMessage)
+}
+
+func (suite *ChatServiceTestSuite) TestSelectBranch() {
+	ctx := context.Background()
+	session, err := suite.service.CreateSession(suite.testUser.ID)
+	assert.NoError(suite.T(), err)
+	question, err := suite.service.SendMessage(session.ID, "user", "Plan a 10k")
+	assert.NoError(suite.T(), err)
+	_, err = suite.service.SendMessage(session.ID, "assistant", "Here is a 10k plan")
+	assert.NoError(suite.T(), err)
+	assert.NoError(suite.T(), suite.repo.Session.UpdateSummary(ctx, session.ID, "Asked for a 10k plan", 2))
+
+	edited, err := suite.service.EditMessage(session.ID, question.ID, "Plan a 5k")
+	assert.NoError(suite.T(), err)
+
+	stored, err := suite.service.GetSession(session.ID)
+	assert.NoError(suite.T(), err)
+	assert.Zero(suite.T(), stored.SummarizedMessageCount, "the summary covered messages that left the active branch")
+
+	messages, err := suite.service.SelectBranch(session.ID, question.ID)
+	assert.NoError(suite.T(), err)
+	if assert.Len(suite.T(), messages, 2) {
+		assert.Equal(suite.T(), "Here is a 10k plan", messages[1].Content)
+	}
+
+	messages, err = suite.service.SelectBranch(session.ID, edited.ID)
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), messages, 1)
+
+	_, err = suite.service.SelectBranch(session.ID, "not-a-message")
+	assert.ErrorIs(suite.T(), err, ErrMessageNotFound)
+}
+
+func TestHighlightSnippet(t *testing.T) {
+	snippet := highlightSnippet("use " + searchMatchStart + "tempo" + searchMatchStop + " & <strides>")
+	assert.Equal(t, "use <mark>tempo</mark> &amp; &lt;strides&gt;", snippet)
+}
+
 func TestChatServiceTestSuite(t *testing.T) {
 	suite.Run(t, new(ChatServiceTestSuite))
 }
\ No newline at end of file
@@ -0,0 +1,410 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"bodda/internal/models"
+
+	"github.com/google/uuid"
+)
+
+var (
+	// ErrCoachingAccessDenied is returned when a user is not the athlete's coach or lacks the permission
+	ErrCoachingAccessDenied = errors.New("coaching access denied")
+	// ErrInvalidCoachingInvitation is returned for unknown, expired or already used invitation codes
+	ErrInvalidCoachingInvitation = errors.New("invalid coaching invitation")
+	// ErrCoachingLinkNotFound is returned when changing a link the user is not part of
+	ErrCoachingLinkNotFound = errors.New("coaching link not found")
+	// ErrInvalidCoachingRequest is returned for invalid permissions, comments and similar input
+	ErrInvalidCoachingRequest = errors.New("invalid coaching request")
+	// ErrAlreadyCoaching is returned when a coach accepts an invitation from an athlete they already coach
+	ErrAlreadyCoaching = errors.New("already coaching this athlete")
+)
+
+const (
+	coachingInvitationTTL  = 7 * 24 * time.Hour
+	maxCoachCommentLength  = 4000
+	coachCommentsPageLimit = 100
+)
+
+// CoachingStore persists coach–athlete links and coach comments
+type CoachingStore interface {
+	CreateInvitation(ctx context.Context, link *models.CoachingLink) error
+	GetByID(ctx context.Context, id string) (*models.CoachingLink, error)
+	GetByInviteHash(ctx context.Context, inviteHash string) (*models.CoachingLink, error)
+	GetActive(ctx context.Context, coachID, athleteID string) (*models.CoachingLink, error)
+	Accept(ctx context.Context, linkID, coachID string, at time.Time) (bool, error)
+	ListForCoach(ctx context.Context, coachID string) ([]*models.CoachingLink, error)
+	ListForAthlete(ctx context.Context, athleteID string) ([]*models.CoachingLink, error)
+	UpdatePermissions(ctx context.Context, athleteID, linkID string, permissions []string) (bool, error)
+	End(ctx context.Context, userID, linkID string, at time.Time) (bool, error)
+	CreateComment(ctx context.Context, comment *models.CoachComment) error
+	ListComments(ctx context.Context, athleteID, coachID string, limit int) ([]*models.CoachComment, error)
+}
+
+// CoachingSessionStore is the part of the session repository coaching needs
+type CoachingSessionStore interface {
+	Create(ctx context.Context, session *models.Session) error
+	GetByID(ctx context.Context, id string) (*models.Session, error)
+	GetByUserID(ctx context.Context, userID string) ([]*models.Session, error)
+}
+
+// CoachingInvitation is a new invitation together with its code, which is only ever shown once
+type CoachingInvitation struct {
+	Code string               `json:"code"`
+	Link *models.CoachingLink `json:"link"`
+}
+
+// CoachingService manages coach–athlete relationships and authorizes coaches' access to athlete data
+type CoachingService interface {
+	CreateInvitation(ctx context.Context, athleteID string, permissions []string) (*CoachingInvitation, error)
+	AcceptInvitation(ctx context.Context, coachID, code string) (*models.CoachingLink, error)
+	ListAthletes(ctx context.Context, coachID string) ([]*models.CoachingLink, error)
+	ListCoaches(ctx context.Context, athleteID string) ([]*models.CoachingLink, error)
+	UpdatePermissions(ctx context.Context, athleteID, linkID string, permissions []string) error
+	EndLink(ctx context.Context, userID, linkID string) error
+
+	// Authorize returns the athlete when coachID actively coaches them with the given permission
+	Authorize(ctx context.Context, coachID, athleteID, permission string) (*models.User, *models.CoachingLink, error)
+	CreateCoachSession(ctx context.Context, coachID, athleteID, title string) (*models.Session, error)
+	ListAthleteSessions(ctx context.Context, coachID, athleteID string) ([]*models.Session, error)
+	GetAthleteSession(ctx context.Context, coachID, athleteID, sessionID string) (*models.Session, error)
+	AddComment(ctx context.Context, coachID, athleteID string, sessionID *string, body string) (*models.CoachComment, error)
+	ListComments(ctx context.Context, userID, athleteID string) ([]*models.CoachComment, error)
+}
+
+type coachingService struct {
+	store    CoachingStore
+	sessions CoachingSessionStore
+	userRepo UserRepository
+	now      func() time.Time
+}
+
+func NewCoachingService(store CoachingStore, sessions CoachingSessionStore, userRepo UserRepository) CoachingService {
+	return &coachingService{
+		store:    store,
+		sessions: sessions,
+		userRepo: userRepo,
+		now:      time.Now,
+	}
+}
+
+func (s *coachingService) CreateInvitation(ctx context.Context, athleteID string, permissions []string) (*CoachingInvitation, error) {
+	granted, err := normalizeCoachPermissions(permissions)
+	if err != nil {
+		return nil, err
+	}
+
+	code, err := generateInviteCode()
+	if err != nil {
+		return nil, err
+	}
+	inviteHash := hashOpaqueToken(code)
+
+	now := s.now().UTC()
+	expiresAt := now.Add(coachingInvitationTTL)
+	link := &models.CoachingLink{
+		AthleteID:       athleteID,
+		Status:          models.CoachingStatusPending,
+		Permissions:     granted,
+		InviteHash:      &inviteHash,
+		InviteExpiresAt: &expiresAt,
+		CreatedAt:       now,
+	}
+	if err := s.store.CreateInvitation(ctx, link); err != nil {
+		return nil, err
+	}
+
+	return &CoachingInvitation{Code: code, Link: link}, nil
+}
+
+func (s *coachingService) AcceptInvitation(ctx context.Context, coachID, code string) (*models.CoachingLink, error) {
+	code = strings.TrimSpace(code)
+	if code == "" {
+		return nil, ErrInvalidCoachingInvitation
+	}
+
+	link, err := s.store.GetByInviteHash(ctx, hashOpaqueToken(code))
+	if err != nil {
+		return nil, err
+	}
+	now := s.now().UTC()
+	if link == nil || link.Status != models.CoachingStatusPending || link.InviteExpiresAt == nil || !link.InviteExpiresAt.After(now) {
+		return nil, ErrInvalidCoachingInvitation
+	}
+	if link.AthleteID == coachID {
+		return nil, fmt.Errorf("%w: you cannot coach yourself", ErrInvalidCoachingRequest)
+	}
+
+	existing, err := s.store.GetActive(ctx, coachID, link.AthleteID)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, ErrAlreadyCoaching
+	}
+
+	accepted, err := s.store.Accept(ctx, link.ID, coachID, now)
+	if err != nil {
+		return nil, err
+	}
+	if !accepted {
+		return nil, ErrInvalidCoachingInvitation
+	}
+
+	return s.store.GetByID(ctx, link.ID)
+}
+
+func (s *coachingService) ListAthletes(ctx context.Context, coachID string) ([]*models.CoachingLink, error) {
+	links, err := s.store.ListForCoach(ctx, coachID)
+	if err != nil {
+		return nil, err
+	}
+	if links == nil {
+		links = []*models.CoachingLink{}
+	}
+	return links, nil
+}
+
+func (s *coachingService) ListCoaches(ctx context.Context, athleteID string) ([]*models.CoachingLink, error) {
+	links, err := s.store.ListForAthlete(ctx, athleteID)
+	if err != nil {
+		return nil, err
+	}
+	if links == nil {
+		links = []*models.CoachingLink{}
+	}
+	return links, nil
+}
+
+func (s *coachingService) UpdatePermissions(ctx context.Context, athleteID, linkID string, permissions []string) error {
+	granted, err := normalizeCoachPermissions(permissions)
+	if err != nil {
+		return err
+	}
+	if _, err := uuid.Parse(linkID); err != nil {
+		return ErrCoachingLinkNotFound
+	}
+
+	updated, err := s.store.UpdatePermissions(ctx, athleteID, linkID, granted)
+	if err != nil {
+		return err
+	}
+	if !updated {
+		return ErrCoachingLinkNotFound
+	}
+	return nil
+}
+
+func (s *coachingService) EndLink(ctx context.Context, userID, linkID string) error {
+	if _, err := uuid.Parse(linkID); err != nil {
+		return ErrCoachingLinkNotFound
+	}
+
+	ended, err := s.store.End(ctx, userID, linkID, s.now().UTC())
+	if err != nil {
+		return err
+	}
+	if !ended {
+		return ErrCoachingLinkNotFound
+	}
+	return nil
+}
+
+func (s *coachingService) Authorize(ctx context.Context, coachID, athleteID, permission string) (*models.User, *models.CoachingLink, error) {
+	if _, err := uuid.Parse(athleteID); err != nil {
+		return nil, nil, ErrCoachingAccessDenied
+	}
+
+	link, err := s.store.GetActive(ctx, coachID, athleteID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if link == nil || (permission != "" && !link.Allows(permission)) {
+		return nil, nil, ErrCoachingAccessDenied
+	}
+
+	athlete, err := s.userRepo.GetByID(ctx, athleteID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get athlete: %w", err)
+	}
+
+	return athlete, link, nil
+}
+
+// CreateCoachSession starts an AI session owned by the coach whose tools read the athlete's data
+func (s *coachingService) CreateCoachSession(ctx context.Context, coachID, athleteID, title string) (*models.Session, error) {
+	athlete, _, err := s.Authorize(ctx, coachID, athleteID, models.CoachPermissionViewTraining)
+	if err != nil {
+		return nil, err
+	}
+
+	title = strings.TrimSpace(title)
+	if title == "" {
+		title = strings.TrimSpace(fmt.Sprintf("Coaching %s %s", athlete.FirstName, athlete.LastName))
+	}
+	if len(title) > 255 {
+		return nil, fmt.Errorf("%w: title is too long", ErrInvalidCoachingRequest)
+	}
+
+	session := &models.Session{
+		UserID:    coachID,
+		Title:     title,
+		AthleteID: &athlete.ID,
+	}
+	if err := s.sessions.Create(ctx, session); err != nil {
+		return nil, err
+	}
+
+	return session, nil
+}
+
+// ListAthleteSessions returns the athlete's own AI conversations. Sessions the athlete holds as
+// someone else's coach are not included.
+func (s *coachingService) ListAthleteSessions(ctx context.Context, coachID, athleteID string) ([]*models.Session, error) {
+	if _, _, err := s.Authorize(ctx, coachID, athleteID, models.CoachPermissionViewSessions); err != nil {
+		return nil, err
+	}
+
+	all, err := s.sessions.GetByUserID(ctx, athleteID)
+	if err != nil {
+		return nil, err
+	}
+
+	sessions := []*models.Session{}
+	for _, session := range all {
+		if session.AthleteID == nil {
+			sessions = append(sessions, session)
+		}
+	}
+	return sessions, nil
+}
+
+func (s *coachingService) GetAthleteSession(ctx context.Context, coachID, athleteID, sessionID string) (*models.Session, error) {
+	if _, _, err := s.Authorize(ctx, coachID, athleteID, models.CoachPermissionViewSessions); err != nil {
+		return nil, err
+	}
+
+	return s.athleteSession(ctx, athleteID, sessionID)
+}
+
+// athleteSession returns one of the athlete's own sessions, or ErrSessionNotFound
+func (s *coachingService) athleteSession(ctx context.Context, athleteID, sessionID string) (*models.Session, error) {
+	if _, err := uuid.Parse(sessionID); err != nil {
+		return nil, ErrSessionNotFound
+	}
+
+	session, err := s.sessions.GetByID(ctx, sessionID)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return nil, ErrSessionNotFound
+		}
+		return nil, err
+	}
+	if session.UserID != athleteID || session.AthleteID != nil {
+		return nil, ErrSessionNotFound
+	}
+
+	return session, nil
+}
+
+func (s *coachingService) AddComment(ctx context.Context, coachID, athleteID string, sessionID *string, body string) (*models.CoachComment, error) {
+	if _, _, err := s.Authorize(ctx, coachID, athleteID, models.CoachPermissionComment); err != nil {
+		return nil, err
+	}
+
+	body = strings.TrimSpace(body)
+	if body == "" || len(body) > maxCoachCommentLength {
+		return nil, fmt.Errorf("%w: comment must be between 1 and %d characters", ErrInvalidCoachingRequest, maxCoachCommentLength)
+	}
+
+	if sessionID != nil {
+		if _, err := s.athleteSession(ctx, athleteID, *sessionID); err != nil {
+			return nil, err
+		}
+	}
+
+	comment := &models.CoachComment{
+		CoachID:   coachID,
+		AthleteID: athleteID,
+		SessionID: sessionID,
+		Body:      body,
+		CreatedAt: s.now().UTC(),
+	}
+	if err := s.store.CreateComment(ctx, comment); err != nil {
+		return nil, err
+	}
+
+	return comment, nil
+}
+
+// ListComments returns comments left for the athlete. Athletes see comments from all their coaches;
+// a coach sees the comments they left themselves.
+func (s *coachingService) ListComments(ctx context.Context, userID, athleteID string) ([]*models.CoachComment, error) {
+	coachID := ""
+	if userID != athleteID {
+		if _, _, err := s.Authorize(ctx, userID, athleteID, ""); err != nil {
+			return nil, err
+		}
+		coachID = userID
+	}
+
+	comments, err := s.store.ListComments(ctx, athleteID, coachID, coachCommentsPageLimit)
+	if err != nil {
+		return nil, err
+	}
+	if comments == nil {
+		comments = []*models.CoachComment{}
+	}
+	return comments, nil
+}
+
+// normalizeCoachPermissions validates granted permissions and returns them deduplicated in display order
+func normalizeCoachPermissions(requested []string) ([]string, error) {
+	wanted := make(map[string]bool, len(requested))
+	for _, permission := range requested {
+		wanted[permission] = true
+	}
+
+	permissions := make([]string, 0, len(wanted))
+	for _, permission := range models.CoachPermissions {
+		if wanted[permission] {
+			permissions = append(permissions, permission)
+			delete(wanted, permission)
+		}
+	}
+	for permission := range wanted {
+		return nil, fmt.Errorf("%w: unknown permission %q", ErrInvalidCoachingRequest, permission)
+	}
+	if len(permissions) == 0 {
+		return nil, fmt.Errorf("%w: grant at least one permission", ErrInvalidCoachingRequest)
+	}
+
+	return permissions, nil
+}
+
+// displayName returns the user's full name for prompts, falling back to a neutral label
+func displayName(user *models.User) string {
+	if user == nil {
+		return "the athlete"
+	}
+	if name := strings.TrimSpace(user.FirstName + " " + user.LastName); name != "" {
+		return name
+	}
+	return "the athlete"
+}
+
+// generateInviteCode returns a random code users share to join; only its hash is stored
+func generateInviteCode() (string, error) {
+	raw := make([]byte, 18)
+	if _, err := rand.Read(raw); err != nil {
+		return "", fmt.Errorf("failed to generate invitation code: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(raw), nil
+}
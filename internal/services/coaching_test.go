@@ -0,0 +1,308 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"bodda/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testAthleteID = "00000000-0000-0000-0000-00000000000a"
+	testCoachID   = "00000000-0000-0000-0000-00000000000c"
+)
+
+// memoryCoachingStore keeps coaching links and comments in memory with the same semantics as the repository
+type memoryCoachingStore struct {
+	links    []*models.CoachingLink
+	comments []*models.CoachComment
+}
+
+func (m *memoryCoachingStore) CreateInvitation(ctx context.Context, link *models.CoachingLink) error {
+	link.ID = fmt.Sprintf("00000000-0000-0000-0001-%012d", len(m.links)+1)
+	m.links = append(m.links, link)
+	return nil
+}
+
+func (m *memoryCoachingStore) find(match func(*models.CoachingLink) bool) *models.CoachingLink {
+	for _, link := range m.links {
+		if match(link) {
+			copied := *link
+			return &copied
+		}
+	}
+	return nil
+}
+
+func (m *memoryCoachingStore) GetByID(ctx context.Context, id string) (*models.CoachingLink, error) {
+	return m.find(func(l *models.CoachingLink) bool { return l.ID == id }), nil
+}
+
+func (m *memoryCoachingStore) GetByInviteHash(ctx context.Context, inviteHash string) (*models.CoachingLink, error) {
+	return m.find(func(l *models.CoachingLink) bool { return l.InviteHash != nil && *l.InviteHash == inviteHash }), nil
+}
+
+func (m *memoryCoachingStore) GetActive(ctx context.Context, coachID, athleteID string) (*models.CoachingLink, error) {
+	return m.find(func(l *models.CoachingLink) bool {
+		return l.CoachID != nil && *l.CoachID == coachID && l.AthleteID == athleteID && l.Status == models.CoachingStatusActive
+	}), nil
+}
+
+func (m *memoryCoachingStore) Accept(ctx context.Context, linkID, coachID string, at time.Time) (bool, error) {
+	for _, link := range m.links {
+		if link.ID == linkID && link.Status == models.CoachingStatusPending && link.InviteExpiresAt.After(at) {
+			link.CoachID = &coachID
+			link.Status = models.CoachingStatusActive
+			link.AcceptedAt = &at
+			link.InviteHash = nil
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (m *memoryCoachingStore) ListForCoach(ctx context.Context, coachID string) ([]*models.CoachingLink, error) {
+	var links []*models.CoachingLink
+	for _, link := range m.links {
+		if link.CoachID != nil && *link.CoachID == coachID && link.Status == models.CoachingStatusActive {
+			links = append(links, link)
+		}
+	}
+	return links, nil
+}
+
+func (m *memoryCoachingStore) ListForAthlete(ctx context.Context, athleteID string) ([]*models.CoachingLink, error) {
+	var links []*models.CoachingLink
+	for _, link := range m.links {
+		if link.AthleteID == athleteID && link.Status != models.CoachingStatusEnded {
+			links = append(links, link)
+		}
+	}
+	return links, nil
+}
+
+func (m *memoryCoachingStore) UpdatePermissions(ctx context.Context, athleteID, linkID string, permissions []string) (bool, error) {
+	for _, link := range m.links {
+		if link.ID == linkID && link.AthleteID == athleteID && link.Status != models.CoachingStatusEnded {
+			link.Permissions = permissions
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (m *memoryCoachingStore) End(ctx context.Context, userID, linkID string, at time.Time) (bool, error) {
+	for _, link := range m.links {
+		isParty := link.AthleteID == userID || (link.CoachID != nil && *link.CoachID == userID)
+		if link.ID == linkID && isParty && link.Status != models.CoachingStatusEnded {
+			link.Status = models.CoachingStatusEnded
+			link.EndedAt = &at
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (m *memoryCoachingStore) CreateComment(ctx context.Context, comment *models.CoachComment) error {
+	comment.ID = fmt.Sprintf("comment-%d", len(m.comments)+1)
+	m.comments = append(m.comments, comment)
+	return nil
+}
+
+func (m *memoryCoachingStore) ListComments(ctx context.Context, athleteID, coachID string, limit int) ([]*models.CoachComment, error) {
+	var comments []*models.CoachComment
+	for _, comment := range m.comments {
+		if comment.AthleteID == athleteID && (coachID == "" || comment.CoachID == coachID) {
+			comments = append(comments, comment)
+		}
+	}
+	return comments, nil
+}
+
+// memoryCoachingSessionStore keeps sessions in memory
+type memoryCoachingSessionStore struct {
+	sessions []*models.Session
+}
+
+func (m *memoryCoachingSessionStore) Create(ctx context.Context, session *models.Session) error {
+	session.ID = fmt.Sprintf("00000000-0000-0000-0002-%012d", len(m.sessions)+1)
+	m.sessions = append(m.sessions, session)
+	return nil
+}
+
+func (m *memoryCoachingSessionStore) GetByID(ctx context.Context, id string) (*models.Session, error) {
+	for _, session := range m.sessions {
+		if session.ID == id {
+			return session, nil
+		}
+	}
+	return nil, fmt.Errorf("session not found")
+}
+
+func (m *memoryCoachingSessionStore) GetByUserID(ctx context.Context, userID string) ([]*models.Session, error) {
+	var sessions []*models.Session
+	for _, session := range m.sessions {
+		if session.UserID == userID {
+			sessions = append(sessions, session)
+		}
+	}
+	return sessions, nil
+}
+
+func newCoachingTestService() (*coachingService, *memoryCoachingSessionStore, *time.Time) {
+	userRepo := &MockUserRepository{}
+	userRepo.On("GetByID", mock.Anything, testAthleteID).Return(&models.User{ID: testAthleteID, FirstName: "Ada", LastName: "Athlete"}, nil)
+
+	sessions := &memoryCoachingSessionStore{}
+	service := NewCoachingService(&memoryCoachingStore{}, sessions, userRepo).(*coachingService)
+	clock := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	service.now = func() time.Time { return clock }
+	return service, sessions, &clock
+}
+
+// linkCoach invites the test coach with the given permissions and accepts the invitation
+func linkCoach(t *testing.T, service *coachingService, permissions ...string) *models.CoachingLink {
+	invitation, err := service.CreateInvitation(context.Background(), testAthleteID, permissions)
+	require.NoError(t, err)
+	link, err := service.AcceptInvitation(context.Background(), testCoachID, invitation.Code)
+	require.NoError(t, err)
+	return link
+}
+
+func TestCoachingService_Invitations(t *testing.T) {
+	service, _, clock := newCoachingTestService()
+	ctx := context.Background()
+
+	_, err := service.CreateInvitation(ctx, testAthleteID, nil)
+	assert.True(t, errors.Is(err, ErrInvalidCoachingRequest), "at least one permission is required")
+	_, err = service.CreateInvitation(ctx, testAthleteID, []string{"edit_everything"})
+	assert.True(t, errors.Is(err, ErrInvalidCoachingRequest))
+
+	invitation, err := service.CreateInvitation(ctx, testAthleteID, []string{models.CoachPermissionComment, models.CoachPermissionViewLogbook})
+	require.NoError(t, err)
+	assert.NotEmpty(t, invitation.Code)
+	assert.Equal(t, []string{models.CoachPermissionViewLogbook, models.CoachPermissionComment}, invitation.Link.Permissions)
+
+	_, err = service.AcceptInvitation(ctx, testAthleteID, invitation.Code)
+	assert.True(t, errors.Is(err, ErrInvalidCoachingRequest), "athletes cannot coach themselves")
+	_, err = service.AcceptInvitation(ctx, testCoachID, "wrong-code")
+	assert.True(t, errors.Is(err, ErrInvalidCoachingInvitation))
+
+	link, err := service.AcceptInvitation(ctx, testCoachID, invitation.Code)
+	require.NoError(t, err)
+	assert.Equal(t, models.CoachingStatusActive, link.Status)
+	_, err = service.AcceptInvitation(ctx, testCoachID, invitation.Code)
+	assert.True(t, errors.Is(err, ErrInvalidCoachingInvitation), "codes are single use")
+
+	second, err := service.CreateInvitation(ctx, testAthleteID, []string{models.CoachPermissionViewTraining})
+	require.NoError(t, err)
+	_, err = service.AcceptInvitation(ctx, testCoachID, second.Code)
+	assert.True(t, errors.Is(err, ErrAlreadyCoaching))
+
+	expired, err := service.CreateInvitation(ctx, testAthleteID, []string{models.CoachPermissionViewTraining})
+	require.NoError(t, err)
+	*clock = clock.Add(8 * 24 * time.Hour)
+	_, err = service.AcceptInvitation(ctx, "00000000-0000-0000-0000-0000000000c2", expired.Code)
+	assert.True(t, errors.Is(err, ErrInvalidCoachingInvitation))
+}
+
+func TestCoachingService_Authorize(t *testing.T) {
+	service, _, _ := newCoachingTestService()
+	ctx := context.Background()
+
+	_, _, err := service.Authorize(ctx, testCoachID, testAthleteID, models.CoachPermissionViewLogbook)
+	assert.True(t, errors.Is(err, ErrCoachingAccessDenied), "no relationship yet")
+
+	link := linkCoach(t, service, models.CoachPermissionViewLogbook)
+
+	athlete, _, err := service.Authorize(ctx, testCoachID, testAthleteID, models.CoachPermissionViewLogbook)
+	require.NoError(t, err)
+	assert.Equal(t, testAthleteID, athlete.ID)
+
+	_, _, err = service.Authorize(ctx, testCoachID, testAthleteID, models.CoachPermissionViewTraining)
+	assert.True(t, errors.Is(err, ErrCoachingAccessDenied), "permission not granted")
+
+	assert.True(t, errors.Is(service.UpdatePermissions(ctx, testCoachID, link.ID, []string{models.CoachPermissionViewTraining}), ErrCoachingLinkNotFound),
+		"only the athlete grants permissions")
+	require.NoError(t, service.UpdatePermissions(ctx, testAthleteID, link.ID, []string{models.CoachPermissionViewTraining}))
+	_, _, err = service.Authorize(ctx, testCoachID, testAthleteID, models.CoachPermissionViewTraining)
+	require.NoError(t, err)
+
+	require.NoError(t, service.EndLink(ctx, testAthleteID, link.ID))
+	_, _, err = service.Authorize(ctx, testCoachID, testAthleteID, models.CoachPermissionViewTraining)
+	assert.True(t, errors.Is(err, ErrCoachingAccessDenied), "access ends with the relationship")
+	assert.True(t, errors.Is(service.EndLink(ctx, testCoachID, link.ID), ErrCoachingLinkNotFound))
+}
+
+func TestCoachingService_SessionsAndComments(t *testing.T) {
+	service, sessions, _ := newCoachingTestService()
+	ctx := context.Background()
+
+	own := &models.Session{UserID: testAthleteID, Title: "Marathon plan"}
+	require.NoError(t, sessions.Create(ctx, own))
+	otherAthlete := "00000000-0000-0000-0000-0000000000b0"
+	asCoach := &models.Session{UserID: testAthleteID, Title: "Coaching someone else", AthleteID: &otherAthlete}
+	require.NoError(t, sessions.Create(ctx, asCoach))
+
+	_, err := service.CreateCoachSession(ctx, testCoachID, testAthleteID, "")
+	assert.True(t, errors.Is(err, ErrCoachingAccessDenied))
+
+	link := linkCoach(t, service, models.CoachPermissionViewTraining)
+
+	session, err := service.CreateCoachSession(ctx, testCoachID, testAthleteID, "")
+	require.NoError(t, err)
+	assert.Equal(t, testCoachID, session.UserID)
+	require.NotNil(t, session.AthleteID)
+	assert.Equal(t, testAthleteID, *session.AthleteID)
+	assert.Equal(t, "Coaching Ada Athlete", session.Title)
+
+	_, err = service.ListAthleteSessions(ctx, testCoachID, testAthleteID)
+	assert.True(t, errors.Is(err, ErrCoachingAccessDenied), "reading conversations needs view_sessions")
+
+	require.NoError(t, service.UpdatePermissions(ctx, testAthleteID, link.ID, []string{models.CoachPermissionViewSessions, models.CoachPermissionComment}))
+	list, err := service.ListAthleteSessions(ctx, testCoachID, testAthleteID)
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+	assert.Equal(t, own.ID, list[0].ID)
+
+	_, err = service.GetAthleteSession(ctx, testCoachID, testAthleteID, asCoach.ID)
+	assert.True(t, errors.Is(err, ErrSessionNotFound))
+	_, err = service.GetAthleteSession(ctx, testCoachID, testAthleteID, session.ID)
+	assert.True(t, errors.Is(err, ErrSessionNotFound), "the coach's own session is not the athlete's")
+
+	_, err = service.AddComment(ctx, testCoachID, testAthleteID, nil, "   ")
+	assert.True(t, errors.Is(err, ErrInvalidCoachingRequest))
+	_, err = service.AddComment(ctx, testCoachID, testAthleteID, &asCoach.ID, "Nice")
+	assert.True(t, errors.Is(err, ErrSessionNotFound))
+	comment, err := service.AddComment(ctx, testCoachID, testAthleteID, &own.ID, "Good plan, keep the long runs easy")
+	require.NoError(t, err)
+	assert.Equal(t, testCoachID, comment.CoachID)
+
+	received, err := service.ListComments(ctx, testAthleteID, testAthleteID)
+	require.NoError(t, err)
+	assert.Len(t, received, 1)
+
+	_, err = service.ListComments(ctx, "00000000-0000-0000-0000-0000000000c2", testAthleteID)
+	assert.True(t, errors.Is(err, ErrCoachingAccessDenied))
+}
+
+func TestCoachContextInAIService(t *testing.T) {
+	ai := &aiService{}
+	athlete := &models.User{ID: testAthleteID, FirstName: "Ada", LastName: "Athlete"}
+	coach := &models.User{ID: testCoachID, FirstName: "Cora", LastName: "Coach"}
+
+	msgCtx := &MessageContext{UserID: coach.ID, User: athlete, Coach: coach}
+	prompt := ai.buildEnhancedSystemPrompt(msgCtx)
+	assert.Contains(t, prompt, "You are assisting Cora Coach, the human coach of Ada Athlete")
+	assert.NotContains(t, prompt, "You should create one", "coaches cannot create the athlete's logbook")
+
+	_, err := ai.executeUpdateAthleteLogbook(context.Background(), msgCtx, "Rewritten by the coach")
+	assert.Error(t, err, "the logbook is read-only for coaches")
+}
@@ -0,0 +1,132 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"bodda/internal/config"
+)
+
+// maxVerbatimHistoryMessages mirrors the conversation history limit enforced by validateMessageContext
+const maxVerbatimHistoryMessages = 50
+
+// SessionSummaryStore persists rolling conversation summaries for a session
+type SessionSummaryStore interface {
+	UpdateSummary(ctx context.Context, sessionID string, summary string, summarizedMessageCount int) error
+}
+
+// ConversationCompactor keeps long-running sessions within the model context budget by
+// folding older turns into a stored session summary while keeping recent turns verbatim
+type ConversationCompactor interface {
+	// CompactIfNeeded applies any stored summary to the message context and, when the estimated
+	// context approaches the token budget (or force is set), summarizes older turns.
+	// It returns true when a new summary was generated.
+	CompactIfNeeded(ctx context.Context, msgCtx *MessageContext, force bool) (bool, error)
+}
+
+// conversationCompactor implements the ConversationCompactor interface
+type conversationCompactor struct {
+	config     config.ConversationCompactionConfig
+	summarizer ConversationSummarizer
+	store      SessionSummaryStore
+	estimator  func(msgCtx *MessageContext) int
+}
+
+// NewConversationCompactor creates a new conversation compactor.
+// The store is optional; without it summaries only live for the current request.
+func NewConversationCompactor(cfg config.ConversationCompactionConfig, summarizer ConversationSummarizer, store SessionSummaryStore, estimator func(msgCtx *MessageContext) int) ConversationCompactor {
+	return &conversationCompactor{
+		config:     cfg,
+		summarizer: summarizer,
+		store:      store,
+		estimator:  estimator,
+	}
+}
+
+// CompactIfNeeded applies the stored summary and compacts the conversation when required
+func (cc *conversationCompactor) CompactIfNeeded(ctx context.Context, msgCtx *MessageContext, force bool) (bool, error) {
+	if msgCtx == nil {
+		return false, nil
+	}
+
+	cc.applyStoredSummary(msgCtx)
+
+	if !cc.config.Enabled || cc.summarizer == nil {
+		return false, nil
+	}
+
+	estimatedTokens := cc.estimator(msgCtx)
+	triggerTokens := int(float64(cc.config.MaxContextTokens) * cc.config.TriggerRatio)
+	tooManyMessages := len(msgCtx.ConversationHistory) > maxVerbatimHistoryMessages
+
+	if !force && !tooManyMessages && estimatedTokens < triggerTokens {
+		return false, nil
+	}
+
+	keep := cc.config.KeepRecentMessages
+	if len(msgCtx.ConversationHistory) <= keep {
+		slog.InfoContext(ctx, "Conversation compaction requested but nothing old enough to summarize",
+			"session_id", msgCtx.SessionID,
+			"history_length", len(msgCtx.ConversationHistory),
+			"estimated_tokens", estimatedTokens)
+		return false, nil
+	}
+
+	olderMessages := msgCtx.ConversationHistory[:len(msgCtx.ConversationHistory)-keep]
+	recentMessages := msgCtx.ConversationHistory[len(msgCtx.ConversationHistory)-keep:]
+
+	slog.InfoContext(ctx, "Compacting conversation history",
+		"session_id", msgCtx.SessionID,
+		"estimated_tokens", estimatedTokens,
+		"trigger_tokens", triggerTokens,
+		"forced", force,
+		"summarizing_messages", len(olderMessages),
+		"keeping_messages", len(recentMessages))
+
+	summary, err := cc.summarizer.SummarizeConversation(ctx, msgCtx.ConversationSummary, olderMessages)
+	if err != nil {
+		return false, fmt.Errorf("failed to summarize conversation: %w", err)
+	}
+
+	summarizedCount := msgCtx.SummarizedMessageCount + len(olderMessages)
+
+	if cc.store != nil {
+		if err := cc.store.UpdateSummary(ctx, msgCtx.SessionID, summary, summarizedCount); err != nil {
+			// The summary is still usable for this request, so only log the failure
+			slog.ErrorContext(ctx, "Failed to persist conversation summary",
+				"session_id", msgCtx.SessionID,
+				"error", err)
+		}
+	}
+
+	msgCtx.ConversationSummary = summary
+	msgCtx.SummarizedMessageCount = summarizedCount
+	msgCtx.ConversationHistory = recentMessages
+	msgCtx.historyOffset = summarizedCount
+	// The server-side response chain still carries the full history, so start a fresh one
+	msgCtx.LastResponseID = ""
+
+	slog.InfoContext(ctx, "Conversation compacted",
+		"session_id", msgCtx.SessionID,
+		"summarized_message_count", summarizedCount,
+		"summary_length", len(summary),
+		"estimated_tokens_after", cc.estimator(msgCtx))
+
+	return true, nil
+}
+
+// applyStoredSummary drops the messages already covered by the stored session summary
+func (cc *conversationCompactor) applyStoredSummary(msgCtx *MessageContext) {
+	alreadyApplied := msgCtx.SummarizedMessageCount - msgCtx.historyOffset
+	if alreadyApplied <= 0 {
+		return
+	}
+
+	if alreadyApplied > len(msgCtx.ConversationHistory) {
+		alreadyApplied = len(msgCtx.ConversationHistory)
+	}
+
+	msgCtx.ConversationHistory = msgCtx.ConversationHistory[alreadyApplied:]
+	msgCtx.historyOffset += alreadyApplied
+}
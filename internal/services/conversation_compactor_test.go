@@ -0,0 +1,238 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"bodda/internal/config"
+	"bodda/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeConversationSummarizer records summarization calls for compactor tests
+type fakeConversationSummarizer struct {
+	calls           int
+	previousSummary string
+	messages        []*models.Message
+	err             error
+}
+
+func (f *fakeConversationSummarizer) SummarizeConversation(ctx context.Context, previousSummary string, messages []*models.Message) (string, error) {
+	f.calls++
+	f.previousSummary = previousSummary
+	f.messages = messages
+	if f.err != nil {
+		return "", f.err
+	}
+	return fmt.Sprintf("summary of %d messages", len(messages)), nil
+}
+
+// fakeSessionSummaryStore records persisted summaries for compactor tests
+type fakeSessionSummaryStore struct {
+	sessionID string
+	summary   string
+	count     int
+	err       error
+}
+
+func (f *fakeSessionSummaryStore) UpdateSummary(ctx context.Context, sessionID string, summary string, summarizedMessageCount int) error {
+	f.sessionID = sessionID
+	f.summary = summary
+	f.count = summarizedMessageCount
+	return f.err
+}
+
+func buildCompactorTestHistory(n int) []*models.Message {
+	messages := make([]*models.Message, n)
+	for i := 0; i < n; i++ {
+		role := "user"
+		if i%2 == 1 {
+			role = "assistant"
+		}
+		messages[i] = &models.Message{
+			ID:      fmt.Sprintf("msg-%d", i),
+			Role:    role,
+			Content: fmt.Sprintf("message %d", i),
+		}
+	}
+	return messages
+}
+
+func testCompactionConfig() config.ConversationCompactionConfig {
+	return config.ConversationCompactionConfig{
+		Enabled:            true,
+		MaxContextTokens:   1000,
+		TriggerRatio:       0.8,
+		KeepRecentMessages: 4,
+	}
+}
+
+func fixedEstimator(tokens int) func(*MessageContext) int {
+	return func(*MessageContext) int { return tokens }
+}
+
+func TestConversationCompactor_BelowThreshold(t *testing.T) {
+	summarizer := &fakeConversationSummarizer{}
+	store := &fakeSessionSummaryStore{}
+	compactor := NewConversationCompactor(testCompactionConfig(), summarizer, store, fixedEstimator(100))
+
+	msgCtx := &MessageContext{
+		SessionID:           "session-1",
+		ConversationHistory: buildCompactorTestHistory(10),
+		LastResponseID:      "resp-1",
+	}
+
+	compacted, err := compactor.CompactIfNeeded(context.Background(), msgCtx, false)
+	require.NoError(t, err)
+	assert.False(t, compacted)
+	assert.Equal(t, 0, summarizer.calls)
+	assert.Len(t, msgCtx.ConversationHistory, 10)
+	assert.Equal(t, "resp-1", msgCtx.LastResponseID)
+}
+
+func TestConversationCompactor_CompactsWhenOverBudget(t *testing.T) {
+	summarizer := &fakeConversationSummarizer{}
+	store := &fakeSessionSummaryStore{}
+	compactor := NewConversationCompactor(testCompactionConfig(), summarizer, store, fixedEstimator(900))
+
+	msgCtx := &MessageContext{
+		SessionID:           "session-1",
+		ConversationHistory: buildCompactorTestHistory(10),
+		LastResponseID:      "resp-1",
+	}
+
+	compacted, err := compactor.CompactIfNeeded(context.Background(), msgCtx, false)
+	require.NoError(t, err)
+	assert.True(t, compacted)
+
+	assert.Equal(t, 1, summarizer.calls)
+	assert.Len(t, summarizer.messages, 6)
+	assert.Equal(t, "msg-0", summarizer.messages[0].ID)
+
+	assert.Equal(t, "summary of 6 messages", msgCtx.ConversationSummary)
+	assert.Equal(t, 6, msgCtx.SummarizedMessageCount)
+	require.Len(t, msgCtx.ConversationHistory, 4)
+	assert.Equal(t, "msg-6", msgCtx.ConversationHistory[0].ID)
+	assert.Empty(t, msgCtx.LastResponseID)
+
+	assert.Equal(t, "session-1", store.sessionID)
+	assert.Equal(t, "summary of 6 messages", store.summary)
+	assert.Equal(t, 6, store.count)
+}
+
+func TestConversationCompactor_CompactsWhenTooManyMessages(t *testing.T) {
+	summarizer := &fakeConversationSummarizer{}
+	compactor := NewConversationCompactor(testCompactionConfig(), summarizer, nil, fixedEstimator(10))
+
+	msgCtx := &MessageContext{
+		SessionID:           "session-1",
+		ConversationHistory: buildCompactorTestHistory(maxVerbatimHistoryMessages + 2),
+	}
+
+	compacted, err := compactor.CompactIfNeeded(context.Background(), msgCtx, false)
+	require.NoError(t, err)
+	assert.True(t, compacted)
+	assert.Len(t, msgCtx.ConversationHistory, 4)
+	assert.Equal(t, maxVerbatimHistoryMessages-2, msgCtx.SummarizedMessageCount)
+}
+
+func TestConversationCompactor_AppliesStoredSummary(t *testing.T) {
+	summarizer := &fakeConversationSummarizer{}
+	compactor := NewConversationCompactor(testCompactionConfig(), summarizer, nil, fixedEstimator(100))
+
+	msgCtx := &MessageContext{
+		SessionID:              "session-1",
+		ConversationHistory:    buildCompactorTestHistory(10),
+		ConversationSummary:    "earlier summary",
+		SummarizedMessageCount: 6,
+	}
+
+	compacted, err := compactor.CompactIfNeeded(context.Background(), msgCtx, false)
+	require.NoError(t, err)
+	assert.False(t, compacted)
+	require.Len(t, msgCtx.ConversationHistory, 4)
+	assert.Equal(t, "msg-6", msgCtx.ConversationHistory[0].ID)
+
+	// Applying again must not trim the history a second time
+	_, err = compactor.CompactIfNeeded(context.Background(), msgCtx, false)
+	require.NoError(t, err)
+	assert.Len(t, msgCtx.ConversationHistory, 4)
+}
+
+func TestConversationCompactor_ForcedRollsPreviousSummaryForward(t *testing.T) {
+	summarizer := &fakeConversationSummarizer{}
+	store := &fakeSessionSummaryStore{}
+	compactor := NewConversationCompactor(testCompactionConfig(), summarizer, store, fixedEstimator(10))
+
+	msgCtx := &MessageContext{
+		SessionID:              "session-1",
+		ConversationHistory:    buildCompactorTestHistory(16),
+		ConversationSummary:    "earlier summary",
+		SummarizedMessageCount: 6,
+	}
+
+	compacted, err := compactor.CompactIfNeeded(context.Background(), msgCtx, true)
+	require.NoError(t, err)
+	assert.True(t, compacted)
+
+	assert.Equal(t, "earlier summary", summarizer.previousSummary)
+	require.Len(t, summarizer.messages, 6)
+	assert.Equal(t, "msg-6", summarizer.messages[0].ID)
+	assert.Equal(t, 12, msgCtx.SummarizedMessageCount)
+	assert.Equal(t, 12, store.count)
+	require.Len(t, msgCtx.ConversationHistory, 4)
+	assert.Equal(t, "msg-12", msgCtx.ConversationHistory[0].ID)
+}
+
+func TestConversationCompactor_Disabled(t *testing.T) {
+	cfg := testCompactionConfig()
+	cfg.Enabled = false
+	summarizer := &fakeConversationSummarizer{}
+	compactor := NewConversationCompactor(cfg, summarizer, nil, fixedEstimator(5000))
+
+	msgCtx := &MessageContext{ConversationHistory: buildCompactorTestHistory(10)}
+
+	compacted, err := compactor.CompactIfNeeded(context.Background(), msgCtx, true)
+	require.NoError(t, err)
+	assert.False(t, compacted)
+	assert.Equal(t, 0, summarizer.calls)
+}
+
+func TestConversationCompactor_SummarizerError(t *testing.T) {
+	summarizer := &fakeConversationSummarizer{err: errors.New("model unavailable")}
+	store := &fakeSessionSummaryStore{}
+	compactor := NewConversationCompactor(testCompactionConfig(), summarizer, store, fixedEstimator(900))
+
+	msgCtx := &MessageContext{
+		SessionID:           "session-1",
+		ConversationHistory: buildCompactorTestHistory(10),
+		LastResponseID:      "resp-1",
+	}
+
+	compacted, err := compactor.CompactIfNeeded(context.Background(), msgCtx, false)
+	assert.Error(t, err)
+	assert.False(t, compacted)
+	assert.Len(t, msgCtx.ConversationHistory, 10)
+	assert.Equal(t, "resp-1", msgCtx.LastResponseID)
+	assert.Empty(t, store.sessionID)
+}
+
+func TestConversationCompactor_StoreErrorStillCompacts(t *testing.T) {
+	summarizer := &fakeConversationSummarizer{}
+	store := &fakeSessionSummaryStore{err: errors.New("db down")}
+	compactor := NewConversationCompactor(testCompactionConfig(), summarizer, store, fixedEstimator(900))
+
+	msgCtx := &MessageContext{
+		SessionID:           "session-1",
+		ConversationHistory: buildCompactorTestHistory(10),
+	}
+
+	compacted, err := compactor.CompactIfNeeded(context.Background(), msgCtx, false)
+	require.NoError(t, err)
+	assert.True(t, compacted)
+	assert.Len(t, msgCtx.ConversationHistory, 4)
+}
@@ -47,6 +47,8 @@ func (dfp *derivedFeaturesProcessor) ExtractFeatures(data *StravaStreams, laps [
 		Trends:           []Trend{},
 		Spikes:           []Spike{},
 		SampleData:       []DataPoint{},
+		Aerobic:          CalculateAerobicAnalysis(data),
+		GradeAdjusted:    CalculateGradeAdjustedPace(data),
 	}
 
 	// Extract derived features
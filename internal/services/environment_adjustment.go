@@ -0,0 +1,416 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+)
+
+const (
+	// Endurance performance is best around this air temperature; heat penalties start above it
+	neutralTemperature = 15.0
+	// Heat penalty in percent is heatLinearPenalty·ΔT + heatQuadraticPenalty·ΔT² for ΔT °C above neutral,
+	// which approximates the slowdowns seen in marathon results (about 2.7% at 25°C, 5.5% at 31°C)
+	heatLinearPenalty    = 0.15
+	heatQuadraticPenalty = 0.012
+	// Cardiovascular drift in the heat raises heart rate by roughly one beat per °C above neutral
+	heatHeartRatePerDegree = 1.0
+	// Reduced oxygen availability costs about 4% of aerobic performance per 1000 m above 500 m
+	altitudeThreshold       = 500.0
+	altitudePenaltyPer1000m = 4.0
+	// Differences in adjusted output below this percentage are treated as fully explained by conditions
+	conditionsExplainedTolerance = 1.0
+)
+
+// Temperature sources for an environment adjustment
+const (
+	TemperatureSourceStream   = "stream"
+	TemperatureSourceActivity = "activity"
+)
+
+// conditionsStreamTypes are the streams fetched for an environment analysis
+var conditionsStreamTypes = []string{"time", "velocity_smooth", "heartrate", "watts", "temp", "altitude", "grade_smooth"}
+
+// HeatPenaltyPercent returns the estimated performance loss at an air temperature in °C
+func HeatPenaltyPercent(temperature float64) float64 {
+	excess := temperature - neutralTemperature
+	if excess <= 0 {
+		return 0
+	}
+	return heatLinearPenalty*excess + heatQuadraticPenalty*excess*excess
+}
+
+// AltitudePenaltyPercent returns the estimated aerobic performance loss at an altitude in metres
+func AltitudePenaltyPercent(altitude float64) float64 {
+	if altitude <= altitudeThreshold {
+		return 0
+	}
+	return (altitude - altitudeThreshold) / 1000 * altitudePenaltyPer1000m
+}
+
+// EnvironmentAdjustment estimates how heat and altitude affected an activity and what its output
+// would have been in neutral conditions (15°C near sea level)
+type EnvironmentAdjustment struct {
+	AvgTemperature    *float64 `json:"avg_temperature,omitempty"`
+	MaxTemperature    *float64 `json:"max_temperature,omitempty"`
+	TemperatureSource string   `json:"temperature_source,omitempty"`
+	AvgAltitude       *float64 `json:"avg_altitude,omitempty"`
+
+	HeatPenaltyPercent     float64 `json:"heat_penalty_percent"`
+	AltitudePenaltyPercent float64 `json:"altitude_penalty_percent"`
+	TotalPenaltyPercent    float64 `json:"total_penalty_percent"`
+	// HeartRateRise is the heat-induced heart rate elevation in bpm
+	HeartRateRise float64 `json:"heart_rate_rise"`
+
+	// GradeAdjusted is set when the adjusted speed also corrects for hills
+	GradeAdjusted     bool    `json:"grade_adjusted"`
+	AvgSpeed          float64 `json:"avg_speed,omitempty"`
+	AdjustedSpeed     float64 `json:"adjusted_speed,omitempty"`
+	AvgPower          float64 `json:"avg_power,omitempty"`
+	AdjustedPower     float64 `json:"adjusted_power,omitempty"`
+	AvgHeartRate      float64 `json:"avg_heart_rate,omitempty"`
+	AdjustedHeartRate float64 `json:"adjusted_heart_rate,omitempty"`
+}
+
+// CalculateEnvironmentAdjustment applies the heat and altitude model to each moving sample. Runs use
+// grade-adjusted speed as the base when grade data exists. Without a temperature stream, fallbackTemp
+// (the activity's average temperature) is applied to every sample. Returns nil when neither temperature
+// nor altitude is known or the activity has no moving samples.
+func CalculateEnvironmentAdjustment(streams *StravaStreams, isRun bool, fallbackTemp *float64) *EnvironmentAdjustment {
+	if streams == nil || (len(streams.Temp) == 0 && len(streams.Altitude) == 0 && fallbackTemp == nil) {
+		return nil
+	}
+
+	n := max(len(streams.VelocitySmooth), len(streams.Watts))
+	if n == 0 {
+		return nil
+	}
+
+	var gap []float64
+	if isRun {
+		gap = CalculateGAPStream(streams)
+	}
+
+	adjustment := &EnvironmentAdjustment{GradeAdjusted: len(gap) > 0}
+	var (
+		tempSum, tempMax, altitudeSum                                float64
+		heatSum, altitudeSumPenalty, hrRiseSum                       float64
+		speedSum, adjustedSpeedSum                                   float64
+		powerSum, adjustedPowerSum, hrSum                            float64
+		samples, speedSamples, tempSamples, altitudeSamples, hrCount int
+	)
+	tempMax = math.Inf(-1)
+
+	for i := 0; i < n; i++ {
+		speed := 0.0
+		if i < len(streams.VelocitySmooth) {
+			speed = streams.VelocitySmooth[i]
+		}
+		power := 0.0
+		if i < len(streams.Watts) {
+			power = float64(streams.Watts[i])
+		}
+		if speed < minMovingSpeed && power <= 0 {
+			continue
+		}
+		samples++
+
+		heat, hrRise := 0.0, 0.0
+		if temperature, ok := sampleTemperature(streams, i, fallbackTemp); ok {
+			heat = HeatPenaltyPercent(temperature)
+			hrRise = math.Max(0, temperature-neutralTemperature) * heatHeartRatePerDegree
+			tempSum += temperature
+			tempMax = math.Max(tempMax, temperature)
+			tempSamples++
+		}
+		altitude := 0.0
+		if i < len(streams.Altitude) {
+			altitude = AltitudePenaltyPercent(streams.Altitude[i])
+			altitudeSum += streams.Altitude[i]
+			altitudeSamples++
+		}
+		factor := 1 + (heat+altitude)/100
+
+		heatSum += heat
+		altitudeSumPenalty += altitude
+		hrRiseSum += hrRise
+
+		if speed >= minMovingSpeed {
+			base := speed
+			if i < len(gap) {
+				base = gap[i]
+			}
+			speedSum += speed
+			adjustedSpeedSum += base * factor
+			speedSamples++
+		}
+		powerSum += power
+		adjustedPowerSum += power * factor
+
+		if i < len(streams.Heartrate) && streams.Heartrate[i] > 0 {
+			hrSum += float64(streams.Heartrate[i])
+			hrCount++
+		}
+	}
+
+	if samples == 0 || (tempSamples == 0 && altitudeSamples == 0) {
+		return nil
+	}
+
+	if tempSamples > 0 {
+		avg, peak := roundTo(tempSum/float64(tempSamples), 1), roundTo(tempMax, 1)
+		adjustment.AvgTemperature, adjustment.MaxTemperature = &avg, &peak
+		adjustment.TemperatureSource = TemperatureSourceStream
+		if len(streams.Temp) == 0 {
+			adjustment.TemperatureSource = TemperatureSourceActivity
+		}
+	}
+	if altitudeSamples > 0 {
+		avg := roundTo(altitudeSum/float64(altitudeSamples), 0)
+		adjustment.AvgAltitude = &avg
+	}
+
+	count := float64(samples)
+	adjustment.HeatPenaltyPercent = roundTo(heatSum/count, 2)
+	adjustment.AltitudePenaltyPercent = roundTo(altitudeSumPenalty/count, 2)
+	adjustment.TotalPenaltyPercent = roundTo((heatSum+altitudeSumPenalty)/count, 2)
+	adjustment.HeartRateRise = roundTo(hrRiseSum/count, 1)
+
+	if speedSamples > 0 {
+		adjustment.AvgSpeed = roundTo(speedSum/float64(speedSamples), 3)
+		adjustment.AdjustedSpeed = roundTo(adjustedSpeedSum/float64(speedSamples), 3)
+	}
+	if powerSum > 0 {
+		adjustment.AvgPower = roundTo(powerSum/count, 1)
+		adjustment.AdjustedPower = roundTo(adjustedPowerSum/count, 1)
+	}
+	if hrCount > 0 {
+		adjustment.AvgHeartRate = roundTo(hrSum/float64(hrCount), 1)
+		adjustment.AdjustedHeartRate = roundTo(adjustment.AvgHeartRate-adjustment.HeartRateRise, 1)
+	}
+	return adjustment
+}
+
+// sampleTemperature returns the temperature at sample i from the stream, or the fallback when there is no stream
+func sampleTemperature(streams *StravaStreams, i int, fallbackTemp *float64) (float64, bool) {
+	if len(streams.Temp) > 0 {
+		if i < len(streams.Temp) {
+			return float64(streams.Temp[i]), true
+		}
+		return 0, false
+	}
+	if fallbackTemp != nil {
+		return *fallbackTemp, true
+	}
+	return 0, false
+}
+
+// usesPower reports whether power is the primary output of the adjusted activity
+func (a *EnvironmentAdjustment) usesPower() bool {
+	return a.AvgPower > 0
+}
+
+// ActivityConditionsRequest selects the activity to analyze and an optional reference to compare against
+type ActivityConditionsRequest struct {
+	ActivityID          int64 `json:"activity_id"`
+	ReferenceActivityID int64 `json:"reference_activity_id"`
+}
+
+// normalize validates the activity IDs
+func (r *ActivityConditionsRequest) normalize() error {
+	if r.ActivityID <= 0 {
+		return fmt.Errorf("activity_id must be a positive Strava activity ID")
+	}
+	if r.ReferenceActivityID < 0 {
+		return fmt.Errorf("invalid reference_activity_id %d", r.ReferenceActivityID)
+	}
+	if r.ReferenceActivityID == r.ActivityID {
+		return fmt.Errorf("reference_activity_id must differ from activity_id")
+	}
+	return nil
+}
+
+// ActivityConditions is the environment analysis of one activity
+type ActivityConditions struct {
+	ActivityID int64                  `json:"activity_id"`
+	Name       string                 `json:"name"`
+	StartDate  string                 `json:"start_date"`
+	IsRun      bool                   `json:"is_run"`
+	Adjustment *EnvironmentAdjustment `json:"adjustment,omitempty"`
+}
+
+// ConditionsComparison explains an output difference between two activities by their conditions.
+// Deficits are percentages of the reference output; positive means the activity was slower or weaker.
+type ConditionsComparison struct {
+	Basis              string  `json:"basis"`
+	ActualDeficit      float64 `json:"actual_deficit"`
+	AdjustedDeficit    float64 `json:"adjusted_deficit"`
+	ExplainedByPercent float64 `json:"explained_by_percent"`
+}
+
+// CompareConditions compares actual and conditions-adjusted output of an activity against a reference.
+// Power is compared when both have power, otherwise speed. Returns nil when no common output exists.
+func CompareConditions(activity, reference *EnvironmentAdjustment) *ConditionsComparison {
+	if activity == nil || reference == nil {
+		return nil
+	}
+
+	comparison := &ConditionsComparison{}
+	var actual, adjusted, refActual, refAdjusted float64
+	switch {
+	case activity.usesPower() && reference.usesPower():
+		comparison.Basis = "power"
+		actual, adjusted, refActual, refAdjusted = activity.AvgPower, activity.AdjustedPower, reference.AvgPower, reference.AdjustedPower
+	case activity.AvgSpeed > 0 && reference.AvgSpeed > 0:
+		comparison.Basis = "speed"
+		actual, adjusted, refActual, refAdjusted = activity.AvgSpeed, activity.AdjustedSpeed, reference.AvgSpeed, reference.AdjustedSpeed
+	default:
+		return nil
+	}
+
+	comparison.ActualDeficit = roundTo((1-actual/refActual)*100, 1)
+	comparison.AdjustedDeficit = roundTo((1-adjusted/refAdjusted)*100, 1)
+	if comparison.ActualDeficit > 0 {
+		explained := (comparison.ActualDeficit - comparison.AdjustedDeficit) / comparison.ActualDeficit * 100
+		comparison.ExplainedByPercent = roundTo(math.Max(0, math.Min(100, explained)), 0)
+	}
+	return comparison
+}
+
+// formatConditionsAnalysis renders the environment analysis and optional comparison as markdown
+func formatConditionsAnalysis(activity ActivityConditions, reference *ActivityConditions, comparison *ConditionsComparison) string {
+	var b strings.Builder
+	b.WriteString("# Conditions-Adjusted Performance\n\n")
+	writeActivityConditions(&b, activity)
+	if reference != nil {
+		writeActivityConditions(&b, *reference)
+	}
+
+	if reference != nil {
+		b.WriteString("## Comparison\n\n")
+		if comparison == nil {
+			b.WriteString("A comparison needs speed or power plus temperature or altitude data for both activities.\n\n")
+		} else {
+			b.WriteString(fmt.Sprintf("- Actual %s: %s vs reference\n", comparison.Basis, formatDeficit(comparison.ActualDeficit)))
+			b.WriteString(fmt.Sprintf("- Conditions-adjusted %s: %s vs reference\n", comparison.Basis, formatDeficit(comparison.AdjustedDeficit)))
+			switch {
+			case comparison.ActualDeficit <= 0:
+				b.WriteString("- Verdict: no deficit to explain; the activity matched or beat the reference\n")
+			case comparison.AdjustedDeficit < conditionsExplainedTolerance:
+				b.WriteString("- Verdict: the deficit is fully explained by conditions; fitness was unchanged\n")
+			default:
+				b.WriteString(fmt.Sprintf("- Verdict: conditions explain about %.0f%% of the deficit; the rest reflects fatigue, pacing or fitness\n", comparison.ExplainedByPercent))
+			}
+			b.WriteString("\n")
+		}
+	}
+
+	b.WriteString("Model: heat costs about 0.15% per °C above 15°C, rising steeply (≈2.7% at 25°C, ≈5.5% at 31°C) and adds about 1 bpm per °C; altitude costs about 4% per 1000 m above 500 m. Estimates ignore humidity, wind and sun, and Strava's temperature sensor reads high in direct sun.\n")
+	return b.String()
+}
+
+// writeActivityConditions renders one activity's conditions and adjusted output
+func writeActivityConditions(b *strings.Builder, activity ActivityConditions) {
+	b.WriteString(fmt.Sprintf("## [%s](https://www.strava.com/activities/%d)", activity.Name, activity.ActivityID))
+	if activity.StartDate != "" {
+		b.WriteString(" – " + activity.StartDate)
+	}
+	b.WriteString("\n\n")
+
+	adj := activity.Adjustment
+	if adj == nil {
+		b.WriteString("No temperature or altitude data is available for this activity.\n\n")
+		return
+	}
+
+	if adj.AvgTemperature != nil {
+		source := ""
+		if adj.TemperatureSource == TemperatureSourceActivity {
+			source = ", activity average"
+		}
+		b.WriteString(fmt.Sprintf("- Temperature: %.1f°C average, %.1f°C max%s\n", *adj.AvgTemperature, *adj.MaxTemperature, source))
+	}
+	if adj.AvgAltitude != nil {
+		b.WriteString(fmt.Sprintf("- Altitude: %.0f m average\n", *adj.AvgAltitude))
+	}
+	b.WriteString(fmt.Sprintf("- Estimated penalty: %.1f%% (heat %.1f%%, altitude %.1f%%)\n", adj.TotalPenaltyPercent, adj.HeatPenaltyPercent, adj.AltitudePenaltyPercent))
+
+	if adj.AvgSpeed > 0 {
+		label := "Neutral-conditions equivalent"
+		if adj.GradeAdjusted {
+			label += " (also grade-adjusted)"
+		}
+		b.WriteString(fmt.Sprintf("- %s: %s (actual %s)\n", label, formatSpeedValue(adj.AdjustedSpeed, activity.IsRun), formatSpeedValue(adj.AvgSpeed, activity.IsRun)))
+	}
+	if adj.AvgPower > 0 {
+		b.WriteString(fmt.Sprintf("- Neutral-conditions power: %.0f W (actual %.0f W)\n", adj.AdjustedPower, adj.AvgPower))
+	}
+	if adj.AvgHeartRate > 0 && adj.HeartRateRise > 0 {
+		b.WriteString(fmt.Sprintf("- Heart rate: %.0f bpm average, about %.0f bpm of it from heat (≈%.0f bpm in neutral conditions)\n", adj.AvgHeartRate, adj.HeartRateRise, adj.AdjustedHeartRate))
+	}
+	b.WriteString("\n")
+}
+
+// formatDeficit describes a percentage deficit relative to the reference
+func formatDeficit(deficit float64) string {
+	switch {
+	case deficit > 0:
+		return fmt.Sprintf("%.1f%% lower", deficit)
+	case deficit < 0:
+		return fmt.Sprintf("%.1f%% higher", -deficit)
+	default:
+		return "equal"
+	}
+}
+
+// fetchActivityConditions loads an activity's details and streams and applies the environment model
+func (s *aiService) fetchActivityConditions(ctx context.Context, msgCtx *MessageContext, activityID int64) (ActivityConditions, error) {
+	detail, err := s.stravaService.GetActivityDetail(ctx, msgCtx.User, activityID)
+	if err != nil {
+		return ActivityConditions{}, s.handleStravaError(err, fmt.Sprintf("activity %d details", activityID))
+	}
+	streams, err := s.stravaService.GetActivityStreams(ctx, msgCtx.User, activityID, conditionsStreamTypes, "medium")
+	if err != nil {
+		return ActivityConditions{}, s.handleStravaError(err, fmt.Sprintf("activity %d streams", activityID))
+	}
+
+	var fallbackTemp *float64
+	if detail.AverageTemp != 0 {
+		fallbackTemp = &detail.AverageTemp
+	}
+	isRun := isRunActivity(detail.SportType) || isRunActivity(detail.Type)
+	return ActivityConditions{
+		ActivityID: activityID,
+		Name:       detail.Name,
+		StartDate:  detail.StartDateLocal,
+		IsRun:      isRun,
+		Adjustment: CalculateEnvironmentAdjustment(streams, isRun, fallbackTemp),
+	}, nil
+}
+
+func (s *aiService) executeAnalyzeActivityConditions(ctx context.Context, msgCtx *MessageContext, req ActivityConditionsRequest) (string, error) {
+	if msgCtx == nil || msgCtx.User == nil {
+		return "", fmt.Errorf("user context is required")
+	}
+	if err := req.normalize(); err != nil {
+		return "", err
+	}
+
+	activity, err := s.fetchActivityConditions(ctx, msgCtx, req.ActivityID)
+	if err != nil {
+		return "", err
+	}
+	if req.ReferenceActivityID == 0 {
+		return formatConditionsAnalysis(activity, nil, nil), nil
+	}
+
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	reference, err := s.fetchActivityConditions(ctx, msgCtx, req.ReferenceActivityID)
+	if err != nil {
+		return "", err
+	}
+	return formatConditionsAnalysis(activity, &reference, CompareConditions(activity.Adjustment, reference.Adjustment)), nil
+}
@@ -0,0 +1,153 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"bodda/internal/config"
+	"bodda/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// steadyConditionsStreams builds a steady run at constant temperature and altitude
+func steadyConditionsStreams(points int, speed float64, hr, temp int, altitude float64) *StravaStreams {
+	streams := &StravaStreams{}
+	for i := 0; i < points; i++ {
+		streams.Time = append(streams.Time, i)
+		streams.VelocitySmooth = append(streams.VelocitySmooth, speed)
+		streams.Heartrate = append(streams.Heartrate, hr)
+		streams.Temp = append(streams.Temp, temp)
+		streams.Altitude = append(streams.Altitude, altitude)
+	}
+	return streams
+}
+
+func TestEnvironmentPenalties(t *testing.T) {
+	assert.Equal(t, 0.0, HeatPenaltyPercent(10))
+	assert.Equal(t, 0.0, HeatPenaltyPercent(neutralTemperature))
+	assert.InDelta(t, 2.7, HeatPenaltyPercent(25), 0.01)
+	assert.InDelta(t, 5.47, HeatPenaltyPercent(31), 0.01)
+
+	assert.Equal(t, 0.0, AltitudePenaltyPercent(300))
+	assert.InDelta(t, 6.0, AltitudePenaltyPercent(2000), 0.001)
+}
+
+func TestCalculateEnvironmentAdjustment(t *testing.T) {
+	hot := CalculateEnvironmentAdjustment(steadyConditionsStreams(600, 3.0, 160, 31, 100), true, nil)
+	require.NotNil(t, hot)
+	assert.Equal(t, 31.0, *hot.AvgTemperature)
+	assert.Equal(t, TemperatureSourceStream, hot.TemperatureSource)
+	assert.InDelta(t, 5.47, hot.HeatPenaltyPercent, 0.01)
+	assert.Equal(t, 0.0, hot.AltitudePenaltyPercent)
+	assert.InDelta(t, 3.164, hot.AdjustedSpeed, 0.001)
+	assert.Equal(t, 16.0, hot.HeartRateRise)
+	assert.Equal(t, 144.0, hot.AdjustedHeartRate)
+	assert.False(t, hot.GradeAdjusted)
+
+	high := CalculateEnvironmentAdjustment(steadyConditionsStreams(600, 3.0, 150, 10, 2000), true, nil)
+	require.NotNil(t, high)
+	assert.Equal(t, 0.0, high.HeatPenaltyPercent)
+	assert.InDelta(t, 6.0, high.TotalPenaltyPercent, 0.001)
+	assert.Equal(t, 0.0, high.HeartRateRise)
+
+	// Without a temperature stream the activity average is applied throughout
+	streams := steadyConditionsStreams(600, 3.0, 150, 0, 0)
+	streams.Temp, streams.Altitude = nil, nil
+	assert.Nil(t, CalculateEnvironmentAdjustment(streams, true, nil), "no conditions data")
+	averageTemp := 25.0
+	fallback := CalculateEnvironmentAdjustment(streams, true, &averageTemp)
+	require.NotNil(t, fallback)
+	assert.Equal(t, TemperatureSourceActivity, fallback.TemperatureSource)
+	assert.InDelta(t, 2.7, fallback.HeatPenaltyPercent, 0.01)
+}
+
+func TestCalculateEnvironmentAdjustment_PowerAndGrade(t *testing.T) {
+	ride := steadyConditionsStreams(600, 8.0, 140, 25, 0)
+	for range ride.Time {
+		ride.Watts = append(ride.Watts, 200)
+	}
+	adjustment := CalculateEnvironmentAdjustment(ride, false, nil)
+	require.NotNil(t, adjustment)
+	assert.Equal(t, 200.0, adjustment.AvgPower)
+	assert.InDelta(t, 205.4, adjustment.AdjustedPower, 0.05)
+
+	climb := steadyConditionsStreams(600, 2.0, 150, 15, 0)
+	for range climb.Time {
+		climb.GradeSmooth = append(climb.GradeSmooth, 10)
+	}
+	graded := CalculateEnvironmentAdjustment(climb, true, nil)
+	require.NotNil(t, graded)
+	assert.True(t, graded.GradeAdjusted)
+	assert.InDelta(t, 3.32, graded.AdjustedSpeed, 0.01, "neutral temperature leaves only the grade adjustment")
+}
+
+func TestCompareConditions(t *testing.T) {
+	reference := CalculateEnvironmentAdjustment(steadyConditionsStreams(600, 3.0, 150, 15, 100), true, nil)
+	hot := CalculateEnvironmentAdjustment(steadyConditionsStreams(600, 2.85, 158, 31, 100), true, nil)
+
+	comparison := CompareConditions(hot, reference)
+	require.NotNil(t, comparison)
+	assert.Equal(t, "speed", comparison.Basis)
+	assert.Equal(t, 5.0, comparison.ActualDeficit)
+	assert.InDelta(t, -0.2, comparison.AdjustedDeficit, 0.1)
+	assert.Equal(t, 100.0, comparison.ExplainedByPercent)
+
+	output := formatConditionsAnalysis(
+		ActivityConditions{ActivityID: 2, Name: "Hot tempo", IsRun: true, Adjustment: hot},
+		&ActivityConditions{ActivityID: 1, Name: "Cool tempo", IsRun: true, Adjustment: reference},
+		comparison,
+	)
+	assert.Contains(t, output, "- Temperature: 31.0°C average, 31.0°C max")
+	assert.Contains(t, output, "- Actual speed: 5.0% lower vs reference")
+	assert.Contains(t, output, "fully explained by conditions")
+
+	slow := CalculateEnvironmentAdjustment(steadyConditionsStreams(600, 2.7, 158, 25, 100), true, nil)
+	partial := CompareConditions(slow, reference)
+	require.NotNil(t, partial)
+	assert.Equal(t, 10.0, partial.ActualDeficit)
+	assert.InDelta(t, 24, partial.ExplainedByPercent, 1)
+
+	assert.Nil(t, CompareConditions(hot, nil))
+}
+
+func TestAnalyzeActivityConditionsTool(t *testing.T) {
+	strava := &conditionsStravaService{streams: map[int64]*StravaStreams{
+		1: steadyConditionsStreams(600, 3.0, 150, 15, 100),
+		2: steadyConditionsStreams(600, 2.85, 158, 31, 100),
+	}}
+	aiService := NewAIService(&config.Config{OpenAIAPIKey: "test-key"}, strava, &mockLogbookServiceForToolExecutor{}, &MockSessionRepositoryForToolExecutor{}, NewToolRegistry())
+	executor := NewToolExecutor(aiService, NewToolRegistry())
+
+	msgCtx := &MessageContext{
+		UserID: "test-user",
+		User:   &models.User{ID: "test-user", AccessToken: "test-token"},
+	}
+
+	result, err := executor.ExecuteTool(context.Background(), "analyze-activity-conditions", map[string]interface{}{
+		"activity_id":           float64(2),
+		"reference_activity_id": float64(1),
+	}, msgCtx)
+	require.NoError(t, err)
+	require.True(t, result.Success, result.Error)
+	assert.Contains(t, result.Data, "(https://www.strava.com/activities/2)")
+	assert.Contains(t, result.Data, "fully explained by conditions")
+
+	result, err = executor.ExecuteTool(context.Background(), "analyze-activity-conditions", map[string]interface{}{
+		"activity_id":           float64(2),
+		"reference_activity_id": float64(2),
+	}, msgCtx)
+	require.NoError(t, err)
+	assert.False(t, result.Success)
+}
+
+// conditionsStravaService returns fixed streams per activity
+type conditionsStravaService struct {
+	mockStravaServiceForToolExecutor
+	streams map[int64]*StravaStreams
+}
+
+func (m *conditionsStravaService) GetActivityStreams(ctx context.Context, user *models.User, activityID int64, streamTypes []string, resolution string) (*StravaStreams, error) {
+	return m.streams[activityID], nil
+}
@@ -79,7 +79,7 @@ func TestStravaServiceErrorHandling(t *testing.T) {
 				RefreshToken: "test_refresh_token",
 				TokenExpiry:  time.Now().Add(time.Hour),
 			}
-			_, err := service.GetAthleteProfile(testUser)
+			_, err := service.GetAthleteProfile(context.Background(), testUser)
 			if !errors.Is(err, tt.expectedError) {
 				t.Errorf("Expected error %v, got %v", tt.expectedError, err)
 			}
@@ -259,31 +259,31 @@ func TestRateLimiterErrorHandling(t *testing.T) {
 
 type mockStravaServiceBasic struct{}
 
-func (m *mockStravaServiceBasic) GetAthleteProfile(user *models.User) (*StravaAthleteWithZones, error) {
+func (m *mockStravaServiceBasic) GetAthleteProfile(ctx context.Context, user *models.User) (*StravaAthleteWithZones, error) {
 	return nil, ErrTokenExpired
 }
 
-func (m *mockStravaServiceBasic) GetAthleteZones(user *models.User) (*StravaAthleteZones, error) {
+func (m *mockStravaServiceBasic) GetAthleteZones(ctx context.Context, user *models.User) (*StravaAthleteZones, error) {
 	return nil, ErrTokenExpired
 }
 
-func (m *mockStravaServiceBasic) GetActivities(user *models.User, params ActivityParams) ([]*StravaActivity, error) {
+func (m *mockStravaServiceBasic) GetActivities(ctx context.Context, user *models.User, params ActivityParams) ([]*StravaActivity, error) {
 	return nil, ErrRateLimitExceeded
 }
 
-func (m *mockStravaServiceBasic) GetActivityDetail(user *models.User, activityID int64) (*StravaActivityDetail, error) {
+func (m *mockStravaServiceBasic) GetActivityDetail(ctx context.Context, user *models.User, activityID int64) (*StravaActivityDetail, error) {
 	return nil, ErrActivityNotFound
 }
 
-func (m *mockStravaServiceBasic) GetActivityDetailWithZones(user *models.User, activityID int64) (*StravaActivityDetailWithZones, error) {
+func (m *mockStravaServiceBasic) GetActivityDetailWithZones(ctx context.Context, user *models.User, activityID int64) (*StravaActivityDetailWithZones, error) {
 	return nil, ErrActivityNotFound
 }
 
-func (m *mockStravaServiceBasic) GetActivityStreams(user *models.User, activityID int64, streamTypes []string, resolution string) (*StravaStreams, error) {
+func (m *mockStravaServiceBasic) GetActivityStreams(ctx context.Context, user *models.User, activityID int64, streamTypes []string, resolution string) (*StravaStreams, error) {
 	return nil, ErrServiceUnavailable
 }
 
-func (m *mockStravaServiceBasic) GetActivityZones(user *models.User, activityID int64) (*StravaActivityZones, error) {
+func (m *mockStravaServiceBasic) GetActivityZones(ctx context.Context, user *models.User, activityID int64) (*StravaActivityZones, error) {
 	return nil, ErrActivityNotFound
 }
 
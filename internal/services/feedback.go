@@ -0,0 +1,467 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"bodda/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// ErrInvalidFeedback is returned when a rating, reason tag, comment or export filter fails validation
+var ErrInvalidFeedback = errors.New("invalid feedback")
+
+// ErrFeedbackNotFound is returned when removing a rating the user never gave
+var ErrFeedbackNotFound = errors.New("feedback not found")
+
+const (
+	maxFeedbackReasons       = 5
+	maxFeedbackCommentLength = 1000
+
+	defaultFeedbackDays = 30
+	maxFeedbackDays     = 366
+
+	// Exports and reports read at most this many ratings, oldest first
+	maxFeedbackExportRows = 5000
+	maxFeedbackReportRows = 50000
+
+	// Conversations of exported replies are loaded this many at a time
+	feedbackExportBatchSize = 100
+)
+
+// Tool usage patterns for replies without tool calls and replies from before generations were recorded
+const (
+	noToolsPattern        = "none"
+	unrecordedToolPattern = "unrecorded"
+)
+
+// FeedbackReasons are the reason tags a rating can carry
+var FeedbackReasons = []string{
+	"accurate",
+	"actionable",
+	"personalized",
+	"clear",
+	"inaccurate",
+	"wrong_data",
+	"not_personalized",
+	"too_long",
+	"too_vague",
+	"unsafe_advice",
+	"other",
+}
+
+// FeedbackStore persists ratings and the generation details of assistant replies
+type FeedbackStore interface {
+	SetGeneration(ctx context.Context, messageID string, generation *models.MessageGeneration) error
+	Upsert(ctx context.Context, feedback *models.MessageFeedback) (bool, error)
+	Delete(ctx context.Context, messageID, userID string) (bool, error)
+	ListRated(ctx context.Context, from, to time.Time, rating string, limit int) ([]*models.MessageFeedback, error)
+	GetConversations(ctx context.Context, messageIDs []string) (map[string][]*models.Message, error)
+}
+
+// FeedbackInput is a rating submitted for an assistant reply
+type FeedbackInput struct {
+	Rating  string   `json:"rating"`
+	Reasons []string `json:"reasons"`
+	Comment string   `json:"comment"`
+}
+
+// FeedbackTurn is one message of an exported conversation
+type FeedbackTurn struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// FeedbackExample is one line of the evaluation export: a rated reply with the conversation that
+// led to it. Users are left out; the session ID only groups examples from the same conversation.
+type FeedbackExample struct {
+	MessageID         string                  `json:"message_id"`
+	SessionID         string                  `json:"session_id"`
+	Rating            string                  `json:"rating"`
+	Reasons           []string                `json:"reasons"`
+	Comment           string                  `json:"comment,omitempty"`
+	RatedAt           time.Time               `json:"rated_at"`
+	Model             string                  `json:"model,omitempty"`
+	PromptVersion     string                  `json:"prompt_version,omitempty"`
+	SummaryInContext  bool                    `json:"summary_in_context,omitempty"`
+	CoachContext      bool                    `json:"coach_context,omitempty"`
+	InjuryRiskWarning bool                    `json:"injury_risk_warning,omitempty"`
+	Rounds            int                     `json:"rounds,omitempty"`
+	StopReason        string                  `json:"stop_reason,omitempty"`
+	ToolInvocations   []models.ToolInvocation `json:"tool_invocations"`
+	Conversation      []FeedbackTurn          `json:"conversation"` // Ends with the rated reply
+}
+
+// FeedbackRate counts ratings and the share that were positive
+type FeedbackRate struct {
+	Rated       int     `json:"rated"`
+	Up          int     `json:"up"`
+	Down        int     `json:"down"`
+	HelpfulRate float64 `json:"helpful_rate"` // Up / Rated, 0 when nothing was rated
+}
+
+// ToolPatternFeedback is the feedback on replies that used the same set of tools
+type ToolPatternFeedback struct {
+	Pattern string   `json:"pattern"` // Tool names joined with "+", "none" or "unrecorded"
+	Tools   []string `json:"tools"`
+	FeedbackRate
+	AverageToolCalls float64 `json:"average_tool_calls"`
+}
+
+// FeedbackReasonCount is how often a reason tag was given with a rating
+type FeedbackReasonCount struct {
+	Reason string `json:"reason"`
+	Rating string `json:"rating"`
+	Count  int    `json:"count"`
+}
+
+// FeedbackReport summarizes feedback for administrators
+type FeedbackReport struct {
+	From          time.Time              `json:"from"`
+	To            time.Time              `json:"to"`
+	Totals        FeedbackRate           `json:"totals"`
+	ByToolPattern []*ToolPatternFeedback `json:"by_tool_pattern"`
+	Reasons       []*FeedbackReasonCount `json:"reasons"`
+	Truncated     bool                   `json:"truncated"` // More ratings than the report reads
+}
+
+// FeedbackExport holds the rated replies selected for an evaluation export
+type FeedbackExport struct {
+	Truncated bool // More replies were rated than an export holds; the newest are left out
+	rated     []*models.MessageFeedback
+}
+
+// FeedbackService collects ratings of assistant replies and turns them into evaluation data
+type FeedbackService interface {
+	// RecordGeneration stores the model and tool calls that produced an assistant reply
+	RecordGeneration(ctx context.Context, messageID string, generation *models.MessageGeneration) error
+	// SubmitFeedback rates an assistant reply in the session, replacing the user's earlier rating
+	SubmitFeedback(ctx context.Context, userID, sessionID, messageID string, input FeedbackInput) (*models.MessageFeedback, error)
+	// DeleteFeedback removes the user's rating of a reply
+	DeleteFeedback(ctx context.Context, userID, messageID string) error
+	// PrepareExport selects the replies rated in the last days, optionally only those with the
+	// given rating, so callers can report truncation before ExportRated writes anything
+	PrepareExport(ctx context.Context, days int, rating string) (*FeedbackExport, error)
+	// ExportRated writes the replies of a prepared export as JSON lines and returns how many were written
+	ExportRated(ctx context.Context, w io.Writer, export *FeedbackExport) (int, error)
+	// GetReport returns feedback rates for the last days by tool usage pattern
+	GetReport(ctx context.Context, days int) (*FeedbackReport, error)
+}
+
+type feedbackService struct {
+	store FeedbackStore
+	now   func() time.Time
+}
+
+// NewFeedbackService creates a new feedback service
+func NewFeedbackService(store FeedbackStore) FeedbackService {
+	return &feedbackService{
+		store: store,
+		now:   time.Now,
+	}
+}
+
+func (f *feedbackService) RecordGeneration(ctx context.Context, messageID string, generation *models.MessageGeneration) error {
+	if generation == nil || generation.Model == "" {
+		return nil
+	}
+	if generation.ToolInvocations == nil {
+		generation.ToolInvocations = []models.ToolInvocation{}
+	}
+
+	return f.store.SetGeneration(ctx, messageID, generation)
+}
+
+func (f *feedbackService) SubmitFeedback(ctx context.Context, userID, sessionID, messageID string, input FeedbackInput) (*models.MessageFeedback, error) {
+	if _, err := uuid.Parse(messageID); err != nil {
+		return nil, ErrMessageNotFound
+	}
+
+	feedback, err := validateFeedback(input)
+	if err != nil {
+		return nil, err
+	}
+	feedback.MessageID = messageID
+	feedback.SessionID = sessionID
+	feedback.UserID = userID
+
+	stored, err := f.store.Upsert(ctx, feedback)
+	if err != nil {
+		return nil, err
+	}
+	if !stored {
+		// Only assistant replies in the session can be rated
+		return nil, ErrMessageNotFound
+	}
+
+	return feedback, nil
+}
+
+func (f *feedbackService) DeleteFeedback(ctx context.Context, userID, messageID string) error {
+	if _, err := uuid.Parse(messageID); err != nil {
+		return ErrFeedbackNotFound
+	}
+
+	deleted, err := f.store.Delete(ctx, messageID, userID)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return ErrFeedbackNotFound
+	}
+
+	return nil
+}
+
+func (f *feedbackService) PrepareExport(ctx context.Context, days int, rating string) (*FeedbackExport, error) {
+	if rating != "" && rating != models.FeedbackUp && rating != models.FeedbackDown {
+		return nil, fmt.Errorf("%w: rating must be %q or %q", ErrInvalidFeedback, models.FeedbackUp, models.FeedbackDown)
+	}
+
+	// Read one row past the limit to tell a full export from a truncated one
+	from, to := f.feedbackPeriod(days)
+	rated, err := f.store.ListRated(ctx, from, to, rating, maxFeedbackExportRows+1)
+	if err != nil {
+		return nil, err
+	}
+
+	export := &FeedbackExport{rated: rated}
+	if len(rated) > maxFeedbackExportRows {
+		export.rated = rated[:maxFeedbackExportRows]
+		export.Truncated = true
+	}
+	return export, nil
+}
+
+func (f *feedbackService) ExportRated(ctx context.Context, w io.Writer, export *FeedbackExport) (int, error) {
+	encoder := json.NewEncoder(w)
+	written := 0
+	for start := 0; start < len(export.rated); start += feedbackExportBatchSize {
+		batch := export.rated[start:min(start+feedbackExportBatchSize, len(export.rated))]
+
+		messageIDs := make([]string, len(batch))
+		for i, feedback := range batch {
+			messageIDs[i] = feedback.MessageID
+		}
+		conversations, err := f.store.GetConversations(ctx, messageIDs)
+		if err != nil {
+			return written, err
+		}
+
+		for _, feedback := range batch {
+			if err := encoder.Encode(buildFeedbackExample(feedback, conversations[feedback.MessageID])); err != nil {
+				return written, fmt.Errorf("failed to write feedback export: %w", err)
+			}
+			written++
+		}
+	}
+
+	return written, nil
+}
+
+func (f *feedbackService) GetReport(ctx context.Context, days int) (*FeedbackReport, error) {
+	from, to := f.feedbackPeriod(days)
+	rated, err := f.store.ListRated(ctx, from, to, "", maxFeedbackReportRows)
+	if err != nil {
+		return nil, err
+	}
+
+	report := buildFeedbackReport(rated)
+	report.From = from
+	report.To = to
+	report.Truncated = len(rated) >= maxFeedbackReportRows
+	return report, nil
+}
+
+// feedbackPeriod returns the UTC days covering the last days, ending with today
+func (f *feedbackService) feedbackPeriod(days int) (time.Time, time.Time) {
+	if days <= 0 {
+		days = defaultFeedbackDays
+	}
+	if days > maxFeedbackDays {
+		days = maxFeedbackDays
+	}
+
+	dayStart, dayEnd := dayBounds(f.now())
+	return dayStart.AddDate(0, 0, -(days - 1)), dayEnd
+}
+
+// validateFeedback checks a submitted rating and returns it with normalized reasons and comment
+func validateFeedback(input FeedbackInput) (*models.MessageFeedback, error) {
+	rating := strings.ToLower(strings.TrimSpace(input.Rating))
+	if rating != models.FeedbackUp && rating != models.FeedbackDown {
+		return nil, fmt.Errorf("%w: rating must be %q or %q", ErrInvalidFeedback, models.FeedbackUp, models.FeedbackDown)
+	}
+
+	reasons := []string{}
+	seen := make(map[string]bool)
+	for _, reason := range input.Reasons {
+		reason = strings.ToLower(strings.TrimSpace(reason))
+		if !isFeedbackReason(reason) {
+			return nil, fmt.Errorf("%w: unknown reason %q", ErrInvalidFeedback, reason)
+		}
+		if seen[reason] {
+			continue
+		}
+		seen[reason] = true
+		reasons = append(reasons, reason)
+	}
+	if len(reasons) > maxFeedbackReasons {
+		return nil, fmt.Errorf("%w: at most %d reasons", ErrInvalidFeedback, maxFeedbackReasons)
+	}
+
+	comment := strings.TrimSpace(input.Comment)
+	if utf8.RuneCountInString(comment) > maxFeedbackCommentLength {
+		return nil, fmt.Errorf("%w: comment is longer than %d characters", ErrInvalidFeedback, maxFeedbackCommentLength)
+	}
+
+	return &models.MessageFeedback{
+		Rating:  rating,
+		Reasons: reasons,
+		Comment: comment,
+	}, nil
+}
+
+func isFeedbackReason(reason string) bool {
+	for _, known := range FeedbackReasons {
+		if reason == known {
+			return true
+		}
+	}
+	return false
+}
+
+// buildFeedbackExample turns a rating and the conversation up to the rated reply into an export line
+func buildFeedbackExample(feedback *models.MessageFeedback, conversation []*models.Message) *FeedbackExample {
+	example := &FeedbackExample{
+		MessageID:       feedback.MessageID,
+		SessionID:       feedback.SessionID,
+		Rating:          feedback.Rating,
+		Reasons:         feedback.Reasons,
+		Comment:         feedback.Comment,
+		RatedAt:         feedback.UpdatedAt,
+		ToolInvocations: []models.ToolInvocation{},
+		Conversation:    make([]FeedbackTurn, 0, len(conversation)),
+	}
+	if example.Reasons == nil {
+		example.Reasons = []string{}
+	}
+	if feedback.Generation != nil {
+		example.Model = feedback.Generation.Model
+		example.PromptVersion = feedback.Generation.PromptVersion
+		example.SummaryInContext = feedback.Generation.SummaryInContext
+		example.CoachContext = feedback.Generation.CoachContext
+		example.InjuryRiskWarning = feedback.Generation.InjuryRiskWarning
+		example.Rounds = feedback.Generation.Rounds
+		example.StopReason = feedback.Generation.StopReason
+		if feedback.Generation.ToolInvocations != nil {
+			example.ToolInvocations = feedback.Generation.ToolInvocations
+		}
+	}
+
+	for _, message := range conversation {
+		example.Conversation = append(example.Conversation, FeedbackTurn{Role: message.Role, Content: message.Content})
+	}
+
+	return example
+}
+
+// toolUsagePattern names the distinct tools a reply used, in alphabetical order
+func toolUsagePattern(generation *models.MessageGeneration) (string, []string) {
+	if generation == nil {
+		return unrecordedToolPattern, []string{}
+	}
+
+	seen := make(map[string]bool)
+	tools := []string{}
+	for _, invocation := range generation.ToolInvocations {
+		if !seen[invocation.Name] {
+			seen[invocation.Name] = true
+			tools = append(tools, invocation.Name)
+		}
+	}
+	if len(tools) == 0 {
+		return noToolsPattern, tools
+	}
+
+	sort.Strings(tools)
+	return strings.Join(tools, "+"), tools
+}
+
+// buildFeedbackReport aggregates ratings by tool usage pattern and reason. Patterns with the most
+// ratings come first.
+func buildFeedbackReport(rated []*models.MessageFeedback) *FeedbackReport {
+	report := &FeedbackReport{
+		ByToolPattern: []*ToolPatternFeedback{},
+		Reasons:       []*FeedbackReasonCount{},
+	}
+
+	patterns := make(map[string]*ToolPatternFeedback)
+	toolCalls := make(map[string]int)
+	reasons := make(map[string]*FeedbackReasonCount)
+
+	for _, feedback := range rated {
+		report.Totals.add(feedback.Rating)
+
+		name, tools := toolUsagePattern(feedback.Generation)
+		pattern, ok := patterns[name]
+		if !ok {
+			pattern = &ToolPatternFeedback{Pattern: name, Tools: tools}
+			patterns[name] = pattern
+			report.ByToolPattern = append(report.ByToolPattern, pattern)
+		}
+		pattern.add(feedback.Rating)
+		if feedback.Generation != nil {
+			toolCalls[name] += len(feedback.Generation.ToolInvocations)
+		}
+
+		for _, reason := range feedback.Reasons {
+			key := feedback.Rating + "/" + reason
+			count, ok := reasons[key]
+			if !ok {
+				count = &FeedbackReasonCount{Reason: reason, Rating: feedback.Rating}
+				reasons[key] = count
+				report.Reasons = append(report.Reasons, count)
+			}
+			count.Count++
+		}
+	}
+
+	for _, pattern := range report.ByToolPattern {
+		pattern.AverageToolCalls = float64(toolCalls[pattern.Pattern]) / float64(pattern.Rated)
+	}
+
+	sort.SliceStable(report.ByToolPattern, func(i, j int) bool {
+		if report.ByToolPattern[i].Rated != report.ByToolPattern[j].Rated {
+			return report.ByToolPattern[i].Rated > report.ByToolPattern[j].Rated
+		}
+		return report.ByToolPattern[i].Pattern < report.ByToolPattern[j].Pattern
+	})
+	sort.SliceStable(report.Reasons, func(i, j int) bool {
+		if report.Reasons[i].Count != report.Reasons[j].Count {
+			return report.Reasons[i].Count > report.Reasons[j].Count
+		}
+		return report.Reasons[i].Reason < report.Reasons[j].Reason
+	})
+
+	return report
+}
+
+// add counts a rating and updates the helpful rate
+func (r *FeedbackRate) add(rating string) {
+	r.Rated++
+	if rating == models.FeedbackUp {
+		r.Up++
+	} else {
+		r.Down++
+	}
+	r.HelpfulRate = float64(r.Up) / float64(r.Rated)
+}
@@ -0,0 +1,361 @@
+package services
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"bodda/internal/models"
+
+	"github.com/openai/openai-go/v2/responses"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryFeedbackStore keeps feedback and assistant replies in memory
+type memoryFeedbackStore struct {
+	replies       map[string]*models.Message
+	generations   map[string]*models.MessageGeneration
+	conversations map[string][]*models.Message
+	feedback      []*models.MessageFeedback
+	lookups       int // GetConversations calls
+	listedFrom    time.Time
+	listedTo      time.Time
+}
+
+func newMemoryFeedbackStore() *memoryFeedbackStore {
+	return &memoryFeedbackStore{
+		replies:       make(map[string]*models.Message),
+		generations:   make(map[string]*models.MessageGeneration),
+		conversations: make(map[string][]*models.Message),
+	}
+}
+
+func (m *memoryFeedbackStore) SetGeneration(ctx context.Context, messageID string, generation *models.MessageGeneration) error {
+	m.generations[messageID] = generation
+	return nil
+}
+
+func (m *memoryFeedbackStore) Upsert(ctx context.Context, feedback *models.MessageFeedback) (bool, error) {
+	reply, ok := m.replies[feedback.MessageID]
+	if !ok || reply.SessionID != feedback.SessionID {
+		return false, nil
+	}
+	feedback.Generation = m.generations[feedback.MessageID]
+	for i, existing := range m.feedback {
+		if existing.MessageID == feedback.MessageID && existing.UserID == feedback.UserID {
+			m.feedback[i] = feedback
+			return true, nil
+		}
+	}
+	m.feedback = append(m.feedback, feedback)
+	return true, nil
+}
+
+func (m *memoryFeedbackStore) Delete(ctx context.Context, messageID, userID string) (bool, error) {
+	for i, existing := range m.feedback {
+		if existing.MessageID == messageID && existing.UserID == userID {
+			m.feedback = append(m.feedback[:i], m.feedback[i+1:]...)
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (m *memoryFeedbackStore) ListRated(ctx context.Context, from, to time.Time, rating string, limit int) ([]*models.MessageFeedback, error) {
+	m.listedFrom, m.listedTo = from, to
+	var rated []*models.MessageFeedback
+	for _, feedback := range m.feedback {
+		if rating == "" || feedback.Rating == rating {
+			rated = append(rated, feedback)
+		}
+	}
+	if len(rated) > limit {
+		rated = rated[:limit]
+	}
+	return rated, nil
+}
+
+func (m *memoryFeedbackStore) GetConversations(ctx context.Context, messageIDs []string) (map[string][]*models.Message, error) {
+	m.lookups++
+	conversations := make(map[string][]*models.Message)
+	for _, id := range messageIDs {
+		if conversation, ok := m.conversations[id]; ok {
+			conversations[id] = conversation
+		}
+	}
+	return conversations, nil
+}
+
+const (
+	feedbackSessionID = "7b0c2c55-2f7e-4c3a-9d59-0f6f0f5f7a10"
+	feedbackReplyID   = "1d5b8e7a-7f0e-4a6b-8c39-3b4a2f9e6c21"
+)
+
+func newFeedbackTestService() (*feedbackService, *memoryFeedbackStore) {
+	store := newMemoryFeedbackStore()
+	store.replies[feedbackReplyID] = &models.Message{ID: feedbackReplyID, SessionID: feedbackSessionID, Role: "assistant"}
+	service := NewFeedbackService(store).(*feedbackService)
+	service.now = func() time.Time { return time.Date(2026, 3, 10, 15, 0, 0, 0, time.UTC) }
+	return service, store
+}
+
+func TestValidateFeedback(t *testing.T) {
+	feedback, err := validateFeedback(FeedbackInput{Rating: " Up ", Reasons: []string{"accurate", "Clear", "accurate"}, Comment: "  Spot on  "})
+	require.NoError(t, err)
+	assert.Equal(t, models.FeedbackUp, feedback.Rating)
+	assert.Equal(t, []string{"accurate", "clear"}, feedback.Reasons, "reasons are normalized and deduplicated")
+	assert.Equal(t, "Spot on", feedback.Comment)
+
+	feedback, err = validateFeedback(FeedbackInput{Rating: "down"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{}, feedback.Reasons)
+
+	invalid := []FeedbackInput{
+		{Rating: "meh"},
+		{Rating: "down", Reasons: []string{"boring"}},
+		{Rating: "down", Reasons: []string{"inaccurate", "wrong_data", "too_long", "too_vague", "unsafe_advice", "other"}},
+		{Rating: "up", Comment: strings.Repeat("a", maxFeedbackCommentLength+1)},
+	}
+	for _, input := range invalid {
+		_, err := validateFeedback(input)
+		assert.ErrorIs(t, err, ErrInvalidFeedback, "%+v", input)
+	}
+}
+
+func TestFeedbackService_SubmitAndDelete(t *testing.T) {
+	service, store := newFeedbackTestService()
+	ctx := context.Background()
+
+	require.NoError(t, service.RecordGeneration(ctx, feedbackReplyID, &models.MessageGeneration{Model: "gpt-5"}))
+	assert.Equal(t, []models.ToolInvocation{}, store.generations[feedbackReplyID].ToolInvocations)
+
+	feedback, err := service.SubmitFeedback(ctx, "user-1", feedbackSessionID, feedbackReplyID, FeedbackInput{Rating: "down", Reasons: []string{"too_long"}})
+	require.NoError(t, err)
+	assert.Equal(t, "gpt-5", feedback.Generation.Model)
+
+	_, err = service.SubmitFeedback(ctx, "user-1", feedbackSessionID, feedbackReplyID, FeedbackInput{Rating: "up"})
+	require.NoError(t, err)
+	require.Len(t, store.feedback, 1, "a second rating replaces the first")
+	assert.Equal(t, models.FeedbackUp, store.feedback[0].Rating)
+
+	_, err = service.SubmitFeedback(ctx, "user-1", "other-session", feedbackReplyID, FeedbackInput{Rating: "up"})
+	assert.ErrorIs(t, err, ErrMessageNotFound)
+	_, err = service.SubmitFeedback(ctx, "user-1", feedbackSessionID, "not-a-uuid", FeedbackInput{Rating: "up"})
+	assert.ErrorIs(t, err, ErrMessageNotFound)
+
+	require.NoError(t, service.DeleteFeedback(ctx, "user-1", feedbackReplyID))
+	assert.ErrorIs(t, service.DeleteFeedback(ctx, "user-1", feedbackReplyID), ErrFeedbackNotFound)
+}
+
+func TestToolUsagePattern(t *testing.T) {
+	pattern, tools := toolUsagePattern(nil)
+	assert.Equal(t, unrecordedToolPattern, pattern)
+	assert.Empty(t, tools)
+
+	pattern, _ = toolUsagePattern(&models.MessageGeneration{Model: "gpt-5"})
+	assert.Equal(t, noToolsPattern, pattern)
+
+	pattern, tools = toolUsagePattern(&models.MessageGeneration{ToolInvocations: []models.ToolInvocation{
+		{Name: "get-recent-activities"},
+		{Name: "get-athlete-profile"},
+		{Name: "get-recent-activities"},
+	}})
+	assert.Equal(t, "get-athlete-profile+get-recent-activities", pattern)
+	assert.Equal(t, []string{"get-athlete-profile", "get-recent-activities"}, tools)
+}
+
+func TestBuildFeedbackReport(t *testing.T) {
+	activities := &models.MessageGeneration{Model: "gpt-5", ToolInvocations: []models.ToolInvocation{
+		{Name: "get-recent-activities", Round: 1},
+		{Name: "get-activity-details", Round: 2},
+		{Name: "get-activity-details", Round: 2},
+	}}
+	noTools := &models.MessageGeneration{Model: "gpt-5", ToolInvocations: []models.ToolInvocation{}}
+
+	report := buildFeedbackReport([]*models.MessageFeedback{
+		{Rating: "up", Reasons: []string{"accurate"}, Generation: activities},
+		{Rating: "down", Reasons: []string{"wrong_data", "too_long"}, Generation: activities},
+		{Rating: "up", Reasons: []string{"accurate"}, Generation: activities},
+		{Rating: "down", Reasons: []string{"too_vague"}, Generation: noTools},
+		{Rating: "up"},
+	})
+
+	assert.Equal(t, FeedbackRate{Rated: 5, Up: 3, Down: 2, HelpfulRate: 0.6}, report.Totals)
+	require.Len(t, report.ByToolPattern, 3)
+
+	top := report.ByToolPattern[0]
+	assert.Equal(t, "get-activity-details+get-recent-activities", top.Pattern)
+	assert.Equal(t, 3, top.Rated)
+	assert.InDelta(t, 2.0/3.0, top.HelpfulRate, 0.0001)
+	assert.Equal(t, 3.0, top.AverageToolCalls)
+
+	assert.Equal(t, noToolsPattern, report.ByToolPattern[1].Pattern)
+	assert.Equal(t, 0.0, report.ByToolPattern[1].HelpfulRate)
+	assert.Equal(t, unrecordedToolPattern, report.ByToolPattern[2].Pattern)
+	assert.Equal(t, 0.0, report.ByToolPattern[2].AverageToolCalls)
+
+	require.NotEmpty(t, report.Reasons)
+	assert.Equal(t, &FeedbackReasonCount{Reason: "accurate", Rating: "up", Count: 2}, report.Reasons[0])
+	assert.Len(t, report.Reasons, 4)
+
+	empty := buildFeedbackReport(nil)
+	assert.Equal(t, 0, empty.Totals.Rated)
+	assert.Empty(t, empty.ByToolPattern)
+}
+
+func TestFeedbackService_GetReportPeriod(t *testing.T) {
+	service, store := newFeedbackTestService()
+
+	report, err := service.GetReport(context.Background(), 7)
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2026, 3, 4, 0, 0, 0, 0, time.UTC), store.listedFrom)
+	assert.Equal(t, time.Date(2026, 3, 11, 0, 0, 0, 0, time.UTC), store.listedTo)
+	assert.Equal(t, store.listedFrom, report.From)
+	assert.False(t, report.Truncated)
+
+	_, err = service.GetReport(context.Background(), 0)
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2026, 2, 9, 0, 0, 0, 0, time.UTC), store.listedFrom, "defaults to 30 days")
+}
+
+func TestFeedbackService_ExportRated(t *testing.T) {
+	service, store := newFeedbackTestService()
+	ctx := context.Background()
+
+	secondReplyID := "9a7f3c1e-5b2d-4e8f-a6c4-2d1e0b9f8a73"
+	store.replies[secondReplyID] = &models.Message{ID: secondReplyID, SessionID: feedbackSessionID, Role: "assistant"}
+	store.generations[feedbackReplyID] = &models.MessageGeneration{
+		Model:            "gpt-5",
+		PromptVersion:    "v1",
+		SummaryInContext: true,
+		Rounds:           1,
+		StopReason:       models.GenerationStopComplete,
+		ToolInvocations: []models.ToolInvocation{
+			{Name: "get-recent-activities", Arguments: `{"per_page":5}`, Round: 1},
+		},
+	}
+	store.conversations[feedbackReplyID] = []*models.Message{
+		{Role: "user", Content: "How was my week?"},
+		{Role: "assistant", Content: "You ran 42 km."},
+	}
+	store.conversations[secondReplyID] = []*models.Message{
+		{Role: "user", Content: "Thanks"},
+		{Role: "assistant", Content: "Any time."},
+	}
+
+	_, err := service.SubmitFeedback(ctx, "user-1", feedbackSessionID, feedbackReplyID, FeedbackInput{Rating: "up", Reasons: []string{"personalized"}})
+	require.NoError(t, err)
+	_, err = service.SubmitFeedback(ctx, "user-1", feedbackSessionID, secondReplyID, FeedbackInput{Rating: "down", Comment: "Too short"})
+	require.NoError(t, err)
+
+	export, err := service.PrepareExport(ctx, 30, "")
+	require.NoError(t, err)
+	assert.False(t, export.Truncated)
+
+	var buf bytes.Buffer
+	written, err := service.ExportRated(ctx, &buf, export)
+	require.NoError(t, err)
+	assert.Equal(t, 2, written)
+	assert.Equal(t, 1, store.lookups, "conversations are loaded in one batch")
+
+	var examples []FeedbackExample
+	scanner := bufio.NewScanner(&buf)
+	for scanner.Scan() {
+		var example FeedbackExample
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &example))
+		examples = append(examples, example)
+	}
+	require.Len(t, examples, 2, "one JSON object per line")
+	assert.NotContains(t, buf.String(), "user-1", "exports leave users out")
+
+	first := examples[0]
+	assert.Equal(t, "up", first.Rating)
+	assert.Equal(t, "gpt-5", first.Model)
+	assert.Equal(t, "v1", first.PromptVersion)
+	assert.True(t, first.SummaryInContext)
+	assert.False(t, first.CoachContext)
+	assert.Equal(t, 1, first.Rounds)
+	assert.Equal(t, models.GenerationStopComplete, first.StopReason)
+	assert.Equal(t, []string{"personalized"}, first.Reasons)
+	require.Len(t, first.ToolInvocations, 1)
+	assert.Equal(t, `{"per_page":5}`, first.ToolInvocations[0].Arguments)
+	assert.Equal(t, []FeedbackTurn{{Role: "user", Content: "How was my week?"}, {Role: "assistant", Content: "You ran 42 km."}}, first.Conversation)
+
+	assert.Equal(t, "Too short", examples[1].Comment)
+	assert.Empty(t, examples[1].Model)
+	assert.Equal(t, []models.ToolInvocation{}, examples[1].ToolInvocations)
+
+	buf.Reset()
+	export, err = service.PrepareExport(ctx, 30, "down")
+	require.NoError(t, err)
+	written, err = service.ExportRated(ctx, &buf, export)
+	require.NoError(t, err)
+	assert.Equal(t, 1, written)
+
+	_, err = service.PrepareExport(ctx, 30, "sideways")
+	assert.ErrorIs(t, err, ErrInvalidFeedback)
+}
+
+func TestFeedbackService_ExportRatedTruncates(t *testing.T) {
+	service, store := newFeedbackTestService()
+	ctx := context.Background()
+
+	for i := 0; i <= maxFeedbackExportRows; i++ {
+		store.feedback = append(store.feedback, &models.MessageFeedback{
+			MessageID: fmt.Sprintf("reply-%d", i),
+			Rating:    models.FeedbackUp,
+		})
+	}
+
+	export, err := service.PrepareExport(ctx, 30, "")
+	require.NoError(t, err)
+	assert.True(t, export.Truncated)
+
+	written, err := service.ExportRated(ctx, io.Discard, export)
+	require.NoError(t, err)
+	assert.Equal(t, maxFeedbackExportRows, written)
+	assert.Equal(t, maxFeedbackExportRows/feedbackExportBatchSize, store.lookups)
+}
+
+func TestRecordToolInvocations(t *testing.T) {
+	msgCtx := &MessageContext{}
+	recordToolInvocations(msgCtx, 1, []responses.ResponseFunctionToolCall{
+		{Name: "get-athlete-profile", Arguments: "{}"},
+	})
+	recordToolInvocations(msgCtx, 2, []responses.ResponseFunctionToolCall{
+		{Name: "get-recent-activities", Arguments: `{"per_page":10}`},
+		{Name: "get-activity-details", Arguments: `{"activity_id":1}`},
+	})
+
+	assert.Equal(t, []models.ToolInvocation{
+		{Name: "get-athlete-profile", Arguments: "{}", Round: 1},
+		{Name: "get-recent-activities", Arguments: `{"per_page":10}`, Round: 2},
+		{Name: "get-activity-details", Arguments: `{"activity_id":1}`, Round: 2},
+	}, msgCtx.Generation.ToolInvocations)
+}
+
+func TestRecordGenerationSettings(t *testing.T) {
+	msgCtx := &MessageContext{
+		ConversationSummary: "Earlier the athlete asked about tempo runs.",
+		Coach:               &models.User{ID: "coach-1"},
+	}
+	recordPromptContext(msgCtx)
+
+	processor := NewIterativeProcessor(msgCtx, nil)
+	processor.CurrentRound = 2
+	finishGeneration(processor, models.GenerationStopMaxRounds)
+
+	generation := msgCtx.Generation
+	assert.True(t, generation.SummaryInContext)
+	assert.True(t, generation.CoachContext)
+	assert.False(t, generation.InjuryRiskWarning)
+	assert.Equal(t, 2, generation.Rounds)
+	assert.Equal(t, models.GenerationStopMaxRounds, generation.StopReason)
+}
@@ -0,0 +1,144 @@
+package services
+
+import "math"
+
+const (
+	// Minetti et al. (2002) measured the energy cost of running between -45% and +45% grade
+	maxModelGrade = 0.45
+	// Energy cost of running on the flat in J/kg/m according to the Minetti model
+	flatRunningCost = 3.6
+	// NGP uses the same 30 second rolling window as normalized power
+	normalizedPaceWindow = 30
+)
+
+// RunningEnergyCost returns the metabolic cost of running in J/kg/m at a grade given as a fraction
+// (0.05 = 5%), using the Minetti polynomial. Grades beyond the measured range are clamped.
+func RunningEnergyCost(grade float64) float64 {
+	grade = math.Max(-maxModelGrade, math.Min(maxModelGrade, grade))
+	return 155.4*math.Pow(grade, 5) - 30.4*math.Pow(grade, 4) - 43.3*math.Pow(grade, 3) +
+		46.3*grade*grade + 19.5*grade + flatRunningCost
+}
+
+// GradeAdjustedSpeed converts a speed on a grade (fraction) into the flat speed of equal energy cost
+func GradeAdjustedSpeed(speed, grade float64) float64 {
+	if speed <= 0 {
+		return 0
+	}
+	return speed * RunningEnergyCost(grade) / flatRunningCost
+}
+
+// CalculateGAPStream returns the grade-adjusted speed (m/s) for each sample, or nil when the
+// velocity or grade stream is missing. Strava's grade_smooth stream is expressed in percent.
+func CalculateGAPStream(streams *StravaStreams) []float64 {
+	if streams == nil || len(streams.VelocitySmooth) == 0 || len(streams.GradeSmooth) == 0 {
+		return nil
+	}
+
+	n := min(len(streams.VelocitySmooth), len(streams.GradeSmooth))
+	gap := make([]float64, n)
+	for i := 0; i < n; i++ {
+		gap[i] = GradeAdjustedSpeed(streams.VelocitySmooth[i], streams.GradeSmooth[i]/100)
+	}
+	return gap
+}
+
+// CalculateNormalizedGradedPace applies the normalized power algorithm to a GAP stream: a 30 second
+// rolling average raised to the fourth power, averaged, and the fourth root taken. Returns m/s.
+func CalculateNormalizedGradedPace(gap []float64) float64 {
+	if len(gap) < normalizedPaceWindow {
+		return 0
+	}
+
+	rolling := calculateMovingAverage(gap, normalizedPaceWindow)
+	var sum float64
+	for _, speed := range rolling {
+		sum += math.Pow(speed, 4)
+	}
+	return math.Pow(sum/float64(len(rolling)), 0.25)
+}
+
+// GradeAdjustedPace summarizes hill-adjusted running speed for an activity
+type GradeAdjustedPace struct {
+	AvgSpeed float64 `json:"avg_speed"`
+	AvgGAP   float64 `json:"avg_gap"`
+	NGP      float64 `json:"ngp"`
+}
+
+// CalculateGradeAdjustedPace derives average GAP and NGP over moving samples, or nil without grade data
+func CalculateGradeAdjustedPace(streams *StravaStreams) *GradeAdjustedPace {
+	gap := CalculateGAPStream(streams)
+	if len(gap) == 0 {
+		return nil
+	}
+
+	var speedSum, gapSum float64
+	moving := 0
+	for i, speed := range streams.VelocitySmooth[:len(gap)] {
+		if speed < minMovingSpeed {
+			continue
+		}
+		speedSum += speed
+		gapSum += gap[i]
+		moving++
+	}
+	if moving == 0 {
+		return nil
+	}
+
+	return &GradeAdjustedPace{
+		AvgSpeed: roundTo(speedSum/float64(moving), 3),
+		AvgGAP:   roundTo(gapSum/float64(moving), 3),
+		NGP:      roundTo(CalculateNormalizedGradedPace(gap), 3),
+	}
+}
+
+// lapGradeAdjustedSpeed averages GAP over the moving samples in [start, end]
+func lapGradeAdjustedSpeed(streams *StravaStreams, start, end int) float64 {
+	if len(streams.GradeSmooth) <= end || len(streams.VelocitySmooth) <= end {
+		return 0
+	}
+
+	var sum float64
+	moving := 0
+	for i := start; i <= end; i++ {
+		speed := streams.VelocitySmooth[i]
+		if speed < minMovingSpeed {
+			continue
+		}
+		sum += GradeAdjustedSpeed(speed, streams.GradeSmooth[i]/100)
+		moving++
+	}
+	if moving == 0 {
+		return 0
+	}
+	return sum / float64(moving)
+}
+
+// EstimateSplitGAP estimates a split's grade-adjusted speed from its average grade
+// when Strava does not provide one
+func EstimateSplitGAP(distance, elevationDifference, speed float64) float64 {
+	if distance <= 0 {
+		return 0
+	}
+	return GradeAdjustedSpeed(speed, elevationDifference/distance)
+}
+
+// activityGradeAdjustedSpeed averages split GAP weighted by split distance, preferring Strava's value
+func activityGradeAdjustedSpeed(splits []StravaSplitStandard) float64 {
+	var weighted, distance float64
+	for _, split := range splits {
+		gap := split.AverageGradeAdjustedSpeed
+		if gap <= 0 {
+			gap = EstimateSplitGAP(split.Distance, split.ElevationDifference, split.AverageSpeed)
+		}
+		if gap <= 0 || split.Distance <= 0 {
+			continue
+		}
+		weighted += gap * split.Distance
+		distance += split.Distance
+	}
+	if distance == 0 {
+		return 0
+	}
+	return weighted / distance
+}
@@ -0,0 +1,99 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// hillRepeatStreams alternates 300 samples climbing at grade percent with 300 samples descending
+func hillRepeatStreams(repeats int, upSpeed, downSpeed, grade float64) *StravaStreams {
+	streams := &StravaStreams{}
+	for r := 0; r < repeats; r++ {
+		for i := 0; i < 600; i++ {
+			streams.Time = append(streams.Time, len(streams.Time))
+			if i < 300 {
+				streams.VelocitySmooth = append(streams.VelocitySmooth, upSpeed)
+				streams.GradeSmooth = append(streams.GradeSmooth, grade)
+			} else {
+				streams.VelocitySmooth = append(streams.VelocitySmooth, downSpeed)
+				streams.GradeSmooth = append(streams.GradeSmooth, -grade)
+			}
+			streams.Heartrate = append(streams.Heartrate, 150)
+		}
+	}
+	return streams
+}
+
+func TestRunningEnergyCost(t *testing.T) {
+	assert.Equal(t, flatRunningCost, RunningEnergyCost(0))
+	assert.InDelta(t, 5.97, RunningEnergyCost(0.10), 0.01)
+	assert.InDelta(t, 2.15, RunningEnergyCost(-0.10), 0.01)
+	assert.Equal(t, RunningEnergyCost(maxModelGrade), RunningEnergyCost(0.8), "grades are clamped to the measured range")
+
+	// Downhill running is cheapest around -20%, then becomes more expensive again
+	assert.Less(t, RunningEnergyCost(-0.20), RunningEnergyCost(-0.10))
+	assert.Less(t, RunningEnergyCost(-0.20), RunningEnergyCost(-0.40))
+}
+
+func TestGradeAdjustedSpeed(t *testing.T) {
+	assert.Equal(t, 3.0, GradeAdjustedSpeed(3.0, 0))
+	assert.InDelta(t, 3.32, GradeAdjustedSpeed(2.0, 0.10), 0.01, "slow uphill running is worth a faster flat pace")
+	assert.Less(t, GradeAdjustedSpeed(4.0, -0.10), 4.0)
+	assert.Equal(t, 0.0, GradeAdjustedSpeed(0, 0.10))
+}
+
+func TestCalculateGradeAdjustedPace(t *testing.T) {
+	streams := hillRepeatStreams(3, 2.0, 4.0, 10)
+
+	gap := CalculateGAPStream(streams)
+	require.Len(t, gap, len(streams.VelocitySmooth))
+	assert.InDelta(t, 3.32, gap[0], 0.01)
+	assert.InDelta(t, 2.39, gap[300], 0.01)
+
+	pace := CalculateGradeAdjustedPace(streams)
+	require.NotNil(t, pace)
+	assert.Equal(t, 3.0, pace.AvgSpeed)
+	assert.InDelta(t, 2.85, pace.AvgGAP, 0.01)
+	assert.Greater(t, pace.NGP, pace.AvgGAP, "NGP weights the harder climbs more heavily")
+
+	assert.Nil(t, CalculateGradeAdjustedPace(&StravaStreams{VelocitySmooth: []float64{3, 3}}), "no grade stream")
+	assert.Equal(t, 0.0, CalculateNormalizedGradedPace([]float64{3, 3}))
+}
+
+func TestAnalyzeSingleLap_GradeAdjustedSpeed(t *testing.T) {
+	streams := hillRepeatStreams(1, 2.0, 4.0, 10)
+	climb := analyzeSingleLap(streams, StravaLap{StartIndex: 0, EndIndex: 299}, 1)
+	descent := analyzeSingleLap(streams, StravaLap{StartIndex: 300, EndIndex: 599}, 2)
+
+	assert.InDelta(t, 3.32, climb.AvgGradeAdjustedSpeed, 0.01)
+	assert.InDelta(t, 2.39, descent.AvgGradeAdjustedSpeed, 0.01)
+	assert.Greater(t, climb.AvgGradeAdjustedSpeed, descent.AvgGradeAdjustedSpeed, "the slow climb was the harder effort")
+}
+
+func TestEfficiencyFactorUsesNormalizedGradedPace(t *testing.T) {
+	ef := CalculateEfficiencyFactor(hillRepeatStreams(3, 2.0, 4.0, 10))
+	require.NotNil(t, ef)
+	assert.Equal(t, AerobicBasisGradedPace, ef.Basis)
+	assert.Contains(t, formatEfficiencyOutput(ef), "/km NGP")
+}
+
+func TestFormatActivityDetails_GradeAdjustedPace(t *testing.T) {
+	details := &StravaActivityDetail{
+		StravaActivity: StravaActivity{ID: 1, Name: "Hill Run", Type: "Run", SportType: "TrailRun", Distance: 2000, AverageSpeed: 2.5},
+		SplitsStandard: []StravaSplitStandard{
+			{Split: 1, Distance: 1000, ElapsedTime: 500, AverageSpeed: 2.0, ElevationDifference: 100},
+			{Split: 2, Distance: 1000, ElapsedTime: 333, AverageSpeed: 3.0, AverageGradeAdjustedSpeed: 3.0},
+		},
+	}
+
+	output := NewOutputFormatter().FormatActivityDetails(details)
+	assert.Contains(t, output, "- Grade-Adjusted Pace: 5:17/km (actual 6:40/km)")
+	assert.Contains(t, output, "GAP (est.): 11.9 km/h")
+
+	details.Type, details.SportType = "Ride", "Ride"
+	output = NewOutputFormatter().FormatActivityDetails(details)
+	assert.NotContains(t, output, "Grade-Adjusted Pace")
+	assert.NotContains(t, output, "GAP (est.)")
+}
@@ -0,0 +1,488 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"regexp"
+	"strings"
+	"time"
+
+	"bodda/internal/models"
+)
+
+const (
+	// Load is assessed over four weekly blocks ending on the assessed day
+	injuryRiskWeeks = 4
+	// Activities are fetched further back so a return from a break can be told apart from a new account
+	injuryRiskHistoryDays = 42
+	// The acute:chronic ratio is only meaningful once the athlete has this much history
+	minACWRHistoryDays = 21
+
+	// Sessions whose average heart rate cannot be placed in a zone are weighted as zone 2
+	defaultSessionIntensity = 2
+	maxSessionIntensity     = 5
+
+	acwrHighThreshold     = 1.5
+	acwrElevatedThreshold = 1.3
+	acwrLowThreshold      = 0.8
+	// Foster's monotony (weekly mean / SD of daily load) above this is linked to illness and overreaching
+	monotonyThreshold = 2.0
+	// Monotony is capped so that identical daily loads do not divide by zero
+	maxTrainingMonotony = 10.0
+	strainSpikeRatio    = 1.5
+
+	// A weekly volume this far above the previous three weeks' average is a sudden jump
+	volumeJumpRatio      = 1.3
+	volumeJumpHighRatio  = 1.5
+	minRunJumpKm         = 5.0
+	minTimeJumpHours     = 1.0
+	longRunSpikeRatio    = 1.3
+	minLongRunSpikeKm    = 3.0
+	maxInjuryNotes       = 5
+	maxInjuryNoteLength  = 160
+	injuryRiskWarningTTL = 30 * time.Minute
+	// Failed assessments are retried sooner, but not on every message
+	injuryRiskRetryTTL       = 5 * time.Minute
+	injuryRiskWarningTimeout = 5 * time.Second
+)
+
+// Injury risk levels
+const (
+	InjuryRiskLow      = "low"
+	InjuryRiskModerate = "moderate"
+	InjuryRiskHigh     = "high"
+)
+
+// injuryPattern matches logbook lines describing injuries or pain
+var injuryPattern = regexp.MustCompile(`(?i)\b(injur\w*|pain\w*|sprain\w*|tendin\w*|tendon\w*|fractur\w*|shin splints?|plantar|achilles|it ?band|itb|niggle\w*|physio\w*|rehab\w*)\b`)
+
+// WeeklyLoad is one week of training load, oldest week first in a report
+type WeeklyLoad struct {
+	Start        time.Time `json:"start"`
+	Load         float64   `json:"load"`
+	Sessions     int       `json:"sessions"`
+	Hours        float64   `json:"hours"`
+	RunKm        float64   `json:"run_km"`
+	LongestRunKm float64   `json:"longest_run_km"`
+	Monotony     *float64  `json:"monotony,omitempty"`
+	Strain       *float64  `json:"strain,omitempty"`
+}
+
+// InjuryRiskFlag is a single threshold that was crossed
+type InjuryRiskFlag struct {
+	Kind     string `json:"kind"`
+	Severity string `json:"severity"`
+	Message  string `json:"message"`
+}
+
+// InjuryRiskReport summarizes load-based injury risk for the week ending on Date
+type InjuryRiskReport struct {
+	Date        time.Time        `json:"date"`
+	RiskLevel   string           `json:"risk_level"`
+	Weeks       []WeeklyLoad     `json:"weeks"`
+	AcuteLoad   float64          `json:"acute_load"`
+	ChronicLoad float64          `json:"chronic_load"`
+	ACWR        *float64         `json:"acwr,omitempty"`
+	HistoryDays int              `json:"history_days"`
+	Flags       []InjuryRiskFlag `json:"flags"`
+	InjuryNotes []string         `json:"injury_notes"`
+
+	// Sessions without heart rate data, weighted as zone 2
+	AssumedIntensitySessions int `json:"assumed_intensity_sessions"`
+}
+
+// SessionLoad returns a session's training load in arbitrary units: moving minutes weighted by the
+// heart rate zone (1-5) of the average heart rate, a summary-level approximation of Edwards' TRIMP.
+// The second value is false when the intensity was assumed because heart rate or zones are missing.
+func SessionLoad(activity *StravaActivity, hrZones []StravaZone) (float64, bool) {
+	intensity := defaultSessionIntensity
+	zone := heartRateZoneIndex(hrZones, activity.AverageHeartrate)
+	if zone >= 0 {
+		intensity = min(zone+1, maxSessionIntensity)
+	}
+	return float64(activity.MovingTime) / 60 * float64(intensity), zone >= 0
+}
+
+// BuildInjuryRiskReport computes ACWR, monotony, strain and volume jumps for the four weeks ending on date,
+// and cross-references the result with injuries mentioned in the logbook. hrZones may be nil.
+func BuildInjuryRiskReport(activities []*StravaActivity, hrZones []StravaZone, logbook string, date time.Time) *InjuryRiskReport {
+	date = wellnessDate(date)
+	end := date.AddDate(0, 0, 1)
+	windowStart := end.AddDate(0, 0, -7*injuryRiskWeeks)
+
+	report := &InjuryRiskReport{Date: date, Flags: []InjuryRiskFlag{}, InjuryNotes: ExtractInjuryNotes(logbook)}
+	weeks := make([]WeeklyLoad, injuryRiskWeeks)
+	daily := make([]float64, 7*injuryRiskWeeks)
+	for i := range weeks {
+		weeks[i].Start = windowStart.AddDate(0, 0, 7*i)
+	}
+
+	var earliest time.Time
+	for _, activity := range activities {
+		start, ok := activityStartLocal(activity)
+		if !ok || !start.Before(end) {
+			continue
+		}
+		if earliest.IsZero() || start.Before(earliest) {
+			earliest = start
+		}
+		if start.Before(windowStart) {
+			continue
+		}
+
+		load, measured := SessionLoad(activity, hrZones)
+		if !measured {
+			report.AssumedIntensitySessions++
+		}
+		day := int(start.Sub(windowStart).Hours() / 24)
+		daily[day] += load
+
+		week := &weeks[day/7]
+		week.Load += load
+		week.Sessions++
+		week.Hours += float64(activity.MovingTime) / 3600
+		// Walks and hikes are left out of running volume, which tracks impact load
+		if strings.Contains(activity.SportType, "Run") || strings.Contains(activity.Type, "Run") {
+			km := activity.Distance / 1000
+			week.RunKm += km
+			week.LongestRunKm = math.Max(week.LongestRunKm, km)
+		}
+	}
+	if !earliest.IsZero() {
+		report.HistoryDays = int(end.Sub(wellnessDate(earliest)).Hours() / 24)
+	}
+
+	for i := range weeks {
+		mean, sd := meanStdDev(daily[7*i : 7*i+7])
+		if mean > 0 {
+			monotony := maxTrainingMonotony
+			if sd > 0 {
+				monotony = math.Min(mean/sd, maxTrainingMonotony)
+			}
+			strain := weeks[i].Load * monotony
+			weeks[i].Monotony = floatPtrRounded(monotony, 2)
+			weeks[i].Strain = floatPtrRounded(strain, 0)
+		}
+		weeks[i].Load = roundTo(weeks[i].Load, 0)
+		weeks[i].Hours = roundTo(weeks[i].Hours, 1)
+		weeks[i].RunKm = roundTo(weeks[i].RunKm, 1)
+		weeks[i].LongestRunKm = roundTo(weeks[i].LongestRunKm, 1)
+	}
+	report.Weeks = weeks
+
+	current := weeks[injuryRiskWeeks-1]
+	prior := weeks[:injuryRiskWeeks-1]
+	var total float64
+	for _, week := range weeks {
+		total += week.Load
+	}
+	report.AcuteLoad = current.Load
+	report.ChronicLoad = roundTo(total/injuryRiskWeeks, 0)
+
+	if report.ChronicLoad > 0 && report.HistoryDays >= minACWRHistoryDays {
+		acwr := roundTo(report.AcuteLoad/report.ChronicLoad, 2)
+		report.ACWR = &acwr
+		switch {
+		case acwr >= acwrHighThreshold:
+			report.addFlag("acwr", InjuryRiskHigh, "Acute:chronic workload ratio %.2f: this week's load is %.0f%% of the 4-week average (above %.1f the injury risk rises sharply)", acwr, acwr*100, acwrHighThreshold)
+		case acwr >= acwrElevatedThreshold:
+			report.addFlag("acwr", InjuryRiskModerate, "Acute:chronic workload ratio %.2f is above the %.1f-%.1f sweet spot", acwr, acwrLowThreshold, acwrElevatedThreshold)
+		}
+	}
+
+	if current.Monotony != nil && *current.Monotony >= monotonyThreshold {
+		report.addFlag("monotony", InjuryRiskModerate, "Training monotony %.1f this week (above %.1f): daily loads are too uniform, with too few easy or rest days", *current.Monotony, monotonyThreshold)
+	}
+
+	// Week-on-week comparisons need prior weeks of real history, not the empty weeks before an account existed
+	if report.HistoryDays >= minACWRHistoryDays {
+		var priorStrain float64
+		strainWeeks := 0
+		for _, week := range prior {
+			if week.Strain != nil {
+				priorStrain += *week.Strain
+				strainWeeks++
+			}
+		}
+		if current.Strain != nil && strainWeeks > 0 {
+			average := priorStrain / float64(strainWeeks)
+			if *current.Strain >= average*strainSpikeRatio {
+				report.addFlag("strain", InjuryRiskModerate, "Training strain %.0f is %.1fx the average of the previous weeks (%.0f)", *current.Strain, *current.Strain/average, average)
+			}
+		}
+
+		var priorRunKm, priorHours, priorLongest float64
+		for _, week := range prior {
+			priorRunKm += week.RunKm
+			priorHours += week.Hours
+			priorLongest = math.Max(priorLongest, week.LongestRunKm)
+		}
+		priorRunKm /= float64(len(prior))
+		priorHours /= float64(len(prior))
+
+		if priorRunKm > 0 && current.RunKm >= priorRunKm*volumeJumpRatio && current.RunKm-priorRunKm >= minRunJumpKm {
+			severity := InjuryRiskModerate
+			if current.RunKm >= priorRunKm*volumeJumpHighRatio {
+				severity = InjuryRiskHigh
+			}
+			report.addFlag("run_volume_jump", severity, "Running volume jumped to %.1f km this week, +%.0f%% on the previous 3-week average of %.1f km", current.RunKm, (current.RunKm/priorRunKm-1)*100, priorRunKm)
+		}
+		if priorHours > 0 && current.Hours >= priorHours*volumeJumpRatio && current.Hours-priorHours >= minTimeJumpHours {
+			report.addFlag("time_volume_jump", InjuryRiskModerate, "Training time jumped to %.1f h this week, +%.0f%% on the previous 3-week average of %.1f h", current.Hours, (current.Hours/priorHours-1)*100, priorHours)
+		}
+		if priorLongest > 0 && current.LongestRunKm >= priorLongest*longRunSpikeRatio && current.LongestRunKm-priorLongest >= minLongRunSpikeKm {
+			report.addFlag("long_run_spike", InjuryRiskModerate, "Longest run of %.1f km is well beyond the longest of the previous 3 weeks (%.1f km)", current.LongestRunKm, priorLongest)
+		}
+	}
+
+	report.RiskLevel = InjuryRiskLow
+	for _, flag := range report.Flags {
+		if flag.Severity == InjuryRiskHigh {
+			report.RiskLevel = InjuryRiskHigh
+			break
+		}
+		report.RiskLevel = InjuryRiskModerate
+	}
+	// A load spike on top of a recorded injury history is treated as high risk
+	if report.RiskLevel == InjuryRiskModerate && len(report.InjuryNotes) > 0 {
+		report.RiskLevel = InjuryRiskHigh
+	}
+	return report
+}
+
+func (r *InjuryRiskReport) addFlag(kind, severity, format string, args ...interface{}) {
+	r.Flags = append(r.Flags, InjuryRiskFlag{Kind: kind, Severity: severity, Message: fmt.Sprintf(format, args...)})
+}
+
+func floatPtrRounded(value float64, places int) *float64 {
+	rounded := roundTo(value, places)
+	return &rounded
+}
+
+// ExtractInjuryNotes returns the logbook lines that mention injuries or pain
+func ExtractInjuryNotes(logbook string) []string {
+	notes := []string{}
+	for _, line := range strings.Split(logbook, "\n") {
+		line = strings.TrimSpace(strings.TrimLeft(strings.TrimSpace(line), "-*#> "))
+		if line == "" {
+			continue
+		}
+		if !injuryPattern.MatchString(line) {
+			continue
+		}
+		if len(line) > maxInjuryNoteLength {
+			line = strings.TrimSpace(line[:maxInjuryNoteLength]) + "..."
+		}
+		notes = append(notes, line)
+		if len(notes) == maxInjuryNotes {
+			break
+		}
+	}
+	return notes
+}
+
+// InjuryRiskService assesses injury risk from Strava training load and the athlete logbook
+type InjuryRiskService interface {
+	Assess(ctx context.Context, user *models.User, logbook *models.AthleteLogbook, date time.Time) (*InjuryRiskReport, error)
+	// ProactiveWarning returns a short system prompt warning when thresholds are crossed, or an empty string.
+	// Results are cached per user and logbook version, and failures only suppress the warning.
+	ProactiveWarning(ctx context.Context, user *models.User, logbook *models.AthleteLogbook) string
+}
+
+type injuryRiskService struct {
+	stravaService StravaService
+	warnings      ToolResultCache
+	now           func() time.Time
+}
+
+// NewInjuryRiskService creates a new injury risk service
+func NewInjuryRiskService(stravaService StravaService) InjuryRiskService {
+	return &injuryRiskService{
+		stravaService: stravaService,
+		warnings:      NewToolResultCache(1000, nil),
+		now:           time.Now,
+	}
+}
+
+// Assess fetches the activity history around date and builds an injury risk report
+func (r *injuryRiskService) Assess(ctx context.Context, user *models.User, logbook *models.AthleteLogbook, date time.Time) (*InjuryRiskReport, error) {
+	if date.IsZero() {
+		date = r.now()
+	}
+	date = wellnessDate(date)
+
+	activities, hrZones, err := loadInjuryRiskHistory(ctx, r.stravaService, user, date)
+	if err != nil {
+		return nil, err
+	}
+
+	content := ""
+	if logbook != nil {
+		content = logbook.Content
+	}
+	return BuildInjuryRiskReport(activities, hrZones, content, date), nil
+}
+
+// loadInjuryRiskHistory fetches the activities and heart rate zones an injury risk report for the
+// week ending on date needs
+func loadInjuryRiskHistory(ctx context.Context, stravaService StravaService, user *models.User, date time.Time) ([]*StravaActivity, []StravaZone, error) {
+	// Strava filters on UTC start times while loads use local dates, so pad the range by a day
+	after := date.AddDate(0, 0, -injuryRiskHistoryDays-1)
+	before := date.AddDate(0, 0, 2)
+	var activities []*StravaActivity
+	_, _, _, err := scanActivities(ctx, stravaService, user, &after, &before, func(page []*StravaActivity) bool {
+		activities = append(activities, page...)
+		return true
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get activities for injury risk: %w", err)
+	}
+
+	// Zones only refine the intensity weighting, so a failure to load them is not fatal
+	var hrZones []StravaZone
+	if zones, err := stravaService.GetAthleteZones(ctx, user); err == nil && zones != nil && zones.HeartRate != nil {
+		hrZones = zones.HeartRate.Zones
+	}
+
+	return activities, hrZones, nil
+}
+
+func (r *injuryRiskService) ProactiveWarning(ctx context.Context, user *models.User, logbook *models.AthleteLogbook) string {
+	if user == nil {
+		return ""
+	}
+
+	key := user.ID
+	if logbook != nil {
+		key += "|" + logbook.UpdatedAt.UTC().Format(time.RFC3339Nano)
+	}
+	if warning, ok := r.warnings.Get(ctx, key); ok {
+		return warning
+	}
+
+	assessCtx, cancel := context.WithTimeout(ctx, injuryRiskWarningTimeout)
+	defer cancel()
+
+	report, err := r.Assess(assessCtx, user, logbook, time.Time{})
+	if err != nil {
+		if !errors.Is(err, context.Canceled) {
+			slog.WarnContext(ctx, "Failed to assess injury risk for proactive warning", "user_id", user.ID, "error", err)
+			r.warnings.Set(ctx, key, user.ID, "get-injury-risk", "", injuryRiskRetryTTL)
+		}
+		return ""
+	}
+
+	warning := formatInjuryRiskWarning(report)
+	r.warnings.Set(ctx, key, user.ID, "get-injury-risk", warning, injuryRiskWarningTTL)
+	return warning
+}
+
+// formatInjuryRiskWarning renders the flags of a moderate or high risk report for the system prompt
+func formatInjuryRiskWarning(report *InjuryRiskReport) string {
+	if report.RiskLevel == InjuryRiskLow {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("Injury risk from the last 4 weeks of training is %s:\n", strings.ToUpper(report.RiskLevel)))
+	for _, flag := range report.Flags {
+		b.WriteString("- " + flag.Message + "\n")
+	}
+	if len(report.InjuryNotes) > 0 {
+		b.WriteString("Injury history in the logbook:\n")
+		for _, note := range report.InjuryNotes {
+			b.WriteString("- " + note + "\n")
+		}
+	}
+	b.WriteString("Mention this to the athlete when it is relevant to their question or plans, and avoid recommending further load increases this week. Use get-injury-risk for the weekly breakdown.")
+	return b.String()
+}
+
+// InjuryRiskRequest selects the last day of the assessed week
+type InjuryRiskRequest struct {
+	Date string `json:"date"`
+}
+
+// formatInjuryRisk renders an injury risk report as markdown
+func formatInjuryRisk(report *InjuryRiskReport) string {
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("# Injury risk for the week ending %s\n\n", report.Date.Format(wellnessDateLayout)))
+	b.WriteString(fmt.Sprintf("**Risk: %s**\n\n", report.RiskLevel))
+
+	if report.ACWR != nil {
+		b.WriteString(fmt.Sprintf("- Acute:chronic workload ratio: %.2f (acute %.0f, chronic %.0f AU/week; %.1f-%.1f is the sweet spot)\n", *report.ACWR, report.AcuteLoad, report.ChronicLoad, acwrLowThreshold, acwrElevatedThreshold))
+		if *report.ACWR < acwrLowThreshold {
+			b.WriteString("- Load is well below the recent average; build back gradually rather than jumping straight to previous volume\n")
+		}
+	} else {
+		b.WriteString(fmt.Sprintf("- Acute:chronic workload ratio: not available (%d days of history, %d needed)\n", report.HistoryDays, minACWRHistoryDays))
+	}
+	b.WriteString("\n")
+
+	b.WriteString("| Week starting | Sessions | Time | Run km | Longest run | Load (AU) | Monotony | Strain |\n|---|---|---|---|---|---|---|---|\n")
+	for _, week := range report.Weeks {
+		b.WriteString(fmt.Sprintf("| %s | %d | %.1f h | %.1f | %.1f | %.0f | %s | %s |\n", week.Start.Format(wellnessDateLayout),
+			week.Sessions, week.Hours, week.RunKm, week.LongestRunKm, week.Load, formatOptionalFloat(week.Monotony, ""), formatOptionalFloat(week.Strain, "")))
+	}
+	b.WriteString("\n")
+
+	if len(report.Flags) > 0 {
+		b.WriteString("**Flags:**\n")
+		for _, flag := range report.Flags {
+			b.WriteString(fmt.Sprintf("- [%s] %s\n", flag.Severity, flag.Message))
+		}
+		b.WriteString("\n")
+	} else {
+		b.WriteString("No load thresholds crossed.\n\n")
+	}
+
+	if len(report.InjuryNotes) > 0 {
+		b.WriteString("**Injury history from the logbook:**\n")
+		for _, note := range report.InjuryNotes {
+			b.WriteString("- " + note + "\n")
+		}
+		b.WriteString("\n")
+	}
+
+	b.WriteString("Load is moving minutes x heart rate zone (1-5) of the session's average heart rate.")
+	if report.AssumedIntensitySessions > 0 {
+		b.WriteString(fmt.Sprintf(" %d sessions had no usable heart rate and are weighted as zone %d.", report.AssumedIntensitySessions, defaultSessionIntensity))
+	}
+	b.WriteString("\n")
+	return b.String()
+}
+
+func (s *aiService) executeGetInjuryRisk(ctx context.Context, msgCtx *MessageContext, req InjuryRiskRequest) (string, error) {
+	if msgCtx == nil || msgCtx.User == nil {
+		return "", fmt.Errorf("user context is required")
+	}
+
+	var date time.Time
+	if req.Date != "" {
+		parsed, err := parseSearchDate(req.Date, false)
+		if err != nil {
+			return "", err
+		}
+		date = *parsed
+	}
+
+	report, err := s.injuryRisk.Assess(ctx, msgCtx.User, msgCtx.AthleteLogbook, date)
+	if err != nil {
+		if errors.Is(err, context.Canceled) {
+			return "", err
+		}
+		return "", s.handleStravaError(err, "activities")
+	}
+	return formatInjuryRisk(report), nil
+}
+
+// attachInjuryRiskWarning adds the proactive injury risk warning to the message context
+func (s *aiService) attachInjuryRiskWarning(ctx context.Context, msgCtx *MessageContext) {
+	if s.injuryRisk == nil || msgCtx.User == nil || msgCtx.InjuryRiskWarning != "" {
+		return
+	}
+	msgCtx.InjuryRiskWarning = s.injuryRisk.ProactiveWarning(ctx, msgCtx.User, msgCtx.AthleteLogbook)
+}
@@ -0,0 +1,161 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"bodda/internal/config"
+	"bodda/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// injuryRiskSession is one day of the weekly pattern used by loadHistory
+type injuryRiskSession struct {
+	minutes   int
+	heartRate float64
+}
+
+// weeklyPattern repeats every 7 days with two rest days, a zone 3 session and a long run
+var weeklyPattern = []injuryRiskSession{{0, 0}, {45, 140}, {60, 155}, {45, 140}, {0, 0}, {40, 140}, {100, 140}}
+
+// loadHistory builds days of runs ending on date at 5 min/km, scaling the last 7 days' durations by lastWeekScale
+func loadHistory(date time.Time, days int, lastWeekScale float64) []*StravaActivity {
+	var activities []*StravaActivity
+	for i := 0; i < days; i++ {
+		session := weeklyPattern[i%7]
+		if session.minutes == 0 {
+			continue
+		}
+		minutes := session.minutes
+		if i < 7 {
+			minutes = int(float64(minutes) * lastWeekScale)
+		}
+		day := date.AddDate(0, 0, -i).Format("2006-01-02")
+		activities = append(activities, summaryActivity(int64(i+1), day, "Run", float64(minutes)/5, minutes, session.heartRate))
+	}
+	return activities
+}
+
+func TestSessionLoad(t *testing.T) {
+	load, measured := SessionLoad(&StravaActivity{MovingTime: 3600, AverageHeartrate: 155}, testHeartRateZones)
+	assert.True(t, measured)
+	assert.Equal(t, 180.0, load)
+
+	load, measured = SessionLoad(&StravaActivity{MovingTime: 3600}, testHeartRateZones)
+	assert.False(t, measured)
+	assert.Equal(t, 120.0, load)
+}
+
+func TestBuildInjuryRiskReport(t *testing.T) {
+	date := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	t.Run("steady training", func(t *testing.T) {
+		report := BuildInjuryRiskReport(loadHistory(date, 42, 1), testHeartRateZones, "", date)
+
+		assert.Equal(t, InjuryRiskLow, report.RiskLevel)
+		assert.Empty(t, report.Flags)
+		require.NotNil(t, report.ACWR)
+		assert.Equal(t, 1.0, *report.ACWR)
+		assert.Equal(t, 640.0, report.AcuteLoad)
+		require.Len(t, report.Weeks, 4)
+		assert.Equal(t, date.AddDate(0, 0, -6), report.Weeks[3].Start)
+		assert.Equal(t, 58.0, report.Weeks[3].RunKm)
+		assert.Equal(t, 20.0, report.Weeks[3].LongestRunKm)
+		assert.InDelta(t, 1.27, *report.Weeks[3].Monotony, 0.01)
+		assert.Equal(t, 0, report.AssumedIntensitySessions)
+	})
+
+	t.Run("load spike", func(t *testing.T) {
+		report := BuildInjuryRiskReport(loadHistory(date, 42, 1.8), testHeartRateZones, "", date)
+
+		assert.Equal(t, InjuryRiskHigh, report.RiskLevel)
+		require.NotNil(t, report.ACWR)
+		assert.Equal(t, 1.5, *report.ACWR)
+
+		kinds := make(map[string]string)
+		for _, flag := range report.Flags {
+			kinds[flag.Kind] = flag.Severity
+		}
+		assert.Equal(t, InjuryRiskHigh, kinds["acwr"])
+		assert.Equal(t, InjuryRiskHigh, kinds["run_volume_jump"])
+		assert.Equal(t, InjuryRiskModerate, kinds["strain"])
+		assert.Contains(t, kinds, "time_volume_jump")
+		assert.Contains(t, kinds, "long_run_spike")
+		assert.NotContains(t, kinds, "monotony")
+	})
+
+	t.Run("injury history escalates moderate risk", func(t *testing.T) {
+		activities := loadHistory(date, 42, 1.4)
+		report := BuildInjuryRiskReport(activities, testHeartRateZones, "", date)
+		assert.Equal(t, InjuryRiskModerate, report.RiskLevel)
+		assert.NotContains(t, report.Flags[0].Kind, "acwr")
+
+		logbook := "## History\n- Raced a marathon in Spain\n- Left Achilles tendinopathy in 2024, cleared by physio\n"
+		report = BuildInjuryRiskReport(activities, testHeartRateZones, logbook, date)
+		assert.Equal(t, InjuryRiskHigh, report.RiskLevel)
+		assert.Equal(t, []string{"Left Achilles tendinopathy in 2024, cleared by physio"}, report.InjuryNotes)
+	})
+
+	t.Run("short history", func(t *testing.T) {
+		report := BuildInjuryRiskReport(loadHistory(date, 10, 1), nil, "", date)
+		assert.Nil(t, report.ACWR)
+		assert.Equal(t, 10, report.HistoryDays)
+		assert.Empty(t, report.Flags, "an empty history is not a volume jump")
+		assert.Equal(t, InjuryRiskLow, report.RiskLevel)
+		assert.Equal(t, 7, report.AssumedIntensitySessions)
+		assert.Contains(t, formatInjuryRisk(report), "not available (10 days of history, 21 needed)")
+	})
+}
+
+func TestInjuryRiskProactiveWarning(t *testing.T) {
+	date := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	strava := &zonedStravaService{pagedStravaService{activities: loadHistory(date, 42, 1.8)}}
+	service := NewInjuryRiskService(strava).(*injuryRiskService)
+	service.now = func() time.Time { return date.Add(18 * time.Hour) }
+
+	user := &models.User{ID: "test-user", AccessToken: "test-token"}
+	logbook := &models.AthleteLogbook{UserID: "test-user", Content: "Goal: autumn marathon", UpdatedAt: date}
+
+	warning := service.ProactiveWarning(context.Background(), user, logbook)
+	assert.Contains(t, warning, "Injury risk from the last 4 weeks of training is HIGH")
+	assert.Contains(t, warning, "Acute:chronic workload ratio 1.50")
+
+	requests := len(strava.requests)
+	assert.Equal(t, warning, service.ProactiveWarning(context.Background(), user, logbook))
+	assert.Equal(t, requests, len(strava.requests), "warnings are cached per logbook version")
+
+	steady := NewInjuryRiskService(&zonedStravaService{pagedStravaService{activities: loadHistory(date, 42, 1)}}).(*injuryRiskService)
+	steady.now = service.now
+	assert.Empty(t, steady.ProactiveWarning(context.Background(), user, logbook))
+
+	ai := &aiService{injuryRisk: service}
+	msgCtx := &MessageContext{User: user, AthleteLogbook: logbook}
+	ai.attachInjuryRiskWarning(context.Background(), msgCtx)
+	assert.Contains(t, ai.buildEnhancedSystemPrompt(msgCtx), "Injury Risk Warning:\nInjury risk from the last 4 weeks")
+}
+
+func TestGetInjuryRiskTool(t *testing.T) {
+	date := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	strava := &zonedStravaService{pagedStravaService{activities: loadHistory(date, 42, 1)}}
+	aiService := NewAIService(&config.Config{OpenAIAPIKey: "test-key"}, strava, &mockLogbookServiceForToolExecutor{}, &MockSessionRepositoryForToolExecutor{}, NewToolRegistry())
+	executor := NewToolExecutor(aiService, NewToolRegistry())
+
+	msgCtx := &MessageContext{
+		UserID: "test-user",
+		User:   &models.User{ID: "test-user", AccessToken: "test-token"},
+	}
+
+	result, err := executor.ExecuteTool(context.Background(), "get-injury-risk", map[string]interface{}{"date": "2025-06-01"}, msgCtx)
+	require.NoError(t, err)
+	require.True(t, result.Success, result.Error)
+	assert.Contains(t, result.Data, "# Injury risk for the week ending 2025-06-01")
+	assert.Contains(t, result.Data, "**Risk: low**")
+	assert.Contains(t, result.Data, "No load thresholds crossed.")
+
+	result, err = executor.ExecuteTool(context.Background(), "get-injury-risk", map[string]interface{}{"date": "June 1st"}, msgCtx)
+	require.NoError(t, err)
+	assert.False(t, result.Success)
+}
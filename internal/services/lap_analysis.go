@@ -24,6 +24,7 @@ type LapSummary struct {
 	ElevationGain   float64                `json:"elevation_gain,omitempty"`
 	ElevationLoss   float64                `json:"elevation_loss,omitempty"`
 	AvgSpeed        float64                `json:"avg_speed,omitempty"`
+	AvgGradeAdjustedSpeed float64          `json:"avg_grade_adjusted_speed,omitempty"`
 	MaxSpeed        float64                `json:"max_speed,omitempty"`
 	AvgHeartRate    float64                `json:"avg_heart_rate,omitempty"`
 	MaxHeartRate    int                    `json:"max_heart_rate,omitempty"`
@@ -236,6 +237,9 @@ func analyzeSingleLap(streams *StravaStreams, lap StravaLap, lapNumber int) LapS
 			summary.MaxSpeed = max
 			summary.Statistics.Speed = CalculateFloatStats(speedData)
 		}
+
+		// Hill-adjusted speed so laps on different terrain compare fairly
+		summary.AvgGradeAdjustedSpeed = lapGradeAdjustedSpeed(streams, lap.StartIndex, lap.EndIndex)
 	}
 
 	// Cadence Analysis
@@ -0,0 +1,180 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"bodda/internal/config"
+)
+
+// ErrInvalidOAuthState is returned when an OAuth callback's state does not match the browser's state cookie
+var ErrInvalidOAuthState = errors.New("invalid OAuth state")
+
+const (
+	// OAuthStateCookieName holds the signed state issued when a login starts
+	OAuthStateCookieName = "oauth_state"
+	// OAuthStateTTL bounds how long a user may take to approve access on Strava
+	OAuthStateTTL = 10 * time.Minute
+
+	// DefaultLoginRedirect is where users land after signing in when no other target was requested
+	DefaultLoginRedirect = "/chat"
+)
+
+// RequiredStravaScopes must all be granted for coaching to work; without activity:read_all private
+// activities and their streams cannot be read
+var RequiredStravaScopes = []string{"read", "activity:read_all"}
+
+// oauthState is the payload of the state cookie
+type oauthState struct {
+	Nonce     string `json:"n"`
+	Redirect  string `json:"r"`
+	ExpiresAt int64  `json:"e"`
+}
+
+// OAuthStateManager issues and verifies the state that binds a Strava login to the browser that started it
+type OAuthStateManager interface {
+	// Issue creates a state for the authorize URL and the signed cookie value that must accompany the callback
+	Issue(redirect string) (state string, cookie string, err error)
+	// Verify checks the callback state against the cookie and returns the allow-listed post-login redirect path
+	Verify(cookie, state string) (redirect string, err error)
+	// SanitizeRedirect returns target as a path on the frontend, or DefaultLoginRedirect when it is not allowed
+	SanitizeRedirect(target string) string
+}
+
+type oauthStateManager struct {
+	key      []byte
+	frontend *url.URL
+	now      func() time.Time
+}
+
+// NewOAuthStateManager creates a state manager whose cookies are signed with a key derived from the JWT secret
+func NewOAuthStateManager(cfg *config.Config) OAuthStateManager {
+	key := sha256.Sum256([]byte("oauth-state:" + cfg.JWTSecret))
+	frontend, err := url.Parse(cfg.FrontendURL)
+	if err != nil {
+		frontend = &url.URL{}
+	}
+
+	return &oauthStateManager{
+		key:      key[:],
+		frontend: frontend,
+		now:      time.Now,
+	}
+}
+
+func (m *oauthStateManager) Issue(redirect string) (string, string, error) {
+	nonce := make([]byte, 32)
+	if _, err := rand.Read(nonce); err != nil {
+		return "", "", fmt.Errorf("failed to generate OAuth state: %w", err)
+	}
+
+	state := oauthState{
+		Nonce:     base64.RawURLEncoding.EncodeToString(nonce),
+		Redirect:  m.SanitizeRedirect(redirect),
+		ExpiresAt: m.now().Add(OAuthStateTTL).Unix(),
+	}
+	payload, err := json.Marshal(state)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to encode OAuth state: %w", err)
+	}
+
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+	return state.Nonce, encoded + "." + m.sign(encoded), nil
+}
+
+func (m *oauthStateManager) Verify(cookie, state string) (string, error) {
+	if cookie == "" {
+		return "", fmt.Errorf("%w: state cookie is missing", ErrInvalidOAuthState)
+	}
+	if state == "" {
+		return "", fmt.Errorf("%w: state parameter is missing", ErrInvalidOAuthState)
+	}
+
+	encoded, signature, ok := strings.Cut(cookie, ".")
+	if !ok || !hmac.Equal([]byte(signature), []byte(m.sign(encoded))) {
+		return "", fmt.Errorf("%w: state cookie signature is invalid", ErrInvalidOAuthState)
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", fmt.Errorf("%w: state cookie is malformed", ErrInvalidOAuthState)
+	}
+	var stored oauthState
+	if err := json.Unmarshal(payload, &stored); err != nil {
+		return "", fmt.Errorf("%w: state cookie is malformed", ErrInvalidOAuthState)
+	}
+
+	if m.now().Unix() > stored.ExpiresAt {
+		return "", fmt.Errorf("%w: login attempt expired", ErrInvalidOAuthState)
+	}
+	if subtle.ConstantTimeCompare([]byte(stored.Nonce), []byte(state)) != 1 {
+		return "", fmt.Errorf("%w: state does not match", ErrInvalidOAuthState)
+	}
+
+	return m.SanitizeRedirect(stored.Redirect), nil
+}
+
+func (m *oauthStateManager) SanitizeRedirect(target string) string {
+	target = strings.TrimSpace(target)
+	if target == "" || strings.ContainsAny(target, "\\\r\n") {
+		return DefaultLoginRedirect
+	}
+
+	parsed, err := url.Parse(target)
+	if err != nil {
+		return DefaultLoginRedirect
+	}
+
+	if parsed.IsAbs() || parsed.Host != "" {
+		// Absolute targets must point at the configured frontend
+		if !strings.EqualFold(parsed.Scheme, m.frontend.Scheme) || !strings.EqualFold(parsed.Host, m.frontend.Host) {
+			return DefaultLoginRedirect
+		}
+	} else if !strings.HasPrefix(target, "/") || strings.HasPrefix(target, "//") {
+		return DefaultLoginRedirect
+	}
+
+	path := parsed.EscapedPath()
+	if path == "" || !strings.HasPrefix(path, "/") || strings.HasPrefix(path, "//") || strings.HasPrefix(path, "/auth/") {
+		return DefaultLoginRedirect
+	}
+	if parsed.RawQuery != "" {
+		path += "?" + parsed.RawQuery
+	}
+	if parsed.Fragment != "" {
+		path += "#" + parsed.EscapedFragment()
+	}
+	return path
+}
+
+func (m *oauthStateManager) sign(value string) string {
+	mac := hmac.New(sha256.New, m.key)
+	mac.Write([]byte(value))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// MissingStravaScopes returns the required scopes absent from the comma-separated scope list Strava
+// passes to the OAuth callback
+func MissingStravaScopes(granted string) []string {
+	grantedSet := make(map[string]bool)
+	for _, scope := range strings.Split(granted, ",") {
+		grantedSet[strings.TrimSpace(scope)] = true
+	}
+
+	var missing []string
+	for _, scope := range RequiredStravaScopes {
+		if !grantedSet[scope] {
+			missing = append(missing, scope)
+		}
+	}
+	return missing
+}
@@ -0,0 +1,93 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"bodda/internal/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestOAuthStateManager() *oauthStateManager {
+	return NewOAuthStateManager(&config.Config{JWTSecret: "test-secret", FrontendURL: "https://app.example.com"}).(*oauthStateManager)
+}
+
+func TestOAuthStateManager_IssueAndVerify(t *testing.T) {
+	manager := newTestOAuthStateManager()
+
+	state, cookie, err := manager.Issue("/chat/session-1?tab=notes")
+	require.NoError(t, err)
+
+	redirect, err := manager.Verify(cookie, state)
+	require.NoError(t, err)
+	assert.Equal(t, "/chat/session-1?tab=notes", redirect)
+
+	otherState, _, err := manager.Issue("")
+	require.NoError(t, err)
+	assert.NotEqual(t, state, otherState)
+
+	_, err = manager.Verify(cookie, otherState)
+	assert.True(t, errors.Is(err, ErrInvalidOAuthState), "a cookie only validates its own state")
+
+	_, err = manager.Verify("", state)
+	assert.True(t, errors.Is(err, ErrInvalidOAuthState))
+
+	_, err = manager.Verify(cookie[:len(cookie)-2]+"AA", state)
+	assert.True(t, errors.Is(err, ErrInvalidOAuthState), "a tampered signature is rejected")
+
+	otherKey := NewOAuthStateManager(&config.Config{JWTSecret: "other-secret", FrontendURL: "https://app.example.com"})
+	_, err = otherKey.Verify(cookie, state)
+	assert.True(t, errors.Is(err, ErrInvalidOAuthState), "cookies are bound to the server secret")
+}
+
+func TestOAuthStateManager_Expiry(t *testing.T) {
+	manager := newTestOAuthStateManager()
+	issued := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	manager.now = func() time.Time { return issued }
+
+	state, cookie, err := manager.Issue("")
+	require.NoError(t, err)
+
+	manager.now = func() time.Time { return issued.Add(OAuthStateTTL - time.Second) }
+	_, err = manager.Verify(cookie, state)
+	assert.NoError(t, err)
+
+	manager.now = func() time.Time { return issued.Add(OAuthStateTTL + time.Second) }
+	_, err = manager.Verify(cookie, state)
+	assert.True(t, errors.Is(err, ErrInvalidOAuthState))
+	assert.Contains(t, err.Error(), "expired")
+}
+
+func TestOAuthStateManager_SanitizeRedirect(t *testing.T) {
+	manager := newTestOAuthStateManager()
+
+	tests := []struct {
+		target string
+		want   string
+	}{
+		{"", DefaultLoginRedirect},
+		{"/settings", "/settings"},
+		{"/chat/abc#latest", "/chat/abc#latest"},
+		{"https://app.example.com/usage?days=7", "/usage?days=7"},
+		{"https://evil.example.com/chat", DefaultLoginRedirect},
+		{"http://app.example.com/chat", DefaultLoginRedirect},
+		{"//evil.example.com/chat", DefaultLoginRedirect},
+		{"/\\evil.example.com", DefaultLoginRedirect},
+		{"javascript:alert(1)", DefaultLoginRedirect},
+		{"chat", DefaultLoginRedirect},
+		{"/auth/strava", DefaultLoginRedirect},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, manager.SanitizeRedirect(tt.target), tt.target)
+	}
+}
+
+func TestMissingStravaScopes(t *testing.T) {
+	assert.Empty(t, MissingStravaScopes("read,activity:read_all,profile:read_all"))
+	assert.Equal(t, []string{"activity:read_all"}, MissingStravaScopes("read,activity:read"))
+	assert.Equal(t, []string{"read", "activity:read_all"}, MissingStravaScopes(""))
+}
@@ -8,14 +8,16 @@ import (
 
 // DerivedFeatures represents comprehensive stream analysis data
 type DerivedFeatures struct {
-	ActivityID       int64             `json:"activity_id"`
-	Summary          FeatureSummary    `json:"summary"`
-	InflectionPoints []InflectionPoint `json:"inflection_points"`
-	Statistics       StreamStatistics  `json:"statistics"`
-	Trends           []Trend           `json:"trends"`
-	Spikes           []Spike           `json:"spikes"`
-	SampleData       []DataPoint       `json:"sample_data"`
-	LapAnalysis      *LapAnalysis      `json:"lap_analysis,omitempty"`
+	ActivityID       int64              `json:"activity_id"`
+	Summary          FeatureSummary     `json:"summary"`
+	InflectionPoints []InflectionPoint  `json:"inflection_points"`
+	Statistics       StreamStatistics   `json:"statistics"`
+	Trends           []Trend            `json:"trends"`
+	Spikes           []Spike            `json:"spikes"`
+	SampleData       []DataPoint        `json:"sample_data"`
+	LapAnalysis      *LapAnalysis       `json:"lap_analysis,omitempty"`
+	Aerobic          *AerobicAnalysis   `json:"aerobic,omitempty"`
+	GradeAdjusted    *GradeAdjustedPace `json:"grade_adjusted,omitempty"`
 }
 
 // FeatureSummary contains high-level activity metrics
@@ -67,6 +69,7 @@ type DataPoint struct {
 type OutputFormatter interface {
 	FormatAthleteProfile(profile *StravaAthleteWithZones) string
 	FormatActivities(activities []*StravaActivity) string
+	FormatActivitySearch(result *ActivitySearchResult) string
 	FormatActivityDetails(details *StravaActivityDetail) string
 	FormatActivityDetailsWithZones(detailsWithZones *StravaActivityDetailWithZones) string
 	FormatActivityZones(zones *StravaActivityZones) string
@@ -267,6 +270,56 @@ func (f *outputFormatter) FormatActivities(activities []*StravaActivity) string
 	return builder.String()
 }
 
+// FormatActivitySearch formats activity search matches as compact one-line summaries in markdown
+func (f *outputFormatter) FormatActivitySearch(result *ActivitySearchResult) string {
+	if result == nil {
+		return "📭 **No matching activities found**"
+	}
+
+	var builder strings.Builder
+	builder.WriteString(fmt.Sprintf("🔎 **Activity Search** (%d matches, %d activities scanned)\n", len(result.Matches), result.Scanned))
+	builder.WriteString(fmt.Sprintf("Filters: %s\n\n", result.Request.Describe()))
+
+	if len(result.Matches) == 0 {
+		builder.WriteString("📭 **No matching activities found**\n")
+	}
+
+	for _, activity := range result.Matches {
+		emoji := f.getActivityEmoji(activity.Type, activity.SportType)
+
+		var dateStr string
+		if startTime, err := time.Parse(time.RFC3339, activity.StartDateLocal); err == nil {
+			dateStr = startTime.Format("1/2/2006")
+		} else {
+			dateStr = "Unknown date"
+		}
+
+		var tags []string
+		if activity.Trainer {
+			tags = append(tags, "trainer")
+		}
+		if activity.Commute {
+			tags = append(tags, "commute")
+		}
+		if isRaceActivity(activity) {
+			tags = append(tags, "race")
+		}
+		tagStr := ""
+		if len(tags) > 0 {
+			tagStr = " [" + strings.Join(tags, ", ") + "]"
+		}
+
+		builder.WriteString(fmt.Sprintf("%s **%s** (ID: %d) — %s in %s on %s%s\n",
+			emoji, activity.Name, activity.ID, f.formatDistance(activity.Distance), f.formatDuration(activity.MovingTime), dateStr, tagStr))
+	}
+
+	if !result.Complete {
+		builder.WriteString("\n_More activities may match; narrow the date range or raise the limit to see them._\n")
+	}
+
+	return builder.String()
+}
+
 // FormatActivityDetails formats detailed activity information with comprehensive metrics in markdown
 func (f *outputFormatter) FormatActivityDetails(details *StravaActivityDetail) string {
 	if details == nil {
@@ -354,6 +407,14 @@ func (f *outputFormatter) FormatActivityDetails(details *StravaActivityDetail) s
 		builder.WriteString("\n")
 	}
 
+	// Grade-adjusted pace for runs, from the splits
+	if isRunActivity(details.SportType) || isRunActivity(details.Type) {
+		if gap := activityGradeAdjustedSpeed(details.SplitsStandard); gap > 0 && details.AverageSpeed > 0 {
+			builder.WriteString(fmt.Sprintf("- Grade-Adjusted Pace: %s (actual %s)\n",
+				formatSpeedValue(gap, true), formatSpeedValue(details.AverageSpeed, true)))
+		}
+	}
+
 	// Power metrics
 	if details.AveragePower > 0 || details.MaxPower > 0 || details.WeightedAverageWatts > 0 {
 		builder.WriteString("- ")
@@ -515,6 +576,10 @@ func (f *outputFormatter) FormatActivityDetails(details *StravaActivityDetail) s
 			}
 			if split.AverageGradeAdjustedSpeed > 0 && split.AverageGradeAdjustedSpeed != split.AverageSpeed {
 				builder.WriteString(fmt.Sprintf(" | GAP: %s", f.formatSpeed(split.AverageGradeAdjustedSpeed)))
+			} else if split.AverageGradeAdjustedSpeed == 0 && split.ElevationDifference != 0 && (isRunActivity(details.SportType) || isRunActivity(details.Type)) {
+				if gap := EstimateSplitGAP(split.Distance, split.ElevationDifference, split.AverageSpeed); gap > 0 {
+					builder.WriteString(fmt.Sprintf(" | GAP (est.): %s", f.formatSpeed(gap)))
+				}
 			}
 			if split.AverageHeartrate > 0 {
 				builder.WriteString(fmt.Sprintf(" | HR: %.0f bpm", split.AverageHeartrate))
@@ -845,6 +910,16 @@ func (f *outputFormatter) FormatDerivedFeatures(features interface{}) string {
 	// Statistical analysis section
 	f.formatStatisticsSection(&builder, &derivedFeatures.Statistics)
 
+	// Grade-adjusted pace section (if grade data is available)
+	if derivedFeatures.GradeAdjusted != nil {
+		f.formatGradeAdjustedSection(&builder, derivedFeatures.GradeAdjusted)
+	}
+
+	// Aerobic efficiency section (if heart rate is available)
+	if derivedFeatures.Aerobic != nil {
+		f.formatAerobicSection(&builder, derivedFeatures.Aerobic)
+	}
+
 	// Trends and patterns section
 	f.formatTrendsSection(&builder, derivedFeatures.Trends)
 
@@ -1076,6 +1151,40 @@ func (f *outputFormatter) formatOverviewSection(builder *strings.Builder, summar
 	builder.WriteString("\n")
 }
 
+// formatGradeAdjustedSection formats grade-adjusted and normalized graded pace
+func (f *outputFormatter) formatGradeAdjustedSection(builder *strings.Builder, gap *GradeAdjustedPace) {
+	builder.WriteString("## ⛰️ **Grade-Adjusted Pace**\n\n")
+	builder.WriteString(fmt.Sprintf("- **Actual Pace:** %s (moving)\n", formatSpeedValue(gap.AvgSpeed, true)))
+	builder.WriteString(fmt.Sprintf("- **Grade-Adjusted Pace (GAP):** %s\n", formatSpeedValue(gap.AvgGAP, true)))
+	if gap.NGP > 0 {
+		builder.WriteString(fmt.Sprintf("- **Normalized Graded Pace (NGP):** %s\n", formatSpeedValue(gap.NGP, true)))
+	}
+	builder.WriteString("\n")
+}
+
+// formatAerobicSection formats decoupling and efficiency factor
+func (f *outputFormatter) formatAerobicSection(builder *strings.Builder, aerobic *AerobicAnalysis) {
+	builder.WriteString("## 🫀 **Aerobic Efficiency**\n\n")
+
+	if ef := aerobic.EfficiencyFactor; ef != nil {
+		builder.WriteString(fmt.Sprintf("- **Efficiency Factor:** %.3f (%s at %.0f bpm average)\n",
+			ef.Value, formatEfficiencyOutput(ef), ef.AvgHeartRate))
+	}
+
+	if decoupling := aerobic.Decoupling; decoupling != nil {
+		assessment := "aerobically stable"
+		if decoupling.DecouplingPercent >= decouplingAerobicThreshold {
+			assessment = "significant drift; aerobic endurance is limiting at this intensity"
+		}
+		builder.WriteString(fmt.Sprintf("- **%s Decoupling:** %.1f%% (%s)\n",
+			decoupling.Basis, decoupling.DecouplingPercent, assessment))
+		builder.WriteString(fmt.Sprintf("  - First half: %.4f, second half: %.4f output per beat\n",
+			decoupling.FirstHalfRatio, decoupling.SecondHalfRatio))
+	}
+
+	builder.WriteString("\n")
+}
+
 // formatStatisticsSection formats statistical analysis for all metrics
 func (f *outputFormatter) formatStatisticsSection(builder *strings.Builder, stats *StreamStatistics) {
 	builder.WriteString("## 📊 **Statistical Analysis**\n\n")
@@ -1285,6 +1394,9 @@ func (f *outputFormatter) formatLapSummary(builder *strings.Builder, lap *LapSum
 	if lap.AvgSpeed > 0 {
 		metrics = append(metrics, fmt.Sprintf("Avg Speed: %.1f km/h", lap.AvgSpeed*3.6))
 	}
+	if lap.AvgGradeAdjustedSpeed > 0 {
+		metrics = append(metrics, fmt.Sprintf("GAP: %s", formatSpeedValue(lap.AvgGradeAdjustedSpeed, true)))
+	}
 	if lap.AvgHeartRate > 0 {
 		metrics = append(metrics, fmt.Sprintf("Avg HR: %.0f bpm", lap.AvgHeartRate))
 	}
@@ -267,7 +267,7 @@ func (pmd *processingModeDispatcher) handleDerivedMode(data *StravaStreams, para
 		"**Available data points:** %d\n"+
 		"**Processing mode:** derived\n\n"+
 		"Please use 'raw' mode for now to access the stream data directly.",
-		params.ActivityID, pmd.streamProcessor.EstimateTokens(data)/4) // Rough estimate of data points
+		params.ActivityID, countStreamDataPoints(data))
 
 	return &ProcessedStreamResult{
 		ToolCallID:     params.ToolCallID,
@@ -342,7 +342,7 @@ func (pmd *processingModeDispatcher) handlePaginatedRawMode(data *StravaStreams,
 		"**Available data points:** %d\n"+
 		"**Processing mode:** raw (paginated)\n\n"+
 		"Please use a smaller dataset or wait for pagination implementation.",
-		params.ActivityID, params.PageNumber, params.PageSize, pmd.streamProcessor.EstimateTokens(data)/4)
+		params.ActivityID, params.PageNumber, params.PageSize, countStreamDataPoints(data))
 
 	return &ProcessedStreamResult{
 		ToolCallID:     params.ToolCallID,
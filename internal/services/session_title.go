@@ -0,0 +1,132 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"bodda/internal/config"
+	"bodda/internal/models"
+
+	openai "github.com/openai/openai-go/v2"
+	"github.com/openai/openai-go/v2/option"
+)
+
+// maxGeneratedTitleLength bounds generated titles so they fit the session sidebar
+const maxGeneratedTitleLength = 60
+
+// titleTrailingPunctuation is removed from the end of generated titles
+const titleTrailingPunctuation = ".,;:-– "
+
+// SessionTitleService names sessions that were started without a title
+type SessionTitleService interface {
+	// GenerateTitle titles the session from its first exchange once the assistant has replied.
+	// It returns the stored title, or "" when the session keeps its current title because the
+	// user named it, it was already titled or it has no reply yet.
+	GenerateTitle(ctx context.Context, userID, sessionID string) (string, error)
+}
+
+type sessionTitleService struct {
+	chat   ChatService
+	titler SessionTitler
+	usage  UsageRecorder
+}
+
+// NewSessionTitleService creates a title service that uses the summary model; usage may be nil
+func NewSessionTitleService(cfg *config.Config, chat ChatService, usage UsageRecorder) SessionTitleService {
+	client := openai.NewClient(option.WithAPIKey(cfg.OpenAIAPIKey))
+	titler, _ := NewSummaryProcessor(&client).(SessionTitler)
+	return newSessionTitleService(chat, titler, usage)
+}
+
+func newSessionTitleService(chat ChatService, titler SessionTitler, usage UsageRecorder) *sessionTitleService {
+	return &sessionTitleService{
+		chat:   chat,
+		titler: titler,
+		usage:  usage,
+	}
+}
+
+func (s *sessionTitleService) GenerateTitle(ctx context.Context, userID, sessionID string) (string, error) {
+	if s.titler == nil {
+		return "", nil
+	}
+
+	session, err := s.chat.GetSession(sessionID)
+	if err != nil {
+		return "", err
+	}
+	if !needsGeneratedTitle(session) {
+		return "", nil
+	}
+
+	// The opening exchange is enough to name the conversation
+	messages, err := s.chat.GetMessagesWithPagination(sessionID, 10, 0)
+	if err != nil {
+		return "", err
+	}
+	var userMessage, assistantReply string
+	for _, message := range messages {
+		if message.Role == "user" && userMessage == "" {
+			userMessage = message.Content
+		}
+		if message.Role == "assistant" && assistantReply == "" {
+			assistantReply = message.Content
+		}
+	}
+	if userMessage == "" || assistantReply == "" {
+		return "", nil
+	}
+
+	generated, err := s.titler.GenerateSessionTitle(withUsageScope(ctx, s.usage, userID, sessionID), userMessage, assistantReply)
+	if err != nil {
+		return "", err
+	}
+	title := cleanGeneratedTitle(generated)
+	if title == "" {
+		return "", fmt.Errorf("generated session title %q is unusable", generated)
+	}
+
+	// The user may have renamed the session while the title was being generated, so the title is
+	// only stored if the session still has its default title at the time of the write
+	updated, err := s.chat.SetGeneratedTitle(sessionID, title)
+	if err != nil {
+		return "", err
+	}
+	if !updated {
+		return "", nil
+	}
+
+	// Read the title back as stored, after the chat service has sanitized it
+	session, err = s.chat.GetSession(sessionID)
+	if err != nil {
+		return "", err
+	}
+	return session.Title, nil
+}
+
+// needsGeneratedTitle reports whether the session still has the title it was created with by default
+func needsGeneratedTitle(session *models.Session) bool {
+	return !session.TitleCustomized && session.Title == defaultSessionTitle
+}
+
+// cleanGeneratedTitle strips the quotes, labels and trailing punctuation models tend to add and
+// shortens the title to whole words within maxGeneratedTitleLength
+func cleanGeneratedTitle(title string) string {
+	title = strings.TrimSpace(strings.SplitN(strings.TrimSpace(title), "\n", 2)[0])
+	title = strings.TrimSpace(strings.TrimPrefix(title, "Title:"))
+	title = strings.Trim(title, "\"'`*#“”‘’ ")
+	title = strings.TrimRight(title, titleTrailingPunctuation)
+	title = strings.Join(strings.Fields(title), " ")
+
+	runes := []rune(title)
+	if len(runes) <= maxGeneratedTitleLength {
+		return title
+	}
+
+	cut := string(runes[:maxGeneratedTitleLength])
+	if space := strings.LastIndex(cut, " "); space > 0 {
+		cut = cut[:space]
+	}
+	return strings.TrimRight(cut, titleTrailingPunctuation)
+}
@@ -0,0 +1,186 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"bodda/internal/database"
+	"bodda/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+type stubSessionTitler struct {
+	title      string
+	err        error
+	calls      int
+	onGenerate func() // Runs while the title is being generated
+}
+
+func (t *stubSessionTitler) GenerateSessionTitle(ctx context.Context, userMessage, assistantReply string) (string, error) {
+	t.calls++
+	if t.onGenerate != nil {
+		t.onGenerate()
+	}
+	return t.title, t.err
+}
+
+func TestCleanGeneratedTitle(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"plain", "Marathon taper plan", "Marathon taper plan"},
+		{"quotes and full stop", `"Marathon taper plan."`, "Marathon taper plan"},
+		{"label", "Title: Threshold power after illness", "Threshold power after illness"},
+		{"first line only", "Hill repeats\nThis conversation covers hills", "Hill repeats"},
+		{"keeps brackets", "Zone 2 heart rate (Z2)", "Zone 2 heart rate (Z2)"},
+		{"collapses whitespace", "  Long   ride   fuelling  ", "Long ride fuelling"},
+		{"shortened on a word", "Building aerobic base for a first ultramarathon while working full time shifts", "Building aerobic base for a first ultramarathon while"},
+		{"empty", `""`, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := cleanGeneratedTitle(tt.input)
+			assert.Equal(t, tt.want, got)
+			assert.LessOrEqual(t, len([]rune(got)), maxGeneratedTitleLength)
+		})
+	}
+}
+
+type SessionTitleServiceTestSuite struct {
+	suite.Suite
+	db       *database.TestDB
+	chat     ChatService
+	titler   *stubSessionTitler
+	service  *sessionTitleService
+	testUser *models.User
+}
+
+func (suite *SessionTitleServiceTestSuite) SetupSuite() {
+	suite.db = database.NewTestDB(suite.T())
+	suite.chat = NewChatService(database.NewRepository(suite.db.Pool))
+}
+
+func (suite *SessionTitleServiceTestSuite) TearDownSuite() {
+	suite.db.Close()
+}
+
+func (suite *SessionTitleServiceTestSuite) SetupTest() {
+	suite.db.CleanTables()
+
+	suite.titler = &stubSessionTitler{title: "Marathon taper plan."}
+	suite.service = newSessionTitleService(suite.chat, suite.titler, nil)
+
+	suite.testUser = &models.User{
+		StravaID:     24680,
+		AccessToken:  "access_token",
+		RefreshToken: "refresh_token",
+		TokenExpiry:  time.Now().Add(time.Hour),
+		FirstName:    "Tess",
+		LastName:     "Title",
+	}
+	require.NoError(suite.T(), database.NewRepository(suite.db.Pool).User.Create(context.Background(), suite.testUser))
+}
+
+func (suite *SessionTitleServiceTestSuite) startConversation(session *models.Session, withReply bool) {
+	_, err := suite.chat.SendMessage(session.ID, "user", "How should I taper for my marathon?")
+	require.NoError(suite.T(), err)
+	if withReply {
+		_, err = suite.chat.SendMessage(session.ID, "assistant", "Cut volume by about 40% over three weeks.")
+		require.NoError(suite.T(), err)
+	}
+}
+
+func (suite *SessionTitleServiceTestSuite) TestTitlesAfterFirstReply() {
+	ctx := context.Background()
+	session, err := suite.chat.CreateSession(suite.testUser.ID)
+	require.NoError(suite.T(), err)
+
+	suite.startConversation(session, false)
+	title, err := suite.service.GenerateTitle(ctx, suite.testUser.ID, session.ID)
+	require.NoError(suite.T(), err)
+	assert.Empty(suite.T(), title, "sessions are titled once the assistant has replied")
+
+	_, err = suite.chat.SendMessage(session.ID, "assistant", "Cut volume by about 40% over three weeks.")
+	require.NoError(suite.T(), err)
+	title, err = suite.service.GenerateTitle(ctx, suite.testUser.ID, session.ID)
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "Marathon taper plan", title)
+
+	stored, err := suite.chat.GetSession(session.ID)
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "Marathon taper plan", stored.Title)
+	assert.False(suite.T(), stored.TitleCustomized)
+
+	title, err = suite.service.GenerateTitle(ctx, suite.testUser.ID, session.ID)
+	require.NoError(suite.T(), err)
+	assert.Empty(suite.T(), title, "generated titles are not replaced")
+	assert.Equal(suite.T(), 1, suite.titler.calls)
+}
+
+func (suite *SessionTitleServiceTestSuite) TestKeepsUserTitles() {
+	ctx := context.Background()
+
+	named, err := suite.chat.CreateSessionWithTitle(suite.testUser.ID, "Race week")
+	require.NoError(suite.T(), err)
+	suite.startConversation(named, true)
+
+	renamed, err := suite.chat.CreateSession(suite.testUser.ID)
+	require.NoError(suite.T(), err)
+	suite.startConversation(renamed, true)
+	title := defaultSessionTitle
+	_, err = suite.chat.UpdateSession(renamed.ID, SessionUpdate{Title: &title})
+	require.NoError(suite.T(), err)
+
+	for _, session := range []*models.Session{named, renamed} {
+		generated, err := suite.service.GenerateTitle(ctx, suite.testUser.ID, session.ID)
+		require.NoError(suite.T(), err)
+		assert.Empty(suite.T(), generated)
+	}
+	assert.Zero(suite.T(), suite.titler.calls)
+}
+
+func (suite *SessionTitleServiceTestSuite) TestKeepsRenameDuringGeneration() {
+	session, err := suite.chat.CreateSession(suite.testUser.ID)
+	require.NoError(suite.T(), err)
+	suite.startConversation(session, true)
+
+	suite.titler.onGenerate = func() {
+		title := "Race week"
+		_, err := suite.chat.UpdateSession(session.ID, SessionUpdate{Title: &title})
+		require.NoError(suite.T(), err)
+	}
+
+	generated, err := suite.service.GenerateTitle(context.Background(), suite.testUser.ID, session.ID)
+	require.NoError(suite.T(), err)
+	assert.Empty(suite.T(), generated)
+
+	stored, err := suite.chat.GetSession(session.ID)
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "Race week", stored.Title)
+}
+
+func (suite *SessionTitleServiceTestSuite) TestTitlerFailureKeepsDefault() {
+	suite.titler.err = errors.New("rate limited")
+	session, err := suite.chat.CreateSession(suite.testUser.ID)
+	require.NoError(suite.T(), err)
+	suite.startConversation(session, true)
+
+	_, err = suite.service.GenerateTitle(context.Background(), suite.testUser.ID, session.ID)
+	assert.Error(suite.T(), err)
+
+	stored, err := suite.chat.GetSession(session.ID)
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), defaultSessionTitle, stored.Title)
+}
+
+func TestSessionTitleServiceTestSuite(t *testing.T) {
+	suite.Run(t, new(SessionTitleServiceTestSuite))
+}
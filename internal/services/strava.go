@@ -11,10 +11,13 @@ import (
 	"net/url"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"bodda/internal/config"
 	"bodda/internal/models"
+
+	"golang.org/x/sync/singleflight"
 )
 
 // Strava API data models
@@ -71,6 +74,7 @@ type StravaActivity struct {
 	Flagged            bool    `json:"flagged"`
 	WorkoutType        int     `json:"workout_type"`
 	AverageTemp        float64 `json:"average_temp"`
+	GearID             string  `json:"gear_id"`
 }
 
 type StravaActivityDetail struct {
@@ -415,13 +419,13 @@ type ActivityParams struct {
 
 // StravaService handles all Strava API interactions
 type StravaService interface {
-	GetAthleteProfile(user *models.User) (*StravaAthleteWithZones, error)
-	GetAthleteZones(user *models.User) (*StravaAthleteZones, error)
-	GetActivities(user *models.User, params ActivityParams) ([]*StravaActivity, error)
-	GetActivityDetail(user *models.User, activityID int64) (*StravaActivityDetail, error)
-	GetActivityDetailWithZones(user *models.User, activityID int64) (*StravaActivityDetailWithZones, error)
-	GetActivityStreams(user *models.User, activityID int64, streamTypes []string, resolution string) (*StravaStreams, error)
-	GetActivityZones(user *models.User, activityID int64) (*StravaActivityZones, error)
+	GetAthleteProfile(ctx context.Context, user *models.User) (*StravaAthleteWithZones, error)
+	GetAthleteZones(ctx context.Context, user *models.User) (*StravaAthleteZones, error)
+	GetActivities(ctx context.Context, user *models.User, params ActivityParams) ([]*StravaActivity, error)
+	GetActivityDetail(ctx context.Context, user *models.User, activityID int64) (*StravaActivityDetail, error)
+	GetActivityDetailWithZones(ctx context.Context, user *models.User, activityID int64) (*StravaActivityDetailWithZones, error)
+	GetActivityStreams(ctx context.Context, user *models.User, activityID int64, streamTypes []string, resolution string) (*StravaStreams, error)
+	GetActivityZones(ctx context.Context, user *models.User, activityID int64) (*StravaActivityZones, error)
 	RefreshToken(refreshToken string) (*TokenResponse, error)
 }
 
@@ -433,13 +437,18 @@ type UserRepositoryInterface interface {
 type stravaService struct {
 	config      *config.Config
 	httpClient  *http.Client
-	rateLimiter *RateLimiter
+	rateLimiter *RateLimiter            // Fixed-window fallback when no shared tracker is configured
+	rateLimits  *StravaRateLimitTracker // Header-driven application-wide budget
 	userRepo    UserRepositoryInterface
-	makeRequest func(method, endpoint, accessToken string, params url.Values) ([]byte, error)
+	makeRequest func(ctx context.Context, method, endpoint, accessToken string, params url.Values) ([]byte, error)
+	tokenMu     sync.Mutex         // Guards user token fields when tool calls run concurrently; never held during I/O
+	refreshes   singleflight.Group // Shares one token refresh per user between concurrent requests
 }
 
-// RateLimiter implements a simple rate limiter for Strava API
+// RateLimiter implements a simple rate limiter for Strava API.
+// It is safe for concurrent use.
 type RateLimiter struct {
+	mu          sync.Mutex
 	requests    []time.Time
 	maxRequests int
 	window      time.Duration
@@ -454,6 +463,9 @@ func NewRateLimiter(maxRequests int, window time.Duration) *RateLimiter {
 }
 
 func (rl *RateLimiter) Allow() bool {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
 	now := time.Now()
 
 	// Remove old requests outside the window
@@ -476,17 +488,15 @@ func (rl *RateLimiter) Allow() bool {
 }
 
 func NewStravaService(cfg *config.Config, userRepo UserRepositoryInterface) StravaService {
-	// Strava API limits: 100 requests per 15 minutes, 1000 requests per day
-	// We'll implement the 15-minute limit here
-	rateLimiter := NewRateLimiter(100, 15*time.Minute)
-
+	// Strava enforces 15 minute and daily limits, both overall and for reads.
+	// The tracker starts from the configured limits and follows the response headers.
 	service := &stravaService{
 		config: cfg,
 		httpClient: &http.Client{
 			Timeout: 30 * time.Second,
 		},
-		rateLimiter: rateLimiter,
-		userRepo:    userRepo,
+		rateLimits: NewStravaRateLimitTracker(cfg.StravaRateLimit),
+		userRepo:   userRepo,
 	}
 
 	// Set the default makeRequest implementation
@@ -502,12 +512,16 @@ func NewTestStravaService(cfg *config.Config, baseURL string, userRepo UserRepos
 		httpClient: &http.Client{
 			Timeout: 30 * time.Second,
 		},
-		rateLimiter: NewRateLimiter(100, 15*time.Minute),
-		userRepo:    userRepo,
+		rateLimits: NewStravaRateLimitTracker(cfg.StravaRateLimit),
+		userRepo:   userRepo,
 	}
 
 	// Override makeRequest for testing
-	service.makeRequest = func(method, endpoint, accessToken string, params url.Values) ([]byte, error) {
+	service.makeRequest = func(ctx context.Context, method, endpoint, accessToken string, params url.Values) ([]byte, error) {
+		if err := service.reserveRequest(ctx, method, endpoint); err != nil {
+			return nil, err
+		}
+
 		fullURL := baseURL + endpoint
 		if len(params) > 0 {
 			fullURL += "?" + params.Encode()
@@ -527,11 +541,17 @@ func NewTestStravaService(cfg *config.Config, baseURL string, userRepo UserRepos
 		}
 		defer resp.Body.Close()
 
+		service.trackResponse(method, resp)
+
 		body, err := io.ReadAll(resp.Body)
 		if err != nil {
 			return nil, fmt.Errorf("failed to read response body: %w", err)
 		}
 
+		if resp.StatusCode == http.StatusTooManyRequests {
+			return nil, ErrRateLimitExceeded
+		}
+
 		if resp.StatusCode != http.StatusOK {
 			return nil, fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(body))
 		}
@@ -545,35 +565,18 @@ func NewTestStravaService(cfg *config.Config, baseURL string, userRepo UserRepos
 // executeWithTokenRefresh wraps API calls with automatic token refresh on 401 errors
 func (s *stravaService) executeWithTokenRefresh(user *models.User, apiCall func(string) (any, error)) (any, error) {
 	// First attempt with current token
-	result, err := apiCall(user.AccessToken)
+	accessToken := s.currentAccessToken(user)
+	result, err := apiCall(accessToken)
 
 	// If we get a token expired error, try to refresh
 	if err != nil && (errors.Is(err, ErrTokenExpired) || errors.Is(err, ErrInvalidToken)) {
-		log.Printf("Token expired for user %s, attempting refresh", user.ID)
-
-		// Attempt to refresh the token
-		tokenResp, refreshErr := s.RefreshToken(user.RefreshToken)
+		newToken, refreshErr := s.refreshUserToken(user, accessToken)
 		if refreshErr != nil {
-			log.Printf("Token refresh failed for user %s: %v", user.ID, refreshErr)
-			return nil, fmt.Errorf("failed to refresh Strava token: %w", refreshErr)
-		}
-
-		// Update user with new tokens
-		user.AccessToken = tokenResp.AccessToken
-		user.RefreshToken = tokenResp.RefreshToken
-		user.TokenExpiry = time.Unix(tokenResp.ExpiresAt, 0)
-
-		// Save updated tokens to database
-		ctx := context.Background()
-		if updateErr := s.userRepo.Update(ctx, user); updateErr != nil {
-			log.Printf("Failed to update user tokens in database for user %s: %v", user.ID, updateErr)
-			return nil, fmt.Errorf("failed to save refreshed tokens: %w", updateErr)
+			return nil, refreshErr
 		}
 
-		log.Printf("Successfully refreshed tokens for user %s", user.ID)
-
 		// Retry the original API call with new token
-		result, err = apiCall(user.AccessToken)
+		result, err = apiCall(newToken)
 		if err != nil {
 			return nil, fmt.Errorf("API call failed even after token refresh: %w", err)
 		}
@@ -582,11 +585,115 @@ func (s *stravaService) executeWithTokenRefresh(user *models.User, apiCall func(
 	return result, err
 }
 
-func (s *stravaService) defaultMakeRequest(method, endpoint string, accessToken string, params url.Values) ([]byte, error) {
-	// Check rate limit
-	if !s.rateLimiter.Allow() {
+// currentAccessToken reads the user's access token under the token lock
+func (s *stravaService) currentAccessToken(user *models.User) string {
+	s.tokenMu.Lock()
+	defer s.tokenMu.Unlock()
+	return user.AccessToken
+}
+
+// refreshUserToken refreshes and persists the user's tokens, returning the new access token.
+// Concurrent callers for the same user share a single refresh, while refreshes for different
+// users run in parallel.
+func (s *stravaService) refreshUserToken(user *models.User, staleToken string) (string, error) {
+	s.tokenMu.Lock()
+	if user.AccessToken != staleToken {
+		// Another request already refreshed the token
+		defer s.tokenMu.Unlock()
+		return user.AccessToken, nil
+	}
+	refreshToken := user.RefreshToken
+	s.tokenMu.Unlock()
+
+	result, err, _ := s.refreshes.Do(user.ID, func() (interface{}, error) {
+		return s.refreshAndStoreTokens(user, refreshToken)
+	})
+	if err != nil {
+		return "", err
+	}
+
+	// Callers sharing the refresh may hold their own copy of the user
+	refreshed := result.(*models.User)
+	s.tokenMu.Lock()
+	defer s.tokenMu.Unlock()
+	user.AccessToken = refreshed.AccessToken
+	user.RefreshToken = refreshed.RefreshToken
+	user.TokenExpiry = refreshed.TokenExpiry
+	return user.AccessToken, nil
+}
+
+// refreshAndStoreTokens exchanges the refresh token with Strava and saves the new tokens,
+// returning a copy of the user holding them
+func (s *stravaService) refreshAndStoreTokens(user *models.User, refreshToken string) (*models.User, error) {
+	log.Printf("Token expired for user %s, attempting refresh", user.ID)
+
+	tokenResp, refreshErr := s.RefreshToken(refreshToken)
+	if refreshErr != nil {
+		log.Printf("Token refresh failed for user %s: %v", user.ID, refreshErr)
+		return nil, fmt.Errorf("failed to refresh Strava token: %w", refreshErr)
+	}
+
+	s.tokenMu.Lock()
+	updated := *user
+	s.tokenMu.Unlock()
+	updated.AccessToken = tokenResp.AccessToken
+	updated.RefreshToken = tokenResp.RefreshToken
+	updated.TokenExpiry = time.Unix(tokenResp.ExpiresAt, 0)
+
+	if updateErr := s.userRepo.Update(context.Background(), &updated); updateErr != nil {
+		log.Printf("Failed to update user tokens in database for user %s: %v", user.ID, updateErr)
+		return nil, fmt.Errorf("failed to save refreshed tokens: %w", updateErr)
+	}
+
+	log.Printf("Successfully refreshed tokens for user %s", user.ID)
+
+	return &updated, nil
+}
+
+// RateLimits returns the shared Strava budget tracker
+func (s *stravaService) RateLimits() *StravaRateLimitTracker {
+	return s.rateLimits
+}
+
+// reserveRequest takes a request from the Strava budget before it is sent, at the priority
+// carried by ctx. Every request queues for budget, so tools making several requests wait
+// between them instead of failing part way through; the user is told about the wait.
+func (s *stravaService) reserveRequest(ctx context.Context, method, endpoint string) error {
+	if s.rateLimits != nil {
+		err := s.rateLimits.WaitAndReserve(ctx, StravaPriorityFromContext(ctx), method == http.MethodGet, func(wait time.Duration) {
+			reportToolProgress(ctx, stravaWaitMessage(wait))
+		})
+		if err != nil {
+			log.Printf("Strava API rate limit exceeded for endpoint: %s: %v", endpoint, err)
+			return err
+		}
+		return nil
+	}
+
+	if s.rateLimiter != nil && !s.rateLimiter.Allow() {
 		log.Printf("Strava API rate limit exceeded for endpoint: %s", endpoint)
-		return nil, ErrRateLimitExceeded
+		return ErrRateLimitExceeded
+	}
+
+	return nil
+}
+
+// trackResponse feeds Strava's rate limit headers back into the shared budget
+func (s *stravaService) trackResponse(method string, resp *http.Response) {
+	if s.rateLimits == nil {
+		return
+	}
+
+	s.rateLimits.Update(resp.Header)
+	if resp.StatusCode == http.StatusTooManyRequests {
+		s.rateLimits.MarkExhausted(method == http.MethodGet)
+	}
+}
+
+func (s *stravaService) defaultMakeRequest(ctx context.Context, method, endpoint string, accessToken string, params url.Values) ([]byte, error) {
+	// Check rate limit
+	if err := s.reserveRequest(ctx, method, endpoint); err != nil {
+		return nil, err
 	}
 
 	baseURL := "https://www.strava.com/api/v3"
@@ -614,6 +721,8 @@ func (s *stravaService) defaultMakeRequest(method, endpoint string, accessToken
 	}
 	defer resp.Body.Close()
 
+	s.trackResponse(method, resp)
+
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read response body: %w", err)
@@ -651,10 +760,10 @@ func (s *stravaService) defaultMakeRequest(method, endpoint string, accessToken
 	}
 }
 
-func (s *stravaService) GetAthleteProfile(user *models.User) (*StravaAthleteWithZones, error) {
+func (s *stravaService) GetAthleteProfile(ctx context.Context, user *models.User) (*StravaAthleteWithZones, error) {
 	// First get the basic athlete profile
 	apiCall := func(accessToken string) (any, error) {
-		body, err := s.makeRequest("GET", "/athlete", accessToken, nil)
+		body, err := s.makeRequest(ctx, "GET", "/athlete", accessToken, nil)
 		if err != nil {
 			return nil, fmt.Errorf("failed to get athlete profile: %w", err)
 		}
@@ -680,7 +789,7 @@ func (s *stravaService) GetAthleteProfile(user *models.User) (*StravaAthleteWith
 	}
 
 	// Attempt to fetch zone data - this is optional and may not be available
-	zones, err := s.GetAthleteZones(user)
+	zones, err := s.GetAthleteZones(ctx, user)
 	if err != nil {
 		// Log the error but don't fail the entire request
 		// Zones may not be configured or accessible
@@ -692,9 +801,9 @@ func (s *stravaService) GetAthleteProfile(user *models.User) (*StravaAthleteWith
 	return profileWithZones, nil
 }
 
-func (s *stravaService) GetAthleteZones(user *models.User) (*StravaAthleteZones, error) {
+func (s *stravaService) GetAthleteZones(ctx context.Context, user *models.User) (*StravaAthleteZones, error) {
 	apiCall := func(accessToken string) (any, error) {
-		body, err := s.makeRequest("GET", "/athlete/zones", accessToken, nil)
+		body, err := s.makeRequest(ctx, "GET", "/athlete/zones", accessToken, nil)
 		if err != nil {
 			return nil, fmt.Errorf("failed to get athlete zones: %w", err)
 		}
@@ -716,7 +825,7 @@ func (s *stravaService) GetAthleteZones(user *models.User) (*StravaAthleteZones,
 	return result.(*StravaAthleteZones), nil
 }
 
-func (s *stravaService) GetActivities(user *models.User, params ActivityParams) ([]*StravaActivity, error) {
+func (s *stravaService) GetActivities(ctx context.Context, user *models.User, params ActivityParams) ([]*StravaActivity, error) {
 	apiCall := func(accessToken string) (any, error) {
 		urlParams := url.Values{}
 
@@ -733,7 +842,7 @@ func (s *stravaService) GetActivities(user *models.User, params ActivityParams)
 			urlParams.Set("per_page", strconv.Itoa(params.PerPage))
 		}
 
-		body, err := s.makeRequest("GET", "/athlete/activities", accessToken, urlParams)
+		body, err := s.makeRequest(ctx, "GET", "/athlete/activities", accessToken, urlParams)
 		if err != nil {
 			return nil, fmt.Errorf("failed to get activities: %w", err)
 		}
@@ -754,11 +863,11 @@ func (s *stravaService) GetActivities(user *models.User, params ActivityParams)
 	return result.([]*StravaActivity), nil
 }
 
-func (s *stravaService) GetActivityDetail(user *models.User, activityID int64) (*StravaActivityDetail, error) {
+func (s *stravaService) GetActivityDetail(ctx context.Context, user *models.User, activityID int64) (*StravaActivityDetail, error) {
 	apiCall := func(accessToken string) (any, error) {
 		endpoint := fmt.Sprintf("/activities/%d", activityID)
 
-		body, err := s.makeRequest("GET", endpoint, accessToken, nil)
+		body, err := s.makeRequest(ctx, "GET", endpoint, accessToken, nil)
 
 		if err != nil {
 			return nil, fmt.Errorf("failed to get activity detail: %w", err)
@@ -780,9 +889,9 @@ func (s *stravaService) GetActivityDetail(user *models.User, activityID int64) (
 	return result.(*StravaActivityDetail), nil
 }
 
-func (s *stravaService) GetActivityDetailWithZones(user *models.User, activityID int64) (*StravaActivityDetailWithZones, error) {
+func (s *stravaService) GetActivityDetailWithZones(ctx context.Context, user *models.User, activityID int64) (*StravaActivityDetailWithZones, error) {
 	// First get the basic activity detail
-	activityDetail, err := s.GetActivityDetail(user, activityID)
+	activityDetail, err := s.GetActivityDetail(ctx, user, activityID)
 	if err != nil {
 		return nil, err
 	}
@@ -794,7 +903,7 @@ func (s *stravaService) GetActivityDetailWithZones(user *models.User, activityID
 
 	// Attempt to fetch zone data if available - this is optional and may not be available
 	if len(activityDetail.AvailableZones) > 0 {
-		zones, err := s.GetActivityZones(user, activityID)
+		zones, err := s.GetActivityZones(ctx, user, activityID)
 		if err != nil {
 			// Log the error but don't fail the entire request
 			// Zone data may not be available for all activities
@@ -807,7 +916,7 @@ func (s *stravaService) GetActivityDetailWithZones(user *models.User, activityID
 	return activityWithZones, nil
 }
 
-func (s *stravaService) GetActivityStreams(user *models.User, activityID int64, streamTypes []string, resolution string) (*StravaStreams, error) {
+func (s *stravaService) GetActivityStreams(ctx context.Context, user *models.User, activityID int64, streamTypes []string, resolution string) (*StravaStreams, error) {
 	apiCall := func(accessToken string) (any, error) {
 		endpoint := fmt.Sprintf("/activities/%d/streams", activityID)
 
@@ -818,7 +927,7 @@ func (s *stravaService) GetActivityStreams(user *models.User, activityID int64,
 		}
 		params.Set("key_by_type", "true")
 
-		body, err := s.makeRequest("GET", endpoint, accessToken, params)
+		body, err := s.makeRequest(ctx, "GET", endpoint, accessToken, params)
 		if err != nil {
 			return nil, fmt.Errorf("failed to get activity streams: %w", err)
 		}
@@ -846,11 +955,11 @@ func (s *stravaService) GetActivityStreams(user *models.User, activityID int64,
 	return result.(*StravaStreams), nil
 }
 
-func (s *stravaService) GetActivityZones(user *models.User, activityID int64) (*StravaActivityZones, error) {
+func (s *stravaService) GetActivityZones(ctx context.Context, user *models.User, activityID int64) (*StravaActivityZones, error) {
 	apiCall := func(accessToken string) (any, error) {
 		endpoint := fmt.Sprintf("/activities/%d/zones", activityID)
 
-		body, err := s.makeRequest("GET", endpoint, accessToken, nil)
+		body, err := s.makeRequest(ctx, "GET", endpoint, accessToken, nil)
 		if err != nil {
 			return nil, fmt.Errorf("failed to get activity zones: %w", err)
 		}
@@ -1,6 +1,7 @@
 package services
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
@@ -291,7 +292,7 @@ func TestStravaService_GetActivityDetail_ComprehensiveFields(t *testing.T) {
 		TokenExpiry:  time.Now().Add(time.Hour),
 	}
 
-	activity, err := service.GetActivityDetail(testUser, 987654321)
+	activity, err := service.GetActivityDetail(context.Background(), testUser, 987654321)
 
 	require.NoError(t, err)
 	require.NotNil(t, activity)
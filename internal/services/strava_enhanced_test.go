@@ -1,6 +1,7 @@
 package services
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
@@ -281,7 +282,7 @@ func TestStravaService_GetActivityDetail_Enhanced(t *testing.T) {
 		TokenExpiry:  time.Now().Add(time.Hour),
 	}
 
-	activity, err := service.GetActivityDetail(testUser, 123456)
+	activity, err := service.GetActivityDetail(context.Background(), testUser, 123456)
 
 	require.NoError(t, err)
 	
@@ -429,7 +430,7 @@ func TestStravaService_GetActivityZones(t *testing.T) {
 		TokenExpiry:  time.Now().Add(time.Hour),
 	}
 
-	zones, err := service.GetActivityZones(testUser, 123456)
+	zones, err := service.GetActivityZones(context.Background(), testUser, 123456)
 
 	require.NoError(t, err)
 	require.NotNil(t, zones)
@@ -479,7 +480,7 @@ func TestStravaService_GetActivityZones_NotFound(t *testing.T) {
 		TokenExpiry:  time.Now().Add(time.Hour),
 	}
 
-	zones, err := service.GetActivityZones(testUser, 123456)
+	zones, err := service.GetActivityZones(context.Background(), testUser, 123456)
 
 	assert.Error(t, err)
 	assert.Nil(t, zones)
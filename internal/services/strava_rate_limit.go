@@ -0,0 +1,444 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"bodda/internal/config"
+)
+
+// StravaRequestPriority orders requests competing for the shared Strava budget
+type StravaRequestPriority int
+
+const (
+	// StravaPriorityInteractive is used for requests a user is actively waiting on, such as chat tool calls
+	StravaPriorityInteractive StravaRequestPriority = iota
+	// StravaPriorityBackground is used for work nobody is waiting on, such as sync jobs.
+	// Background requests cannot use the share of the budget reserved for interactive requests.
+	StravaPriorityBackground
+)
+
+func (p StravaRequestPriority) String() string {
+	switch p {
+	case StravaPriorityInteractive:
+		return "interactive"
+	case StravaPriorityBackground:
+		return "background"
+	default:
+		return "unknown"
+	}
+}
+
+type stravaPriorityKey struct{}
+
+// WithStravaPriority returns a context whose Strava requests use the priority class.
+// Batch work such as team dashboards runs at StravaPriorityBackground so it cannot
+// take the budget reserved for users waiting on a reply.
+func WithStravaPriority(ctx context.Context, priority StravaRequestPriority) context.Context {
+	return context.WithValue(ctx, stravaPriorityKey{}, priority)
+}
+
+// StravaPriorityFromContext returns the priority class set with WithStravaPriority,
+// or StravaPriorityInteractive when none was set
+func StravaPriorityFromContext(ctx context.Context) StravaRequestPriority {
+	if ctx != nil {
+		if priority, ok := ctx.Value(stravaPriorityKey{}).(StravaRequestPriority); ok {
+			return priority
+		}
+	}
+	return StravaPriorityInteractive
+}
+
+const (
+	stravaShortTermWindow = 15 * time.Minute
+	// Background waiters re-check this often while interactive requests are queued ahead of them
+	stravaBackgroundPollInterval = 250 * time.Millisecond
+)
+
+// StravaRateLimitError reports which Strava budget is exhausted and when it frees up
+type StravaRateLimitError struct {
+	Window     string        `json:"window"` // "15min", "daily", "read_15min" or "read_daily"
+	RetryAfter time.Duration `json:"retry_after"`
+}
+
+func (e *StravaRateLimitError) Error() string {
+	return fmt.Sprintf("%s: %s budget exhausted, retry in %s", ErrRateLimitExceeded.Error(), e.Window, e.RetryAfter.Round(time.Second))
+}
+
+// Is allows errors.Is(err, ErrRateLimitExceeded)
+func (e *StravaRateLimitError) Is(target error) bool {
+	return target == ErrRateLimitExceeded
+}
+
+// rateLimitWindow tracks usage of one Strava budget until it resets
+type rateLimitWindow struct {
+	name     string
+	limit    int
+	usage    int
+	resetsAt time.Time
+	daily    bool
+}
+
+// roll resets usage once the window has passed. Strava resets the 15 minute
+// budget on the quarter hour and the daily budget at midnight UTC.
+func (w *rateLimitWindow) roll(now time.Time) {
+	if now.Before(w.resetsAt) {
+		return
+	}
+	w.usage = 0
+	w.resetsAt = nextRateLimitReset(now, w.daily)
+}
+
+func nextRateLimitReset(now time.Time, daily bool) time.Time {
+	utc := now.UTC()
+	if daily {
+		return time.Date(utc.Year(), utc.Month(), utc.Day(), 0, 0, 0, 0, time.UTC).AddDate(0, 0, 1)
+	}
+	return utc.Truncate(stravaShortTermWindow).Add(stravaShortTermWindow)
+}
+
+// StravaRateLimitStatus is a snapshot of the shared Strava budget for metrics
+type StravaRateLimitStatus struct {
+	Windows          []StravaRateLimitWindowStatus `json:"windows"`
+	Requests         int64                         `json:"requests"`
+	Throttled        int64                         `json:"throttled"`
+	Queued           int64                         `json:"queued"`
+	QueuedNow        map[string]int                `json:"queued_now"`
+	TotalWaitMs      int64                         `json:"total_wait_ms"`
+	LastHeaderUpdate *time.Time                    `json:"last_header_update,omitempty"`
+}
+
+// StravaRateLimitWindowStatus reports usage of a single Strava budget
+type StravaRateLimitWindowStatus struct {
+	Window    string    `json:"window"`
+	Limit     int       `json:"limit"`
+	Usage     int       `json:"usage"`
+	Remaining int       `json:"remaining"`
+	ResetsAt  time.Time `json:"resets_at"`
+}
+
+// StravaRateLimitProvider is implemented by Strava services that track the shared API budget
+type StravaRateLimitProvider interface {
+	RateLimits() *StravaRateLimitTracker
+}
+
+// StravaRateLimitTracker tracks Strava's two-tier (15 minute and daily, overall and read)
+// application-wide budget. Limits and usage are taken from the X-RateLimit-* and
+// X-ReadRateLimit-* response headers and counted locally between responses.
+// It is safe for concurrent use and shared by all users.
+type StravaRateLimitTracker struct {
+	mu sync.Mutex
+
+	shortTerm     rateLimitWindow
+	daily         rateLimitWindow
+	readShortTerm rateLimitWindow
+	readDaily     rateLimitWindow
+
+	backgroundReservePercent int
+	maxQueueWait             time.Duration
+	now                      func() time.Time
+
+	waiting          map[StravaRequestPriority]int
+	requests         int64
+	throttled        int64
+	queued           int64
+	totalWait        time.Duration
+	lastHeaderUpdate time.Time
+}
+
+// NewStravaRateLimitTracker creates a tracker seeded with the configured limits
+func NewStravaRateLimitTracker(cfg config.StravaRateLimitConfig) *StravaRateLimitTracker {
+	orDefault := func(value, fallback int) int {
+		if value <= 0 {
+			return fallback
+		}
+		return value
+	}
+
+	t := &StravaRateLimitTracker{
+		shortTerm:                rateLimitWindow{name: "15min", limit: orDefault(cfg.ShortTermLimit, 200)},
+		daily:                    rateLimitWindow{name: "daily", limit: orDefault(cfg.DailyLimit, 2000), daily: true},
+		readShortTerm:            rateLimitWindow{name: "read_15min", limit: orDefault(cfg.ReadShortTermLimit, 100)},
+		readDaily:                rateLimitWindow{name: "read_daily", limit: orDefault(cfg.ReadDailyLimit, 1000), daily: true},
+		backgroundReservePercent: cfg.BackgroundReservePercent,
+		maxQueueWait:             time.Duration(cfg.MaxQueueWait) * time.Second,
+		now:                      time.Now,
+		waiting:                  make(map[StravaRequestPriority]int),
+	}
+	return t
+}
+
+func (t *StravaRateLimitTracker) windows(read bool) []*rateLimitWindow {
+	if read {
+		return []*rateLimitWindow{&t.shortTerm, &t.daily, &t.readShortTerm, &t.readDaily}
+	}
+	return []*rateLimitWindow{&t.shortTerm, &t.daily}
+}
+
+func (t *StravaRateLimitTracker) rollAll(now time.Time) {
+	for _, w := range t.windows(true) {
+		w.roll(now)
+	}
+}
+
+// budgetFor returns how much of a window's limit the priority class may use
+func (t *StravaRateLimitTracker) budgetFor(w *rateLimitWindow, priority StravaRequestPriority) int {
+	if priority == StravaPriorityInteractive {
+		return w.limit
+	}
+	return w.limit * (100 - t.backgroundReservePercent) / 100
+}
+
+// blockingWindow returns the first exhausted window for the request, or nil when it may proceed.
+// Must be called with mu held and windows rolled.
+func (t *StravaRateLimitTracker) blockingWindow(priority StravaRequestPriority, read bool) *rateLimitWindow {
+	for _, w := range t.windows(read) {
+		if w.usage >= t.budgetFor(w, priority) {
+			return w
+		}
+	}
+	return nil
+}
+
+// Reserve takes one request from the budget without waiting.
+// It returns a *StravaRateLimitError when the budget for the priority class is exhausted.
+func (t *StravaRateLimitTracker) Reserve(priority StravaRequestPriority, read bool) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := t.now()
+	t.rollAll(now)
+
+	if w := t.blockingWindow(priority, read); w != nil {
+		t.throttled++
+		return &StravaRateLimitError{Window: w.name, RetryAfter: w.resetsAt.Sub(now)}
+	}
+
+	for _, w := range t.windows(read) {
+		w.usage++
+	}
+	t.requests++
+	return nil
+}
+
+// Wait blocks until the budget has room for a request of the given priority without taking it.
+// Interactive requests are let through before queued background requests.
+// onWait is called once with the estimated wait when the request has to queue.
+// Wait fails immediately when the estimated wait exceeds the configured maximum.
+func (t *StravaRateLimitTracker) Wait(ctx context.Context, priority StravaRequestPriority, read bool, onWait func(time.Duration)) error {
+	return t.wait(ctx, priority, read, false, onWait)
+}
+
+// WaitAndReserve blocks like Wait and takes one request from the budget once it has room. The
+// check and the reservation happen under one lock, so concurrent callers cannot all see the same
+// free budget and then be refused.
+func (t *StravaRateLimitTracker) WaitAndReserve(ctx context.Context, priority StravaRequestPriority, read bool, onWait func(time.Duration)) error {
+	return t.wait(ctx, priority, read, true, onWait)
+}
+
+func (t *StravaRateLimitTracker) wait(ctx context.Context, priority StravaRequestPriority, read, reserve bool, onWait func(time.Duration)) error {
+	start := time.Now()
+	queued := false
+
+	defer func() {
+		if queued {
+			t.mu.Lock()
+			t.waiting[priority]--
+			t.totalWait += time.Since(start)
+			t.mu.Unlock()
+		}
+	}()
+
+	for {
+		t.mu.Lock()
+		now := t.now()
+		t.rollAll(now)
+
+		var wait time.Duration
+		if w := t.blockingWindow(priority, read); w != nil {
+			wait = w.resetsAt.Sub(now)
+		} else if priority != StravaPriorityInteractive && t.waiting[StravaPriorityInteractive] > 0 {
+			wait = stravaBackgroundPollInterval
+		} else {
+			if reserve {
+				for _, w := range t.windows(read) {
+					w.usage++
+				}
+				t.requests++
+			}
+			t.mu.Unlock()
+			return nil
+		}
+
+		// Waiters that lose the budget to others when a window resets may have to wait again, but
+		// never longer than the configured maximum in total
+		waited := time.Duration(0)
+		if queued {
+			waited = time.Since(start)
+		}
+		if waited+wait > t.maxQueueWait {
+			t.throttled++
+			t.mu.Unlock()
+			return &StravaRateLimitError{Window: t.blockingWindowName(priority, read), RetryAfter: wait}
+		}
+		if !queued {
+			queued = true
+			t.queued++
+			t.waiting[priority]++
+		}
+		t.mu.Unlock()
+
+		if onWait != nil {
+			onWait(wait)
+			onWait = nil
+		}
+
+		slog.InfoContext(ctx, "Waiting for Strava rate limit budget",
+			"priority", priority.String(),
+			"read", read,
+			"wait_ms", wait.Milliseconds())
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+func (t *StravaRateLimitTracker) blockingWindowName(priority StravaRequestPriority, read bool) string {
+	if w := t.blockingWindow(priority, read); w != nil {
+		return w.name
+	}
+	return "queue"
+}
+
+// Update applies the limits and usage Strava reported in response headers
+func (t *StravaRateLimitTracker) Update(header http.Header) {
+	overallLimit, okLimit := parseRateLimitHeader(header.Get("X-RateLimit-Limit"))
+	overallUsage, okUsage := parseRateLimitHeader(header.Get("X-RateLimit-Usage"))
+	readLimit, okReadLimit := parseRateLimitHeader(header.Get("X-ReadRateLimit-Limit"))
+	readUsage, okReadUsage := parseRateLimitHeader(header.Get("X-ReadRateLimit-Usage"))
+
+	if !okLimit && !okUsage && !okReadLimit && !okReadUsage {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := t.now()
+	t.rollAll(now)
+	t.lastHeaderUpdate = now
+
+	if okLimit {
+		t.shortTerm.limit, t.daily.limit = overallLimit[0], overallLimit[1]
+	}
+	if okUsage {
+		t.shortTerm.usage, t.daily.usage = overallUsage[0], overallUsage[1]
+	}
+	if okReadLimit {
+		t.readShortTerm.limit, t.readDaily.limit = readLimit[0], readLimit[1]
+	}
+	if okReadUsage {
+		t.readShortTerm.usage, t.readDaily.usage = readUsage[0], readUsage[1]
+	}
+
+	slog.Debug("Updated Strava rate limit budget from headers",
+		"short_term_remaining", t.shortTerm.limit-t.shortTerm.usage,
+		"daily_remaining", t.daily.limit-t.daily.usage,
+		"read_short_term_remaining", t.readShortTerm.limit-t.readShortTerm.usage,
+		"read_daily_remaining", t.readDaily.limit-t.readDaily.usage)
+}
+
+// MarkExhausted records a 429 response. When the headers did not already show
+// an exhausted budget, the 15 minute window is treated as used up.
+func (t *StravaRateLimitTracker) MarkExhausted(read bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.rollAll(t.now())
+	t.throttled++
+
+	if t.blockingWindow(StravaPriorityInteractive, read) != nil {
+		return
+	}
+	if read {
+		t.readShortTerm.usage = t.readShortTerm.limit
+	}
+	t.shortTerm.usage = t.shortTerm.limit
+}
+
+// Status returns a snapshot of the budget for metrics and dashboards
+func (t *StravaRateLimitTracker) Status() StravaRateLimitStatus {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.rollAll(t.now())
+
+	status := StravaRateLimitStatus{
+		Requests:    t.requests,
+		Throttled:   t.throttled,
+		Queued:      t.queued,
+		QueuedNow:   make(map[string]int),
+		TotalWaitMs: t.totalWait.Milliseconds(),
+	}
+
+	for _, w := range t.windows(true) {
+		remaining := w.limit - w.usage
+		if remaining < 0 {
+			remaining = 0
+		}
+		status.Windows = append(status.Windows, StravaRateLimitWindowStatus{
+			Window:    w.name,
+			Limit:     w.limit,
+			Usage:     w.usage,
+			Remaining: remaining,
+			ResetsAt:  w.resetsAt,
+		})
+	}
+
+	for priority, count := range t.waiting {
+		if count > 0 {
+			status.QueuedNow[priority.String()] = count
+		}
+	}
+
+	if !t.lastHeaderUpdate.IsZero() {
+		updated := t.lastHeaderUpdate
+		status.LastHeaderUpdate = &updated
+	}
+
+	return status
+}
+
+// parseRateLimitHeader parses Strava's "15min,daily" header format
+func parseRateLimitHeader(value string) ([2]int, bool) {
+	var result [2]int
+	if value == "" {
+		return result, false
+	}
+
+	parts := strings.Split(value, ",")
+	if len(parts) != 2 {
+		return result, false
+	}
+
+	for i, part := range parts {
+		n, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil || n < 0 {
+			return result, false
+		}
+		result[i] = n
+	}
+
+	return result, true
+}
@@ -0,0 +1,318 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"bodda/internal/config"
+	"bodda/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestRateLimitTracker(now time.Time) *StravaRateLimitTracker {
+	tracker := NewStravaRateLimitTracker(config.StravaRateLimitConfig{
+		ShortTermLimit:           10,
+		DailyLimit:               100,
+		ReadShortTermLimit:       5,
+		ReadDailyLimit:           50,
+		BackgroundReservePercent: 20,
+		MaxQueueWait:             60,
+	})
+	tracker.now = func() time.Time { return now }
+	return tracker
+}
+
+func TestParseRateLimitHeader(t *testing.T) {
+	values, ok := parseRateLimitHeader("200, 2000")
+	assert.True(t, ok)
+	assert.Equal(t, [2]int{200, 2000}, values)
+
+	for _, invalid := range []string{"", "200", "a,b", "1,2,3", "-1,5"} {
+		_, ok := parseRateLimitHeader(invalid)
+		assert.False(t, ok, invalid)
+	}
+}
+
+func TestStravaRateLimitTracker_Reserve(t *testing.T) {
+	now := time.Date(2025, 3, 15, 10, 7, 0, 0, time.UTC)
+
+	t.Run("read requests stop at the read budget", func(t *testing.T) {
+		tracker := newTestRateLimitTracker(now)
+		for i := 0; i < 5; i++ {
+			require.NoError(t, tracker.Reserve(StravaPriorityInteractive, true))
+		}
+
+		err := tracker.Reserve(StravaPriorityInteractive, true)
+		require.Error(t, err)
+		assert.True(t, errors.Is(err, ErrRateLimitExceeded))
+
+		var limitErr *StravaRateLimitError
+		require.True(t, errors.As(err, &limitErr))
+		assert.Equal(t, "read_15min", limitErr.Window)
+		assert.Equal(t, 8*time.Minute, limitErr.RetryAfter, "15 minute budget resets on the quarter hour")
+
+		// Writes only count against the overall budget
+		assert.NoError(t, tracker.Reserve(StravaPriorityInteractive, false))
+	})
+
+	t.Run("background requests leave the reserve to interactive ones", func(t *testing.T) {
+		tracker := newTestRateLimitTracker(now)
+		for i := 0; i < 8; i++ {
+			require.NoError(t, tracker.Reserve(StravaPriorityBackground, false))
+		}
+
+		assert.Error(t, tracker.Reserve(StravaPriorityBackground, false))
+		assert.NoError(t, tracker.Reserve(StravaPriorityInteractive, false))
+		assert.NoError(t, tracker.Reserve(StravaPriorityInteractive, false))
+		assert.Error(t, tracker.Reserve(StravaPriorityInteractive, false))
+	})
+
+	t.Run("budget frees up after the window resets", func(t *testing.T) {
+		current := now
+		tracker := newTestRateLimitTracker(now)
+		tracker.now = func() time.Time { return current }
+
+		for i := 0; i < 5; i++ {
+			require.NoError(t, tracker.Reserve(StravaPriorityInteractive, true))
+		}
+		require.Error(t, tracker.Reserve(StravaPriorityInteractive, true))
+
+		current = time.Date(2025, 3, 15, 10, 15, 0, 0, time.UTC)
+		assert.NoError(t, tracker.Reserve(StravaPriorityInteractive, true))
+	})
+}
+
+func TestStravaRateLimitTracker_UpdateFromHeaders(t *testing.T) {
+	now := time.Date(2025, 3, 15, 23, 50, 0, 0, time.UTC)
+	tracker := newTestRateLimitTracker(now)
+
+	header := http.Header{}
+	header.Set("X-RateLimit-Limit", "200,2000")
+	header.Set("X-RateLimit-Usage", "20,2000")
+	header.Set("X-ReadRateLimit-Limit", "100,1000")
+	header.Set("X-ReadRateLimit-Usage", "10,900")
+	tracker.Update(header)
+
+	status := tracker.Status()
+	require.Len(t, status.Windows, 4)
+	assert.Equal(t, StravaRateLimitWindowStatus{Window: "15min", Limit: 200, Usage: 20, Remaining: 180, ResetsAt: time.Date(2025, 3, 16, 0, 0, 0, 0, time.UTC)}, status.Windows[0])
+	assert.Equal(t, 0, status.Windows[1].Remaining)
+	assert.Equal(t, 100, status.Windows[3].Remaining)
+	require.NotNil(t, status.LastHeaderUpdate)
+
+	var limitErr *StravaRateLimitError
+	require.True(t, errors.As(tracker.Reserve(StravaPriorityInteractive, true), &limitErr))
+	assert.Equal(t, "daily", limitErr.Window)
+	assert.Equal(t, 10*time.Minute, limitErr.RetryAfter)
+}
+
+func TestStravaRateLimitTracker_MarkExhausted(t *testing.T) {
+	tracker := newTestRateLimitTracker(time.Date(2025, 3, 15, 10, 7, 0, 0, time.UTC))
+	tracker.MarkExhausted(true)
+
+	assert.Error(t, tracker.Reserve(StravaPriorityInteractive, false))
+	assert.Equal(t, int64(2), tracker.Status().Throttled)
+}
+
+func TestStravaRateLimitTracker_Wait(t *testing.T) {
+	t.Run("returns immediately when budget is available", func(t *testing.T) {
+		tracker := newTestRateLimitTracker(time.Now())
+		called := false
+		err := tracker.Wait(context.Background(), StravaPriorityInteractive, true, func(time.Duration) { called = true })
+		assert.NoError(t, err)
+		assert.False(t, called)
+	})
+
+	t.Run("fails fast when the wait exceeds the queue limit", func(t *testing.T) {
+		tracker := newTestRateLimitTracker(time.Date(2025, 3, 15, 10, 1, 0, 0, time.UTC))
+		for i := 0; i < 5; i++ {
+			require.NoError(t, tracker.Reserve(StravaPriorityInteractive, true))
+		}
+
+		err := tracker.Wait(context.Background(), StravaPriorityInteractive, true, nil)
+		var limitErr *StravaRateLimitError
+		require.True(t, errors.As(err, &limitErr))
+		assert.Equal(t, 14*time.Minute, limitErr.RetryAfter)
+	})
+
+	t.Run("queues until the window resets and reports the wait once", func(t *testing.T) {
+		reset := time.Date(2025, 3, 15, 10, 15, 0, 0, time.UTC)
+		base := reset.Add(-50 * time.Millisecond)
+		started := time.Now()
+
+		tracker := newTestRateLimitTracker(base)
+		tracker.now = func() time.Time { return base.Add(time.Since(started)) }
+		for i := 0; i < 5; i++ {
+			require.NoError(t, tracker.Reserve(StravaPriorityInteractive, true))
+		}
+
+		var notifications []time.Duration
+		err := tracker.Wait(context.Background(), StravaPriorityInteractive, true, func(wait time.Duration) {
+			notifications = append(notifications, wait)
+		})
+
+		require.NoError(t, err)
+		require.Len(t, notifications, 1)
+		assert.LessOrEqual(t, notifications[0], 50*time.Millisecond)
+
+		status := tracker.Status()
+		assert.Equal(t, int64(1), status.Queued)
+		assert.Empty(t, status.QueuedNow)
+	})
+
+	t.Run("stops waiting when the context is cancelled", func(t *testing.T) {
+		tracker := newTestRateLimitTracker(time.Date(2025, 3, 15, 10, 14, 0, 0, time.UTC))
+		for i := 0; i < 5; i++ {
+			require.NoError(t, tracker.Reserve(StravaPriorityInteractive, true))
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+
+		err := tracker.Wait(ctx, StravaPriorityInteractive, true, nil)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+}
+
+func TestStravaRateLimitTracker_WaitAndReserve(t *testing.T) {
+	reset := time.Date(2025, 3, 15, 10, 15, 0, 0, time.UTC)
+	base := reset.Add(-50 * time.Millisecond)
+	started := time.Now()
+
+	tracker := newTestRateLimitTracker(base)
+	tracker.now = func() time.Time { return base.Add(time.Since(started)) }
+	for i := 0; i < 4; i++ {
+		require.NoError(t, tracker.Reserve(StravaPriorityBackground, true))
+	}
+
+	// Six background readers queue for a budget of four per window; exactly four get through
+	// once the window resets and the others are refused instead of waiting for the next one
+	var wg sync.WaitGroup
+	var reserved, refused int32
+	for i := 0; i < 6; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := tracker.WaitAndReserve(context.Background(), StravaPriorityBackground, true, nil)
+			if err == nil {
+				atomic.AddInt32(&reserved, 1)
+			} else if errors.Is(err, ErrRateLimitExceeded) {
+				atomic.AddInt32(&refused, 1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, int32(4), reserved)
+	assert.Equal(t, int32(2), refused)
+	status := tracker.Status()
+	assert.Equal(t, 4, status.Windows[2].Usage)
+	assert.Empty(t, status.QueuedNow)
+}
+
+func TestStravaService_ReportsQueuedRequests(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(StravaActivityDetail{StravaActivity: StravaActivity{ID: 1}})
+	}))
+	defer server.Close()
+
+	cfg := &config.Config{StravaRateLimit: config.StravaRateLimitConfig{ReadShortTermLimit: 1, MaxQueueWait: 1000}}
+	service := NewTestStravaService(cfg, server.URL, &MockStravaUserRepository{})
+	user := &models.User{ID: "user-1", AccessToken: "test_token", TokenExpiry: time.Now().Add(time.Hour)}
+
+	_, err := service.GetActivityDetail(context.Background(), user, 1)
+	require.NoError(t, err)
+
+	// The second request of the tool call has to queue until the window resets
+	var messages []string
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	ctx = withToolProgress(ctx, func(message string) { messages = append(messages, message) })
+
+	_, err = service.GetActivityDetail(ctx, user, 1)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	require.Len(t, messages, 1)
+	assert.True(t, strings.HasPrefix(messages[0], "Strava is limiting requests right now"))
+}
+
+func TestStravaService_TracksRateLimitHeaders(t *testing.T) {
+	var hits int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		w.Header().Set("X-RateLimit-Limit", "200,2000")
+		w.Header().Set("X-RateLimit-Usage", "50,500")
+		w.Header().Set("X-ReadRateLimit-Limit", "100,1000")
+		w.Header().Set("X-ReadRateLimit-Usage", "100,500")
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(StravaActivityDetail{StravaActivity: StravaActivity{ID: 1}})
+	}))
+	defer server.Close()
+
+	cfg := &config.Config{StravaRateLimit: config.StravaRateLimitConfig{BackgroundReservePercent: 20, MaxQueueWait: 1}}
+	service := NewTestStravaService(cfg, server.URL, &MockStravaUserRepository{})
+	user := &models.User{ID: "user-1", AccessToken: "test_token", TokenExpiry: time.Now().Add(time.Hour)}
+
+	_, err := service.GetActivityDetail(context.Background(), user, 1)
+	require.NoError(t, err)
+
+	// The read budget reported by Strava is used up, so the next read is refused locally
+	_, err = service.GetActivityDetail(context.Background(), user, 1)
+	assert.True(t, errors.Is(err, ErrRateLimitExceeded))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&hits))
+
+	provider, ok := service.(StravaRateLimitProvider)
+	require.True(t, ok)
+	assert.Equal(t, 150, provider.RateLimits().Status().Windows[0].Remaining)
+}
+
+func TestStravaPriorityFromContext(t *testing.T) {
+	assert.Equal(t, StravaPriorityInteractive, StravaPriorityFromContext(context.Background()))
+
+	ctx := WithStravaPriority(context.Background(), StravaPriorityBackground)
+	assert.Equal(t, StravaPriorityBackground, StravaPriorityFromContext(ctx))
+}
+
+func TestStravaService_ReservesAtContextPriority(t *testing.T) {
+	var hits int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		// 85 of 100 reads used: past the background share, within the interactive reserve
+		w.Header().Set("X-ReadRateLimit-Limit", "100,1000")
+		w.Header().Set("X-ReadRateLimit-Usage", "85,500")
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(StravaActivityDetail{StravaActivity: StravaActivity{ID: 1}})
+	}))
+	defer server.Close()
+
+	cfg := &config.Config{StravaRateLimit: config.StravaRateLimitConfig{BackgroundReservePercent: 20, MaxQueueWait: 1}}
+	service := NewTestStravaService(cfg, server.URL, &MockStravaUserRepository{})
+	user := &models.User{ID: "user-1", AccessToken: "test_token", TokenExpiry: time.Now().Add(time.Hour)}
+
+	_, err := service.GetActivityDetail(context.Background(), user, 1)
+	require.NoError(t, err)
+
+	background := WithStravaPriority(context.Background(), StravaPriorityBackground)
+	_, err = service.GetActivityDetail(background, user, 1)
+	assert.True(t, errors.Is(err, ErrRateLimitExceeded), "background requests cannot use the interactive reserve")
+	assert.Equal(t, int32(1), atomic.LoadInt32(&hits))
+
+	_, err = service.GetActivityDetail(context.Background(), user, 1)
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&hits))
+}
+
+func TestStravaWaitMessage(t *testing.T) {
+	assert.Contains(t, stravaWaitMessage(30*time.Second), "30 seconds")
+	assert.Contains(t, stravaWaitMessage(4*time.Minute+20*time.Second), "4 minutes")
+}
@@ -4,8 +4,13 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -127,7 +132,7 @@ func TestStravaService_GetAthleteProfile(t *testing.T) {
 		TokenExpiry:  time.Now().Add(time.Hour),
 	}
 	
-	athleteWithZones, err := service.GetAthleteProfile(testUser)
+	athleteWithZones, err := service.GetAthleteProfile(context.Background(), testUser)
 	
 	require.NoError(t, err)
 	require.NotNil(t, athleteWithZones)
@@ -208,7 +213,7 @@ func TestStravaService_GetActivities(t *testing.T) {
 		TokenExpiry:  time.Now().Add(time.Hour),
 	}
 	
-	activities, err := service.GetActivities(testUser, ActivityParams{
+	activities, err := service.GetActivities(context.Background(), testUser, ActivityParams{
 		PerPage: 10,
 	})
 	
@@ -272,7 +277,7 @@ func TestStravaService_GetActivityDetail(t *testing.T) {
 		TokenExpiry:  time.Now().Add(time.Hour),
 	}
 	
-	activity, err := service.GetActivityDetail(testUser, 123456)
+	activity, err := service.GetActivityDetail(context.Background(), testUser, 123456)
 	
 	require.NoError(t, err)
 	assert.Equal(t, mockActivity.ID, activity.ID)
@@ -339,7 +344,7 @@ func TestStravaService_GetActivityStreams(t *testing.T) {
 		TokenExpiry:  time.Now().Add(time.Hour),
 	}
 	
-	streams, err := service.GetActivityStreams(testUser, 123456, []string{"time", "heartrate", "watts"}, "high")
+	streams, err := service.GetActivityStreams(context.Background(), testUser, 123456, []string{"time", "heartrate", "watts"}, "high")
 	
 	require.NoError(t, err)
 	assert.Len(t, streams.Time, 4)
@@ -371,7 +376,7 @@ func TestStravaService_ErrorHandling(t *testing.T) {
 			TokenExpiry:  time.Now().Add(time.Hour),
 		}
 		
-		_, err := service.GetAthleteProfile(testUser)
+		_, err := service.GetAthleteProfile(context.Background(), testUser)
 		assert.Error(t, err)
 	})
 	
@@ -395,7 +400,7 @@ func TestStravaService_ErrorHandling(t *testing.T) {
 			TokenExpiry:  time.Now().Add(time.Hour),
 		}
 		
-		_, err := service.GetAthleteProfile(testUser)
+		_, err := service.GetAthleteProfile(context.Background(), testUser)
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "rate limit exceeded")
 	})
@@ -531,6 +536,78 @@ func TestStravaService_ExecuteWithTokenRefresh(t *testing.T) {
 	})
 }
 
+// tokenRefreshTransport answers Strava token refreshes, blocking refreshes of the "slow" token
+// until release is closed
+type tokenRefreshTransport struct {
+	calls   int32
+	release chan struct{}
+}
+
+func (rt *tokenRefreshTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	atomic.AddInt32(&rt.calls, 1)
+	if err := req.ParseForm(); err != nil {
+		return nil, err
+	}
+	refreshToken := req.PostForm.Get("refresh_token")
+	if refreshToken == "slow" {
+		<-rt.release
+	}
+
+	body := fmt.Sprintf(`{"access_token":"new-%s","refresh_token":"%s","expires_at":%d}`, refreshToken, refreshToken, time.Now().Add(6*time.Hour).Unix())
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       io.NopCloser(strings.NewReader(body)),
+		Request:    req,
+	}, nil
+}
+
+func TestStravaService_RefreshUserTokenPerUser(t *testing.T) {
+	transport := &tokenRefreshTransport{release: make(chan struct{})}
+	mockUserRepo := &MockStravaUserRepository{}
+	mockUserRepo.On("Update", mock.Anything, mock.Anything).Return(nil)
+	service := &stravaService{
+		config:     &config.Config{},
+		httpClient: &http.Client{Transport: transport},
+		userRepo:   mockUserRepo,
+	}
+
+	// A slow refresh for one user does not hold up refreshes for others
+	slowUser := &models.User{ID: "slow-user", AccessToken: "stale", RefreshToken: "slow"}
+	slowDone := make(chan string)
+	go func() {
+		token, _ := service.refreshUserToken(slowUser, "stale")
+		slowDone <- token
+	}()
+
+	fastUser := &models.User{ID: "fast-user", AccessToken: "stale", RefreshToken: "fast"}
+	token, err := service.refreshUserToken(fastUser, "stale")
+	require.NoError(t, err)
+	assert.Equal(t, "new-fast", token)
+
+	// Concurrent requests for the same user share one refresh, including callers holding
+	// their own copy of the user
+	copies := []*models.User{slowUser, {ID: "slow-user", AccessToken: "stale", RefreshToken: "slow"}}
+	var wg sync.WaitGroup
+	for _, user := range copies {
+		wg.Add(1)
+		go func(user *models.User) {
+			defer wg.Done()
+			_, err := service.refreshUserToken(user, "stale")
+			assert.NoError(t, err)
+		}(user)
+	}
+	time.Sleep(20 * time.Millisecond)
+	close(transport.release)
+	wg.Wait()
+
+	assert.Equal(t, "new-slow", <-slowDone)
+	for _, user := range copies {
+		assert.Equal(t, "new-slow", service.currentAccessToken(user))
+	}
+	assert.Equal(t, int32(2), atomic.LoadInt32(&transport.calls), "one refresh per user")
+}
+
 func TestStravaService_RefreshToken(t *testing.T) {
 	t.Run("validates token response structure", func(t *testing.T) {
 		// Test the token response structure
@@ -1,6 +1,7 @@
 package services
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
@@ -64,7 +65,7 @@ func TestStravaService_GetAthleteProfile_ZoneIntegration(t *testing.T) {
 			TokenExpiry:  time.Now().Add(time.Hour),
 		}
 
-		result, err := service.GetAthleteProfile(testUser)
+		result, err := service.GetAthleteProfile(context.Background(), testUser)
 
 		require.NoError(t, err)
 		require.NotNil(t, result)
@@ -135,7 +136,7 @@ func TestStravaService_GetAthleteProfile_ZoneIntegration(t *testing.T) {
 			TokenExpiry:  time.Now().Add(time.Hour),
 		}
 
-		result, err := service.GetAthleteProfile(testUser)
+		result, err := service.GetAthleteProfile(context.Background(), testUser)
 
 		require.NoError(t, err)
 		require.NotNil(t, result)
@@ -188,7 +189,7 @@ func TestStravaService_GetAthleteProfile_ZoneIntegration(t *testing.T) {
 			TokenExpiry:  time.Now().Add(time.Hour),
 		}
 
-		result, err := service.GetAthleteProfile(testUser)
+		result, err := service.GetAthleteProfile(context.Background(), testUser)
 
 		// Should still succeed even if zones fail
 		require.NoError(t, err)
@@ -241,7 +242,7 @@ func TestStravaService_GetAthleteProfile_ZoneIntegration(t *testing.T) {
 			TokenExpiry:  time.Now().Add(time.Hour),
 		}
 
-		result, err := service.GetAthleteProfile(testUser)
+		result, err := service.GetAthleteProfile(context.Background(), testUser)
 
 		require.NoError(t, err)
 		require.NotNil(t, result)
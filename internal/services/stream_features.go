@@ -535,6 +535,23 @@ func CalculateCorrelations(streams *StravaStreams) *CorrelationAnalysis {
 		analysis.CadencePower = calculateCorrelation(cadenceFloat[:minLen], powerFloat[:minLen])
 	}
 
+	// Temperature vs Heart Rate correlation
+	if len(streams.Temp) > 0 && len(streams.Heartrate) > 0 {
+		minLen := len(streams.Temp)
+		if len(streams.Heartrate) < minLen {
+			minLen = len(streams.Heartrate)
+		}
+
+		tempFloat := make([]float64, minLen)
+		hrFloat := make([]float64, minLen)
+		for i := 0; i < minLen; i++ {
+			tempFloat[i] = float64(streams.Temp[i])
+			hrFloat[i] = float64(streams.Heartrate[i])
+		}
+
+		analysis.TemperatureHR = calculateCorrelation(tempFloat, hrFloat)
+	}
+
 	return analysis
 }
 
@@ -383,6 +383,16 @@ func TestCalculateCorrelations(t *testing.T) {
 				CadencePower:   1.0,
 			},
 		},
+		{
+			name: "temperature heart rate correlation",
+			streams: &StravaStreams{
+				Temp:      []int{20, 22, 24, 26, 28},
+				Heartrate: []int{140, 142, 145, 147, 150},
+			},
+			expected: &CorrelationAnalysis{
+				TemperatureHR: 1.0,
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -400,6 +410,9 @@ func TestCalculateCorrelations(t *testing.T) {
 			if math.Abs(result.CadencePower-tt.expected.CadencePower) > tolerance {
 				t.Errorf("CadencePower = %f, want %f", result.CadencePower, tt.expected.CadencePower)
 			}
+			if math.Abs(result.TemperatureHR-tt.expected.TemperatureHR) > tolerance {
+				t.Errorf("TemperatureHR = %f, want %f", result.TemperatureHR, tt.expected.TemperatureHR)
+			}
 		})
 	}
 }
@@ -1,6 +1,7 @@
 package services
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"math"
@@ -61,11 +62,9 @@ func (pc *PaginationCalculator) CalculateOptimalPageSize(currentContextTokens in
 	usableTokens := int(float64(availableTokens) * 0.8)
 	
 	// Estimate data points that fit in available tokens
-	// Rough estimate: each data point uses about 4 characters when serialized
-	charsPerDataPoint := 4.0
-	tokensPerDataPoint := charsPerDataPoint * pc.config.TokenPerCharRatio
+	perPointTokens := tokensPerDataPoint(pc.config.tokenCounter())
 	
-	optimalPageSize := int(float64(usableTokens) / tokensPerDataPoint)
+	optimalPageSize := int(float64(usableTokens) / perPointTokens)
 	
 	// Apply bounds
 	if optimalPageSize < 100 {
@@ -82,7 +81,7 @@ func (pc *PaginationCalculator) CalculateOptimalPageSize(currentContextTokens in
 }
 
 // EstimateTotalPages estimates the total number of pages for an activity
-func (pc *PaginationCalculator) EstimateTotalPages(user *models.User, activityID int64, streamTypes []string, resolution string, pageSize int) (int, error) {
+func (pc *PaginationCalculator) EstimateTotalPages(ctx context.Context, user *models.User, activityID int64, streamTypes []string, resolution string, pageSize int) (int, error) {
 	// Handle negative page size (full dataset request)
 	if pageSize < 0 {
 		return 1, nil
@@ -95,7 +94,7 @@ func (pc *PaginationCalculator) EstimateTotalPages(user *models.User, activityID
 		estimationResolution = resolution
 	}
 	
-	sampleStreams, err := pc.stravaService.GetActivityStreams(user, activityID, streamTypes, estimationResolution)
+	sampleStreams, err := pc.stravaService.GetActivityStreams(ctx, user, activityID, streamTypes, estimationResolution)
 	if err != nil {
 		return 0, fmt.Errorf("failed to get sample streams for estimation: %w", err)
 	}
@@ -131,17 +130,17 @@ func (pc *PaginationCalculator) EstimateTotalPages(user *models.User, activityID
 }
 
 // RequestSpecificDataChunk requests a specific chunk of stream data from Strava API
-func (pc *PaginationCalculator) RequestSpecificDataChunk(user *models.User, req *PaginatedStreamRequest) (*StravaStreams, error) {
+func (pc *PaginationCalculator) RequestSpecificDataChunk(ctx context.Context, user *models.User, req *PaginatedStreamRequest) (*StravaStreams, error) {
 	// Handle negative page size (full dataset request)
 	if req.PageSize < 0 {
 		log.Printf("Requesting full dataset for activity %d", req.ActivityID)
-		return pc.stravaService.GetActivityStreams(user, req.ActivityID, req.StreamTypes, req.Resolution)
+		return pc.stravaService.GetActivityStreams(ctx, user, req.ActivityID, req.StreamTypes, req.Resolution)
 	}
 	
 	// For positive page sizes, we need to implement chunking
 	// Since Strava API doesn't support direct pagination, we'll get the full dataset
 	// and then slice it to the requested page
-	fullStreams, err := pc.stravaService.GetActivityStreams(user, req.ActivityID, req.StreamTypes, req.Resolution)
+	fullStreams, err := pc.stravaService.GetActivityStreams(ctx, user, req.ActivityID, req.StreamTypes, req.Resolution)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get full stream data: %w", err)
 	}
@@ -286,14 +285,13 @@ func (pc *PaginationCalculator) countDataPoints(data *StravaStreams) int {
 
 // EstimatePageTokens estimates the token count for a specific page
 func (pc *PaginationCalculator) EstimatePageTokens(pageSize int, streamTypeCount int) int {
-	// Rough estimate: each data point uses about 4 characters per stream type when serialized
-	charsPerDataPoint := 4.0 * float64(streamTypeCount)
-	totalChars := charsPerDataPoint * float64(pageSize)
+	// Each data point is serialized once per stream type
+	totalTokens := tokensPerDataPoint(pc.config.tokenCounter()) * float64(streamTypeCount) * float64(pageSize)
 	
 	// Add overhead for JSON structure and formatting
-	totalChars *= 1.2
+	totalTokens *= 1.2
 	
-	estimatedTokens := int(totalChars * pc.config.TokenPerCharRatio)
+	estimatedTokens := int(totalTokens)
 	
 	return estimatedTokens
 }
@@ -109,12 +109,21 @@ type ProcessedStreamResult struct {
 
 // StreamConfig holds configuration for stream processing
 type StreamConfig struct {
-	MaxContextTokens    int     `json:"max_context_tokens"`
-	TokenPerCharRatio   float64 `json:"token_per_char_ratio"`
-	DefaultPageSize     int     `json:"default_page_size"`
-	MaxPageSize         int     `json:"max_page_size"`
-	RedactionEnabled    bool    `json:"redaction_enabled"`
-	StravaResolutions   []string `json:"strava_resolutions"`
+	MaxContextTokens    int          `json:"max_context_tokens"`
+	TokenPerCharRatio   float64      `json:"token_per_char_ratio"`
+	DefaultPageSize     int          `json:"default_page_size"`
+	MaxPageSize         int          `json:"max_page_size"`
+	RedactionEnabled    bool         `json:"redaction_enabled"`
+	StravaResolutions   []string     `json:"strava_resolutions"`
+	TokenCounter        TokenCounter `json:"-"` // Falls back to TokenPerCharRatio when nil
+}
+
+// tokenCounter returns the configured token counter or the character-ratio heuristic
+func (c *StreamConfig) tokenCounter() TokenCounter {
+	if c.TokenCounter != nil {
+		return c.TokenCounter
+	}
+	return NewHeuristicTokenCounter(c.TokenPerCharRatio)
 }
 
 // StreamProcessor interface defines the core stream processing functionality
@@ -141,6 +150,7 @@ func NewStreamProcessor(cfg *config.Config) StreamProcessor {
 		MaxPageSize:       cfg.StreamProcessing.MaxPageSize,
 		RedactionEnabled:  cfg.StreamProcessing.RedactionEnabled,
 		StravaResolutions: []string{"low", "medium", "high"},
+		TokenCounter:      NewTokenCounter(cfg),
 	}
 
 	return &streamProcessor{
@@ -206,11 +216,12 @@ func (sp *streamProcessor) EstimateTokens(data *StravaStreams) int {
 		return 0
 	}
 
-	// Estimate tokens based on character count
+	// Count tokens of the serialized data
 	charCount := len(jsonData)
-	estimatedTokens := int(float64(charCount) * sp.config.TokenPerCharRatio)
+	counter := sp.config.tokenCounter()
+	estimatedTokens := counter.CountTokens(string(jsonData))
 
-	log.Printf("Stream data size estimation: %d characters, ~%d tokens", charCount, estimatedTokens)
+	log.Printf("Stream data size estimation: %d characters, ~%d tokens (%s)", charCount, estimatedTokens, counter.Name())
 	return estimatedTokens
 }
 
@@ -348,9 +359,10 @@ func (sp *streamProcessor) buildProcessingOptionsMessageWithContext(data *Strava
 	}
 	
 	builder.WriteString("📏 **Token Usage Estimates:**\n")
-	builder.WriteString(fmt.Sprintf("- Page size 500: ~%d tokens per page\n", int(500*sp.config.TokenPerCharRatio*4))) // Rough estimate
-	builder.WriteString(fmt.Sprintf("- Page size 1000: ~%d tokens per page\n", int(1000*sp.config.TokenPerCharRatio*4)))
-	builder.WriteString(fmt.Sprintf("- Page size 2000: ~%d tokens per page\n", int(2000*sp.config.TokenPerCharRatio*4)))
+	perPointTokens := tokensPerDataPoint(sp.config.tokenCounter())
+	builder.WriteString(fmt.Sprintf("- Page size 500: ~%d tokens per page\n", int(500*perPointTokens)))
+	builder.WriteString(fmt.Sprintf("- Page size 1000: ~%d tokens per page\n", int(1000*perPointTokens)))
+	builder.WriteString(fmt.Sprintf("- Page size 2000: ~%d tokens per page\n", int(2000*perPointTokens)))
 	builder.WriteString(fmt.Sprintf("- Full dataset (-1): ~%d tokens (requires processing)\n", estimatedTokens))
 	
 	// Add current context information if available
@@ -374,11 +386,9 @@ func (sp *streamProcessor) calculateOptimalPageSize(availableTokens int) int {
 	usableTokens := int(float64(availableTokens) * 0.8)
 	
 	// Estimate data points that fit in available tokens
-	// Rough estimate: each data point uses about 4 characters when serialized
-	charsPerDataPoint := 4.0
-	tokensPerDataPoint := charsPerDataPoint * sp.config.TokenPerCharRatio
+	perPointTokens := tokensPerDataPoint(sp.config.tokenCounter())
 	
-	optimalPageSize := int(float64(usableTokens) / tokensPerDataPoint)
+	optimalPageSize := int(float64(usableTokens) / perPointTokens)
 	
 	// Apply bounds
 	if optimalPageSize < 100 {
@@ -393,6 +403,15 @@ func (sp *streamProcessor) calculateOptimalPageSize(availableTokens int) int {
 
 // Helper functions for data analysis
 func (sp *streamProcessor) countDataPoints(data *StravaStreams) int {
+	return countStreamDataPoints(data)
+}
+
+// countStreamDataPoints returns the length of the longest stream
+func countStreamDataPoints(data *StravaStreams) int {
+	if data == nil {
+		return 0
+	}
+	
 	maxPoints := 0
 	
 	if len(data.Time) > maxPoints {
@@ -7,6 +7,8 @@ import (
 	"log/slog"
 	"strings"
 
+	"bodda/internal/models"
+
 	openai "github.com/openai/openai-go/v2"
 	"github.com/openai/openai-go/v2/responses"
 )
@@ -26,6 +28,16 @@ type SummaryProcessor interface {
 	PrepareStreamDataForSummarization(data *StravaStreams) (string, error)
 }
 
+// ConversationSummarizer condenses older conversation turns into a compact running summary
+type ConversationSummarizer interface {
+	SummarizeConversation(ctx context.Context, previousSummary string, messages []*models.Message) (string, error)
+}
+
+// SessionTitler names a conversation from its first user message and assistant reply
+type SessionTitler interface {
+	GenerateSessionTitle(ctx context.Context, userMessage, assistantReply string) (string, error)
+}
+
 // summaryProcessor implements the SummaryProcessor interface
 type summaryProcessor struct {
 	client *openai.Client
@@ -101,8 +113,8 @@ Please analyze this data and provide insights based on the user's specific reque
 				summaryContent.WriteString(textEvent.Delta)
 			}
 		case "response.completed":
-			// Stream completed successfully
-			break
+			// Stream completed successfully; meter the summarization call
+			recordResponseUsage(ctx, event.AsResponseCompleted().Response)
 		}
 	}
 
@@ -213,4 +225,110 @@ func (sp *summaryProcessor) getAvailableStreamTypes(data *StravaStreams) []strin
 	}
 	
 	return types
-}
\ No newline at end of file
+}
+
+// SummarizeConversation folds older conversation turns into the previous running summary
+func (sp *summaryProcessor) SummarizeConversation(ctx context.Context, previousSummary string, messages []*models.Message) (string, error) {
+	if len(messages) == 0 {
+		return previousSummary, nil
+	}
+
+	systemPrompt := `You maintain the running memory of a coaching conversation between an endurance athlete and their AI coach.
+
+Condense the conversation into a concise summary that preserves everything the coach needs to continue: the athlete's questions and goals, activities discussed (keep Strava activity IDs and links), key numbers and findings, advice and plans given, and any open questions or commitments.
+
+Write in compact markdown bullet points. Do not invent information. Drop greetings and filler.`
+
+	var transcript strings.Builder
+	if previousSummary != "" {
+		transcript.WriteString("EXISTING SUMMARY:\n")
+		transcript.WriteString(previousSummary)
+		transcript.WriteString("\n\n")
+	}
+	transcript.WriteString("NEW TURNS TO FOLD INTO THE SUMMARY:\n\n")
+	for _, msg := range messages {
+		transcript.WriteString(fmt.Sprintf("[%s]\n%s\n\n", msg.Role, msg.Content))
+	}
+
+	slog.InfoContext(ctx, "Invoking LLM for conversation summary",
+		"message_count", len(messages),
+		"has_previous_summary", previousSummary != "")
+
+	summary, err := sp.complete(ctx, systemPrompt, transcript.String())
+	if err != nil {
+		log.Printf("OpenAI API streaming error during conversation summarization: %v", err)
+		return "", fmt.Errorf("failed to generate conversation summary: %w", err)
+	}
+
+	if summary == "" {
+		return "", fmt.Errorf("conversation summary is empty")
+	}
+
+	return summary, nil
+}
+
+// GenerateSessionTitle names a conversation from its opening exchange
+func (sp *summaryProcessor) GenerateSessionTitle(ctx context.Context, userMessage, assistantReply string) (string, error) {
+	systemPrompt := `You name conversations between an endurance athlete and their AI coach for a sidebar list.
+
+Reply with a title of at most six words that says what the conversation is about, such as "Marathon taper plan" or "Threshold power after illness". Use sentence case. Do not use quotes, emoji or a trailing full stop. Reply with the title only.`
+
+	input := fmt.Sprintf("[user]\n%s\n\n[assistant]\n%s", truncateForTitle(userMessage), truncateForTitle(assistantReply))
+
+	slog.InfoContext(ctx, "Invoking LLM for session title")
+
+	title, err := sp.complete(ctx, systemPrompt, input)
+	if err != nil {
+		log.Printf("OpenAI API streaming error during session titling: %v", err)
+		return "", fmt.Errorf("failed to generate session title: %w", err)
+	}
+
+	if title == "" {
+		return "", fmt.Errorf("session title is empty")
+	}
+
+	return title, nil
+}
+
+// truncateForTitle keeps the start of a message; the opening is enough to name a conversation
+func truncateForTitle(content string) string {
+	const maxRunes = 2000
+	runes := []rune(content)
+	if len(runes) <= maxRunes {
+		return content
+	}
+	return string(runes[:maxRunes])
+}
+
+// complete runs a single-turn request on the small model and returns the trimmed output text
+func (sp *summaryProcessor) complete(ctx context.Context, systemPrompt, input string) (string, error) {
+	params := responses.ResponseNewParams{
+		Model: responses.ChatModelGPT5Nano,
+		Input: responses.ResponseNewParamsInputUnion{
+			OfInputItemList: []responses.ResponseInputItemUnionParam{
+				responses.ResponseInputItemParamOfMessage(systemPrompt, responses.EasyInputMessageRoleSystem),
+				responses.ResponseInputItemParamOfMessage(input, responses.EasyInputMessageRoleUser),
+			},
+		},
+	}
+
+	stream := sp.client.Responses.NewStreaming(ctx, params)
+	defer stream.Close()
+
+	var content strings.Builder
+	for stream.Next() {
+		event := stream.Current()
+		switch event.Type {
+		case "response.output_text.delta":
+			content.WriteString(event.AsResponseOutputTextDelta().Delta)
+		case "response.completed":
+			recordResponseUsage(ctx, event.AsResponseCompleted().Response)
+		}
+	}
+
+	if err := stream.Err(); err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(content.String()), nil
+}
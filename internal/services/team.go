@@ -0,0 +1,732 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"bodda/internal/models"
+
+	"github.com/google/uuid"
+)
+
+var (
+	// ErrTeamNotFound is returned for teams that do not exist or that the user does not belong to
+	ErrTeamNotFound = errors.New("team not found")
+	// ErrTeamAccessDenied is returned when a member's role does not allow the action
+	ErrTeamAccessDenied = errors.New("team access denied")
+	// ErrInvalidTeamRequest is returned for invalid names, roles and similar input
+	ErrInvalidTeamRequest = errors.New("invalid team request")
+	// ErrInvalidTeamCode is returned for unknown or disabled join codes
+	ErrInvalidTeamCode = errors.New("invalid team join code")
+	// ErrAlreadyTeamMember is returned when joining a team the user already belongs to
+	ErrAlreadyTeamMember = errors.New("already a member of this team")
+	// ErrTeamFull is returned when a team has reached the member limit
+	ErrTeamFull = errors.New("team is full")
+	// ErrTeamMemberNotFound is returned when changing a user who is not a member, or the owner
+	ErrTeamMemberNotFound = errors.New("team member not found")
+)
+
+const (
+	maxTeamNameLength = 100
+	// Dashboards fetch every athlete's Strava history, so teams are kept to club squad size
+	maxTeamMembers = 50
+	// Members are read from Strava a few at a time to stay inside the application rate limit
+	teamDashboardConcurrency = 4
+	teamDashboardTTL         = 15 * time.Minute
+
+	// An athlete is missing sessions when this week has at most half their usual number
+	missedSessionRatio = 0.5
+	minUsualSessions   = 2.0
+	teamInactivityDays = 7
+)
+
+// TeamStore persists teams and memberships
+type TeamStore interface {
+	Create(ctx context.Context, team *models.Team) error
+	GetByID(ctx context.Context, id string) (*models.Team, error)
+	GetByInviteHash(ctx context.Context, inviteHash string) (*models.Team, error)
+	ListForUser(ctx context.Context, userID string) ([]*models.Team, error)
+	Rename(ctx context.Context, teamID, name string, at time.Time) error
+	SetInviteHash(ctx context.Context, teamID string, inviteHash *string, at time.Time) error
+	Delete(ctx context.Context, teamID string) error
+	GetMember(ctx context.Context, teamID, userID string) (*models.TeamMember, error)
+	ListMembers(ctx context.Context, teamID string) ([]*models.TeamMember, error)
+	AddMember(ctx context.Context, member *models.TeamMember, maxMembers int) (bool, int, error)
+	UpdateMemberRole(ctx context.Context, teamID, userID, role string) (bool, error)
+	RemoveMember(ctx context.Context, teamID, userID string) (bool, error)
+}
+
+// TeamJoinCode is a team together with its new join code, which is only ever shown once
+type TeamJoinCode struct {
+	Code string       `json:"code"`
+	Team *models.Team `json:"team"`
+}
+
+// TeamAthleteStatus is one athlete's recent training on a team dashboard
+type TeamAthleteStatus struct {
+	UserID string       `json:"user_id"`
+	Name   string       `json:"name"`
+	Weeks  []WeeklyLoad `json:"weeks,omitempty"` // Rolling weeks ending on the dashboard date, oldest first
+
+	SessionsThisWeek      int        `json:"sessions_this_week"`
+	UsualSessions         float64    `json:"usual_sessions"` // Average of the three weeks before
+	LastActivity          *time.Time `json:"last_activity,omitempty"`
+	DaysSinceLastActivity *int       `json:"days_since_last_activity,omitempty"`
+	MissingSessions       bool       `json:"missing_sessions"`
+	MissingReason         string     `json:"missing_reason,omitempty"`
+
+	RiskLevel string           `json:"risk_level,omitempty"`
+	ACWR      *float64         `json:"acwr,omitempty"`
+	Flags     []InjuryRiskFlag `json:"flags,omitempty"`
+
+	// Set instead of the training data when the athlete's Strava history could not be read
+	Error string `json:"error,omitempty"`
+}
+
+// TeamDashboard aggregates the training of a team's athletes over the last four weeks
+type TeamDashboard struct {
+	Team        *models.Team        `json:"team"`
+	Date        time.Time           `json:"date"`
+	GeneratedAt time.Time           `json:"generated_at"`
+	Athletes    []TeamAthleteStatus `json:"athletes"`
+
+	TotalHoursThisWeek float64 `json:"total_hours_this_week"`
+	TotalRunKmThisWeek float64 `json:"total_run_km_this_week"`
+	AtRiskCount        int     `json:"at_risk_count"`
+	MissingCount       int     `json:"missing_count"`
+}
+
+// TeamService manages teams and builds their training dashboards
+type TeamService interface {
+	CreateTeam(ctx context.Context, ownerID, name string) (*TeamJoinCode, error)
+	ListTeams(ctx context.Context, userID string) ([]*models.Team, error)
+	// GetTeam returns the team with its members when the user belongs to it
+	GetTeam(ctx context.Context, userID, teamID string) (*models.Team, []*models.TeamMember, error)
+	RenameTeam(ctx context.Context, userID, teamID, name string) error
+	DeleteTeam(ctx context.Context, userID, teamID string) error
+	RotateJoinCode(ctx context.Context, userID, teamID string) (*TeamJoinCode, error)
+	DisableJoinCode(ctx context.Context, userID, teamID string) error
+	JoinTeam(ctx context.Context, userID, code string) (*models.Team, error)
+	UpdateMemberRole(ctx context.Context, userID, teamID, memberID, role string) error
+	// RemoveMember removes a member; owners remove anyone else, other members can only leave
+	RemoveMember(ctx context.Context, userID, teamID, memberID string) error
+
+	// Dashboard returns the team's weekly volume, missed sessions and load warnings for staff
+	Dashboard(ctx context.Context, userID, teamID string) (*TeamDashboard, error)
+	// ResolveStaffTeam finds a team the user owns or coaches by ID or name. An empty reference
+	// matches when the user is staff of exactly one team.
+	ResolveStaffTeam(ctx context.Context, userID, reference string) (*models.Team, error)
+}
+
+type cachedTeamDashboard struct {
+	dashboard *TeamDashboard
+	expiresAt time.Time
+}
+
+type teamService struct {
+	store         TeamStore
+	userRepo      UserRepository
+	stravaService StravaService
+	now           func() time.Time
+
+	mu         sync.Mutex
+	dashboards map[string]cachedTeamDashboard
+}
+
+func NewTeamService(store TeamStore, userRepo UserRepository, stravaService StravaService) TeamService {
+	return &teamService{
+		store:         store,
+		userRepo:      userRepo,
+		stravaService: stravaService,
+		now:           time.Now,
+		dashboards:    make(map[string]cachedTeamDashboard),
+	}
+}
+
+func (s *teamService) CreateTeam(ctx context.Context, ownerID, name string) (*TeamJoinCode, error) {
+	name, err := normalizeTeamName(name)
+	if err != nil {
+		return nil, err
+	}
+
+	code, err := generateInviteCode()
+	if err != nil {
+		return nil, err
+	}
+	inviteHash := hashOpaqueToken(code)
+
+	now := s.now().UTC()
+	team := &models.Team{
+		Name:        name,
+		OwnerID:     ownerID,
+		InviteHash:  &inviteHash,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+		Role:        models.TeamRoleOwner,
+		MemberCount: 1,
+	}
+	if err := s.store.Create(ctx, team); err != nil {
+		return nil, err
+	}
+
+	return &TeamJoinCode{Code: code, Team: team}, nil
+}
+
+func (s *teamService) ListTeams(ctx context.Context, userID string) ([]*models.Team, error) {
+	teams, err := s.store.ListForUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if teams == nil {
+		teams = []*models.Team{}
+	}
+	return teams, nil
+}
+
+// membership returns the team and the user's membership, or ErrTeamNotFound when they do not belong to it
+func (s *teamService) membership(ctx context.Context, userID, teamID string) (*models.Team, *models.TeamMember, error) {
+	if _, err := uuid.Parse(teamID); err != nil {
+		return nil, nil, ErrTeamNotFound
+	}
+
+	member, err := s.store.GetMember(ctx, teamID, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if member == nil {
+		return nil, nil, ErrTeamNotFound
+	}
+
+	team, err := s.store.GetByID(ctx, teamID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if team == nil {
+		return nil, nil, ErrTeamNotFound
+	}
+	team.Role = member.Role
+
+	return team, member, nil
+}
+
+// requireOwner returns the team when the user owns it
+func (s *teamService) requireOwner(ctx context.Context, userID, teamID string) (*models.Team, error) {
+	team, member, err := s.membership(ctx, userID, teamID)
+	if err != nil {
+		return nil, err
+	}
+	if member.Role != models.TeamRoleOwner {
+		return nil, fmt.Errorf("%w: only the team owner can do this", ErrTeamAccessDenied)
+	}
+	return team, nil
+}
+
+func (s *teamService) GetTeam(ctx context.Context, userID, teamID string) (*models.Team, []*models.TeamMember, error) {
+	team, _, err := s.membership(ctx, userID, teamID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	members, err := s.store.ListMembers(ctx, teamID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if members == nil {
+		members = []*models.TeamMember{}
+	}
+	team.MemberCount = len(members)
+
+	return team, members, nil
+}
+
+func (s *teamService) RenameTeam(ctx context.Context, userID, teamID, name string) error {
+	name, err := normalizeTeamName(name)
+	if err != nil {
+		return err
+	}
+	if _, err := s.requireOwner(ctx, userID, teamID); err != nil {
+		return err
+	}
+
+	return s.store.Rename(ctx, teamID, name, s.now().UTC())
+}
+
+func (s *teamService) DeleteTeam(ctx context.Context, userID, teamID string) error {
+	if _, err := s.requireOwner(ctx, userID, teamID); err != nil {
+		return err
+	}
+
+	if err := s.store.Delete(ctx, teamID); err != nil {
+		return err
+	}
+	s.invalidateDashboard(teamID)
+	return nil
+}
+
+func (s *teamService) RotateJoinCode(ctx context.Context, userID, teamID string) (*TeamJoinCode, error) {
+	team, err := s.requireOwner(ctx, userID, teamID)
+	if err != nil {
+		return nil, err
+	}
+
+	code, err := generateInviteCode()
+	if err != nil {
+		return nil, err
+	}
+	inviteHash := hashOpaqueToken(code)
+	if err := s.store.SetInviteHash(ctx, teamID, &inviteHash, s.now().UTC()); err != nil {
+		return nil, err
+	}
+	team.InviteHash = &inviteHash
+
+	return &TeamJoinCode{Code: code, Team: team}, nil
+}
+
+func (s *teamService) DisableJoinCode(ctx context.Context, userID, teamID string) error {
+	if _, err := s.requireOwner(ctx, userID, teamID); err != nil {
+		return err
+	}
+
+	return s.store.SetInviteHash(ctx, teamID, nil, s.now().UTC())
+}
+
+func (s *teamService) JoinTeam(ctx context.Context, userID, code string) (*models.Team, error) {
+	code = strings.TrimSpace(code)
+	if code == "" {
+		return nil, ErrInvalidTeamCode
+	}
+
+	team, err := s.store.GetByInviteHash(ctx, hashOpaqueToken(code))
+	if err != nil {
+		return nil, err
+	}
+	if team == nil {
+		return nil, ErrInvalidTeamCode
+	}
+
+	// The store checks the member limit as it adds the member, so concurrent joins cannot overfill the team
+	added, count, err := s.store.AddMember(ctx, &models.TeamMember{
+		TeamID:   team.ID,
+		UserID:   userID,
+		Role:     models.TeamRoleAthlete,
+		JoinedAt: s.now().UTC(),
+	}, maxTeamMembers)
+	if err != nil {
+		return nil, err
+	}
+	if !added {
+		existing, err := s.store.GetMember(ctx, team.ID, userID)
+		if err != nil {
+			return nil, err
+		}
+		if existing != nil {
+			return nil, ErrAlreadyTeamMember
+		}
+		return nil, fmt.Errorf("%w: teams are limited to %d members", ErrTeamFull, maxTeamMembers)
+	}
+	s.invalidateDashboard(team.ID)
+
+	team.Role = models.TeamRoleAthlete
+	team.MemberCount = count
+	return team, nil
+}
+
+func (s *teamService) UpdateMemberRole(ctx context.Context, userID, teamID, memberID, role string) error {
+	if role != models.TeamRoleCoach && role != models.TeamRoleAthlete {
+		return fmt.Errorf("%w: role must be %s or %s", ErrInvalidTeamRequest, models.TeamRoleCoach, models.TeamRoleAthlete)
+	}
+	if _, err := s.requireOwner(ctx, userID, teamID); err != nil {
+		return err
+	}
+	if _, err := uuid.Parse(memberID); err != nil {
+		return ErrTeamMemberNotFound
+	}
+
+	updated, err := s.store.UpdateMemberRole(ctx, teamID, memberID, role)
+	if err != nil {
+		return err
+	}
+	if !updated {
+		return ErrTeamMemberNotFound
+	}
+	s.invalidateDashboard(teamID)
+	return nil
+}
+
+func (s *teamService) RemoveMember(ctx context.Context, userID, teamID, memberID string) error {
+	_, member, err := s.membership(ctx, userID, teamID)
+	if err != nil {
+		return err
+	}
+	if member.Role == models.TeamRoleOwner && memberID == userID {
+		return fmt.Errorf("%w: the owner cannot leave the team, delete it instead", ErrInvalidTeamRequest)
+	}
+	if member.Role != models.TeamRoleOwner && memberID != userID {
+		return fmt.Errorf("%w: only the team owner can remove other members", ErrTeamAccessDenied)
+	}
+	if _, err := uuid.Parse(memberID); err != nil {
+		return ErrTeamMemberNotFound
+	}
+
+	removed, err := s.store.RemoveMember(ctx, teamID, memberID)
+	if err != nil {
+		return err
+	}
+	if !removed {
+		return ErrTeamMemberNotFound
+	}
+	s.invalidateDashboard(teamID)
+	return nil
+}
+
+func (s *teamService) ResolveStaffTeam(ctx context.Context, userID, reference string) (*models.Team, error) {
+	teams, err := s.store.ListForUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var staffTeams []*models.Team
+	for _, team := range teams {
+		if models.IsTeamStaff(team.Role) {
+			staffTeams = append(staffTeams, team)
+		}
+	}
+	if len(staffTeams) == 0 {
+		return nil, fmt.Errorf("%w: you are not the owner or a coach of any team", ErrTeamAccessDenied)
+	}
+
+	reference = strings.TrimSpace(reference)
+	if reference == "" {
+		if len(staffTeams) == 1 {
+			return staffTeams[0], nil
+		}
+		names := make([]string, len(staffTeams))
+		for i, team := range staffTeams {
+			names[i] = team.Name
+		}
+		return nil, fmt.Errorf("%w: specify one of your teams: %s", ErrInvalidTeamRequest, strings.Join(names, ", "))
+	}
+
+	for _, team := range staffTeams {
+		if team.ID == reference || strings.EqualFold(team.Name, reference) {
+			return team, nil
+		}
+	}
+	return nil, ErrTeamNotFound
+}
+
+func (s *teamService) Dashboard(ctx context.Context, userID, teamID string) (*TeamDashboard, error) {
+	team, member, err := s.membership(ctx, userID, teamID)
+	if err != nil {
+		return nil, err
+	}
+	if !models.IsTeamStaff(member.Role) {
+		return nil, fmt.Errorf("%w: only the team owner and coaches can see team training data", ErrTeamAccessDenied)
+	}
+
+	now := s.now()
+	if cached := s.cachedDashboard(teamID, now); cached != nil {
+		return cached, nil
+	}
+
+	members, err := s.store.ListMembers(ctx, teamID)
+	if err != nil {
+		return nil, err
+	}
+	var athletes []*models.TeamMember
+	for _, m := range members {
+		if m.Role == models.TeamRoleAthlete {
+			athletes = append(athletes, m)
+		}
+	}
+	team.MemberCount = len(members)
+
+	// The fan-out reads many athletes' histories at once, so it runs at background priority and
+	// cannot drain the budget kept for interactive requests
+	stravaCtx := WithStravaPriority(ctx, StravaPriorityBackground)
+	date := wellnessDate(now)
+	statuses := make([]TeamAthleteStatus, len(athletes))
+	sem := make(chan struct{}, teamDashboardConcurrency)
+	var wg sync.WaitGroup
+	for i, athlete := range athletes {
+		wg.Add(1)
+		go func(i int, athlete *models.TeamMember) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			statuses[i] = s.athleteStatus(stravaCtx, athlete, date)
+		}(i, athlete)
+	}
+	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	dashboard := BuildTeamDashboard(team, statuses, date)
+	dashboard.GeneratedAt = now.UTC()
+
+	s.mu.Lock()
+	s.dashboards[teamID] = cachedTeamDashboard{dashboard: dashboard, expiresAt: now.Add(teamDashboardTTL)}
+	s.mu.Unlock()
+
+	return dashboard, nil
+}
+
+func (s *teamService) cachedDashboard(teamID string, now time.Time) *TeamDashboard {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cached, ok := s.dashboards[teamID]
+	if !ok {
+		return nil
+	}
+	if !now.Before(cached.expiresAt) {
+		delete(s.dashboards, teamID)
+		return nil
+	}
+	return cached.dashboard
+}
+
+// invalidateDashboard drops the cached dashboard after membership changes
+func (s *teamService) invalidateDashboard(teamID string) {
+	s.mu.Lock()
+	delete(s.dashboards, teamID)
+	s.mu.Unlock()
+}
+
+// athleteStatus reads one athlete's Strava history. Failures are reported on the athlete rather than
+// failing the whole dashboard, since one expired Strava connection should not hide the rest of the team.
+func (s *teamService) athleteStatus(ctx context.Context, member *models.TeamMember, date time.Time) TeamAthleteStatus {
+	status := TeamAthleteStatus{UserID: member.UserID, Name: member.Name}
+
+	user, err := s.userRepo.GetByID(ctx, member.UserID)
+	if err != nil || user == nil {
+		status.Error = "Athlete account could not be loaded"
+		return status
+	}
+
+	activities, hrZones, err := loadInjuryRiskHistory(ctx, s.stravaService, user, date)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrTokenExpired), errors.Is(err, ErrInvalidToken):
+			status.Error = "Strava connection expired"
+		case errors.Is(err, ErrRateLimitExceeded):
+			status.Error = "Strava rate limit reached, try again later"
+		default:
+			if !errors.Is(err, context.Canceled) {
+				slog.WarnContext(ctx, "Failed to read team member training", "team_id", member.TeamID, "user_id", member.UserID, "error", err)
+			}
+			status.Error = "Training data unavailable"
+		}
+		return status
+	}
+
+	return BuildTeamAthleteStatus(member, activities, hrZones, date)
+}
+
+// BuildTeamAthleteStatus summarizes an athlete's last four weeks for a team dashboard. Logbook injury
+// notes are private to the athlete and their personal coaches, so they are not part of the assessment.
+func BuildTeamAthleteStatus(member *models.TeamMember, activities []*StravaActivity, hrZones []StravaZone, date time.Time) TeamAthleteStatus {
+	date = wellnessDate(date)
+	report := BuildInjuryRiskReport(activities, hrZones, "", date)
+	status := TeamAthleteStatus{
+		UserID:    member.UserID,
+		Name:      member.Name,
+		Weeks:     report.Weeks,
+		RiskLevel: report.RiskLevel,
+		ACWR:      report.ACWR,
+		Flags:     report.Flags,
+	}
+
+	current := report.Weeks[len(report.Weeks)-1]
+	status.SessionsThisWeek = current.Sessions
+	var priorSessions int
+	for _, week := range report.Weeks[:len(report.Weeks)-1] {
+		priorSessions += week.Sessions
+	}
+	status.UsualSessions = roundTo(float64(priorSessions)/float64(len(report.Weeks)-1), 1)
+
+	end := date.AddDate(0, 0, 1)
+	for _, activity := range activities {
+		start, ok := activityStartLocal(activity)
+		if !ok || !start.Before(end) {
+			continue
+		}
+		if status.LastActivity == nil || start.After(*status.LastActivity) {
+			last := start
+			status.LastActivity = &last
+		}
+	}
+	if status.LastActivity != nil {
+		days := int(date.Sub(wellnessDate(*status.LastActivity)).Hours() / 24)
+		status.DaysSinceLastActivity = &days
+	}
+
+	switch {
+	case status.UsualSessions >= minUsualSessions && float64(status.SessionsThisWeek) <= status.UsualSessions*missedSessionRatio:
+		status.MissingSessions = true
+		status.MissingReason = fmt.Sprintf("%d sessions in the last 7 days against a usual %.1f per week", status.SessionsThisWeek, status.UsualSessions)
+	case status.DaysSinceLastActivity == nil:
+		status.MissingSessions = true
+		status.MissingReason = fmt.Sprintf("No activities in the last %d days", injuryRiskHistoryDays)
+	case *status.DaysSinceLastActivity >= teamInactivityDays:
+		status.MissingSessions = true
+		status.MissingReason = fmt.Sprintf("No activities for %d days", *status.DaysSinceLastActivity)
+	}
+
+	return status
+}
+
+// BuildTeamDashboard orders athletes with the highest risk first and totals the current week
+func BuildTeamDashboard(team *models.Team, statuses []TeamAthleteStatus, date time.Time) *TeamDashboard {
+	dashboard := &TeamDashboard{Team: team, Date: wellnessDate(date), Athletes: statuses}
+	if dashboard.Athletes == nil {
+		dashboard.Athletes = []TeamAthleteStatus{}
+	}
+
+	for _, status := range dashboard.Athletes {
+		if len(status.Weeks) > 0 {
+			current := status.Weeks[len(status.Weeks)-1]
+			dashboard.TotalHoursThisWeek += current.Hours
+			dashboard.TotalRunKmThisWeek += current.RunKm
+		}
+		if status.RiskLevel == InjuryRiskModerate || status.RiskLevel == InjuryRiskHigh {
+			dashboard.AtRiskCount++
+		}
+		if status.MissingSessions {
+			dashboard.MissingCount++
+		}
+	}
+	dashboard.TotalHoursThisWeek = roundTo(dashboard.TotalHoursThisWeek, 1)
+	dashboard.TotalRunKmThisWeek = roundTo(dashboard.TotalRunKmThisWeek, 1)
+
+	sort.SliceStable(dashboard.Athletes, func(i, j int) bool {
+		a, b := dashboard.Athletes[i], dashboard.Athletes[j]
+		if riskRank(a.RiskLevel) != riskRank(b.RiskLevel) {
+			return riskRank(a.RiskLevel) > riskRank(b.RiskLevel)
+		}
+		return acwrOrZero(a.ACWR) > acwrOrZero(b.ACWR)
+	})
+	return dashboard
+}
+
+func riskRank(level string) int {
+	switch level {
+	case InjuryRiskHigh:
+		return 2
+	case InjuryRiskModerate:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func acwrOrZero(acwr *float64) float64 {
+	if acwr == nil {
+		return 0
+	}
+	return *acwr
+}
+
+func normalizeTeamName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", fmt.Errorf("%w: team name is required", ErrInvalidTeamRequest)
+	}
+	if len([]rune(name)) > maxTeamNameLength {
+		return "", fmt.Errorf("%w: team name must be at most %d characters", ErrInvalidTeamRequest, maxTeamNameLength)
+	}
+	return name, nil
+}
+
+// TeamOverviewRequest selects the team for the get-team-overview tool
+type TeamOverviewRequest struct {
+	Team string `json:"team"`
+}
+
+// formatTeamDashboard renders a team dashboard as markdown for the model
+func formatTeamDashboard(dashboard *TeamDashboard) string {
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("# %s: last 7 days to %s\n\n", dashboard.Team.Name, dashboard.Date.Format(wellnessDateLayout)))
+	b.WriteString(fmt.Sprintf("%d athletes, %.1f h and %.1f run km in total this week. %d with load warnings, %d missing sessions.\n\n",
+		len(dashboard.Athletes), dashboard.TotalHoursThisWeek, dashboard.TotalRunKmThisWeek, dashboard.AtRiskCount, dashboard.MissingCount))
+
+	if len(dashboard.Athletes) == 0 {
+		b.WriteString("The team has no athletes yet.\n")
+		return b.String()
+	}
+
+	b.WriteString("| Athlete | Sessions (usual) | Time | Run km | Load (AU) | ACWR | Risk | Last activity |\n|---|---|---|---|---|---|---|---|\n")
+	for _, status := range dashboard.Athletes {
+		if status.Error != "" {
+			b.WriteString(fmt.Sprintf("| %s | - | - | - | - | - | unknown | %s |\n", status.Name, status.Error))
+			continue
+		}
+		current := status.Weeks[len(status.Weeks)-1]
+		lastActivity := "none in 6 weeks"
+		if status.DaysSinceLastActivity != nil {
+			lastActivity = fmt.Sprintf("%d days ago", *status.DaysSinceLastActivity)
+		}
+		b.WriteString(fmt.Sprintf("| %s | %d (%.1f) | %.1f h | %.1f | %.0f | %s | %s | %s |\n", status.Name,
+			status.SessionsThisWeek, status.UsualSessions, current.Hours, current.RunKm, current.Load,
+			formatOptionalFloat(status.ACWR, ""), status.RiskLevel, lastActivity))
+	}
+	b.WriteString("\n")
+
+	b.WriteString("**Load warnings:**\n")
+	warnings := 0
+	for _, status := range dashboard.Athletes {
+		if status.Error != "" || status.RiskLevel == InjuryRiskLow {
+			continue
+		}
+		warnings++
+		b.WriteString(fmt.Sprintf("- %s (%s risk)\n", status.Name, status.RiskLevel))
+		for _, flag := range status.Flags {
+			b.WriteString(fmt.Sprintf("  - [%s] %s\n", flag.Severity, flag.Message))
+		}
+	}
+	if warnings == 0 {
+		b.WriteString("- None\n")
+	}
+	b.WriteString("\n")
+
+	b.WriteString("**Missing sessions:**\n")
+	if dashboard.MissingCount == 0 {
+		b.WriteString("- None\n")
+	}
+	for _, status := range dashboard.Athletes {
+		if status.MissingSessions {
+			b.WriteString(fmt.Sprintf("- %s: %s\n", status.Name, status.MissingReason))
+		}
+	}
+	b.WriteString("\n")
+
+	b.WriteString(fmt.Sprintf("Weeks are rolling 7-day blocks. Load is moving minutes x heart rate zone; an acute:chronic ratio of %.1f or more, or a sudden volume jump, signals overreaching.\n", acwrElevatedThreshold))
+	return b.String()
+}
+
+func (s *aiService) executeGetTeamOverview(ctx context.Context, msgCtx *MessageContext, req TeamOverviewRequest) (string, error) {
+	if msgCtx == nil || msgCtx.UserID == "" {
+		return "", fmt.Errorf("user context is required")
+	}
+	if s.teamService == nil {
+		return "", fmt.Errorf("teams are not available")
+	}
+
+	// Team data belongs to the requesting user, also when they are coaching a single athlete
+	team, err := s.teamService.ResolveStaffTeam(ctx, msgCtx.UserID, req.Team)
+	if err != nil {
+		return "", err
+	}
+	dashboard, err := s.teamService.Dashboard(ctx, msgCtx.UserID, team.ID)
+	if err != nil {
+		return "", err
+	}
+	return formatTeamDashboard(dashboard), nil
+}
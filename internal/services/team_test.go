@@ -0,0 +1,363 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"bodda/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testTeamOwnerID = "00000000-0000-0000-0000-0000000000f1"
+	testTeamRunner1 = "00000000-0000-0000-0000-0000000000f2"
+	testTeamRunner2 = "00000000-0000-0000-0000-0000000000f3"
+	testTeamRunner3 = "00000000-0000-0000-0000-0000000000f4"
+)
+
+// memoryTeamStore keeps teams in memory with the same semantics as the repository
+type memoryTeamStore struct {
+	teams   []*models.Team
+	members []*models.TeamMember
+	names   map[string]string
+}
+
+func (m *memoryTeamStore) Create(ctx context.Context, team *models.Team) error {
+	team.ID = fmt.Sprintf("00000000-0000-0000-0003-%012d", len(m.teams)+1)
+	stored := *team
+	m.teams = append(m.teams, &stored)
+	m.members = append(m.members, &models.TeamMember{TeamID: team.ID, UserID: team.OwnerID, Role: models.TeamRoleOwner, JoinedAt: team.CreatedAt})
+	return nil
+}
+
+func (m *memoryTeamStore) GetByID(ctx context.Context, id string) (*models.Team, error) {
+	for _, team := range m.teams {
+		if team.ID == id {
+			copied := *team
+			return &copied, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *memoryTeamStore) GetByInviteHash(ctx context.Context, inviteHash string) (*models.Team, error) {
+	for _, team := range m.teams {
+		if team.InviteHash != nil && *team.InviteHash == inviteHash {
+			copied := *team
+			return &copied, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *memoryTeamStore) ListForUser(ctx context.Context, userID string) ([]*models.Team, error) {
+	var teams []*models.Team
+	for _, member := range m.members {
+		if member.UserID != userID {
+			continue
+		}
+		team, _ := m.GetByID(ctx, member.TeamID)
+		team.Role = member.Role
+		team.MemberCount, _ = m.CountMembers(ctx, team.ID)
+		teams = append(teams, team)
+	}
+	return teams, nil
+}
+
+func (m *memoryTeamStore) Rename(ctx context.Context, teamID, name string, at time.Time) error {
+	for _, team := range m.teams {
+		if team.ID == teamID {
+			team.Name = name
+		}
+	}
+	return nil
+}
+
+func (m *memoryTeamStore) SetInviteHash(ctx context.Context, teamID string, inviteHash *string, at time.Time) error {
+	for _, team := range m.teams {
+		if team.ID == teamID {
+			team.InviteHash = inviteHash
+		}
+	}
+	return nil
+}
+
+func (m *memoryTeamStore) Delete(ctx context.Context, teamID string) error {
+	var teams []*models.Team
+	for _, team := range m.teams {
+		if team.ID != teamID {
+			teams = append(teams, team)
+		}
+	}
+	var members []*models.TeamMember
+	for _, member := range m.members {
+		if member.TeamID != teamID {
+			members = append(members, member)
+		}
+	}
+	m.teams, m.members = teams, members
+	return nil
+}
+
+func (m *memoryTeamStore) GetMember(ctx context.Context, teamID, userID string) (*models.TeamMember, error) {
+	for _, member := range m.members {
+		if member.TeamID == teamID && member.UserID == userID {
+			copied := *member
+			return &copied, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *memoryTeamStore) ListMembers(ctx context.Context, teamID string) ([]*models.TeamMember, error) {
+	var members []*models.TeamMember
+	for _, member := range m.members {
+		if member.TeamID == teamID {
+			copied := *member
+			copied.Name = m.names[member.UserID]
+			members = append(members, &copied)
+		}
+	}
+	return members, nil
+}
+
+func (m *memoryTeamStore) CountMembers(ctx context.Context, teamID string) (int, error) {
+	members, _ := m.ListMembers(ctx, teamID)
+	return len(members), nil
+}
+
+func (m *memoryTeamStore) AddMember(ctx context.Context, member *models.TeamMember, maxMembers int) (bool, int, error) {
+	count, _ := m.CountMembers(ctx, member.TeamID)
+	if existing, _ := m.GetMember(ctx, member.TeamID, member.UserID); existing != nil || count >= maxMembers {
+		return false, count, nil
+	}
+	m.members = append(m.members, member)
+	return true, count + 1, nil
+}
+
+func (m *memoryTeamStore) UpdateMemberRole(ctx context.Context, teamID, userID, role string) (bool, error) {
+	for _, member := range m.members {
+		if member.TeamID == teamID && member.UserID == userID && member.Role != models.TeamRoleOwner {
+			member.Role = role
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (m *memoryTeamStore) RemoveMember(ctx context.Context, teamID, userID string) (bool, error) {
+	for i, member := range m.members {
+		if member.TeamID == teamID && member.UserID == userID && member.Role != models.TeamRoleOwner {
+			m.members = append(m.members[:i], m.members[i+1:]...)
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// teamStravaService serves a separate activity history per user
+type teamStravaService struct {
+	mockStravaServiceForToolExecutor
+	histories map[string][]*StravaActivity
+	errors    map[string]error
+
+	mu         sync.Mutex
+	priorities []StravaRequestPriority
+}
+
+func (m *teamStravaService) GetActivities(ctx context.Context, user *models.User, params ActivityParams) ([]*StravaActivity, error) {
+	m.mu.Lock()
+	m.priorities = append(m.priorities, StravaPriorityFromContext(ctx))
+	m.mu.Unlock()
+	if err := m.errors[user.ID]; err != nil {
+		return nil, err
+	}
+	if params.Page > 1 {
+		return nil, nil
+	}
+	return m.histories[user.ID], nil
+}
+
+func (m *teamStravaService) GetAthleteZones(ctx context.Context, user *models.User) (*StravaAthleteZones, error) {
+	return &StravaAthleteZones{HeartRate: &StravaZoneSet{Zones: testHeartRateZones}}, nil
+}
+
+func newTeamTestService(strava StravaService) (*teamService, *memoryTeamStore) {
+	store := &memoryTeamStore{names: map[string]string{
+		testTeamOwnerID: "Olga Owner",
+		testTeamRunner1: "Steady Runner",
+		testTeamRunner2: "Overreaching Runner",
+		testTeamRunner3: "Quiet Runner",
+	}}
+	userRepo := &MockUserRepository{}
+	for id := range store.names {
+		userRepo.On("GetByID", mock.Anything, id).Return(&models.User{ID: id}, nil)
+	}
+
+	service := NewTeamService(store, userRepo, strava).(*teamService)
+	service.now = func() time.Time { return time.Date(2025, 6, 1, 18, 0, 0, 0, time.UTC) }
+	return service, store
+}
+
+func TestTeamService_Membership(t *testing.T) {
+	service, _ := newTeamTestService(&teamStravaService{})
+	ctx := context.Background()
+
+	_, err := service.CreateTeam(ctx, testTeamOwnerID, "   ")
+	assert.True(t, errors.Is(err, ErrInvalidTeamRequest))
+
+	created, err := service.CreateTeam(ctx, testTeamOwnerID, " Harriers ")
+	require.NoError(t, err)
+	assert.Equal(t, "Harriers", created.Team.Name)
+	assert.NotEmpty(t, created.Code)
+	teamID := created.Team.ID
+
+	_, err = service.JoinTeam(ctx, testTeamRunner1, "wrong")
+	assert.True(t, errors.Is(err, ErrInvalidTeamCode))
+	joined, err := service.JoinTeam(ctx, testTeamRunner1, created.Code)
+	require.NoError(t, err)
+	assert.Equal(t, models.TeamRoleAthlete, joined.Role)
+	_, err = service.JoinTeam(ctx, testTeamRunner1, created.Code)
+	assert.True(t, errors.Is(err, ErrAlreadyTeamMember))
+
+	_, members, err := service.GetTeam(ctx, testTeamRunner1, teamID)
+	require.NoError(t, err)
+	assert.Len(t, members, 2)
+	_, _, err = service.GetTeam(ctx, testTeamRunner2, teamID)
+	assert.True(t, errors.Is(err, ErrTeamNotFound), "non-members cannot see the team")
+
+	assert.True(t, errors.Is(service.RenameTeam(ctx, testTeamRunner1, teamID, "Mine"), ErrTeamAccessDenied))
+	assert.True(t, errors.Is(service.UpdateMemberRole(ctx, testTeamOwnerID, teamID, testTeamRunner1, models.TeamRoleOwner), ErrInvalidTeamRequest))
+	require.NoError(t, service.UpdateMemberRole(ctx, testTeamOwnerID, teamID, testTeamRunner1, models.TeamRoleCoach))
+
+	rotated, err := service.RotateJoinCode(ctx, testTeamOwnerID, teamID)
+	require.NoError(t, err)
+	_, err = service.JoinTeam(ctx, testTeamRunner2, created.Code)
+	assert.True(t, errors.Is(err, ErrInvalidTeamCode), "the previous code stops working")
+	_, err = service.JoinTeam(ctx, testTeamRunner2, rotated.Code)
+	require.NoError(t, err)
+
+	assert.True(t, errors.Is(service.RemoveMember(ctx, testTeamRunner1, teamID, testTeamRunner2), ErrTeamAccessDenied),
+		"coaches cannot remove other members")
+	require.NoError(t, service.RemoveMember(ctx, testTeamRunner2, teamID, testTeamRunner2), "members can leave")
+	assert.True(t, errors.Is(service.RemoveMember(ctx, testTeamOwnerID, teamID, testTeamOwnerID), ErrInvalidTeamRequest))
+
+	require.NoError(t, service.DisableJoinCode(ctx, testTeamOwnerID, teamID))
+	_, err = service.JoinTeam(ctx, testTeamRunner3, rotated.Code)
+	assert.True(t, errors.Is(err, ErrInvalidTeamCode))
+}
+
+func TestTeamService_JoinFullTeam(t *testing.T) {
+	service, store := newTeamTestService(&teamStravaService{})
+	ctx := context.Background()
+
+	created, err := service.CreateTeam(ctx, testTeamOwnerID, "Harriers")
+	require.NoError(t, err)
+	joined, err := service.JoinTeam(ctx, testTeamRunner1, created.Code)
+	require.NoError(t, err)
+	assert.Equal(t, 2, joined.MemberCount)
+
+	for i := len(store.members); i < maxTeamMembers; i++ {
+		store.members = append(store.members, &models.TeamMember{
+			TeamID: created.Team.ID,
+			UserID: fmt.Sprintf("member-%d", i),
+			Role:   models.TeamRoleAthlete,
+		})
+	}
+
+	_, err = service.JoinTeam(ctx, testTeamRunner2, created.Code)
+	assert.True(t, errors.Is(err, ErrTeamFull))
+	_, err = service.JoinTeam(ctx, testTeamRunner1, created.Code)
+	assert.True(t, errors.Is(err, ErrAlreadyTeamMember), "members of a full team are told they already belong")
+}
+
+func TestBuildTeamAthleteStatus(t *testing.T) {
+	date := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	member := &models.TeamMember{UserID: testTeamRunner1, Name: "Runner"}
+
+	steady := BuildTeamAthleteStatus(member, loadHistory(date, 42, 1), testHeartRateZones, date)
+	assert.Equal(t, InjuryRiskLow, steady.RiskLevel)
+	assert.Equal(t, 5, steady.SessionsThisWeek)
+	assert.Equal(t, 5.0, steady.UsualSessions)
+	assert.False(t, steady.MissingSessions)
+	require.NotNil(t, steady.DaysSinceLastActivity)
+	assert.Equal(t, 1, *steady.DaysSinceLastActivity, "the pattern rests on the last day")
+
+	// Only the sessions of the three weeks before the last one
+	var skipped []*StravaActivity
+	for _, activity := range loadHistory(date, 42, 1) {
+		if start, _ := activityStartLocal(activity); start.Before(date.AddDate(0, 0, -6)) {
+			skipped = append(skipped, activity)
+		}
+	}
+	quiet := BuildTeamAthleteStatus(member, skipped, testHeartRateZones, date)
+	assert.True(t, quiet.MissingSessions)
+	assert.Equal(t, 0, quiet.SessionsThisWeek)
+	assert.Contains(t, quiet.MissingReason, "0 sessions in the last 7 days")
+
+	none := BuildTeamAthleteStatus(member, nil, testHeartRateZones, date)
+	assert.True(t, none.MissingSessions)
+	assert.Nil(t, none.LastActivity)
+}
+
+func TestTeamService_Dashboard(t *testing.T) {
+	date := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	strava := &teamStravaService{
+		histories: map[string][]*StravaActivity{
+			testTeamRunner1: loadHistory(date, 42, 1),
+			testTeamRunner2: loadHistory(date, 42, 2),
+		},
+		errors: map[string]error{testTeamRunner3: ErrTokenExpired},
+	}
+	service, _ := newTeamTestService(strava)
+	ctx := context.Background()
+
+	created, err := service.CreateTeam(ctx, testTeamOwnerID, "Harriers")
+	require.NoError(t, err)
+	teamID := created.Team.ID
+	for _, runner := range []string{testTeamRunner1, testTeamRunner2, testTeamRunner3} {
+		_, err := service.JoinTeam(ctx, runner, created.Code)
+		require.NoError(t, err)
+	}
+
+	_, err = service.Dashboard(ctx, testTeamRunner1, teamID)
+	assert.True(t, errors.Is(err, ErrTeamAccessDenied), "athletes cannot see teammates' training")
+
+	dashboard, err := service.Dashboard(ctx, testTeamOwnerID, teamID)
+	require.NoError(t, err)
+	require.Len(t, dashboard.Athletes, 3, "the owner is not an athlete on the dashboard")
+	assert.Equal(t, "Overreaching Runner", dashboard.Athletes[0].Name, "highest risk first")
+	assert.Equal(t, InjuryRiskHigh, dashboard.Athletes[0].RiskLevel)
+	assert.Equal(t, 1, dashboard.AtRiskCount)
+
+	var quiet TeamAthleteStatus
+	for _, status := range dashboard.Athletes {
+		if status.UserID == testTeamRunner3 {
+			quiet = status
+		}
+	}
+	assert.Equal(t, "Strava connection expired", quiet.Error)
+	require.NotEmpty(t, strava.priorities)
+	for _, priority := range strava.priorities {
+		assert.Equal(t, StravaPriorityBackground, priority, "dashboard reads leave the interactive reserve alone")
+	}
+
+	// The team tool resolves the only team the user is staff of
+	ai := &aiService{teamService: service}
+	content, err := ai.executeGetTeamOverview(ctx, &MessageContext{UserID: testTeamOwnerID}, TeamOverviewRequest{})
+	require.NoError(t, err)
+	assert.Contains(t, content, "# Harriers")
+	assert.Contains(t, content, "- Overreaching Runner (high risk)")
+	assert.True(t, strings.Index(content, "Overreaching Runner") < strings.Index(content, "Steady Runner"))
+
+	_, err = ai.executeGetTeamOverview(ctx, &MessageContext{UserID: testTeamRunner1}, TeamOverviewRequest{})
+	assert.True(t, errors.Is(err, ErrTeamAccessDenied))
+}
@@ -0,0 +1,124 @@
+package services
+
+import (
+	"log/slog"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"bodda/internal/config"
+	"bodda/internal/tokenizer"
+)
+
+// TokenCounter counts model tokens in text
+type TokenCounter interface {
+	CountTokens(text string) int
+	Name() string
+}
+
+// heuristicTokenCounter estimates tokens from the character count
+type heuristicTokenCounter struct {
+	ratio float64
+}
+
+// NewHeuristicTokenCounter creates a fast token counter based on a tokens-per-character ratio
+func NewHeuristicTokenCounter(ratio float64) TokenCounter {
+	if ratio <= 0 {
+		ratio = 0.25
+	}
+	return &heuristicTokenCounter{ratio: ratio}
+}
+
+// CountTokens estimates the token count from the number of bytes in text
+func (h *heuristicTokenCounter) CountTokens(text string) int {
+	return int(float64(len(text)) * h.ratio)
+}
+
+// Name returns the counter name
+func (h *heuristicTokenCounter) Name() string {
+	return "heuristic"
+}
+
+// bpeTokenCounter counts tokens with a BPE encoding
+type bpeTokenCounter struct {
+	encoding      *tokenizer.Encoding
+	maxExactChars int
+}
+
+// NewBPETokenCounter creates a token counter backed by a BPE encoding.
+// Texts longer than maxExactChars are counted on a prefix and extrapolated.
+func NewBPETokenCounter(encoding *tokenizer.Encoding, maxExactChars int) TokenCounter {
+	return &bpeTokenCounter{
+		encoding:      encoding,
+		maxExactChars: maxExactChars,
+	}
+}
+
+// CountTokens counts tokens in text
+func (b *bpeTokenCounter) CountTokens(text string) int {
+	if b.maxExactChars <= 0 || len(text) <= b.maxExactChars {
+		return b.encoding.Count(text)
+	}
+
+	// Cut the prefix on a rune boundary and scale its token density to the full text
+	cut := b.maxExactChars
+	for cut > 0 && !utf8.RuneStart(text[cut]) {
+		cut--
+	}
+	if cut == 0 {
+		return b.encoding.Count(text)
+	}
+
+	prefixTokens := b.encoding.Count(text[:cut])
+	return int(float64(prefixTokens) * float64(len(text)) / float64(cut))
+}
+
+// Name returns the encoding name
+func (b *bpeTokenCounter) Name() string {
+	return b.encoding.Name()
+}
+
+// NewTokenCounter creates the configured token counter, falling back to the
+// character-ratio heuristic when the tokenizer is disabled or its vocabulary is unavailable
+func NewTokenCounter(cfg *config.Config) TokenCounter {
+	heuristic := NewHeuristicTokenCounter(cfg.StreamProcessing.TokenPerCharRatio)
+
+	if cfg.Tokenizer.Encoding == "" || cfg.Tokenizer.Encoding == "heuristic" {
+		return heuristic
+	}
+
+	encoding, err := tokenizer.Load(cfg.Tokenizer.Encoding, cfg.Tokenizer.VocabDir)
+	if err != nil {
+		slog.Warn("Tokenizer unavailable, using heuristic token estimation",
+			"encoding", cfg.Tokenizer.Encoding,
+			"error", err)
+		return heuristic
+	}
+
+	return NewBPETokenCounter(encoding, cfg.Tokenizer.MaxExactChars)
+}
+
+// streamSampleDataPoints is the number of values in the sample used to calibrate per-point token cost
+const streamSampleDataPoints = 100
+
+// streamDataPointSample is a serialized run of typical stream values (about 4 characters per point)
+var streamDataPointSample = buildStreamDataPointSample()
+
+func buildStreamDataPointSample() string {
+	var builder strings.Builder
+	for i := 0; i < streamSampleDataPoints; i++ {
+		// Three-digit values such as heart rate, cadence or power followed by a separator
+		builder.WriteString(strconv.Itoa(100 + (i*37)%900))
+		builder.WriteByte(',')
+	}
+	return builder.String()
+}
+
+// tokensPerDataPoint estimates how many tokens a single serialized stream value costs
+func tokensPerDataPoint(counter TokenCounter) float64 {
+	tokens := float64(counter.CountTokens(streamDataPointSample)) / streamSampleDataPoints
+	if tokens <= 0 {
+		return 1
+	}
+	return tokens
+}
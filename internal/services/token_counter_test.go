@@ -0,0 +1,188 @@
+package services
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"bodda/internal/config"
+	"bodda/internal/tokenizer"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newByteLevelTokenCounter builds a BPE counter over a vocabulary of single bytes only,
+// so every byte is one token
+func newByteLevelTokenCounter(t *testing.T, maxExactChars int) TokenCounter {
+	t.Helper()
+	ranks := make(map[string]int, 256)
+	for i := 0; i < 256; i++ {
+		ranks[string([]byte{byte(i)})] = i
+	}
+	pattern, err := tokenizer.Pattern(tokenizer.Cl100kBase)
+	require.NoError(t, err)
+	encoding, err := tokenizer.NewEncoding("bytes", ranks, pattern)
+	require.NoError(t, err)
+	return NewBPETokenCounter(encoding, maxExactChars)
+}
+
+// newRealTokenCounter returns the production BPE counter. It skips when no vocabulary is available,
+// unless TOKENIZER_REQUIRE_VOCAB is set as it is in CI.
+func newRealTokenCounter(t *testing.T) TokenCounter {
+	t.Helper()
+	counter := NewTokenCounter(&config.Config{
+		StreamProcessing: config.StreamProcessingConfig{TokenPerCharRatio: 0.25},
+		Tokenizer: config.TokenizerConfig{
+			Encoding:      tokenizer.O200kBase,
+			VocabDir:      os.Getenv("TOKENIZER_VOCAB_DIR"),
+			MaxExactChars: 200000,
+		},
+	})
+	if counter.Name() == "heuristic" {
+		if os.Getenv("TOKENIZER_REQUIRE_VOCAB") != "" {
+			t.Fatal("o200k_base vocabulary not available; run make tokenizer-vocab")
+		}
+		t.Skip("o200k_base vocabulary not available")
+	}
+	return counter
+}
+
+func TestHeuristicTokenCounter(t *testing.T) {
+	counter := NewHeuristicTokenCounter(0.25)
+
+	assert.Equal(t, "heuristic", counter.Name())
+	assert.Equal(t, 0, counter.CountTokens(""))
+	assert.Equal(t, 25, counter.CountTokens(strings.Repeat("a", 100)))
+
+	// Invalid ratios fall back to the default
+	assert.Equal(t, 25, NewHeuristicTokenCounter(0).CountTokens(strings.Repeat("a", 100)))
+}
+
+func TestBPETokenCounter(t *testing.T) {
+	counter := newByteLevelTokenCounter(t, 0)
+
+	assert.Equal(t, "bytes", counter.Name())
+	assert.Equal(t, 11, counter.CountTokens("hello world"))
+}
+
+func TestBPETokenCounter_ExtrapolatesLongText(t *testing.T) {
+	counter := newByteLevelTokenCounter(t, 1000)
+
+	text := strings.Repeat("héllo ", 1000)
+	tokens := counter.CountTokens(text)
+	assert.InDelta(t, len(text), tokens, float64(len(text))*0.01)
+}
+
+func TestNewTokenCounter_Fallback(t *testing.T) {
+	t.Run("heuristic encoding", func(t *testing.T) {
+		counter := NewTokenCounter(&config.Config{
+			StreamProcessing: config.StreamProcessingConfig{TokenPerCharRatio: 0.3},
+			Tokenizer:        config.TokenizerConfig{Encoding: "heuristic"},
+		})
+		assert.Equal(t, "heuristic", counter.Name())
+		assert.Equal(t, 30, counter.CountTokens(strings.Repeat("a", 100)))
+	})
+
+	t.Run("unconfigured", func(t *testing.T) {
+		counter := NewTokenCounter(&config.Config{})
+		assert.Equal(t, "heuristic", counter.Name())
+	})
+
+	t.Run("unknown encoding", func(t *testing.T) {
+		counter := NewTokenCounter(&config.Config{
+			Tokenizer: config.TokenizerConfig{Encoding: "p50k_base"},
+		})
+		assert.Equal(t, "heuristic", counter.Name())
+	})
+}
+
+func TestTokensPerDataPoint(t *testing.T) {
+	// The heuristic matches the historical "4 characters per data point" estimate
+	assert.InDelta(t, 1.0, tokensPerDataPoint(NewHeuristicTokenCounter(0.25)), 0.0001)
+	assert.InDelta(t, 4.0, tokensPerDataPoint(newByteLevelTokenCounter(t, 0)), 0.0001)
+}
+
+func TestStreamConfigTokenCounter(t *testing.T) {
+	cfg := &StreamConfig{
+		MaxContextTokens:  15000,
+		TokenPerCharRatio: 0.25,
+		MaxPageSize:       5000,
+	}
+	assert.Equal(t, "heuristic", cfg.tokenCounter().Name())
+
+	// A denser tokenizer yields smaller pages
+	heuristicPageSize := NewPaginationCalculator(cfg, nil).CalculateOptimalPageSize(13000)
+	cfg.TokenCounter = newByteLevelTokenCounter(t, 0)
+	bpePageSize := NewPaginationCalculator(cfg, nil).CalculateOptimalPageSize(13000)
+	assert.Less(t, bpePageSize, heuristicPageSize)
+}
+
+// tokenCounterComparisonSamples returns real formatter outputs used to compare counters
+func tokenCounterComparisonSamples() map[string]string {
+	formatter := NewOutputFormatter()
+
+	activities := []*StravaActivity{
+		{
+			ID:                 1001,
+			Name:               "Morning Tempo Run",
+			Distance:           12034.5,
+			MovingTime:         3120,
+			ElapsedTime:        3300,
+			TotalElevationGain: 84.2,
+			Type:               "Run",
+			SportType:          "Run",
+			StartDateLocal:     "2024-05-12T06:45:00Z",
+			AverageSpeed:       3.86,
+			MaxSpeed:           5.1,
+			AverageHeartrate:   158.4,
+			MaxHeartrate:       176,
+			HasHeartrate:       true,
+		},
+		{
+			ID:                 1002,
+			Name:               "Endurance Ride with hill repeats",
+			Distance:           84210.0,
+			MovingTime:         10840,
+			ElapsedTime:        11500,
+			TotalElevationGain: 1120.5,
+			Type:               "Ride",
+			SportType:          "Ride",
+			StartDateLocal:     "2024-05-13T08:00:00Z",
+			AverageSpeed:       7.77,
+			MaxSpeed:           16.3,
+			AverageHeartrate:   141.2,
+			MaxHeartrate:       182,
+			AveragePower:       212.5,
+			MaxPower:           780,
+			DeviceWatts:        true,
+			HasHeartrate:       true,
+		},
+	}
+
+	return map[string]string{
+		"activities": formatter.FormatActivities(activities),
+		"streams":    formatter.FormatStreamData(createLargeStreamData(300), "raw"),
+	}
+}
+
+func TestTokenCounters_CompareOnFormatterOutputs(t *testing.T) {
+	bpe := newRealTokenCounter(t)
+	heuristic := NewHeuristicTokenCounter(0.25)
+
+	for name, text := range tokenCounterComparisonSamples() {
+		t.Run(name, func(t *testing.T) {
+			require.NotEmpty(t, text)
+
+			exact := bpe.CountTokens(text)
+			estimate := heuristic.CountTokens(text)
+			require.Greater(t, exact, 0)
+
+			// The heuristic is a rough fallback; it should stay within a small factor of the tokenizer
+			ratio := float64(estimate) / float64(exact)
+			t.Logf("%s: tokenizer=%d heuristic=%d ratio=%.2f", name, exact, estimate, ratio)
+			assert.Greater(t, ratio, 0.4)
+			assert.Less(t, ratio, 2.5)
+		})
+	}
+}
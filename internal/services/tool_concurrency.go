@@ -0,0 +1,221 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/openai/openai-go/v2/responses"
+)
+
+// sequentialTools modify athlete state and are never run concurrently with each other.
+// They run in call order after the read-only calls of the round have completed.
+var sequentialTools = map[string]bool{
+	"update-athlete-logbook": true,
+}
+
+// userConcurrencyLimiter bounds the number of tool calls in flight per user across all requests
+type userConcurrencyLimiter struct {
+	mu    sync.Mutex
+	limit int
+	slots map[string]*userSlots
+}
+
+// userSlots holds one user's slots. refs counts the calls holding or waiting for a slot, so the
+// entry can be dropped once the user has none and the map only holds users with calls in flight.
+type userSlots struct {
+	ch   chan struct{}
+	refs int
+}
+
+func newUserConcurrencyLimiter(limit int) *userConcurrencyLimiter {
+	if limit <= 0 {
+		limit = 1
+	}
+	return &userConcurrencyLimiter{
+		limit: limit,
+		slots: make(map[string]*userSlots),
+	}
+}
+
+// retain returns the user's slots, counting the caller until it calls releaseRef
+func (l *userConcurrencyLimiter) retain(userID string) chan struct{} {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	slots, ok := l.slots[userID]
+	if !ok {
+		slots = &userSlots{ch: make(chan struct{}, l.limit)}
+		l.slots[userID] = slots
+	}
+	slots.refs++
+	return slots.ch
+}
+
+// releaseRef stops counting a caller, freeing its slot first when it holds one
+func (l *userConcurrencyLimiter) releaseRef(userID string, holdsSlot bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	slots, ok := l.slots[userID]
+	if !ok {
+		return
+	}
+	if holdsSlot {
+		select {
+		case <-slots.ch:
+		default:
+		}
+	}
+	slots.refs--
+	if slots.refs <= 0 {
+		delete(l.slots, userID)
+	}
+}
+
+// Acquire blocks until the user has a free slot or ctx is done
+func (l *userConcurrencyLimiter) Acquire(ctx context.Context, userID string) error {
+	if l == nil {
+		return nil
+	}
+
+	slots := l.retain(userID)
+	select {
+	case slots <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		l.releaseRef(userID, false)
+		return ctx.Err()
+	}
+}
+
+// Release frees a slot previously taken with Acquire
+func (l *userConcurrencyLimiter) Release(userID string) {
+	if l == nil {
+		return
+	}
+
+	l.releaseRef(userID, true)
+}
+
+// toolCallParallelism returns how many tool calls of a round may run at once.
+// Services without configuration keep the sequential behaviour.
+func (s *aiService) toolCallParallelism(callCount int) int {
+	if s.config == nil || callCount <= 1 {
+		return 1
+	}
+
+	workers := s.config.ToolExecution.MaxConcurrentExecs
+	if perUser := int(s.config.ToolMonitoring.MaxConcurrentPerUser); perUser > 0 && perUser < workers {
+		workers = perUser
+	}
+	if workers > callCount {
+		workers = callCount
+	}
+	if workers < 1 {
+		workers = 1
+	}
+	return workers
+}
+
+// executeToolCallsConcurrently runs independent tool calls of a round on a bounded worker pool.
+// Results are returned in the same order as toolCalls so call IDs line up with the model's request.
+func (s *aiService) executeToolCallsConcurrently(ctx context.Context, msgCtx *MessageContext, toolCalls []responses.ResponseFunctionToolCall) []ToolResult {
+	if len(toolCalls) == 0 {
+		return nil
+	}
+
+	results := make([]ToolResult, len(toolCalls))
+
+	var parallel, sequential []int
+	for i, toolCall := range toolCalls {
+		if sequentialTools[toolCall.Name] {
+			sequential = append(sequential, i)
+		} else {
+			parallel = append(parallel, i)
+		}
+	}
+
+	workers := s.toolCallParallelism(len(parallel))
+	start := time.Now()
+
+	slog.Info("Dispatching tool calls",
+		"parallel_calls", len(parallel),
+		"sequential_calls", len(sequential),
+		"workers", workers)
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				results[i] = s.executeLimitedToolCall(ctx, msgCtx, i, toolCalls[i])
+			}
+		}()
+	}
+	for _, i := range parallel {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	for _, i := range sequential {
+		results[i] = s.executeLimitedToolCall(ctx, msgCtx, i, toolCalls[i])
+	}
+
+	slog.Info("Tool calls completed",
+		"tool_call_count", len(toolCalls),
+		"workers", workers,
+		"duration_ms", time.Since(start).Milliseconds())
+
+	return results
+}
+
+// executeLimitedToolCall executes a tool call once the user has a free concurrency slot
+func (s *aiService) executeLimitedToolCall(ctx context.Context, msgCtx *MessageContext, index int, toolCall responses.ResponseFunctionToolCall) ToolResult {
+	userID := ""
+	if msgCtx != nil && msgCtx.User != nil {
+		userID = msgCtx.User.ID
+	}
+
+	if err := s.toolLimiter.Acquire(ctx, userID); err != nil {
+		return ToolResult{
+			ToolCallID: toolCall.CallID,
+			Error:      err.Error(),
+			Content:    "Error: tool call cancelled before it could start",
+		}
+	}
+	defer s.toolLimiter.Release(userID)
+
+	return s.executeResponsesAPIToolCall(ctx, msgCtx, index, toolCall)
+}
+
+// stravaWaitMessage tells the user a Strava request is queued for rate limit budget
+func stravaWaitMessage(wait time.Duration) string {
+	if wait < time.Minute {
+		return fmt.Sprintf("Strava is limiting requests right now, so I'm queued for about %d seconds before I can fetch your data...", int(wait.Round(time.Second).Seconds()))
+	}
+	return fmt.Sprintf("Strava is limiting requests right now, so I'm queued for about %d minutes before I can fetch your data...", int(wait.Round(time.Minute).Minutes()))
+}
+
+type toolProgressKey struct{}
+
+// withToolProgress attaches a progress reporter for messages sent while tools run.
+// The reporter is called at most once so parallel calls don't repeat the same notice.
+func withToolProgress(ctx context.Context, report func(string)) context.Context {
+	var once sync.Once
+	return context.WithValue(ctx, toolProgressKey{}, func(message string) {
+		once.Do(func() { report(message) })
+	})
+}
+
+// reportToolProgress sends a progress message to the user if a reporter is attached
+func reportToolProgress(ctx context.Context, message string) {
+	if report, ok := ctx.Value(toolProgressKey{}).(func(string)); ok {
+		report(message)
+	}
+}
@@ -0,0 +1,242 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"bodda/internal/config"
+	"bodda/internal/models"
+
+	"github.com/openai/openai-go/v2/responses"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeStravaServer serves activity details after a fixed delay and records peak concurrency
+type fakeStravaServer struct {
+	*httptest.Server
+	latency  time.Duration
+	inFlight int32
+	peak     int32
+}
+
+func newFakeStravaServer(latency time.Duration) *fakeStravaServer {
+	f := &fakeStravaServer{latency: latency}
+	f.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		current := atomic.AddInt32(&f.inFlight, 1)
+		defer atomic.AddInt32(&f.inFlight, -1)
+		for {
+			peak := atomic.LoadInt32(&f.peak)
+			if current <= peak || atomic.CompareAndSwapInt32(&f.peak, peak, current) {
+				break
+			}
+		}
+
+		time.Sleep(f.latency)
+
+		var id int64
+		fmt.Sscanf(strings.TrimPrefix(r.URL.Path, "/activities/"), "%d", &id)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(StravaActivityDetail{
+			StravaActivity: StravaActivity{ID: id, Name: fmt.Sprintf("Activity %d", id), Type: "Run"},
+		})
+	}))
+	return f
+}
+
+func newConcurrentTestAIService(stravaURL string, maxConcurrent int, maxPerUser int64) *aiService {
+	cfg := &config.Config{}
+	cfg.ToolExecution.MaxConcurrentExecs = maxConcurrent
+	cfg.ToolMonitoring.MaxConcurrentPerUser = maxPerUser
+
+	return &aiService{
+		stravaService: NewTestStravaService(cfg, stravaURL, &MockStravaUserRepository{}),
+		formatter:     NewOutputFormatter(),
+		config:        cfg,
+		toolLimiter:   newUserConcurrencyLimiter(int(maxPerUser)),
+	}
+}
+
+func activityDetailCalls(ids ...int64) []responses.ResponseFunctionToolCall {
+	calls := make([]responses.ResponseFunctionToolCall, len(ids))
+	for i, id := range ids {
+		calls[i] = responses.ResponseFunctionToolCall{
+			ID:        fmt.Sprintf("fc_%d", id),
+			CallID:    fmt.Sprintf("call_%d", id),
+			Name:      "get-activity-details",
+			Arguments: fmt.Sprintf(`{"activity_id": %d}`, id),
+		}
+	}
+	return calls
+}
+
+func concurrencyTestContext() *MessageContext {
+	return &MessageContext{
+		UserID: "user-1",
+		User: &models.User{
+			ID:           "user-1",
+			AccessToken:  "test_token",
+			RefreshToken: "test_refresh_token",
+			TokenExpiry:  time.Now().Add(time.Hour),
+		},
+	}
+}
+
+func TestExecuteToolsFromResponsesAPI_RunsCallsConcurrently(t *testing.T) {
+	latency := 100 * time.Millisecond
+	server := newFakeStravaServer(latency)
+	defer server.Close()
+
+	service := newConcurrentTestAIService(server.URL, 5, 5)
+	toolCalls := activityDetailCalls(11, 22, 33, 44, 55)
+
+	start := time.Now()
+	results, err := service.executeToolsFromResponsesAPI(context.Background(), concurrencyTestContext(), toolCalls)
+	elapsed := time.Since(start)
+
+	require.NoError(t, err)
+	require.Len(t, results, len(toolCalls))
+	assert.Less(t, elapsed, 3*latency, "five calls should overlap instead of running back to back")
+	assert.Greater(t, atomic.LoadInt32(&server.peak), int32(1))
+
+	for i, result := range results {
+		assert.Equal(t, toolCalls[i].CallID, result.ToolCallID, "results must keep call order")
+		assert.Empty(t, result.Error)
+	}
+	assert.Contains(t, results[2].Content, "Activity 33")
+}
+
+func TestExecuteToolsFromResponsesAPI_RespectsPerUserLimit(t *testing.T) {
+	server := newFakeStravaServer(30 * time.Millisecond)
+	defer server.Close()
+
+	service := newConcurrentTestAIService(server.URL, 5, 2)
+	results, err := service.executeToolsFromResponsesAPI(context.Background(), concurrencyTestContext(), activityDetailCalls(1, 2, 3, 4, 5, 6))
+
+	require.NoError(t, err)
+	assert.Len(t, results, 6)
+	assert.LessOrEqual(t, atomic.LoadInt32(&server.peak), int32(2))
+}
+
+func TestExecuteToolsFromResponsesAPI_PerUserLimitSpansRequests(t *testing.T) {
+	server := newFakeStravaServer(30 * time.Millisecond)
+	defer server.Close()
+
+	service := newConcurrentTestAIService(server.URL, 5, 2)
+
+	var wg sync.WaitGroup
+	for r := 0; r < 3; r++ {
+		wg.Add(1)
+		go func(r int64) {
+			defer wg.Done()
+			_, err := service.executeToolsFromResponsesAPI(context.Background(), concurrencyTestContext(), activityDetailCalls(r*10+1, r*10+2))
+			assert.NoError(t, err)
+		}(int64(r))
+	}
+	wg.Wait()
+
+	assert.LessOrEqual(t, atomic.LoadInt32(&server.peak), int32(2))
+}
+
+func TestExecuteToolsFromResponsesAPI_SequentialWithoutConfig(t *testing.T) {
+	server := newFakeStravaServer(10 * time.Millisecond)
+	defer server.Close()
+
+	service := &aiService{
+		stravaService: NewTestStravaService(&config.Config{}, server.URL, &MockStravaUserRepository{}),
+		formatter:     NewOutputFormatter(),
+	}
+
+	results, err := service.executeToolsFromResponsesAPI(context.Background(), concurrencyTestContext(), activityDetailCalls(1, 2, 3))
+	require.NoError(t, err)
+	assert.Len(t, results, 3)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&server.peak))
+}
+
+func TestExecuteToolCallsConcurrently_CancelledContext(t *testing.T) {
+	limiter := newUserConcurrencyLimiter(1)
+	require.NoError(t, limiter.Acquire(context.Background(), "user-1"))
+	defer limiter.Release("user-1")
+
+	service := &aiService{config: &config.Config{}, toolLimiter: limiter}
+	service.config.ToolExecution.MaxConcurrentExecs = 2
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	results := service.executeToolCallsConcurrently(ctx, concurrencyTestContext(), activityDetailCalls(1, 2))
+	require.Len(t, results, 2)
+	for i, result := range results {
+		assert.Equal(t, fmt.Sprintf("call_%d", i+1), result.ToolCallID)
+		assert.NotEmpty(t, result.Error)
+	}
+}
+
+func TestUserConcurrencyLimiter_DropsIdleUsers(t *testing.T) {
+	limiter := newUserConcurrencyLimiter(1)
+	require.NoError(t, limiter.Acquire(context.Background(), "user-1"))
+
+	// A waiter keeps the user's slots alive until it has been served
+	acquired := make(chan struct{})
+	go func() {
+		assert.NoError(t, limiter.Acquire(context.Background(), "user-1"))
+		close(acquired)
+	}()
+	time.Sleep(10 * time.Millisecond)
+
+	limiter.Release("user-1")
+	<-acquired
+	assert.Len(t, limiter.slots, 1)
+
+	// Callers that give up waiting are no longer counted
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.Error(t, limiter.Acquire(ctx, "user-1"))
+	assert.Equal(t, 1, limiter.slots["user-1"].refs)
+
+	limiter.Release("user-1")
+	assert.Empty(t, limiter.slots, "users without calls in flight are forgotten")
+}
+
+func TestFixToolResultIDs_KeepsCallIDsForRepeatedFunctions(t *testing.T) {
+	service := &aiService{}
+	toolCalls := activityDetailCalls(1, 2, 3)
+	results := []ToolResult{
+		{ToolCallID: "call_1", Content: "one"},
+		{ToolCallID: "call_2", Content: "two"},
+		{ToolCallID: "call_3", Content: "three"},
+	}
+
+	fixed := service.fixToolResultIDs(toolCalls, results)
+	require.Len(t, fixed, 3)
+	for i, result := range fixed {
+		assert.Equal(t, toolCalls[i].CallID, result.ToolCallID)
+	}
+}
+
+func TestRateLimiter_ConcurrentAllow(t *testing.T) {
+	limiter := NewRateLimiter(50, time.Minute)
+
+	var allowed int32
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if limiter.Allow() {
+				atomic.AddInt32(&allowed, 1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, int32(50), allowed)
+}
@@ -2,6 +2,9 @@ package services
 
 import (
 	"context"
+	"fmt"
+
+	"github.com/openai/openai-go/v2/responses"
 )
 
 // toolExecutionAdapter adapts the existing AI service to provide tool execution capabilities
@@ -39,4 +42,16 @@ func (tea *toolExecutionAdapter) ExecuteGetActivityStreams(ctx context.Context,
 // ExecuteUpdateAthleteLogbook executes the update-athlete-logbook tool
 func (tea *toolExecutionAdapter) ExecuteUpdateAthleteLogbook(ctx context.Context, msgCtx *MessageContext, content string) (string, error) {
 	return tea.aiService.ExecuteUpdateAthleteLogbook(ctx, msgCtx, content)
+}
+
+// ExecuteToolCall executes tools that are not part of ToolExecutionService
+func (tea *toolExecutionAdapter) ExecuteToolCall(ctx context.Context, msgCtx *MessageContext, toolCall responses.ResponseFunctionToolCall) ToolResult {
+	if extended, ok := tea.aiService.(ToolCallExecutor); ok {
+		return extended.ExecuteToolCall(ctx, msgCtx, toolCall)
+	}
+	return ToolResult{
+		ToolCallID: toolCall.CallID,
+		Error:      fmt.Sprintf("unknown tool: %s", toolCall.Name),
+		Content:    fmt.Sprintf("Tool '%s' is not supported", toolCall.Name),
+	}
 }
\ No newline at end of file
@@ -9,6 +9,7 @@ import (
 
 	"bodda/internal/models"
 
+	"github.com/openai/openai-go/v2/responses"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -368,6 +369,14 @@ func (m *mockToolExecutionServiceComprehensive) ExecuteUpdateAthleteLogbook(ctx
 	return m.executeWithMock(ctx, "update-athlete-logbook")
 }
 
+func (m *mockToolExecutionServiceComprehensive) ExecuteToolCall(ctx context.Context, msgCtx *MessageContext, toolCall responses.ResponseFunctionToolCall) ToolResult {
+	content, err := m.executeWithMock(ctx, toolCall.Name)
+	if err != nil {
+		return ToolResult{ToolCallID: toolCall.CallID, Error: err.Error(), Content: err.Error()}
+	}
+	return ToolResult{ToolCallID: toolCall.CallID, Content: content}
+}
+
 func (m *mockToolExecutionServiceComprehensive) executeWithMock(ctx context.Context, toolName string) (string, error) {
 	response, exists := m.responses[toolName]
 	if !exists {
@@ -416,6 +425,47 @@ func getValidParametersForTool(toolName string) map[string]interface{} {
 		return map[string]interface{}{
 			"content": "Test logbook content",
 		}
+	case "render-activity-chart":
+		return map[string]interface{}{
+			"activity_id": int64(123456),
+			"chart_type":  "metrics",
+		}
+	case "compare-activities":
+		return map[string]interface{}{
+			"activity_ids": []int64{123456, 654321},
+		}
+	case "search-activities":
+		return map[string]interface{}{
+			"sport_types": []string{"Run"},
+			"limit":       10,
+		}
+	case "get-training-summary":
+		return map[string]interface{}{
+			"period":  "week",
+			"periods": 12,
+		}
+	case "get-aerobic-trend":
+		return map[string]interface{}{
+			"sport_types": []string{"Run"},
+			"weeks":       8,
+		}
+	case "analyze-activity-conditions":
+		return map[string]interface{}{
+			"activity_id":           123456,
+			"reference_activity_id": 654321,
+		}
+	case "get-readiness":
+		return map[string]interface{}{
+			"date": "",
+		}
+	case "get-injury-risk":
+		return map[string]interface{}{
+			"date": "",
+		}
+	case "get-team-overview":
+		return map[string]interface{}{
+			"team": "",
+		}
 	default:
 		return map[string]interface{}{}
 	}
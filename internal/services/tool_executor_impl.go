@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"bodda/internal/models"
+	"bodda/internal/monitoring"
 
 	"github.com/openai/openai-go/v2/responses"
 )
@@ -22,6 +23,12 @@ type ToolExecutionService interface {
 	ExecuteUpdateAthleteLogbook(ctx context.Context, msgCtx *MessageContext, content string) (string, error)
 }
 
+// ToolCallExecutor is implemented by tool services that can run tools beyond the
+// ToolExecutionService methods, such as analysis and chart tools
+type ToolCallExecutor interface {
+	ExecuteToolCall(ctx context.Context, msgCtx *MessageContext, toolCall responses.ResponseFunctionToolCall) ToolResult
+}
+
 // toolExecutor implements the ToolExecutor interface with enhanced timeout and streaming support
 type toolExecutor struct {
 	toolService    ToolExecutionService
@@ -30,28 +37,40 @@ type toolExecutor struct {
 	maxTimeout     time.Duration
 	mu             sync.RWMutex
 	activeJobs     map[string]context.CancelFunc
+	cache          ToolResultCache
+	cacheTTL       time.Duration
 }
 
-// NewToolExecutor creates a new tool executor with enhanced timeout and streaming support
-func NewToolExecutor(toolService ToolExecutionService, registry ToolRegistry) ToolExecutor {
-	return &toolExecutor{
-		toolService:    toolService,
-		registry:       registry,
-		defaultTimeout: 30 * time.Second,
-		maxTimeout:     300 * time.Second,
-		activeJobs:     make(map[string]context.CancelFunc),
+// ToolExecutorOption configures optional tool executor behaviour
+type ToolExecutorOption func(*toolExecutor)
+
+// WithToolResultCache reuses successful results of cacheable tools.
+// defaultTTL applies to tools without a tool-specific TTL.
+func WithToolResultCache(cache ToolResultCache, defaultTTL time.Duration) ToolExecutorOption {
+	return func(te *toolExecutor) {
+		te.cache = cache
+		te.cacheTTL = defaultTTL
 	}
 }
 
+// NewToolExecutor creates a new tool executor with enhanced timeout and streaming support
+func NewToolExecutor(toolService ToolExecutionService, registry ToolRegistry, opts ...ToolExecutorOption) ToolExecutor {
+	return NewToolExecutorWithConfig(toolService, registry, 30*time.Second, 300*time.Second, opts...)
+}
+
 // NewToolExecutorWithConfig creates a new tool executor with custom timeout configuration
-func NewToolExecutorWithConfig(toolService ToolExecutionService, registry ToolRegistry, defaultTimeout, maxTimeout time.Duration) ToolExecutor {
-	return &toolExecutor{
+func NewToolExecutorWithConfig(toolService ToolExecutionService, registry ToolRegistry, defaultTimeout, maxTimeout time.Duration, opts ...ToolExecutorOption) ToolExecutor {
+	te := &toolExecutor{
 		toolService:    toolService,
 		registry:       registry,
 		defaultTimeout: defaultTimeout,
 		maxTimeout:     maxTimeout,
 		activeJobs:     make(map[string]context.CancelFunc),
 	}
+	for _, opt := range opts {
+		opt(te)
+	}
+	return te
 }
 
 // ExecuteTool executes a tool with the given parameters and context
@@ -85,6 +104,20 @@ func (te *toolExecutor) ExecuteToolWithOptions(ctx context.Context, toolName str
 		return result, err
 	}
 
+	// Serve cacheable tools from the result cache when possible
+	cacheKey, cacheTTL := te.cacheLookupKey(toolName, parameters, msgCtx)
+	if cacheKey != "" {
+		if content, ok := te.cache.Get(ctx, cacheKey); ok {
+			monitoring.RecordCacheHit(toolName)
+			result.Success = true
+			result.Data = content
+			result.Duration = time.Since(startTime).Milliseconds()
+			log.Printf("Tool result served from cache: tool=%s", toolName)
+			return result, nil
+		}
+		monitoring.RecordCacheMiss(toolName)
+	}
+
 	// Set up timeout context with enhanced timeout handling
 	execCtx, cancel := te.setupTimeoutContext(ctx, options, jobID)
 	defer func() {
@@ -96,11 +129,54 @@ func (te *toolExecutor) ExecuteToolWithOptions(ctx context.Context, toolName str
 	log.Printf("Starting tool execution: tool=%s, job_id=%s, timeout=%v", toolName, jobID, te.getTimeoutDuration(options))
 
 	// Handle streaming vs buffered execution
+	var execErr error
 	if options != nil && options.Streaming {
-		return te.executeToolStreaming(execCtx, toolName, parameters, msgCtx, result, jobID)
+		result, execErr = te.executeToolStreaming(execCtx, toolName, parameters, msgCtx, result, jobID)
 	} else {
-		return te.executeToolBuffered(execCtx, toolName, parameters, msgCtx, result, jobID)
+		result, execErr = te.executeToolBuffered(execCtx, toolName, parameters, msgCtx, result, jobID)
 	}
+
+	if cacheKey != "" && execErr == nil && result.Success {
+		if content, ok := result.Data.(string); ok {
+			te.cache.Set(ctx, cacheKey, cacheUserID(msgCtx), toolName, content, cacheTTL)
+		}
+	}
+
+	return result, execErr
+}
+
+// cacheLookupKey returns the cache key and TTL for a tool call, or an empty key when the call is not cacheable
+func (te *toolExecutor) cacheLookupKey(toolName string, parameters map[string]interface{}, msgCtx *MessageContext) (string, time.Duration) {
+	userID := cacheUserID(msgCtx)
+	if te.cache == nil || userID == "" {
+		return "", 0
+	}
+
+	ttl, cacheable := toolCacheTTL(toolName, te.cacheTTL)
+	if !cacheable {
+		return "", 0
+	}
+
+	key, err := toolCacheKey(userID, toolName, parameters)
+	if err != nil {
+		log.Printf("Skipping tool result cache for %s: %v", toolName, err)
+		return "", 0
+	}
+	return key, ttl
+}
+
+// cacheUserID returns the user that cached results belong to
+func cacheUserID(msgCtx *MessageContext) string {
+	if msgCtx == nil {
+		return ""
+	}
+	if msgCtx.UserID != "" {
+		return msgCtx.UserID
+	}
+	if msgCtx.User != nil {
+		return msgCtx.User.ID
+	}
+	return ""
 }
 
 // setupTimeoutContext creates a timeout context with job tracking for graceful cleanup
@@ -416,6 +492,10 @@ func (te *toolExecutor) executeToolCall(ctx context.Context, msgCtx *MessageCont
 		}
 
 	default:
+		if extended, ok := te.toolService.(ToolCallExecutor); ok {
+			result = extended.ExecuteToolCall(ctx, msgCtx, toolCall)
+			break
+		}
 		result.Error = fmt.Sprintf("unknown tool: %s", toolCall.Name)
 		result.Content = fmt.Sprintf("Tool '%s' is not supported", toolCall.Name)
 	}
@@ -137,7 +137,7 @@ func TestToolExecutorIntegrationWithAIService(t *testing.T) {
 // Mock services for integration testing (with unique names to avoid conflicts)
 type mockStravaServiceForToolExecutor struct{}
 
-func (m *mockStravaServiceForToolExecutor) GetAthleteProfile(user *models.User) (*StravaAthleteWithZones, error) {
+func (m *mockStravaServiceForToolExecutor) GetAthleteProfile(ctx context.Context, user *models.User) (*StravaAthleteWithZones, error) {
 	return &StravaAthleteWithZones{
 		StravaAthlete: &StravaAthlete{
 			ID:        12345,
@@ -148,11 +148,11 @@ func (m *mockStravaServiceForToolExecutor) GetAthleteProfile(user *models.User)
 	}, nil
 }
 
-func (m *mockStravaServiceForToolExecutor) GetAthleteZones(user *models.User) (*StravaAthleteZones, error) {
+func (m *mockStravaServiceForToolExecutor) GetAthleteZones(ctx context.Context, user *models.User) (*StravaAthleteZones, error) {
 	return &StravaAthleteZones{}, nil
 }
 
-func (m *mockStravaServiceForToolExecutor) GetActivities(user *models.User, params ActivityParams) ([]*StravaActivity, error) {
+func (m *mockStravaServiceForToolExecutor) GetActivities(ctx context.Context, user *models.User, params ActivityParams) ([]*StravaActivity, error) {
 	return []*StravaActivity{
 		{
 			ID:   123456,
@@ -162,7 +162,7 @@ func (m *mockStravaServiceForToolExecutor) GetActivities(user *models.User, para
 	}, nil
 }
 
-func (m *mockStravaServiceForToolExecutor) GetActivityDetail(user *models.User, activityID int64) (*StravaActivityDetail, error) {
+func (m *mockStravaServiceForToolExecutor) GetActivityDetail(ctx context.Context, user *models.User, activityID int64) (*StravaActivityDetail, error) {
 	return &StravaActivityDetail{
 		StravaActivity: StravaActivity{
 			ID:   activityID,
@@ -172,7 +172,7 @@ func (m *mockStravaServiceForToolExecutor) GetActivityDetail(user *models.User,
 	}, nil
 }
 
-func (m *mockStravaServiceForToolExecutor) GetActivityDetailWithZones(user *models.User, activityID int64) (*StravaActivityDetailWithZones, error) {
+func (m *mockStravaServiceForToolExecutor) GetActivityDetailWithZones(ctx context.Context, user *models.User, activityID int64) (*StravaActivityDetailWithZones, error) {
 	return &StravaActivityDetailWithZones{
 		StravaActivityDetail: &StravaActivityDetail{
 			StravaActivity: StravaActivity{
@@ -193,14 +193,14 @@ func (m *mockStravaServiceForToolExecutor) GetActivityDetailWithZones(user *mode
 	}, nil
 }
 
-func (m *mockStravaServiceForToolExecutor) GetActivityStreams(user *models.User, activityID int64, streamTypes []string, resolution string) (*StravaStreams, error) {
+func (m *mockStravaServiceForToolExecutor) GetActivityStreams(ctx context.Context, user *models.User, activityID int64, streamTypes []string, resolution string) (*StravaStreams, error) {
 	return &StravaStreams{
 		Time:      []int{0, 1, 2, 3, 4},
 		Heartrate: []int{120, 125, 130, 135, 140},
 	}, nil
 }
 
-func (m *mockStravaServiceForToolExecutor) GetActivityZones(user *models.User, activityID int64) (*StravaActivityZones, error) {
+func (m *mockStravaServiceForToolExecutor) GetActivityZones(ctx context.Context, user *models.User, activityID int64) (*StravaActivityZones, error) {
 	return &StravaActivityZones{
 		HeartRate: &StravaZoneDistribution{
 			Type: "heartrate",
@@ -357,6 +357,425 @@ Last updated: 2024-01-15`,
 			},
 		},
 	}
+
+	// Define render-activity-chart tool
+	tr.tools["render-activity-chart"] = models.ToolDefinition{
+		Name:        "render-activity-chart",
+		Description: "Render a Vega-Lite chart built directly from an activity's Strava data. Returns a vega-lite code block to include verbatim in the reply.",
+		Parameters: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"activity_id": map[string]interface{}{
+					"type":        "integer",
+					"description": "The Strava activity ID",
+				},
+				"chart_type": map[string]interface{}{
+					"type":        "string",
+					"description": "metrics: selected metrics over distance; zones: time in heart rate, power or pace zones; laps: per-lap bars; elevation: elevation profile with climbs highlighted",
+					"enum":        []interface{}{"metrics", "zones", "laps", "elevation"},
+					"default":     "metrics",
+				},
+				"metrics": map[string]interface{}{
+					"type":        "array",
+					"description": "Metrics to plot for the metrics chart. Empty plots pace, heart rate and power when available",
+					"items": map[string]interface{}{
+						"type": "string",
+						"enum": []interface{}{"pace", "heartrate", "power", "cadence", "altitude"},
+					},
+				},
+				"zone_type": map[string]interface{}{
+					"type":        "string",
+					"description": "Zone distribution for the zones chart. Empty picks the first available",
+					"enum":        []interface{}{"", "heartrate", "power", "pace"},
+				},
+				"max_points": map[string]interface{}{
+					"type":        "integer",
+					"description": "Maximum number of points after down-sampling (50-1000, 0 for the default of 300)",
+					"minimum":     0,
+					"maximum":     1000,
+					"default":     300,
+				},
+			},
+			"required":             []string{"activity_id", "chart_type"},
+			"additionalProperties": false,
+		},
+		Examples: []models.ToolExample{
+			{
+				Description: "Plot pace and heart rate over distance",
+				Request: map[string]interface{}{
+					"activity_id": 123456789,
+					"chart_type":  "metrics",
+					"metrics":     []string{"pace", "heartrate"},
+				},
+				Response: map[string]interface{}{
+					"content": "```vega-lite\n{\"$schema\": \"https://vega.github.io/schema/vega-lite/v5.json\", ...}\n```",
+				},
+			},
+			{
+				Description: "Show time in heart rate zones",
+				Request: map[string]interface{}{
+					"activity_id": 123456789,
+					"chart_type":  "zones",
+					"zone_type":   "heartrate",
+				},
+				Response: map[string]interface{}{
+					"content": "```vega-lite\n{...bar chart of minutes per zone...}\n```",
+				},
+			},
+		},
+	}
+
+	// Define compare-activities tool
+	tr.tools["compare-activities"] = models.ToolDefinition{
+		Name:        "compare-activities",
+		Description: "Compare two to five activities (e.g. the same course or the same workout type) aligned on distance or time. Reports pace, heart rate, power, cadence, decoupling and lap split deltas relative to the first activity.",
+		Parameters: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"activity_ids": map[string]interface{}{
+					"type":        "array",
+					"description": "Strava activity IDs to compare. The first one is the baseline",
+					"items": map[string]interface{}{
+						"type": "integer",
+					},
+					"minItems": 2,
+					"maxItems": 5,
+				},
+				"align_by": map[string]interface{}{
+					"type":        "string",
+					"description": "Align activities on distance (same course) or elapsed time (same workout duration)",
+					"enum":        []interface{}{"distance", "time"},
+					"default":     "distance",
+				},
+			},
+			"required":             []string{"activity_ids"},
+			"additionalProperties": false,
+		},
+		Examples: []models.ToolExample{
+			{
+				Description: "Compare today's tempo run with last month's",
+				Request: map[string]interface{}{
+					"activity_ids": []int64{123456789, 987654321},
+					"align_by":     "distance",
+				},
+				Response: map[string]interface{}{
+					"content": "Summary table per activity, deltas vs the baseline (pace, HR, power, cadence, decoupling, lap consistency) and aligned splits",
+				},
+			},
+		},
+	}
+
+	tr.tools["search-activities"] = models.ToolDefinition{
+		Name:        "search-activities",
+		Description: "Search the athlete's Strava history for activities matching a date range, sport type, distance or moving time range, name keywords, trainer/commute/race flags or gear. Returns compact one-line summaries with activity IDs, newest first. Use empty strings or 0 for filters that should not apply.",
+		Parameters: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"after": map[string]interface{}{
+					"type":        "string",
+					"description": "Earliest start date (YYYY-MM-DD, inclusive), or empty for no lower bound",
+				},
+				"before": map[string]interface{}{
+					"type":        "string",
+					"description": "Latest start date (YYYY-MM-DD, inclusive), or empty for no upper bound",
+				},
+				"sport_types": map[string]interface{}{
+					"type":        "array",
+					"description": "Strava sport types to include (e.g. Run, TrailRun, Ride, VirtualRide, Swim). Empty for all sports",
+					"items": map[string]interface{}{
+						"type": "string",
+					},
+				},
+				"min_distance_km": map[string]interface{}{
+					"type":        "number",
+					"description": "Minimum distance in km, or 0",
+					"minimum":     0,
+				},
+				"max_distance_km": map[string]interface{}{
+					"type":        "number",
+					"description": "Maximum distance in km, or 0",
+					"minimum":     0,
+				},
+				"min_duration_minutes": map[string]interface{}{
+					"type":        "number",
+					"description": "Minimum moving time in minutes, or 0",
+					"minimum":     0,
+				},
+				"max_duration_minutes": map[string]interface{}{
+					"type":        "number",
+					"description": "Maximum moving time in minutes, or 0",
+					"minimum":     0,
+				},
+				"keywords": map[string]interface{}{
+					"type":        "string",
+					"description": "Words that must all appear in the activity name (case-insensitive), or empty",
+				},
+				"trainer": map[string]interface{}{
+					"type":        "string",
+					"description": "Indoor trainer activities: any, only or exclude",
+					"enum":        []interface{}{"any", "only", "exclude"},
+					"default":     "any",
+				},
+				"commute": map[string]interface{}{
+					"type":        "string",
+					"description": "Commutes: any, only or exclude",
+					"enum":        []interface{}{"any", "only", "exclude"},
+					"default":     "any",
+				},
+				"race": map[string]interface{}{
+					"type":        "string",
+					"description": "Activities marked as a race: any, only or exclude",
+					"enum":        []interface{}{"any", "only", "exclude"},
+					"default":     "any",
+				},
+				"gear_id": map[string]interface{}{
+					"type":        "string",
+					"description": "Strava gear ID (e.g. g123456 for shoes, b123456 for bikes), or empty",
+				},
+				"limit": map[string]interface{}{
+					"type":        "integer",
+					"description": "Maximum number of matches to return",
+					"minimum":     1,
+					"maximum":     100,
+					"default":     20,
+				},
+			},
+			"required":             []string{},
+			"additionalProperties": false,
+		},
+		Examples: []models.ToolExample{
+			{
+				Description: "Find long runs in the last marathon block",
+				Request: map[string]interface{}{
+					"after":           "2025-01-01",
+					"before":          "2025-04-30",
+					"sport_types":     []string{"Run"},
+					"min_distance_km": 25,
+					"race":            "exclude",
+				},
+				Response: map[string]interface{}{
+					"content": "Match count, applied filters and one line per activity with name, ID, distance, moving time, date and trainer/commute/race tags",
+				},
+			},
+		},
+	}
+
+	tr.tools["get-training-summary"] = models.ToolDefinition{
+		Name:        "get-training-summary",
+		Description: "Aggregate the athlete's training volume per week or per month: sessions, distance, moving time and elevation by sport, week-over-week ramp rate, heart rate intensity distribution and the longest session of each period. Computed server-side from the full activity history, so prefer it over listing activities for volume and consistency questions.",
+		Parameters: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"period": map[string]interface{}{
+					"type":        "string",
+					"description": "Aggregation period. Weeks start on Monday",
+					"enum":        []interface{}{"week", "month"},
+					"default":     "week",
+				},
+				"periods": map[string]interface{}{
+					"type":        "integer",
+					"description": "Number of periods to cover, ending with the current one (up to 52 weeks or 24 months)",
+					"minimum":     1,
+					"maximum":     52,
+					"default":     12,
+				},
+				"sport_types": map[string]interface{}{
+					"type":        "array",
+					"description": "Strava sport types to include (e.g. Run, TrailRun, Ride). Empty for all sports",
+					"items": map[string]interface{}{
+						"type": "string",
+					},
+				},
+			},
+			"required":             []string{},
+			"additionalProperties": false,
+		},
+		Examples: []models.ToolExample{
+			{
+				Description: "Weekly running volume over the last 12 weeks",
+				Request: map[string]interface{}{
+					"period":      "week",
+					"periods":     12,
+					"sport_types": []string{"Run", "TrailRun"},
+				},
+				Response: map[string]interface{}{
+					"content": "Table of weekly totals with ramp rate and intensity shares, per-sport breakdown and the longest session of each week",
+				},
+			},
+		},
+	}
+
+	tr.tools["get-aerobic-trend"] = models.ToolDefinition{
+		Name:        "get-aerobic-trend",
+		Description: "Track aerobic base development across recent easy sessions: efficiency factor (normalized power or pace per heartbeat), Pa:HR / Pw:HR decoupling between the first and second half of each session, and their trend over the block. Races are excluded and sessions are capped at an easy average heart rate.",
+		Parameters: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"sport_types": map[string]interface{}{
+					"type":        "array",
+					"description": "Strava sport types to include (e.g. Run, Ride). Defaults to Run when empty",
+					"items": map[string]interface{}{
+						"type": "string",
+					},
+				},
+				"weeks": map[string]interface{}{
+					"type":        "integer",
+					"description": "How many weeks back to look",
+					"minimum":     1,
+					"maximum":     26,
+					"default":     8,
+				},
+				"max_avg_heart_rate": map[string]interface{}{
+					"type":        "integer",
+					"description": "Only include sessions with an average heart rate at or below this value. 0 uses the top of the athlete's heart rate zone 2",
+					"minimum":     0,
+					"default":     0,
+				},
+				"min_duration_minutes": map[string]interface{}{
+					"type":        "integer",
+					"description": "Minimum moving time; decoupling is only meaningful for steady sessions of 40 minutes or more",
+					"minimum":     0,
+					"default":     40,
+				},
+				"limit": map[string]interface{}{
+					"type":        "integer",
+					"description": "Maximum number of sessions to analyze (each needs a stream download)",
+					"minimum":     1,
+					"maximum":     15,
+					"default":     10,
+				},
+			},
+			"required":             []string{},
+			"additionalProperties": false,
+		},
+		Examples: []models.ToolExample{
+			{
+				Description: "Is my aerobic base improving over this base block?",
+				Request: map[string]interface{}{
+					"sport_types": []string{"Run"},
+					"weeks":       10,
+				},
+				Response: map[string]interface{}{
+					"content": "Per-session efficiency factor and decoupling, the EF change per 4 weeks and mean decoupling against the 5% threshold",
+				},
+			},
+		},
+	}
+
+	tr.tools["analyze-activity-conditions"] = models.ToolDefinition{
+		Name:        "analyze-activity-conditions",
+		Description: "Estimate how heat and altitude affected an activity from its temperature and altitude streams: the performance penalty, the heat-induced heart rate rise and the equivalent pace or power in neutral conditions (15°C near sea level, runs also grade-adjusted). With a reference activity it reports how much of the pace or power difference the conditions explain.",
+		Parameters: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"activity_id": map[string]interface{}{
+					"type":        "integer",
+					"description": "The Strava activity ID to analyze",
+				},
+				"reference_activity_id": map[string]interface{}{
+					"type":        "integer",
+					"description": "Optional activity to compare against, typically a similar session in cooler or lower conditions. 0 for none",
+					"minimum":     0,
+					"default":     0,
+				},
+			},
+			"required":             []string{"activity_id"},
+			"additionalProperties": false,
+		},
+		Examples: []models.ToolExample{
+			{
+				Description: "Was today's slower tempo run explained by the heat?",
+				Request: map[string]interface{}{
+					"activity_id":           12345678,
+					"reference_activity_id": 12340000,
+				},
+				Response: map[string]interface{}{
+					"content": "Temperature, altitude and penalty for both activities, actual and conditions-adjusted deficits and how much of the slowdown the conditions explain",
+				},
+			},
+		},
+	}
+
+	tr.tools["get-readiness"] = models.ToolDefinition{
+		Name:        "get-readiness",
+		Description: "Assess the athlete's recovery from their logged daily wellness metrics (resting heart rate, HRV rMSSD, sleep, fatigue, soreness and mood). Returns a 0-100 readiness score relative to the athlete's own 28-day baseline with flags such as suppressed HRV or elevated resting heart rate, the last 7 days of entries and recent Strava training load.",
+		Parameters: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"date": map[string]interface{}{
+					"type":        "string",
+					"description": "Day to assess as YYYY-MM-DD. Empty for today",
+				},
+			},
+			"required":             []string{},
+			"additionalProperties": false,
+		},
+		Examples: []models.ToolExample{
+			{
+				Description: "Should I do intervals today?",
+				Request: map[string]interface{}{
+					"date": "",
+				},
+				Response: map[string]interface{}{
+					"content": "Readiness score and status with component scores against baseline, flags, recent entries and the last week's training load",
+				},
+			},
+		},
+	}
+
+	tr.tools["get-injury-risk"] = models.ToolDefinition{
+		Name:        "get-injury-risk",
+		Description: "Assess load-related injury risk over the 4 weeks ending on a day. Returns the acute:chronic workload ratio, Foster training monotony and strain from session RPE load, sudden jumps in weekly running volume, training time or long-run distance, injuries mentioned in the athlete logbook, and an overall low/moderate/high risk level with the thresholds that were crossed.",
+		Parameters: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"date": map[string]interface{}{
+					"type":        "string",
+					"description": "Last day of the assessed week as YYYY-MM-DD. Empty for today",
+				},
+			},
+			"required":             []string{},
+			"additionalProperties": false,
+		},
+		Examples: []models.ToolExample{
+			{
+				Description: "Is it safe to add another long run this week?",
+				Request: map[string]interface{}{
+					"date": "",
+				},
+				Response: map[string]interface{}{
+					"content": "Risk level, acute:chronic workload ratio, weekly load/monotony/strain table, triggered flags and logbook injury history",
+				},
+			},
+		},
+	}
+
+	tr.tools["get-team-overview"] = models.ToolDefinition{
+		Name:        "get-team-overview",
+		Description: "Summarize the last 4 weeks of training for every athlete of a team the user owns or coaches. Returns each athlete's sessions this week against their usual number, time, running volume, training load, acute:chronic workload ratio and injury risk level, followed by the load warnings and athletes who are missing sessions. Only team owners and coaches can use it.",
+		Parameters: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"team": map[string]interface{}{
+					"type":        "string",
+					"description": "Team name or ID. Empty when the user coaches a single team",
+				},
+			},
+			"required":             []string{},
+			"additionalProperties": false,
+		},
+		Examples: []models.ToolExample{
+			{
+				Description: "Who is overreaching this week?",
+				Request: map[string]interface{}{
+					"team": "",
+				},
+				Response: map[string]interface{}{
+					"content": "Per-athlete weekly volume and risk table, load warnings with triggered flags, and athletes missing sessions",
+				},
+			},
+		},
+	}
 }
 
 // GetAvailableTools returns all available tools
@@ -179,11 +179,20 @@ func TestToolRegistry_IntegrationWithAIService(t *testing.T) {
 	
 	// Test that all tools match the AI service implementation
 	expectedTools := map[string]bool{
-		"get-athlete-profile":     true,
-		"get-recent-activities":   true,
-		"get-activity-details":    true,
-		"get-activity-streams":    true,
-		"update-athlete-logbook":  true,
+		"get-athlete-profile":         true,
+		"get-recent-activities":       true,
+		"get-activity-details":        true,
+		"get-activity-streams":        true,
+		"update-athlete-logbook":      true,
+		"render-activity-chart":       true,
+		"compare-activities":          true,
+		"search-activities":           true,
+		"get-training-summary":        true,
+		"get-aerobic-trend":           true,
+		"analyze-activity-conditions": true,
+		"get-readiness":               true,
+		"get-injury-risk":             true,
+		"get-team-overview":           true,
 	}
 	
 	tools := registry.GetAvailableTools()
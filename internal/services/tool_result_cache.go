@@ -0,0 +1,224 @@
+package services
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"bodda/internal/models"
+)
+
+const (
+	// Activity details and streams are fetched for completed activities and rarely change
+	completedActivityCacheTTL = 24 * time.Hour
+	// Recent activities, searches and summaries change whenever the athlete uploads, so they are only reused briefly
+	recentActivitiesCacheTTL = 2 * time.Minute
+	// How often writes also delete expired rows from the persistent store
+	toolCachePruneInterval = time.Hour
+)
+
+// toolCacheTTL returns how long a tool's successful results may be reused.
+// Tools that change state, and tools without an explicit rule, are never cached.
+func toolCacheTTL(toolName string, defaultTTL time.Duration) (time.Duration, bool) {
+	switch toolName {
+	case "get-activity-details", "get-activity-streams", "render-activity-chart", "compare-activities", "analyze-activity-conditions":
+		return completedActivityCacheTTL, true
+	case "get-recent-activities", "search-activities", "get-training-summary", "get-aerobic-trend", "get-injury-risk":
+		if defaultTTL > 0 && defaultTTL < recentActivitiesCacheTTL {
+			return defaultTTL, true
+		}
+		return recentActivitiesCacheTTL, true
+	case "get-athlete-profile":
+		return defaultTTL, defaultTTL > 0
+	default:
+		// update-athlete-logbook and unknown tools
+		return 0, false
+	}
+}
+
+// toolCacheKey derives a cache key from the user, tool and parameters.
+// encoding/json sorts map keys, so equal parameter sets always produce the same key.
+func toolCacheKey(userID, toolName string, parameters map[string]interface{}) (string, error) {
+	canonical, err := json.Marshal(parameters)
+	if err != nil {
+		return "", fmt.Errorf("failed to canonicalize tool parameters: %w", err)
+	}
+
+	hash := sha256.New()
+	hash.Write([]byte(userID))
+	hash.Write([]byte{0})
+	hash.Write([]byte(toolName))
+	hash.Write([]byte{0})
+	hash.Write(canonical)
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// ToolResultStore persists cached tool results beyond the in-memory cache
+type ToolResultStore interface {
+	Get(ctx context.Context, key string) (*models.ToolCacheEntry, error)
+	Set(ctx context.Context, entry *models.ToolCacheEntry) error
+	DeleteExpired(ctx context.Context) (int64, error)
+}
+
+// ToolResultCache stores successful tool output for reuse
+type ToolResultCache interface {
+	Get(ctx context.Context, key string) (string, bool)
+	Set(ctx context.Context, key, userID, toolName, content string, ttl time.Duration)
+	Len() int
+}
+
+type toolCacheItem struct {
+	key       string
+	content   string
+	expiresAt time.Time
+}
+
+// lruToolResultCache is a size-bounded in-memory LRU, optionally backed by a persistent store
+type lruToolResultCache struct {
+	mu         sync.Mutex
+	maxEntries int
+	ll         *list.List
+	items      map[string]*list.Element
+	store      ToolResultStore
+	now        func() time.Time
+	lastPruned time.Time
+}
+
+// NewToolResultCache creates an LRU cache holding up to maxEntries results.
+// When store is non-nil, misses fall through to it and writes go to both.
+func NewToolResultCache(maxEntries int, store ToolResultStore) ToolResultCache {
+	if maxEntries <= 0 {
+		maxEntries = 1000
+	}
+
+	return &lruToolResultCache{
+		maxEntries: maxEntries,
+		ll:         list.New(),
+		items:      make(map[string]*list.Element),
+		store:      store,
+		now:        time.Now,
+	}
+}
+
+// Get returns the cached content for key if it has not expired
+func (c *lruToolResultCache) Get(ctx context.Context, key string) (string, bool) {
+	if content, ok := c.getMemory(key); ok {
+		return content, true
+	}
+
+	if c.store == nil {
+		return "", false
+	}
+
+	entry, err := c.store.Get(ctx, key)
+	if err != nil {
+		log.Printf("Failed to read tool result cache: %v", err)
+		return "", false
+	}
+	if entry == nil || !entry.ExpiresAt.After(c.now()) {
+		return "", false
+	}
+
+	c.setMemory(key, entry.Content, entry.ExpiresAt)
+	return entry.Content, true
+}
+
+// Set caches content for ttl
+func (c *lruToolResultCache) Set(ctx context.Context, key, userID, toolName, content string, ttl time.Duration) {
+	if ttl <= 0 {
+		return
+	}
+
+	expiresAt := c.now().Add(ttl)
+	c.setMemory(key, content, expiresAt)
+
+	if c.store == nil {
+		return
+	}
+
+	err := c.store.Set(ctx, &models.ToolCacheEntry{
+		Key:       key,
+		UserID:    userID,
+		ToolName:  toolName,
+		Content:   content,
+		ExpiresAt: expiresAt,
+	})
+	if err != nil {
+		log.Printf("Failed to persist tool result cache entry for %s: %v", toolName, err)
+	}
+
+	c.pruneStore(ctx)
+}
+
+// pruneStore deletes expired rows from the persistent store, at most once per prune interval, so
+// results that are never read again do not accumulate
+func (c *lruToolResultCache) pruneStore(ctx context.Context) {
+	c.mu.Lock()
+	now := c.now()
+	due := now.Sub(c.lastPruned) >= toolCachePruneInterval
+	if due {
+		c.lastPruned = now
+	}
+	c.mu.Unlock()
+	if !due {
+		return
+	}
+
+	if _, err := c.store.DeleteExpired(ctx); err != nil {
+		log.Printf("Failed to delete expired tool result cache entries: %v", err)
+	}
+}
+
+// Len returns the number of entries held in memory
+func (c *lruToolResultCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.ll.Len()
+}
+
+func (c *lruToolResultCache) getMemory(key string) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, ok := c.items[key]
+	if !ok {
+		return "", false
+	}
+
+	item := element.Value.(*toolCacheItem)
+	if !item.expiresAt.After(c.now()) {
+		c.ll.Remove(element)
+		delete(c.items, key)
+		return "", false
+	}
+
+	c.ll.MoveToFront(element)
+	return item.content, true
+}
+
+func (c *lruToolResultCache) setMemory(key, content string, expiresAt time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if element, ok := c.items[key]; ok {
+		item := element.Value.(*toolCacheItem)
+		item.content = content
+		item.expiresAt = expiresAt
+		c.ll.MoveToFront(element)
+		return
+	}
+
+	c.items[key] = c.ll.PushFront(&toolCacheItem{key: key, content: content, expiresAt: expiresAt})
+
+	for c.ll.Len() > c.maxEntries {
+		oldest := c.ll.Back()
+		c.ll.Remove(oldest)
+		delete(c.items, oldest.Value.(*toolCacheItem).key)
+	}
+}
@@ -0,0 +1,243 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"bodda/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeToolResultStore struct {
+	entries map[string]*models.ToolCacheEntry
+	getErr  error
+	prunes  int
+}
+
+func newFakeToolResultStore() *fakeToolResultStore {
+	return &fakeToolResultStore{entries: make(map[string]*models.ToolCacheEntry)}
+}
+
+func (f *fakeToolResultStore) Get(ctx context.Context, key string) (*models.ToolCacheEntry, error) {
+	if f.getErr != nil {
+		return nil, f.getErr
+	}
+	return f.entries[key], nil
+}
+
+func (f *fakeToolResultStore) Set(ctx context.Context, entry *models.ToolCacheEntry) error {
+	f.entries[entry.Key] = entry
+	return nil
+}
+
+func (f *fakeToolResultStore) DeleteExpired(ctx context.Context) (int64, error) {
+	f.prunes++
+	var deleted int64
+	for key, entry := range f.entries {
+		if !entry.ExpiresAt.After(time.Now()) {
+			delete(f.entries, key)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+// countingToolExecutionService counts how often each tool actually runs
+type countingToolExecutionService struct {
+	mockToolExecutionService
+	calls int32
+}
+
+func (c *countingToolExecutionService) ExecuteGetActivityDetails(ctx context.Context, msgCtx *MessageContext, activityID int64) (string, error) {
+	atomic.AddInt32(&c.calls, 1)
+	return c.mockToolExecutionService.ExecuteGetActivityDetails(ctx, msgCtx, activityID)
+}
+
+func (c *countingToolExecutionService) ExecuteUpdateAthleteLogbook(ctx context.Context, msgCtx *MessageContext, content string) (string, error) {
+	atomic.AddInt32(&c.calls, 1)
+	return c.mockToolExecutionService.ExecuteUpdateAthleteLogbook(ctx, msgCtx, content)
+}
+
+func TestToolCacheTTL(t *testing.T) {
+	ttl, ok := toolCacheTTL("update-athlete-logbook", 5*time.Minute)
+	assert.False(t, ok)
+	assert.Zero(t, ttl)
+
+	ttl, ok = toolCacheTTL("get-activity-details", 5*time.Minute)
+	assert.True(t, ok)
+	assert.Equal(t, 24*time.Hour, ttl)
+
+	ttl, ok = toolCacheTTL("get-recent-activities", 5*time.Minute)
+	assert.True(t, ok)
+	assert.Equal(t, 2*time.Minute, ttl)
+
+	ttl, ok = toolCacheTTL("get-recent-activities", 30*time.Second)
+	assert.True(t, ok)
+	assert.Equal(t, 30*time.Second, ttl, "a shorter configured TTL wins")
+
+	ttl, ok = toolCacheTTL("get-athlete-profile", 5*time.Minute)
+	assert.True(t, ok)
+	assert.Equal(t, 5*time.Minute, ttl)
+
+	_, ok = toolCacheTTL("unknown-tool", 5*time.Minute)
+	assert.False(t, ok)
+}
+
+func TestToolCacheKey(t *testing.T) {
+	a, err := toolCacheKey("user-1", "get-activity-details", map[string]interface{}{"activity_id": 1, "extra": "x"})
+	require.NoError(t, err)
+	b, err := toolCacheKey("user-1", "get-activity-details", map[string]interface{}{"extra": "x", "activity_id": 1})
+	require.NoError(t, err)
+	assert.Equal(t, a, b, "parameter order does not change the key")
+
+	other, err := toolCacheKey("user-2", "get-activity-details", map[string]interface{}{"activity_id": 1, "extra": "x"})
+	require.NoError(t, err)
+	assert.NotEqual(t, a, other, "keys are scoped to the user")
+
+	_, err = toolCacheKey("user-1", "get-activity-details", map[string]interface{}{"bad": make(chan int)})
+	assert.Error(t, err)
+}
+
+func TestToolResultCache_LRUEviction(t *testing.T) {
+	ctx := context.Background()
+	cache := NewToolResultCache(2, nil)
+
+	cache.Set(ctx, "a", "user-1", "get-activity-details", "A", time.Hour)
+	cache.Set(ctx, "b", "user-1", "get-activity-details", "B", time.Hour)
+
+	// Touch "a" so "b" becomes the least recently used entry
+	_, ok := cache.Get(ctx, "a")
+	require.True(t, ok)
+
+	cache.Set(ctx, "c", "user-1", "get-activity-details", "C", time.Hour)
+	assert.Equal(t, 2, cache.Len())
+
+	_, ok = cache.Get(ctx, "b")
+	assert.False(t, ok)
+	content, ok := cache.Get(ctx, "a")
+	assert.True(t, ok)
+	assert.Equal(t, "A", content)
+}
+
+func TestToolResultCache_Expiry(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2025, 3, 15, 10, 0, 0, 0, time.UTC)
+	cache := NewToolResultCache(10, nil).(*lruToolResultCache)
+	cache.now = func() time.Time { return now }
+
+	cache.Set(ctx, "a", "user-1", "get-recent-activities", "A", time.Minute)
+	_, ok := cache.Get(ctx, "a")
+	assert.True(t, ok)
+
+	now = now.Add(2 * time.Minute)
+	_, ok = cache.Get(ctx, "a")
+	assert.False(t, ok)
+	assert.Equal(t, 0, cache.Len())
+}
+
+func TestToolResultCache_PersistentStore(t *testing.T) {
+	ctx := context.Background()
+	store := newFakeToolResultStore()
+
+	first := NewToolResultCache(10, store)
+	first.Set(ctx, "a", "user-1", "get-activity-details", "A", time.Hour)
+	require.Contains(t, store.entries, "a")
+	assert.Equal(t, "user-1", store.entries["a"].UserID)
+
+	// A fresh cache, e.g. after a restart, falls back to the store and warms memory
+	second := NewToolResultCache(10, store)
+	content, ok := second.Get(ctx, "a")
+	assert.True(t, ok)
+	assert.Equal(t, "A", content)
+	assert.Equal(t, 1, second.Len())
+
+	// Expired rows are ignored
+	store.entries["old"] = &models.ToolCacheEntry{Key: "old", Content: "old", ExpiresAt: time.Now().Add(-time.Minute)}
+	_, ok = second.Get(ctx, "old")
+	assert.False(t, ok)
+
+	// Store errors are treated as misses
+	store.getErr = errors.New("connection refused")
+	_, ok = NewToolResultCache(10, store).Get(ctx, "a")
+	assert.False(t, ok)
+}
+
+func TestToolResultCache_PrunesStore(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	store := newFakeToolResultStore()
+	store.entries["old"] = &models.ToolCacheEntry{Key: "old", Content: "old", ExpiresAt: now.Add(-time.Minute)}
+
+	cache := NewToolResultCache(10, store).(*lruToolResultCache)
+	cache.now = func() time.Time { return now }
+
+	cache.Set(ctx, "a", "user-1", "get-activity-details", "A", time.Hour)
+	assert.Equal(t, 1, store.prunes)
+	assert.NotContains(t, store.entries, "old", "expired rows are deleted on write")
+
+	// Later writes within the prune interval leave the store alone
+	cache.Set(ctx, "b", "user-1", "get-activity-details", "B", time.Hour)
+	assert.Equal(t, 1, store.prunes)
+
+	now = now.Add(toolCachePruneInterval)
+	cache.Set(ctx, "c", "user-1", "get-activity-details", "C", time.Hour)
+	assert.Equal(t, 2, store.prunes)
+}
+
+func TestToolExecutor_ResultCache(t *testing.T) {
+	ctx := context.Background()
+	msgCtx := &MessageContext{UserID: "user-1", SessionID: "session-1"}
+
+	t.Run("repeated calls are served from the cache", func(t *testing.T) {
+		service := &countingToolExecutionService{mockToolExecutionService: mockToolExecutionService{response: "activity 42"}}
+		cache := NewToolResultCache(10, nil)
+		executor := NewToolExecutor(service, NewToolRegistry(), WithToolResultCache(cache, 5*time.Minute))
+
+		params := map[string]interface{}{"activity_id": float64(42)}
+		for i := 0; i < 3; i++ {
+			result, err := executor.ExecuteTool(ctx, "get-activity-details", params, msgCtx)
+			require.NoError(t, err)
+			assert.True(t, result.Success)
+			assert.Equal(t, "activity 42", result.Data)
+		}
+		assert.Equal(t, int32(1), atomic.LoadInt32(&service.calls))
+
+		// A different user does not share the cached result
+		_, err := executor.ExecuteTool(ctx, "get-activity-details", params, &MessageContext{UserID: "user-2"})
+		require.NoError(t, err)
+		assert.Equal(t, int32(2), atomic.LoadInt32(&service.calls))
+	})
+
+	t.Run("failed results are not cached", func(t *testing.T) {
+		service := &countingToolExecutionService{mockToolExecutionService: mockToolExecutionService{shouldError: true}}
+		cache := NewToolResultCache(10, nil)
+		executor := NewToolExecutor(service, NewToolRegistry(), WithToolResultCache(cache, 5*time.Minute))
+
+		params := map[string]interface{}{"activity_id": float64(42)}
+		for i := 0; i < 2; i++ {
+			result, _ := executor.ExecuteTool(ctx, "get-activity-details", params, msgCtx)
+			assert.False(t, result.Success)
+		}
+		assert.Equal(t, int32(2), atomic.LoadInt32(&service.calls))
+		assert.Equal(t, 0, cache.Len())
+	})
+
+	t.Run("logbook updates always execute", func(t *testing.T) {
+		service := &countingToolExecutionService{mockToolExecutionService: mockToolExecutionService{response: "updated"}}
+		cache := NewToolResultCache(10, nil)
+		executor := NewToolExecutor(service, NewToolRegistry(), WithToolResultCache(cache, 5*time.Minute))
+
+		params := map[string]interface{}{"content": "Ran 10k"}
+		for i := 0; i < 2; i++ {
+			_, err := executor.ExecuteTool(ctx, "update-athlete-logbook", params, msgCtx)
+			require.NoError(t, err)
+		}
+		assert.Equal(t, int32(2), atomic.LoadInt32(&service.calls))
+		assert.Equal(t, 0, cache.Len())
+	})
+}
@@ -0,0 +1,397 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"bodda/internal/models"
+)
+
+// Aggregation periods for get-training-summary
+const (
+	SummaryPeriodWeek  = "week"
+	SummaryPeriodMonth = "month"
+)
+
+const (
+	defaultSummaryWeeks  = 12
+	maxSummaryWeeks      = 52
+	defaultSummaryMonths = 6
+	maxSummaryMonths     = 24
+)
+
+// TrainingSummaryRequest selects the aggregation period, how many periods to cover and which sports to include
+type TrainingSummaryRequest struct {
+	Period     string   `json:"period"`
+	Periods    int      `json:"periods"`
+	SportTypes []string `json:"sport_types"`
+}
+
+// normalize applies defaults and validates the period
+func (r *TrainingSummaryRequest) normalize() error {
+	r.Period = strings.ToLower(strings.TrimSpace(r.Period))
+	if r.Period == "" {
+		r.Period = SummaryPeriodWeek
+	}
+
+	var defaultPeriods, maxPeriods int
+	switch r.Period {
+	case SummaryPeriodWeek:
+		defaultPeriods, maxPeriods = defaultSummaryWeeks, maxSummaryWeeks
+	case SummaryPeriodMonth:
+		defaultPeriods, maxPeriods = defaultSummaryMonths, maxSummaryMonths
+	default:
+		return fmt.Errorf("unsupported period '%s', expected week or month", r.Period)
+	}
+
+	if r.Periods <= 0 {
+		r.Periods = defaultPeriods
+	}
+	if r.Periods > maxPeriods {
+		r.Periods = maxPeriods
+	}
+	return nil
+}
+
+// periodStart returns the start of the week (Monday) or month containing t
+func periodStart(t time.Time, period string) time.Time {
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	if period == SummaryPeriodMonth {
+		return day.AddDate(0, 0, 1-day.Day())
+	}
+	offset := (int(day.Weekday()) + 6) % 7
+	return day.AddDate(0, 0, -offset)
+}
+
+// nextPeriodStart returns the start of the period following start
+func nextPeriodStart(start time.Time, period string) time.Time {
+	if period == SummaryPeriodMonth {
+		return start.AddDate(0, 1, 0)
+	}
+	return start.AddDate(0, 0, 7)
+}
+
+// SportTotals aggregates the sessions of one sport within a period
+type SportTotals struct {
+	SportType  string  `json:"sport_type"`
+	Count      int     `json:"count"`
+	DistanceKm float64 `json:"distance_km"`
+	MovingTime int     `json:"moving_time"`
+	Elevation  float64 `json:"elevation"`
+}
+
+// SessionSummary identifies a single activity
+type SessionSummary struct {
+	ActivityID int64   `json:"activity_id"`
+	Name       string  `json:"name"`
+	SportType  string  `json:"sport_type"`
+	Date       string  `json:"date"`
+	DistanceKm float64 `json:"distance_km"`
+	MovingTime int     `json:"moving_time"`
+}
+
+// TrainingPeriod holds the totals for one week or month
+type TrainingPeriod struct {
+	Start      time.Time     `json:"start"`
+	Count      int           `json:"count"`
+	DistanceKm float64       `json:"distance_km"`
+	MovingTime int           `json:"moving_time"`
+	Elevation  float64       `json:"elevation"`
+	BySport    []SportTotals `json:"by_sport"`
+	// ZoneTime is moving time per heart rate zone, attributed by each session's average heart rate
+	ZoneTime []int `json:"zone_time,omitempty"`
+	// NoHeartRateTime is moving time of sessions without heart rate data
+	NoHeartRateTime int             `json:"no_heart_rate_time"`
+	Longest         *SessionSummary `json:"longest,omitempty"`
+	// RampRate is the percentage change in moving time from the previous period, nil when there is no baseline
+	RampRate *float64 `json:"ramp_rate,omitempty"`
+}
+
+// TrainingSummary is the result of get-training-summary, with periods ordered oldest first
+type TrainingSummary struct {
+	Period     string           `json:"period"`
+	SportTypes []string         `json:"sport_types,omitempty"`
+	Periods    []TrainingPeriod `json:"periods"`
+	ZoneCount  int              `json:"zone_count"`
+	// Complete is false when the activity history was too long to read in full
+	Complete bool `json:"complete"`
+}
+
+// activityStartLocal returns the local start time of an activity, falling back to the UTC start
+func activityStartLocal(activity *StravaActivity) (time.Time, bool) {
+	for _, value := range []string{activity.StartDateLocal, activity.StartDate} {
+		if parsed, err := time.Parse(time.RFC3339, value); err == nil {
+			return parsed, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// heartRateZoneIndex returns the zone containing an average heart rate, or -1 when it cannot be placed
+func heartRateZoneIndex(zones []StravaZone, heartRate float64) int {
+	if heartRate <= 0 {
+		return -1
+	}
+	for i, zone := range zones {
+		if heartRate >= float64(zone.Min) && (zone.Max <= 0 || heartRate < float64(zone.Max)) {
+			return i
+		}
+	}
+	return -1
+}
+
+// BuildTrainingSummary aggregates activities into count periods ending with the period containing now.
+// hrZones may be nil, in which case no intensity distribution is computed.
+func BuildTrainingSummary(activities []*StravaActivity, hrZones []StravaZone, period string, count int, sportTypes []string, now time.Time) *TrainingSummary {
+	summary := &TrainingSummary{
+		Period:     period,
+		SportTypes: sportTypes,
+		Periods:    make([]TrainingPeriod, count),
+		ZoneCount:  len(hrZones),
+		Complete:   true,
+	}
+
+	start := periodStart(now, period)
+	for i := count - 1; i >= 0; i-- {
+		summary.Periods[i].Start = start
+		start = periodStart(start.AddDate(0, 0, -1), period)
+	}
+	first := summary.Periods[0].Start
+	end := nextPeriodStart(summary.Periods[count-1].Start, period)
+
+	filter := ActivitySearchRequest{SportTypes: sportTypes}
+	if err := filter.normalize(); err != nil {
+		return summary
+	}
+
+	sportIndex := make([]map[string]int, count)
+	for _, activity := range activities {
+		if activity == nil || !filter.Matches(activity) {
+			continue
+		}
+		startedAt, ok := activityStartLocal(activity)
+		if !ok || startedAt.Before(first) || !startedAt.Before(end) {
+			continue
+		}
+
+		index := sort.Search(count, func(i int) bool {
+			return summary.Periods[i].Start.After(startedAt)
+		}) - 1
+		p := &summary.Periods[index]
+
+		distanceKm := activity.Distance / 1000
+		p.Count++
+		p.DistanceKm += distanceKm
+		p.MovingTime += activity.MovingTime
+		p.Elevation += activity.TotalElevationGain
+
+		sportType := activity.SportType
+		if sportType == "" {
+			sportType = activity.Type
+		}
+		if sportIndex[index] == nil {
+			sportIndex[index] = make(map[string]int)
+		}
+		i, exists := sportIndex[index][sportType]
+		if !exists {
+			i = len(p.BySport)
+			sportIndex[index][sportType] = i
+			p.BySport = append(p.BySport, SportTotals{SportType: sportType})
+		}
+		p.BySport[i].Count++
+		p.BySport[i].DistanceKm += distanceKm
+		p.BySport[i].MovingTime += activity.MovingTime
+		p.BySport[i].Elevation += activity.TotalElevationGain
+
+		if len(hrZones) > 0 {
+			if p.ZoneTime == nil {
+				p.ZoneTime = make([]int, len(hrZones))
+			}
+			if zone := heartRateZoneIndex(hrZones, activity.AverageHeartrate); zone >= 0 {
+				p.ZoneTime[zone] += activity.MovingTime
+			} else {
+				p.NoHeartRateTime += activity.MovingTime
+			}
+		}
+
+		if p.Longest == nil || activity.MovingTime > p.Longest.MovingTime {
+			p.Longest = &SessionSummary{
+				ActivityID: activity.ID,
+				Name:       activity.Name,
+				SportType:  sportType,
+				Date:       startedAt.Format("2006-01-02"),
+				DistanceKm: roundTo(distanceKm, 2),
+				MovingTime: activity.MovingTime,
+			}
+		}
+	}
+
+	for i := range summary.Periods {
+		p := &summary.Periods[i]
+		p.DistanceKm = roundTo(p.DistanceKm, 2)
+		p.Elevation = roundTo(p.Elevation, 0)
+		for j := range p.BySport {
+			p.BySport[j].DistanceKm = roundTo(p.BySport[j].DistanceKm, 2)
+			p.BySport[j].Elevation = roundTo(p.BySport[j].Elevation, 0)
+		}
+		sort.SliceStable(p.BySport, func(a, b int) bool {
+			return p.BySport[a].MovingTime > p.BySport[b].MovingTime
+		})
+
+		if i > 0 && summary.Periods[i-1].MovingTime > 0 {
+			previous := float64(summary.Periods[i-1].MovingTime)
+			ramp := roundTo((float64(p.MovingTime)-previous)/previous*100, 1)
+			p.RampRate = &ramp
+		}
+	}
+
+	return summary
+}
+
+// periodLabel formats the start of a period for display
+func periodLabel(start time.Time, period string) string {
+	if period == SummaryPeriodMonth {
+		return start.Format("Jan 2006")
+	}
+	return "Week of " + start.Format("Jan 2, 2006")
+}
+
+// formatTrainingSummary renders the summary as markdown tables
+func formatTrainingSummary(summary *TrainingSummary) string {
+	var builder strings.Builder
+
+	title := "Weekly"
+	if summary.Period == SummaryPeriodMonth {
+		title = "Monthly"
+	}
+	builder.WriteString(fmt.Sprintf("# %s Training Summary\n\n", title))
+	if len(summary.SportTypes) > 0 {
+		builder.WriteString(fmt.Sprintf("Sports: %s\n\n", strings.Join(summary.SportTypes, ", ")))
+	}
+	if !summary.Complete {
+		builder.WriteString("⚠️ The activity history was too long to read in full; the oldest periods may be undercounted.\n\n")
+	}
+
+	builder.WriteString("| Period | Sessions | Distance | Moving Time | Elevation | Ramp |")
+	if summary.ZoneCount > 0 {
+		builder.WriteString(" Intensity (Z1…Z" + fmt.Sprint(summary.ZoneCount) + ") |")
+	}
+	builder.WriteString("\n|---|---|---|---|---|---|")
+	if summary.ZoneCount > 0 {
+		builder.WriteString("---|")
+	}
+	builder.WriteString("\n")
+
+	for _, p := range summary.Periods {
+		ramp := "–"
+		if p.RampRate != nil {
+			ramp = fmt.Sprintf("%+.0f%%", *p.RampRate)
+		}
+		builder.WriteString(fmt.Sprintf("| %s | %d | %.1f km | %s | %.0f m | %s |",
+			periodLabel(p.Start, summary.Period), p.Count, p.DistanceKm, formatTrainingTime(p.MovingTime), p.Elevation, ramp))
+		if summary.ZoneCount > 0 {
+			builder.WriteString(" " + formatZoneShare(p) + " |")
+		}
+		builder.WriteString("\n")
+	}
+
+	builder.WriteString("\n## By Sport\n\n| Period | Sport | Sessions | Distance | Moving Time | Elevation |\n|---|---|---|---|---|---|\n")
+	for _, p := range summary.Periods {
+		for _, sport := range p.BySport {
+			builder.WriteString(fmt.Sprintf("| %s | %s | %d | %.1f km | %s | %.0f m |\n",
+				periodLabel(p.Start, summary.Period), sport.SportType, sport.Count, sport.DistanceKm, formatTrainingTime(sport.MovingTime), sport.Elevation))
+		}
+	}
+
+	builder.WriteString("\n## Longest Sessions\n\n")
+	for _, p := range summary.Periods {
+		if p.Longest == nil {
+			continue
+		}
+		builder.WriteString(fmt.Sprintf("- %s: [%s](https://www.strava.com/activities/%d) (%s, %s) — %.1f km in %s\n",
+			periodLabel(p.Start, summary.Period), p.Longest.Name, p.Longest.ActivityID, p.Longest.SportType, p.Longest.Date, p.Longest.DistanceKm, formatTrainingTime(p.Longest.MovingTime)))
+	}
+
+	if summary.ZoneCount > 0 {
+		builder.WriteString("\nIntensity shares attribute each session's moving time to the heart rate zone of its average heart rate; sessions without heart rate are excluded.\n")
+	}
+	builder.WriteString("Ramp is the change in moving time from the previous period; the latest period is still in progress.\n")
+
+	return builder.String()
+}
+
+// formatTrainingTime renders a training volume as hours and minutes, e.g. "7h 05m"
+func formatTrainingTime(seconds int) string {
+	return fmt.Sprintf("%dh %02dm", seconds/3600, (seconds%3600)/60)
+}
+
+// formatZoneShare renders the percentage of heart rate tracked time in each zone, e.g. "60/25/10/5/0"
+func formatZoneShare(p TrainingPeriod) string {
+	total := 0
+	for _, seconds := range p.ZoneTime {
+		total += seconds
+	}
+	if total == 0 {
+		return "–"
+	}
+	shares := make([]string, len(p.ZoneTime))
+	for i, seconds := range p.ZoneTime {
+		shares[i] = fmt.Sprintf("%.0f", float64(seconds)/float64(total)*100)
+	}
+	return strings.Join(shares, "/") + " %"
+}
+
+func (s *aiService) executeGetTrainingSummary(ctx context.Context, msgCtx *MessageContext, req TrainingSummaryRequest) (string, error) {
+	if msgCtx == nil || msgCtx.User == nil {
+		return "", fmt.Errorf("user context is required")
+	}
+	if err := req.normalize(); err != nil {
+		return "", err
+	}
+
+	summary, err := GetTrainingSummary(ctx, s.stravaService, msgCtx.User, req, time.Now())
+	if err != nil {
+		if errors.Is(err, context.Canceled) {
+			return "", err
+		}
+		return "", s.handleStravaError(err, "activities")
+	}
+	return formatTrainingSummary(summary), nil
+}
+
+// GetTrainingSummary fetches the activities covering the requested periods and aggregates them.
+// Heart rate zones are optional; a failure to load them only drops the intensity distribution.
+func GetTrainingSummary(ctx context.Context, stravaService StravaService, user *models.User, req TrainingSummaryRequest, now time.Time) (*TrainingSummary, error) {
+	if err := req.normalize(); err != nil {
+		return nil, err
+	}
+
+	start := periodStart(now, req.Period)
+	for i := 1; i < req.Periods; i++ {
+		start = periodStart(start.AddDate(0, 0, -1), req.Period)
+	}
+	// Strava filters on UTC start times while periods use local dates, so fetch a day early
+	after := start.AddDate(0, 0, -1)
+
+	var activities []*StravaActivity
+	_, _, complete, err := scanActivities(ctx, stravaService, user, &after, nil, func(page []*StravaActivity) bool {
+		activities = append(activities, page...)
+		return true
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get activities for training summary: %w", err)
+	}
+
+	var hrZones []StravaZone
+	if zones, err := stravaService.GetAthleteZones(ctx, user); err == nil && zones != nil && zones.HeartRate != nil {
+		hrZones = zones.HeartRate.Zones
+	}
+
+	summary := BuildTrainingSummary(activities, hrZones, req.Period, req.Periods, req.SportTypes, now)
+	summary.Complete = complete
+	return summary, nil
+}
@@ -0,0 +1,153 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"bodda/internal/config"
+	"bodda/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// zonedStravaService adds athlete heart rate zones to a paged activity history
+type zonedStravaService struct {
+	pagedStravaService
+}
+
+func (m *zonedStravaService) GetAthleteZones(ctx context.Context, user *models.User) (*StravaAthleteZones, error) {
+	return &StravaAthleteZones{HeartRate: &StravaZoneSet{Zones: testHeartRateZones}}, nil
+}
+
+var testHeartRateZones = []StravaZone{{Min: 0, Max: 130}, {Min: 130, Max: 150}, {Min: 150, Max: 165}, {Min: 165, Max: 175}, {Min: 175, Max: -1}}
+
+func summaryActivity(id int64, date string, sportType string, km float64, minutes int, heartRate float64) *StravaActivity {
+	return &StravaActivity{
+		ID:                 id,
+		Name:               sportType + " session",
+		Type:               sportType,
+		SportType:          sportType,
+		Distance:           km * 1000,
+		MovingTime:         minutes * 60,
+		TotalElevationGain: 50,
+		AverageHeartrate:   heartRate,
+		StartDate:          date + "T07:00:00Z",
+		StartDateLocal:     date + "T08:00:00Z",
+	}
+}
+
+func TestPeriodStart(t *testing.T) {
+	sunday := time.Date(2025, 3, 16, 21, 0, 0, 0, time.UTC)
+	assert.Equal(t, time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC), periodStart(sunday, SummaryPeriodWeek), "weeks start on Monday")
+	assert.Equal(t, time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC), periodStart(sunday, SummaryPeriodMonth))
+}
+
+func TestTrainingSummaryRequest_Normalize(t *testing.T) {
+	req := TrainingSummaryRequest{}
+	require.NoError(t, req.normalize())
+	assert.Equal(t, SummaryPeriodWeek, req.Period)
+	assert.Equal(t, defaultSummaryWeeks, req.Periods)
+
+	req = TrainingSummaryRequest{Period: "Month", Periods: 100}
+	require.NoError(t, req.normalize())
+	assert.Equal(t, maxSummaryMonths, req.Periods)
+
+	assert.Error(t, (&TrainingSummaryRequest{Period: "year"}).normalize())
+}
+
+func TestBuildTrainingSummary_Weekly(t *testing.T) {
+	now := time.Date(2025, 3, 19, 12, 0, 0, 0, time.UTC) // Wednesday
+	activities := []*StravaActivity{
+		summaryActivity(1, "2025-03-03", "Run", 10, 60, 140),
+		summaryActivity(2, "2025-03-05", "Run", 8, 45, 160),
+		summaryActivity(3, "2025-03-09", "Ride", 40, 90, 0),
+		summaryActivity(4, "2025-03-11", "Run", 21, 120, 145),
+		summaryActivity(5, "2025-03-18", "Run", 5, 30, 150),
+		summaryActivity(6, "2025-02-20", "Run", 12, 70, 140), // before the first period
+	}
+
+	summary := BuildTrainingSummary(activities, testHeartRateZones, SummaryPeriodWeek, 3, nil, now)
+	require.Len(t, summary.Periods, 3)
+
+	first, second, current := summary.Periods[0], summary.Periods[1], summary.Periods[2]
+	assert.Equal(t, time.Date(2025, 3, 3, 0, 0, 0, 0, time.UTC), first.Start)
+	assert.Equal(t, 3, first.Count)
+	assert.Equal(t, 58.0, first.DistanceKm)
+	assert.Equal(t, 195*60, first.MovingTime)
+	require.Len(t, first.BySport, 2)
+	assert.Equal(t, "Run", first.BySport[0].SportType, "sports are ordered by moving time")
+	assert.Equal(t, 2, first.BySport[0].Count)
+	assert.Equal(t, []int{0, 3600, 2700, 0, 0}, first.ZoneTime)
+	assert.Equal(t, 90*60, first.NoHeartRateTime)
+	assert.Equal(t, int64(3), first.Longest.ActivityID)
+	assert.Nil(t, first.RampRate)
+
+	require.NotNil(t, second.RampRate)
+	assert.InDelta(t, -38.5, *second.RampRate, 0.05)
+	require.NotNil(t, current.RampRate)
+	assert.InDelta(t, -75.0, *current.RampRate, 0.05)
+
+	runsOnly := BuildTrainingSummary(activities, nil, SummaryPeriodWeek, 3, []string{"Run"}, now)
+	assert.Equal(t, 2, runsOnly.Periods[0].Count)
+	assert.Nil(t, runsOnly.Periods[0].ZoneTime, "no zones means no intensity distribution")
+}
+
+func TestBuildTrainingSummary_Monthly(t *testing.T) {
+	now := time.Date(2025, 3, 19, 12, 0, 0, 0, time.UTC)
+	activities := []*StravaActivity{
+		summaryActivity(1, "2025-01-31", "Run", 10, 60, 0),
+		summaryActivity(2, "2025-02-01", "Run", 10, 60, 0),
+		summaryActivity(3, "2025-03-01", "Run", 10, 60, 0),
+	}
+
+	summary := BuildTrainingSummary(activities, nil, SummaryPeriodMonth, 3, nil, now)
+	require.Len(t, summary.Periods, 3)
+	assert.Equal(t, time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), summary.Periods[0].Start)
+	for _, period := range summary.Periods {
+		assert.Equal(t, 1, period.Count)
+	}
+	assert.Equal(t, 0.0, *summary.Periods[2].RampRate)
+}
+
+func TestFormatTrainingSummary(t *testing.T) {
+	now := time.Date(2025, 3, 19, 12, 0, 0, 0, time.UTC)
+	summary := BuildTrainingSummary([]*StravaActivity{
+		summaryActivity(1, "2025-03-11", "Run", 21, 125, 145),
+	}, testHeartRateZones, SummaryPeriodWeek, 2, []string{"Run"}, now)
+
+	output := formatTrainingSummary(summary)
+	assert.Contains(t, output, "# Weekly Training Summary")
+	assert.Contains(t, output, "| Week of Mar 10, 2025 | 1 | 21.0 km | 2h 05m | 50 m | – | 0/100/0/0/0 % |")
+	assert.Contains(t, output, "| Week of Mar 17, 2025 | 0 | 0.0 km | 0h 00m | 0 m | -100% | – |")
+	assert.Contains(t, output, "[Run session](https://www.strava.com/activities/1)")
+}
+
+func TestGetTrainingSummaryTool(t *testing.T) {
+	var history []*StravaActivity
+	for i := 0; i < 20; i++ {
+		date := time.Now().AddDate(0, 0, -i*3).Format("2006-01-02")
+		history = append(history, summaryActivity(int64(i+1), date, "Run", 10, 50, 140))
+	}
+	strava := &zonedStravaService{pagedStravaService{activities: history}}
+
+	aiService := NewAIService(&config.Config{OpenAIAPIKey: "test-key"}, strava, &mockLogbookServiceForToolExecutor{}, &MockSessionRepositoryForToolExecutor{}, NewToolRegistry())
+	executor := NewToolExecutor(aiService, NewToolRegistry())
+
+	msgCtx := &MessageContext{
+		UserID: "test-user",
+		User:   &models.User{ID: "test-user", AccessToken: "test-token"},
+	}
+
+	result, err := executor.ExecuteTool(context.Background(), "get-training-summary", map[string]interface{}{
+		"period":  "month",
+		"periods": float64(3),
+	}, msgCtx)
+	require.NoError(t, err)
+	require.True(t, result.Success, result.Error)
+	assert.Contains(t, result.Data, "# Monthly Training Summary")
+	assert.Contains(t, result.Data, "Intensity (Z1…Z5)")
+	require.NotEmpty(t, strava.requests)
+	assert.NotNil(t, strava.requests[0].After)
+}
@@ -44,7 +44,7 @@ func NewUnifiedStreamProcessor(
 }
 
 // ProcessPaginatedStreamRequest processes a paginated stream request with the specified mode
-func (usp *UnifiedStreamProcessor) ProcessPaginatedStreamRequest(user *models.User, req *PaginatedStreamRequest, currentContextTokens int) (*StreamPage, error) {
+func (usp *UnifiedStreamProcessor) ProcessPaginatedStreamRequest(ctx context.Context, user *models.User, req *PaginatedStreamRequest, currentContextTokens int) (*StreamPage, error) {
 	// Start performance monitoring
 	timer := usp.performanceMonitor.StartOperation(context.Background(), "paginated_stream_request", req.PageSize)
 	defer func() {
@@ -65,7 +65,7 @@ func (usp *UnifiedStreamProcessor) ProcessPaginatedStreamRequest(user *models.Us
 	
 	// Handle negative page size (full dataset request)
 	if req.PageSize < 0 {
-		return usp.processFullDatasetRequest(user, req, currentContextTokens)
+		return usp.processFullDatasetRequest(ctx, user, req, currentContextTokens)
 	}
 	
 	// Calculate optimal page size if not specified or if current page size is too large
@@ -75,7 +75,7 @@ func (usp *UnifiedStreamProcessor) ProcessPaginatedStreamRequest(user *models.Us
 	}
 	
 	// Estimate total pages
-	totalPages, err := usp.paginationCalculator.EstimateTotalPages(user, req.ActivityID, req.StreamTypes, req.Resolution, req.PageSize)
+	totalPages, err := usp.paginationCalculator.EstimateTotalPages(ctx, user, req.ActivityID, req.StreamTypes, req.Resolution, req.PageSize)
 	if err != nil {
 		// Create detailed error for pagination failure
 		streamErr := NewStreamProcessingError("pagination_failure", "Failed to estimate total pages", req.ActivityID, req.ProcessingMode).
@@ -99,7 +99,7 @@ func (usp *UnifiedStreamProcessor) ProcessPaginatedStreamRequest(user *models.Us
 	}
 	
 	// Request the specific data chunk
-	streamData, err := usp.paginationCalculator.RequestSpecificDataChunk(user, req)
+	streamData, err := usp.paginationCalculator.RequestSpecificDataChunk(ctx, user, req)
 	if err != nil {
 		// Create detailed error for Strava API failure
 		streamErr := NewStreamProcessingError("strava_api_failure", "Failed to retrieve stream data from Strava API", req.ActivityID, req.ProcessingMode).
@@ -112,7 +112,7 @@ func (usp *UnifiedStreamProcessor) ProcessPaginatedStreamRequest(user *models.Us
 	}
 	
 	// Apply processing mode to the paginated data
-	processedData, err := usp.applyProcessingModeWithFallback(user, req, streamData)
+	processedData, err := usp.applyProcessingModeWithFallback(ctx, user, req, streamData)
 	if err != nil {
 		// Update timer with error before creating fallback
 		timer.EndOperation(err)
@@ -144,11 +144,11 @@ func (usp *UnifiedStreamProcessor) ProcessPaginatedStreamRequest(user *models.Us
 }
 
 // processFullDatasetRequest handles requests for the full dataset (negative page size)
-func (usp *UnifiedStreamProcessor) processFullDatasetRequest(user *models.User, req *PaginatedStreamRequest, currentContextTokens int) (*StreamPage, error) {
+func (usp *UnifiedStreamProcessor) processFullDatasetRequest(ctx context.Context, user *models.User, req *PaginatedStreamRequest, currentContextTokens int) (*StreamPage, error) {
 	log.Printf("Processing full dataset request for activity %d", req.ActivityID)
 	
 	// Get the full stream data
-	streamData, err := usp.stravaService.GetActivityStreams(user, req.ActivityID, req.StreamTypes, req.Resolution)
+	streamData, err := usp.stravaService.GetActivityStreams(ctx, user, req.ActivityID, req.StreamTypes, req.Resolution)
 	if err != nil {
 		// Create detailed error for Strava API failure
 		streamErr := NewStreamProcessingError("strava_api_failure", "Failed to retrieve full stream data from Strava API", req.ActivityID, req.ProcessingMode).
@@ -178,7 +178,7 @@ func (usp *UnifiedStreamProcessor) processFullDatasetRequest(user *models.User,
 	}
 	
 	// Apply processing mode to the full dataset
-	processedData, err := usp.applyProcessingModeWithFallback(user, req, streamData)
+	processedData, err := usp.applyProcessingModeWithFallback(ctx, user, req, streamData)
 	if err != nil {
 		// If processing fails, create fallback result
 		log.Printf("Processing mode %s failed for full dataset activity %d, creating fallback", req.ProcessingMode, req.ActivityID)
@@ -207,7 +207,7 @@ func (usp *UnifiedStreamProcessor) processFullDatasetRequest(user *models.User,
 }
 
 // applyProcessingMode applies the specified processing mode to the stream data
-func (usp *UnifiedStreamProcessor) applyProcessingMode(user *models.User, req *PaginatedStreamRequest, streamData *StravaStreams) (interface{}, error) {
+func (usp *UnifiedStreamProcessor) applyProcessingMode(ctx context.Context, user *models.User, req *PaginatedStreamRequest, streamData *StravaStreams) (interface{}, error) {
 	switch req.ProcessingMode {
 	case "raw":
 		// Return formatted raw stream data
@@ -216,7 +216,7 @@ func (usp *UnifiedStreamProcessor) applyProcessingMode(user *models.User, req *P
 	case "derived":
 		// Get lap data if available for enhanced analysis
 		var laps []StravaLap
-		if activityDetail, err := usp.stravaService.GetActivityDetail(user, req.ActivityID); err == nil {
+		if activityDetail, err := usp.stravaService.GetActivityDetail(ctx, user, req.ActivityID); err == nil {
 			laps = activityDetail.Laps
 		}
 		
@@ -435,9 +435,9 @@ func (usp *UnifiedStreamProcessor) FormatPaginatedResult(page *StreamPage) strin
 }
 
 // applyProcessingModeWithFallback applies processing mode with automatic fallback on failure
-func (usp *UnifiedStreamProcessor) applyProcessingModeWithFallback(user *models.User, req *PaginatedStreamRequest, streamData *StravaStreams) (interface{}, error) {
+func (usp *UnifiedStreamProcessor) applyProcessingModeWithFallback(ctx context.Context, user *models.User, req *PaginatedStreamRequest, streamData *StravaStreams) (interface{}, error) {
 	// Try the requested processing mode first
-	result, err := usp.applyProcessingMode(user, req, streamData)
+	result, err := usp.applyProcessingMode(ctx, user, req, streamData)
 	if err == nil {
 		return result, nil
 	}
@@ -459,7 +459,7 @@ func (usp *UnifiedStreamProcessor) applyProcessingModeWithFallback(user *models.
 			fallbackReq.SummaryPrompt = ""
 		}
 		
-		result, fallbackErr := usp.applyProcessingMode(user, &fallbackReq, streamData)
+		result, fallbackErr := usp.applyProcessingMode(ctx, user, &fallbackReq, streamData)
 		if fallbackErr == nil {
 			log.Printf("Successfully fell back to %s mode for activity %d", fallbackMode, req.ActivityID)
 			
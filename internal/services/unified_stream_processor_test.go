@@ -11,19 +11,19 @@ import (
 
 type mockUnifiedStravaService struct{}
 
-func (m *mockUnifiedStravaService) GetAthleteProfile(user *models.User) (*StravaAthleteWithZones, error) {
+func (m *mockUnifiedStravaService) GetAthleteProfile(ctx context.Context, user *models.User) (*StravaAthleteWithZones, error) {
 	return nil, nil
 }
 
-func (m *mockUnifiedStravaService) GetAthleteZones(user *models.User) (*StravaAthleteZones, error) {
+func (m *mockUnifiedStravaService) GetAthleteZones(ctx context.Context, user *models.User) (*StravaAthleteZones, error) {
 	return nil, nil
 }
 
-func (m *mockUnifiedStravaService) GetActivities(user *models.User, params ActivityParams) ([]*StravaActivity, error) {
+func (m *mockUnifiedStravaService) GetActivities(ctx context.Context, user *models.User, params ActivityParams) ([]*StravaActivity, error) {
 	return nil, nil
 }
 
-func (m *mockUnifiedStravaService) GetActivityDetail(user *models.User, activityID int64) (*StravaActivityDetail, error) {
+func (m *mockUnifiedStravaService) GetActivityDetail(ctx context.Context, user *models.User, activityID int64) (*StravaActivityDetail, error) {
 	return &StravaActivityDetail{
 		Laps: []StravaLap{
 			{
@@ -37,7 +37,7 @@ func (m *mockUnifiedStravaService) GetActivityDetail(user *models.User, activity
 	}, nil
 }
 
-func (m *mockUnifiedStravaService) GetActivityDetailWithZones(user *models.User, activityID int64) (*StravaActivityDetailWithZones, error) {
+func (m *mockUnifiedStravaService) GetActivityDetailWithZones(ctx context.Context, user *models.User, activityID int64) (*StravaActivityDetailWithZones, error) {
 	return &StravaActivityDetailWithZones{
 		StravaActivityDetail: &StravaActivityDetail{
 			Laps: []StravaLap{
@@ -54,7 +54,7 @@ func (m *mockUnifiedStravaService) GetActivityDetailWithZones(user *models.User,
 	}, nil
 }
 
-func (m *mockUnifiedStravaService) GetActivityStreams(user *models.User, activityID int64, streamTypes []string, resolution string) (*StravaStreams, error) {
+func (m *mockUnifiedStravaService) GetActivityStreams(ctx context.Context, user *models.User, activityID int64, streamTypes []string, resolution string) (*StravaStreams, error) {
 	// Return mock stream data
 	return &StravaStreams{
 		Time:      []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9},
@@ -64,7 +64,7 @@ func (m *mockUnifiedStravaService) GetActivityStreams(user *models.User, activit
 	}, nil
 }
 
-func (m *mockUnifiedStravaService) GetActivityZones(user *models.User, activityID int64) (*StravaActivityZones, error) {
+func (m *mockUnifiedStravaService) GetActivityZones(ctx context.Context, user *models.User, activityID int64) (*StravaActivityZones, error) {
 	return nil, nil
 }
 
@@ -215,7 +215,7 @@ func TestUnifiedStreamProcessor_ProcessPaginatedStreamRequest(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result, err := processor.ProcessPaginatedStreamRequest(user, tt.request, 5000)
+			result, err := processor.ProcessPaginatedStreamRequest(context.Background(), user, tt.request, 5000)
 
 			if tt.expectError {
 				if err == nil {
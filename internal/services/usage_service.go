@@ -0,0 +1,318 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"bodda/internal/config"
+	"bodda/internal/models"
+
+	"github.com/openai/openai-go/v2/responses"
+)
+
+// ErrTokenQuotaExceeded is returned when a user has used up a token quota
+var ErrTokenQuotaExceeded = errors.New("token quota exceeded")
+
+// QuotaExceededError describes which quota was exhausted and when it resets
+type QuotaExceededError struct {
+	Period   string    `json:"period"` // "daily" or "monthly"
+	Limit    int64     `json:"limit"`
+	Used     int64     `json:"used"`
+	ResetsAt time.Time `json:"resets_at"`
+}
+
+func (e *QuotaExceededError) Error() string {
+	return fmt.Sprintf("%s token quota exceeded: used %d of %d, resets at %s",
+		e.Period, e.Used, e.Limit, e.ResetsAt.Format(time.RFC3339))
+}
+
+// Is allows errors.Is(err, ErrTokenQuotaExceeded)
+func (e *QuotaExceededError) Is(target error) bool {
+	return target == ErrTokenQuotaExceeded
+}
+
+// TokenUsage is the token consumption reported for a single model response
+type TokenUsage struct {
+	Model             string
+	InputTokens       int64
+	OutputTokens      int64
+	CachedInputTokens int64
+	ReasoningTokens   int64
+}
+
+// UsageRecorder records LLM token consumption
+type UsageRecorder interface {
+	RecordUsage(ctx context.Context, userID, sessionID string, usage TokenUsage) error
+}
+
+// TokenUsageStore persists token usage aggregates
+type TokenUsageStore interface {
+	RecordUsage(ctx context.Context, userID, sessionID string, usageDate time.Time, inputTokens, outputTokens, cachedInputTokens, reasoningTokens int64) error
+	GetUserTotals(ctx context.Context, userID string, from, to time.Time) (*models.TokenUsageTotals, error)
+	GetUserDailyUsage(ctx context.Context, userID string, from, to time.Time) ([]*models.DailyTokenUsage, error)
+	GetUserSessionUsage(ctx context.Context, userID string, from, to time.Time, limit int) ([]*models.SessionTokenUsage, error)
+	GetTotals(ctx context.Context, from, to time.Time) (*models.TokenUsageTotals, error)
+	GetDailyUsage(ctx context.Context, from, to time.Time) ([]*models.DailyTokenUsage, error)
+	GetTopUsers(ctx context.Context, from, to time.Time, limit int) ([]*models.UserTokenUsage, error)
+}
+
+// QuotaStatus reports usage against a quota for the current period
+type QuotaStatus struct {
+	Used     int64     `json:"used"`
+	Limit    int64     `json:"limit"` // 0 means unlimited
+	ResetsAt time.Time `json:"resets_at"`
+}
+
+// UserUsageReport is the usage overview returned to a user
+type UserUsageReport struct {
+	Today    QuotaStatus                 `json:"today"`
+	Month    QuotaStatus                 `json:"month"`
+	From     time.Time                   `json:"from"`
+	To       time.Time                   `json:"to"`
+	Totals   *models.TokenUsageTotals    `json:"totals"`
+	Daily    []*models.DailyTokenUsage   `json:"daily"`
+	Sessions []*models.SessionTokenUsage `json:"sessions"`
+}
+
+// UsageSummaryReport is the usage overview across all users for administrators
+type UsageSummaryReport struct {
+	From     time.Time                 `json:"from"`
+	To       time.Time                 `json:"to"`
+	Totals   *models.TokenUsageTotals  `json:"totals"`
+	Daily    []*models.DailyTokenUsage `json:"daily"`
+	TopUsers []*models.UserTokenUsage  `json:"top_users"`
+}
+
+// UsageService meters LLM token usage and enforces per-user quotas
+type UsageService interface {
+	UsageRecorder
+	CheckQuota(ctx context.Context, userID string) error
+	GetUserUsage(ctx context.Context, userID string, days int) (*UserUsageReport, error)
+	GetUsageSummary(ctx context.Context, days int, limit int) (*UsageSummaryReport, error)
+}
+
+const (
+	defaultUsageReportDays  = 30
+	maxUsageReportDays      = 366
+	defaultUsageReportLimit = 20
+)
+
+type usageService struct {
+	store  TokenUsageStore
+	config config.UsageConfig
+	now    func() time.Time
+}
+
+// NewUsageService creates a new usage service
+func NewUsageService(cfg *config.Config, store TokenUsageStore) UsageService {
+	return &usageService{
+		store:  store,
+		config: cfg.Usage,
+		now:    time.Now,
+	}
+}
+
+// RecordUsage adds a model response's token usage to today's aggregates
+func (u *usageService) RecordUsage(ctx context.Context, userID, sessionID string, usage TokenUsage) error {
+	if userID == "" {
+		return fmt.Errorf("user ID is required to record token usage")
+	}
+
+	return u.store.RecordUsage(ctx, userID, sessionID, u.now().UTC(),
+		usage.InputTokens, usage.OutputTokens, usage.CachedInputTokens, usage.ReasoningTokens)
+}
+
+// CheckQuota returns a QuotaExceededError when the user has exhausted the daily or monthly quota
+func (u *usageService) CheckQuota(ctx context.Context, userID string) error {
+	if u.config.DailyTokenQuota <= 0 && u.config.MonthlyTokenQuota <= 0 {
+		return nil
+	}
+
+	dayStart, dayEnd := dayBounds(u.now())
+	monthStart, monthEnd := monthBounds(u.now())
+
+	if u.config.DailyTokenQuota > 0 {
+		totals, err := u.store.GetUserTotals(ctx, userID, dayStart, dayEnd)
+		if err != nil {
+			return fmt.Errorf("failed to check daily token quota: %w", err)
+		}
+		if totals.TotalTokens >= u.config.DailyTokenQuota {
+			return &QuotaExceededError{Period: "daily", Limit: u.config.DailyTokenQuota, Used: totals.TotalTokens, ResetsAt: dayEnd}
+		}
+	}
+
+	if u.config.MonthlyTokenQuota > 0 {
+		totals, err := u.store.GetUserTotals(ctx, userID, monthStart, monthEnd)
+		if err != nil {
+			return fmt.Errorf("failed to check monthly token quota: %w", err)
+		}
+		if totals.TotalTokens >= u.config.MonthlyTokenQuota {
+			return &QuotaExceededError{Period: "monthly", Limit: u.config.MonthlyTokenQuota, Used: totals.TotalTokens, ResetsAt: monthEnd}
+		}
+	}
+
+	return nil
+}
+
+// GetUserUsage returns quota status plus daily and per-session usage for the last days
+func (u *usageService) GetUserUsage(ctx context.Context, userID string, days int) (*UserUsageReport, error) {
+	days = clampUsageDays(days)
+
+	now := u.now()
+	dayStart, dayEnd := dayBounds(now)
+	monthStart, monthEnd := monthBounds(now)
+	from := dayStart.AddDate(0, 0, -(days - 1))
+
+	today, err := u.store.GetUserTotals(ctx, userID, dayStart, dayEnd)
+	if err != nil {
+		return nil, err
+	}
+
+	month, err := u.store.GetUserTotals(ctx, userID, monthStart, monthEnd)
+	if err != nil {
+		return nil, err
+	}
+
+	totals, err := u.store.GetUserTotals(ctx, userID, from, dayEnd)
+	if err != nil {
+		return nil, err
+	}
+
+	daily, err := u.store.GetUserDailyUsage(ctx, userID, from, dayEnd)
+	if err != nil {
+		return nil, err
+	}
+
+	sessions, err := u.store.GetUserSessionUsage(ctx, userID, from, dayEnd, defaultUsageReportLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	return &UserUsageReport{
+		Today:    QuotaStatus{Used: today.TotalTokens, Limit: u.config.DailyTokenQuota, ResetsAt: dayEnd},
+		Month:    QuotaStatus{Used: month.TotalTokens, Limit: u.config.MonthlyTokenQuota, ResetsAt: monthEnd},
+		From:     from,
+		To:       dayEnd,
+		Totals:   totals,
+		Daily:    daily,
+		Sessions: sessions,
+	}, nil
+}
+
+// GetUsageSummary returns usage across all users for the last days
+func (u *usageService) GetUsageSummary(ctx context.Context, days int, limit int) (*UsageSummaryReport, error) {
+	days = clampUsageDays(days)
+	if limit <= 0 || limit > 100 {
+		limit = defaultUsageReportLimit
+	}
+
+	dayStart, dayEnd := dayBounds(u.now())
+	from := dayStart.AddDate(0, 0, -(days - 1))
+
+	totals, err := u.store.GetTotals(ctx, from, dayEnd)
+	if err != nil {
+		return nil, err
+	}
+
+	daily, err := u.store.GetDailyUsage(ctx, from, dayEnd)
+	if err != nil {
+		return nil, err
+	}
+
+	topUsers, err := u.store.GetTopUsers(ctx, from, dayEnd, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	return &UsageSummaryReport{
+		From:     from,
+		To:       dayEnd,
+		Totals:   totals,
+		Daily:    daily,
+		TopUsers: topUsers,
+	}, nil
+}
+
+func clampUsageDays(days int) int {
+	if days <= 0 {
+		return defaultUsageReportDays
+	}
+	if days > maxUsageReportDays {
+		return maxUsageReportDays
+	}
+	return days
+}
+
+// dayBounds returns the start of the UTC day containing t and the start of the next day
+func dayBounds(t time.Time) (time.Time, time.Time) {
+	utc := t.UTC()
+	start := time.Date(utc.Year(), utc.Month(), utc.Day(), 0, 0, 0, 0, time.UTC)
+	return start, start.AddDate(0, 0, 1)
+}
+
+// monthBounds returns the start of the UTC month containing t and the start of the next month
+func monthBounds(t time.Time) (time.Time, time.Time) {
+	utc := t.UTC()
+	start := time.Date(utc.Year(), utc.Month(), 1, 0, 0, 0, 0, time.UTC)
+	return start, start.AddDate(0, 1, 0)
+}
+
+// usageScope attributes model usage within a request to a user and session
+type usageScope struct {
+	recorder  UsageRecorder
+	userID    string
+	sessionID string
+}
+
+type usageScopeKey struct{}
+
+// withUsageScope attaches usage attribution to ctx so every model call made with it is metered
+func withUsageScope(ctx context.Context, recorder UsageRecorder, userID, sessionID string) context.Context {
+	if recorder == nil || userID == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, usageScopeKey{}, &usageScope{
+		recorder:  recorder,
+		userID:    userID,
+		sessionID: sessionID,
+	})
+}
+
+// recordResponseUsage records the usage reported by a Responses API completion event.
+// Failures are logged only so metering never breaks a conversation.
+func recordResponseUsage(ctx context.Context, response responses.Response) {
+	scope, ok := ctx.Value(usageScopeKey{}).(*usageScope)
+	if !ok {
+		return
+	}
+
+	usage := TokenUsage{
+		Model:             string(response.Model),
+		InputTokens:       response.Usage.InputTokens,
+		OutputTokens:      response.Usage.OutputTokens,
+		CachedInputTokens: response.Usage.InputTokensDetails.CachedTokens,
+		ReasoningTokens:   response.Usage.OutputTokensDetails.ReasoningTokens,
+	}
+	if usage.InputTokens == 0 && usage.OutputTokens == 0 {
+		return
+	}
+
+	if err := scope.recorder.RecordUsage(ctx, scope.userID, scope.sessionID, usage); err != nil {
+		slog.ErrorContext(ctx, "Failed to record token usage",
+			"user_id", scope.userID,
+			"session_id", scope.sessionID,
+			"model", usage.Model,
+			"error", err)
+		return
+	}
+
+	slog.DebugContext(ctx, "Recorded token usage",
+		"user_id", scope.userID,
+		"session_id", scope.sessionID,
+		"model", usage.Model,
+		"input_tokens", usage.InputTokens,
+		"output_tokens", usage.OutputTokens)
+}
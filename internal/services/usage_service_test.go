@@ -0,0 +1,218 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"bodda/internal/config"
+	"bodda/internal/models"
+
+	"github.com/openai/openai-go/v2/responses"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type usageRecord struct {
+	userID    string
+	sessionID string
+	date      time.Time
+	input     int64
+	output    int64
+	cached    int64
+	reasoning int64
+}
+
+// fakeTokenUsageStore keeps usage records in memory
+type fakeTokenUsageStore struct {
+	records []usageRecord
+	err     error
+}
+
+func (f *fakeTokenUsageStore) RecordUsage(ctx context.Context, userID, sessionID string, usageDate time.Time, inputTokens, outputTokens, cachedInputTokens, reasoningTokens int64) error {
+	if f.err != nil {
+		return f.err
+	}
+	f.records = append(f.records, usageRecord{userID, sessionID, usageDate, inputTokens, outputTokens, cachedInputTokens, reasoningTokens})
+	return nil
+}
+
+func (f *fakeTokenUsageStore) totals(userID string, from, to time.Time) *models.TokenUsageTotals {
+	totals := &models.TokenUsageTotals{}
+	for _, r := range f.records {
+		if userID != "" && r.userID != userID {
+			continue
+		}
+		if r.date.Before(from) || !r.date.Before(to) {
+			continue
+		}
+		totals.InputTokens += r.input
+		totals.OutputTokens += r.output
+		totals.CachedInputTokens += r.cached
+		totals.ReasoningTokens += r.reasoning
+		totals.TotalTokens += r.input + r.output
+		totals.RequestCount++
+	}
+	return totals
+}
+
+func (f *fakeTokenUsageStore) GetUserTotals(ctx context.Context, userID string, from, to time.Time) (*models.TokenUsageTotals, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	return f.totals(userID, from, to), nil
+}
+
+func (f *fakeTokenUsageStore) GetUserDailyUsage(ctx context.Context, userID string, from, to time.Time) ([]*models.DailyTokenUsage, error) {
+	return nil, f.err
+}
+
+func (f *fakeTokenUsageStore) GetUserSessionUsage(ctx context.Context, userID string, from, to time.Time, limit int) ([]*models.SessionTokenUsage, error) {
+	return nil, f.err
+}
+
+func (f *fakeTokenUsageStore) GetTotals(ctx context.Context, from, to time.Time) (*models.TokenUsageTotals, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	return f.totals("", from, to), nil
+}
+
+func (f *fakeTokenUsageStore) GetDailyUsage(ctx context.Context, from, to time.Time) ([]*models.DailyTokenUsage, error) {
+	return nil, f.err
+}
+
+func (f *fakeTokenUsageStore) GetTopUsers(ctx context.Context, from, to time.Time, limit int) ([]*models.UserTokenUsage, error) {
+	return nil, f.err
+}
+
+func newTestUsageService(store TokenUsageStore, daily, monthly int64, now time.Time) *usageService {
+	cfg := &config.Config{Usage: config.UsageConfig{DailyTokenQuota: daily, MonthlyTokenQuota: monthly}}
+	svc := NewUsageService(cfg, store).(*usageService)
+	svc.now = func() time.Time { return now }
+	return svc
+}
+
+func TestUsageService_CheckQuota(t *testing.T) {
+	now := time.Date(2025, 3, 15, 12, 0, 0, 0, time.UTC)
+	ctx := context.Background()
+
+	t.Run("unlimited when no quotas configured", func(t *testing.T) {
+		store := &fakeTokenUsageStore{records: []usageRecord{{userID: "u1", date: now, input: 1_000_000}}}
+		svc := newTestUsageService(store, 0, 0, now)
+		assert.NoError(t, svc.CheckQuota(ctx, "u1"))
+	})
+
+	t.Run("daily quota exceeded", func(t *testing.T) {
+		store := &fakeTokenUsageStore{records: []usageRecord{{userID: "u1", date: now, input: 800, output: 200}}}
+		svc := newTestUsageService(store, 1000, 0, now)
+
+		err := svc.CheckQuota(ctx, "u1")
+		require.Error(t, err)
+		assert.True(t, errors.Is(err, ErrTokenQuotaExceeded))
+
+		var quotaErr *QuotaExceededError
+		require.True(t, errors.As(err, &quotaErr))
+		assert.Equal(t, "daily", quotaErr.Period)
+		assert.Equal(t, int64(1000), quotaErr.Used)
+		assert.Equal(t, time.Date(2025, 3, 16, 0, 0, 0, 0, time.UTC), quotaErr.ResetsAt)
+	})
+
+	t.Run("yesterday's usage does not count toward daily quota", func(t *testing.T) {
+		store := &fakeTokenUsageStore{records: []usageRecord{{userID: "u1", date: now.AddDate(0, 0, -1), input: 5000}}}
+		svc := newTestUsageService(store, 1000, 0, now)
+		assert.NoError(t, svc.CheckQuota(ctx, "u1"))
+	})
+
+	t.Run("monthly quota exceeded", func(t *testing.T) {
+		store := &fakeTokenUsageStore{records: []usageRecord{
+			{userID: "u1", date: now.AddDate(0, 0, -3), input: 600},
+			{userID: "u1", date: now.AddDate(0, 0, -1), input: 600},
+		}}
+		svc := newTestUsageService(store, 1000, 1000, now)
+
+		var quotaErr *QuotaExceededError
+		require.True(t, errors.As(svc.CheckQuota(ctx, "u1"), &quotaErr))
+		assert.Equal(t, "monthly", quotaErr.Period)
+		assert.Equal(t, time.Date(2025, 4, 1, 0, 0, 0, 0, time.UTC), quotaErr.ResetsAt)
+	})
+
+	t.Run("other users' usage is ignored", func(t *testing.T) {
+		store := &fakeTokenUsageStore{records: []usageRecord{{userID: "u2", date: now, input: 5000}}}
+		svc := newTestUsageService(store, 1000, 0, now)
+		assert.NoError(t, svc.CheckQuota(ctx, "u1"))
+	})
+
+	t.Run("store errors are wrapped", func(t *testing.T) {
+		store := &fakeTokenUsageStore{err: errors.New("connection refused")}
+		svc := newTestUsageService(store, 1000, 0, now)
+
+		err := svc.CheckQuota(ctx, "u1")
+		require.Error(t, err)
+		assert.False(t, errors.Is(err, ErrTokenQuotaExceeded))
+	})
+}
+
+func TestUsageService_GetUserUsage(t *testing.T) {
+	now := time.Date(2025, 3, 15, 12, 0, 0, 0, time.UTC)
+	store := &fakeTokenUsageStore{records: []usageRecord{
+		{userID: "u1", date: now, input: 100, output: 50},
+		{userID: "u1", date: now.AddDate(0, 0, -10), input: 1000},
+		{userID: "u1", date: now.AddDate(0, 0, -40), input: 9999},
+	}}
+	svc := newTestUsageService(store, 500, 0, now)
+
+	report, err := svc.GetUserUsage(context.Background(), "u1", 0)
+	require.NoError(t, err)
+
+	assert.Equal(t, int64(150), report.Today.Used)
+	assert.Equal(t, int64(500), report.Today.Limit)
+	assert.Equal(t, int64(1150), report.Month.Used)
+	assert.Equal(t, int64(0), report.Month.Limit)
+	assert.Equal(t, int64(1150), report.Totals.TotalTokens)
+	assert.Equal(t, time.Date(2025, 2, 14, 0, 0, 0, 0, time.UTC), report.From)
+}
+
+func TestClampUsageDays(t *testing.T) {
+	assert.Equal(t, defaultUsageReportDays, clampUsageDays(0))
+	assert.Equal(t, defaultUsageReportDays, clampUsageDays(-5))
+	assert.Equal(t, 7, clampUsageDays(7))
+	assert.Equal(t, maxUsageReportDays, clampUsageDays(10000))
+}
+
+func TestRecordResponseUsage(t *testing.T) {
+	now := time.Date(2025, 3, 15, 12, 0, 0, 0, time.UTC)
+	store := &fakeTokenUsageStore{}
+	svc := newTestUsageService(store, 0, 0, now)
+
+	response := responses.Response{Model: "gpt-5"}
+	response.Usage.InputTokens = 120
+	response.Usage.OutputTokens = 30
+	response.Usage.InputTokensDetails.CachedTokens = 100
+	response.Usage.OutputTokensDetails.ReasoningTokens = 10
+
+	t.Run("no scope records nothing", func(t *testing.T) {
+		recordResponseUsage(context.Background(), response)
+		assert.Empty(t, store.records)
+	})
+
+	t.Run("scoped context records usage", func(t *testing.T) {
+		ctx := withUsageScope(context.Background(), svc, "u1", "s1")
+		recordResponseUsage(ctx, response)
+
+		require.Len(t, store.records, 1)
+		record := store.records[0]
+		assert.Equal(t, "u1", record.userID)
+		assert.Equal(t, "s1", record.sessionID)
+		assert.Equal(t, int64(120), record.input)
+		assert.Equal(t, int64(30), record.output)
+		assert.Equal(t, int64(100), record.cached)
+		assert.Equal(t, int64(10), record.reasoning)
+	})
+
+	t.Run("scope without user is ignored", func(t *testing.T) {
+		ctx := withUsageScope(context.Background(), svc, "", "s1")
+		assert.Nil(t, ctx.Value(usageScopeKey{}))
+	})
+}
@@ -0,0 +1,766 @@
+package services
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"bodda/internal/models"
+)
+
+// ErrInvalidWellnessEntry is returned when a wellness entry or import row fails validation
+var ErrInvalidWellnessEntry = errors.New("invalid wellness entry")
+
+// ErrWellnessNotConfigured is returned by the readiness tool when no wellness service is wired in
+var ErrWellnessNotConfigured = errors.New("wellness tracking is not available")
+
+const (
+	wellnessDateLayout = "2006-01-02"
+
+	// Readiness compares today against this many preceding days
+	readinessBaselineDays = 28
+	// A metric needs this many baseline days before it counts towards readiness
+	minReadinessBaselineSamples = 7
+	// Readiness uses the latest entry at most this many days before the requested date
+	maxReadinessEntryAgeDays = 2
+
+	defaultWellnessDays = 30
+	maxWellnessDays     = 366
+
+	maxWellnessImportRows = 1000
+
+	// Sleep at or above this many hours scores fully
+	targetSleepHours = 8.0
+
+	// Minimum spread assumed for baselines, so a very stable history does not turn noise into large deviations
+	minHRVLogStdDev      = 0.05
+	minRestingHRStdDev   = 2.0
+	restingHRAlertRise   = 5
+	shortSleepHours      = 6.0
+	highSubjectiveRating = 4
+)
+
+// Readiness component weights; missing components are left out and the rest renormalized
+const (
+	readinessWeightHRV        = 0.35
+	readinessWeightRestingHR  = 0.25
+	readinessWeightSleep      = 0.20
+	readinessWeightSubjective = 0.20
+)
+
+// Readiness statuses
+const (
+	ReadinessHigh     = "high"
+	ReadinessModerate = "moderate"
+	ReadinessLow      = "low"
+)
+
+// WellnessStore persists daily wellness metrics
+type WellnessStore interface {
+	Upsert(ctx context.Context, entry *models.DailyWellness) error
+	UpsertBatch(ctx context.Context, entries []*models.DailyWellness) error
+	Get(ctx context.Context, userID string, date time.Time) (*models.DailyWellness, error)
+	GetRange(ctx context.Context, userID string, from, to time.Time) ([]*models.DailyWellness, error)
+	Delete(ctx context.Context, userID string, date time.Time) (bool, error)
+}
+
+// WellnessImportRowError describes a CSV row that could not be imported
+type WellnessImportRowError struct {
+	Line  int    `json:"line"`
+	Error string `json:"error"`
+}
+
+// WellnessImportResult summarizes a CSV import
+type WellnessImportResult struct {
+	Imported       int                      `json:"imported"`
+	Skipped        int                      `json:"skipped"`
+	Errors         []WellnessImportRowError `json:"errors,omitempty"`
+	IgnoredColumns []string                 `json:"ignored_columns,omitempty"`
+}
+
+// ReadinessComponent is one input to the readiness score, scored 0-100
+type ReadinessComponent struct {
+	Name     string   `json:"name"`
+	Score    float64  `json:"score"`
+	Value    float64  `json:"value"`
+	Baseline *float64 `json:"baseline,omitempty"`
+	Weight   float64  `json:"weight"`
+}
+
+// ReadinessReport scores an athlete's recovery for a day relative to their own recent baseline
+type ReadinessReport struct {
+	RequestedDate time.Time               `json:"requested_date"`
+	Entry         *models.DailyWellness   `json:"entry,omitempty"`
+	Score         *int                    `json:"score,omitempty"`
+	Status        string                  `json:"status,omitempty"`
+	Components    []ReadinessComponent    `json:"components"`
+	Flags         []string                `json:"flags"`
+	BaselineDays  int                     `json:"baseline_days"`
+	Recent        []*models.DailyWellness `json:"recent"`
+}
+
+// WellnessService records daily wellness metrics and derives readiness
+type WellnessService interface {
+	RecordEntry(ctx context.Context, entry *models.DailyWellness) error
+	DeleteEntry(ctx context.Context, userID string, date time.Time) (bool, error)
+	ImportCSV(ctx context.Context, userID string, r io.Reader) (*WellnessImportResult, error)
+	GetEntries(ctx context.Context, userID string, days int) ([]*models.DailyWellness, error)
+	GetReadiness(ctx context.Context, userID string, date time.Time) (*ReadinessReport, error)
+}
+
+type wellnessService struct {
+	store WellnessStore
+	now   func() time.Time
+}
+
+// NewWellnessService creates a new wellness service
+func NewWellnessService(store WellnessStore) WellnessService {
+	return &wellnessService{
+		store: store,
+		now:   time.Now,
+	}
+}
+
+// RecordEntry validates an entry and merges it into the day's stored metrics
+func (w *wellnessService) RecordEntry(ctx context.Context, entry *models.DailyWellness) error {
+	if err := w.validateEntry(entry); err != nil {
+		return err
+	}
+	return w.store.Upsert(ctx, entry)
+}
+
+// DeleteEntry removes a day's metrics
+func (w *wellnessService) DeleteEntry(ctx context.Context, userID string, date time.Time) (bool, error) {
+	return w.store.Delete(ctx, userID, date)
+}
+
+// GetEntries returns the user's entries for the last days days including today, oldest first
+func (w *wellnessService) GetEntries(ctx context.Context, userID string, days int) ([]*models.DailyWellness, error) {
+	if days <= 0 {
+		days = defaultWellnessDays
+	}
+	if days > maxWellnessDays {
+		days = maxWellnessDays
+	}
+
+	today := wellnessDate(w.now())
+	return w.store.GetRange(ctx, userID, today.AddDate(0, 0, -days+1), today.AddDate(0, 0, 1))
+}
+
+// validateEntry checks the ranges enforced by the database so callers get a descriptive error
+func (w *wellnessService) validateEntry(entry *models.DailyWellness) error {
+	if entry == nil || entry.UserID == "" {
+		return fmt.Errorf("%w: user is required", ErrInvalidWellnessEntry)
+	}
+	if entry.Date.IsZero() {
+		return fmt.Errorf("%w: date is required", ErrInvalidWellnessEntry)
+	}
+	entry.Date = wellnessDate(entry.Date)
+	// Allow one day ahead so athletes east of UTC can log their morning
+	if entry.Date.After(wellnessDate(w.now()).AddDate(0, 0, 1)) {
+		return fmt.Errorf("%w: date %s is in the future", ErrInvalidWellnessEntry, entry.Date.Format(wellnessDateLayout))
+	}
+
+	if entry.RestingHR == nil && entry.HRVRMSSD == nil && entry.SleepHours == nil &&
+		entry.Fatigue == nil && entry.Soreness == nil && entry.Mood == nil && strings.TrimSpace(entry.Notes) == "" {
+		return fmt.Errorf("%w: at least one metric is required", ErrInvalidWellnessEntry)
+	}
+	if entry.RestingHR != nil && (*entry.RestingHR < 20 || *entry.RestingHR > 150) {
+		return fmt.Errorf("%w: resting_hr must be between 20 and 150 bpm", ErrInvalidWellnessEntry)
+	}
+	if entry.HRVRMSSD != nil && (*entry.HRVRMSSD <= 0 || *entry.HRVRMSSD > 300) {
+		return fmt.Errorf("%w: hrv_rmssd must be between 0 and 300 ms", ErrInvalidWellnessEntry)
+	}
+	if entry.SleepHours != nil && (*entry.SleepHours < 0 || *entry.SleepHours > 24) {
+		return fmt.Errorf("%w: sleep_hours must be between 0 and 24", ErrInvalidWellnessEntry)
+	}
+	for _, rating := range []struct {
+		name  string
+		value *int
+	}{{"fatigue", entry.Fatigue}, {"soreness", entry.Soreness}, {"mood", entry.Mood}} {
+		if rating.value != nil && (*rating.value < 1 || *rating.value > 5) {
+			return fmt.Errorf("%w: %s must be between 1 and 5", ErrInvalidWellnessEntry, rating.name)
+		}
+	}
+	entry.Notes = strings.TrimSpace(entry.Notes)
+	return nil
+}
+
+// wellnessCSVColumns maps accepted header names to canonical columns
+var wellnessCSVColumns = map[string]string{
+	"date":               "date",
+	"day":                "date",
+	"resting_hr":         "resting_hr",
+	"rhr":                "resting_hr",
+	"resting_heart_rate": "resting_hr",
+	"hrv_rmssd":          "hrv_rmssd",
+	"hrv":                "hrv_rmssd",
+	"rmssd":              "hrv_rmssd",
+	"sleep_hours":        "sleep_hours",
+	"sleep":              "sleep_hours",
+	"fatigue":            "fatigue",
+	"soreness":           "soreness",
+	"mood":               "mood",
+	"notes":              "notes",
+}
+
+// ImportCSV imports a CSV file with a header row. Valid rows are stored in one batch; invalid rows are
+// reported with their line number and skipped. Empty cells leave the stored value unchanged.
+func (w *wellnessService) ImportCSV(ctx context.Context, userID string, r io.Reader) (*WellnessImportResult, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("%w: the file is empty", ErrInvalidWellnessEntry)
+		}
+		return nil, fmt.Errorf("%w: failed to read CSV header: %v", ErrInvalidWellnessEntry, err)
+	}
+
+	result := &WellnessImportResult{}
+	columns := make(map[string]int)
+	for i, name := range header {
+		key := strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		key = strings.NewReplacer(" ", "_", "-", "_").Replace(key)
+		canonical, ok := wellnessCSVColumns[key]
+		if !ok {
+			result.IgnoredColumns = append(result.IgnoredColumns, name)
+			continue
+		}
+		if _, duplicate := columns[canonical]; duplicate {
+			return nil, fmt.Errorf("%w: column %s appears more than once", ErrInvalidWellnessEntry, canonical)
+		}
+		columns[canonical] = i
+	}
+	if _, ok := columns["date"]; !ok {
+		return nil, fmt.Errorf("%w: a date column is required", ErrInvalidWellnessEntry)
+	}
+
+	var entries []*models.DailyWellness
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			var parseErr *csv.ParseError
+			if !errors.As(err, &parseErr) {
+				return nil, fmt.Errorf("failed to read wellness CSV: %w", err)
+			}
+			result.Errors = append(result.Errors, WellnessImportRowError{Line: parseErr.StartLine, Error: parseErr.Err.Error()})
+			result.Skipped++
+			continue
+		}
+		line, _ := reader.FieldPos(0)
+		if isBlankRecord(record) {
+			continue
+		}
+		if len(entries)+result.Skipped >= maxWellnessImportRows {
+			return nil, fmt.Errorf("%w: imports are limited to %d rows", ErrInvalidWellnessEntry, maxWellnessImportRows)
+		}
+
+		entry, err := parseWellnessRecord(record, columns)
+		if err == nil {
+			entry.UserID = userID
+			err = w.validateEntry(entry)
+		}
+		if err != nil {
+			result.Errors = append(result.Errors, WellnessImportRowError{Line: line, Error: strings.TrimPrefix(err.Error(), ErrInvalidWellnessEntry.Error()+": ")})
+			result.Skipped++
+			continue
+		}
+		entries = append(entries, entry)
+	}
+
+	if len(entries) > 0 {
+		if err := w.store.UpsertBatch(ctx, entries); err != nil {
+			return nil, err
+		}
+	}
+	result.Imported = len(entries)
+	return result, nil
+}
+
+func isBlankRecord(record []string) bool {
+	for _, field := range record {
+		if strings.TrimSpace(field) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// parseWellnessRecord converts a CSV record into an entry using the resolved column positions
+func parseWellnessRecord(record []string, columns map[string]int) (*models.DailyWellness, error) {
+	field := func(name string) string {
+		if i, ok := columns[name]; ok && i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+
+	date, err := ParseWellnessDate(field("date"))
+	if err != nil {
+		return nil, err
+	}
+	entry := &models.DailyWellness{Date: date, Notes: field("notes")}
+
+	if entry.RestingHR, err = parseOptionalInt(field("resting_hr"), "resting_hr"); err != nil {
+		return nil, err
+	}
+	if entry.HRVRMSSD, err = parseOptionalFloat(field("hrv_rmssd"), "hrv_rmssd"); err != nil {
+		return nil, err
+	}
+	if entry.SleepHours, err = ParseSleepHours(field("sleep_hours")); err != nil {
+		return nil, err
+	}
+	if entry.Fatigue, err = parseOptionalInt(field("fatigue"), "fatigue"); err != nil {
+		return nil, err
+	}
+	if entry.Soreness, err = parseOptionalInt(field("soreness"), "soreness"); err != nil {
+		return nil, err
+	}
+	if entry.Mood, err = parseOptionalInt(field("mood"), "mood"); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+// ParseWellnessDate parses a YYYY-MM-DD or YYYY/MM/DD calendar date
+func ParseWellnessDate(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, fmt.Errorf("%w: date is required", ErrInvalidWellnessEntry)
+	}
+	for _, layout := range []string{wellnessDateLayout, "2006/01/02"} {
+		if date, err := time.Parse(layout, value); err == nil {
+			return date, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("%w: invalid date '%s', expected YYYY-MM-DD", ErrInvalidWellnessEntry, value)
+}
+
+// ParseSleepHours parses decimal hours ("7.5") or hours and minutes ("7:30"); empty means not recorded
+func ParseSleepHours(value string) (*float64, error) {
+	if value == "" {
+		return nil, nil
+	}
+	if hours, minutes, found := strings.Cut(value, ":"); found {
+		h, herr := strconv.Atoi(hours)
+		m, merr := strconv.Atoi(minutes)
+		if herr != nil || merr != nil || m < 0 || m >= 60 {
+			return nil, fmt.Errorf("%w: invalid sleep_hours '%s'", ErrInvalidWellnessEntry, value)
+		}
+		total := roundTo(float64(h)+float64(m)/60, 2)
+		return &total, nil
+	}
+	return parseOptionalFloat(value, "sleep_hours")
+}
+
+func parseOptionalInt(value, name string) (*int, error) {
+	if value == "" {
+		return nil, nil
+	}
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid %s '%s'", ErrInvalidWellnessEntry, name, value)
+	}
+	return &parsed, nil
+}
+
+func parseOptionalFloat(value, name string) (*float64, error) {
+	if value == "" {
+		return nil, nil
+	}
+	parsed, err := strconv.ParseFloat(value, 64)
+	if err != nil || math.IsNaN(parsed) || math.IsInf(parsed, 0) {
+		return nil, fmt.Errorf("%w: invalid %s '%s'", ErrInvalidWellnessEntry, name, value)
+	}
+	return &parsed, nil
+}
+
+// GetReadiness scores the latest entry on or up to two days before date against the preceding 28 days
+func (w *wellnessService) GetReadiness(ctx context.Context, userID string, date time.Time) (*ReadinessReport, error) {
+	if date.IsZero() {
+		date = w.now()
+	}
+	date = wellnessDate(date)
+
+	history, err := w.store.GetRange(ctx, userID, date.AddDate(0, 0, -readinessBaselineDays-maxReadinessEntryAgeDays), date.AddDate(0, 0, 1))
+	if err != nil {
+		return nil, err
+	}
+	return BuildReadinessReport(history, date), nil
+}
+
+// BuildReadinessReport scores the most recent entry in history (oldest first) that is at most two days
+// before date, using the 28 days before that entry as the baseline
+func BuildReadinessReport(history []*models.DailyWellness, date time.Time) *ReadinessReport {
+	report := &ReadinessReport{RequestedDate: date, Components: []ReadinessComponent{}, Flags: []string{}}
+
+	for i := len(history) - 1; i >= 0; i-- {
+		entry := history[i]
+		if entry.Date.After(date) {
+			continue
+		}
+		if date.Sub(entry.Date) <= time.Duration(maxReadinessEntryAgeDays)*24*time.Hour {
+			report.Entry = entry
+		}
+		break
+	}
+
+	recentFrom := date.AddDate(0, 0, -6)
+	for _, entry := range history {
+		if !entry.Date.Before(recentFrom) && !entry.Date.After(date) {
+			report.Recent = append(report.Recent, entry)
+		}
+	}
+
+	if report.Entry == nil {
+		return report
+	}
+
+	var baseline []*models.DailyWellness
+	baselineFrom := report.Entry.Date.AddDate(0, 0, -readinessBaselineDays)
+	for _, entry := range history {
+		if !entry.Date.Before(baselineFrom) && entry.Date.Before(report.Entry.Date) {
+			baseline = append(baseline, entry)
+		}
+	}
+	report.BaselineDays = len(baseline)
+
+	entry := report.Entry
+	if entry.HRVRMSSD != nil {
+		var logs []float64
+		for _, day := range baseline {
+			if day.HRVRMSSD != nil {
+				logs = append(logs, math.Log(*day.HRVRMSSD))
+			}
+		}
+		if len(logs) >= minReadinessBaselineSamples {
+			mean, sd := meanStdDev(logs)
+			sd = math.Max(sd, minHRVLogStdDev)
+			z := (math.Log(*entry.HRVRMSSD) - mean) / sd
+			typical := roundTo(math.Exp(mean), 1)
+			report.Components = append(report.Components, ReadinessComponent{
+				Name: "HRV (rMSSD)", Score: baselineScore(z), Value: *entry.HRVRMSSD, Baseline: &typical, Weight: readinessWeightHRV,
+			})
+			if z <= -1 {
+				report.Flags = append(report.Flags, fmt.Sprintf("HRV suppressed: %.0f ms against a typical %.0f ms", *entry.HRVRMSSD, typical))
+			}
+		}
+	}
+
+	if entry.RestingHR != nil {
+		var values []float64
+		for _, day := range baseline {
+			if day.RestingHR != nil {
+				values = append(values, float64(*day.RestingHR))
+			}
+		}
+		if len(values) >= minReadinessBaselineSamples {
+			mean, sd := meanStdDev(values)
+			sd = math.Max(sd, minRestingHRStdDev)
+			z := (float64(*entry.RestingHR) - mean) / sd
+			typical := roundTo(mean, 1)
+			report.Components = append(report.Components, ReadinessComponent{
+				Name: "Resting HR", Score: baselineScore(-z), Value: float64(*entry.RestingHR), Baseline: &typical, Weight: readinessWeightRestingHR,
+			})
+			if float64(*entry.RestingHR)-mean >= restingHRAlertRise {
+				report.Flags = append(report.Flags, fmt.Sprintf("Resting HR elevated: %d bpm against a typical %.0f bpm", *entry.RestingHR, mean))
+			}
+		}
+	}
+
+	if entry.SleepHours != nil {
+		report.Components = append(report.Components, ReadinessComponent{
+			Name: "Sleep", Score: roundTo(math.Min(100, *entry.SleepHours/targetSleepHours*100), 0), Value: *entry.SleepHours, Weight: readinessWeightSleep,
+		})
+		if *entry.SleepHours < shortSleepHours {
+			report.Flags = append(report.Flags, fmt.Sprintf("Short sleep: %.1f h", *entry.SleepHours))
+		}
+	}
+
+	if subjective, ok := subjectiveScore(entry); ok {
+		report.Components = append(report.Components, ReadinessComponent{
+			Name: "Subjective", Score: subjective, Value: subjective, Weight: readinessWeightSubjective,
+		})
+		if entry.Fatigue != nil && *entry.Fatigue >= highSubjectiveRating {
+			report.Flags = append(report.Flags, fmt.Sprintf("High fatigue reported (%d/5)", *entry.Fatigue))
+		}
+		if entry.Soreness != nil && *entry.Soreness >= highSubjectiveRating {
+			report.Flags = append(report.Flags, fmt.Sprintf("High soreness reported (%d/5)", *entry.Soreness))
+		}
+	}
+
+	var weighted, weights float64
+	for _, component := range report.Components {
+		weighted += component.Score * component.Weight
+		weights += component.Weight
+	}
+	if weights > 0 {
+		score := int(math.Round(weighted / weights))
+		report.Score = &score
+		report.Status = readinessStatus(score)
+	}
+	return report
+}
+
+// baselineScore maps a deviation from baseline in standard deviations to 0-100, with the baseline at 75
+func baselineScore(z float64) float64 {
+	return roundTo(math.Max(0, math.Min(100, 75+25*z)), 0)
+}
+
+// subjectiveScore averages the subjective ratings on a 0-100 scale where higher means better recovered
+func subjectiveScore(entry *models.DailyWellness) (float64, bool) {
+	var sum float64
+	count := 0
+	if entry.Fatigue != nil {
+		sum += float64(5-*entry.Fatigue) / 4
+		count++
+	}
+	if entry.Soreness != nil {
+		sum += float64(5-*entry.Soreness) / 4
+		count++
+	}
+	if entry.Mood != nil {
+		sum += float64(*entry.Mood-1) / 4
+		count++
+	}
+	if count == 0 {
+		return 0, false
+	}
+	return roundTo(sum/float64(count)*100, 0), true
+}
+
+func readinessStatus(score int) string {
+	switch {
+	case score >= 75:
+		return ReadinessHigh
+	case score >= 55:
+		return ReadinessModerate
+	default:
+		return ReadinessLow
+	}
+}
+
+func meanStdDev(values []float64) (float64, float64) {
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	mean := sum / float64(len(values))
+	var variance float64
+	for _, v := range values {
+		variance += (v - mean) * (v - mean)
+	}
+	return mean, math.Sqrt(variance / float64(len(values)))
+}
+
+// wellnessDate truncates a time to its calendar date in UTC
+func wellnessDate(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// RecentLoad compares the last week's training with the preceding four weeks
+type RecentLoad struct {
+	Last7DaysSessions  int     `json:"last_7_days_sessions"`
+	Last7DaysHours     float64 `json:"last_7_days_hours"`
+	WeeklyAverageHours float64 `json:"weekly_average_hours"`
+	LastSessionName    string  `json:"last_session_name,omitempty"`
+	LastSessionID      int64   `json:"last_session_id,omitempty"`
+	LastSessionDate    string  `json:"last_session_date,omitempty"`
+	LastSessionMinutes int     `json:"last_session_minutes,omitempty"`
+}
+
+// BuildRecentLoad summarizes moving time for the 7 days up to and including date against the 28 days before
+func BuildRecentLoad(activities []*StravaActivity, date time.Time) *RecentLoad {
+	load := &RecentLoad{}
+	end := date.AddDate(0, 0, 1)
+	weekStart := date.AddDate(0, 0, -6)
+	baselineStart := weekStart.AddDate(0, 0, -readinessBaselineDays)
+
+	var weekSeconds, baselineSeconds int
+	var last *StravaActivity
+	var lastDate time.Time
+	for _, activity := range activities {
+		start, ok := activityStartLocal(activity)
+		if !ok || !start.Before(end) || start.Before(baselineStart) {
+			continue
+		}
+		if !start.Before(weekStart) {
+			weekSeconds += activity.MovingTime
+			load.Last7DaysSessions++
+		} else {
+			baselineSeconds += activity.MovingTime
+		}
+		if last == nil || start.After(lastDate) {
+			last, lastDate = activity, start
+		}
+	}
+
+	load.Last7DaysHours = roundTo(float64(weekSeconds)/3600, 1)
+	load.WeeklyAverageHours = roundTo(float64(baselineSeconds)/3600/4, 1)
+	if last != nil {
+		load.LastSessionName = last.Name
+		load.LastSessionID = last.ID
+		load.LastSessionDate = lastDate.Format(wellnessDateLayout)
+		load.LastSessionMinutes = last.MovingTime / 60
+	}
+	return load
+}
+
+// ReadinessRequest selects the day to assess
+type ReadinessRequest struct {
+	Date string `json:"date"`
+}
+
+// formatReadiness renders a readiness report and the recent training load as markdown
+func formatReadiness(report *ReadinessReport, load *RecentLoad) string {
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("# Readiness for %s\n\n", report.RequestedDate.Format(wellnessDateLayout)))
+
+	if report.Entry == nil {
+		b.WriteString("No wellness entry was recorded in the last 3 days. Ask the athlete how they slept and feel, or suggest logging resting HR and HRV each morning.\n\n")
+	} else {
+		if !report.Entry.Date.Equal(report.RequestedDate) {
+			b.WriteString(fmt.Sprintf("Based on the latest entry from %s.\n\n", report.Entry.Date.Format(wellnessDateLayout)))
+		}
+		if report.Score != nil {
+			b.WriteString(fmt.Sprintf("**Readiness: %d/100 (%s)** – %s\n\n", *report.Score, report.Status, readinessAdvice(report.Status)))
+		} else {
+			b.WriteString("Not enough data for a readiness score yet; HRV and resting HR need at least 7 days of history.\n\n")
+		}
+
+		if len(report.Components) > 0 {
+			b.WriteString("| Component | Today | Baseline | Score |\n|---|---|---|---|\n")
+			for _, component := range report.Components {
+				baseline := "–"
+				if component.Baseline != nil {
+					baseline = strconv.FormatFloat(*component.Baseline, 'f', -1, 64)
+				}
+				value := strconv.FormatFloat(component.Value, 'f', -1, 64)
+				if component.Name == "Subjective" {
+					value = formatSubjective(report.Entry)
+				}
+				b.WriteString(fmt.Sprintf("| %s | %s | %s | %.0f |\n", component.Name, value, baseline, component.Score))
+			}
+			b.WriteString(fmt.Sprintf("\nBaseline: %d entries in the preceding %d days.\n\n", report.BaselineDays, readinessBaselineDays))
+		}
+
+		if len(report.Flags) > 0 {
+			b.WriteString("**Flags:**\n")
+			for _, flag := range report.Flags {
+				b.WriteString("- " + flag + "\n")
+			}
+			b.WriteString("\n")
+		}
+		if report.Entry.Notes != "" {
+			b.WriteString(fmt.Sprintf("Athlete notes: %s\n\n", report.Entry.Notes))
+		}
+	}
+
+	if len(report.Recent) > 0 {
+		b.WriteString("## Last 7 days\n\n| Date | Resting HR | HRV | Sleep | Fatigue/Soreness/Mood |\n|---|---|---|---|---|\n")
+		for _, entry := range report.Recent {
+			b.WriteString(fmt.Sprintf("| %s | %s | %s | %s | %s |\n", entry.Date.Format(wellnessDateLayout),
+				formatOptionalInt(entry.RestingHR, " bpm"), formatOptionalFloat(entry.HRVRMSSD, " ms"),
+				formatOptionalFloat(entry.SleepHours, " h"), formatSubjective(entry)))
+		}
+		b.WriteString("\n")
+	}
+
+	b.WriteString("## Recent training load\n\n")
+	if load == nil {
+		b.WriteString("Strava activities could not be loaded.\n")
+	} else {
+		b.WriteString(fmt.Sprintf("- Last 7 days: %d sessions, %.1f h (4-week weekly average %.1f h)\n", load.Last7DaysSessions, load.Last7DaysHours, load.WeeklyAverageHours))
+		if load.LastSessionID != 0 {
+			b.WriteString(fmt.Sprintf("- Last session: [%s](https://www.strava.com/activities/%d) on %s, %d min\n", load.LastSessionName, load.LastSessionID, load.LastSessionDate, load.LastSessionMinutes))
+		}
+	}
+	return b.String()
+}
+
+func readinessAdvice(status string) string {
+	switch status {
+	case ReadinessHigh:
+		return "recovered; quality sessions are appropriate"
+	case ReadinessModerate:
+		return "train as planned but keep intensity controlled"
+	default:
+		return "prioritize recovery; favour rest or easy aerobic work"
+	}
+}
+
+func formatSubjective(entry *models.DailyWellness) string {
+	if entry.Fatigue == nil && entry.Soreness == nil && entry.Mood == nil {
+		return "–"
+	}
+	return fmt.Sprintf("%s/%s/%s", formatOptionalInt(entry.Fatigue, ""), formatOptionalInt(entry.Soreness, ""), formatOptionalInt(entry.Mood, ""))
+}
+
+func formatOptionalInt(value *int, unit string) string {
+	if value == nil {
+		return "–"
+	}
+	return strconv.Itoa(*value) + unit
+}
+
+func formatOptionalFloat(value *float64, unit string) string {
+	if value == nil {
+		return "–"
+	}
+	return strconv.FormatFloat(*value, 'f', -1, 64) + unit
+}
+
+func (s *aiService) executeGetReadiness(ctx context.Context, msgCtx *MessageContext, req ReadinessRequest) (string, error) {
+	if msgCtx == nil || msgCtx.User == nil {
+		return "", fmt.Errorf("user context is required")
+	}
+	if s.wellnessService == nil {
+		return "", ErrWellnessNotConfigured
+	}
+	// Wellness check-ins are more personal than training data, so coaches need their own permission
+	if msgCtx.Coach != nil && (msgCtx.CoachLink == nil || !msgCtx.CoachLink.Allows(models.CoachPermissionViewWellness)) {
+		return "", fmt.Errorf("%w: the athlete has not shared their wellness data", ErrCoachingAccessDenied)
+	}
+
+	var date time.Time
+	if req.Date != "" {
+		parsed, err := ParseWellnessDate(req.Date)
+		if err != nil {
+			return "", err
+		}
+		date = parsed
+	} else {
+		date = wellnessDate(time.Now())
+	}
+
+	report, err := s.wellnessService.GetReadiness(ctx, msgCtx.User.ID, date)
+	if err != nil {
+		return "", err
+	}
+
+	// Training load is context for the score, so Strava failures only omit that section
+	var load *RecentLoad
+	var activities []*StravaActivity
+	after := date.AddDate(0, 0, -readinessBaselineDays-7)
+	before := date.AddDate(0, 0, 2)
+	_, _, _, err = scanActivities(ctx, s.stravaService, msgCtx.User, &after, &before, func(page []*StravaActivity) bool {
+		activities = append(activities, page...)
+		return true
+	})
+	if err == nil {
+		load = BuildRecentLoad(activities, date)
+	}
+
+	return formatReadiness(report, load), nil
+}
@@ -0,0 +1,279 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"bodda/internal/config"
+	"bodda/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryWellnessStore keeps wellness entries in memory, keyed by date
+type memoryWellnessStore struct {
+	entries map[string]*models.DailyWellness
+	batches int
+}
+
+func newMemoryWellnessStore() *memoryWellnessStore {
+	return &memoryWellnessStore{entries: make(map[string]*models.DailyWellness)}
+}
+
+func (m *memoryWellnessStore) Upsert(ctx context.Context, entry *models.DailyWellness) error {
+	m.entries[entry.Date.Format(wellnessDateLayout)] = entry
+	return nil
+}
+
+func (m *memoryWellnessStore) UpsertBatch(ctx context.Context, entries []*models.DailyWellness) error {
+	m.batches++
+	for _, entry := range entries {
+		m.entries[entry.Date.Format(wellnessDateLayout)] = entry
+	}
+	return nil
+}
+
+func (m *memoryWellnessStore) Get(ctx context.Context, userID string, date time.Time) (*models.DailyWellness, error) {
+	return m.entries[date.Format(wellnessDateLayout)], nil
+}
+
+func (m *memoryWellnessStore) GetRange(ctx context.Context, userID string, from, to time.Time) ([]*models.DailyWellness, error) {
+	var entries []*models.DailyWellness
+	for day := from; day.Before(to); day = day.AddDate(0, 0, 1) {
+		if entry, ok := m.entries[day.Format(wellnessDateLayout)]; ok {
+			entries = append(entries, entry)
+		}
+	}
+	return entries, nil
+}
+
+func (m *memoryWellnessStore) Delete(ctx context.Context, userID string, date time.Time) (bool, error) {
+	key := date.Format(wellnessDateLayout)
+	_, ok := m.entries[key]
+	delete(m.entries, key)
+	return ok, nil
+}
+
+func wellnessIntPtr(v int) *int { return &v }
+
+func wellnessFloatPtr(v float64) *float64 { return &v }
+
+// wellnessBaseline builds days of entries ending the day before end, alternating HRV 55/65 ms and resting HR 48/52 bpm
+func wellnessBaseline(end time.Time, days int) []*models.DailyWellness {
+	var history []*models.DailyWellness
+	for i := days; i >= 1; i-- {
+		hrv, rhr := 55.0, 48
+		if i%2 == 0 {
+			hrv, rhr = 65.0, 52
+		}
+		history = append(history, &models.DailyWellness{
+			UserID:    "test-user",
+			Date:      end.AddDate(0, 0, -i),
+			HRVRMSSD:  wellnessFloatPtr(hrv),
+			RestingHR: wellnessIntPtr(rhr),
+		})
+	}
+	return history
+}
+
+func TestWellnessService_RecordEntryValidation(t *testing.T) {
+	store := newMemoryWellnessStore()
+	service := NewWellnessService(store).(*wellnessService)
+	service.now = func() time.Time { return time.Date(2025, 3, 10, 8, 0, 0, 0, time.UTC) }
+
+	tests := []struct {
+		name  string
+		entry *models.DailyWellness
+		want  string
+	}{
+		{"no metrics", &models.DailyWellness{UserID: "u", Date: time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC)}, "at least one metric"},
+		{"future date", &models.DailyWellness{UserID: "u", Date: time.Date(2025, 3, 13, 0, 0, 0, 0, time.UTC), Mood: wellnessIntPtr(3)}, "in the future"},
+		{"resting hr range", &models.DailyWellness{UserID: "u", Date: time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC), RestingHR: wellnessIntPtr(12)}, "resting_hr"},
+		{"rating range", &models.DailyWellness{UserID: "u", Date: time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC), Soreness: wellnessIntPtr(6)}, "soreness"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := service.RecordEntry(context.Background(), tt.entry)
+			require.Error(t, err)
+			assert.True(t, errors.Is(err, ErrInvalidWellnessEntry))
+			assert.Contains(t, err.Error(), tt.want)
+		})
+	}
+	assert.Empty(t, store.entries)
+
+	valid := &models.DailyWellness{UserID: "u", Date: time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC), HRVRMSSD: wellnessFloatPtr(70)}
+	require.NoError(t, service.RecordEntry(context.Background(), valid))
+	assert.Len(t, store.entries, 1)
+}
+
+func TestWellnessService_ImportCSV(t *testing.T) {
+	store := newMemoryWellnessStore()
+	service := NewWellnessService(store).(*wellnessService)
+	service.now = func() time.Time { return time.Date(2025, 3, 10, 8, 0, 0, 0, time.UTC) }
+
+	csv := "\ufeffDate,Resting HR,HRV,Sleep,Fatigue,Weather\n" +
+		"2025-03-07,49,68.5,7:30,2,sunny\n" +
+		"2025/03/08,51,,6.5,,rain\n" +
+		"\n" +
+		"2025-03-09,500,60,8,3,\n" +
+		"not-a-date,50,60,8,3,\n"
+
+	result, err := service.ImportCSV(context.Background(), "test-user", strings.NewReader(csv))
+	require.NoError(t, err)
+	assert.Equal(t, 2, result.Imported)
+	assert.Equal(t, 2, result.Skipped)
+	assert.Equal(t, []string{"Weather"}, result.IgnoredColumns)
+	require.Len(t, result.Errors, 2)
+	assert.Equal(t, 5, result.Errors[0].Line)
+	assert.Contains(t, result.Errors[0].Error, "resting_hr")
+	assert.Equal(t, 6, result.Errors[1].Line)
+	assert.Equal(t, 1, store.batches, "valid rows are stored in one batch")
+
+	first := store.entries["2025-03-07"]
+	require.NotNil(t, first)
+	assert.Equal(t, "test-user", first.UserID)
+	assert.Equal(t, 7.5, *first.SleepHours)
+	assert.Equal(t, 68.5, *first.HRVRMSSD)
+	assert.Nil(t, store.entries["2025-03-08"].HRVRMSSD, "empty cells are left unset")
+
+	_, err = service.ImportCSV(context.Background(), "test-user", strings.NewReader("hrv,sleep\n60,8\n"))
+	assert.True(t, errors.Is(err, ErrInvalidWellnessEntry))
+
+	_, err = service.ImportCSV(context.Background(), "test-user", strings.NewReader(""))
+	assert.True(t, errors.Is(err, ErrInvalidWellnessEntry))
+}
+
+func TestParseSleepHours(t *testing.T) {
+	hours, err := ParseSleepHours("7:45")
+	require.NoError(t, err)
+	assert.Equal(t, 7.75, *hours)
+
+	hours, err = ParseSleepHours("6.5")
+	require.NoError(t, err)
+	assert.Equal(t, 6.5, *hours)
+
+	_, err = ParseSleepHours("7:75")
+	assert.Error(t, err)
+}
+
+func TestBuildReadinessReport(t *testing.T) {
+	date := time.Date(2025, 3, 20, 0, 0, 0, 0, time.UTC)
+
+	t.Run("suppressed recovery", func(t *testing.T) {
+		history := append(wellnessBaseline(date, 14), &models.DailyWellness{
+			Date:       date,
+			HRVRMSSD:   wellnessFloatPtr(40),
+			RestingHR:  wellnessIntPtr(56),
+			SleepHours: wellnessFloatPtr(5.5),
+			Fatigue:    wellnessIntPtr(4),
+		})
+
+		report := BuildReadinessReport(history, date)
+		require.NotNil(t, report.Score)
+		assert.Equal(t, 19, *report.Score)
+		assert.Equal(t, ReadinessLow, report.Status)
+		assert.Equal(t, 14, report.BaselineDays)
+		require.Len(t, report.Components, 4)
+		assert.InDelta(t, 59.8, *report.Components[0].Baseline, 0.1)
+		assert.Len(t, report.Flags, 4)
+		assert.Contains(t, report.Flags[0], "HRV suppressed")
+		assert.Contains(t, report.Flags[1], "Resting HR elevated")
+	})
+
+	t.Run("renormalizes available components", func(t *testing.T) {
+		history := append(wellnessBaseline(date, 3), &models.DailyWellness{
+			Date:       date,
+			HRVRMSSD:   wellnessFloatPtr(40),
+			SleepHours: wellnessFloatPtr(8.5),
+			Mood:       wellnessIntPtr(5),
+		})
+
+		report := BuildReadinessReport(history, date)
+		require.NotNil(t, report.Score)
+		assert.Equal(t, 100, *report.Score, "HRV is ignored until the baseline has enough days")
+		assert.Equal(t, ReadinessHigh, report.Status)
+		assert.Len(t, report.Components, 2)
+		assert.Empty(t, report.Flags)
+	})
+
+	t.Run("uses an entry from the day before", func(t *testing.T) {
+		history := []*models.DailyWellness{{Date: date.AddDate(0, 0, -1), SleepHours: wellnessFloatPtr(5)}}
+		report := BuildReadinessReport(history, date)
+		require.NotNil(t, report.Entry)
+		assert.Equal(t, ReadinessModerate, report.Status)
+	})
+
+	t.Run("stale entry", func(t *testing.T) {
+		history := []*models.DailyWellness{{Date: date.AddDate(0, 0, -3), SleepHours: wellnessFloatPtr(8)}}
+		report := BuildReadinessReport(history, date)
+		assert.Nil(t, report.Entry)
+		assert.Nil(t, report.Score)
+		assert.Empty(t, report.Status)
+	})
+}
+
+func TestBuildRecentLoad(t *testing.T) {
+	date := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	load := BuildRecentLoad(activityHistory(35), date)
+
+	assert.Equal(t, 7, load.Last7DaysSessions)
+	assert.Equal(t, 5.8, load.Last7DaysHours)
+	assert.Equal(t, 5.7, load.WeeklyAverageHours)
+	assert.Equal(t, int64(1), load.LastSessionID)
+	assert.Equal(t, "2025-06-01", load.LastSessionDate)
+	assert.Equal(t, 45, load.LastSessionMinutes)
+}
+
+func TestGetReadinessTool(t *testing.T) {
+	date := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	store := newMemoryWellnessStore()
+	for _, entry := range wellnessBaseline(date, 10) {
+		store.entries[entry.Date.Format(wellnessDateLayout)] = entry
+	}
+	store.entries["2025-06-01"] = &models.DailyWellness{Date: date, HRVRMSSD: wellnessFloatPtr(60), RestingHR: wellnessIntPtr(50), SleepHours: wellnessFloatPtr(8)}
+
+	strava := &pagedStravaService{activities: activityHistory(35)}
+	msgCtx := &MessageContext{
+		UserID: "test-user",
+		User:   &models.User{ID: "test-user", AccessToken: "test-token"},
+	}
+
+	aiService := NewAIService(&config.Config{OpenAIAPIKey: "test-key"}, strava, &mockLogbookServiceForToolExecutor{}, &MockSessionRepositoryForToolExecutor{}, NewToolRegistry(), WithWellnessService(NewWellnessService(store)))
+	executor := NewToolExecutor(aiService, NewToolRegistry())
+
+	result, err := executor.ExecuteTool(context.Background(), "get-readiness", map[string]interface{}{"date": "2025-06-01"}, msgCtx)
+	require.NoError(t, err)
+	require.True(t, result.Success, result.Error)
+	assert.Contains(t, result.Data, "# Readiness for 2025-06-01")
+	assert.Contains(t, result.Data, "(high)")
+	assert.Contains(t, result.Data, "Easy Run 0")
+
+	// Coaches read the athlete's readiness only when wellness data was shared with them
+	coach := &models.User{ID: "coach-user"}
+	coachCtx := &MessageContext{
+		UserID:    coach.ID,
+		User:      msgCtx.User,
+		Coach:     coach,
+		CoachLink: &models.CoachingLink{Permissions: []string{models.CoachPermissionViewTraining, models.CoachPermissionViewLogbook}},
+	}
+	result, err = executor.ExecuteTool(context.Background(), "get-readiness", map[string]interface{}{"date": "2025-06-01"}, coachCtx)
+	require.NoError(t, err)
+	assert.False(t, result.Success)
+	assert.Contains(t, result.Error, "has not shared their wellness data")
+
+	coachCtx.CoachLink.Permissions = append(coachCtx.CoachLink.Permissions, models.CoachPermissionViewWellness)
+	result, err = executor.ExecuteTool(context.Background(), "get-readiness", map[string]interface{}{"date": "2025-06-01"}, coachCtx)
+	require.NoError(t, err)
+	require.True(t, result.Success, result.Error)
+	assert.Contains(t, result.Data, "# Readiness for 2025-06-01")
+
+	unconfigured := NewAIService(&config.Config{OpenAIAPIKey: "test-key"}, strava, &mockLogbookServiceForToolExecutor{}, &MockSessionRepositoryForToolExecutor{}, NewToolRegistry())
+	result, err = NewToolExecutor(unconfigured, NewToolRegistry()).ExecuteTool(context.Background(), "get-readiness", map[string]interface{}{}, msgCtx)
+	require.NoError(t, err)
+	assert.False(t, result.Success)
+}
@@ -0,0 +1,120 @@
+package tokenizer
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// Supported encodings
+const (
+	O200kBase  = "o200k_base"
+	Cl100kBase = "cl100k_base"
+)
+
+// ErrVocabularyNotFound is returned when neither the embedded files nor the vocabulary
+// directory contain the requested encoding
+var ErrVocabularyNotFound = errors.New("tokenizer vocabulary not found")
+
+// ErrUnknownEncoding is returned for encoding names without a known pre-tokenization pattern
+var ErrUnknownEncoding = errors.New("unknown tokenizer encoding")
+
+//go:embed vocab
+var vocabFS embed.FS
+
+// whitespace matches Unicode White_Space, which is wider than RE2's ASCII-only \s
+const whitespace = `\s\x{0B}\x{85}\p{Z}`
+
+// cl100kPattern is the cl100k_base pre-tokenization pattern without the `\s+(?!\S)` lookahead
+const cl100kPattern = `(?i:'s|'t|'re|'ve|'m|'ll|'d)` +
+	`|[^\r\n\p{L}\p{N}]?\p{L}+` +
+	`|\p{N}{1,3}` +
+	`| ?[^` + whitespace + `\p{L}\p{N}]+[\r\n]*` +
+	`|[` + whitespace + `]*[\r\n]+` +
+	`|[` + whitespace + `]+`
+
+// o200kPattern is the o200k_base pre-tokenization pattern without the `\s+(?!\S)` lookahead
+const o200kPattern = `[^\r\n\p{L}\p{N}]?[\p{Lu}\p{Lt}\p{Lm}\p{Lo}\p{M}]*[\p{Ll}\p{Lm}\p{Lo}\p{M}]+(?i:'s|'t|'re|'ve|'m|'ll|'d)?` +
+	`|[^\r\n\p{L}\p{N}]?[\p{Lu}\p{Lt}\p{Lm}\p{Lo}\p{M}]+[\p{Ll}\p{Lm}\p{Lo}\p{M}]*(?i:'s|'t|'re|'ve|'m|'ll|'d)?` +
+	`|\p{N}{1,3}` +
+	`| ?[^` + whitespace + `\p{L}\p{N}]+[\r\n/]*` +
+	`|[` + whitespace + `]*[\r\n]+` +
+	`|[` + whitespace + `]+`
+
+var (
+	encodingsMu sync.Mutex
+	encodings   = make(map[string]*Encoding)
+)
+
+// Pattern returns the pre-tokenization pattern for a known encoding
+func Pattern(name string) (string, error) {
+	switch name {
+	case O200kBase:
+		return o200kPattern, nil
+	case Cl100kBase:
+		return cl100kPattern, nil
+	default:
+		return "", fmt.Errorf("%w: %s", ErrUnknownEncoding, name)
+	}
+}
+
+// Load returns the named encoding, reading its ranks from the embedded vocabulary or,
+// when not embedded, from vocabDir. Loaded encodings are cached for the process lifetime.
+func Load(name string, vocabDir string) (*Encoding, error) {
+	encodingsMu.Lock()
+	defer encodingsMu.Unlock()
+
+	if enc, ok := encodings[name]; ok {
+		return enc, nil
+	}
+
+	pattern, err := Pattern(name)
+	if err != nil {
+		return nil, err
+	}
+
+	reader, err := openVocabulary(name, vocabDir)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	ranks, err := ParseRanks(reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s vocabulary: %w", name, err)
+	}
+
+	enc, err := NewEncoding(name, ranks, pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	encodings[name] = enc
+	return enc, nil
+}
+
+// openVocabulary locates the rank file for an encoding
+func openVocabulary(name string, vocabDir string) (io.ReadCloser, error) {
+	fileName := name + ".tiktoken"
+
+	if data, err := vocabFS.ReadFile("vocab/" + fileName); err == nil {
+		return io.NopCloser(bytes.NewReader(data)), nil
+	}
+
+	if vocabDir != "" {
+		file, err := os.Open(filepath.Join(vocabDir, fileName))
+		if err == nil {
+			return file, nil
+		}
+		if !errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("failed to open %s vocabulary: %w", name, err)
+		}
+	}
+
+	return nil, fmt.Errorf("%w: %s", ErrVocabularyNotFound, name)
+}
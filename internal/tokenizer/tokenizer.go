@@ -0,0 +1,229 @@
+// Package tokenizer implements byte-level BPE tokenization compatible with the
+// tiktoken o200k_base and cl100k_base encodings used by OpenAI models.
+package tokenizer
+
+import (
+	"bufio"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const maxRank = math.MaxInt
+
+// Encoding is a byte-level BPE encoding with a pre-tokenization pattern
+type Encoding struct {
+	name    string
+	ranks   map[string]int
+	pattern *regexp.Regexp
+}
+
+// NewEncoding creates an encoding from mergeable ranks and a pre-tokenization pattern.
+// The pattern must be RE2 compatible; the tiktoken `\s+(?!\S)` lookahead is emulated
+// by the splitter, so patterns should end with a plain whitespace alternative.
+func NewEncoding(name string, ranks map[string]int, pattern string) (*Encoding, error) {
+	if len(ranks) == 0 {
+		return nil, fmt.Errorf("encoding %s has no mergeable ranks", name)
+	}
+
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pre-tokenization pattern for %s: %w", name, err)
+	}
+
+	return &Encoding{
+		name:    name,
+		ranks:   ranks,
+		pattern: re,
+	}, nil
+}
+
+// Name returns the encoding name
+func (e *Encoding) Name() string {
+	return e.name
+}
+
+// VocabularySize returns the number of mergeable ranks in the encoding
+func (e *Encoding) VocabularySize() int {
+	return len(e.ranks)
+}
+
+// Encode converts text into token ranks. Special tokens are treated as ordinary text.
+func (e *Encoding) Encode(text string) []int {
+	var tokens []int
+	for _, piece := range e.split(text) {
+		if rank, ok := e.ranks[piece]; ok {
+			tokens = append(tokens, rank)
+			continue
+		}
+
+		bytes := []byte(piece)
+		boundaries := bytePairMerge(bytes, e.ranks)
+		for i := 0; i < len(boundaries)-1; i++ {
+			tokens = append(tokens, e.ranks[string(bytes[boundaries[i]:boundaries[i+1]])])
+		}
+	}
+	return tokens
+}
+
+// Count returns the number of tokens in text without materializing the token slice
+func (e *Encoding) Count(text string) int {
+	count := 0
+	for _, piece := range e.split(text) {
+		if _, ok := e.ranks[piece]; ok {
+			count++
+			continue
+		}
+		count += len(bytePairMerge([]byte(piece), e.ranks)) - 1
+	}
+	return count
+}
+
+// split pre-tokenizes text into pieces that are merged independently
+func (e *Encoding) split(text string) []string {
+	var pieces []string
+	pos := 0
+	for pos < len(text) {
+		loc := e.pattern.FindStringIndex(text[pos:])
+		if loc == nil || loc[1] == 0 {
+			// Patterns cover every character; guard against a malformed pattern looping forever
+			_, size := utf8.DecodeRuneInString(text[pos:])
+			pieces = append(pieces, text[pos:pos+size])
+			pos += size
+			continue
+		}
+
+		start, end := pos+loc[0], pos+loc[1]
+		if start > pos {
+			pieces = append(pieces, text[pos:start])
+		}
+
+		end = trimTrailingWhitespace(text, start, end)
+		pieces = append(pieces, text[start:end])
+		pos = end
+	}
+	return pieces
+}
+
+// trimTrailingWhitespace emulates the `\s+(?!\S)` alternative: a run of whitespace followed
+// by non-whitespace leaves its last character to prefix the next piece
+func trimTrailingWhitespace(text string, start, end int) int {
+	if end >= len(text) {
+		return end
+	}
+
+	match := text[start:end]
+	if utf8.RuneCountInString(match) < 2 || strings.IndexFunc(match, func(r rune) bool { return !unicode.IsSpace(r) }) >= 0 {
+		return end
+	}
+
+	// Runs ending in a newline come from the newline alternative and are kept whole
+	if strings.HasSuffix(match, "\n") || strings.HasSuffix(match, "\r") {
+		return end
+	}
+
+	_, size := utf8.DecodeLastRuneInString(match)
+	return end - size
+}
+
+// bytePairMerge repeatedly merges the lowest ranked adjacent pair and returns the
+// byte offsets of the resulting token boundaries (including 0 and len(piece))
+func bytePairMerge(piece []byte, ranks map[string]int) []int {
+	if len(piece) == 0 {
+		return []int{0}
+	}
+	if len(piece) == 1 {
+		return []int{0, 1}
+	}
+
+	type part struct {
+		start int
+		rank  int
+	}
+
+	parts := make([]part, 0, len(piece)+1)
+	for i := 0; i < len(piece)-1; i++ {
+		rank, ok := ranks[string(piece[i:i+2])]
+		if !ok {
+			rank = maxRank
+		}
+		parts = append(parts, part{start: i, rank: rank})
+	}
+	parts = append(parts, part{start: len(piece) - 1, rank: maxRank}, part{start: len(piece), rank: maxRank})
+
+	getRank := func(i int) int {
+		if i+3 < len(parts) {
+			if rank, ok := ranks[string(piece[parts[i].start:parts[i+3].start])]; ok {
+				return rank
+			}
+		}
+		return maxRank
+	}
+
+	for {
+		minIdx, minRank := -1, maxRank
+		for i := 0; i < len(parts)-1; i++ {
+			if parts[i].rank < minRank {
+				minIdx, minRank = i, parts[i].rank
+			}
+		}
+		if minIdx < 0 {
+			break
+		}
+
+		if minIdx > 0 {
+			parts[minIdx-1].rank = getRank(minIdx - 1)
+		}
+		parts[minIdx].rank = getRank(minIdx)
+		parts = append(parts[:minIdx+1], parts[minIdx+2:]...)
+	}
+
+	boundaries := make([]int, len(parts))
+	for i, p := range parts {
+		boundaries[i] = p.start
+	}
+	return boundaries
+}
+
+// ParseRanks reads mergeable ranks in the tiktoken file format (`<base64 token> <rank>` per line)
+func ParseRanks(r io.Reader) (map[string]int, error) {
+	ranks := make(map[string]int, 200000)
+	scanner := bufio.NewScanner(r)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("invalid rank entry on line %d", lineNumber)
+		}
+
+		token, err := base64.StdEncoding.DecodeString(fields[0])
+		if err != nil {
+			return nil, fmt.Errorf("invalid token encoding on line %d: %w", lineNumber, err)
+		}
+
+		rank, err := strconv.Atoi(fields[1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid rank on line %d: %w", lineNumber, err)
+		}
+
+		ranks[string(token)] = rank
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read ranks: %w", err)
+	}
+
+	return ranks, nil
+}
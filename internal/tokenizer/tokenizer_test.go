@@ -0,0 +1,176 @@
+package tokenizer
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestRanks builds a tiny byte-level vocabulary: every single byte plus a few merges
+func newTestRanks(merges ...string) map[string]int {
+	ranks := make(map[string]int, 256+len(merges))
+	for i := 0; i < 256; i++ {
+		ranks[string([]byte{byte(i)})] = i
+	}
+	for i, merge := range merges {
+		ranks[merge] = 256 + i
+	}
+	return ranks
+}
+
+func newTestEncoding(t *testing.T, merges ...string) *Encoding {
+	t.Helper()
+	enc, err := NewEncoding("test", newTestRanks(merges...), cl100kPattern)
+	require.NoError(t, err)
+	return enc
+}
+
+func TestBytePairMerge(t *testing.T) {
+	enc := newTestEncoding(t, "he", "ll", "hell")
+
+	tokens := enc.Encode("hello")
+	assert.Equal(t, []int{258, int('o')}, tokens)
+	assert.Equal(t, 2, enc.Count("hello"))
+}
+
+func TestBytePairMerge_PrefersLowestRank(t *testing.T) {
+	// "ab" and "bc" compete; the lower ranked "bc" wins
+	enc := newTestEncoding(t, "bc", "ab")
+
+	assert.Equal(t, []int{int('a'), 256}, enc.Encode("abc"))
+}
+
+func TestEncode_WholePieceLookup(t *testing.T) {
+	enc := newTestEncoding(t, " w", "or", " wor", "ld", " world")
+
+	assert.Equal(t, []int{260}, enc.Encode(" world"))
+}
+
+func TestEncode_UnmergedBytes(t *testing.T) {
+	enc := newTestEncoding(t)
+
+	text := "héllo"
+	assert.Equal(t, len([]byte(text)), enc.Count(text))
+	assert.Empty(t, enc.Encode(""))
+	assert.Equal(t, 0, enc.Count(""))
+}
+
+func TestSplit_Cl100k(t *testing.T) {
+	enc := newTestEncoding(t)
+
+	pieces := enc.split("Hello world  foo\n\nbar 12345 don't!!")
+	assert.Equal(t, []string{"Hello", " world", " ", " foo", "\n\n", "bar", " ", "123", "45", " don", "'t", "!!"}, pieces)
+}
+
+func TestSplit_TrailingWhitespaceKeptWhole(t *testing.T) {
+	enc := newTestEncoding(t)
+
+	assert.Equal(t, []string{"end", "   "}, enc.split("end   "))
+	assert.Equal(t, []string{"a", "  \n", "b"}, enc.split("a  \nb"))
+}
+
+func TestSplit_O200kCaseBoundaries(t *testing.T) {
+	enc, err := NewEncoding("test", newTestRanks(), o200kPattern)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"Hello", "World", " path", "/\n"}, enc.split("HelloWorld path/\n"))
+}
+
+func TestSplit_CoversInput(t *testing.T) {
+	enc := newTestEncoding(t)
+
+	text := "**Activity:** Morning Run 🏃 — 10.5 km @ 4:52/km\n\tHR: 152 bpm avg\r\n"
+	assert.Equal(t, text, strings.Join(enc.split(text), ""))
+}
+
+func TestParseRanks(t *testing.T) {
+	input := fmt.Sprintf("%s 0\n%s 1\n\n%s 2\n",
+		base64.StdEncoding.EncodeToString([]byte("a")),
+		base64.StdEncoding.EncodeToString([]byte("b")),
+		base64.StdEncoding.EncodeToString([]byte("ab")))
+
+	ranks, err := ParseRanks(strings.NewReader(input))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int{"a": 0, "b": 1, "ab": 2}, ranks)
+
+	_, err = ParseRanks(strings.NewReader("not-base64! 0\n"))
+	assert.Error(t, err)
+
+	_, err = ParseRanks(strings.NewReader("YQ==\n"))
+	assert.Error(t, err)
+}
+
+func TestLoad_FromVocabDir(t *testing.T) {
+	dir := t.TempDir()
+	var builder strings.Builder
+	for token, rank := range newTestRanks("he", "ll") {
+		builder.WriteString(fmt.Sprintf("%s %d\n", base64.StdEncoding.EncodeToString([]byte(token)), rank))
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(dir, Cl100kBase+".tiktoken"), []byte(builder.String()), 0o600))
+
+	// Skip when a real vocabulary is embedded, since it takes precedence
+	if _, err := vocabFS.ReadFile("vocab/" + Cl100kBase + ".tiktoken"); err == nil {
+		t.Skip("cl100k_base vocabulary is embedded")
+	}
+
+	encodingsMu.Lock()
+	delete(encodings, Cl100kBase)
+	encodingsMu.Unlock()
+	defer func() {
+		encodingsMu.Lock()
+		delete(encodings, Cl100kBase)
+		encodingsMu.Unlock()
+	}()
+
+	enc, err := Load(Cl100kBase, dir)
+	require.NoError(t, err)
+	assert.Equal(t, Cl100kBase, enc.Name())
+	assert.Equal(t, 258, enc.VocabularySize())
+	assert.Equal(t, 3, enc.Count("hello"))
+}
+
+func TestLoad_Errors(t *testing.T) {
+	_, err := Load("p50k_base", "")
+	assert.True(t, errors.Is(err, ErrUnknownEncoding))
+
+	if _, embedErr := vocabFS.ReadFile("vocab/" + O200kBase + ".tiktoken"); embedErr == nil {
+		t.Skip("o200k_base vocabulary is embedded")
+	}
+	_, err = Load(O200kBase, t.TempDir())
+	assert.True(t, errors.Is(err, ErrVocabularyNotFound))
+}
+
+// loadRealEncoding loads a production vocabulary. It skips the test when the vocabulary is
+// unavailable, unless TOKENIZER_REQUIRE_VOCAB is set as it is in CI.
+func loadRealEncoding(t *testing.T, name string) *Encoding {
+	t.Helper()
+	enc, err := Load(name, os.Getenv("TOKENIZER_VOCAB_DIR"))
+	if errors.Is(err, ErrVocabularyNotFound) {
+		if os.Getenv("TOKENIZER_REQUIRE_VOCAB") != "" {
+			t.Fatalf("%s vocabulary not available; run make tokenizer-vocab", name)
+		}
+		t.Skipf("%s vocabulary not available", name)
+	}
+	require.NoError(t, err)
+	return enc
+}
+
+func TestRealEncodings(t *testing.T) {
+	t.Run("cl100k_base", func(t *testing.T) {
+		enc := loadRealEncoding(t, Cl100kBase)
+		assert.Equal(t, []int{15339, 1917}, enc.Encode("hello world"))
+	})
+
+	t.Run("o200k_base", func(t *testing.T) {
+		enc := loadRealEncoding(t, O200kBase)
+		assert.Equal(t, 2, enc.Count("hello world"))
+		assert.Greater(t, enc.VocabularySize(), 190000)
+	})
+}
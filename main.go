@@ -1,16 +1,23 @@
 package main
 
 import (
+	"context"
+	"flag"
 	"log"
+	"os"
 
 	"bodda/internal/config"
 	"bodda/internal/database"
 	"bodda/internal/server"
+	"bodda/internal/tokenizer"
 
 	"github.com/joho/godotenv"
 )
 
 func main() {
+	reencryptTokens := flag.Bool("reencrypt-tokens", false, "encrypt stored Strava tokens with the active token encryption key and exit")
+	flag.Parse()
+
 	// Load environment variables
 	if err := godotenv.Load(); err != nil {
 		log.Println("No .env file found")
@@ -19,6 +26,15 @@ func main() {
 	// Load configuration
 	cfg := config.Load()
 
+	// Initialize token encryption
+	tokenCipher, err := database.NewTokenCipher(cfg.TokenEncryption.Keys, cfg.TokenEncryption.ActiveKeyID)
+	if err != nil {
+		log.Fatal("Invalid token encryption configuration:", err)
+	}
+	if tokenCipher == nil && !cfg.IsDevelopment {
+		log.Println("WARNING: TOKEN_ENCRYPTION_KEYS is not set, Strava tokens are stored unencrypted")
+	}
+
 	// Initialize database
 	db, err := database.Connect(cfg.DatabaseURL)
 	if err != nil {
@@ -31,10 +47,38 @@ func main() {
 		log.Fatal("Failed to run migrations:", err)
 	}
 
+	if *reencryptTokens {
+		users := database.NewUserRepository(db, database.WithTokenCipher(tokenCipher))
+		result, err := users.ReencryptTokens(context.Background(), 100)
+		if result != nil {
+			log.Printf("Re-encrypted tokens with key %q: %d users scanned, %d updated, %d changed during the run, %d failed",
+				tokenCipher.ActiveKeyID(), result.Scanned, result.Updated, result.Skipped, len(result.FailedIDs))
+			for _, id := range result.FailedIDs {
+				log.Printf("Could not decrypt tokens for user %s", id)
+			}
+		}
+		if err != nil {
+			log.Fatal("Failed to re-encrypt tokens:", err)
+		}
+		if len(result.FailedIDs) > 0 {
+			db.Close()
+			os.Exit(1)
+		}
+		return
+	}
+
+	// Load the tokenizer vocabulary up front so a missing vocabulary stops startup instead of
+	// silently degrading token counts to the heuristic
+	if cfg.Tokenizer.Encoding != "heuristic" {
+		if _, err := tokenizer.Load(cfg.Tokenizer.Encoding, cfg.Tokenizer.VocabDir); err != nil {
+			log.Fatal("Invalid tokenizer configuration (run make tokenizer-vocab, set TOKENIZER_VOCAB_DIR or set TOKENIZER_ENCODING=heuristic):", err)
+		}
+	}
+
 	// Start server
-	srv := server.New(cfg, db)
+	srv := server.New(cfg, db, tokenCipher)
 	log.Printf("Server starting on port %s", cfg.Port)
 	if err := srv.Run(":" + cfg.Port); err != nil {
 		log.Fatal("Failed to start server:", err)
 	}
-}
\ No newline at end of file
+}